@@ -0,0 +1,73 @@
+// Package benchmarks measures tool latency. The Go benchmarks in this
+// package run the tools against the integration test workspaces, and
+// Latencies summarizes the timings that replay --benchmark collects from a
+// recorded session.
+package benchmarks
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Latencies are the durations of repeated calls to one tool
+type Latencies []time.Duration
+
+// Percentile returns the duration that p percent of the calls took at most,
+// using the nearest rank. It is 0 when there are no calls.
+func (l Latencies) Percentile(p float64) time.Duration {
+	if len(l) == 0 {
+		return 0
+	}
+	sorted := append(Latencies(nil), l...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+// Report holds the latencies of each tool in a run
+type Report map[string]Latencies
+
+// Add records one call to a tool
+func (r Report) Add(tool string, latency time.Duration) {
+	r[tool] = append(r[tool], latency)
+}
+
+// Slowest returns the tool with the highest p95 latency, and that latency
+func (r Report) Slowest() (string, time.Duration) {
+	var slowest string
+	var p95 time.Duration
+	for _, tool := range r.tools() {
+		if latency := r[tool].Percentile(95); latency > p95 {
+			slowest, p95 = tool, latency
+		}
+	}
+	return slowest, p95
+}
+
+// Write prints a table of the call count and p50, p95 and maximum latency
+// of each tool, by name
+func (r Report) Write(w io.Writer) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "tool\tcalls\tp50\tp95\tmax")
+	for _, tool := range r.tools() {
+		latencies := r[tool]
+		fmt.Fprintf(table, "%s\t%d\t%s\t%s\t%s\n", tool, len(latencies),
+			latencies.Percentile(50).Round(time.Microsecond),
+			latencies.Percentile(95).Round(time.Microsecond),
+			latencies.Percentile(100).Round(time.Microsecond))
+	}
+	return table.Flush()
+}
+
+func (r Report) tools() []string {
+	tools := make([]string, 0, len(r))
+	for tool := range r {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	return tools
+}
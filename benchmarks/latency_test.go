@@ -0,0 +1,48 @@
+package benchmarks
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentile(t *testing.T) {
+	var latencies Latencies
+	assert.Equal(t, time.Duration(0), latencies.Percentile(50))
+
+	// 1ms to 20ms, out of order
+	for i := 20; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 10*time.Millisecond, latencies.Percentile(50))
+	assert.Equal(t, 19*time.Millisecond, latencies.Percentile(95))
+	assert.Equal(t, 20*time.Millisecond, latencies.Percentile(100))
+	assert.Equal(t, 1*time.Millisecond, latencies.Percentile(0))
+	// The input is left as it was
+	assert.Equal(t, 20*time.Millisecond, latencies[0])
+
+	assert.Equal(t, 7*time.Millisecond, Latencies{7 * time.Millisecond}.Percentile(95))
+}
+
+func TestReport(t *testing.T) {
+	report := Report{}
+	for i := 1; i <= 4; i++ {
+		report.Add("hover", time.Duration(i)*time.Millisecond)
+	}
+	report.Add("references", 30*time.Millisecond)
+
+	tool, p95 := report.Slowest()
+	assert.Equal(t, "references", tool)
+	assert.Equal(t, 30*time.Millisecond, p95)
+
+	var out strings.Builder
+	require.NoError(t, report.Write(&out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"tool", "calls", "p50", "p95", "max"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"hover", "4", "2ms", "4ms", "4ms"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"references", "1", "30ms", "30ms", "30ms"}, strings.Fields(lines[2]))
+}
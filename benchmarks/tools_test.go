@@ -0,0 +1,157 @@
+package benchmarks
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/internal/workspacegen"
+	"github.com/isaacphi/mcp-language-server/testharness"
+)
+
+// fixture describes the tool calls benchmarked against one of the
+// integration test workspaces
+type fixture struct {
+	// language names the testharness fixture
+	language string
+	// definition and references are symbols looked up with read_definition
+	// and find_references
+	definition string
+	references string
+	// hoverFile, hoverLine and hoverColumn are where hover is requested
+	hoverFile   string
+	hoverLine   int
+	hoverColumn int
+	// diagnosticsFile is the file diagnostics are requested for
+	diagnosticsFile string
+}
+
+func BenchmarkGo(b *testing.B) {
+	benchmarkFixture(b, fixture{
+		language:        "go",
+		definition:      "TestStruct",
+		references:      "HelperFunction",
+		hoverFile:       "types.go",
+		hoverLine:       6,
+		hoverColumn:     6,
+		diagnosticsFile: "main.go",
+	})
+}
+
+func BenchmarkTypeScript(b *testing.B) {
+	benchmarkFixture(b, fixture{
+		language:        "typescript",
+		definition:      "TestClass",
+		references:      "SharedFunction",
+		hoverFile:       "main.ts",
+		hoverLine:       2,
+		hoverColumn:     17,
+		diagnosticsFile: "main.ts",
+	})
+}
+
+func BenchmarkRust(b *testing.B) {
+	benchmarkFixture(b, fixture{
+		language:        "rust",
+		definition:      "TestStruct",
+		references:      "helper_function",
+		hoverFile:       "src/types.rs",
+		hoverLine:       13,
+		hoverColumn:     12,
+		diagnosticsFile: "src/main.rs",
+	})
+}
+
+// BenchmarkFallback measures the tools that work without a language server
+// on a generated workspace, larger than the fixtures, where walking and
+// reading files dominates
+func BenchmarkFallback(b *testing.B) {
+	workspace, err := workspacegen.Generate(b.TempDir(), workspacegen.Spec{
+		Packages:         20,
+		FilesPerPackage:  50,
+		FunctionsPerFile: 20,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	dir := workspace.Dir
+
+	ctx := context.Background()
+	benchmarkTool(b, "search", func() error {
+		_, err := tools.SearchWorkspace(ctx, dir, `Func1\d_7_\d+\b`, true, nil)
+		return err
+	})
+	benchmarkTool(b, "definition", func() error {
+		_, err := tools.FallbackReadDefinition(ctx, dir, "Shared")
+		return err
+	})
+	benchmarkTool(b, "references", func() error {
+		_, err := tools.FallbackFindReferences(ctx, dir, workspacegen.FunctionName(3, 4, 5))
+		return err
+	})
+}
+
+// benchmarkFixture starts the fixture's language server on a copy of its
+// workspace and benchmarks each tool call. It is skipped when the language
+// server isn't installed.
+func benchmarkFixture(b *testing.B, f fixture) {
+	config, err := testharness.Fixture(f.language)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if _, err := exec.LookPath(config.Command); err != nil {
+		b.Skipf("%s is not installed", config.Command)
+	}
+	outputDir, err := filepath.Abs("../integrationtests/test-output")
+	if err != nil {
+		b.Fatal(err)
+	}
+	config.OutputDir = outputDir
+	suite := testharness.NewTestSuite(b, config)
+	if err := suite.Setup(); err != nil {
+		b.Fatalf("Failed to set up test suite: %v", err)
+	}
+	b.Cleanup(suite.Cleanup)
+
+	ctx := suite.Context
+	benchmarkTool(b, "definition", func() error {
+		_, err := suite.Definition(ctx, f.definition)
+		return err
+	})
+	benchmarkTool(b, "references", func() error {
+		_, err := suite.References(ctx, f.references)
+		return err
+	})
+	benchmarkTool(b, "hover", func() error {
+		_, err := suite.Hover(ctx, f.hoverFile, f.hoverLine, f.hoverColumn)
+		return err
+	})
+	benchmarkTool(b, "diagnostics", func() error {
+		_, err := suite.Diagnostics(ctx, f.diagnosticsFile)
+		return err
+	})
+}
+
+// benchmarkTool runs a tool call as a sub-benchmark, reporting its p50 and
+// p95 latency alongside the mean. The first call is made before timing
+// starts, so that opening files isn't counted.
+func benchmarkTool(b *testing.B, name string, call func() error) {
+	b.Run(name, func(b *testing.B) {
+		if err := call(); err != nil {
+			b.Fatalf("%s failed: %v", name, err)
+		}
+		var latencies Latencies
+		for b.Loop() {
+			started := time.Now()
+			if err := call(); err != nil {
+				b.Fatalf("%s failed: %v", name, err)
+			}
+			latencies = append(latencies, time.Since(started))
+		}
+		b.ReportMetric(float64(latencies.Percentile(50).Nanoseconds()), "p50-ns")
+		b.ReportMetric(float64(latencies.Percentile(95).Nanoseconds()), "p95-ns")
+	})
+}
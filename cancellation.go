@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// toolCalls cancels the tool calls the client gives up on with
+// notifications/cancelled, so that a cancelled workspace scan stops walking.
+// The MCP library gives every call of a session the same context and only
+// shows request IDs to its hooks, so a call is registered by the hook and
+// found by its handler through the _meta of the request, which both share.
+type toolCalls struct {
+	mu sync.Mutex
+	// starting are the calls registered by the hook whose handlers haven't
+	// started yet
+	starting map[*mcp.Meta]*toolCall
+	// running are the calls that can be cancelled, by request ID
+	running map[string]*toolCall
+}
+
+type toolCall struct {
+	id string
+	// ctx is cancelled when the client cancels the call, which may happen
+	// before its handler starts
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// toolCallKey marks the context of a tracked call, so that the calls it
+// makes through the server, such as those of tool aliases, are cancelled
+// with it
+type toolCallKey struct{}
+
+func newToolCalls() *toolCalls {
+	return &toolCalls{
+		starting: make(map[*mcp.Meta]*toolCall),
+		running:  make(map[string]*toolCall),
+	}
+}
+
+// register is a BeforeCallTool hook that makes a call cancellable. Editing
+// tools run to the end once called, so that a cancelled call doesn't leave
+// part of its edits applied.
+func (c *toolCalls) register(ctx context.Context, id any, request *mcp.CallToolRequest) {
+	if editingTools[request.Params.Name] || ctx.Value(toolCallKey{}) != nil {
+		return
+	}
+	if request.Params.Meta == nil {
+		request.Params.Meta = &mcp.Meta{}
+	}
+	callCtx, cancel := context.WithCancel(context.Background())
+	call := &toolCall{id: mcp.NewRequestId(id).String(), ctx: callCtx, cancel: cancel}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.starting[request.Params.Meta] = call
+	c.running[call.id] = call
+}
+
+// middleware runs a registered call under a context that is cancelled along
+// with the call
+func (c *toolCalls) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		c.mu.Lock()
+		call, ok := c.starting[request.Params.Meta]
+		delete(c.starting, request.Params.Meta)
+		c.mu.Unlock()
+		if !ok {
+			return next(ctx, request)
+		}
+		defer c.finish(call)
+
+		ctx, cancel := context.WithCancel(context.WithValue(ctx, toolCallKey{}, call.id))
+		defer cancel()
+		stop := context.AfterFunc(call.ctx, cancel)
+		defer stop()
+		return next(ctx, request)
+	}
+}
+
+// failed is an OnError hook that forgets the calls that failed before their
+// handlers started, such as calls to unknown tools
+func (c *toolCalls) failed(ctx context.Context, id any, method mcp.MCPMethod, message any, err error) {
+	if method != mcp.MethodToolsCall {
+		return
+	}
+	c.mu.Lock()
+	call, ok := c.running[mcp.NewRequestId(id).String()]
+	c.mu.Unlock()
+	if ok {
+		c.finish(call)
+	}
+}
+
+// finish forgets a call once it returned
+func (c *toolCalls) finish(call *toolCall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running[call.id] == call {
+		delete(c.running, call.id)
+	}
+	for meta, starting := range c.starting {
+		if starting == call {
+			delete(c.starting, meta)
+		}
+	}
+	call.cancel()
+}
+
+// cancelled handles notifications/cancelled by cancelling the call it names.
+// Calls that already returned, or that can't be cancelled, are left alone.
+func (c *toolCalls) cancelled(ctx context.Context, notification mcp.JSONRPCNotification) {
+	var params mcp.CancelledNotificationParams
+	data, err := json.Marshal(notification.Params.AdditionalFields)
+	if err == nil {
+		err = json.Unmarshal(data, &params)
+	}
+	if err != nil {
+		coreLogger.Debug("Invalid cancellation: %v", err)
+		return
+	}
+	c.mu.Lock()
+	call, ok := c.running[params.RequestId.String()]
+	c.mu.Unlock()
+	if ok {
+		coreLogger.Info("Tool call %s cancelled by the client: %s", params.RequestId.Value(), params.Reason)
+		call.cancel()
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelToolCall(t *testing.T) {
+	s := newToolServer(t, lsptest.NewServer(protocol.ServerCapabilities{}))
+	for i := range 200 {
+		dir := filepath.Join(s.config.workspaceDir, fmt.Sprintf("pkg%d", i))
+		require.NoError(t, os.Mkdir(dir, 0755))
+		for j := range 20 {
+			content := fmt.Sprintf("package pkg%d\n\n// TODO: finish %d\n", i, j)
+			require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.go", j)), []byte(content), 0644))
+		}
+	}
+
+	message, err := json.Marshal(mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(7),
+		Request: mcp.Request{Method: string(mcp.MethodToolsCall)},
+		Params:  mcp.CallToolParams{Name: "find_todos", Arguments: map[string]any{}},
+	})
+	require.NoError(t, err)
+	responses := make(chan mcp.JSONRPCMessage, 1)
+	go func() {
+		responses <- s.mcpServer.HandleMessage(context.Background(), message)
+	}()
+	require.Eventually(t, func() bool {
+		s.calls.mu.Lock()
+		defer s.calls.mu.Unlock()
+		return s.calls.running["int64:7"] != nil
+	}, 5*time.Second, time.Millisecond)
+
+	// The scan stops instead of listing every marker
+	cancel, err := json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"method":  "notifications/cancelled",
+		"params":  map[string]any{"requestId": 7, "reason": "test"},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, s.mcpServer.HandleMessage(context.Background(), cancel))
+	var response mcp.JSONRPCMessage
+	select {
+	case response = <-responses:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The cancelled scan didn't stop")
+	}
+	result, ok := response.(mcp.JSONRPCResponse).Result.(mcp.CallToolResult)
+	require.True(t, ok, "unexpected response %#v", response)
+	assert.True(t, result.IsError)
+	require.NotEmpty(t, resultText(&result))
+	assert.Contains(t, resultText(&result)[0], context.Canceled.Error())
+
+	// The call is forgotten once it returned
+	assert.Empty(t, s.calls.running)
+	assert.Empty(t, s.calls.starting)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/isaacphi/mcp-language-server/internal/chunks"
+)
+
+func newChunksFlags(cfg *config, output *string, maxLines *int) *flag.FlagSet {
+	flags := newFlagSet("chunks")
+	addLSPFlags(flags, cfg)
+	flags.StringVar(output, "output", "-", "Path of the JSON lines file to write, or - for stdout")
+	flags.IntVar(maxLines, "max-lines", 200, "Split chunks longer than this many lines into parts, 0 for no limit")
+	return flags
+}
+
+// runChunks implements the chunks subcommand, which writes the workspace as
+// symbol-aligned chunks for embedding pipelines
+func runChunks(args []string) error {
+	var output string
+	var maxLines int
+	cfg, err := parseFlags(args, func(cfg *config) *flag.FlagSet { return newChunksFlags(cfg, &output, &maxLines) })
+	if err != nil {
+		return err
+	}
+
+	if maxLines < 0 {
+		return fmt.Errorf("--max-lines must not be negative")
+	}
+	if err := cfg.resolveWorkspace(); err != nil {
+		return err
+	}
+	if cfg.lspCommand == "" && cfg.lspConnect == "" {
+		return fmt.Errorf("LSP command or --lsp-connect is required")
+	}
+	if err := cfg.expandLSPOptions(); err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if output != "-" {
+		var err error
+		out, err = os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %v", err)
+		}
+		defer func() {
+			if err := out.Close(); err != nil {
+				coreLogger.Error("Failed to close output file: %v", err)
+			}
+		}()
+	}
+
+	s, err := newServer(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup(s, make(chan struct{}))
+
+	if err := os.Chdir(cfg.workspaceDir); err != nil {
+		return fmt.Errorf("failed to change to workspace directory: %v", err)
+	}
+	client, err := cfg.startLSPClient(s.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create LSP client: %v", err)
+	}
+	s.lspClient = client
+
+	if _, err := client.InitializeLSPClient(s.ctx, cfg.workspaceDir); err != nil {
+		return fmt.Errorf("initialize failed: %v", err)
+	}
+	if err := client.WaitForServerReady(s.ctx); err != nil {
+		return err
+	}
+
+	return chunks.NewExporter(client, cfg.workspaceDir, out, maxLines).Run(s.ctx)
+}
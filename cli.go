@@ -0,0 +1,299 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+)
+
+// subcommand is a command of the command line interface
+type subcommand struct {
+	name string
+	// usage is the synopsis of the arguments, shown in help
+	usage   string
+	summary string
+	// flags returns the command's flags for help and shell completion
+	flags func() *flag.FlagSet
+	run   func(args []string) error
+}
+
+// subcommands is filled in by init since help and completion refer to it
+var subcommands []subcommand
+
+func init() {
+	subcommands = []subcommand{
+		{
+			name:    "serve",
+			usage:   "--workspace DIR [--lsp COMMAND] [flags] [-- LSP args]",
+			summary: "Run the MCP server over stdio. This is the default when no command is given.",
+			flags:   func() *flag.FlagSet { return newServeFlags(&config{}) },
+			run:     runServe,
+		},
+		{
+			name:    "daemon",
+			usage:   "[--socket PATH] [--idle-timeout DURATION]",
+			summary: "Keep language servers running between sessions started with serve --daemon.",
+			flags:   func() *flag.FlagSet { return newDaemonFlags(new(string), new(time.Duration)) },
+			run:     runDaemon,
+		},
+		{
+			name:    "doctor",
+			usage:   "--workspace DIR --lsp COMMAND [flags] [-- LSP args]",
+			summary: "Check the workspace, tools and language server setup and report problems.",
+			flags:   func() *flag.FlagSet { return newDoctorFlags(&config{}, new(int)) },
+			run:     runDoctor,
+		},
+		{
+			name:    "install",
+			usage:   "[--name NAME] [--config FILE] -- SERVE FLAGS [-- LSP args]",
+			summary: "Print, or add to an MCP client's configuration file, the entry that runs this server with the given serve flags.",
+			flags:   func() *flag.FlagSet { return newInstallFlags(new(string), new(string), new(bool)) },
+			run:     runInstall,
+		},
+		{
+			name:    "index",
+			usage:   "--workspace DIR --lsp COMMAND [--output FILE] [flags] [-- LSP args]",
+			summary: "Write an LSIF dump of the workspace.",
+			flags:   func() *flag.FlagSet { return newIndexFlags(&config{}, new(string)) },
+			run:     runIndex,
+		},
+		{
+			name:    "chunks",
+			usage:   "--workspace DIR --lsp COMMAND [--output FILE] [--max-lines N] [flags] [-- LSP args]",
+			summary: "Write the workspace as symbol-aligned chunks in JSON lines, for embedding pipelines.",
+			flags:   func() *flag.FlagSet { return newChunksFlags(&config{}, new(string), new(int)) },
+			run:     runChunks,
+		},
+		{
+			name:    "replay",
+			usage:   "--session FILE --workspace DIR [--lsp COMMAND] [--benchmark] [flags] [-- LSP args]",
+			summary: "Run the tool calls recorded with serve --record again and print the results or their latencies.",
+			flags:   func() *flag.FlagSet { return newReplayFlags(&config{}, &replayOptions{}) },
+			run:     runReplay,
+		},
+		{
+			name:    "config",
+			usage:   "validate [--workspace DIR] FILE | schema | show [--effective] [--json] [serve flags] [-- LSP args]",
+			summary: "Check a --config file against the schema, print the schema, or show the configuration the serve flags result in and where each setting came from.",
+			flags:   func() *flag.FlagSet { return newConfigFlags(&config{}, new(bool), new(bool)) },
+			run:     runConfig,
+		},
+		{
+			name:    "sandbox",
+			usage:   "[--read PATH] [--write PATH] -- COMMAND [args]",
+			summary: "Run a command with access to the filesystem limited to the given paths, as --sandbox runs language servers (Linux).",
+			flags:   func() *flag.FlagSet { return newSandboxFlags(new(StringArrayFlag), new(StringArrayFlag)) },
+			run:     runSandbox,
+		},
+		{
+			name:    "version",
+			summary: "Print version and build information.",
+			flags:   func() *flag.FlagSet { return newFlagSet("version") },
+			run:     runVersion,
+		},
+		{
+			name:    "completion",
+			usage:   "bash|zsh|fish",
+			summary: "Print a shell completion script.",
+			flags:   func() *flag.FlagSet { return newFlagSet("completion") },
+			run:     runCompletion,
+		},
+		{
+			name:    "help",
+			usage:   "[COMMAND]",
+			summary: "Show help for a command.",
+			flags:   func() *flag.FlagSet { return newFlagSet("help") },
+			run:     runHelp,
+		},
+	}
+}
+
+func findSubcommand(name string) (subcommand, bool) {
+	for _, cmd := range subcommands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return subcommand{}, false
+}
+
+// newFlagSet creates the flag set of a subcommand. Parse errors are returned
+// rather than exiting, and -h prints the command's help.
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		if cmd, ok := findSubcommand(name); ok {
+			printCommandHelp(flags.Output(), cmd, flags)
+		}
+	}
+	return flags
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: mcp-language-server [COMMAND] [flags]\n\n")
+	fmt.Fprintf(w, "An MCP server that gives LLMs semantic tools backed by a language server.\n\n")
+	fmt.Fprintf(w, "Commands:\n")
+	for _, cmd := range subcommands {
+		fmt.Fprintf(w, "  %-12s%s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun 'mcp-language-server help COMMAND' for the flags of a command.\n")
+}
+
+func printCommandHelp(w io.Writer, cmd subcommand, flags *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: mcp-language-server %s %s\n\n%s\n", cmd.name, cmd.usage, cmd.summary)
+	hasFlags := false
+	flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintf(w, "\nFlags:\n")
+		flags.SetOutput(w)
+		flags.PrintDefaults()
+	}
+}
+
+func main() {
+	lsp.ClientVersion = version
+
+	// Flags without a command start the server, as before subcommands existed
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := findSubcommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+
+	if err := cmd.run(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		coreLogger.Fatal("%v", err)
+	}
+}
+
+func runVersion(args []string) error {
+	if err := newFlagSet("version").Parse(args); err != nil {
+		return err
+	}
+	fmt.Print(currentBuildInfo())
+	return nil
+}
+
+func runHelp(args []string) error {
+	if len(args) == 0 {
+		printUsage(os.Stdout)
+		return nil
+	}
+	cmd, ok := findSubcommand(args[0])
+	if !ok {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	printCommandHelp(os.Stdout, cmd, cmd.flags())
+	return nil
+}
+
+func runCompletion(args []string) error {
+	flags := newFlagSet("completion")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: mcp-language-server completion bash|zsh|fish")
+	}
+	switch flags.Arg(0) {
+	case "bash":
+		fmt.Print(bashCompletion())
+	case "zsh":
+		// zsh runs bash completion functions through bashcompinit
+		fmt.Print("autoload -U +X bashcompinit && bashcompinit\n" + bashCompletion())
+	case "fish":
+		fmt.Print(fishCompletion())
+	default:
+		return fmt.Errorf("unsupported shell %q, expected bash, zsh or fish", flags.Arg(0))
+	}
+	return nil
+}
+
+// flagNames lists a command's flags in the --name form
+func flagNames(cmd subcommand) []string {
+	var names []string
+	cmd.flags().VisitAll(func(f *flag.Flag) {
+		names = append(names, "--"+f.Name)
+	})
+	sort.Strings(names)
+	return names
+}
+
+func bashCompletion() string {
+	var names []string
+	for _, cmd := range subcommands {
+		names = append(names, cmd.name)
+	}
+	serve, _ := findSubcommand("serve")
+
+	var b strings.Builder
+	b.WriteString("# bash completion for mcp-language-server\n")
+	b.WriteString("_mcp_language_server() {\n")
+	b.WriteString("  local cur=\"${COMP_WORDS[COMP_CWORD]}\" words\n")
+	b.WriteString("  if [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(&b, "    words=%q\n", strings.Join(append(names, flagNames(serve)...), " "))
+	b.WriteString("  else\n")
+	b.WriteString("    case \"${COMP_WORDS[1]}\" in\n")
+	for _, cmd := range subcommands {
+		words := flagNames(cmd)
+		if cmd.name == "help" {
+			words = names
+		} else if cmd.name == "completion" {
+			words = []string{"bash", "zsh", "fish"}
+		}
+		fmt.Fprintf(&b, "      %s) words=%q ;;\n", cmd.name, strings.Join(words, " "))
+	}
+	fmt.Fprintf(&b, "      *) words=%q ;;\n", strings.Join(flagNames(serve), " "))
+	b.WriteString("    esac\n")
+	b.WriteString("  fi\n")
+	b.WriteString("  COMPREPLY=($(compgen -W \"$words\" -- \"$cur\"))\n")
+	b.WriteString("}\n")
+	b.WriteString("complete -o default -F _mcp_language_server mcp-language-server\n")
+	return b.String()
+}
+
+func fishCompletion() string {
+	var b strings.Builder
+	b.WriteString("# fish completion for mcp-language-server\n")
+	var names []string
+	for _, cmd := range subcommands {
+		names = append(names, cmd.name)
+	}
+	for _, cmd := range subcommands {
+		fmt.Fprintf(&b, "complete -c mcp-language-server -n '__fish_use_subcommand' -f -a %s -d %s\n", cmd.name, fishQuote(cmd.summary))
+	}
+	for _, cmd := range subcommands {
+		cmd.flags().VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(&b, "complete -c mcp-language-server -n '__fish_seen_subcommand_from %s' -l %s -d %s\n", cmd.name, f.Name, fishQuote(firstSentence(f.Usage)))
+		})
+	}
+	fmt.Fprintf(&b, "complete -c mcp-language-server -n '__fish_seen_subcommand_from help' -f -a %q\n", strings.Join(names, " "))
+	b.WriteString("complete -c mcp-language-server -n '__fish_seen_subcommand_from completion' -f -a 'bash zsh fish'\n")
+	return b.String()
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// firstSentence shortens flag help to fit completion menus
+func firstSentence(s string) string {
+	if i := strings.Index(s, ". "); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/isaacphi/mcp-language-server/internal/i18n"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// lspArgsSource is the key of the language server's arguments, given after
+// --, in a config's sources
+const lspArgsSource = "--"
+
+const configUsage = "usage: mcp-language-server config validate [--workspace DIR] FILE | config schema | config show [--effective] [--json] [serve flags] [-- LSP args]"
+
+// newConfigFlags defines the flags of config show, which are the serve
+// flags it reports on and its own
+func newConfigFlags(cfg *config, effective, asJSON *bool) *flag.FlagSet {
+	flags := newFlagSet("config")
+	addLSPFlags(flags, cfg)
+	addServeFlags(flags, cfg)
+	flags.BoolVar(effective, "effective", false, "With show, list every setting in effect, defaults included, rather than only those that were set")
+	flags.BoolVar(asJSON, "json", false, "With show, print the settings as JSON")
+	return flags
+}
+
+func newValidateFlags(workspaceDir *string) *flag.FlagSet {
+	flags := newFlagSet("config")
+	flags.StringVar(workspaceDir, "workspace", "", "Workspace that ${workspaceFolder} in the file stands for")
+	return flags
+}
+
+// runConfig implements the config subcommand: validate checks a --config
+// file against the schema without starting anything, schema prints the
+// schema for editors, and show prints the configuration the serve flags
+// result in and where each setting came from
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", configUsage)
+	}
+	switch args[0] {
+	case "validate":
+		return runConfigValidate(args[1:])
+	case "schema":
+		if len(args) > 1 {
+			return fmt.Errorf("%s", configUsage)
+		}
+		_, err := os.Stdout.Write(configSchemaJSON)
+		return err
+	case "show":
+		return runConfigShow(args[1:])
+	}
+	// Flags before the command are only accepted for -h
+	if err := newConfigFlags(&config{}, new(bool), new(bool)).Parse(args); err != nil {
+		return err
+	}
+	return fmt.Errorf("%s", configUsage)
+}
+
+func runConfigValidate(args []string) error {
+	var workspaceDir string
+	flags := newValidateFlags(&workspaceDir)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("%s", configUsage)
+	}
+	vars := variables{}
+	if workspaceDir != "" {
+		dir, err := filepath.Abs(variables{}.expand(workspaceDir))
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path for workspace: %v", err)
+		}
+		vars.workspaceDir = dir
+	}
+	path := flags.Arg(0)
+	if _, err := loadFileConfig(path, vars); err != nil {
+		return err
+	}
+	fmt.Printf("%s is valid\n", path)
+	return nil
+}
+
+func runConfigShow(args []string) error {
+	var effective, asJSON bool
+	cfg, err := parseFlags(args, func(cfg *config) *flag.FlagSet { return newConfigFlags(cfg, &effective, &asJSON) })
+	if err != nil {
+		return err
+	}
+	if err := cfg.resolveWorkspace(); err != nil {
+		return err
+	}
+	if err := cfg.expandLSPOptions(); err != nil {
+		return err
+	}
+	file := &fileConfig{}
+	if cfg.configFile != "" {
+		if cfg.configFile, err = filepath.Abs(cfg.configFile); err != nil {
+			return fmt.Errorf("failed to get absolute path for config file: %v", err)
+		}
+		if file, err = loadFileConfig(cfg.configFile, cfg.variables()); err != nil {
+			return err
+		}
+	}
+	entries := cfg.effectiveConfig(file, effective)
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+	return writeConfigEntries(os.Stdout, entries)
+}
+
+// configEntry is a setting in effect and where it came from: the command
+// line, a profile, the config file, the environment, a default, or derived
+// from other settings
+type configEntry struct {
+	Name   string `json:"name"`
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// environmentSettings are the environment variables the server reads, with
+// what applies when they are unset
+var environmentSettings = []struct{ name, fallback string }{
+	{"LOG_LEVEL", "INFO"},
+	{"LOG_COMPONENT_LEVELS", ""},
+	{"LOG_FILE", ""},
+	{"LSP_CONTEXT_LINES", "5"},
+}
+
+// fileDefaults are what applies for the config file settings that have a
+// default
+var fileDefaults = map[string]any{
+	"locale":      i18n.English,
+	"ranking":     tools.DefaultRankingWeights,
+	"todoMarkers": tools.DefaultTodoMarkers,
+}
+
+// effectiveConfig lists the serve flags, what the language server is
+// started with, the config file settings and the environment variables the
+// server reads. Unless all is set, defaults are left out.
+func (cfg *config) effectiveConfig(file *fileConfig, all bool) []configEntry {
+	var entries []configEntry
+	add := func(name string, value any, source string) {
+		if all || source != "default" {
+			entries = append(entries, configEntry{Name: name, Value: value, Source: source})
+		}
+	}
+
+	// The flags are bound to a copy of the config to read its values
+	current := &config{}
+	flags := newServeFlags(current)
+	*current = *cfg
+	flags.VisitAll(func(f *flag.Flag) {
+		if f.Name == "version" {
+			return
+		}
+		source := cfg.sources[f.Name]
+		if source == "" {
+			source = "default"
+		}
+		add("--"+f.Name, flagValue(f), source)
+	})
+
+	given := cfg.lspArgs[:len(cfg.lspArgs)-len(cfg.addedLSPArgs)]
+	if source := cfg.sources[lspArgsSource]; source != "" {
+		add("-- (LSP args)", given, source)
+	} else {
+		add("-- (LSP args)", []string{}, "default")
+	}
+	if len(cfg.addedLSPArgs) > 0 {
+		add("added LSP args", cfg.addedLSPArgs, "derived from the workspace")
+	}
+	if cfg.lspCommand != "" {
+		opts := cfg.processOptions()
+		command, args := opts.Command(cfg.lspCommand, cfg.lspArgs...)
+		add("LSP command line", append([]string{command}, args...), "derived")
+		if len(opts.Env) > 0 {
+			add("LSP environment", opts.Env, "derived")
+		}
+	}
+	if cfg.sandboxPolicy != nil {
+		add("sandbox", map[string]any{"read": cfg.sandboxPolicy.Read, "write": cfg.sandboxPolicy.Write}, "derived")
+	}
+
+	// Settings the file leaves out are null or empty
+	var settings map[string]any
+	data, _ := json.Marshal(file)
+	_ = json.Unmarshal(data, &settings)
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		value := settings[key]
+		if isEmpty(value) {
+			if value = fileDefaults[key]; value == nil {
+				value = settings[key]
+			}
+			add(key, value, "default")
+		} else {
+			add(key, value, "config file "+cfg.configFile)
+		}
+	}
+
+	for _, env := range environmentSettings {
+		if value, ok := os.LookupEnv(env.name); ok {
+			add(env.name, value, "environment")
+		} else {
+			add(env.name, env.fallback, "default")
+		}
+	}
+	return entries
+}
+
+// flagValue returns a flag's value as it is best shown: a list for
+// repeatable flags, a boolean or number where it is one, and text otherwise
+func flagValue(f *flag.Flag) any {
+	if values, ok := f.Value.(*StringArrayFlag); ok {
+		return append([]string{}, *values...)
+	}
+	if getter, ok := f.Value.(flag.Getter); ok {
+		switch value := getter.Get().(type) {
+		case bool, int, int64:
+			return value
+		}
+	}
+	return f.Value.String()
+}
+
+func isEmpty(value any) bool {
+	switch value := value.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	case []any:
+		return len(value) == 0
+	case map[string]any:
+		return len(value) == 0
+	}
+	return false
+}
+
+// writeConfigEntries writes one line per setting: its name, value and
+// source, with values other than text in JSON
+func writeConfigEntries(w io.Writer, entries []configEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "SETTING\tVALUE\tSOURCE\n")
+	for _, entry := range entries {
+		value, ok := entry.Value.(string)
+		if !ok {
+			data, _ := json.Marshal(entry.Value)
+			value = string(data)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", entry.Name, strings.ReplaceAll(value, "\t", " "), entry.Source)
+	}
+	return tw.Flush()
+}
+
+// registerConfigTool registers get_config, which reports the configuration
+// the server runs with, the config file as it was last reloaded
+func (s *mcpServer) registerConfigTool() {
+	getConfigTool := mcp.NewTool("get_config",
+		mcp.WithDescription("Show the server's configuration: every flag, the language server command line and environment, the config file settings and the environment variables the server reads, with where each came from (command line, profile, config file, environment, default or derived). Use this to find out why the server or language server behaves as it does."),
+		mcp.WithBoolean("effective",
+			mcp.Description("If true, also lists the settings left at their defaults"),
+			mcp.DefaultBool(true),
+		),
+	)
+
+	s.mcpServer.AddTool(getConfigTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		effective := request.GetBool("effective", true)
+
+		coreLogger.Debug("Executing get_config")
+		s.liveConfig.mu.RLock()
+		file := s.liveConfig.current
+		s.liveConfig.mu.RUnlock()
+		var text strings.Builder
+		if err := writeConfigEntries(&text, s.config.effectiveConfig(&file, effective)); err != nil {
+			return toolError("failed to show the configuration", err), nil
+		}
+		return mcp.NewToolResultText(text.String()), nil
+	})
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/fsnotify/fsnotify"
+	"github.com/isaacphi/mcp-language-server/internal/i18n"
+	"github.com/isaacphi/mcp-language-server/internal/logging"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/schema"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// configReloadDelay is how long the config file has to stay unchanged before
+// it is reloaded, so that an editor's write, rename and chmod apply once
+const configReloadDelay = 200 * time.Millisecond
+
+// fileConfig is the part of the configuration that can be given in a file
+// with --config and changed while the server runs
+type fileConfig struct {
+	// DisabledTools are hidden from tools/list and refused when called
+	DisabledTools []string `json:"disabledTools"`
+	// LogLevel overrides LOG_LEVEL for every component
+	LogLevel string `json:"logLevel"`
+	// LSPSettings are returned to workspace/configuration requests and sent
+	// with workspace/didChangeConfiguration when they change
+	LSPSettings map[string]any `json:"lspSettings"`
+	// Ranking overrides the weights that order references and definitions
+	Ranking *tools.RankingWeights `json:"ranking"`
+	// Languages maps globs to the language IDs of the files they match,
+	// overriding detection by extension
+	Languages map[string]string `json:"languages"`
+	// PostEditHooks run after tools edit files, on the files they edited
+	PostEditHooks []tools.PostEditHook `json:"postEditHooks"`
+	// TodoMarkers are the markers find_todos looks for by default
+	TodoMarkers []string `json:"todoMarkers"`
+	// LSPExecutables, when not empty, are the only executables language
+	// servers may be started with. They are read once, at startup.
+	LSPExecutables []lsp.PinnedExecutable `json:"lspExecutables"`
+	// Locale is the language of tool descriptions and error messages
+	Locale string `json:"locale"`
+}
+
+// configSchemaJSON is the JSON schema of the config file, which editors can
+// be pointed at with a $schema key
+//
+//go:embed config.schema.json
+var configSchemaJSON []byte
+
+var configSchema = func() *schema.Schema {
+	s, err := schema.Parse(configSchemaJSON)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}()
+
+// loadFileConfig reads and validates a config file, substituting variables
+// in its strings. A file that doesn't match the schema is refused whole,
+// with every mismatch, rather than applied in part.
+func loadFileConfig(path string, vars variables) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	data = vars.expandJSON(data)
+	if errs := configSchema.Validate(data); len(errs) > 0 {
+		return nil, schemaError(path, errs)
+	}
+	var cfg fileConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	if cfg.LogLevel != "" {
+		if _, err := logging.ParseLevel(cfg.LogLevel); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+		}
+	}
+	for glob, language := range cfg.Languages {
+		if !doublestar.ValidatePattern(glob) {
+			return nil, fmt.Errorf("invalid config file %s: invalid glob %q in languages", path, glob)
+		}
+		if language == "" {
+			return nil, fmt.Errorf("invalid config file %s: no language for %q in languages", path, glob)
+		}
+	}
+	for i, hook := range cfg.PostEditHooks {
+		if err := hook.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: postEditHooks[%d]: %v", path, i, err)
+		}
+	}
+	for i, marker := range cfg.TodoMarkers {
+		if strings.TrimSpace(marker) == "" {
+			return nil, fmt.Errorf("invalid config file %s: todoMarkers[%d] is empty", path, i)
+		}
+	}
+	for i, pin := range cfg.LSPExecutables {
+		if err := pin.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: lspExecutables[%d]: %v", path, i, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// schemaError reports the mismatches between a config file and the schema,
+// one per line when there are several
+func schemaError(path string, errs []schema.Error) error {
+	if len(errs) == 1 {
+		return fmt.Errorf("invalid config file %s: %v", path, errs[0])
+	}
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = "  " + err.Error()
+	}
+	return fmt.Errorf("invalid config file %s:\n%s", path, strings.Join(lines, "\n"))
+}
+
+// liveConfig holds the file configuration currently in effect
+type liveConfig struct {
+	mu      sync.RWMutex
+	current fileConfig
+	// startLevel and startLevels are restored when logLevel is removed
+	startLevel  logging.LogLevel
+	startLevels map[logging.Component]logging.LogLevel
+}
+
+func newLiveConfig() *liveConfig {
+	live := &liveConfig{}
+	live.startLevel, live.startLevels = logging.Levels()
+	return live
+}
+
+// toolDisabled reports whether a tool is disabled by the config file
+func (l *liveConfig) toolDisabled(name string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return slices.Contains(l.current.DisabledTools, name)
+}
+
+// filterTools hides disabled tools from tools/list
+func (l *liveConfig) filterTools(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	filtered := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if !l.toolDisabled(tool.Name) {
+			filtered = append(filtered, tool)
+		}
+	}
+	return filtered
+}
+
+// lspSettings returns the settings for the language server
+func (l *liveConfig) lspSettings() map[string]any {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.current.LSPSettings
+}
+
+// postEditHooks returns the hooks to run after tools edit files
+func (l *liveConfig) postEditHooks() []tools.PostEditHook {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.current.PostEditHooks
+}
+
+// todoMarkers returns the markers find_todos looks for unless it is given
+// others
+func (l *liveConfig) todoMarkers() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.current.TodoMarkers
+}
+
+// middleware refuses calls to disabled tools, which clients may still make
+// from a tool list they fetched before the tool was disabled
+func (l *liveConfig) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if l.toolDisabled(request.Params.Name) {
+			return codedResult(tools.ToolDisabled, i18n.Sprintf("tool %s is disabled by the server configuration", request.Params.Name)), nil
+		}
+		return next(ctx, request)
+	}
+}
+
+// loadConfigFile applies the config file before the language server starts
+func (s *mcpServer) loadConfigFile() error {
+	s.liveConfig = newLiveConfig()
+	if s.config.configFile == "" {
+		return nil
+	}
+	cfg, err := loadFileConfig(s.config.configFile, s.config.variables())
+	if err != nil {
+		return err
+	}
+	s.applyFileConfig(cfg)
+	return nil
+}
+
+// applyFileConfig puts a config into effect, notifying MCP clients when the
+// tool set changes and the language server when its settings change
+func (s *mcpServer) applyFileConfig(cfg *fileConfig) {
+	live := s.liveConfig
+	live.mu.Lock()
+	previous := live.current
+	live.current = *cfg
+	live.mu.Unlock()
+
+	if cfg.LogLevel != previous.LogLevel {
+		if cfg.LogLevel == "" {
+			logging.SetLevels(live.startLevel, live.startLevels)
+		} else if level, err := logging.ParseLevel(cfg.LogLevel); err == nil {
+			logging.SetGlobalLevel(level)
+		}
+		coreLogger.Info("Log level set to %q", cfg.LogLevel)
+	}
+
+	if !sameToolSet(cfg.DisabledTools, previous.DisabledTools) {
+		coreLogger.Info("Disabled tools: %v", cfg.DisabledTools)
+		if s.mcpServer != nil {
+			s.mcpServer.SendNotificationToAllClients(mcp.MethodNotificationToolsListChanged, nil)
+		}
+	}
+
+	if cfg.Locale != previous.Locale {
+		if err := i18n.SetLocale(cfg.Locale); err != nil {
+			coreLogger.Error("Failed to set locale: %v", err)
+		} else {
+			coreLogger.Info("Locale set to %s", i18n.Locale())
+			if s.mcpServer != nil {
+				s.mcpServer.SendNotificationToAllClients(mcp.MethodNotificationToolsListChanged, nil)
+			}
+		}
+	}
+
+	if !reflect.DeepEqual(cfg.Ranking, previous.Ranking) {
+		tools.SetRankingWeights(cfg.Ranking)
+		coreLogger.Info("Result ranking weights updated")
+	}
+
+	// Pins that a rewritten config file could loosen would protect nothing
+	if !reflect.DeepEqual(cfg.LSPExecutables, s.config.pinnedExecutables) {
+		coreLogger.Warn("lspExecutables changed, the new pins apply after a restart")
+	}
+
+	if !reflect.DeepEqual(cfg.Languages, previous.Languages) {
+		lsp.SetLanguageOverrides(s.config.workspaceDir, cfg.Languages)
+		coreLogger.Info("Language overrides: %v", cfg.Languages)
+	}
+
+	// A server that isn't started yet is given the settings by initializeLSP
+	if s.lspClient != nil && !reflect.DeepEqual(cfg.LSPSettings, previous.LSPSettings) {
+		if err := s.lspClient.UpdateSettings(s.ctx, cfg.LSPSettings); err != nil {
+			coreLogger.Error("Failed to send LSP settings: %v", err)
+		}
+	}
+}
+
+// sameToolSet reports whether two lists name the same tools
+func sameToolSet(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+// watchConfigFile reloads the config file whenever it changes. The
+// directory is watched rather than the file so that editors replacing the
+// file are noticed. A file that fails to load leaves the previous config in
+// effect.
+func (s *mcpServer) watchConfigFile() error {
+	path, err := filepath.Abs(s.config.configFile)
+	if err != nil {
+		return err
+	}
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch config file: %v", err)
+	}
+	if err := fsWatcher.Add(filepath.Dir(path)); err != nil {
+		_ = fsWatcher.Close()
+		return fmt.Errorf("failed to watch config file: %v", err)
+	}
+
+	go func() {
+		defer func() { _ = fsWatcher.Close() }()
+		var reload <-chan time.Time
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case event, ok := <-fsWatcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == path {
+					reload = time.After(configReloadDelay)
+				}
+			case err, ok := <-fsWatcher.Errors:
+				if !ok {
+					return
+				}
+				coreLogger.Error("Config file watcher error: %v", err)
+			case <-reload:
+				reload = nil
+				cfg, err := loadFileConfig(path, s.config.variables())
+				if err != nil {
+					coreLogger.Error("Keeping the previous configuration: %v", err)
+					continue
+				}
+				coreLogger.Info("Reloaded configuration from %s", path)
+				s.applyFileConfig(cfg)
+			}
+		}
+	}()
+	return nil
+}
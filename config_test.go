@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configSources maps the settings of entries to their sources
+func configSources(entries []configEntry) (map[string]string, map[string]any) {
+	sources := make(map[string]string)
+	values := make(map[string]any)
+	for _, entry := range entries {
+		sources[entry.Name] = entry.Source
+		values[entry.Name] = entry.Value
+	}
+	return sources, values
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "DEBUG")
+	workspace, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	global := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(global, []byte(`{"profiles": [
+		{"name": "go", "workspaces": ["`+filepath.ToSlash(workspace)+`"], "args": ["--lsp", "gopls", "--open", "**/*.go", "--request-timeout", "2m"], "lspArgs": ["serve"]}
+	]}`), 0644))
+	configFile := filepath.Join(t.TempDir(), "server.json")
+	require.NoError(t, os.WriteFile(configFile, []byte(`{"locale": "ja", "todoMarkers": ["HACK"]}`), 0644))
+
+	cfg, err := parseConfig([]string{
+		"--workspace", workspace, "--global-config", global, "--config", configFile,
+		"--test-command", "go test {target}", "--open", "**/*.mod",
+	})
+	require.NoError(t, err)
+	file, err := loadFileConfig(cfg.configFile, cfg.variables())
+	require.NoError(t, err)
+
+	sources, values := configSources(cfg.effectiveConfig(file, true))
+	for name, want := range map[string]string{
+		"--lsp":                "profile go",
+		"--request-timeout":    "profile go",
+		"-- (LSP args)":        "profile go",
+		"--open":               "profile go and command line",
+		"--test-command":       "command line",
+		"--workspace":          "command line",
+		"--max-delete-percent": "default",
+		"LSP command line":     "derived",
+		"locale":               "config file " + cfg.configFile,
+		"todoMarkers":          "config file " + cfg.configFile,
+		"ranking":              "default",
+		"LOG_LEVEL":            "environment",
+		"LOG_FILE":             "default",
+	} {
+		assert.Equal(t, want, sources[name], name)
+	}
+	assert.Equal(t, "gopls", values["--lsp"])
+	assert.Equal(t, (2 * time.Minute).String(), values["--request-timeout"])
+	assert.Equal(t, []string{"serve"}, values["-- (LSP args)"])
+	assert.Equal(t, []string{"**/*.go", "**/*.mod"}, values["--open"])
+	assert.Equal(t, "ja", values["locale"])
+	assert.Equal(t, "DEBUG", values["LOG_LEVEL"])
+
+	// Without all, the defaults are left out
+	sources, _ = configSources(cfg.effectiveConfig(file, false))
+	assert.NotContains(t, sources, "--max-delete-percent")
+	assert.NotContains(t, sources, "ranking")
+	assert.Equal(t, "profile go", sources["--lsp"])
+	assert.Equal(t, "environment", sources["LOG_LEVEL"])
+}
+
+func TestGetConfigTool(t *testing.T) {
+	s := newToolServer(t, lsptest.NewServer(protocol.ServerCapabilities{}), "--allow-get-config", "--test-command", "make test")
+	s.liveConfig.current.Locale = "zh"
+	s.registerConfigTool()
+
+	result := callTest(t, s, "get_config", map[string]any{"effective": false})
+	require.False(t, result.IsError, resultText(result))
+	text := resultText(result)[0]
+	assert.Regexp(t, `(?m)^--test-command\s+make test\s+command line$`, text)
+	assert.Regexp(t, `(?m)^locale\s+zh\s+config file`, text)
+	assert.NotContains(t, text, "--max-delete-percent")
+}
@@ -0,0 +1,540 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// The daemon keeps language servers running between sessions, so that the
+// many short sessions an agent starts in a day don't each wait for the
+// workspace to be indexed again. serve --daemon is a shim that passes its
+// stdio session to the daemon over a unix socket. The daemon runs each
+// session in a worker, a serve --listen process for the session's
+// workspace and flags that serves sessions one after another, and keeps
+// idle workers for --idle-timeout. A worker is a process of its own because
+// the server's state, from its working directory to its settings, is per
+// process. A workspace with several sessions at once gets several workers.
+
+// defaultDaemonIdleTimeout is how long idle workers, and the daemon once it
+// has none, are kept
+const defaultDaemonIdleTimeout = 30 * time.Minute
+
+// minDaemonIdleTimeout is the shortest --idle-timeout, below which idle
+// checks would run constantly
+const minDaemonIdleTimeout = time.Second
+
+// workerStartTimeout bounds how long the daemon waits for a new worker to
+// listen. The worker listens before starting its language server.
+const workerStartTimeout = 30 * time.Second
+
+// daemonRequest is the first line a shim sends, naming the session's
+// workspace. The rest of the connection is the session.
+type daemonRequest struct {
+	Workspace string `json:"workspace"`
+	// Dir and Env are the shim's working directory and environment, which
+	// a worker started for the session runs with
+	Dir string   `json:"dir"`
+	Env []string `json:"env"`
+	// Args are the shim's serve flags and LSP args, without --daemon
+	Args []string `json:"args"`
+}
+
+// key identifies the workers that can serve a request: those started for
+// the same workspace, directory, flags and environment, in any order
+func (r daemonRequest) key() string {
+	env := slices.Sorted(slices.Values(r.Env))
+	fields := append([]string{r.Workspace, r.Dir}, r.Args...)
+	fields = append(fields, "\x01")
+	return strings.Join(append(fields, env...), "\x00")
+}
+
+// defaultDaemonSocket is the daemon's socket in a directory only the user
+// can reach
+func defaultDaemonSocket() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, fmt.Sprintf("mcp-language-server-%d", os.Getuid()), "daemon.sock")
+}
+
+func newDaemonFlags(socket *string, idleTimeout *time.Duration) *flag.FlagSet {
+	flags := newFlagSet("daemon")
+	flags.StringVar(socket, "socket", defaultDaemonSocket(), "Unix socket to listen on. Its directory must be yours with mode 0700, and is created so if missing")
+	flags.DurationVar(idleTimeout, "idle-timeout", defaultDaemonIdleTimeout, "How long to keep a workspace's language server without sessions, and the daemon without workspaces")
+	return flags
+}
+
+// runDaemon implements the daemon subcommand
+func runDaemon(args []string) error {
+	var socket string
+	var idleTimeout time.Duration
+	flags := newDaemonFlags(&socket, &idleTimeout)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if idleTimeout < minDaemonIdleTimeout {
+		return fmt.Errorf("invalid --idle-timeout %v: must be at least %v", idleTimeout, minDaemonIdleTimeout)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the executable for workers: %v", err)
+	}
+
+	if err := makeSocketDir(filepath.Dir(socket)); err != nil {
+		return err
+	}
+	listener, err := listenSocket(socket)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	d := &daemon{
+		executable:  executable,
+		dir:         filepath.Dir(socket),
+		idleTimeout: idleTimeout,
+		workers:     make(map[string][]*daemonWorker),
+		lastActive:  time.Now(),
+		stop:        stop,
+	}
+	coreLogger.Info("Daemon listening on %s", socket)
+	go d.exitWhenIdle(ctx)
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			d.shutdown()
+			return fmt.Errorf("daemon stopped accepting sessions: %v", err)
+		}
+		go d.handle(conn.(*net.UnixConn))
+	}
+	d.shutdown()
+	coreLogger.Info("Daemon stopped")
+	return nil
+}
+
+// listenUnix listens on a unix socket, creating its directory for the user
+// alone and replacing a socket left by a process that is gone
+func listenUnix(socket string) (*net.UnixListener, error) {
+	if err := os.MkdirAll(filepath.Dir(socket), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %v", err)
+	}
+	return listenSocket(socket)
+}
+
+// makeSocketDir creates the directory of the daemon's socket for the user
+// alone, refusing one that another user could reach
+func makeSocketDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %v", err)
+	}
+	return checkSocketDir(dir)
+}
+
+// listenSocket listens on a unix socket only the user can connect to,
+// replacing a socket left by a process that is gone
+func listenSocket(socket string) (*net.UnixListener, error) {
+	if conn, err := net.Dial("unix", socket); err == nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("%s is already in use", socket)
+	}
+	_ = os.Remove(socket)
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: socket, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %v", socket, err)
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to restrict %s to the user: %v", socket, err)
+	}
+	return listener, nil
+}
+
+type daemon struct {
+	executable  string
+	dir         string
+	idleTimeout time.Duration
+	stop        context.CancelFunc
+
+	mu       sync.Mutex
+	workers  map[string][]*daemonWorker
+	sessions int
+	// lastActive is when the daemon last had a session or a worker
+	lastActive time.Time
+	nextID     int
+}
+
+// daemonWorker is a serve --listen process
+type daemonWorker struct {
+	key    string
+	socket string
+	cmd    *exec.Cmd
+	busy   bool
+	// stopping is set once the worker is being stopped, so that no session
+	// is given to it
+	stopping bool
+	idle     *time.Timer
+	exited   chan struct{}
+}
+
+// handle runs a shim's session in a worker for its workspace
+func (d *daemon) handle(conn *net.UnixConn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		coreLogger.Error("Failed to read session request: %v", err)
+		return
+	}
+	var request daemonRequest
+	if err := json.Unmarshal(line, &request); err != nil || !filepath.IsAbs(request.Workspace) {
+		coreLogger.Error("Invalid session request: %s", strings.TrimSpace(string(line)))
+		return
+	}
+
+	d.mu.Lock()
+	d.sessions++
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		d.sessions--
+		d.lastActive = time.Now()
+		d.mu.Unlock()
+	}()
+
+	worker, workerConn, err := d.acquire(request)
+	if err != nil {
+		coreLogger.Error("Failed to start a worker for %s: %v", request.Workspace, err)
+		return
+	}
+	defer d.release(worker)
+	defer workerConn.Close()
+	coreLogger.Info("Session started for %s", request.Workspace)
+
+	// The worker ends the session when the shim's input ends, and the
+	// session is over when the worker has written its last response
+	go func() {
+		_, _ = io.Copy(workerConn, reader)
+		_ = workerConn.CloseWrite()
+	}()
+	_, _ = io.Copy(conn, workerConn)
+	coreLogger.Info("Session ended for %s", request.Workspace)
+}
+
+// acquire connects to an idle worker for the request, starting one if there
+// is none
+func (d *daemon) acquire(request daemonRequest) (*daemonWorker, *net.UnixConn, error) {
+	key := request.key()
+	d.mu.Lock()
+	for _, worker := range d.workers[key] {
+		if !worker.busy && !worker.stopping {
+			worker.busy = true
+			if worker.idle != nil {
+				worker.idle.Stop()
+			}
+			d.mu.Unlock()
+			conn, err := dialWorker(worker, workerStartTimeout)
+			if err != nil {
+				d.release(worker)
+				return nil, nil, err
+			}
+			return worker, conn, nil
+		}
+	}
+	d.nextID++
+	worker := &daemonWorker{
+		key:    key,
+		socket: filepath.Join(d.dir, fmt.Sprintf("worker-%d-%d.sock", os.Getpid(), d.nextID)),
+		busy:   true,
+		exited: make(chan struct{}),
+	}
+	worker.cmd = exec.Command(d.executable, append([]string{"serve", "--listen", worker.socket}, request.Args...)...)
+	worker.cmd.Dir = request.Dir
+	worker.cmd.Env = request.Env
+	worker.cmd.Stderr = os.Stderr
+	if err := worker.cmd.Start(); err != nil {
+		d.mu.Unlock()
+		return nil, nil, err
+	}
+	d.workers[key] = append(d.workers[key], worker)
+	d.mu.Unlock()
+	coreLogger.Info("Started worker %d for %s", worker.cmd.Process.Pid, request.Workspace)
+
+	go func() {
+		err := worker.cmd.Wait()
+		coreLogger.Info("Worker %d exited: %v", worker.cmd.Process.Pid, err)
+		_ = os.Remove(worker.socket)
+		d.mu.Lock()
+		workers := d.workers[worker.key]
+		for i, w := range workers {
+			if w == worker {
+				d.workers[worker.key] = append(workers[:i:i], workers[i+1:]...)
+				break
+			}
+		}
+		if len(d.workers[worker.key]) == 0 {
+			delete(d.workers, worker.key)
+		}
+		d.lastActive = time.Now()
+		d.mu.Unlock()
+		close(worker.exited)
+	}()
+
+	conn, err := dialWorker(worker, workerStartTimeout)
+	if err != nil {
+		d.terminate(worker)
+		return nil, nil, err
+	}
+	return worker, conn, nil
+}
+
+// dialWorker connects to a worker, waiting for it to listen
+func dialWorker(worker *daemonWorker, timeout time.Duration) (*net.UnixConn, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: worker.socket, Net: "unix"})
+		if err == nil {
+			return conn, nil
+		}
+		select {
+		case <-worker.exited:
+			return nil, errors.New("worker exited")
+		case <-time.After(50 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("worker isn't listening: %v", err)
+		}
+	}
+}
+
+// release makes a worker available to the next session, stopping it if it
+// stays idle
+func (d *daemon) release(worker *daemonWorker) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	worker.busy = false
+	if worker.idle == nil {
+		worker.idle = time.AfterFunc(d.idleTimeout, func() { d.expire(worker) })
+	} else {
+		worker.idle.Reset(d.idleTimeout)
+	}
+}
+
+// expire stops a worker that has been idle for the idle timeout
+func (d *daemon) expire(worker *daemonWorker) {
+	d.mu.Lock()
+	idle := !worker.busy && !worker.stopping
+	if idle {
+		worker.stopping = true
+	}
+	d.mu.Unlock()
+	if idle {
+		coreLogger.Info("Stopping idle worker %d", worker.cmd.Process.Pid)
+		d.terminate(worker)
+	}
+}
+
+// terminate asks a worker to shut down, killing it if it doesn't
+func (d *daemon) terminate(worker *daemonWorker) {
+	if err := worker.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		_ = worker.cmd.Process.Kill()
+		return
+	}
+	select {
+	case <-worker.exited:
+	case <-time.After(10 * time.Second):
+		_ = worker.cmd.Process.Kill()
+	}
+}
+
+// shutdown stops every worker
+func (d *daemon) shutdown() {
+	d.mu.Lock()
+	var workers []*daemonWorker
+	for _, list := range d.workers {
+		for _, worker := range list {
+			worker.stopping = true
+		}
+		workers = append(workers, list...)
+	}
+	d.mu.Unlock()
+	var wg sync.WaitGroup
+	for _, worker := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.terminate(worker)
+		}()
+	}
+	wg.Wait()
+}
+
+// exitWhenIdle stops the daemon once it has had no workers or sessions for
+// the idle timeout, so that a daemon started by a shim doesn't stay forever
+func (d *daemon) exitWhenIdle(ctx context.Context) {
+	ticker := time.NewTicker(min(d.idleTimeout/4, time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		d.mu.Lock()
+		idle := len(d.workers) == 0 && d.sessions == 0 && time.Since(d.lastActive) >= d.idleTimeout
+		d.mu.Unlock()
+		if idle {
+			coreLogger.Info("Daemon idle for %v, stopping", d.idleTimeout)
+			d.stop()
+			return
+		}
+	}
+}
+
+// runDaemonSession implements serve --daemon: it passes the stdio session
+// to the daemon, starting the daemon if it isn't running
+func runDaemonSession(cfg *config, args []string) error {
+	socket := cfg.daemonSocket
+	if socket == "" {
+		socket = defaultDaemonSocket()
+	}
+	if err := makeSocketDir(filepath.Dir(socket)); err != nil {
+		return err
+	}
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: socket, Net: "unix"})
+	if err != nil {
+		if conn, err = startDaemon(socket); err != nil {
+			return err
+		}
+	}
+	defer conn.Close()
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	return passSession(conn, daemonRequest{
+		Workspace: cfg.workspaceDir,
+		Dir:       dir,
+		Env:       os.Environ(),
+		Args:      withoutDaemonFlags(args),
+	}, os.Stdin, os.Stdout)
+}
+
+// passSession sends a request to the daemon over conn, then copies the
+// session from in to the daemon and its responses to out
+func passSession(conn *net.UnixConn, request daemonRequest, in io.Reader, out io.Writer) error {
+	line, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to reach the daemon: %v", err)
+	}
+
+	go func() {
+		_, _ = io.Copy(conn, in)
+		_ = conn.CloseWrite()
+	}()
+	_, err = io.Copy(out, conn)
+	return err
+}
+
+// startDaemon starts the daemon in the background, logging to daemon.log
+// next to its socket, and connects to it
+func startDaemon(socket string) (*net.UnixConn, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	if err := makeSocketDir(filepath.Dir(socket)); err != nil {
+		return nil, err
+	}
+	logFile, err := os.OpenFile(filepath.Join(filepath.Dir(socket), "daemon.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open daemon log: %v", err)
+	}
+	defer logFile.Close()
+	cmd := exec.Command(executable, "daemon", "--socket", socket)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start the daemon: %v", err)
+	}
+	coreLogger.Info("Started daemon %d", cmd.Process.Pid)
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: socket, Net: "unix"})
+		if err == nil {
+			return conn, nil
+		}
+		select {
+		case <-exited:
+			// Another shim may have started one first
+			if conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: socket, Net: "unix"}); err == nil {
+				return conn, nil
+			}
+			return nil, fmt.Errorf("the daemon exited, see %s", filepath.Join(filepath.Dir(socket), "daemon.log"))
+		case <-time.After(50 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("the daemon isn't listening on %s: %v", socket, err)
+		}
+	}
+}
+
+// withoutDaemonFlags removes --daemon and --daemon-socket from serve
+// arguments, leaving the LSP args after -- alone
+func withoutDaemonFlags(args []string) []string {
+	var kept []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(kept, args[i:]...)
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") {
+			kept = append(kept, arg)
+			continue
+		}
+		switch name {
+		case "daemon":
+			continue
+		case "daemon-socket":
+			if !hasValue {
+				i++
+			}
+			continue
+		}
+		kept = append(kept, arg)
+	}
+	return kept
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runTestWorker stands in for serve --listen, echoing each session back
+// until the session's input ends
+func runTestWorker(args []string) {
+	i := slices.Index(args, "--listen")
+	if i < 0 || i+1 >= len(args) {
+		os.Exit(2)
+	}
+	listener, err := listenSocket(args[i+1])
+	if err != nil {
+		os.Exit(1)
+	}
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			os.Exit(1)
+		}
+		_, _ = io.Copy(conn, conn)
+		_ = conn.Close()
+	}
+}
+
+func newTestDaemon(t *testing.T, idleTimeout time.Duration) *daemon {
+	t.Helper()
+	d := &daemon{
+		executable:  os.Args[0],
+		dir:         t.TempDir(),
+		idleTimeout: idleTimeout,
+		workers:     make(map[string][]*daemonWorker),
+		lastActive:  time.Now(),
+		stop:        func() {},
+	}
+	t.Cleanup(d.shutdown)
+	return d
+}
+
+func newTestRequest(t *testing.T) daemonRequest {
+	return daemonRequest{
+		Workspace: t.TempDir(),
+		Dir:       t.TempDir(),
+		Env:       append(os.Environ(), testWorkerEnv+"=1"),
+		Args:      []string{"--lsp", "gopls"},
+	}
+}
+
+func exited(worker *daemonWorker, timeout time.Duration) bool {
+	select {
+	case <-worker.exited:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func TestWithoutDaemonFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"daemon", []string{"--daemon", "--workspace", "/w", "--lsp", "gopls"}, []string{"--workspace", "/w", "--lsp", "gopls"}},
+		{"socket value", []string{"--daemon-socket", "/s", "--daemon", "--lsp=gopls"}, []string{"--lsp=gopls"}},
+		{"socket with =", []string{"-daemon-socket=/s", "--workspace", "/w"}, []string{"--workspace", "/w"}},
+		{"LSP args", []string{"--daemon", "--lsp", "gopls", "--", "--daemon", "serve"}, []string{"--lsp", "gopls", "--", "--daemon", "serve"}},
+		{"none", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, withoutDaemonFlags(tt.args))
+		})
+	}
+}
+
+func TestRunDaemonRejectsShortIdleTimeout(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "daemon.sock")
+	for _, timeout := range []string{"0", "1ns", "999ms"} {
+		err := runDaemon([]string{"--socket", socket, "--idle-timeout", timeout})
+		assert.ErrorContains(t, err, "must be at least 1s", timeout)
+	}
+}
+
+func TestDaemonRequestKey(t *testing.T) {
+	request := daemonRequest{Workspace: "/w", Dir: "/d", Env: []string{"A=1", "B=2"}, Args: []string{"--lsp", "gopls"}}
+
+	reordered := request
+	reordered.Env = []string{"B=2", "A=1"}
+	assert.Equal(t, request.key(), reordered.key())
+
+	other := request
+	other.Env = []string{"A=1", "B=3"}
+	assert.NotEqual(t, request.key(), other.key())
+
+	moved := request
+	moved.Args = []string{"--lsp"}
+	moved.Env = []string{"gopls", "A=1", "B=2"}
+	assert.NotEqual(t, request.key(), moved.key())
+}
+
+func TestDaemonReusesWorkers(t *testing.T) {
+	d := newTestDaemon(t, time.Minute)
+	request := newTestRequest(t)
+
+	first, conn, err := d.acquire(request)
+	require.NoError(t, err)
+	_ = conn.Close()
+
+	// A concurrent session gets a worker of its own
+	second, conn, err := d.acquire(request)
+	require.NoError(t, err)
+	_ = conn.Close()
+	assert.NotSame(t, first, second)
+
+	// The next session is given an idle one
+	d.release(first)
+	reused, conn, err := d.acquire(request)
+	require.NoError(t, err)
+	_ = conn.Close()
+	assert.Same(t, first, reused)
+	d.release(first)
+	d.release(second)
+
+	// Other environments don't share it
+	other := request
+	other.Env = append(slices.Clone(request.Env), "GOFLAGS=-mod=mod")
+	third, conn, err := d.acquire(other)
+	require.NoError(t, err)
+	_ = conn.Close()
+	assert.NotSame(t, first, third)
+	assert.NotSame(t, second, third)
+	d.release(third)
+
+	// Neither does a worker that is being stopped
+	d.mu.Lock()
+	first.stopping = true
+	second.stopping = true
+	d.mu.Unlock()
+	fourth, conn, err := d.acquire(request)
+	require.NoError(t, err)
+	_ = conn.Close()
+	assert.NotSame(t, first, fourth)
+	assert.NotSame(t, second, fourth)
+	d.release(fourth)
+}
+
+func TestDaemonExpiresIdleWorkers(t *testing.T) {
+	timeout := 200 * time.Millisecond
+	d := newTestDaemon(t, timeout)
+	request := newTestRequest(t)
+
+	worker, conn, err := d.acquire(request)
+	require.NoError(t, err)
+	_ = conn.Close()
+	d.release(worker)
+
+	// A worker given a session again isn't stopped while it is busy
+	reused, conn, err := d.acquire(request)
+	require.NoError(t, err)
+	require.Same(t, worker, reused)
+	assert.False(t, exited(worker, 3*timeout))
+	_ = conn.Close()
+
+	d.release(worker)
+	require.True(t, exited(worker, 10*time.Second), "idle worker wasn't stopped")
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	assert.Empty(t, d.workers)
+}
+
+func TestDaemonSession(t *testing.T) {
+	d := newTestDaemon(t, time.Minute)
+	listener, err := listenSocket(filepath.Join(d.dir, "daemon.sock"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go d.handle(conn.(*net.UnixConn))
+		}
+	}()
+	request := newTestRequest(t)
+
+	session := func(input string) string {
+		conn, err := net.DialUnix("unix", nil, listener.Addr().(*net.UnixAddr))
+		require.NoError(t, err)
+		defer conn.Close()
+		var out bytes.Buffer
+		require.NoError(t, passSession(conn, request, strings.NewReader(input), &out))
+		return out.String()
+	}
+	idle := func() bool {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return d.sessions == 0
+	}
+
+	// The shim's session reaches a worker and its responses come back
+	assert.Equal(t, "first\n", session("first\n"))
+	require.Eventually(t, idle, 10*time.Second, 10*time.Millisecond)
+
+	// and the next session is served by the same worker
+	assert.Equal(t, "second\n", session("second\n"))
+	require.Eventually(t, idle, 10*time.Second, 10*time.Millisecond)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	assert.Len(t, d.workers[request.key()], 1)
+}
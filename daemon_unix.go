@@ -0,0 +1,30 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkSocketDir refuses a socket directory that isn't the user's own
+// directory with mode 0700. The default one is at a predictable path when
+// there is no XDG_RUNTIME_DIR, and another user who created it first could
+// serve the daemon's socket or reach its workers.
+func checkSocketDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	switch {
+	case !info.IsDir():
+		return fmt.Errorf("socket directory %s is not a directory", dir)
+	case !ok || int(stat.Uid) != os.Getuid():
+		return fmt.Errorf("socket directory %s is not owned by the user", dir)
+	case info.Mode().Perm() != 0700:
+		return fmt.Errorf("socket directory %s has mode %#o, it must be 0700", dir, info.Mode().Perm())
+	}
+	return nil
+}
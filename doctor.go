@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/git"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/runner"
+	"github.com/isaacphi/mcp-language-server/internal/trust"
+)
+
+func newDoctorFlags(cfg *config, timeout *int) *flag.FlagSet {
+	flags := newFlagSet("doctor")
+	addLSPFlags(flags, cfg)
+	flags.IntVar(timeout, "timeout", 30, "Seconds to wait for the language server to initialize")
+	return flags
+}
+
+// doctorReport prints check results and remembers whether any failed
+type doctorReport struct {
+	failed bool
+}
+
+func (r *doctorReport) ok(format string, args ...any) {
+	fmt.Printf("[ok]   "+format+"\n", args...)
+}
+
+func (r *doctorReport) warn(format string, args ...any) {
+	fmt.Printf("[warn] "+format+"\n", args...)
+}
+
+func (r *doctorReport) fail(format string, args ...any) {
+	r.failed = true
+	fmt.Printf("[FAIL] "+format+"\n", args...)
+}
+
+// runDoctor implements the doctor subcommand. It goes through the setup the
+// server depends on, starts the language server once and reports what it
+// supports, so configuration problems show up before an agent hits them.
+func runDoctor(args []string) error {
+	var timeout int
+	cfg, err := parseFlags(args, func(cfg *config) *flag.FlagSet { return newDoctorFlags(cfg, &timeout) })
+	if err != nil {
+		return err
+	}
+
+	report := &doctorReport{}
+	build := currentBuildInfo()
+	report.ok("mcp-language-server %s (commit %s, %s)", build.version, orUnknown(build.shortCommit()), build.goVersion)
+
+	if err := cfg.resolveWorkspace(); err != nil {
+		report.fail("Workspace: %v", err)
+		return fmt.Errorf("doctor found problems")
+	}
+	report.ok("Workspace: %s", cfg.workspaceDir)
+	if cfg.profile != "" {
+		report.ok("Profile: %s", cfg.profile)
+	}
+
+	ctx := context.Background()
+	if _, err := exec.LookPath("git"); err != nil {
+		report.warn("git not found, blame and change tools will not work")
+	} else if repo, err := git.Open(ctx, cfg.workspaceDir); err != nil {
+		report.warn("Workspace is not in a git repository, blame and change tools will not work")
+	} else {
+		report.ok("Git repository: %s", repo.Root())
+	}
+
+	if path, err := trust.DefaultPath(); err != nil {
+		report.warn("Trust store: %v", err)
+	} else if decision, err := trust.NewStore(path).Decision(cfg.workspaceDir); err != nil {
+		report.warn("Trust store: %v", err)
+	} else if decision == trust.Trusted {
+		report.ok("Workspace is trusted, tools that change files or run commands are enabled")
+	} else {
+		report.warn("Workspace is %s, tools that change files or run commands need approval or --trust-workspace", decision)
+	}
+
+	if command := runner.DetectTestCommand(cfg.workspaceDir); command != "" {
+		report.ok("Detected test command for --test-command auto: %s", command)
+	} else {
+		report.warn("No test command detected, --test-command auto will not work")
+	}
+	if command := runner.DetectBuildCommand(cfg.workspaceDir); command != "" {
+		report.ok("Detected build command for --build-command auto: %s", command)
+	} else {
+		report.warn("No build command detected, --build-command auto will not work")
+	}
+
+	if err := cfg.expandLSPOptions(); err != nil {
+		report.fail("Language server options: %v", err)
+		return fmt.Errorf("doctor found problems")
+	}
+	if cfg.lspCommand == "" && cfg.lspConnect == "" {
+		report.fail("No --lsp or --lsp-connect given, the server would run in fallback mode")
+		return fmt.Errorf("doctor found problems")
+	}
+	if cfg.lspCommand != "" {
+		executable := cfg.lspCommand
+		if len(cfg.lspRunnerArgs) > 0 {
+			executable = cfg.lspRunnerArgs[0]
+		}
+		path, err := cfg.processOptions().LookPath(executable)
+		if err != nil {
+			report.fail("Command not found: %s", executable)
+			return fmt.Errorf("doctor found problems")
+		}
+		report.ok("Command: %s", path)
+		_, args := cfg.processOptions().Command(cfg.lspCommand, cfg.lspArgs...)
+		if err := lsp.CheckExecutable(path, args, cfg.pinnedExecutables); err != nil {
+			report.fail("%v", err)
+			return fmt.Errorf("doctor found problems")
+		}
+	}
+	if cfg.sandboxPolicy != nil {
+		report.ok("Sandbox: no network, %d readable and %d writable paths", len(cfg.sandboxPolicy.Read), len(cfg.sandboxPolicy.Write))
+	}
+	for _, mapping := range cfg.pathMappings {
+		report.ok("Path mapping: %s -> %s", mapping.Local, mapping.Remote)
+	}
+	for _, warning := range lsp.CheckWorkspace(cfg.workspaceDir, cfg.lspCommand) {
+		report.warn("%s", warning)
+	}
+
+	checkLanguageServer(cfg, time.Duration(timeout)*time.Second, report)
+
+	if report.failed {
+		return fmt.Errorf("doctor found problems")
+	}
+	fmt.Println("\nNo problems found")
+	return nil
+}
+
+// checkLanguageServer starts and initializes the language server and lists
+// the capabilities that tools rely on
+func checkLanguageServer(cfg *config, timeout time.Duration, report *doctorReport) {
+	s, err := newServer(cfg)
+	if err != nil {
+		report.fail("%v", err)
+		return
+	}
+	defer cleanup(s, make(chan struct{}))
+
+	if err := os.Chdir(cfg.workspaceDir); err != nil {
+		report.fail("Failed to change to workspace directory: %v", err)
+		return
+	}
+	client, err := cfg.startLSPClient(s.ctx)
+	if err != nil {
+		report.fail("Failed to start language server: %v", err)
+		return
+	}
+	s.lspClient = client
+
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+	defer cancel()
+	started := time.Now()
+	result, err := client.InitializeLSPClient(ctx, cfg.workspaceDir)
+	if err != nil {
+		report.fail("Language server did not initialize: %v", err)
+		return
+	}
+	name := "Language server"
+	if result.ServerInfo != nil {
+		name = strings.TrimSpace(result.ServerInfo.Name + " " + result.ServerInfo.Version)
+	}
+	report.ok("%s initialized in %s", name, time.Since(started).Round(time.Millisecond))
+
+	caps := result.Capabilities
+	checks := []struct {
+		name      string
+		supported bool
+		tools     string
+	}{
+		{"workspace symbols", caps.WorkspaceSymbolProvider != nil, "definition, references, callers, context_for"},
+		{"definition", caps.DefinitionProvider != nil, "definition"},
+		{"references", caps.ReferencesProvider != nil, "references, usage_examples, call_sites, add_call_argument, find_unused_symbols, impact_of, context_for"},
+		{"hover", caps.HoverProvider != nil, "hover, documentation"},
+		{"rename", caps.RenameProvider != nil, "rename_symbol"},
+		{"call hierarchy", caps.CallHierarchyProvider != nil, "callers, callees, export_call_graph"},
+		{"code actions", caps.CodeActionProvider != nil, "extract_*, generate_code, inline_symbol, move_symbol"},
+		{"document symbols", caps.DocumentSymbolProvider != nil, "outline, content, check_interface, doc_coverage, code_metrics"},
+		{"implementation", caps.ImplementationProvider != nil, "check_interface"},
+		{"pull diagnostics", caps.DiagnosticProvider != nil, "diagnostics (push diagnostics are used otherwise)"},
+	}
+	for _, check := range checks {
+		if check.supported {
+			report.ok("Supports %s", check.name)
+		} else {
+			report.warn("No %s support, affects %s", check.name, check.tools)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// editingTools change files, so their edits are checked before they are
+// written, and post-edit hooks and, with --validate-edits, validation run
+// after
+var editingTools = map[string]bool{
+	"rename_symbol":     true,
+	"rename_package":    true,
+	"extract_function":  true,
+	"extract_variable":  true,
+	"generate_code":     true,
+	"inline_symbol":     true,
+	"move_symbol":       true,
+	"add_import":        true,
+	"rename_file":       true,
+	"organize_imports":  true,
+	"add_call_argument": true,
+}
+
+// forceArgument lets an editing tool delete more of a file than
+// --max-delete-percent allows
+const forceArgument = "force"
+
+// addForceArgument lists the editing tools with the force argument
+func addForceArgument(ctx context.Context, listed []mcp.Tool) []mcp.Tool {
+	if utilities.MaxDeletedPercent <= 0 {
+		return listed
+	}
+	result := make([]mcp.Tool, len(listed))
+	for i, tool := range listed {
+		if editingTools[tool.Name] && tool.RawInputSchema == nil {
+			properties := maps.Clone(tool.InputSchema.Properties)
+			if properties == nil {
+				properties = map[string]any{}
+			}
+			properties[forceArgument] = map[string]any{
+				"type":        "boolean",
+				"description": "Write the edits even if they delete most of a file. Only set it after a LARGE_DELETION error, when the deletion is intended.",
+			}
+			tool.InputSchema.Properties = properties
+		}
+		result[i] = tool
+	}
+	return result
+}
+
+// editGuardMiddleware forces the edits of editing tools called with force,
+// records them in the journal undo_edits reverts them from, runs the
+// post-edit hooks on the files they edited and, with --validate-edits, adds
+// the errors their edits introduce to their results. The journal entry and
+// validation take the hooks' changes in, so that a formatter's changes are
+// undone along with the edits and errors it fixes aren't reported.
+func (s *mcpServer) editGuardMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !editingTools[request.Params.Name] {
+			return next(ctx, request)
+		}
+		if request.GetBool(forceArgument, false) {
+			ctx = utilities.ForceEdits(ctx)
+		}
+		hooks := s.liveConfig.postEditHooks()
+		var validation *tools.EditValidation
+		if s.config.validateEdits && s.lspClient != nil {
+			validation = tools.NewEditValidation(s.lspClient)
+		}
+		record := s.edits.Record(request.Params.Name)
+		defer record.Finish()
+
+		var mu sync.Mutex
+		var edited []string
+		watched := utilities.WatchEdits(ctx, func(path string) {
+			mu.Lock()
+			if !slices.Contains(edited, path) {
+				edited = append(edited, path)
+			}
+			mu.Unlock()
+			record.Before(path)
+			if validation != nil {
+				validation.Before(path)
+			}
+		})
+		result, err := next(watched, request)
+		mu.Lock()
+		files := slices.Clone(edited)
+		mu.Unlock()
+		if result == nil || result.IsError || len(files) == 0 {
+			return result, err
+		}
+
+		if report := tools.RunPostEditHooks(ctx, s.lspClient, s.config.workspaceDir, hooks, files); report != "" {
+			result.Content = append(result.Content, mcp.NewTextContent(report))
+		}
+		if validation != nil {
+			if report := validation.Report(ctx); report != "" {
+				result.Content = append(result.Content, mcp.NewTextContent(report))
+			}
+		}
+		return result, err
+	}
+}
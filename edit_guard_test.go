@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newToolServer serves the tools through the middleware of the real server,
+// with lspServer as the language server of a trusted workspace
+func newToolServer(t *testing.T, lspServer *lsptest.Server, args ...string) *mcpServer {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	workspace, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	cfg, err := parseConfig(append([]string{"--workspace", workspace, "--lsp", "lsptest", "--trust-workspace"}, args...))
+	require.NoError(t, err)
+	s, err := newServer(cfg)
+	require.NoError(t, err)
+	t.Cleanup(s.cancelFunc)
+
+	defer func(percent int) {
+		t.Cleanup(func() { utilities.MaxDeletedPercent = percent })
+	}(utilities.MaxDeletedPercent)
+	utilities.MaxDeletedPercent = s.config.maxDeletePercent
+	s.liveConfig = newLiveConfig()
+	s.trust, err = newWorkspaceTrust(workspace, true)
+	require.NoError(t, err)
+	s.lspClient = lsptest.Start(t, lspServer, workspace)
+
+	options, err := s.serverOptions()
+	require.NoError(t, err)
+	s.mcpServer = server.NewMCPServer("test", "1", options...)
+	s.trust.mcpServer = s.mcpServer
+	s.mcpServer.AddNotificationHandler("notifications/cancelled", s.calls.cancelled)
+	require.NoError(t, s.registerTools())
+	return s
+}
+
+// renameServer answers renames by replacing the whole document with one line
+func renameServer() *lsptest.Server {
+	lspServer := lsptest.NewServer(protocol.ServerCapabilities{RenameProvider: true})
+	lspServer.Handle("textDocument/rename", func(params json.RawMessage) (any, error) {
+		var rename protocol.RenameParams
+		if err := json.Unmarshal(params, &rename); err != nil {
+			return nil, err
+		}
+		return protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			rename.TextDocument.URI: {{
+				Range:   protocol.Range{End: protocol.Position{Line: 40}},
+				NewText: rename.NewName + "\n",
+			}},
+		}}, nil
+	})
+	return lspServer
+}
+
+func TestForcedEdit(t *testing.T) {
+	s := newToolServer(t, renameServer())
+	file := filepath.Join(s.config.workspaceDir, "main.go")
+	content := strings.Repeat("line\n", 40)
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	arguments := map[string]any{"filePath": file, "line": 1, "column": 1, "newName": "renamed"}
+
+	// Without force the edit deletes too much of the file
+	result := callTest(t, s, "rename_symbol", arguments)
+	assert.True(t, result.IsError)
+	assert.Equal(t, "LARGE_DELETION", result.Meta[errorCodeKey])
+	written, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(written))
+
+	arguments[forceArgument] = true
+	result = callTest(t, s, "rename_symbol", arguments)
+	require.False(t, result.IsError, resultText(result))
+	written, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed\n", string(written))
+}
+
+func TestPostEditHooksRun(t *testing.T) {
+	s := newToolServer(t, renameServer())
+	s.liveConfig.current.PostEditHooks = []tools.PostEditHook{
+		{Glob: "*.go", Command: "echo hooked {files}"},
+		{Glob: "*.ts", Command: "echo never"},
+	}
+	file := filepath.Join(s.config.workspaceDir, "main.go")
+	require.NoError(t, os.WriteFile(file, []byte("package main\n"), 0644))
+
+	result := callTest(t, s, "rename_symbol", map[string]any{"filePath": file, "line": 1, "column": 1, "newName": "renamed"})
+	require.False(t, result.IsError, resultText(result))
+	texts := resultText(result)
+	require.Len(t, texts, 2)
+	assert.Contains(t, texts[0], "Successfully renamed symbol to 'renamed'")
+	assert.Contains(t, texts[1], "Post-edit hooks:\necho hooked {files}: passed\nhooked main.go\n")
+	assert.NotContains(t, texts[1], "never")
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// elicitationIDPrefix marks the IDs of elicitation requests, keeping them
+// apart from the numeric IDs of sampling requests sent by the MCP library
+const elicitationIDPrefix = "elicitation-"
+
+// stdioElicitor asks the client questions with elicitation/create requests.
+// The MCP library doesn't support elicitation, so it sits between the
+// library's stdio server and the real stdin and stdout: requests are written
+// to stdout alongside the server's messages, and responses to them are taken
+// out of stdin before the server sees them.
+type stdioElicitor struct {
+	// in is what the MCP server reads instead of stdin
+	in *io.PipeReader
+	// out is shared by the MCP server and the elicitor so that their
+	// messages aren't interleaved
+	out *lockedWriter
+
+	supported atomic.Bool
+	nextID    atomic.Int64
+	pendingMu sync.Mutex
+	pending   map[string]chan elicitationResponse
+}
+
+type elicitationResponse struct {
+	Result *struct {
+		Action  string         `json:"action"`
+		Content map[string]any `json:"content"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// newStdioElicitor starts reading stdin. The MCP server must read from
+// e.in and write to e.out.
+func newStdioElicitor(stdin io.Reader, stdout io.Writer) *stdioElicitor {
+	in, pipe := io.Pipe()
+	e := &stdioElicitor{
+		in:      in,
+		out:     &lockedWriter{w: stdout},
+		pending: make(map[string]chan elicitationResponse),
+	}
+	go e.read(stdin, pipe)
+	return e
+}
+
+// read passes stdin on to the MCP server, except for responses to
+// elicitation requests. The client's capabilities are noted on the way.
+func (e *stdioElicitor) read(stdin io.Reader, pipe *io.PipeWriter) {
+	reader := bufio.NewReader(stdin)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && !e.intercept(line) {
+			if _, err := pipe.Write(line); err != nil {
+				return
+			}
+		}
+		if err != nil {
+			_ = pipe.CloseWithError(err)
+			return
+		}
+	}
+}
+
+// intercept handles a message meant for the elicitor and reports whether it
+// was one
+func (e *stdioElicitor) intercept(line []byte) bool {
+	var message struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params struct {
+			Capabilities struct {
+				Elicitation json.RawMessage `json:"elicitation"`
+			} `json:"capabilities"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(line, &message); err != nil {
+		return false
+	}
+	if message.Method == "initialize" {
+		e.supported.Store(len(message.Params.Capabilities.Elicitation) > 0)
+		return false
+	}
+
+	var id string
+	if message.Method != "" || json.Unmarshal(message.ID, &id) != nil || !strings.HasPrefix(id, elicitationIDPrefix) {
+		return false
+	}
+	var response elicitationResponse
+	if err := json.Unmarshal(line, &response); err != nil {
+		coreLogger.Error("Invalid elicitation response: %v", err)
+	}
+	e.pendingMu.Lock()
+	ch, ok := e.pending[id]
+	delete(e.pending, id)
+	e.pendingMu.Unlock()
+	if ok {
+		ch <- response
+	}
+	return true
+}
+
+// notify writes a notification to the client directly, rather than through
+// the MCP library's notification queue, so that it arrives before the
+// response to the request that sent it and blocks instead of being dropped
+// when the client falls behind
+func (e *stdioElicitor) notify(method string, params map[string]any) error {
+	notification, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = e.out.Write(append(notification, '\n'))
+	return err
+}
+
+// choose asks the user to pick one of the options with a single-select
+// form. It implements tools.Chooser.
+func (e *stdioElicitor) choose(ctx context.Context, question string, options []string) (int, error) {
+	// Options are answered by number, as their labels may repeat
+	values := make([]string, len(options))
+	for i := range options {
+		values[i] = strconv.Itoa(i + 1)
+	}
+	choice, err := e.elicitEnum(ctx, question, "Choice", values, options)
+	if err != nil {
+		return -1, err
+	}
+	i, err := strconv.Atoi(choice)
+	if err != nil || i < 1 || i > len(options) {
+		return -1, fmt.Errorf("invalid choice %q", choice)
+	}
+	return i - 1, nil
+}
+
+// confirm asks the user a yes or no question with a single-select form and
+// returns the value picked, "yes" or "no"
+func (e *stdioElicitor) confirm(ctx context.Context, question, yes, no string) (string, error) {
+	return e.elicitEnum(ctx, question, "Answer", []string{"yes", "no"}, []string{yes, no})
+}
+
+// elicitEnum sends an elicitation/create request for a form with a single
+// required choice and returns the value picked
+func (e *stdioElicitor) elicitEnum(ctx context.Context, question, title string, values, names []string) (string, error) {
+	if !e.supported.Load() {
+		return "", tools.ErrCannotAsk
+	}
+
+	id := fmt.Sprintf("%s%d", elicitationIDPrefix, e.nextID.Add(1))
+	request, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  "elicitation/create",
+		"params": map[string]any{
+			"message": question,
+			"requestedSchema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"choice": map[string]any{
+						"type":      "string",
+						"title":     title,
+						"enum":      values,
+						"enumNames": names,
+					},
+				},
+				"required": []string{"choice"},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	ch := make(chan elicitationResponse, 1)
+	e.pendingMu.Lock()
+	e.pending[id] = ch
+	e.pendingMu.Unlock()
+	defer func() {
+		e.pendingMu.Lock()
+		delete(e.pending, id)
+		e.pendingMu.Unlock()
+	}()
+
+	if _, err := e.out.Write(append(request, '\n')); err != nil {
+		return "", fmt.Errorf("failed to send elicitation request: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case response := <-ch:
+		switch {
+		case response.Error != nil:
+			return "", fmt.Errorf("elicitation failed: %s", response.Error.Message)
+		case response.Result == nil || response.Result.Action != "accept":
+			return "", tools.ErrNotChosen
+		}
+		choice, _ := response.Result.Content["choice"].(string)
+		return choice, nil
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sessionClient is the client end of a session served with serveSession
+type sessionClient struct {
+	t        *testing.T
+	in       *io.PipeWriter
+	messages chan map[string]any
+}
+
+func startSession(t *testing.T, s *mcpServer) *sessionClient {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = s.serveSession(ctx, inReader, outWriter)
+		outWriter.Close()
+	}()
+	t.Cleanup(func() {
+		cancel()
+		inWriter.Close()
+		<-done
+	})
+
+	c := &sessionClient{t: t, in: inWriter, messages: make(chan map[string]any, 10)}
+	go func() {
+		scanner := bufio.NewScanner(outReader)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			var message map[string]any
+			if json.Unmarshal(scanner.Bytes(), &message) == nil {
+				c.messages <- message
+			}
+		}
+	}()
+	return c
+}
+
+func (c *sessionClient) send(message map[string]any) {
+	c.t.Helper()
+	message["jsonrpc"] = "2.0"
+	data, err := json.Marshal(message)
+	require.NoError(c.t, err)
+	_, err = c.in.Write(append(data, '\n'))
+	require.NoError(c.t, err)
+}
+
+func (c *sessionClient) next() map[string]any {
+	c.t.Helper()
+	select {
+	case message := <-c.messages:
+		return message
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("No message from the server")
+		return nil
+	}
+}
+
+func TestSessionChooser(t *testing.T) {
+	lspServer := lsptest.NewServer(protocol.ServerCapabilities{CodeActionProvider: true})
+	s := newToolServer(t, lspServer)
+	file := filepath.Join(s.config.workspaceDir, "main.go")
+	require.NoError(t, os.WriteFile(file, []byte("x := a()\n"), 0644))
+	inline := func(title, text string) protocol.CodeAction {
+		return protocol.CodeAction{Title: title, Kind: protocol.RefactorInline, Edit: &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentUri][]protocol.TextEdit{protocol.URIFromPath(file): {{
+				Range:   protocol.Range{End: protocol.Position{Line: 1}},
+				NewText: text,
+			}}},
+		}}
+	}
+	lspServer.Respond("textDocument/codeAction", []protocol.CodeAction{
+		inline("Inline call to a", "x := 1\n"),
+		inline("Inline variable x", "_ = a()\n"),
+	})
+
+	client := startSession(t, s)
+	client.send(map[string]any{"id": 1, "method": "initialize", "params": map[string]any{
+		"protocolVersion": "2025-03-26",
+		"capabilities":    map[string]any{"elicitation": map[string]any{}},
+		"clientInfo":      map[string]any{"name": "test", "version": "1"},
+	}})
+	assert.Contains(t, client.next(), "result")
+	client.send(map[string]any{"method": "notifications/initialized"})
+
+	client.send(map[string]any{"id": 2, "method": "tools/call", "params": map[string]any{
+		"name":      "inline_symbol",
+		"arguments": map[string]any{"filePath": file, "line": 1, "column": 6},
+	}})
+	// The session's own client is asked which refactoring to apply
+	request := client.next()
+	require.Equal(t, "elicitation/create", request["method"], request)
+	params, _ := request["params"].(map[string]any)
+	assert.Contains(t, params["message"], "Several inline refactorings are available")
+	client.send(map[string]any{"id": request["id"], "result": map[string]any{
+		"action":  "accept",
+		"content": map[string]any{"choice": "2"},
+	}})
+
+	response := client.next()
+	require.Contains(t, response, "result", response)
+	written, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "_ = a()\n", string(written))
+}
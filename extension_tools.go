@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+
+	"github.com/isaacphi/mcp-language-server/internal/i18n"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// serverIs reports whether the language server is the one named, going by
+// the command that started it and the name it reports
+func (s *mcpServer) serverIs(name string) bool {
+	if s.config.lspCommand != "" && lsp.ServerName(s.config.lspCommand) == name {
+		return true
+	}
+	return s.lspInfo != nil && lsp.ServerName(s.lspInfo.Name) == name
+}
+
+// registerExtensionTools registers the tools built on the extensions of a
+// particular language server, when that is the server running
+func (s *mcpServer) registerExtensionTools() {
+	if s.serverIs("clangd") {
+		s.registerClangdTools()
+	}
+	if s.serverIs("gopls") {
+		s.registerGoplsTools()
+	}
+	if s.serverIs("rust-analyzer") {
+		s.registerRustAnalyzerTools()
+	}
+	if s.serverIs("typescript-language-server") {
+		s.registerTypeScriptTools()
+	}
+}
+
+func (s *mcpServer) registerClangdTools() {
+	switchSourceHeaderTool := mcp.NewTool("switch_source_header",
+		mcp.WithDescription("Find the header of a C or C++ source file, or the source file that implements a header, using clangd's index. Falls back to files with the same name when clangd doesn't know."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The source file or header to switch from"),
+		),
+	)
+
+	s.mcpServer.AddTool(switchSourceHeaderTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing switch_source_header for file: %s", filePath)
+		text, err := tools.SwitchSourceHeader(ctx, s.lspClient, s.config.workspaceDir, filePath)
+		if err != nil {
+			coreLogger.Error("Failed to switch between source and header: %v", err)
+			return toolError("failed to switch between source and header", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+}
+
+func (s *mcpServer) registerGoplsTools() {
+	listKnownPackagesTool := mcp.NewTool("list_known_packages",
+		mcp.WithDescription("List the Go packages a file can import: the standard library, the module's own packages and its dependencies. Pass query to find the package that provides something, e.g. \"yaml\"."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The Go file that would import the package"),
+		),
+		mcp.WithString("query",
+			mcp.Description("Only list import paths containing this text, ignoring case"),
+		),
+	)
+
+	s.mcpServer.AddTool(listKnownPackagesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+		query := request.GetString("query", "")
+
+		coreLogger.Debug("Executing list_known_packages for file: %s query: %s", filePath, query)
+		text, err := tools.ListKnownPackages(ctx, s.lspClient, filePath, query)
+		if err != nil {
+			coreLogger.Error("Failed to list known packages: %v", err)
+			return toolError("failed to list known packages", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	addImportTool := mcp.NewTool("add_import",
+		mcp.WithDescription("Add an import to a Go file with gopls, placed and grouped as goimports would, and return the diff. Use list_known_packages to find the import path."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The Go file to add the import to"),
+		),
+		mcp.WithString("importPath",
+			mcp.Required(),
+			mcp.Description("The import path of the package, e.g. \"net/http\""),
+		),
+	)
+
+	s.mcpServer.AddTool(addImportTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+		importPath, err := request.RequireString("importPath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing add_import for file: %s import: %s", filePath, importPath)
+		text, err := tools.AddImport(ctx, s.lspClient, filePath, importPath)
+		if err != nil {
+			coreLogger.Error("Failed to add import: %v", err)
+			return toolError("failed to add import", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+}
+
+func (s *mcpServer) registerRustAnalyzerTools() {
+	expandMacroTool := mcp.NewTool("expand_macro",
+		mcp.WithDescription("Show what the Rust macro call at a position expands to, with the macro calls in the expansion expanded too."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the macro call"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number of the macro call (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number of the macro name (1-indexed)"),
+		),
+	)
+
+	s.mcpServer.AddTool(expandMacroTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return argumentError(err), nil
+		}
+		column, err := request.RequireInt("column")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing expand_macro for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.ExpandMacro(ctx, s.lspClient, filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to expand macro: %v", err)
+			return toolError("failed to expand macro", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	viewSyntaxTreeTool := mcp.NewTool("view_syntax_tree",
+		mcp.WithDescription("Show the syntax tree rust-analyzer parsed a Rust file into, with the kind and text range of every node and token."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the Rust file"),
+		),
+	)
+
+	s.mcpServer.AddTool(viewSyntaxTreeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing view_syntax_tree for file: %s", filePath)
+		text, err := tools.ViewSyntaxTree(ctx, s.lspClient, filePath)
+		if err != nil {
+			coreLogger.Error("Failed to view syntax tree: %v", err)
+			return toolError("failed to view syntax tree", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	viewIRTool := mcp.NewTool("view_ir",
+		mcp.WithDescription("Show the HIR or MIR rust-analyzer lowers the Rust function at a position to, e.g. to see desugared loops, method resolution or where values are moved and dropped."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the function"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("A line in the function (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("A column on that line (1-indexed)"),
+		),
+		mcp.WithString("kind",
+			mcp.Description("The representation to show"),
+			mcp.Enum("hir", "mir"),
+			mcp.DefaultString("hir"),
+		),
+	)
+
+	s.mcpServer.AddTool(viewIRTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return argumentError(err), nil
+		}
+		column, err := request.RequireInt("column")
+		if err != nil {
+			return argumentError(err), nil
+		}
+		kind := request.GetString("kind", "hir")
+
+		coreLogger.Debug("Executing view_ir for file: %s line: %d column: %d kind: %s", filePath, line, column, kind)
+		text, err := tools.ViewIR(ctx, s.lspClient, filePath, line, column, kind)
+		if err != nil {
+			coreLogger.Error("Failed to view %s: %v", kind, err)
+			return toolError(i18n.Sprintf("failed to view %s", kind), err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+}
+
+func (s *mcpServer) registerTypeScriptTools() {
+	renameFileTool := mcp.NewTool("rename_file",
+		mcp.WithDescription("Move or rename a TypeScript or JavaScript file and update every import specifier that refers to it, as well as the file's own relative imports, and return the diff."),
+		mcp.WithString("oldPath",
+			mcp.Required(),
+			mcp.Description("The file to move, absolute or relative to the workspace"),
+		),
+		mcp.WithString("newPath",
+			mcp.Required(),
+			mcp.Description("The new path of the file, absolute or relative to the workspace. It must not exist yet."),
+		),
+	)
+
+	s.mcpServer.AddTool(renameFileTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		oldPath, err := request.RequireString("oldPath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+		newPath, err := request.RequireString("newPath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing rename_file from: %s to: %s", oldPath, newPath)
+		text, err := tools.RenameTypeScriptFile(ctx, s.lspClient, s.config.workspaceDir, oldPath, newPath)
+		if err != nil {
+			coreLogger.Error("Failed to rename file: %v", err)
+			return toolError("failed to rename file", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	organizeImportsTool := mcp.NewTool("organize_imports",
+		mcp.WithDescription("Sort and merge the imports of a TypeScript or JavaScript file and remove the unused ones, then return the diff."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The file whose imports to organize"),
+		),
+		mcp.WithBoolean("keepUnused",
+			mcp.Description("Only sort and merge imports, keeping unused ones (default: false)"),
+		),
+	)
+
+	s.mcpServer.AddTool(organizeImportsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+		keepUnused := request.GetBool("keepUnused", false)
+
+		coreLogger.Debug("Executing organize_imports for file: %s", filePath)
+		text, err := tools.OrganizeTypeScriptImports(ctx, s.lspClient, filePath, keepUnused)
+		if err != nil {
+			coreLogger.Error("Failed to organize imports: %v", err)
+			return toolError("failed to organize imports", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// registerFallbackTools registers the tools that work without a language server.
+// Results are based on text heuristics and are labeled as such.
+func (s *mcpServer) registerFallbackTools() error {
+	coreLogger.Debug("Registering fallback MCP tools")
+
+	readDefinitionTool := mcp.NewTool("definition",
+		mcp.WithDescription("Find the source code definition of a symbol (function, type, constant, etc.) using text heuristics. No language server is running, so results are not semantic and may be incomplete."),
+		mcp.WithString("symbolName",
+			mcp.Required(),
+			mcp.Description("The name of the symbol whose definition you want to find (e.g. 'MyFunction', 'MyType.MyMethod')"),
+		),
+	)
+
+	s.mcpServer.AddTool(readDefinitionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		symbolName, err := request.RequireString("symbolName")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing fallback definition for symbol: %s", symbolName)
+		text, err := tools.FallbackReadDefinition(ctx, s.config.workspaceDir, symbolName)
+		if err != nil {
+			coreLogger.Error("Failed to get definition: %v", err)
+			return toolError("failed to get definition", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	findReferencesTool := mcp.NewTool("references",
+		mcp.WithDescription("Find whole-word occurrences of a symbol name throughout the codebase. No language server is running, so this is a text search and may include unrelated matches."),
+		mcp.WithString("symbolName",
+			mcp.Required(),
+			mcp.Description("The name of the symbol to search for (e.g. 'MyFunction', 'MyType')"),
+		),
+	)
+
+	s.mcpServer.AddTool(findReferencesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		symbolName, err := request.RequireString("symbolName")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing fallback references for symbol: %s", symbolName)
+		text, err := tools.FallbackFindReferences(ctx, s.config.workspaceDir, symbolName)
+		if err != nil {
+			coreLogger.Error("Failed to find references: %v", err)
+			return toolError("failed to find references", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	outlineTool := mcp.NewTool("outline",
+		mcp.WithDescription("List the declarations (functions, types, classes, etc.) in a file using text heuristics."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file"),
+		),
+	)
+
+	s.mcpServer.AddTool(outlineTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing outline for file: %s", filePath)
+		text, err := tools.GetOutline(filePath)
+		if err != nil {
+			coreLogger.Error("Failed to get outline: %v", err)
+			return toolError("failed to get outline", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	searchTool := mcp.NewTool("search",
+		mcp.WithDescription("Search the workspace for lines matching a string or regular expression. Files excluded by .gitignore are skipped."),
+		mcp.WithString("pattern",
+			mcp.Required(),
+			mcp.Description("The text or regular expression to search for"),
+		),
+		mcp.WithBoolean("regex",
+			mcp.Description("If true, pattern is treated as a Go regular expression"),
+			mcp.DefaultBool(false),
+		),
+	)
+
+	s.mcpServer.AddTool(searchTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		pattern, err := request.RequireString("pattern")
+		if err != nil {
+			return argumentError(err), nil
+		}
+		isRegex := request.GetBool("regex", false)
+
+		coreLogger.Debug("Executing search for pattern: %s", pattern)
+		text, err := tools.SearchWorkspace(ctx, s.config.workspaceDir, pattern, isRegex, s.resultStream(ctx, request))
+		if err != nil {
+			coreLogger.Error("Failed to search workspace: %v", err)
+			return toolError("failed to search", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	importGraphTool := mcp.NewTool("import_graph",
+		mcp.WithDescription("Compute the package import graph of the workspace by parsing Go, Python, JavaScript/TypeScript, C/C++ and Rust imports. Returns a summary and the graph as JSON. Set package to see what a single package imports and what imports it."),
+		mcp.WithString("package",
+			mcp.Description("Optional package to report on (e.g. 'internal/lsp', 'myapp.models', 'react'). External dependencies are accepted too."),
+		),
+	)
+	s.mcpServer.AddTool(importGraphTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		packageName := request.GetString("package", "")
+
+		coreLogger.Debug("Executing import_graph for package: %s", packageName)
+		text, err := tools.GetImportGraph(ctx, s.config.workspaceDir, packageName)
+		if err != nil {
+			coreLogger.Error("Failed to get import graph: %v", err)
+			return toolError("failed to get import graph", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	workspaceStatsTool := mcp.NewTool("workspace_stats",
+		mcp.WithDescription("Summarize the workspace cheaply: files and lines per language, top-level declarations per directory, the largest files and functions, and diagnostic counts. Declarations are found by pattern matching, so this is fast even on large workspaces. Call it at the start of a task to get oriented."),
+	)
+	s.mcpServer.AddTool(workspaceStatsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		coreLogger.Debug("Executing workspace_stats")
+		text, err := tools.GetWorkspaceStats(ctx, nil, s.config.workspaceDir)
+		if err != nil {
+			coreLogger.Error("Failed to get workspace statistics: %v", err)
+			return toolError("failed to get workspace statistics", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	contentTool := mcp.NewTool("content",
+		mcp.WithDescription("Read the declaration enclosing the specified location using text heuristics."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number where the content is requested (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number where the content is requested (1-indexed)"),
+		),
+	)
+
+	s.mcpServer.AddTool(contentTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		column, err := request.RequireInt("column")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing fallback content for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.FallbackGetContentInfo(filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to get content information: %v", err)
+			return toolError("failed to get content", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	coreLogger.Info("Successfully registered fallback MCP tools")
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/isaacphi/mcp-language-server/internal/index"
+)
+
+func newIndexFlags(cfg *config, output *string) *flag.FlagSet {
+	flags := newFlagSet("index")
+	addLSPFlags(flags, cfg)
+	flags.StringVar(output, "output", "dump.lsif", "Path of the LSIF dump to write, or - for stdout")
+	return flags
+}
+
+// runIndex implements the index subcommand, which writes an LSIF dump of the
+// workspace using the same language server plumbing as the MCP tools
+func runIndex(args []string) error {
+	var output string
+	cfg, err := parseFlags(args, func(cfg *config) *flag.FlagSet { return newIndexFlags(cfg, &output) })
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.resolveWorkspace(); err != nil {
+		return err
+	}
+	if cfg.lspCommand == "" && cfg.lspConnect == "" {
+		return fmt.Errorf("LSP command or --lsp-connect is required")
+	}
+	if err := cfg.expandLSPOptions(); err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if output != "-" {
+		var err error
+		out, err = os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %v", err)
+		}
+		defer func() {
+			if err := out.Close(); err != nil {
+				coreLogger.Error("Failed to close output file: %v", err)
+			}
+		}()
+	}
+
+	s, err := newServer(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup(s, make(chan struct{}))
+
+	if err := os.Chdir(cfg.workspaceDir); err != nil {
+		return fmt.Errorf("failed to change to workspace directory: %v", err)
+	}
+	client, err := cfg.startLSPClient(s.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create LSP client: %v", err)
+	}
+	s.lspClient = client
+
+	if _, err := client.InitializeLSPClient(s.ctx, cfg.workspaceDir); err != nil {
+		return fmt.Errorf("initialize failed: %v", err)
+	}
+	if err := client.WaitForServerReady(s.ctx); err != nil {
+		return err
+	}
+
+	return index.NewIndexer(client, cfg.workspaceDir, out, "mcp-language-server", version).Run(s.ctx)
+}
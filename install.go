@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func newInstallFlags(name, configFile *string, force *bool) *flag.FlagSet {
+	flags := newFlagSet("install")
+	flags.StringVar(name, "name", "language-server", "Name of the server entry")
+	flags.StringVar(configFile, "config", "", "MCP client configuration file to add the entry to, e.g. claude_desktop_config.json. The entry is printed if empty")
+	flags.BoolVar(force, "force", false, "Replace an existing entry with the same name")
+	return flags
+}
+
+// runInstall implements the install subcommand, which writes the MCP client
+// configuration that starts this binary with the given serve flags
+func runInstall(args []string) error {
+	var name, configFile string
+	var force bool
+	flags := newInstallFlags(&name, &configFile, &force)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	serveArgs := flags.Args()
+
+	// Check the serve flags now rather than when the client starts the server
+	cfg, err := parseConfig(serveArgs)
+	if err != nil {
+		return fmt.Errorf("invalid serve flags: %v", err)
+	}
+	serveArgs = withWorkspace(serveArgs, cfg.workspaceDir)
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find this executable: %v", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+	entry := map[string]any{
+		"command": executable,
+		"args":    serveArgs,
+	}
+
+	if configFile == "" {
+		data, err := json.MarshalIndent(map[string]any{"mcpServers": map[string]any{name: entry}}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if err := addServerEntry(configFile, name, entry, force); err != nil {
+		return err
+	}
+	fmt.Printf("Added %s to %s. Restart the MCP client to load it.\n", name, configFile)
+	return nil
+}
+
+// withWorkspace replaces the --workspace value in serve arguments with its
+// absolute path, since clients start servers from an unknown directory
+func withWorkspace(args []string, workspaceDir string) []string {
+	result := append([]string{}, args...)
+	for i := 0; i < len(result); i++ {
+		arg := result[i]
+		if arg == "--" {
+			break
+		}
+		flagName, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || flagName != "workspace" {
+			continue
+		}
+		if hasValue {
+			result[i] = "--workspace=" + workspaceDir
+		} else if i+1 < len(result) {
+			result[i+1] = workspaceDir
+			i++
+		}
+	}
+	return result
+}
+
+// addServerEntry adds a server under mcpServers in a client configuration
+// file, keeping everything else in it. The file is created if needed.
+func addServerEntry(path, name string, entry map[string]any, force bool) error {
+	settings := make(map[string]any)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+	case os.IsNotExist(err):
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	servers, _ := settings["mcpServers"].(map[string]any)
+	if servers == nil {
+		servers = make(map[string]any)
+	}
+	if _, exists := servers[name]; exists && !force {
+		return fmt.Errorf("%s already has a server named %s, choose another --name or pass --force", path, name)
+	}
+	servers[name] = entry
+	settings["mcpServers"] = servers
+
+	data, err = json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,60 @@
+package diagnostics_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/bash/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// TestDiagnostics tests that ShellCheck's warnings are reported for
+// scripts recognized by their #! line
+func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		file       string
+		expected   string
+		unexpected string
+	}{
+		{
+			// The unquoted $TARGET
+			name:     "UnquotedVariable",
+			file:     "bin/deploy",
+			expected: "SC2086",
+		},
+		{
+			name:       "CleanScript",
+			file:       "lib/log.sh",
+			unexpected: "SC2086",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := internal.GetTestSuite(t)
+
+			ctx, cancel := context.WithTimeout(suite.Context, 20*time.Second)
+			defer cancel()
+
+			filePath := filepath.Join(suite.WorkspaceDir, tt.file)
+			result, err := tools.GetDiagnosticsForFile(ctx, suite.Client, filePath, 2, true)
+			if err != nil {
+				t.Fatalf("GetDiagnosticsForFile failed: %v", err)
+			}
+			if tt.expected != "" && !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected diagnostics to contain %q, got: %s", tt.expected, result)
+			}
+			if tt.unexpected != "" && strings.Contains(result, tt.unexpected) {
+				t.Errorf("Expected diagnostics not to contain %q, got: %s", tt.unexpected, result)
+			}
+		})
+	}
+}
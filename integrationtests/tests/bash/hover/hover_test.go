@@ -0,0 +1,33 @@
+package hover_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/bash/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// TestHover tests that a call to a function in a script without an
+// extension, recognized by its #! line, shows the function's comment
+func TestHover(t *testing.T) {
+	t.Parallel()
+
+	suite := internal.GetTestSuite(t)
+
+	ctx, cancel := context.WithTimeout(suite.Context, 20*time.Second)
+	defer cancel()
+
+	// copy_build where it is called
+	filePath := filepath.Join(suite.WorkspaceDir, "bin/deploy")
+	result, err := tools.GetHoverInfo(ctx, suite.Client, filePath, 14, 1)
+	if err != nil {
+		t.Fatalf("GetHoverInfo failed: %v", err)
+	}
+	if !strings.Contains(result, "Copies the build to the target directory") {
+		t.Errorf("Expected the function's comment, got: %s", result)
+	}
+}
@@ -8,14 +8,16 @@ import (
 	"time"
 
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/clangd/internal"
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestReadDefinition tests the ReadDefinition tool with various C++ type definitions
 func TestReadDefinition(t *testing.T) {
+	t.Parallel()
+
 	// Helper function to open all files and wait for indexing
-	openAllFilesAndWait := func(suite *common.TestSuite, ctx context.Context) {
+	openAllFilesAndWait := func(suite *testharness.TestSuite, ctx context.Context) {
 		// Open one file so that clangd loads compiles commands and begins indexing
 		filesToOpen := []string{
 			"src/main.cpp",
@@ -111,14 +113,16 @@ func TestReadDefinition(t *testing.T) {
 			}
 
 			// Use snapshot testing to verify exact output
-			common.SnapshotTest(t, "clangd", "definition", tc.snapshotName, result)
+			testharness.SnapshotTest(t, "clangd", "definition", tc.snapshotName, result)
 		})
 	}
 }
 
 func TestReadDefinitionInAnotherFile(t *testing.T) {
+	t.Parallel()
+
 	// Helper function to open all files and wait for indexing
-	openAllFilesAndWait := func(suite *common.TestSuite, ctx context.Context) {
+	openAllFilesAndWait := func(suite *testharness.TestSuite, ctx context.Context) {
 		// Open all files to ensure clangd indexes everything
 		filesToOpen := []string{
 			"src/main.cpp",
@@ -171,7 +175,7 @@ func TestReadDefinitionInAnotherFile(t *testing.T) {
 			}
 
 			// Use snapshot testing to verify exact output
-			common.SnapshotTest(t, "clangd", "definition", tc.snapshotName, result)
+			testharness.SnapshotTest(t, "clangd", "definition", tc.snapshotName, result)
 		})
 	}
 }
@@ -10,14 +10,16 @@ import (
 	"time"
 
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/clangd/internal"
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestDiagnostics tests diagnostics functionality with the Clangd language server
 func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+
 	// Helper function to open all files and wait for indexing
-	openAllFilesAndWait := func(suite *common.TestSuite, ctx context.Context) {
+	openAllFilesAndWait := func(suite *testharness.TestSuite, ctx context.Context) {
 		// Open one file so that clangd loads compiles commands and begins indexing
 		filesToOpen := []string{
 			"src/main.cpp",
@@ -38,6 +40,8 @@ func TestDiagnostics(t *testing.T) {
 
 	// Test with a clean file
 	t.Run("CleanFile", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -58,11 +62,13 @@ func TestDiagnostics(t *testing.T) {
 			t.Errorf("Expected no diagnostics but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "clangd", "diagnostics", "clean", result)
+		testharness.SnapshotTest(t, "clangd", "diagnostics", "clean", result)
 	})
 
 	// Test with a file containing an error
 	t.Run("FileWithError", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with code that contains errors
 		suite := internal.GetTestSuite(t)
 
@@ -87,6 +93,6 @@ func TestDiagnostics(t *testing.T) {
 			t.Errorf("Expected unreachable code error but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "clangd", "diagnostics", "unreachable", result)
+		testharness.SnapshotTest(t, "clangd", "diagnostics", "unreachable", result)
 	})
 }
@@ -8,14 +8,16 @@ import (
 	"time"
 
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/clangd/internal"
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestHover tests hover functionality with the Clangd language server
 func TestHover(t *testing.T) {
+	t.Parallel()
+
 	// Helper function to open all files and wait for indexing
-	openAllFilesAndWait := func(suite *common.TestSuite, ctx context.Context) {
+	openAllFilesAndWait := func(suite *testharness.TestSuite, ctx context.Context) {
 		// Open one file so that clangd loads compiles commands and begins indexing
 		filesToOpen := []string{
 			"src/main.cpp",
@@ -105,6 +107,8 @@ func TestHover(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
 			// Get a test suite
 			suite := internal.GetTestSuite(t)
 
@@ -128,7 +132,7 @@ func TestHover(t *testing.T) {
 					} else if result != "" {
 						snapshotContent = result
 					}
-					common.SnapshotTest(t, "clangd", "hover", tt.snapshotName, snapshotContent)
+					testharness.SnapshotTest(t, "clangd", "hover", tt.snapshotName, snapshotContent)
 					return
 				}
 				t.Fatalf("GetHoverInfo failed for %s: %v. Result: %s", tt.name, err, result)
@@ -144,7 +148,7 @@ func TestHover(t *testing.T) {
 				t.Errorf("Test %s: Expected hover info NOT to contain %q but it was found: %s", tt.name, tt.unexpectedText, result)
 			}
 
-			common.SnapshotTest(t, "clangd", "hover", tt.snapshotName, result)
+			testharness.SnapshotTest(t, "clangd", "hover", tt.snapshotName, result)
 		})
 	}
 }
@@ -5,27 +5,25 @@ import (
 	"path/filepath"
 	"testing"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // GetTestSuite returns a test suite for Clangd language server tests
-func GetTestSuite(t *testing.T) *common.TestSuite {
+func GetTestSuite(t *testing.T) *testharness.TestSuite {
 	// Configure Clangd LSP
 	repoRoot, err := filepath.Abs("../../../..")
 	if err != nil {
 		t.Fatalf("Failed to get repo root: %v", err)
 	}
 
-	config := common.LSPTestConfig{
-		Name:             "clangd",
-		Command:          "clangd",
-		Args:             []string{"--compile-commands-dir=" + filepath.Join(repoRoot, "integrationtests/workspaces/clangd")},
-		WorkspaceDir:     filepath.Join(repoRoot, "integrationtests/workspaces/clangd"),
-		InitializeTimeMs: 2000,
+	config, err := testharness.Fixture("clangd")
+	if err != nil {
+		t.Fatalf("Failed to get fixture: %v", err)
 	}
+	config.OutputDir = filepath.Join(repoRoot, "integrationtests/test-output")
 
 	// Create a test suite
-	suite := common.NewTestSuite(t, config)
+	suite := testharness.NewTestSuite(t, config)
 
 	// Set up the suite
 	if err := suite.Setup(); err != nil {
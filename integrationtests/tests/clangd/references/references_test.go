@@ -8,15 +8,17 @@ import (
 	"time"
 
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/clangd/internal"
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestFindReferences tests the FindReferences tool with C++ symbols
 // that have references across different files
 func TestFindReferences(t *testing.T) {
+	t.Parallel()
+
 	// Helper function to open all files and wait for indexing
-	openAllFilesAndWait := func(suite *common.TestSuite, ctx context.Context) {
+	openAllFilesAndWait := func(suite *testharness.TestSuite, ctx context.Context) {
 		// Open one file so that clangd loads compiles commands and begins indexing
 		filesToOpen := []string{
 			"src/main.cpp",
@@ -77,7 +79,7 @@ func TestFindReferences(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			// Call the FindReferences tool
-			result, err := tools.FindReferences(ctx, suite.Client, tc.symbolName)
+			result, err := tools.FindReferences(ctx, suite.Client, tc.symbolName, false, tools.OrderPath, nil)
 			if err != nil {
 				t.Fatalf("Failed to find references for %s: %v. Result: %s", tc.symbolName, err, result)
 			}
@@ -95,7 +97,7 @@ func TestFindReferences(t *testing.T) {
 			}
 
 			// Use snapshot testing to verify exact output
-			common.SnapshotTest(t, "clangd", "references", tc.snapshotName, result)
+			testharness.SnapshotTest(t, "clangd", "references", tc.snapshotName, result)
 		})
 	}
 }
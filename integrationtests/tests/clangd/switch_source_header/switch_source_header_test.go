@@ -0,0 +1,63 @@
+package switch_source_header_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/clangd/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// TestSwitchSourceHeader tests switching between a header in include/ and
+// its source file in src/, which clangd finds through its index
+func TestSwitchSourceHeader(t *testing.T) {
+	t.Parallel()
+
+	suite := internal.GetTestSuite(t)
+
+	ctx, cancel := context.WithTimeout(suite.Context, 20*time.Second)
+	defer cancel()
+
+	// Open one file so that clangd loads compile commands and begins
+	// indexing, which it needs to find a header's source file
+	if err := suite.Client.OpenFile(ctx, filepath.Join(suite.WorkspaceDir, "src/main.cpp")); err != nil {
+		t.Fatalf("Failed to open main.cpp: %v", err)
+	}
+	time.Sleep(5 * time.Second)
+
+	tests := []struct {
+		name     string
+		file     string
+		expected string
+	}{
+		{
+			name:     "Source to header",
+			file:     "src/helper.cpp",
+			expected: "Header: " + filepath.Join(suite.WorkspaceDir, "include/helper.hpp"),
+		},
+		{
+			name:     "Header to source",
+			file:     "include/helper.hpp",
+			expected: "Source file: " + filepath.Join(suite.WorkspaceDir, "src/helper.cpp"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tools.SwitchSourceHeader(ctx, suite.Client, suite.WorkspaceDir, filepath.Join(suite.WorkspaceDir, tt.file))
+			if err != nil {
+				t.Fatalf("SwitchSourceHeader failed: %v", err)
+			}
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected result to contain %q, got: %s", tt.expected, result)
+			}
+			// The answer should come from clangd rather than from file names
+			if strings.Contains(result, "matched by name") {
+				t.Errorf("clangd didn't find the counterpart: %s", result)
+			}
+		})
+	}
+}
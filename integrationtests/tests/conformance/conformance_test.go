@@ -0,0 +1,129 @@
+// Package conformance_test runs the same scenarios against every language
+// server this repository supports and reports which of them each passes.
+package conformance_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/testharness"
+)
+
+// cases place the conformance scenarios in the fixture workspace of each
+// language
+var cases = map[string]testharness.ConformanceCase{
+	"go": {
+		Definition:  "FooBar",
+		References:  "HelperFunction",
+		Diagnostics: "main.go",
+		CodeActions: testharness.Position{File: "main.go", Line: 8, Column: 2},
+		Rename:      testharness.Position{File: "types.go", Line: 25, Column: 7},
+		RenameTo:    "UpdatedConstant",
+	},
+	"python": {
+		OpenFiles:   []string{"helper.py", "consumer.py"},
+		Settle:      2 * time.Second,
+		Definition:  "test_function",
+		References:  "helper_function",
+		Diagnostics: "error_file.py",
+		CodeActions: testharness.Position{File: "error_file.py", Line: 22, Column: 9},
+		Rename:      testharness.Position{File: "helper.py", Line: 8, Column: 1},
+		RenameTo:    "UPDATED_CONSTANT",
+	},
+	"typescript": {
+		Files: map[string]string{
+			"error.ts": "function errorFunction(x: number): string {\n  return x;\n}\n",
+		},
+		OpenFiles:   []string{"main.ts", "helper.ts", "consumer.ts", "another_consumer.ts", "error.ts"},
+		Settle:      3 * time.Second,
+		Definition:  "TestFunction",
+		References:  "SharedFunction",
+		Diagnostics: "error.ts",
+		CodeActions: testharness.Position{File: "error.ts", Line: 2, Column: 3},
+		Rename:      testharness.Position{File: "helper.ts", Line: 39, Column: 14},
+		RenameTo:    "UpdatedConstant",
+	},
+	"rust": {
+		OpenFiles:   []string{"src/main.rs", "src/types.rs", "src/helper.rs", "src/consumer.rs", "src/another_consumer.rs"},
+		Settle:      5 * time.Second,
+		Definition:  "foo_bar",
+		References:  "helper_function",
+		Diagnostics: "src/main.rs",
+		CodeActions: testharness.Position{File: "src/main.rs", Line: 11, Column: 5},
+		Rename:      testharness.Position{File: "src/types.rs", Line: 78, Column: 13},
+		RenameTo:    "UPDATED_CONSTANT",
+	},
+	"clangd": {
+		// clangd doesn't index files until one is opened
+		OpenFiles:   []string{"src/main.cpp"},
+		Settle:      10 * time.Second,
+		Definition:  "foo_bar",
+		References:  "helperFunction",
+		Diagnostics: "src/main.cpp",
+		CodeActions: testharness.Position{File: "src/main.cpp", Line: 17, Column: 3},
+		Rename:      testharness.Position{File: "src/main.cpp", Line: 5, Column: 6},
+		RenameTo:    "renamed_foo_bar",
+	},
+}
+
+// TestConformance runs the scenarios against each server that is installed,
+// in parallel, and writes the compatibility matrix to
+// integrationtests/test-output/conformance.txt. Run one server with
+// -run TestConformance/servers/go.
+func TestConformance(t *testing.T) {
+	repoRoot, err := filepath.Abs("../../..")
+	if err != nil {
+		t.Fatalf("Failed to get repo root: %v", err)
+	}
+	outputDir := filepath.Join(repoRoot, "integrationtests", "test-output")
+
+	languages := []string{"go", "python", "typescript", "rust", "clangd"}
+	var mu sync.Mutex
+	byLanguage := make(map[string][]testharness.ConformanceResult)
+	t.Run("servers", func(t *testing.T) {
+		for _, language := range languages {
+			t.Run(language, func(t *testing.T) {
+				t.Parallel()
+				config, err := testharness.Fixture(language)
+				if err != nil {
+					t.Fatalf("Failed to get fixture: %v", err)
+				}
+				config.OutputDir = outputDir
+				if _, err := exec.LookPath(config.Command); err != nil {
+					t.Skipf("%s is not installed", config.Command)
+				}
+
+				results := testharness.RunConformance(t, config, cases[language])
+				mu.Lock()
+				byLanguage[language] = results
+				mu.Unlock()
+			})
+		}
+	})
+
+	// Servers in a stable order, rather than the order they finished in
+	var results []testharness.ConformanceResult
+	for _, language := range languages {
+		results = append(results, byLanguage[language]...)
+	}
+	if len(results) == 0 {
+		return
+	}
+
+	var report strings.Builder
+	if err := testharness.WriteConformanceReport(&report, results); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("Conformance:\n%s", report.String())
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "conformance.txt"), []byte(report.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,35 @@
+package hover_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/csharp/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// TestHover tests hovering over a method declared in another file of the
+// project, which is only known once the solution is loaded
+func TestHover(t *testing.T) {
+	t.Parallel()
+
+	suite := internal.GetTestSuite(t)
+
+	ctx, cancel := context.WithTimeout(suite.Context, 30*time.Second)
+	defer cancel()
+
+	// cart.Total() in Program.cs
+	filePath := filepath.Join(suite.WorkspaceDir, "src/Shop/Program.cs")
+	result, err := tools.GetHoverInfo(ctx, suite.Client, filePath, 6, 24)
+	if err != nil {
+		t.Fatalf("GetHoverInfo failed: %v", err)
+	}
+	for _, expected := range []string{"Total", "The sum of the prices"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected hover to contain %q, got: %s", expected, result)
+		}
+	}
+}
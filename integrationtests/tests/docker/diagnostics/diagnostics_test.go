@@ -0,0 +1,31 @@
+package diagnostics_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/docker/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// TestDiagnostics tests that docker-langserver's checks are reported
+func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	suite := internal.GetTestSuite(t)
+
+	ctx, cancel := context.WithTimeout(suite.Context, 20*time.Second)
+	defer cancel()
+
+	filePath := filepath.Join(suite.WorkspaceDir, "Dockerfile")
+	result, err := tools.GetDiagnosticsForFile(ctx, suite.Client, filePath, 2, true)
+	if err != nil {
+		t.Fatalf("GetDiagnosticsForFile failed: %v", err)
+	}
+	if !strings.Contains(result, "MAINTAINER has been deprecated") {
+		t.Errorf("Expected the deprecated MAINTAINER to be reported, got: %s", result)
+	}
+}
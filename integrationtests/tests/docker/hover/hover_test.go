@@ -0,0 +1,39 @@
+package hover_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/docker/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// TestHover tests that Dockerfile instructions are documented, including in
+// variants such as Dockerfile.dev
+func TestHover(t *testing.T) {
+	t.Parallel()
+
+	for _, file := range []string{"Dockerfile", "Dockerfile.dev"} {
+		t.Run(file, func(t *testing.T) {
+			t.Parallel()
+
+			suite := internal.GetTestSuite(t)
+
+			ctx, cancel := context.WithTimeout(suite.Context, 20*time.Second)
+			defer cancel()
+
+			// The FROM instruction
+			filePath := filepath.Join(suite.WorkspaceDir, file)
+			result, err := tools.GetHoverInfo(ctx, suite.Client, filePath, 1, 1)
+			if err != nil {
+				t.Fatalf("GetHoverInfo failed: %v", err)
+			}
+			if !strings.Contains(strings.ToLower(result), "base image") {
+				t.Errorf("Expected FROM's documentation, got: %s", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package hover_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/elixir/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// TestHover tests hovering over a call to a function of another module,
+// which is only known once ElixirLS has built the project, and shows its @doc
+func TestHover(t *testing.T) {
+	t.Parallel()
+
+	suite := internal.GetTestSuite(t)
+
+	ctx, cancel := context.WithTimeout(suite.Context, 60*time.Second)
+	defer cancel()
+
+	// The call to total
+	filePath := filepath.Join(suite.WorkspaceDir, "lib/checkout.ex")
+	result, err := tools.GetHoverInfo(ctx, suite.Client, filePath, 5, 10)
+	if err != nil {
+		t.Fatalf("GetHoverInfo failed: %v", err)
+	}
+	if !strings.Contains(result, "The sum of the prices") {
+		t.Errorf("Expected the function's documentation, got: %s", result)
+	}
+}
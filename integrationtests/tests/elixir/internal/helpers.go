@@ -0,0 +1,39 @@
+// Package internal contains shared helpers for Elixir tests
+package internal
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/testharness"
+)
+
+// GetTestSuite returns a test suite for ElixirLS tests
+func GetTestSuite(t *testing.T) *testharness.TestSuite {
+	// Configure ElixirLS
+	repoRoot, err := filepath.Abs("../../../..")
+	if err != nil {
+		t.Fatalf("Failed to get repo root: %v", err)
+	}
+
+	config, err := testharness.Fixture("elixir")
+	if err != nil {
+		t.Fatalf("Failed to get fixture: %v", err)
+	}
+	config.OutputDir = filepath.Join(repoRoot, "integrationtests/test-output")
+
+	// Create a test suite
+	suite := testharness.NewTestSuite(t, config)
+
+	// Set up the suite
+	if err := suite.Setup(); err != nil {
+		t.Fatalf("Failed to set up test suite: %v", err)
+	}
+
+	// Register cleanup
+	t.Cleanup(func() {
+		suite.Cleanup()
+	})
+
+	return suite
+}
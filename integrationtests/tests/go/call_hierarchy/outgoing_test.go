@@ -6,12 +6,14 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/go/internal"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 func TestOutgoingCalls(t *testing.T) {
+	t.Parallel()
+
 	suite := internal.GetTestSuite(t)
 
 	ctx, cancel := context.WithTimeout(suite.Context, 10*time.Second)
@@ -51,7 +53,7 @@ func TestOutgoingCalls(t *testing.T) {
 			}
 
 			// Use snapshot testing to verify exact output
-			common.SnapshotTest(t, "go", "call_hierarchy", tc.snapshotName, result)
+			testharness.SnapshotTest(t, "go", "call_hierarchy", tc.snapshotName, result)
 		})
 	}
 
@@ -7,17 +7,21 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/go/internal"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestCodeLens tests the codelens functionality with the Go language server
 func TestCodeLens(t *testing.T) {
+	t.Parallel()
+
 	t.Skip("Remove this line to run codelens tool tests")
 
 	// Test GetCodeLens with a file that should have codelenses
 	t.Run("GetCodeLens", func(t *testing.T) {
+		t.Parallel()
+
 		suite := internal.GetTestSuite(t)
 
 		ctx, cancel := context.WithTimeout(suite.Context, 5*time.Second)
@@ -45,11 +49,13 @@ func TestCodeLens(t *testing.T) {
 			t.Errorf("Expected 'tidy' code lens but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "go", "codelens", "get", result)
+		testharness.SnapshotTest(t, "go", "codelens", "get", result)
 	})
 
 	// Test ExecuteCodeLens by running the tidy codelens command
 	t.Run("ExecuteCodeLens", func(t *testing.T) {
+		t.Parallel()
+
 		suite := internal.GetTestSuite(t)
 
 		ctx, cancel := context.WithTimeout(suite.Context, 10*time.Second)
@@ -96,6 +102,6 @@ func TestCodeLens(t *testing.T) {
 			t.Errorf("Expected dependency to be removed, but it's still there:\n%s", updatedContent)
 		}
 
-		common.SnapshotTest(t, "go", "codelens", "execute", execResult)
+		testharness.SnapshotTest(t, "go", "codelens", "execute", execResult)
 	})
 }
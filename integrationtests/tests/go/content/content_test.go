@@ -7,12 +7,14 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/go/internal"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 func TestContent(t *testing.T) {
+	t.Parallel()
+
 	suite := internal.GetTestSuite(t)
 
 	ctx, cancel := context.WithTimeout(suite.Context, 10*time.Second)
@@ -50,7 +52,7 @@ func TestContent(t *testing.T) {
 			}
 
 			// Use snapshot testing to verify exact output
-			common.SnapshotTest(t, "go", "content", tc.snapshotName, result)
+			testharness.SnapshotTest(t, "go", "content", tc.snapshotName, result)
 		})
 	}
 }
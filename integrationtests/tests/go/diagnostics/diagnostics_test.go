@@ -8,16 +8,20 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/go/internal"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestDiagnostics tests diagnostics functionality with the Go language server
 func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+
 	// Test with a clean file
 	t.Run("CleanFile", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -35,11 +39,13 @@ func TestDiagnostics(t *testing.T) {
 			t.Errorf("Expected no diagnostics but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "go", "diagnostics", "clean", result)
+		testharness.SnapshotTest(t, "go", "diagnostics", "clean", result)
 	})
 
 	// Test with a file containing an error
 	t.Run("FileWithError", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with code that contains errors
 		suite := internal.GetTestSuite(t)
 
@@ -64,12 +70,14 @@ func TestDiagnostics(t *testing.T) {
 			t.Errorf("Expected unreachable code error but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "go", "diagnostics", "unreachable", result)
+		testharness.SnapshotTest(t, "go", "diagnostics", "unreachable", result)
 	})
 
 	// Test file dependency: file A (helper.go) provides a function,
 	// file B (consumer.go) uses it, then modify A to break B
 	t.Run("FileDependency", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -180,6 +188,6 @@ func HelperFunction(value int) string {
 			t.Errorf("Expected error about wrong arguments but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "go", "diagnostics", "dependency", result)
+		testharness.SnapshotTest(t, "go", "diagnostics", "dependency", result)
 	})
 }
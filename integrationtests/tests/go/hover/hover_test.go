@@ -7,13 +7,15 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/go/internal"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestHover tests hover functionality with the Go language server
 func TestHover(t *testing.T) {
+	t.Parallel()
+
 	tests := []struct {
 		name           string
 		file           string
@@ -86,6 +88,8 @@ func TestHover(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
 			// Get a test suite
 			suite := internal.GetTestSuite(t)
 
@@ -104,7 +108,7 @@ func TestHover(t *testing.T) {
 				// For the "OutsideFile" test, we expect an error
 				if tt.name == "OutsideFile" {
 					// Create a snapshot even for error case
-					common.SnapshotTest(t, "go", "hover", tt.snapshotName, err.Error())
+					testharness.SnapshotTest(t, "go", "hover", tt.snapshotName, err.Error())
 					return
 				}
 				t.Fatalf("GetHoverInfo failed: %v", err)
@@ -120,7 +124,7 @@ func TestHover(t *testing.T) {
 				t.Errorf("Expected hover info NOT to contain %q but it was found: %s", tt.unexpectedText, result)
 			}
 
-			common.SnapshotTest(t, "go", "hover", tt.snapshotName, result)
+			testharness.SnapshotTest(t, "go", "hover", tt.snapshotName, result)
 		})
 	}
 }
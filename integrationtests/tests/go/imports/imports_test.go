@@ -0,0 +1,71 @@
+package imports_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/go/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// TestListKnownPackages tests that gopls lists the standard library for
+// main.go, filtered by the query
+func TestListKnownPackages(t *testing.T) {
+	t.Parallel()
+
+	suite := internal.GetTestSuite(t)
+
+	ctx, cancel := context.WithTimeout(suite.Context, 20*time.Second)
+	defer cancel()
+
+	filePath := filepath.Join(suite.WorkspaceDir, "main.go")
+	result, err := tools.ListKnownPackages(ctx, suite.Client, filePath, "strconv")
+	if err != nil {
+		t.Fatalf("ListKnownPackages failed: %v", err)
+	}
+	if !strings.Contains(result, "\nstrconv\n") {
+		t.Errorf("Expected strconv to be listed, got: %s", result)
+	}
+	if strings.Contains(result, "net/http") {
+		t.Errorf("Expected only packages matching the query, got: %s", result)
+	}
+}
+
+// TestAddImport tests that gopls adds an import to main.go, which already
+// imports fmt, and that adding it again changes nothing
+func TestAddImport(t *testing.T) {
+	t.Parallel()
+
+	suite := internal.GetTestSuite(t)
+
+	ctx, cancel := context.WithTimeout(suite.Context, 20*time.Second)
+	defer cancel()
+
+	filePath := filepath.Join(suite.WorkspaceDir, "main.go")
+	result, err := tools.AddImport(ctx, suite.Client, filePath, "strings")
+	if err != nil {
+		t.Fatalf("AddImport failed: %v", err)
+	}
+	if !strings.Contains(result, "+\t\"strings\"") {
+		t.Errorf("Expected the diff to add strings, got: %s", result)
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read main.go: %v", err)
+	}
+	if !strings.Contains(string(content), "\"strings\"") {
+		t.Errorf("Expected main.go to import strings, got:\n%s", content)
+	}
+
+	result, err = tools.AddImport(ctx, suite.Client, filePath, "strings")
+	if err != nil {
+		t.Fatalf("AddImport failed: %v", err)
+	}
+	if !strings.Contains(result, "already imports strings") {
+		t.Errorf("Expected no change, got: %s", result)
+	}
+}
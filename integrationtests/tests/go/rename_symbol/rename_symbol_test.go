@@ -7,15 +7,19 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/go/internal"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestRenameSymbol tests the RenameSymbol functionality with the Go language server
 func TestRenameSymbol(t *testing.T) {
+	t.Parallel()
+
 	// Test with a successful rename of a symbol that exists
 	t.Run("SuccessfulRename", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -49,7 +53,7 @@ func TestRenameSymbol(t *testing.T) {
 			t.Errorf("Expected multiple occurrences to be renamed but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "go", "rename_symbol", "successful", result)
+		testharness.SnapshotTest(t, "go", "rename_symbol", "successful", result)
 
 		// Verify that the rename worked by checking for the updated constant name in the file
 		fileContent, err := suite.ReadFile("types.go")
@@ -74,6 +78,8 @@ func TestRenameSymbol(t *testing.T) {
 
 	// Test with a symbol that doesn't exist
 	t.Run("SymbolNotFound", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -107,6 +113,6 @@ func TestRenameSymbol(t *testing.T) {
 			t.Errorf("Expected error message about failed rename but got: %s", errorMessage)
 		}
 
-		common.SnapshotTest(t, "go", "rename_symbol", "not_found", errorMessage)
+		testharness.SnapshotTest(t, "go", "rename_symbol", "not_found", errorMessage)
 	})
 }
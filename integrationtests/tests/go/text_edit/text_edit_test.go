@@ -7,13 +7,15 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/go/internal"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestApplyTextEdits tests the ApplyTextEdits tool with various edit scenarios
 func TestApplyTextEdits(t *testing.T) {
+	t.Parallel()
+
 	suite := internal.GetTestSuite(t)
 
 	ctx, cancel := context.WithTimeout(suite.Context, 10*time.Second)
@@ -195,13 +197,15 @@ func AnotherFunction() {
 
 			// Use snapshot testing to verify the exact result
 			snapshotName := strings.ToLower(strings.ReplaceAll(tc.name, " ", "_"))
-			common.SnapshotTest(t, "go", "text_edit", snapshotName, result)
+			testharness.SnapshotTest(t, "go", "text_edit", snapshotName, result)
 		})
 	}
 }
 
 // TestApplyTextEditsWithBorderCases tests edge cases for the ApplyTextEdits tool
 func TestApplyTextEditsWithBorderCases(t *testing.T) {
+	t.Parallel()
+
 	suite := internal.GetTestSuite(t)
 
 	ctx, cancel := context.WithTimeout(suite.Context, 10*time.Second)
@@ -343,7 +347,7 @@ func NewFunction() {
 
 			// Use snapshot testing to verify the exact result
 			snapshotName := strings.ToLower(strings.ReplaceAll(tc.name, " ", "_"))
-			common.SnapshotTest(t, "go", "text_edit", snapshotName, result)
+			testharness.SnapshotTest(t, "go", "text_edit", snapshotName, result)
 		})
 	}
 }
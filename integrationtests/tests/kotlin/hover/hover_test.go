@@ -0,0 +1,33 @@
+package hover_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/kotlin/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// TestHover tests hovering over a call to a method declared in another file
+// of the Gradle project, which shows the method's doc comment
+func TestHover(t *testing.T) {
+	t.Parallel()
+
+	suite := internal.GetTestSuite(t)
+
+	ctx, cancel := context.WithTimeout(suite.Context, 60*time.Second)
+	defer cancel()
+
+	// The call to total
+	filePath := filepath.Join(suite.WorkspaceDir, "src/main/kotlin/shop/Main.kt")
+	result, err := tools.GetHoverInfo(ctx, suite.Client, filePath, 7, 18)
+	if err != nil {
+		t.Fatalf("GetHoverInfo failed: %v", err)
+	}
+	if !strings.Contains(result, "The sum of the prices") {
+		t.Errorf("Expected the method's doc comment, got: %s", result)
+	}
+}
@@ -0,0 +1,93 @@
+package mcp_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// listedTools is the part of a tools/list result the examples are checked
+// against
+type listedTools struct {
+	Meta struct {
+		Examples map[string][]struct {
+			Title     string         `json:"title"`
+			Arguments map[string]any `json:"arguments"`
+			Output    string         `json:"output"`
+		} `json:"examples"`
+	} `json:"_meta"`
+	Tools []struct {
+		Name        string `json:"name"`
+		InputSchema struct {
+			Properties map[string]any `json:"properties"`
+			Required   []string       `json:"required"`
+		} `json:"inputSchema"`
+	} `json:"tools"`
+}
+
+// TestToolExamples checks that the examples in tools/list are of listed
+// tools and call them with arguments they take
+func TestToolExamples(t *testing.T) {
+	binary := buildServer(t)
+
+	list := func(t *testing.T, args ...string) listedTools {
+		session := startSession(t, binary, args...)
+		session.initialize()
+		var result listedTools
+		if err := json.Unmarshal(session.request("tools/list", map[string]any{}), &result); err != nil {
+			t.Fatalf("Failed to decode tools/list: %v", err)
+		}
+		return result
+	}
+
+	check := func(t *testing.T, result listedTools) {
+		if len(result.Meta.Examples) == 0 {
+			t.Fatal("No examples listed")
+		}
+		for name, examples := range result.Meta.Examples {
+			var found bool
+			for _, tool := range result.Tools {
+				if tool.Name != name {
+					continue
+				}
+				found = true
+				for _, example := range examples {
+					if example.Title == "" || example.Output == "" {
+						t.Errorf("Example of %s has no title or output", name)
+					}
+					for argument := range example.Arguments {
+						if _, ok := tool.InputSchema.Properties[argument]; !ok {
+							t.Errorf("Example %q of %s has unknown argument %s", example.Title, name, argument)
+						}
+					}
+					for _, argument := range tool.InputSchema.Required {
+						if _, ok := example.Arguments[argument]; !ok {
+							t.Errorf("Example %q of %s lacks required argument %s", example.Title, name, argument)
+						}
+					}
+				}
+			}
+			if !found {
+				t.Errorf("Examples listed for %s, which isn't listed", name)
+			}
+		}
+	}
+
+	t.Run("language server", func(t *testing.T) {
+		server := lsptest.NewServer(protocol.ServerCapabilities{})
+		check(t, list(t, "--lsp-connect", serve(t, server)))
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		check(t, list(t, "--lsp-connect", closedAddress(t)))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		result := list(t, "--lsp-connect", closedAddress(t), "--no-tool-examples")
+		if len(result.Meta.Examples) != 0 {
+			t.Errorf("Examples listed with --no-tool-examples: %v", result.Meta.Examples)
+		}
+	})
+}
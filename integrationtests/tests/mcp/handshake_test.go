@@ -0,0 +1,264 @@
+// Package mcp_test snapshots what an MCP client sees of the server before it
+// calls a tool: the initialize result, and the name, description, input
+// schema and annotations of every tool in tools/list. Agents' prompts are
+// written against these, so a change to them should be deliberate; accept
+// one by running the tests with UPDATE_SNAPSHOTS=true.
+//
+// The tests run the built server against a scripted language server, so
+// they need no language server installed.
+package mcp_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/testharness"
+)
+
+// clientCapabilities are those of a client that can be asked to trust the
+// workspace, so that the tools gated on trust are listed too
+var clientCapabilities = map[string]any{"sampling": map[string]any{}}
+
+func TestHandshake(t *testing.T) {
+	binary := buildServer(t)
+
+	t.Run("language server", func(t *testing.T) {
+		server := lsptest.NewServer(protocol.ServerCapabilities{})
+		session := startSession(t, binary, "--lsp-connect", serve(t, server))
+		snapshotHandshake(t, session, "language_server")
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		// Nothing listens at the address, so the heuristic tools are served
+		session := startSession(t, binary, "--lsp-connect", closedAddress(t))
+		snapshotHandshake(t, session, "fallback")
+	})
+
+	// Only the tools added for a particular server are snapshotted, as the
+	// others are those of any language server
+	t.Run("server extensions", func(t *testing.T) {
+		common := make(map[string]bool)
+		for _, tool := range listTools(t, binary, "lsptest") {
+			common[tool["name"].(string)] = true
+		}
+		for _, name := range []string{"clangd", "gopls", "rust-analyzer", "typescript-language-server"} {
+			var extensions []map[string]any
+			for _, tool := range listTools(t, binary, name) {
+				if !common[tool["name"].(string)] {
+					extensions = append(extensions, tool)
+				}
+			}
+			testharness.SnapshotJSON(t, "mcp", "tools_list", "extensions_"+name, extensions)
+		}
+	})
+}
+
+// listTools returns the tools listed with a scripted language server that
+// reports the given name
+func listTools(t *testing.T, binary, serverName string) []map[string]any {
+	t.Helper()
+	server := lsptest.NewServer(protocol.ServerCapabilities{})
+	server.Handle("initialize", func(json.RawMessage) (any, error) {
+		return protocol.InitializeResult{ServerInfo: &protocol.ServerInfo{Name: serverName}}, nil
+	})
+	session := startSession(t, binary, "--lsp-connect", serve(t, server))
+	session.initialize()
+
+	var result struct {
+		Tools []map[string]any `json:"tools"`
+	}
+	if err := json.Unmarshal(session.request("tools/list", map[string]any{}), &result); err != nil {
+		t.Fatalf("Failed to decode tools/list: %v", err)
+	}
+	return result.Tools
+}
+
+func snapshotHandshake(t *testing.T, session *session, name string) {
+	initialize := session.initialize()
+	// The version names the commit the server was built from
+	testharness.SnapshotJSON(t, "mcp", "initialize", name, initialize, testharness.IgnoreField("serverInfo.version"))
+
+	tools := session.request("tools/list", map[string]any{})
+	testharness.SnapshotJSON(t, "mcp", "tools_list", name, tools)
+}
+
+// buildServer builds the server binary into a temporary directory
+func buildServer(t *testing.T) string {
+	t.Helper()
+	root, err := testharness.FindRepoRoot()
+	if err != nil {
+		t.Fatalf("Failed to find repo root: %v", err)
+	}
+	binary := filepath.Join(t.TempDir(), "mcp-language-server")
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	cmd := exec.Command("go", "build", "-o", binary, ".")
+	cmd.Dir = root
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build the server: %v\n%s", err, out)
+	}
+	return binary
+}
+
+// serve serves a scripted language server on a local TCP port and returns
+// its --lsp-connect address
+func serve(t *testing.T, server *lsptest.Server) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = server.Serve(conn)
+			}()
+		}
+	}()
+	return "tcp://" + listener.Addr().String()
+}
+
+// closedAddress returns an address nothing listens at
+func closedAddress(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	address := "tcp://" + listener.Addr().String()
+	_ = listener.Close()
+	return address
+}
+
+// session is an MCP client talking to the server over its stdio
+type session struct {
+	t         *testing.T
+	cmd       *exec.Cmd
+	workspace string
+	stdin     io.Writer
+	stdout    *bufio.Reader
+	nextID    int
+}
+
+// startSession starts the server on a workspace of its own, with a config
+// directory of its own so that no recorded trust decision applies
+func startSession(t *testing.T, binary string, args ...string) *session {
+	t.Helper()
+	workspace := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module example.com/handshake\n\ngo 1.24\n",
+		"main.go": "package main\n\nfunc main() {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(workspace, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	home := t.TempDir()
+
+	cmd := exec.Command(binary, append([]string{"--workspace", workspace}, args...)...)
+	cmd.Env = append(os.Environ(), "HOME="+home, "XDG_CONFIG_HOME="+home, "APPDATA="+home)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatalf("Failed to open stdin: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("Failed to open stdout: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start the server: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		if t.Failed() {
+			t.Logf("Server log:\n%s", stderr.String())
+		}
+	})
+	return &session{t: t, cmd: cmd, workspace: workspace, stdin: stdin, stdout: bufio.NewReader(stdout)}
+}
+
+// initialize performs the MCP handshake and returns the initialize result
+func (s *session) initialize() json.RawMessage {
+	s.t.Helper()
+	result := s.request("initialize", map[string]any{
+		"protocolVersion": "2025-03-26",
+		"capabilities":    clientCapabilities,
+		"clientInfo":      map[string]any{"name": "handshake-test", "version": "1.0.0"},
+	})
+	s.notify("notifications/initialized")
+	return result
+}
+
+// request sends a request and returns its result, skipping the
+// notifications the server sends meanwhile
+func (s *session) request(method string, params any) json.RawMessage {
+	s.t.Helper()
+	s.nextID++
+	id := s.nextID
+	s.send(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+
+	// A server that doesn't answer is stopped, which ends the read below
+	deadline := time.AfterFunc(30*time.Second, func() { _ = s.cmd.Process.Kill() })
+	defer deadline.Stop()
+	for {
+		line, err := s.stdout.ReadBytes('\n')
+		if err != nil {
+			s.t.Fatalf("Failed to read the response to %s: %v", method, err)
+		}
+		var response struct {
+			ID     *int            `json:"id"`
+			Method string          `json:"method"`
+			Result json.RawMessage `json:"result"`
+			Error  json.RawMessage `json:"error"`
+		}
+		if err := json.Unmarshal(line, &response); err != nil {
+			s.t.Fatalf("Server sent a message that isn't JSON: %q", line)
+		}
+		if response.Method != "" || response.ID == nil || *response.ID != id {
+			continue
+		}
+		if response.Error != nil {
+			s.t.Fatalf("%s failed: %s", method, response.Error)
+		}
+		return response.Result
+	}
+}
+
+func (s *session) notify(method string) {
+	s.t.Helper()
+	s.send(map[string]any{"jsonrpc": "2.0", "method": method})
+}
+
+func (s *session) send(message any) {
+	s.t.Helper()
+	data, err := json.Marshal(message)
+	if err != nil {
+		s.t.Fatalf("Failed to encode message: %v", err)
+	}
+	if _, err := fmt.Fprintf(s.stdin, "%s\n", data); err != nil {
+		s.t.Fatalf("Failed to send message: %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package mcp_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// TestLSPProxy checks that an editor connected at --lsp-proxy shares the
+// language server with the tools
+func TestLSPProxy(t *testing.T) {
+	binary := buildServer(t)
+
+	server := lsptest.NewServer(protocol.ServerCapabilities{HoverProvider: &protocol.Or_ServerCapabilities_hoverProvider{Value: true}})
+	server.Respond("textDocument/hover", map[string]any{
+		"contents": map[string]any{"kind": "markdown", "value": "func main()"},
+	})
+	changes := make(chan json.RawMessage, 4)
+	server.OnNotification("textDocument/didChange", func(params json.RawMessage) { changes <- params })
+
+	// Unix socket paths are short, so the socket isn't in the test's
+	// temporary directory
+	socket := filepath.Join(shortTempDir(t), "lsp.sock")
+	session := startSession(t, binary, "--lsp-connect", serve(t, server), "--lsp-proxy", "unix://"+socket, "--trust-workspace")
+	session.initialize()
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatalf("Failed to stat the socket: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("Socket mode = %v, want 0600", info.Mode().Perm())
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatalf("Failed to connect to the proxy: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+	editor := &editorSession{t: t, conn: conn, reader: bufio.NewReader(conn)}
+
+	var initialized protocol.InitializeResult
+	if err := json.Unmarshal(editor.request("initialize", protocol.InitializeParams{}), &initialized); err != nil {
+		t.Fatalf("Failed to decode initialize: %v", err)
+	}
+	if initialized.ServerInfo == nil || initialized.ServerInfo.Name != "lsptest" {
+		t.Errorf("Editor wasn't given the server's initialize result: %+v", initialized)
+	}
+	editor.notify("initialized", protocol.InitializedParams{})
+
+	// The editor's unsaved text is what the server sees, with incremental
+	// changes applied
+	uri := protocol.URIFromPath(filepath.Join(session.workspace, "main.go"))
+	editor.notify("textDocument/didOpen", protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, LanguageID: "go", Version: 1, Text: "package main\n\nfunc main() {}\n"},
+	})
+	editor.notify("textDocument/didChange", map[string]any{
+		"textDocument": map[string]any{"uri": uri, "version": 2},
+		"contentChanges": []map[string]any{{
+			"range": map[string]any{"start": map[string]any{"line": 2, "character": 13}, "end": map[string]any{"line": 2, "character": 13}},
+			"text":  "\n\tprintln()\n",
+		}},
+	})
+	select {
+	case params := <-changes:
+		var change struct {
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(params, &change); err != nil || len(change.ContentChanges) != 1 {
+			t.Fatalf("Unexpected didChange: %s", params)
+		}
+		if want := "package main\n\nfunc main() {\n\tprintln()\n}\n"; change.ContentChanges[0].Text != want {
+			t.Errorf("Server was sent %q, want %q", change.ContentChanges[0].Text, want)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Editor's change wasn't passed on")
+	}
+
+	var hover struct {
+		Contents struct {
+			Value string `json:"value"`
+		} `json:"contents"`
+	}
+	if err := json.Unmarshal(editor.request("textDocument/hover", protocol.HoverParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     protocol.Position{Line: 2, Character: 6},
+		},
+	}), &hover); err != nil {
+		t.Fatalf("Failed to decode hover: %v", err)
+	}
+	if hover.Contents.Value != "func main()" {
+		t.Errorf("Hover = %+v", hover)
+	}
+
+	// Diagnostics the server publishes reach the editor too
+	if err := server.PublishDiagnostics(uri, []protocol.Diagnostic{{Message: "unused"}}); err != nil {
+		t.Fatalf("Failed to publish diagnostics: %v", err)
+	}
+	for {
+		msg := editor.read()
+		if msg.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var published protocol.PublishDiagnosticsParams
+		if err := json.Unmarshal(msg.Params, &published); err != nil {
+			t.Fatalf("Failed to decode diagnostics: %v", err)
+		}
+		if len(published.Diagnostics) == 1 && published.Diagnostics[0].Message == "unused" {
+			break
+		}
+	}
+
+	// Shutting the editor down leaves the server to the tools
+	editor.request("shutdown", nil)
+	editor.notify("exit", nil)
+	session.request("tools/list", map[string]any{})
+	if len(server.Received("shutdown")) != 0 {
+		t.Error("Editor's shutdown was passed on to the server")
+	}
+}
+
+// TestLSPProxyRefused checks that the language server is only shared with
+// the user's editors, over a socket only they can reach, and for a trusted
+// workspace
+func TestLSPProxyRefused(t *testing.T) {
+	binary := buildServer(t)
+	server := lsptest.NewServer(protocol.ServerCapabilities{})
+
+	type refusal struct {
+		name    string
+		proxy   string
+		trusted bool
+		want    string
+	}
+	tests := []refusal{
+		{"untrusted workspace", "unix://" + filepath.Join(shortTempDir(t), "lsp.sock"), false, "requires the workspace"},
+		{"public address", "tcp://0.0.0.0:7777", true, "not a unix socket"},
+		{"loopback address", "tcp://127.0.0.1:7777", true, "not a unix socket"},
+	}
+	if runtime.GOOS != "windows" {
+		shared := shortTempDir(t)
+		if err := os.Chmod(shared, 0o755); err != nil {
+			t.Fatalf("Failed to open up %s: %v", shared, err)
+		}
+		tests = append(tests, refusal{"shared directory", "unix://" + filepath.Join(shared, "lsp.sock"), true, "must be 0700"})
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			home := t.TempDir()
+			args := []string{"--workspace", t.TempDir(), "--lsp-connect", serve(t, server), "--lsp-proxy", tt.proxy}
+			if tt.trusted {
+				args = append(args, "--trust-workspace")
+			}
+			cmd := exec.CommandContext(ctx, binary, args...)
+			cmd.Env = append(os.Environ(), "HOME="+home, "XDG_CONFIG_HOME="+home, "APPDATA="+home)
+			out, err := cmd.CombinedOutput()
+			if err == nil {
+				t.Fatalf("Server started with --lsp-proxy %s", tt.proxy)
+			}
+			if !strings.Contains(string(out), tt.want) {
+				t.Errorf("Output doesn't mention %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+// shortTempDir is a temporary directory with a path short enough for a unix
+// socket
+func shortTempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "mls")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+// editorSession is an editor speaking LSP to the proxy
+type editorSession struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID int32
+}
+
+// request sends a request and returns its result, skipping the
+// notifications sent meanwhile
+func (e *editorSession) request(method string, params any) json.RawMessage {
+	e.t.Helper()
+	e.nextID++
+	msg, err := lsp.NewRequest(e.nextID, method, params)
+	if err != nil {
+		e.t.Fatalf("Failed to create %s: %v", method, err)
+	}
+	if err := lsp.WriteMessage(e.conn, msg); err != nil {
+		e.t.Fatalf("Failed to send %s: %v", method, err)
+	}
+	for {
+		response := e.read()
+		if response.Method != "" || !response.ID.Equals(msg.ID) {
+			continue
+		}
+		if response.Error != nil {
+			e.t.Fatalf("%s failed: %v", method, response.Error)
+		}
+		return response.Result
+	}
+}
+
+func (e *editorSession) notify(method string, params any) {
+	e.t.Helper()
+	msg, err := lsp.NewNotification(method, params)
+	if err != nil {
+		e.t.Fatalf("Failed to create %s: %v", method, err)
+	}
+	if err := lsp.WriteMessage(e.conn, msg); err != nil {
+		e.t.Fatalf("Failed to send %s: %v", method, err)
+	}
+}
+
+func (e *editorSession) read() *lsp.Message {
+	e.t.Helper()
+	msg, err := lsp.ReadMessage(e.reader)
+	if err != nil {
+		e.t.Fatalf("Failed to read from the proxy: %v", err)
+	}
+	return msg
+}
@@ -0,0 +1,61 @@
+package diagnostics_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/php/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
+)
+
+// TestDiagnostics tests that Intelephense reports problems in a file and
+// none in a clean one
+func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		file         string
+		expected     string
+		snapshotName string
+	}{
+		{
+			name:         "UndefinedMethod",
+			file:         "src/broken.php",
+			expected:     "Undefined method",
+			snapshotName: "errors",
+		},
+		{
+			name:         "Clean",
+			file:         "src/Cart.php",
+			expected:     "No diagnostics found",
+			snapshotName: "clean",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := internal.GetTestSuite(t)
+
+			ctx, cancel := context.WithTimeout(suite.Context, 20*time.Second)
+			defer cancel()
+
+			filePath := filepath.Join(suite.WorkspaceDir, tt.file)
+			result, err := tools.GetDiagnosticsForFile(ctx, suite.Client, filePath, 2, true)
+			if err != nil {
+				t.Fatalf("GetDiagnosticsForFile failed: %v", err)
+			}
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected diagnostics to contain %q, got: %s", tt.expected, result)
+			}
+
+			testharness.SnapshotTest(t, "php", "diagnostics", tt.snapshotName, result)
+		})
+	}
+}
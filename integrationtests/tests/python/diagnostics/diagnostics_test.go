@@ -8,16 +8,20 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/python/internal"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestDiagnostics tests diagnostics functionality with the Python language server
 func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+
 	// Test with a clean file
 	t.Run("CleanFile", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -36,11 +40,13 @@ func TestDiagnostics(t *testing.T) {
 			t.Errorf("Expected no diagnostics but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "python", "diagnostics", "clean", result)
+		testharness.SnapshotTest(t, "python", "diagnostics", "clean", result)
 	})
 
 	// Test with a file containing errors
 	t.Run("FileWithErrors", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with code that contains errors
 		suite := internal.GetTestSuite(t)
 
@@ -68,12 +74,14 @@ func TestDiagnostics(t *testing.T) {
 			t.Errorf("Expected type errors or undefined variable errors but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "python", "diagnostics", "errors", result)
+		testharness.SnapshotTest(t, "python", "diagnostics", "errors", result)
 	})
 
 	// Test file dependency: helper.py provides a function,
 	// consumer_clean.py uses it, then modify helper.py to break consumer_clean.py
 	t.Run("FileDependency", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -204,6 +212,6 @@ def get_items() -> List[str]:
 			t.Errorf("Expected error about wrong arguments but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "python", "diagnostics", "dependency", result)
+		testharness.SnapshotTest(t, "python", "diagnostics", "dependency", result)
 	})
 }
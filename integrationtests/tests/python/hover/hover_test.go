@@ -7,13 +7,15 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/python/internal"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestHover tests hover functionality with the Python language server
 func TestHover(t *testing.T) {
+	t.Parallel()
+
 	tests := []struct {
 		name           string
 		file           string
@@ -102,6 +104,8 @@ func TestHover(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
 			// Get a test suite
 			suite := internal.GetTestSuite(t)
 
@@ -127,7 +131,7 @@ func TestHover(t *testing.T) {
 					if tt.unexpectedText != "" && strings.Contains(result, tt.unexpectedText) {
 						t.Errorf("Expected hover info NOT to contain %q but it was found: %s", tt.unexpectedText, result)
 					}
-					common.SnapshotTest(t, "python", "hover", tt.snapshotName, err.Error())
+					testharness.SnapshotTest(t, "python", "hover", tt.snapshotName, err.Error())
 					return
 				}
 				t.Fatalf("GetHoverInfo failed: %v", err)
@@ -143,7 +147,7 @@ func TestHover(t *testing.T) {
 				t.Errorf("Expected hover info NOT to contain %q but it was found: %s", tt.unexpectedText, result)
 			}
 
-			common.SnapshotTest(t, "python", "hover", tt.snapshotName, result)
+			testharness.SnapshotTest(t, "python", "hover", tt.snapshotName, result)
 		})
 	}
 }
@@ -6,14 +6,16 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/python/internal"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestFindReferences tests the FindReferences tool with Python symbols
 // that have references across different files
 func TestFindReferences(t *testing.T) {
+	t.Parallel()
+
 	suite := internal.GetTestSuite(t)
 
 	ctx, cancel := context.WithTimeout(suite.Context, 10*time.Second)
@@ -80,7 +82,7 @@ func TestFindReferences(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			// Call the FindReferences tool
-			result, err := tools.FindReferences(ctx, suite.Client, tc.symbolName)
+			result, err := tools.FindReferences(ctx, suite.Client, tc.symbolName, false, tools.OrderPath, nil)
 			if err != nil {
 				t.Fatalf("Failed to find references: %v", err)
 			}
@@ -98,7 +100,7 @@ func TestFindReferences(t *testing.T) {
 			}
 
 			// Use snapshot testing to verify exact output
-			common.SnapshotTest(t, "python", "references", tc.snapshotName, result)
+			testharness.SnapshotTest(t, "python", "references", tc.snapshotName, result)
 		})
 	}
 }
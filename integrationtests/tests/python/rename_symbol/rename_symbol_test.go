@@ -7,15 +7,19 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/python/internal"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestRenameSymbol tests the RenameSymbol functionality with the Python language server
 func TestRenameSymbol(t *testing.T) {
+	t.Parallel()
+
 	// Test with a successful rename of a symbol that exists
 	t.Run("SuccessfulRename", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -59,7 +63,7 @@ func TestRenameSymbol(t *testing.T) {
 			t.Errorf("Expected multiple occurrences to be renamed but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "python", "rename_symbol", "successful", result)
+		testharness.SnapshotTest(t, "python", "rename_symbol", "successful", result)
 
 		// Verify that the rename worked by checking for the updated constant name in the file
 		fileContent, err := suite.ReadFile("helper.py")
@@ -84,6 +88,8 @@ func TestRenameSymbol(t *testing.T) {
 
 	// Test with a symbol that doesn't exist
 	t.Run("SymbolNotFound", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -130,7 +136,7 @@ def dummy_function():
 			if !strings.Contains(result, "0 occurrences") {
 				t.Errorf("Expected 0 occurrences or error for non-existent symbol, but got: %s", result)
 			}
-			common.SnapshotTest(t, "python", "rename_symbol", "not_found", result)
+			testharness.SnapshotTest(t, "python", "rename_symbol", "not_found", result)
 		} else {
 			// If there was an error, check it and snapshot that instead
 			errorMessage := err.Error()
@@ -139,7 +145,7 @@ def dummy_function():
 				!strings.Contains(errorMessage, "cannot rename") {
 				t.Errorf("Expected error message about failed rename but got: %s", errorMessage)
 			}
-			common.SnapshotTest(t, "python", "rename_symbol", "not_found", errorMessage)
+			testharness.SnapshotTest(t, "python", "rename_symbol", "not_found", errorMessage)
 		}
 	})
 }
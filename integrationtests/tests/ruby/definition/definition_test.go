@@ -0,0 +1,56 @@
+package definition_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/ruby/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
+)
+
+// TestReadDefinition tests finding definitions by name with solargraph
+func TestReadDefinition(t *testing.T) {
+	t.Parallel()
+
+	suite := internal.GetTestSuite(t)
+
+	ctx, cancel := context.WithTimeout(suite.Context, 10*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name         string
+		symbolName   string
+		expectedText string
+		snapshotName string
+	}{
+		{
+			name:         "Class",
+			symbolName:   "Cart",
+			expectedText: "class Cart",
+			snapshotName: "class",
+		},
+		{
+			name:         "Method",
+			symbolName:   "total",
+			expectedText: "def total",
+			snapshotName: "method",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := tools.ReadDefinition(ctx, suite.Client, tc.symbolName)
+			if err != nil {
+				t.Fatalf("Failed to read definition: %v", err)
+			}
+			if !strings.Contains(result, tc.expectedText) {
+				t.Errorf("Definition does not contain expected text: %s", tc.expectedText)
+			}
+
+			testharness.SnapshotTest(t, "ruby", "definition", tc.snapshotName, result)
+		})
+	}
+}
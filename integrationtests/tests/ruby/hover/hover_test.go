@@ -0,0 +1,36 @@
+package hover_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/ruby/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
+)
+
+// TestHover tests hovering over a call to a method declared in another file,
+// which shows the method's doc comment
+func TestHover(t *testing.T) {
+	t.Parallel()
+
+	suite := internal.GetTestSuite(t)
+
+	ctx, cancel := context.WithTimeout(suite.Context, 10*time.Second)
+	defer cancel()
+
+	// The call to total
+	filePath := filepath.Join(suite.WorkspaceDir, "lib/checkout.rb")
+	result, err := tools.GetHoverInfo(ctx, suite.Client, filePath, 6, 11)
+	if err != nil {
+		t.Fatalf("GetHoverInfo failed: %v", err)
+	}
+	if !strings.Contains(result, "The sum of the prices") {
+		t.Errorf("Expected the method's doc comment, got: %s", result)
+	}
+
+	testharness.SnapshotTest(t, "ruby", "hover", "method-call", result)
+}
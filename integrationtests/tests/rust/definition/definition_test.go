@@ -7,15 +7,17 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/rust/internal"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestReadDefinition tests the ReadDefinition tool with various Rust type definitions
 func TestReadDefinition(t *testing.T) {
+	t.Parallel()
+
 	// Helper function to open all files and wait for indexing
-	openAllFilesAndWait := func(suite *common.TestSuite, ctx context.Context) {
+	openAllFilesAndWait := func(suite *testharness.TestSuite, ctx context.Context) {
 		// Open all files to ensure rust-analyzer indexes everything
 		filesToOpen := []string{
 			"src/main.rs",
@@ -114,7 +116,7 @@ func TestReadDefinition(t *testing.T) {
 			}
 
 			// Use snapshot testing to verify exact output
-			common.SnapshotTest(t, "rust", "definition", tc.snapshotName, result)
+			testharness.SnapshotTest(t, "rust", "definition", tc.snapshotName, result)
 		})
 	}
 }
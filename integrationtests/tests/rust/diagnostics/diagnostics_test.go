@@ -8,16 +8,18 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/rust/internal"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestDiagnostics tests diagnostics functionality with the Rust language server
 func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+
 	// Helper function to open all files and wait for indexing
-	openAllFilesAndWait := func(suite *common.TestSuite, ctx context.Context) {
+	openAllFilesAndWait := func(suite *testharness.TestSuite, ctx context.Context) {
 		// Open all files to ensure rust-analyzer indexes everything
 		filesToOpen := []string{
 			"src/main.rs",
@@ -39,6 +41,8 @@ func TestDiagnostics(t *testing.T) {
 	}
 	// Test with a clean file
 	t.Run("CleanFile", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -59,11 +63,13 @@ func TestDiagnostics(t *testing.T) {
 			t.Errorf("Expected no diagnostics but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "rust", "diagnostics", "clean", result)
+		testharness.SnapshotTest(t, "rust", "diagnostics", "clean", result)
 	})
 
 	// Test with a file containing an error
 	t.Run("FileWithError", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with code that contains errors
 		suite := internal.GetTestSuite(t)
 
@@ -89,12 +95,14 @@ func TestDiagnostics(t *testing.T) {
 		}
 
 		t.Skip("Flaky snapshot. If we have diagnostics then it's working, but the format changes often.")
-		// common.SnapshotTest(t, "rust", "diagnostics", "unreachable", result)
+		// testharness.SnapshotTest(t, "rust", "diagnostics", "unreachable", result)
 	})
 
 	// Test file dependency: file A (helper.rs) provides a function,
 	// file B (consumer.rs) uses it, then modify A to break B
 	t.Run("FileDependency", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -186,6 +194,6 @@ pub fn helper_function(value: i32) -> String {
 			t.Errorf("Expected error about wrong arguments but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "rust", "diagnostics", "dependency", result)
+		testharness.SnapshotTest(t, "rust", "diagnostics", "dependency", result)
 	})
 }
@@ -0,0 +1,66 @@
+package extensions_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/rust/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// TestRustAnalyzerExtensions tests the tools built on rust-analyzer's
+// extension methods against main.rs, whose main function prints with
+// println!
+func TestRustAnalyzerExtensions(t *testing.T) {
+	t.Parallel()
+
+	suite := internal.GetTestSuite(t)
+
+	ctx, cancel := context.WithTimeout(suite.Context, 20*time.Second)
+	defer cancel()
+
+	filePath := filepath.Join(suite.WorkspaceDir, "src/main.rs")
+	if err := suite.Client.OpenFile(ctx, filePath); err != nil {
+		t.Fatalf("Failed to open main.rs: %v", err)
+	}
+	// Give rust-analyzer time to load the crate, which it needs to resolve
+	// println! to the standard library
+	time.Sleep(5 * time.Second)
+
+	tests := []struct {
+		name     string
+		run      func() (string, error)
+		expected string
+	}{
+		{
+			name:     "Expand macro",
+			run:      func() (string, error) { return tools.ExpandMacro(ctx, suite.Client, filePath, 15, 5) },
+			expected: "_print",
+		},
+		{
+			name:     "Syntax tree",
+			run:      func() (string, error) { return tools.ViewSyntaxTree(ctx, suite.Client, filePath) },
+			expected: "SOURCE_FILE",
+		},
+		{
+			name:     "HIR",
+			run:      func() (string, error) { return tools.ViewIR(ctx, suite.Client, filePath, 15, 5, "hir") },
+			expected: "foo_bar",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.run()
+			if err != nil {
+				t.Fatalf("%s failed: %v", tt.name, err)
+			}
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected result to contain %q, got: %s", tt.expected, result)
+			}
+		})
+	}
+}
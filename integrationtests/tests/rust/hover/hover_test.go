@@ -7,15 +7,17 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/rust/internal"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestHover tests hover functionality with the Rust language server
 func TestHover(t *testing.T) {
+	t.Parallel()
+
 	// Helper function to open all files and wait for indexing
-	openAllFilesAndWait := func(suite *common.TestSuite, ctx context.Context) {
+	openAllFilesAndWait := func(suite *testharness.TestSuite, ctx context.Context) {
 		// Open all files to ensure rust-analyzer indexes everything
 		filesToOpen := []string{
 			"src/main.rs",
@@ -116,6 +118,8 @@ func TestHover(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
 			// Get a test suite
 			suite := internal.GetTestSuite(t)
 
@@ -137,7 +141,7 @@ func TestHover(t *testing.T) {
 				// For the "OutsideFile" test, we expect an error
 				if tt.name == "OutsideFile" {
 					// Create a snapshot even for error case
-					common.SnapshotTest(t, "rust", "hover", tt.snapshotName, err.Error())
+					testharness.SnapshotTest(t, "rust", "hover", tt.snapshotName, err.Error())
 					return
 				}
 				t.Fatalf("GetHoverInfo failed: %v", err)
@@ -153,7 +157,7 @@ func TestHover(t *testing.T) {
 				t.Errorf("Expected hover info NOT to contain %q but it was found: %s", tt.unexpectedText, result)
 			}
 
-			common.SnapshotTest(t, "rust", "hover", tt.snapshotName, result)
+			testharness.SnapshotTest(t, "rust", "hover", tt.snapshotName, result)
 		})
 	}
 }
@@ -5,27 +5,25 @@ import (
 	"path/filepath"
 	"testing"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // GetTestSuite returns a test suite for Rust language server tests
-func GetTestSuite(t *testing.T) *common.TestSuite {
+func GetTestSuite(t *testing.T) *testharness.TestSuite {
 	// Configure Rust LSP (rust-analyzer)
 	repoRoot, err := filepath.Abs("../../../..")
 	if err != nil {
 		t.Fatalf("Failed to get repo root: %v", err)
 	}
 
-	config := common.LSPTestConfig{
-		Name:             "rust",
-		Command:          "rust-analyzer",
-		Args:             []string{},
-		WorkspaceDir:     filepath.Join(repoRoot, "integrationtests/workspaces/rust"),
-		InitializeTimeMs: 5000,
+	config, err := testharness.Fixture("rust")
+	if err != nil {
+		t.Fatalf("Failed to get fixture: %v", err)
 	}
+	config.OutputDir = filepath.Join(repoRoot, "integrationtests/test-output")
 
 	// Create a test suite
-	suite := common.NewTestSuite(t, config)
+	suite := testharness.NewTestSuite(t, config)
 
 	// Set up the suite
 	err = suite.Setup()
@@ -7,16 +7,18 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/rust/internal"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestFindReferences tests the FindReferences tool with Rust symbols
 // that have references across different files
 func TestFindReferences(t *testing.T) {
+	t.Parallel()
+
 	// Helper function to open all files and wait for indexing
-	openAllFilesAndWait := func(suite *common.TestSuite, ctx context.Context) {
+	openAllFilesAndWait := func(suite *testharness.TestSuite, ctx context.Context) {
 		// Open all files to ensure rust-analyzer indexes everything
 		filesToOpen := []string{
 			"src/main.rs",
@@ -113,7 +115,7 @@ func TestFindReferences(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			// Call the FindReferences tool
-			result, err := tools.FindReferences(ctx, suite.Client, tc.symbolName)
+			result, err := tools.FindReferences(ctx, suite.Client, tc.symbolName, false, tools.OrderPath, nil)
 			if err != nil {
 				t.Fatalf("Failed to find references: %v", err)
 			}
@@ -131,7 +133,7 @@ func TestFindReferences(t *testing.T) {
 			}
 
 			// Use snapshot testing to verify exact output
-			common.SnapshotTest(t, "rust", "references", tc.snapshotName, result)
+			testharness.SnapshotTest(t, "rust", "references", tc.snapshotName, result)
 		})
 	}
 }
@@ -7,15 +7,17 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/rust/internal"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestRenameSymbol tests the RenameSymbol functionality with the Rust language server
 func TestRenameSymbol(t *testing.T) {
+	t.Parallel()
+
 	// Helper function to open all files and wait for indexing (copied from diagnostics_test.go)
-	openAllFilesAndWait := func(suite *common.TestSuite, ctx context.Context) {
+	openAllFilesAndWait := func(suite *testharness.TestSuite, ctx context.Context) {
 		// Open all files to ensure rust-analyzer indexes everything
 		filesToOpen := []string{
 			"src/main.rs",
@@ -41,6 +43,8 @@ func TestRenameSymbol(t *testing.T) {
 
 	// Test with a successful rename of a symbol that exists
 	t.Run("SuccessfulRename", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -70,7 +74,7 @@ func TestRenameSymbol(t *testing.T) {
 			t.Errorf("Expected multiple occurrences to be renamed but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "rust", "rename_symbol", "successful", result)
+		testharness.SnapshotTest(t, "rust", "rename_symbol", "successful", result)
 
 		// Verify that the rename worked by checking for the updated constant name in the file
 		fileContent, err := suite.ReadFile("src/types.rs")
@@ -95,6 +99,8 @@ func TestRenameSymbol(t *testing.T) {
 
 	// Test with a symbol that doesn't exist
 	t.Run("SymbolNotFound", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -134,7 +140,7 @@ fn dummy_function() {
 			if !strings.Contains(result, "0 occurrences") {
 				t.Errorf("Expected 0 occurrences or error for non-existent symbol, but got: %s", result)
 			}
-			common.SnapshotTest(t, "rust", "rename_symbol", "not_found", result)
+			testharness.SnapshotTest(t, "rust", "rename_symbol", "not_found", result)
 		} else {
 			// If there was an error, check it and snapshot that instead
 			errorMessage := err.Error()
@@ -143,7 +149,7 @@ fn dummy_function() {
 				!strings.Contains(errorMessage, "cannot rename") {
 				t.Errorf("Expected error message about failed rename but got: %s", errorMessage)
 			}
-			common.SnapshotTest(t, "rust", "rename_symbol", "not_found", errorMessage)
+			testharness.SnapshotTest(t, "rust", "rename_symbol", "not_found", errorMessage)
 		}
 	})
 }
@@ -0,0 +1,54 @@
+package diagnostics_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/terraform/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// TestDiagnostics tests that terraform-ls reports the syntax error in
+// broken.tf and nothing in main.tf
+func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("CleanFile", func(t *testing.T) {
+		t.Parallel()
+
+		suite := internal.GetTestSuite(t)
+
+		ctx, cancel := context.WithTimeout(suite.Context, 10*time.Second)
+		defer cancel()
+
+		filePath := filepath.Join(suite.WorkspaceDir, "main.tf")
+		result, err := tools.GetDiagnosticsForFile(ctx, suite.Client, filePath, 2, true)
+		if err != nil {
+			t.Fatalf("GetDiagnosticsForFile failed: %v", err)
+		}
+		if !strings.Contains(result, "No diagnostics found") {
+			t.Errorf("Expected no diagnostics, got: %s", result)
+		}
+	})
+
+	t.Run("UnclosedBlock", func(t *testing.T) {
+		t.Parallel()
+
+		suite := internal.GetTestSuite(t)
+
+		ctx, cancel := context.WithTimeout(suite.Context, 10*time.Second)
+		defer cancel()
+
+		filePath := filepath.Join(suite.WorkspaceDir, "broken.tf")
+		result, err := tools.GetDiagnosticsForFile(ctx, suite.Client, filePath, 2, true)
+		if err != nil {
+			t.Fatalf("GetDiagnosticsForFile failed: %v", err)
+		}
+		if !strings.Contains(result, "Unclosed configuration block") {
+			t.Errorf("Expected the unclosed block to be reported, got: %s", result)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package hover_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/terraform/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// TestHover tests hovering over a reference to a variable declared in
+// another file of the module
+func TestHover(t *testing.T) {
+	t.Parallel()
+
+	suite := internal.GetTestSuite(t)
+
+	ctx, cancel := context.WithTimeout(suite.Context, 10*time.Second)
+	defer cancel()
+
+	// var.region in the locals block
+	filePath := filepath.Join(suite.WorkspaceDir, "main.tf")
+	result, err := tools.GetHoverInfo(ctx, suite.Client, filePath, 7, 21)
+	if err != nil {
+		t.Fatalf("GetHoverInfo failed: %v", err)
+	}
+	for _, expected := range []string{"region", "string"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected hover to contain %q, got: %s", expected, result)
+		}
+	}
+}
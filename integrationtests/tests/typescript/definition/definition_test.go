@@ -6,13 +6,15 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/typescript/internal"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestReadDefinition tests the ReadDefinition tool with various TypeScript type definitions
 func TestReadDefinition(t *testing.T) {
+	t.Parallel()
+
 	suite := internal.GetTestSuite(t)
 
 	ctx, cancel := context.WithTimeout(suite.Context, 10*time.Second)
@@ -82,7 +84,7 @@ func TestReadDefinition(t *testing.T) {
 			}
 
 			// Use snapshot testing to verify exact output
-			common.SnapshotTest(t, "typescript", "definition", tc.snapshotName, result)
+			testharness.SnapshotTest(t, "typescript", "definition", tc.snapshotName, result)
 		})
 	}
 }
@@ -8,16 +8,18 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/typescript/internal"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestDiagnostics tests diagnostics functionality with the TypeScript language server
 func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+
 	// Helper function to open all files and wait for indexing
-	openAllFilesAndWait := func(suite *common.TestSuite, ctx context.Context) {
+	openAllFilesAndWait := func(suite *testharness.TestSuite, ctx context.Context) {
 		// Open all files to ensure TypeScript server indexes everything
 		filesToOpen := []string{
 			"main.ts",
@@ -41,6 +43,8 @@ func TestDiagnostics(t *testing.T) {
 	}
 	// Test with a clean file
 	t.Run("CleanFile", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -63,11 +67,13 @@ func TestDiagnostics(t *testing.T) {
 			t.Errorf("Expected no diagnostics but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "typescript", "diagnostics", "clean", result)
+		testharness.SnapshotTest(t, "typescript", "diagnostics", "clean", result)
 	})
 
 	// Test with a file containing an error
 	t.Run("FileWithError", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with code that contains errors
 		suite := internal.GetTestSuite(t)
 
@@ -116,12 +122,14 @@ console.log(result);
 			t.Errorf("Expected type error but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "typescript", "diagnostics", "type-error", result)
+		testharness.SnapshotTest(t, "typescript", "diagnostics", "type-error", result)
 	})
 
 	// Test file dependency: file A (helper.ts) provides a function,
 	// file B (consumer.ts) uses it, then modify A to break B
 	t.Run("FileDependency", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -270,6 +278,6 @@ export enum SharedEnum {
 			t.Errorf("Expected error about arguments/parameters but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "typescript", "diagnostics", "dependency", result)
+		testharness.SnapshotTest(t, "typescript", "diagnostics", "dependency", result)
 	})
 }
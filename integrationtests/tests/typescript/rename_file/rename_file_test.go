@@ -0,0 +1,75 @@
+package rename_file_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/typescript/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// TestRenameFile tests moving helper.ts, which consumer.ts and
+// another_consumer.ts import, into a subdirectory
+func TestRenameFile(t *testing.T) {
+	t.Parallel()
+
+	suite := internal.GetTestSuite(t)
+
+	ctx, cancel := context.WithTimeout(suite.Context, 20*time.Second)
+	defer cancel()
+
+	// Open the importers so that they are in the server's project
+	for _, file := range []string{"consumer.ts", "another_consumer.ts"} {
+		if err := suite.Client.OpenFile(ctx, filepath.Join(suite.WorkspaceDir, file)); err != nil {
+			t.Fatalf("Failed to open %s: %v", file, err)
+		}
+	}
+	time.Sleep(3 * time.Second)
+
+	result, err := tools.RenameTypeScriptFile(ctx, suite.Client, suite.WorkspaceDir, "helper.ts", "lib/helper.ts")
+	if err != nil {
+		t.Fatalf("RenameTypeScriptFile failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(suite.WorkspaceDir, "lib/helper.ts")); err != nil {
+		t.Fatalf("Expected helper.ts to be moved: %v", err)
+	}
+	for _, file := range []string{"consumer.ts", "another_consumer.ts"} {
+		content, err := os.ReadFile(filepath.Join(suite.WorkspaceDir, file))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		if !strings.Contains(string(content), "from './lib/helper'") {
+			t.Errorf("Expected %s to import ./lib/helper, got:\n%s\nResult: %s", file, content, result)
+		}
+	}
+}
+
+// TestOrganizeImports tests that the unused imports of consumer.ts are
+// kept when keepUnused is set
+func TestOrganizeImports(t *testing.T) {
+	t.Parallel()
+
+	suite := internal.GetTestSuite(t)
+
+	ctx, cancel := context.WithTimeout(suite.Context, 20*time.Second)
+	defer cancel()
+
+	filePath := filepath.Join(suite.WorkspaceDir, "consumer.ts")
+	result, err := tools.OrganizeTypeScriptImports(ctx, suite.Client, filePath, true)
+	if err != nil {
+		t.Fatalf("OrganizeTypeScriptImports failed: %v", err)
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read consumer.ts: %v", err)
+	}
+	for _, name := range []string{"SharedFunction", "SharedEnum"} {
+		if !strings.Contains(string(content), name) {
+			t.Errorf("Expected %s to stay imported, got:\n%s\nResult: %s", name, content, result)
+		}
+	}
+}
@@ -7,15 +7,17 @@ import (
 	"testing"
 	"time"
 
-	"github.com/isaacphi/mcp-language-server/integrationtests/tests/common"
 	"github.com/isaacphi/mcp-language-server/integrationtests/tests/typescript/internal"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/testharness"
 )
 
 // TestRenameSymbol tests the RenameSymbol functionality with the TypeScript language server
 func TestRenameSymbol(t *testing.T) {
+	t.Parallel()
+
 	// Helper function to open all files and wait for indexing (copied from diagnostics_test.go)
-	openAllFilesAndWait := func(suite *common.TestSuite, ctx context.Context) {
+	openAllFilesAndWait := func(suite *testharness.TestSuite, ctx context.Context) {
 		// Open all files to ensure TypeScript server indexes everything
 		filesToOpen := []string{
 			"main.ts",
@@ -40,6 +42,8 @@ func TestRenameSymbol(t *testing.T) {
 
 	// Test with a successful rename of a symbol that exists
 	t.Run("SuccessfulRename", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -67,7 +71,7 @@ func TestRenameSymbol(t *testing.T) {
 			t.Errorf("Expected multiple occurrences to be renamed but got: %s", result)
 		}
 
-		common.SnapshotTest(t, "typescript", "rename_symbol", "successful", result)
+		testharness.SnapshotTest(t, "typescript", "rename_symbol", "successful", result)
 
 		// Verify that the rename worked by checking for the updated constant name in the file
 		fileContent, err := suite.ReadFile("helper.ts")
@@ -92,6 +96,8 @@ func TestRenameSymbol(t *testing.T) {
 
 	// Test with a symbol that doesn't exist
 	t.Run("SymbolNotFound", func(t *testing.T) {
+		t.Parallel()
+
 		// Get a test suite with clean code
 		suite := internal.GetTestSuite(t)
 
@@ -131,7 +137,7 @@ function dummyFunction(): void {
 			if !strings.Contains(result, "0 occurrences") {
 				t.Errorf("Expected 0 occurrences or error for non-existent symbol, but got: %s", result)
 			}
-			common.SnapshotTest(t, "typescript", "rename_symbol", "not_found", result)
+			testharness.SnapshotTest(t, "typescript", "rename_symbol", "not_found", result)
 		} else {
 			// If there was an error, check it and snapshot that instead
 			errorMessage := err.Error()
@@ -140,7 +146,7 @@ function dummyFunction(): void {
 				!strings.Contains(errorMessage, "cannot rename") {
 				t.Errorf("Expected error message about failed rename but got: %s", errorMessage)
 			}
-			common.SnapshotTest(t, "typescript", "rename_symbol", "not_found", errorMessage)
+			testharness.SnapshotTest(t, "typescript", "rename_symbol", "not_found", errorMessage)
 		}
 	})
 }
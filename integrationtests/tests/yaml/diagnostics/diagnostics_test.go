@@ -0,0 +1,65 @@
+package diagnostics_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/yaml/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// TestDiagnostics tests that files are validated against the schemas they
+// are associated with at startup
+func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		file       string
+		expected   string
+		unexpected string
+	}{
+		{
+			name:     "KubernetesSchemaError",
+			file:     "k8s/invalid.yaml",
+			expected: "Incorrect type",
+		},
+		{
+			name:       "ValidManifest",
+			file:       "k8s/deployment.yaml",
+			unexpected: "ERROR",
+		},
+		{
+			// CloudFormation's short form tags are known
+			name:       "CloudFormationTags",
+			file:       "infra/stack.yaml",
+			unexpected: "Unresolved tag",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := internal.GetTestSuite(t)
+
+			ctx, cancel := context.WithTimeout(suite.Context, 20*time.Second)
+			defer cancel()
+
+			filePath := filepath.Join(suite.WorkspaceDir, tt.file)
+			result, err := tools.GetDiagnosticsForFile(ctx, suite.Client, filePath, 2, true)
+			if err != nil {
+				t.Fatalf("GetDiagnosticsForFile failed: %v", err)
+			}
+			if tt.expected != "" && !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected diagnostics to contain %q, got: %s", tt.expected, result)
+			}
+			if tt.unexpected != "" && strings.Contains(result, tt.unexpected) {
+				t.Errorf("Expected diagnostics not to contain %q, got: %s", tt.unexpected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package hover_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/integrationtests/tests/yaml/internal"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// TestHover tests that a field of a Kubernetes manifest is described by the
+// Kubernetes schema, which the manifest is associated with at startup
+func TestHover(t *testing.T) {
+	t.Parallel()
+
+	suite := internal.GetTestSuite(t)
+
+	ctx, cancel := context.WithTimeout(suite.Context, 20*time.Second)
+	defer cancel()
+
+	// replicas in the deployment's spec
+	filePath := filepath.Join(suite.WorkspaceDir, "k8s/deployment.yaml")
+	result, err := tools.GetHoverInfo(ctx, suite.Client, filePath, 6, 3)
+	if err != nil {
+		t.Fatalf("GetHoverInfo failed: %v", err)
+	}
+	if !strings.Contains(strings.ToLower(result), "desired pods") {
+		t.Errorf("Expected the schema's description of replicas, got: %s", result)
+	}
+}
@@ -0,0 +1,6 @@
+# Logging helpers shared by the scripts in bin
+
+# Prints an informational message to stderr
+log_info() {
+  echo "[info] $*" >&2
+}
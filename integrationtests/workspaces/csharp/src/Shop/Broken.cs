@@ -0,0 +1,11 @@
+namespace Shop;
+
+public static class Broken
+{
+    public static int Count(Cart cart)
+    {
+        // Total returns a decimal
+        int total = cart.Total();
+        return total;
+    }
+}
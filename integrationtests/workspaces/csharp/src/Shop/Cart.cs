@@ -0,0 +1,13 @@
+namespace Shop;
+
+/// <summary>A shopping cart holding the prices of its items.</summary>
+public class Cart
+{
+    private readonly List<decimal> _prices = new();
+
+    /// <summary>Adds an item with the given price.</summary>
+    public void Add(decimal price) => _prices.Add(price);
+
+    /// <summary>The sum of the prices of the items in the cart.</summary>
+    public decimal Total() => _prices.Sum();
+}
@@ -0,0 +1,6 @@
+using Shop;
+
+var cart = new Cart();
+cart.Add(4.50m);
+cart.Add(2.25m);
+Console.WriteLine(cart.Total());
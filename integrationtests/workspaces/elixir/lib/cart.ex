@@ -0,0 +1,6 @@
+defmodule Shop.Cart do
+  @moduledoc "A shopping cart holding the prices of its items."
+
+  @doc "The sum of the prices of the items in the cart."
+  def total(prices), do: Enum.sum(prices)
+end
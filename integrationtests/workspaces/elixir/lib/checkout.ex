@@ -0,0 +1,7 @@
+defmodule Shop.Checkout do
+  alias Shop.Cart
+
+  def run do
+    Cart.total([4.5, 2.25])
+  end
+end
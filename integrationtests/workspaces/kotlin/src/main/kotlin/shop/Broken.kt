@@ -0,0 +1,7 @@
+package shop
+
+fun count(cart: Cart): Int {
+    // total returns a Double
+    val total: Int = cart.total()
+    return total
+}
@@ -0,0 +1,14 @@
+package shop
+
+/** A shopping cart holding the prices of its items. */
+class Cart {
+    private val prices = mutableListOf<Double>()
+
+    /** Adds an item with the given price. */
+    fun add(price: Double) {
+        prices.add(price)
+    }
+
+    /** The sum of the prices of the items in the cart. */
+    fun total(): Double = prices.sum()
+}
@@ -0,0 +1,8 @@
+package shop
+
+fun main() {
+    val cart = Cart()
+    cart.add(4.5)
+    cart.add(2.25)
+    println(cart.total())
+}
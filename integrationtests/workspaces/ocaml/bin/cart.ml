@@ -0,0 +1,2 @@
+(** The sum of the prices of the items in a cart. *)
+let total prices = List.fold_left ( +. ) 0. prices
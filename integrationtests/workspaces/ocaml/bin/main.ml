@@ -0,0 +1 @@
+let () = print_float (Cart.total [ 4.5; 2.25 ])
@@ -0,0 +1,28 @@
+<?php
+
+namespace Shop;
+
+/**
+ * A shopping cart holding the prices of its items.
+ */
+class Cart
+{
+    /** @var float[] */
+    private array $prices = [];
+
+    /**
+     * Adds an item with the given price.
+     */
+    public function add(float $price): void
+    {
+        $this->prices[] = $price;
+    }
+
+    /**
+     * The sum of the prices of the items in the cart.
+     */
+    public function total(): float
+    {
+        return array_sum($this->prices);
+    }
+}
@@ -0,0 +1,8 @@
+<?php
+
+use Shop\Cart;
+
+$cart = new Cart();
+// Cart has no method named remove
+$cart->remove(4.5);
+echo undefined_function();
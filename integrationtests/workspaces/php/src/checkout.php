@@ -0,0 +1,8 @@
+<?php
+
+use Shop\Cart;
+
+$cart = new Cart();
+$cart->add(4.5);
+$cart->add(2.25);
+echo $cart->total();
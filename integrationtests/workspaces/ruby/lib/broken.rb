@@ -0,0 +1,6 @@
+require_relative "cart"
+require "no_such_library"
+
+def checkout(cart)
+  cart.total
+end
@@ -0,0 +1,19 @@
+# A shopping cart holding the prices of its items.
+class Cart
+  def initialize
+    @prices = []
+  end
+
+  # Adds an item with the given price.
+  # @param price [Float]
+  # @return [void]
+  def add(price)
+    @prices << price
+  end
+
+  # The sum of the prices of the items in the cart.
+  # @return [Float]
+  def total
+    @prices.sum
+  end
+end
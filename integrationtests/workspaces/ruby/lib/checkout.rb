@@ -0,0 +1,6 @@
+require_relative "cart"
+
+cart = Cart.new
+cart.add(4.5)
+cart.add(2.25)
+puts cart.total
@@ -0,0 +1,5 @@
+func count(_ cart: Cart) -> Int {
+    // total returns a Double
+    let total: Int = cart.total()
+    return total
+}
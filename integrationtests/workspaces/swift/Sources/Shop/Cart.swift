@@ -0,0 +1,14 @@
+/// A shopping cart holding the prices of its items.
+struct Cart {
+    private var prices: [Double] = []
+
+    /// Adds an item with the given price.
+    mutating func add(_ price: Double) {
+        prices.append(price)
+    }
+
+    /// The sum of the prices of the items in the cart.
+    func total() -> Double {
+        prices.reduce(0, +)
+    }
+}
@@ -0,0 +1,4 @@
+var cart = Cart()
+cart.add(4.5)
+cart.add(2.25)
+print(cart.total())
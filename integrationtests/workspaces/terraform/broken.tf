@@ -0,0 +1,3 @@
+# broken has an unclosed block
+output "broken" {
+  value = local.name
@@ -0,0 +1,12 @@
+terraform {
+  required_version = ">= 1.5"
+}
+
+locals {
+  # name is used by the outputs
+  name = "app-${var.region}"
+}
+
+output "name" {
+  value = local.name
+}
@@ -0,0 +1,5 @@
+variable "region" {
+  type        = string
+  description = "Region to deploy to"
+  default     = "eu-west-1"
+}
@@ -0,0 +1,6 @@
+/// The sum of the prices of the items in a cart.
+pub fn total(prices: []const f64) f64 {
+    var sum: f64 = 0;
+    for (prices) |price| sum += price;
+    return sum;
+}
@@ -0,0 +1,7 @@
+const std = @import("std");
+const cart = @import("cart.zig");
+
+pub fn main() void {
+    const prices = [_]f64{ 4.5, 2.25 };
+    std.debug.print("{d}\n", .{cart.total(&prices)});
+}
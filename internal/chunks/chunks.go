@@ -0,0 +1,280 @@
+package chunks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Chunk is a symbol-aligned piece of a file, sized for embedding
+type Chunk struct {
+	// ID is derived from the path, symbol and text, so it stays the same
+	// while the chunk's content does
+	ID   string `json:"id"`
+	Path string `json:"path"`
+	// Symbol is the dotted path of the symbol the chunk belongs to, empty
+	// for code outside any symbol
+	Symbol string `json:"symbol"`
+	Kind   string `json:"kind"`
+	// Part numbers the pieces of a chunk split to stay within the line limit
+	Part     int    `json:"part,omitempty"`
+	Language string `json:"language,omitempty"`
+	// Lines are 1-indexed and inclusive
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+	// Bytes are offsets into the file, the end exclusive
+	StartByte int    `json:"startByte"`
+	EndByte   int    `json:"endByte"`
+	Text      string `json:"text"`
+}
+
+// node is a symbol of a file with its 0-indexed line span
+type node struct {
+	name      string
+	kind      protocol.SymbolKind
+	startLine int
+	endLine   int
+	children  []*node
+}
+
+// functionKinds are chunked whole, including anything declared inside them
+var functionKinds = map[protocol.SymbolKind]bool{
+	protocol.Function:    true,
+	protocol.Method:      true,
+	protocol.Constructor: true,
+}
+
+// typeKinds are chunked whole unless their members are chunked, in which
+// case the rest of the type forms a chunk of its own
+var typeKinds = map[protocol.SymbolKind]bool{
+	protocol.Class:     true,
+	protocol.Struct:    true,
+	protocol.Interface: true,
+	protocol.Enum:      true,
+}
+
+// chunkCommentMarkers start the lines of doc comments and attributes that
+// belong to the declaration below them
+var chunkCommentMarkers = []string{"//", "/*", "*", "#", "--", "@"}
+
+// span is a run of lines assigned to a symbol
+type span struct {
+	symbol    string
+	kind      string
+	startLine int
+	endLine   int
+}
+
+// symbolTree converts document symbols to nodes. Flat symbol information is
+// nested by range, the way hierarchical symbols are.
+func symbolTree(symbols []protocol.DocumentSymbolResult) []*node {
+	var roots []*node
+	var flat []*node
+	var convert func(sym *protocol.DocumentSymbol) *node
+	convert = func(sym *protocol.DocumentSymbol) *node {
+		n := &node{name: sym.Name, kind: sym.Kind, startLine: int(sym.Range.Start.Line), endLine: int(sym.Range.End.Line)}
+		for i := range sym.Children {
+			n.children = append(n.children, convert(&sym.Children[i]))
+		}
+		return n
+	}
+	for _, sym := range symbols {
+		switch v := sym.(type) {
+		case *protocol.DocumentSymbol:
+			roots = append(roots, convert(v))
+		case *protocol.SymbolInformation:
+			rng := v.Location.Range
+			flat = append(flat, &node{name: v.Name, kind: v.Kind, startLine: int(rng.Start.Line), endLine: int(rng.End.Line)})
+		}
+	}
+	if len(flat) == 0 {
+		return roots
+	}
+
+	sort.SliceStable(flat, func(i, j int) bool {
+		if flat[i].startLine != flat[j].startLine {
+			return flat[i].startLine < flat[j].startLine
+		}
+		return flat[i].endLine > flat[j].endLine
+	})
+	var stack []*node
+	for _, n := range flat {
+		for len(stack) > 0 && stack[len(stack)-1].endLine < n.endLine {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, n)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, n)
+		}
+		stack = append(stack, n)
+	}
+	return roots
+}
+
+// hasChunkedMember reports whether any symbol below n gets a chunk of its own
+func hasChunkedMember(n *node) bool {
+	for _, child := range n.children {
+		if functionKinds[child.kind] || typeKinds[child.kind] || hasChunkedMember(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// symbolSpans assigns every line of a file to the innermost chunked symbol
+// that covers it. The result holds the span each line belongs to, or nil for
+// lines outside any symbol.
+func symbolSpans(roots []*node, lineCount int) []*span {
+	owners := make([]*span, lineCount)
+	var assign func(nodes []*node, prefix string)
+	assign = func(nodes []*node, prefix string) {
+		for _, n := range nodes {
+			path := n.name
+			if prefix != "" {
+				path = prefix + "." + n.name
+			}
+			start, end := max(n.startLine, 0), min(n.endLine, lineCount-1)
+			switch {
+			case functionKinds[n.kind] || (typeKinds[n.kind] && !hasChunkedMember(n)):
+				s := &span{symbol: path, kind: protocol.TableKindMap[n.kind], startLine: start, endLine: end}
+				for line := start; line <= end; line++ {
+					owners[line] = s
+				}
+			case typeKinds[n.kind]:
+				s := &span{symbol: path, kind: protocol.TableKindMap[n.kind], startLine: start, endLine: end}
+				for line := start; line <= end; line++ {
+					owners[line] = s
+				}
+				assign(n.children, path)
+			default:
+				assign(n.children, path)
+			}
+		}
+	}
+	assign(roots, "")
+	return owners
+}
+
+// attachComments gives the comment lines directly above a symbol to the
+// symbol, as long as they aren't part of another symbol
+func attachComments(lines []string, owners []*span) {
+	for line := 1; line < len(owners); line++ {
+		s := owners[line]
+		if s == nil || s.startLine != line || owners[line-1] == s {
+			continue
+		}
+		outside := owners[line-1]
+		for above := line - 1; above >= 0 && owners[above] == outside && isCommentLine(lines[above]); above-- {
+			owners[above] = s
+		}
+	}
+}
+
+// isCommentLine reports whether a line is a comment or an annotation
+func isCommentLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, marker := range chunkCommentMarkers {
+		if strings.HasPrefix(trimmed, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// FileChunks splits a file into chunks along its symbols. Every line of the
+// file with some code in it ends up in exactly one chunk: functions and types,
+// with the comments above them, get chunks of their own, and the code
+// between them is chunked by the symbol it sits in, or with an empty symbol
+// at the top level. Chunks longer than maxLines are split into parts; 0
+// means no limit.
+func FileChunks(path, language, content string, symbols []protocol.DocumentSymbolResult, maxLines int) []Chunk {
+	if content == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	offsets := make([]int, len(lines)+1)
+	for i, line := range lines {
+		offsets[i+1] = offsets[i] + len(line)
+	}
+
+	owners := symbolSpans(symbolTree(symbols), len(lines))
+	attachComments(lines, owners)
+
+	var chunks []Chunk
+	seen := make(map[string]int)
+	emit := func(owner *span, start, end, part int) {
+		// Blank lines at either end belong to no one
+		for start <= end && strings.TrimSpace(lines[start]) == "" {
+			start++
+		}
+		for end >= start && strings.TrimSpace(lines[end]) == "" {
+			end--
+		}
+		if start > end {
+			return
+		}
+		// Nor are lines with nothing but punctuation, like the brace
+		// closing a type whose methods are chunked on their own
+		text := content[offsets[start]:offsets[end+1]]
+		if strings.IndexFunc(text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			return
+		}
+		c := Chunk{
+			Path:      path,
+			Kind:      "Code",
+			Part:      part,
+			Language:  language,
+			StartLine: start + 1,
+			EndLine:   end + 1,
+			StartByte: offsets[start],
+			EndByte:   offsets[end+1],
+			Text:      text,
+		}
+		if owner != nil {
+			c.Symbol, c.Kind = owner.symbol, owner.kind
+		}
+		c.ID = chunkID(c)
+		// Identical chunks in a file, like repeated boilerplate, are told
+		// apart by their order
+		if n := seen[c.ID]; n > 0 {
+			seen[c.ID] = n + 1
+			c.ID += "-" + strconv.Itoa(n+1)
+		} else {
+			seen[c.ID] = 1
+		}
+		chunks = append(chunks, c)
+	}
+
+	for start := 0; start < len(lines); {
+		end := start
+		for end+1 < len(lines) && owners[end+1] == owners[start] {
+			end++
+		}
+		if maxLines <= 0 || end-start+1 <= maxLines {
+			emit(owners[start], start, end, 0)
+		} else {
+			for part, from := 1, start; from <= end; part, from = part+1, from+maxLines {
+				emit(owners[start], from, min(from+maxLines-1, end), part)
+			}
+		}
+		start = end + 1
+	}
+	return chunks
+}
+
+// chunkID hashes what identifies a chunk. The position is left out so that
+// editing one function doesn't change the IDs of the chunks below it.
+func chunkID(c Chunk) string {
+	sum := sha256.Sum256([]byte(c.Path + "\x00" + c.Symbol + "\x00" + strconv.Itoa(c.Part) + "\x00" + c.Text))
+	return hex.EncodeToString(sum[:8])
+}
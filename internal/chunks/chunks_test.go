@@ -0,0 +1,169 @@
+package chunks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const goSource = `package store
+
+import "fmt"
+
+// Store keeps items
+type Store struct {
+	items map[string]int
+}
+
+// Get returns an item
+func (s *Store) Get(key string) int {
+	return s.items[key]
+}
+
+var defaultStore = Store{}
+
+func helper() {
+	fmt.Println("x")
+}
+`
+
+func lines(start, end uint32) protocol.Range {
+	return protocol.Range{Start: protocol.Position{Line: start}, End: protocol.Position{Line: end, Character: 1}}
+}
+
+func symbol(name string, kind protocol.SymbolKind, rng protocol.Range, children ...protocol.DocumentSymbol) *protocol.DocumentSymbol {
+	return &protocol.DocumentSymbol{Name: name, Kind: kind, Range: rng, SelectionRange: rng, Children: children}
+}
+
+func summarize(chunks []Chunk) []string {
+	var result []string
+	for _, c := range chunks {
+		result = append(result, c.Kind+" "+c.Symbol+" "+strings.TrimSpace(strings.SplitN(c.Text, "\n", 2)[0]))
+	}
+	return result
+}
+
+func TestFileChunksAlignsWithSymbols(t *testing.T) {
+	symbols := []protocol.DocumentSymbolResult{
+		symbol("Store", protocol.Struct, lines(5, 7)),
+		symbol("(*Store).Get", protocol.Method, lines(10, 12)),
+		symbol("defaultStore", protocol.Variable, lines(14, 14)),
+		symbol("helper", protocol.Function, lines(16, 18)),
+	}
+	chunks := FileChunks("store/store.go", "go", goSource, symbols, 0)
+
+	assert.Equal(t, []string{
+		`Code  package store`,
+		`Struct Store // Store keeps items`,
+		`Method (*Store).Get // Get returns an item`,
+		`Code  var defaultStore = Store{}`,
+		`Function helper func helper() {`,
+	}, summarize(chunks))
+
+	// Byte ranges and lines locate the text in the file
+	for _, c := range chunks {
+		assert.Equal(t, c.Text, goSource[c.StartByte:c.EndByte])
+		assert.Equal(t, "store/store.go", c.Path)
+		assert.Equal(t, "go", c.Language)
+	}
+	assert.Equal(t, 1, chunks[0].StartLine)
+	assert.Equal(t, 3, chunks[0].EndLine)
+	assert.Equal(t, 10, chunks[2].StartLine)
+	assert.Equal(t, 13, chunks[2].EndLine)
+}
+
+func TestFileChunksIDsAreStable(t *testing.T) {
+	symbols := []protocol.DocumentSymbolResult{symbol("helper", protocol.Function, lines(16, 18))}
+	before := FileChunks("store.go", "go", goSource, symbols, 0)
+
+	// Adding lines above moves the function without changing it
+	shifted := "// Package store\n\n" + goSource
+	symbols = []protocol.DocumentSymbolResult{symbol("helper", protocol.Function, lines(18, 20))}
+	after := FileChunks("store.go", "go", shifted, symbols, 0)
+
+	assert.Equal(t, before[len(before)-1].ID, after[len(after)-1].ID)
+	assert.NotEqual(t, before[0].ID, after[0].ID)
+
+	ids := make(map[string]bool)
+	for _, c := range after {
+		assert.False(t, ids[c.ID], "duplicate ID %s", c.ID)
+		ids[c.ID] = true
+	}
+}
+
+func TestFileChunksSplitsTypesWithMethods(t *testing.T) {
+	source := `class Cart:
+    """A shopping cart"""
+    tax = 0.2
+
+    def total(self):
+        return 1
+
+    # Adds an item
+    def add(self, item):
+        pass
+`
+	symbols := []protocol.DocumentSymbolResult{
+		symbol("Cart", protocol.Class, lines(0, 9),
+			*symbol("total", protocol.Method, lines(4, 5)),
+			*symbol("add", protocol.Method, lines(8, 9)),
+		),
+	}
+	chunks := FileChunks("cart.py", "python", source, symbols, 0)
+
+	assert.Equal(t, []string{
+		`Class Cart class Cart:`,
+		`Method Cart.total def total(self):`,
+		`Method Cart.add # Adds an item`,
+	}, summarize(chunks))
+}
+
+func TestFileChunksNestsFlatSymbols(t *testing.T) {
+	source := "class A {\n  void f() {\n  }\n}\n"
+	at := func(name string, kind protocol.SymbolKind, rng protocol.Range) *protocol.SymbolInformation {
+		return &protocol.SymbolInformation{Name: name, Kind: kind, Location: protocol.Location{Range: rng}}
+	}
+	symbols := []protocol.DocumentSymbolResult{
+		at("f", protocol.Method, lines(1, 2)),
+		at("A", protocol.Class, lines(0, 3)),
+	}
+	chunks := FileChunks("A.java", "java", source, symbols, 0)
+
+	// The closing brace alone isn't worth a chunk
+	assert.Equal(t, []string{
+		`Class A class A {`,
+		`Method A.f void f() {`,
+	}, summarize(chunks))
+}
+
+func TestFileChunksSplitsLongChunks(t *testing.T) {
+	var source strings.Builder
+	source.WriteString("func long() {\n")
+	for i := 0; i < 8; i++ {
+		source.WriteString("\tstep()\n")
+	}
+	source.WriteString("}\n")
+	symbols := []protocol.DocumentSymbolResult{symbol("long", protocol.Function, lines(0, 9))}
+
+	chunks := FileChunks("long.go", "go", source.String(), symbols, 4)
+	require.Len(t, chunks, 3)
+	for i, c := range chunks {
+		assert.Equal(t, i+1, c.Part)
+		assert.Equal(t, "long", c.Symbol)
+	}
+	assert.Equal(t, 1, chunks[0].StartLine)
+	assert.Equal(t, 9, chunks[2].StartLine)
+	assert.Equal(t, 10, chunks[2].EndLine)
+}
+
+func TestFileChunksWithoutSymbols(t *testing.T) {
+	chunks := FileChunks("notes.txt", "", "first\n\nsecond\n", nil, 0)
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "", chunks[0].Symbol)
+	assert.Equal(t, "first\n\nsecond\n", chunks[0].Text)
+
+	assert.Empty(t, FileChunks("empty.txt", "", "", nil, 0))
+}
@@ -0,0 +1,129 @@
+package chunks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/logging"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+var chunksLogger = logging.NewLogger(logging.Index)
+
+// Exporter walks a workspace and writes the chunks of every file as JSON
+// lines
+type Exporter struct {
+	client   *lsp.Client
+	root     string
+	maxLines int
+	encoder  *json.Encoder
+}
+
+// NewExporter creates an exporter that writes the chunks of root to w,
+// splitting chunks longer than maxLines (0 means no limit)
+func NewExporter(client *lsp.Client, root string, w io.Writer, maxLines int) *Exporter {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return &Exporter{client: client, root: root, maxLines: maxLines, encoder: encoder}
+}
+
+// Run exports the workspace. Files the language server can't answer for are
+// chunked along the declarations found by pattern matching, and binary files
+// are skipped.
+func (e *Exporter) Run(ctx context.Context) error {
+	var files, total int
+	err := heuristics.WalkSourceFiles(ctx, e.root, func(path string) error {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			chunksLogger.Warn("Skipping %s: %v", path, err)
+			return nil
+		}
+		if !utf8.Valid(content) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(e.root, path)
+		if err != nil {
+			rel = path
+		}
+		languageID := lsp.DetectLanguageID("file://" + path)
+		chunks := FileChunks(filepath.ToSlash(rel), string(languageID), string(content), e.symbols(ctx, path, string(content), languageID), e.maxLines)
+		for _, chunk := range chunks {
+			if err := e.encoder.Encode(chunk); err != nil {
+				return fmt.Errorf("failed to write chunks: %w", err)
+			}
+		}
+		files++
+		total += len(chunks)
+		chunksLogger.Debug("Chunked %s into %d chunks", rel, len(chunks))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	chunksLogger.Info("Exported %d chunks from %d files", total, files)
+	return nil
+}
+
+// symbols returns the symbols of a file from the language server, falling
+// back to pattern matching
+func (e *Exporter) symbols(ctx context.Context, path, content string, languageID protocol.LanguageKind) []protocol.DocumentSymbolResult {
+	if languageID != "" {
+		symbols, err := e.documentSymbols(ctx, path)
+		if err == nil && len(symbols) > 0 {
+			return symbols
+		}
+		if err != nil {
+			chunksLogger.Debug("Using pattern matching for %s: %v", path, err)
+		}
+	}
+
+	var symbols []protocol.DocumentSymbolResult
+	for _, sym := range heuristics.ExtractSymbols(path, content) {
+		symbols = append(symbols, &protocol.SymbolInformation{
+			Name: sym.Name,
+			Kind: sym.Kind,
+			Location: protocol.Location{
+				URI: protocol.URIFromPath(path),
+				Range: protocol.Range{
+					Start: protocol.Position{Line: uint32(sym.Line)},
+					End:   protocol.Position{Line: uint32(sym.EndLine)},
+				},
+			},
+		})
+	}
+	return symbols
+}
+
+// documentSymbols asks the language server for the symbols of a file
+func (e *Exporter) documentSymbols(ctx context.Context, path string) ([]protocol.DocumentSymbolResult, error) {
+	if err := e.client.OpenFile(ctx, path); err != nil {
+		return nil, err
+	}
+	// Files are only needed once, so the server needn't keep them all open
+	defer func() {
+		if err := e.client.CloseFile(ctx, path); err != nil {
+			chunksLogger.Debug("Failed to close %s: %v", path, err)
+		}
+	}()
+	uri := protocol.URIFromPath(path)
+	symResult, err := e.client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document symbols: %w", err)
+	}
+	symbols, err := symResult.Results()
+	if err != nil {
+		return nil, fmt.Errorf("failed to process document symbols: %w", err)
+	}
+	return symbols, nil
+}
@@ -0,0 +1,311 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emptyTree is the hash of git's empty tree, used as the base when a
+// repository has no commit old enough
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// uncommitted is the commit hash git blame reports for changed lines that
+// are not committed yet
+const uncommitted = "0000000000000000000000000000000000000000"
+
+// Repo runs git commands in a working tree
+type Repo struct {
+	root string
+}
+
+// Open finds the repository containing dir
+func Open(ctx context.Context, dir string) (*Repo, error) {
+	out, err := run(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	return &Repo{root: strings.TrimSpace(out)}, nil
+}
+
+// Root returns the top level directory of the working tree
+func (r *Repo) Root() string {
+	return r.root
+}
+
+func run(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+func (r *Repo) run(ctx context.Context, args ...string) (string, error) {
+	return run(ctx, r.root, args...)
+}
+
+// checkArg rejects user supplied values that git would parse as options
+func checkArg(name, value string) error {
+	if strings.HasPrefix(value, "-") {
+		return fmt.Errorf("%s must not start with -: %s", name, value)
+	}
+	return nil
+}
+
+// Commit describes a commit
+type Commit struct {
+	Hash    string
+	Author  string
+	Time    time.Time
+	Summary string
+}
+
+// ShortHash returns the abbreviated commit hash
+func (c Commit) ShortHash() string {
+	if len(c.Hash) > 8 {
+		return c.Hash[:8]
+	}
+	return c.Hash
+}
+
+// Uncommitted reports whether the commit stands for changes in the working tree
+func (c Commit) Uncommitted() bool {
+	return c.Hash == uncommitted
+}
+
+// BlameLine is the commit that last changed a line
+type BlameLine struct {
+	// Line is 1-indexed
+	Line   int
+	Commit Commit
+}
+
+// Blame returns the last commit to change each line from startLine to
+// endLine of a file. Lines are 1-indexed and inclusive.
+func (r *Repo) Blame(ctx context.Context, path string, startLine, endLine int) ([]BlameLine, error) {
+	out, err := r.run(ctx, "blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", startLine, endLine), "--", path)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlame(out), nil
+}
+
+// parseBlame reads git blame --porcelain output. Commit details are only
+// printed the first time a commit appears.
+func parseBlame(out string) []BlameLine {
+	commits := make(map[string]*Commit)
+	var lines []BlameLine
+	var current *Commit
+	var currentLine int
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\t") {
+			if current != nil {
+				lines = append(lines, BlameLine{Line: currentLine, Commit: *current})
+			}
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		if len(key) == 40 && isHex(key) {
+			fields := strings.Fields(value)
+			if len(fields) >= 2 {
+				currentLine, _ = strconv.Atoi(fields[1])
+			}
+			if commits[key] == nil {
+				commits[key] = &Commit{Hash: key}
+			}
+			current = commits[key]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		switch key {
+		case "author":
+			current.Author = value
+		case "author-time":
+			if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+				current.Time = time.Unix(seconds, 0)
+			}
+		case "summary":
+			current.Summary = value
+		}
+	}
+	return lines
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// LineRange is a span of lines, 1-indexed and inclusive
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// BaseBefore returns the last commit on HEAD made before since, which may be
+// anything git accepts as a date such as "2 weeks ago" or "2024-01-31". If
+// there is no such commit the empty tree is returned so that everything
+// counts as changed.
+func (r *Repo) BaseBefore(ctx context.Context, since string) (string, error) {
+	if err := checkArg("since", since); err != nil {
+		return "", err
+	}
+	out, err := r.run(ctx, "rev-list", "-1", "--before="+since, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if base := strings.TrimSpace(out); base != "" {
+		return base, nil
+	}
+	return emptyTree, nil
+}
+
+// ChangedLines compares the working tree with base and returns the changed
+// lines of each file under path, numbered as in the working tree. Files that
+// were deleted are left out. Paths are absolute.
+func (r *Repo) ChangedLines(ctx context.Context, base, path string) (map[string][]LineRange, error) {
+	if err := checkArg("base", base); err != nil {
+		return nil, err
+	}
+	out, err := r.run(ctx, "diff", "-U0", "--no-color", "--no-ext-diff", "--no-renames", base, "--", path)
+	if err != nil {
+		return nil, err
+	}
+	return parseDiff(out, r.root), nil
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// parseDiff collects the added and modified lines of a zero context diff.
+// A pure deletion is recorded as the line after it, since that is where the
+// change shows in the new file.
+func parseDiff(out, root string) map[string][]LineRange {
+	changes := make(map[string][]LineRange)
+	var current string
+	for _, line := range strings.Split(out, "\n") {
+		if file, ok := strings.CutPrefix(line, "+++ "); ok {
+			current = ""
+			if file != "/dev/null" {
+				current = filepath.Join(root, strings.TrimPrefix(file, "b/"))
+			}
+			continue
+		}
+		if current == "" {
+			continue
+		}
+		m := hunkHeaderRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		start, err := strconv.ParseInt(m[1], 10, 32)
+		if err != nil {
+			continue
+		}
+		count := int64(1)
+		if m[2] != "" {
+			if count, err = strconv.ParseInt(m[2], 10, 32); err != nil {
+				continue
+			}
+		}
+		if count == 0 {
+			// git numbers a deletion by the line before it
+			changes[current] = append(changes[current], LineRange{Start: int(start) + 1, End: int(start) + 1})
+			continue
+		}
+		if start == 0 {
+			// Only an empty side starts at line 0
+			continue
+		}
+		changes[current] = append(changes[current], LineRange{Start: int(start), End: int(start + count - 1)})
+	}
+	return changes
+}
+
+// Untracked lists the files under path that are not tracked by git and not
+// ignored. Paths are absolute.
+func (r *Repo) Untracked(ctx context.Context, path string) ([]string, error) {
+	out, err := r.run(ctx, "ls-files", "--others", "--exclude-standard", "--full-name", "-z", "--", path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, file := range strings.Split(out, "\x00") {
+		if file != "" {
+			files = append(files, filepath.Join(r.root, file))
+		}
+	}
+	return files, nil
+}
+
+// Log lists up to max commits since a date that touched path, newest first
+func (r *Repo) Log(ctx context.Context, since, path string, max int) ([]Commit, error) {
+	if err := checkArg("since", since); err != nil {
+		return nil, err
+	}
+	out, err := r.run(ctx, "log", fmt.Sprintf("--max-count=%d", max), "--since="+since, "--format=%H%x00%an%x00%at%x00%s", "--", path)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		seconds, _ := strconv.ParseInt(fields[2], 10, 64)
+		commits = append(commits, Commit{Hash: fields[0], Author: fields[1], Time: time.Unix(seconds, 0), Summary: fields[3]})
+	}
+	return commits, nil
+}
+
+// FormatAge describes how long ago t was in the largest sensible unit
+func FormatAge(t, now time.Time) string {
+	d := now.Sub(t)
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour")
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day")
+	case d < 365*24*time.Hour:
+		return plural(int(d/(30*24*time.Hour)), "month")
+	default:
+		return plural(int(d/(365*24*time.Hour)), "year")
+	}
+}
@@ -0,0 +1,138 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initRepo creates a repository with one commit of main.go
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	gitCmd := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Alice", "-c", "user.email=alice@example.com"}, args...)...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE=2024-01-01T00:00:00Z", "GIT_COMMITTER_DATE=2024-01-01T00:00:00Z")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	gitCmd("init", "-q")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc a() {}\n\nfunc b() {}\n"), 0644))
+	gitCmd("add", ".")
+	gitCmd("commit", "-q", "-m", "Add main")
+	return dir
+}
+
+func TestBlame(t *testing.T) {
+	dir := initRepo(t)
+	repo, err := Open(context.Background(), dir)
+	require.NoError(t, err)
+
+	lines, err := repo.Blame(context.Background(), "main.go", 3, 5)
+	require.NoError(t, err)
+	require.Len(t, lines, 3)
+	assert.Equal(t, 3, lines[0].Line)
+	assert.Equal(t, "Alice", lines[0].Commit.Author)
+	assert.Equal(t, "Add main", lines[0].Commit.Summary)
+	assert.Equal(t, int64(1704067200), lines[0].Commit.Time.Unix())
+	assert.False(t, lines[0].Commit.Uncommitted())
+}
+
+func TestChangedLines(t *testing.T) {
+	dir := initRepo(t)
+	repo, err := Open(context.Background(), dir)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc a() {}\n\nfunc b() {\n\tprintln()\n}\n"), 0644))
+
+	changes, err := repo.ChangedLines(context.Background(), "HEAD", ".")
+	require.NoError(t, err)
+	main, err := filepath.EvalSymlinks(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	for path, ranges := range changes {
+		resolved, err := filepath.EvalSymlinks(path)
+		require.NoError(t, err)
+		assert.Equal(t, main, resolved)
+		assert.Equal(t, []LineRange{{Start: 5, End: 7}}, ranges)
+	}
+	assert.Len(t, changes, 1)
+
+	base, err := repo.BaseBefore(context.Background(), "2020-01-01")
+	require.NoError(t, err)
+	assert.Equal(t, emptyTree, base)
+
+	_, err = repo.BaseBefore(context.Background(), "--output=/tmp/x")
+	assert.Error(t, err)
+}
+
+func TestUntracked(t *testing.T) {
+	dir := initRepo(t)
+	repo, err := Open(context.Background(), dir)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.log\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "debug.log"), []byte("ignored\n"), 0644))
+
+	files, err := repo.Untracked(context.Background(), ".")
+	require.NoError(t, err)
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = filepath.Base(file)
+	}
+	assert.ElementsMatch(t, []string{".gitignore", "new.go"}, names)
+}
+
+func TestLog(t *testing.T) {
+	dir := initRepo(t)
+	repo, err := Open(context.Background(), dir)
+	require.NoError(t, err)
+
+	commits, err := repo.Log(context.Background(), "2023-12-01", "main.go", 10)
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	assert.Equal(t, "Add main", commits[0].Summary)
+	assert.Len(t, commits[0].ShortHash(), 8)
+}
+
+func TestParseDiffDeletion(t *testing.T) {
+	out := "diff --git a/x.go b/x.go\n--- a/x.go\n+++ b/x.go\n@@ -4,2 +3,0 @@\n-gone\n-gone\n"
+	changes := parseDiff(out, "/repo")
+	assert.Equal(t, []LineRange{{Start: 4, End: 4}}, changes["/repo/x.go"])
+}
+
+// FuzzParseDiff checks that whatever git prints, the changed lines are
+// valid ranges
+func FuzzParseDiff(f *testing.F) {
+	f.Add("diff --git a/x.go b/x.go\n--- a/x.go\n+++ b/x.go\n@@ -4,2 +3,0 @@\n-gone\n-gone\n")
+	f.Add("--- a/x.go\n+++ b/x.go\n@@ -1 +1,3 @@\n+a\n+b\n+c\n@@ -9,0 +12 @@\n+d\n")
+	f.Add("--- a/x.go\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-a\n-b\n")
+	f.Add("+++ b/x.go\n@@ -1 +99999999999999999999,2 @@\n")
+	f.Fuzz(func(t *testing.T, out string) {
+		for file, ranges := range parseDiff(out, "/repo") {
+			for _, r := range ranges {
+				if r.Start < 1 || r.End < r.Start {
+					t.Fatalf("invalid range %+v for %s", r, file)
+				}
+			}
+		}
+	})
+}
+
+func TestFormatAge(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "just now", FormatAge(now.Add(-time.Second), now))
+	assert.Equal(t, "1 hour ago", FormatAge(now.Add(-time.Hour), now))
+	assert.Equal(t, "3 days ago", FormatAge(now.Add(-72*time.Hour), now))
+	assert.Equal(t, "2 years ago", FormatAge(now.AddDate(-2, 0, 0), now))
+}
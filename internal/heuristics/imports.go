@@ -0,0 +1,102 @@
+package heuristics
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Import is a dependency of a source file as written in the source
+type Import struct {
+	Path string
+	// Line is 0-indexed
+	Line int
+}
+
+var (
+	goImportSingleRegex = regexp.MustCompile(`^import\s+(?:[\w.]+\s+)?"([^"]+)"`)
+	goImportSpecRegex   = regexp.MustCompile(`^\s*(?:[\w.]+\s+)?"([^"]+)"`)
+
+	pythonImportRegex     = regexp.MustCompile(`^\s*import\s+(.+)`)
+	pythonFromImportRegex = regexp.MustCompile(`^\s*from\s+(\.*[\w.]*)\s+import\b`)
+
+	jsImportRegex = regexp.MustCompile(`(?:^|\s)(?:import|export)\b[^'"]*?\bfrom\s+['"]([^'"]+)['"]|^\s*import\s+['"]([^'"]+)['"]|\brequire\(\s*['"]([^'"]+)['"]\s*\)|\bimport\(\s*['"]([^'"]+)['"]\s*\)`)
+
+	cIncludeRegex = regexp.MustCompile(`^\s*#\s*include\s+(?:"([^"]+)"|<([^>]+)>)`)
+
+	rustUseRegex = regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?use\s+((?:::)?[\w]+(?:::[\w]+)*)`)
+)
+
+// ExtractImports finds the imports of a Go, Python, JavaScript/TypeScript,
+// C/C++ or Rust file. Imports are returned as written, e.g. a relative
+// JavaScript import stays relative.
+func ExtractImports(path string, content string) []Import {
+	lines := strings.Split(content, "\n")
+	var imports []Import
+	add := func(importPath string, line int) {
+		if importPath != "" {
+			imports = append(imports, Import{Path: importPath, Line: line})
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		inBlock := false
+		for i, line := range lines {
+			trimmed := strings.TrimSpace(line)
+			switch {
+			case inBlock && strings.HasPrefix(trimmed, ")"):
+				inBlock = false
+			case inBlock:
+				if m := goImportSpecRegex.FindStringSubmatch(line); m != nil {
+					add(m[1], i)
+				}
+			case strings.HasPrefix(trimmed, "import ("):
+				inBlock = true
+			default:
+				if m := goImportSingleRegex.FindStringSubmatch(trimmed); m != nil {
+					add(m[1], i)
+				}
+			}
+		}
+
+	case ".py", ".pyi":
+		for i, line := range lines {
+			if m := pythonFromImportRegex.FindStringSubmatch(line); m != nil {
+				add(m[1], i)
+			} else if m := pythonImportRegex.FindStringSubmatch(line); m != nil {
+				for _, part := range strings.Split(m[1], ",") {
+					fields := strings.Fields(part)
+					if len(fields) > 0 {
+						add(strings.Trim(fields[0], "()"), i)
+					}
+				}
+			}
+		}
+
+	case ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs":
+		for i, line := range lines {
+			for _, m := range jsImportRegex.FindAllStringSubmatch(line, -1) {
+				for _, group := range m[1:] {
+					add(group, i)
+				}
+			}
+		}
+
+	case ".c", ".h", ".cpp", ".cc", ".cxx", ".hpp", ".hh", ".hxx":
+		for i, line := range lines {
+			if m := cIncludeRegex.FindStringSubmatch(line); m != nil {
+				add(m[1]+m[2], i)
+			}
+		}
+
+	case ".rs":
+		for i, line := range lines {
+			if m := rustUseRegex.FindStringSubmatch(line); m != nil {
+				add(strings.TrimPrefix(m[1], "::"), i)
+			}
+		}
+	}
+
+	return imports
+}
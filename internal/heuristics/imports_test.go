@@ -0,0 +1,60 @@
+package heuristics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func importPaths(imports []Import) []string {
+	var paths []string
+	for _, imp := range imports {
+		paths = append(paths, imp.Path)
+	}
+	return paths
+}
+
+func TestExtractImportsGo(t *testing.T) {
+	content := `package main
+
+import "fmt"
+
+import (
+	"os"
+	lsp "example.com/app/internal/lsp"
+	_ "embed"
+)
+
+func main() {}
+`
+	imports := ExtractImports("main.go", content)
+	assert.Equal(t, []string{"fmt", "os", "example.com/app/internal/lsp", "embed"}, importPaths(imports))
+	assert.Equal(t, 2, imports[0].Line)
+	assert.Equal(t, 6, imports[2].Line)
+}
+
+func TestExtractImportsPython(t *testing.T) {
+	content := `import os, sys as system
+from collections import OrderedDict
+from .models import User
+from .. import config
+`
+	assert.Equal(t, []string{"os", "sys", "collections", ".models", ".."}, importPaths(ExtractImports("app.py", content)))
+}
+
+func TestExtractImportsJavaScript(t *testing.T) {
+	content := `import React from 'react';
+import { a, b } from "./utils";
+import './styles.css';
+export * from '../shared';
+const fs = require('fs');
+const lazy = import('./lazy');
+`
+	assert.Equal(t, []string{"react", "./utils", "./styles.css", "../shared", "fs", "./lazy"}, importPaths(ExtractImports("app.ts", content)))
+}
+
+func TestExtractImportsCAndRust(t *testing.T) {
+	assert.Equal(t, []string{"stdio.h", "util.h"}, importPaths(ExtractImports("main.c", "#include <stdio.h>\n#include \"util.h\"\n")))
+	assert.Equal(t, []string{"std::collections::HashMap", "crate::config", "super::util"},
+		importPaths(ExtractImports("lib.rs", "use std::collections::HashMap;\npub use crate::config::{Config, load};\nuse super::util;\n")))
+}
@@ -0,0 +1,248 @@
+package heuristics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/walk"
+	"github.com/isaacphi/mcp-language-server/internal/watcher"
+)
+
+// Match is a single line matching a search
+type Match struct {
+	Path string
+	// Line and Column are 0-indexed
+	Line   int
+	Column int
+	Text   string
+	// Omitted is Binary or Minified for the one match reported for a file
+	// whose lines aren't shown. Line and Column are those of its first match.
+	Omitted string
+}
+
+// maxLineLength is the longest line that will be scanned. Longer lines are
+// usually minified or generated content.
+const maxLineLength = 1024 * 1024
+
+// Kinds of files whose content is left out of results
+const (
+	Binary   = "binary"
+	Minified = "minified"
+)
+
+// minifiedLineLength is the longest line expected in code written by hand;
+// a file with a longer one is taken to be minified or generated
+const minifiedLineLength = 4096
+
+// OpaqueKind reports whether content is Binary, because it has a null byte,
+// or Minified, because it has a line longer than written code has. It
+// returns "" for content worth showing as text.
+func OpaqueKind(content []byte) string {
+	if bytes.IndexByte(content, 0) >= 0 {
+		return Binary
+	}
+	for len(content) > 0 {
+		end := bytes.IndexByte(content, '\n')
+		if end < 0 {
+			end = len(content)
+		}
+		if end > minifiedLineLength {
+			return Minified
+		}
+		content = content[min(end+1, len(content)):]
+	}
+	return ""
+}
+
+// OmittedMarker is shown in place of the content of a Binary or Minified file
+func OmittedMarker(kind string) string {
+	return fmt.Sprintf("[%s file, content not shown]", kind)
+}
+
+// sourceFilter leaves out the files and directories excluded by the default
+// watcher configuration or by .gitignore
+type sourceFilter struct {
+	root      string
+	config    *watcher.WatcherConfig
+	gitignore *watcher.GitignoreMatcher
+}
+
+func newSourceFilter(root string) sourceFilter {
+	// A missing or unreadable .gitignore just means nothing is ignored
+	gitignore, _ := watcher.NewGitignoreMatcher(root)
+	return sourceFilter{root: root, config: watcher.DefaultWatcherConfig(), gitignore: gitignore}
+}
+
+// skipDir reports whether a directory below the root is left out
+func (f sourceFilter) skipDir(path string, d fs.DirEntry) bool {
+	name := d.Name()
+	if strings.HasPrefix(name, ".") || f.config.ExcludedDirs[name] {
+		return true
+	}
+	return f.gitignore != nil && f.gitignore.ShouldIgnore(path, true)
+}
+
+// skipFile reports whether a file is left out
+func (f sourceFilter) skipFile(path string, d fs.DirEntry) bool {
+	name := d.Name()
+	if strings.HasPrefix(name, ".") {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	if f.config.ExcludedFileExtensions[ext] || f.config.LargeBinaryExtensions[ext] {
+		return true
+	}
+	if f.gitignore != nil && f.gitignore.ShouldIgnore(path, false) {
+		return true
+	}
+	info, err := d.Info()
+	return err != nil || info.Size() > f.config.MaxFileSize
+}
+
+// WalkSourceFiles calls fn for every file in the workspace that is not excluded
+// by the default watcher configuration or by .gitignore, one at a time and in
+// lexical order
+func WalkSourceFiles(ctx context.Context, root string, fn func(path string) error) error {
+	filter := newSourceFilter(root)
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if d.IsDir() {
+			if path != root && filter.skipDir(path, d) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filter.skipFile(path, d) {
+			return nil
+		}
+		return fn(path)
+	})
+}
+
+// ScanSourceFiles works like WalkSourceFiles, but reads directories and
+// calls fn on a pool of goroutines. fn may be called concurrently and in any
+// order, so it suits work that is heavier than walking, like reading files.
+func ScanSourceFiles(ctx context.Context, root string, fn func(path string) error) error {
+	filter := newSourceFilter(root)
+	return walk.Walk(ctx, root, walk.Options{SkipDir: filter.skipDir}, func(path string, d fs.DirEntry) error {
+		if d.IsDir() || filter.skipFile(path, d) {
+			return nil
+		}
+		return fn(path)
+	})
+}
+
+// SearchFiles calls fn with the matches for re in each file that has any.
+// Files are searched concurrently, in no particular order, but fn is called
+// for one file at a time. Returning fs.SkipAll from fn ends the search.
+func SearchFiles(ctx context.Context, root string, re *regexp.Regexp, fn func(matches []Match) error) error {
+	var mu sync.Mutex
+	return ScanSourceFiles(ctx, root, func(path string) error {
+		fileMatches, err := SearchFile(path, re)
+		if err != nil || len(fileMatches) == 0 {
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return fn(fileMatches)
+	})
+}
+
+// Search finds lines in the workspace matching re, stopping after maxResults matches
+// (0 means no limit). Matches are sorted by path and line; when there are more than
+// maxResults, which ones are kept depends on which files were searched first.
+func Search(ctx context.Context, root string, re *regexp.Regexp, maxResults int) ([]Match, error) {
+	var matches []Match
+
+	err := SearchFiles(ctx, root, re, func(fileMatches []Match) error {
+		for _, m := range fileMatches {
+			matches = append(matches, m)
+			if maxResults > 0 && len(matches) >= maxResults {
+				return fs.SkipAll
+			}
+		}
+		return nil
+	})
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Path != matches[j].Path {
+			return matches[i].Path < matches[j].Path
+		}
+		return matches[i].Line < matches[j].Line
+	})
+	return matches, err
+}
+
+// SearchFile finds lines in a single file matching re. A binary or minified
+// file that matches gets a single match with Omitted set instead of its lines.
+func SearchFile(path string, re *regexp.Regexp) ([]Match, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var matches []Match
+	omitted := ""
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+	lineNum := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		if omitted == "" {
+			if strings.IndexByte(line, 0) >= 0 {
+				omitted = Binary
+			} else if len(line) > minifiedLineLength {
+				omitted = Minified
+			}
+		}
+		if loc := re.FindStringIndex(line); loc != nil {
+			matches = append(matches, Match{
+				Path:   path,
+				Line:   lineNum,
+				Column: loc[0],
+				Text:   line,
+			})
+		}
+		// Once the file won't be shown, only its first match matters
+		if omitted != "" && len(matches) > 0 {
+			break
+		}
+		lineNum++
+	}
+	err = scanner.Err()
+	if errors.Is(err, bufio.ErrTooLong) {
+		// Lines too long to scan are minified content
+		omitted, err = Minified, nil
+	}
+	if omitted != "" && len(matches) > 0 {
+		first := matches[0]
+		return []Match{{Path: path, Line: first.Line, Column: first.Column, Omitted: omitted}}, err
+	}
+	if omitted != "" {
+		return nil, err
+	}
+	return matches, err
+}
+
+// WordPattern returns a regular expression matching name as a whole word
+func WordPattern(name string) *regexp.Regexp {
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+}
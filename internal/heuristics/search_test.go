@@ -0,0 +1,58 @@
+package heuristics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpaqueKind(t *testing.T) {
+	assert.Equal(t, "", OpaqueKind([]byte("package main\n\nfunc main() {}\n")))
+	assert.Equal(t, "", OpaqueKind(nil))
+	assert.Equal(t, Binary, OpaqueKind([]byte("PK\x03\x04\x00\x00")))
+	assert.Equal(t, Minified, OpaqueKind([]byte("/* header */\n"+strings.Repeat("a=1;", 2000))))
+	assert.Equal(t, "", OpaqueKind([]byte(strings.Repeat("x", minifiedLineLength)+"\n")))
+}
+
+func TestSearchFileOmitsBinaryAndMinified(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	text := write("text.js", "one\nneedle\nneedle again\n")
+	matches, err := SearchFile(text, WordPattern("needle"))
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Empty(t, matches[0].Omitted)
+
+	// The long line comes after the first match
+	minified := write("app.min.js", "// needle\n"+strings.Repeat("var needle=1;", 1000)+"\n")
+	matches, err = SearchFile(minified, WordPattern("needle"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, Minified, matches[0].Omitted)
+	assert.Equal(t, 0, matches[0].Line)
+	assert.Empty(t, matches[0].Text)
+
+	tooLong := write("bundle.js", strings.Repeat("needle;", maxLineLength/7+1))
+	matches, err = SearchFile(tooLong, WordPattern("nothing"))
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	binary := write("data.bin", "header\x00needle\x00")
+	matches, err = SearchFile(binary, WordPattern("needle"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, Binary, matches[0].Omitted)
+
+	matches, err = SearchFile(binary, WordPattern("missing"))
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
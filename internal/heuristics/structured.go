@@ -0,0 +1,361 @@
+package heuristics
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Configuration, build and markup files are usually not handled by the
+// language server configured for a project. They don't fit the
+// declaration-per-line model used for code, so each gets its own extractor.
+// The extractors are line based rather than tree-sitter grammars, which
+// would need cgo and a vendored grammar per format.
+
+type extractorFunc func(lines []string) []Symbol
+
+var protoPatterns = languagePatterns{
+	block: blockBraces,
+	patterns: []symbolPattern{
+		{regexp.MustCompile(`^\s*message\s+(\w+)`), protocol.Struct},
+		{regexp.MustCompile(`^\s*enum\s+(\w+)`), protocol.Enum},
+		{regexp.MustCompile(`^\s*service\s+(\w+)`), protocol.Interface},
+		{regexp.MustCompile(`^\s*rpc\s+(\w+)`), protocol.Method},
+		{regexp.MustCompile(`^\s*oneof\s+(\w+)`), protocol.Field},
+	},
+}
+
+var shellPatterns = languagePatterns{
+	block: blockBraces,
+	patterns: []symbolPattern{
+		{regexp.MustCompile(`^\s*function\s+([\w:.-]+)`), protocol.Function},
+		{regexp.MustCompile(`^\s*([\w:.-]+)\s*\(\)`), protocol.Function},
+	},
+}
+
+// extractorForFile picks an extractor for non-code files by name or extension
+func extractorForFile(path string) (extractorFunc, bool) {
+	base := strings.ToLower(filepath.Base(path))
+	switch {
+	case base == "dockerfile" || strings.HasPrefix(base, "dockerfile.") || strings.HasSuffix(base, ".dockerfile") || base == "containerfile":
+		return extractDockerfile, true
+	case base == "makefile" || base == "gnumakefile" || strings.HasSuffix(base, ".mk"):
+		return extractMakefile, true
+	}
+
+	switch filepath.Ext(base) {
+	case ".yaml", ".yml":
+		return extractYAML, true
+	case ".md", ".markdown":
+		return extractMarkdown, true
+	case ".toml", ".ini", ".cfg":
+		return extractSections, true
+	default:
+		return nil, false
+	}
+}
+
+// IsStructured reports whether the file is a configuration, build or markup
+// file rather than source code. These are the files a project's language
+// server is least likely to understand.
+func IsStructured(path string) bool {
+	if _, ok := extractorForFile(path); ok {
+		return true
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".proto", ".sh", ".bash", ".zsh":
+		return true
+	}
+	return false
+}
+
+var yamlKeyRegex = regexp.MustCompile(`^(\s*)(?:-\s+)?("[^"]+"|'[^']+'|[\w.$/-]+)\s*:(?:\s|$)`)
+
+// extractYAML lists mapping keys. Only keys that start a nested block and
+// top-level keys are included, which keeps long value lists out of the outline.
+func extractYAML(lines []string) []Symbol {
+	var symbols []Symbol
+	var containers []Symbol
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if trimmed == "---" {
+			containers = nil
+			continue
+		}
+
+		m := yamlKeyRegex.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		topLevel := m[3]-m[2] == 0
+		value := strings.TrimSpace(line[m[1]:])
+		nested := value == "" || value == "|" || value == ">" || strings.HasPrefix(value, "#")
+		if !topLevel && !nested {
+			continue
+		}
+
+		sym := Symbol{
+			Name:    strings.Trim(line[m[4]:m[5]], `"'`),
+			Kind:    protocol.Key,
+			Line:    i,
+			Column:  m[4],
+			EndLine: findYAMLEnd(lines, i, m[4]),
+		}
+		for len(containers) > 0 && containers[len(containers)-1].EndLine < i {
+			containers = containers[:len(containers)-1]
+		}
+		if len(containers) > 0 {
+			sym.Container = containers[len(containers)-1].Name
+		}
+
+		symbols = append(symbols, sym)
+		if sym.EndLine > i {
+			containers = append(containers, sym)
+		}
+	}
+	return symbols
+}
+
+// findYAMLEnd is like findIndentEnd but treats sequence items at the key's
+// own indentation as part of its value
+func findYAMLEnd(lines []string, start int, keyColumn int) int {
+	end := start
+	for i := start + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := indentation(lines[i])
+		if indent < keyColumn || trimmed == "---" {
+			break
+		}
+		if indent == keyColumn && !strings.HasPrefix(trimmed, "- ") && trimmed != "-" {
+			break
+		}
+		end = i
+	}
+	return end
+}
+
+var (
+	dockerFromRegex = regexp.MustCompile(`(?i)^\s*FROM\s+(?:--\S+\s+)*(\S+)(?:\s+AS\s+(\S+))?`)
+	dockerVarRegex  = regexp.MustCompile(`(?i)^\s*(?:ARG|ENV)\s+(\w+)`)
+)
+
+// extractDockerfile lists build stages and the ARG and ENV variables declared in them
+func extractDockerfile(lines []string) []Symbol {
+	var symbols []Symbol
+	stage := -1
+
+	for i, line := range lines {
+		if m := dockerFromRegex.FindStringSubmatchIndex(line); m != nil {
+			if stage >= 0 {
+				symbols[stage].EndLine = lastNonBlank(lines, stage, i-1)
+			}
+			name, column := line[m[2]:m[3]], m[2]
+			if m[4] >= 0 {
+				name, column = line[m[4]:m[5]], m[4]
+			}
+			symbols = append(symbols, Symbol{
+				Name:   name,
+				Kind:   protocol.Module,
+				Line:   i,
+				Column: column,
+			})
+			stage = len(symbols) - 1
+			continue
+		}
+
+		if m := dockerVarRegex.FindStringSubmatchIndex(line); m != nil {
+			sym := Symbol{
+				Name:    line[m[2]:m[3]],
+				Kind:    protocol.Variable,
+				Line:    i,
+				Column:  m[2],
+				EndLine: i,
+			}
+			if stage >= 0 {
+				sym.Container = symbols[stage].Name
+			}
+			symbols = append(symbols, sym)
+		}
+	}
+	if stage >= 0 {
+		symbols[stage].EndLine = lastNonBlank(lines, stage, len(lines)-1)
+	}
+	return symbols
+}
+
+var (
+	makeTargetRegex   = regexp.MustCompile(`^([\w./%-]+(?:\s+[\w./%-]+)*)\s*::?(?:[^=]|$)`)
+	makeVariableRegex = regexp.MustCompile(`^(?:export\s+|override\s+)?([A-Za-z_]\w*)\s*(?::=|::=|\?=|\+=|!=|=)`)
+)
+
+// extractMakefile lists targets, with their recipes, and variable assignments
+func extractMakefile(lines []string) []Symbol {
+	var symbols []Symbol
+
+	for i, line := range lines {
+		if strings.HasPrefix(line, "\t") || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		if m := makeVariableRegex.FindStringSubmatchIndex(line); m != nil {
+			symbols = append(symbols, Symbol{
+				Name:    line[m[2]:m[3]],
+				Kind:    protocol.Variable,
+				Line:    i,
+				Column:  m[2],
+				EndLine: i,
+			})
+			continue
+		}
+
+		m := makeTargetRegex.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		end := i
+		for j := i + 1; j < len(lines) && strings.HasPrefix(lines[j], "\t"); j++ {
+			end = j
+		}
+		for _, target := range strings.Fields(line[m[2]:m[3]]) {
+			// Special targets like .PHONY are directives, not targets
+			if strings.HasPrefix(target, ".") && strings.ToUpper(target) == target {
+				continue
+			}
+			symbols = append(symbols, Symbol{
+				Name:    target,
+				Kind:    protocol.Function,
+				Line:    i,
+				Column:  strings.Index(line, target),
+				EndLine: end,
+			})
+		}
+	}
+	return symbols
+}
+
+var markdownHeadingRegex = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+
+// extractMarkdown lists headings. Each section runs until the next heading
+// of the same or a higher level.
+func extractMarkdown(lines []string) []Symbol {
+	var symbols []Symbol
+	var levels []int
+	inFence := false
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		m := markdownHeadingRegex.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		level := m[3] - m[2]
+
+		// Close sections at the same or a deeper level
+		for j := len(symbols) - 1; j >= 0; j-- {
+			if symbols[j].EndLine == -1 && levels[j] >= level {
+				symbols[j].EndLine = lastNonBlank(lines, symbols[j].Line, i-1)
+			}
+		}
+
+		sym := Symbol{
+			Name:    line[m[4]:m[5]],
+			Kind:    protocol.String,
+			Line:    i,
+			Column:  m[4],
+			EndLine: -1,
+		}
+		for j := len(symbols) - 1; j >= 0; j-- {
+			if symbols[j].EndLine == -1 && levels[j] < level {
+				sym.Container = symbols[j].Name
+				break
+			}
+		}
+		symbols = append(symbols, sym)
+		levels = append(levels, level)
+	}
+
+	for j := range symbols {
+		if symbols[j].EndLine == -1 {
+			symbols[j].EndLine = lastNonBlank(lines, symbols[j].Line, len(lines)-1)
+		}
+	}
+	return symbols
+}
+
+var (
+	sectionRegex    = regexp.MustCompile(`^\s*\[\[?\s*([^\]]+?)\s*\]\]?`)
+	sectionKeyRegex = regexp.MustCompile(`^\s*("[^"]+"|[\w.-]+)\s*[=:]`)
+)
+
+// extractSections lists the tables of TOML files and the sections of INI
+// files, along with the keys defined in them
+func extractSections(lines []string) []Symbol {
+	var symbols []Symbol
+	section := -1
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+
+		if m := sectionRegex.FindStringSubmatchIndex(line); m != nil {
+			if section >= 0 {
+				symbols[section].EndLine = lastNonBlank(lines, symbols[section].Line, i-1)
+			}
+			symbols = append(symbols, Symbol{
+				Name:   line[m[2]:m[3]],
+				Kind:   protocol.Namespace,
+				Line:   i,
+				Column: m[2],
+			})
+			section = len(symbols) - 1
+			continue
+		}
+
+		if m := sectionKeyRegex.FindStringSubmatchIndex(line); m != nil {
+			sym := Symbol{
+				Name:    strings.Trim(line[m[2]:m[3]], `"`),
+				Kind:    protocol.Key,
+				Line:    i,
+				Column:  m[2],
+				EndLine: i,
+			}
+			if section >= 0 {
+				sym.Container = symbols[section].Name
+			}
+			symbols = append(symbols, sym)
+		}
+	}
+	if section >= 0 {
+		symbols[section].EndLine = lastNonBlank(lines, symbols[section].Line, len(lines)-1)
+	}
+	return symbols
+}
+
+// lastNonBlank returns the last non-blank line in [start, end], or start
+func lastNonBlank(lines []string, start, end int) int {
+	for i := end; i > start; i-- {
+		if strings.TrimSpace(lines[i]) != "" {
+			return i
+		}
+	}
+	return start
+}
@@ -0,0 +1,141 @@
+package heuristics
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func symbolNames(symbols []Symbol) []string {
+	names := make([]string, len(symbols))
+	for i, sym := range symbols {
+		names[i] = sym.Name
+	}
+	return names
+}
+
+func TestExtractSymbolsYAML(t *testing.T) {
+	content := `version: "3"
+services:
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+  db:
+    image: postgres
+volumes:
+  - data
+`
+	symbols := ExtractSymbols("docker-compose.yml", content)
+	assert.Equal(t, []string{"version", "services", "web", "ports", "db", "volumes"}, symbolNames(symbols))
+
+	services := symbols[1]
+	assert.Equal(t, protocol.Key, services.Kind)
+	assert.Equal(t, 1, services.Line)
+	assert.Equal(t, 7, services.EndLine)
+
+	assert.Equal(t, "services", symbols[2].Container)
+	assert.Equal(t, "web", symbols[3].Container)
+
+	// Sequence items at the key's indentation belong to it
+	assert.Equal(t, 9, symbols[5].EndLine)
+}
+
+func TestExtractSymbolsDockerfile(t *testing.T) {
+	content := `ARG GO_VERSION=1.24
+FROM golang:${GO_VERSION} AS build
+ENV CGO_ENABLED=0
+RUN go build -o /app
+
+FROM scratch
+COPY --from=build /app /app
+`
+	symbols := ExtractSymbols("Dockerfile", content)
+	assert.Equal(t, []string{"GO_VERSION", "build", "CGO_ENABLED", "scratch"}, symbolNames(symbols))
+
+	assert.Equal(t, protocol.Module, symbols[1].Kind)
+	assert.Equal(t, 1, symbols[1].Line)
+	assert.Equal(t, 3, symbols[1].EndLine)
+	assert.Equal(t, "build", symbols[2].Container)
+	assert.Equal(t, 6, symbols[3].EndLine)
+}
+
+func TestExtractSymbolsMakefile(t *testing.T) {
+	content := "GO ?= go\n" +
+		".PHONY: build test\n" +
+		"build test: deps\n" +
+		"\t$(GO) build ./...\n" +
+		"\t$(GO) test ./...\n" +
+		"\n" +
+		"deps:\n" +
+		"\t$(GO) mod download\n"
+
+	symbols := ExtractSymbols("Makefile", content)
+	assert.Equal(t, []string{"GO", "build", "test", "deps"}, symbolNames(symbols))
+	assert.Equal(t, protocol.Variable, symbols[0].Kind)
+	assert.Equal(t, protocol.Function, symbols[1].Kind)
+	assert.Equal(t, 4, symbols[1].EndLine)
+	assert.Equal(t, 7, symbols[3].EndLine)
+}
+
+func TestExtractSymbolsMarkdown(t *testing.T) {
+	content := "# Title\n" +
+		"intro\n" +
+		"## Setup\n" +
+		"```sh\n" +
+		"# not a heading\n" +
+		"```\n" +
+		"## Usage\n" +
+		"text\n"
+
+	symbols := ExtractSymbols("README.md", content)
+	require.Equal(t, []string{"Title", "Setup", "Usage"}, symbolNames(symbols))
+	assert.Equal(t, 7, symbols[0].EndLine)
+	assert.Equal(t, 5, symbols[1].EndLine)
+	assert.Equal(t, "Title", symbols[1].Container)
+	assert.Equal(t, 7, symbols[2].EndLine)
+}
+
+func TestExtractSymbolsTOML(t *testing.T) {
+	content := `name = "app"
+
+[server]
+port = 8080
+
+[[plugins]]
+id = "a"
+`
+	symbols := ExtractSymbols("config.toml", content)
+	require.Equal(t, []string{"name", "server", "port", "plugins", "id"}, symbolNames(symbols))
+	assert.Equal(t, protocol.Namespace, symbols[1].Kind)
+	assert.Equal(t, 3, symbols[1].EndLine)
+	assert.Equal(t, "server", symbols[2].Container)
+	assert.Equal(t, "plugins", symbols[4].Container)
+}
+
+func TestExtractSymbolsProto(t *testing.T) {
+	content := `syntax = "proto3";
+
+service Greeter {
+  rpc SayHello (HelloRequest) returns (HelloReply);
+}
+
+message HelloRequest {
+  string name = 1;
+}
+`
+	symbols := ExtractSymbols("greeter.proto", content)
+	require.Equal(t, []string{"Greeter", "SayHello", "HelloRequest"}, symbolNames(symbols))
+	assert.Equal(t, 4, symbols[0].EndLine)
+	assert.Equal(t, "Greeter", symbols[1].Container)
+	assert.Equal(t, 8, symbols[2].EndLine)
+}
+
+func TestIsStructured(t *testing.T) {
+	assert.True(t, IsStructured("deploy/values.yaml"))
+	assert.True(t, IsStructured("build/Dockerfile.dev"))
+	assert.True(t, IsStructured("api/service.proto"))
+	assert.False(t, IsStructured("main.go"))
+}
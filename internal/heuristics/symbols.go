@@ -0,0 +1,363 @@
+package heuristics
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Symbol is a declaration found by pattern matching rather than by a language server
+type Symbol struct {
+	Name      string
+	Kind      protocol.SymbolKind
+	Container string
+	// Line and Column are 0-indexed, matching LSP positions
+	Line   int
+	Column int
+	// EndLine is the last line (0-indexed, inclusive) of the declaration body
+	EndLine int
+}
+
+// symbolPattern matches a single kind of declaration on a line.
+// The name of the symbol must be in the first capture group.
+type symbolPattern struct {
+	re   *regexp.Regexp
+	kind protocol.SymbolKind
+}
+
+// blockStyle describes how the end of a declaration is found
+type blockStyle int
+
+const (
+	blockBraces blockStyle = iota
+	blockIndent
+	blockKeywordEnd
+)
+
+type languagePatterns struct {
+	patterns []symbolPattern
+	block    blockStyle
+}
+
+var goPatterns = languagePatterns{
+	block: blockBraces,
+	patterns: []symbolPattern{
+		{regexp.MustCompile(`^func\s+\([^)]*\)\s*(\w+)`), protocol.Method},
+		{regexp.MustCompile(`^func\s+(\w+)`), protocol.Function},
+		{regexp.MustCompile(`^type\s+(\w+)\s+struct\b`), protocol.Struct},
+		{regexp.MustCompile(`^type\s+(\w+)\s+interface\b`), protocol.Interface},
+		{regexp.MustCompile(`^type\s+(\w+)`), protocol.Class},
+		{regexp.MustCompile(`^const\s+(\w+)`), protocol.Constant},
+		{regexp.MustCompile(`^var\s+(\w+)`), protocol.Variable},
+	},
+}
+
+var pythonPatterns = languagePatterns{
+	block: blockIndent,
+	patterns: []symbolPattern{
+		{regexp.MustCompile(`^\s*class\s+(\w+)`), protocol.Class},
+		{regexp.MustCompile(`^\s+(?:async\s+)?def\s+(\w+)`), protocol.Method},
+		{regexp.MustCompile(`^(?:async\s+)?def\s+(\w+)`), protocol.Function},
+		{regexp.MustCompile(`^([A-Z][A-Z0-9_]*)\s*(?::[^=]+)?=`), protocol.Constant},
+		{regexp.MustCompile(`^([a-z_]\w*)\s*(?::[^=]+)?=[^=]`), protocol.Variable},
+	},
+}
+
+var typescriptPatterns = languagePatterns{
+	block: blockBraces,
+	patterns: []symbolPattern{
+		{regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(\w+)`), protocol.Class},
+		{regexp.MustCompile(`^\s*(?:export\s+)?interface\s+(\w+)`), protocol.Interface},
+		{regexp.MustCompile(`^\s*(?:export\s+)?(?:const\s+)?enum\s+(\w+)`), protocol.Enum},
+		{regexp.MustCompile(`^\s*(?:export\s+)?type\s+(\w+)`), protocol.TypeParameter},
+		{regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(\w+)`), protocol.Function},
+		{regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+(\w+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:\([^)]*\)|\w+)\s*=>`), protocol.Function},
+		{regexp.MustCompile(`^(?:export\s+)?const\s+(\w+)`), protocol.Constant},
+		{regexp.MustCompile(`^(?:export\s+)?(?:let|var)\s+(\w+)`), protocol.Variable},
+		{regexp.MustCompile(`^\s+(?:public\s+|private\s+|protected\s+|static\s+|async\s+|readonly\s+)*(\w+)\s*\([^)]*\)\s*(?::[^{]+)?\{`), protocol.Method},
+	},
+}
+
+var rustPatterns = languagePatterns{
+	block: blockBraces,
+	patterns: []symbolPattern{
+		{regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(?:unsafe\s+)?(?:const\s+)?fn\s+(\w+)`), protocol.Function},
+		{regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?struct\s+(\w+)`), protocol.Struct},
+		{regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?enum\s+(\w+)`), protocol.Enum},
+		{regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:unsafe\s+)?trait\s+(\w+)`), protocol.Interface},
+		{regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?type\s+(\w+)`), protocol.TypeParameter},
+		{regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?mod\s+(\w+)`), protocol.Module},
+		{regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:const|static)\s+(?:mut\s+)?(\w+)`), protocol.Constant},
+		{regexp.MustCompile(`^\s*macro_rules!\s*(\w+)`), protocol.Function},
+	},
+}
+
+var cPatterns = languagePatterns{
+	block: blockBraces,
+	patterns: []symbolPattern{
+		{regexp.MustCompile(`^\s*namespace\s+(\w+)`), protocol.Namespace},
+		{regexp.MustCompile(`^\s*(?:typedef\s+)?struct\s+(\w+)\s*(?:\{|$|:)`), protocol.Struct},
+		{regexp.MustCompile(`^\s*(?:template\s*<[^>]*>\s*)?class\s+(\w+)\s*(?:\{|$|:|final)`), protocol.Class},
+		{regexp.MustCompile(`^\s*enum\s+(?:class\s+)?(\w+)`), protocol.Enum},
+		{regexp.MustCompile(`^\s*#define\s+(\w+)`), protocol.Constant},
+		{regexp.MustCompile(`^(?:[\w:<>,*&]+\s+)+[*&]*((?:\w+::)*~?\w+)\s*\([^;]*$`), protocol.Function},
+	},
+}
+
+var rubyPatterns = languagePatterns{
+	block: blockKeywordEnd,
+	patterns: []symbolPattern{
+		{regexp.MustCompile(`^\s*class\s+([\w:]+)`), protocol.Class},
+		{regexp.MustCompile(`^\s*module\s+([\w:]+)`), protocol.Module},
+		{regexp.MustCompile(`^\s*def\s+(?:self\.)?(\w+[?!=]?)`), protocol.Method},
+	},
+}
+
+var javaPatterns = languagePatterns{
+	block: blockBraces,
+	patterns: []symbolPattern{
+		{regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|static|abstract|final|sealed|partial|open|data)\s+)*class\s+(\w+)`), protocol.Class},
+		{regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|static|sealed|partial)\s+)*interface\s+(\w+)`), protocol.Interface},
+		{regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|static)\s+)*enum\s+(?:class\s+)?(\w+)`), protocol.Enum},
+		{regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|static|final|abstract|override|suspend|synchronized|async|virtual)\s+)*fun\s+(?:<[^>]*>\s*)?(\w+)`), protocol.Function},
+		{regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|static|final|abstract|override|synchronized|async|virtual)\s+)+[\w<>\[\],.?]+\s+(\w+)\s*\(`), protocol.Method},
+	},
+}
+
+// patternsForFile picks the declaration patterns to use based on the file extension
+func patternsForFile(path string) (languagePatterns, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return goPatterns, true
+	case ".py", ".pyi":
+		return pythonPatterns, true
+	case ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs":
+		return typescriptPatterns, true
+	case ".rs":
+		return rustPatterns, true
+	case ".c", ".h", ".cpp", ".cc", ".cxx", ".hpp", ".hh", ".hxx":
+		return cPatterns, true
+	case ".rb":
+		return rubyPatterns, true
+	case ".java", ".kt", ".kts", ".cs", ".scala", ".swift":
+		return javaPatterns, true
+	case ".proto":
+		return protoPatterns, true
+	case ".sh", ".bash", ".zsh":
+		return shellPatterns, true
+	default:
+		return languagePatterns{}, false
+	}
+}
+
+// IsSupported reports whether symbols can be extracted heuristically from the file
+func IsSupported(path string) bool {
+	if _, ok := extractorForFile(path); ok {
+		return true
+	}
+	_, ok := patternsForFile(path)
+	return ok
+}
+
+// cKeywords are words that look like function names to the C pattern but are control flow
+var cKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "return": true, "sizeof": true, "else": true,
+}
+
+// ExtractSymbols finds declarations in the content of the file at path.
+// Symbols are returned in the order they appear in the file.
+func ExtractSymbols(path string, content string) []Symbol {
+	if extract, ok := extractorForFile(path); ok {
+		return extract(strings.Split(content, "\n"))
+	}
+
+	lang, ok := patternsForFile(path)
+	if !ok {
+		return nil
+	}
+
+	lines := strings.Split(content, "\n")
+	var symbols []Symbol
+	var containers []Symbol
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "#") && lang.block != blockBraces {
+			continue
+		}
+
+		for _, p := range lang.patterns {
+			m := p.re.FindStringSubmatchIndex(line)
+			if m == nil || m[2] < 0 {
+				continue
+			}
+			name := line[m[2]:m[3]]
+			if cKeywords[name] && lang.block == blockBraces {
+				break
+			}
+
+			sym := Symbol{
+				Name:   name,
+				Kind:   p.kind,
+				Line:   i,
+				Column: m[2],
+			}
+			sym.EndLine = findBlockEnd(lines, i, lang.block)
+
+			// Attach to the innermost enclosing container, if any
+			for len(containers) > 0 && containers[len(containers)-1].EndLine < i {
+				containers = containers[:len(containers)-1]
+			}
+			if len(containers) > 0 {
+				sym.Container = containers[len(containers)-1].Name
+			}
+			if sym.Kind == protocol.Function && sym.Container != "" && lang.block != blockBraces {
+				sym.Kind = protocol.Method
+			}
+
+			symbols = append(symbols, sym)
+			switch sym.Kind {
+			case protocol.Class, protocol.Struct, protocol.Interface, protocol.Module, protocol.Namespace, protocol.Enum:
+				if sym.EndLine > i {
+					containers = append(containers, sym)
+				}
+			}
+			break
+		}
+	}
+
+	return symbols
+}
+
+// findBlockEnd returns the last line of the declaration starting at line start
+func findBlockEnd(lines []string, start int, style blockStyle) int {
+	switch style {
+	case blockIndent:
+		return findIndentEnd(lines, start)
+	case blockKeywordEnd:
+		return findKeywordEnd(lines, start)
+	default:
+		return findBraceEnd(lines, start)
+	}
+}
+
+// findBraceEnd matches braces from the declaration line. If no brace opens
+// within a few lines the declaration is assumed to be a single statement.
+func findBraceEnd(lines []string, start int) int {
+	depth := 0
+	opened := false
+	for i := start; i < len(lines); i++ {
+		line := stripStringsAndComments(lines[i])
+		for _, ch := range line {
+			switch ch {
+			case '{':
+				depth++
+				opened = true
+			case '}':
+				depth--
+			}
+		}
+		if opened && depth <= 0 {
+			return i
+		}
+		if !opened {
+			// Declarations like `const x = 1;` or `type X int` end on their own line,
+			// except for grouped declarations and multi-line signatures
+			trimmed := strings.TrimSpace(line)
+			if strings.HasSuffix(trimmed, ";") || i-start >= 5 {
+				return start
+			}
+			if i == start && !strings.HasSuffix(trimmed, "(") && !strings.HasSuffix(trimmed, ",") && !strings.HasSuffix(trimmed, "=") {
+				if i+1 >= len(lines) || !strings.HasPrefix(strings.TrimSpace(lines[i+1]), "{") {
+					return start
+				}
+			}
+		}
+	}
+	return len(lines) - 1
+}
+
+// stripStringsAndComments removes string literals and line comments so that
+// braces inside them are not counted
+func stripStringsAndComments(line string) string {
+	var b strings.Builder
+	var quote rune
+	escaped := false
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		if quote != 0 {
+			if escaped {
+				escaped = false
+			} else if ch == '\\' {
+				escaped = true
+			} else if ch == quote {
+				quote = 0
+			}
+			continue
+		}
+		if ch == '/' && i+1 < len(runes) && runes[i+1] == '/' {
+			break
+		}
+		if ch == '"' || ch == '\'' || ch == '`' {
+			quote = ch
+			continue
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// findIndentEnd returns the last non-blank line indented deeper than the declaration
+func findIndentEnd(lines []string, start int) int {
+	base := indentation(lines[start])
+	end := start
+	for i := start + 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		if indentation(lines[i]) <= base {
+			// Closing brackets of a multi-line value belong to the declaration
+			trimmed := strings.TrimSpace(lines[i])
+			if strings.HasPrefix(trimmed, ")") || strings.HasPrefix(trimmed, "]") || strings.HasPrefix(trimmed, "}") {
+				end = i
+				continue
+			}
+			break
+		}
+		end = i
+	}
+	return end
+}
+
+// findKeywordEnd finds the `end` keyword matching the declaration at the same indentation
+func findKeywordEnd(lines []string, start int) int {
+	base := indentation(lines[start])
+	for i := start + 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "end" && indentation(lines[i]) == base {
+			return i
+		}
+	}
+	return start
+}
+
+// FindEnclosingSymbol returns the smallest symbol whose body contains the 0-indexed line
+func FindEnclosingSymbol(symbols []Symbol, line int) (Symbol, bool) {
+	var best Symbol
+	found := false
+	for _, sym := range symbols {
+		if sym.Line <= line && sym.EndLine >= line {
+			if !found || sym.EndLine-sym.Line < best.EndLine-best.Line {
+				best = sym
+				found = true
+			}
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,110 @@
+package heuristics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractSymbolsGo(t *testing.T) {
+	content := `package main
+
+type Server struct {
+	name string
+}
+
+func (s *Server) Start() error {
+	if s.name == "" {
+		return nil
+	}
+	return nil
+}
+
+func main() {
+	s := &Server{}
+	_ = s.Start()
+}
+`
+	symbols := ExtractSymbols("main.go", content)
+	require.Len(t, symbols, 3)
+
+	assert.Equal(t, "Server", symbols[0].Name)
+	assert.Equal(t, protocol.Struct, symbols[0].Kind)
+	assert.Equal(t, 2, symbols[0].Line)
+	assert.Equal(t, 4, symbols[0].EndLine)
+
+	assert.Equal(t, "Start", symbols[1].Name)
+	assert.Equal(t, protocol.Method, symbols[1].Kind)
+	assert.Equal(t, 6, symbols[1].Line)
+	assert.Equal(t, 11, symbols[1].EndLine)
+
+	assert.Equal(t, "main", symbols[2].Name)
+	assert.Equal(t, protocol.Function, symbols[2].Kind)
+	assert.Equal(t, 16, symbols[2].EndLine)
+}
+
+func TestExtractSymbolsPython(t *testing.T) {
+	content := `class Greeter:
+    def greet(self, name):
+        return "hello " + name
+
+def main():
+    print(Greeter().greet("x"))
+`
+	symbols := ExtractSymbols("app.py", content)
+	require.Len(t, symbols, 3)
+
+	assert.Equal(t, "Greeter", symbols[0].Name)
+	assert.Equal(t, 2, symbols[0].EndLine)
+
+	assert.Equal(t, "greet", symbols[1].Name)
+	assert.Equal(t, protocol.Method, symbols[1].Kind)
+	assert.Equal(t, "Greeter", symbols[1].Container)
+
+	assert.Equal(t, "main", symbols[2].Name)
+	assert.Equal(t, protocol.Function, symbols[2].Kind)
+	assert.Equal(t, 5, symbols[2].EndLine)
+}
+
+func TestFindEnclosingSymbol(t *testing.T) {
+	symbols := []Symbol{
+		{Name: "Outer", Line: 0, EndLine: 10},
+		{Name: "inner", Line: 2, EndLine: 4},
+	}
+
+	sym, ok := FindEnclosingSymbol(symbols, 3)
+	require.True(t, ok)
+	assert.Equal(t, "inner", sym.Name)
+
+	sym, ok = FindEnclosingSymbol(symbols, 8)
+	require.True(t, ok)
+	assert.Equal(t, "Outer", sym.Name)
+
+	_, ok = FindEnclosingSymbol(symbols, 20)
+	assert.False(t, ok)
+}
+
+func TestIsSupported(t *testing.T) {
+	assert.True(t, IsSupported("main.go"))
+	assert.True(t, IsSupported("src/index.ts"))
+	assert.False(t, IsSupported("image.png"))
+}
+
+func TestSearchRespectsGitignore(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("ignored.txt\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "kept.txt"), []byte("one\nneedle here\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "ignored.txt"), []byte("needle\n"), 0644))
+
+	matches, err := Search(context.Background(), root, WordPattern("needle"), 0)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, filepath.Join(root, "kept.txt"), matches[0].Path)
+	assert.Equal(t, 1, matches[0].Line)
+	assert.Equal(t, 0, matches[0].Column)
+}
@@ -0,0 +1,83 @@
+// Package i18n translates the text models read from the server: the
+// descriptions of tools and their arguments, and error messages. Agents
+// pass these into prompts verbatim, so deployments that prompt in another
+// language can have them in that language.
+//
+// Catalogs map the English text to its translation. Text without a
+// translation is shown in English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// English is the locale the server's text is written in
+const English = "en"
+
+// Locales are the locales text can be shown in
+var Locales = []string{English, "ja", "zh"}
+
+//go:embed catalogs/*.json
+var catalogFiles embed.FS
+
+// catalogs holds the translations of each locale but English
+var catalogs = func() map[string]map[string]string {
+	catalogs := make(map[string]map[string]string)
+	for _, locale := range Locales[1:] {
+		data, err := catalogFiles.ReadFile("catalogs/" + locale + ".json")
+		if err != nil {
+			panic(err)
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("invalid catalog %s: %v", locale, err))
+		}
+		catalogs[locale] = catalog
+	}
+	return catalogs
+}()
+
+var current = struct {
+	sync.RWMutex
+	locale string
+}{locale: English}
+
+// SetLocale changes the locale text is shown in; "" restores English
+func SetLocale(locale string) error {
+	if locale == "" {
+		locale = English
+	}
+	if !slices.Contains(Locales, locale) {
+		return fmt.Errorf("unknown locale %q, expected one of %s", locale, strings.Join(Locales, ", "))
+	}
+	current.Lock()
+	defer current.Unlock()
+	current.locale = locale
+	return nil
+}
+
+// Locale returns the locale text is shown in
+func Locale() string {
+	current.RLock()
+	defer current.RUnlock()
+	return current.locale
+}
+
+// Text translates a message into the current locale
+func Text(message string) string {
+	if translated, ok := catalogs[Locale()][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// Sprintf formats according to the translation of format. Translations may
+// reorder the arguments with explicit indexes such as %[2]s.
+func Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(Text(format), args...)
+}
@@ -0,0 +1,61 @@
+package i18n
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var verb = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z]`)
+
+// TestCatalogs checks that translations of format strings take the same
+// arguments as the English text, in whatever order
+func TestCatalogs(t *testing.T) {
+	require.Len(t, catalogs, len(Locales)-1)
+	for locale, catalog := range catalogs {
+		for message, translated := range catalog {
+			assert.NotEmpty(t, strings.TrimSpace(translated), "%s: %q", locale, message)
+			verbs := len(verb.FindAllString(message, -1))
+			if verbs == 0 {
+				assert.NotContains(t, translated, "%", "%s: %q", locale, message)
+				continue
+			}
+			args := make([]any, verbs)
+			for i := range args {
+				args[i] = fmt.Sprintf("<arg%d>", i)
+			}
+			formatted := fmt.Sprintf(translated, args...)
+			assert.NotContains(t, formatted, "%!", "%s: %q", locale, message)
+			for _, arg := range args {
+				assert.Contains(t, formatted, arg, "%s: %q", locale, message)
+			}
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	t.Cleanup(func() { _ = SetLocale("") })
+
+	assert.Equal(t, "failed to search", Text("failed to search"))
+
+	require.NoError(t, SetLocale("ja"))
+	assert.Equal(t, "ja", Locale())
+	assert.Equal(t, "検索に失敗しました", Text("failed to search"))
+	// Text without a translation stays in English
+	assert.Equal(t, "no translation", Text("no translation"))
+	assert.Equal(t, "ツール hover はサーバーの設定で無効になっています", Sprintf("tool %s is disabled by the server configuration", "hover"))
+
+	require.NoError(t, SetLocale("zh"))
+	assert.Equal(t, "read_definition 已重命名为 definition，但 definition 不可用：gone",
+		Sprintf("%s was renamed to %s, which is unavailable: %s", "read_definition", "definition", "gone"))
+
+	assert.Error(t, SetLocale("fr"))
+	assert.Equal(t, "zh", Locale())
+
+	require.NoError(t, SetLocale(""))
+	assert.Equal(t, English, Locale())
+}
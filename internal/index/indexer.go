@@ -0,0 +1,286 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/logging"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+var indexLogger = logging.NewLogger(logging.Index)
+
+// Indexer walks a workspace, asks the language server about every symbol it
+// declares, and writes the answers as an LSIF dump
+type Indexer struct {
+	client   *lsp.Client
+	root     string
+	emitter  *Emitter
+	tool     string
+	version  string
+	docs     map[protocol.DocumentUri]*document
+	docOrder []protocol.DocumentUri
+}
+
+type document struct {
+	uri        protocol.DocumentUri
+	languageID protocol.LanguageKind
+	id         int
+	// ranges maps each range in the document to its vertex id
+	ranges map[protocol.Range]int
+	// rangeOrder keeps range vertices in emission order for the contains edge
+	rangeOrder []int
+}
+
+// definition is a symbol declared in the workspace together with what the
+// language server knows about it
+type definition struct {
+	uri        protocol.DocumentUri
+	rng        protocol.Range
+	hover      string
+	references []protocol.Location
+}
+
+// NewIndexer creates an indexer that writes an LSIF dump of root to w. tool
+// and version identify this program in the dump's metadata.
+func NewIndexer(client *lsp.Client, root string, w io.Writer, tool, version string) *Indexer {
+	return &Indexer{
+		client:  client,
+		root:    root,
+		emitter: NewEmitter(w),
+		tool:    tool,
+		version: version,
+		docs:    make(map[protocol.DocumentUri]*document),
+	}
+}
+
+// Run indexes the workspace. Files the language server can't answer for are
+// logged and skipped.
+func (ix *Indexer) Run(ctx context.Context) error {
+	err := heuristics.WalkSourceFiles(ctx, ix.root, func(path string) error {
+		uri := protocol.URIFromPath(path)
+		languageID := lsp.DetectLanguageID(string(uri))
+		if languageID == "" {
+			return nil
+		}
+		ix.docs[uri] = &document{uri: uri, languageID: languageID, ranges: make(map[protocol.Range]int)}
+		ix.docOrder = append(ix.docOrder, uri)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk workspace: %w", err)
+	}
+	indexLogger.Info("Indexing %d files in %s", len(ix.docOrder), ix.root)
+
+	var definitions []*definition
+	for i, uri := range ix.docOrder {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		defs, err := ix.collectDefinitions(ctx, uri)
+		if err != nil {
+			indexLogger.Warn("Skipping %s: %v", uri.Path(), err)
+			continue
+		}
+		definitions = append(definitions, defs...)
+		indexLogger.Debug("Collected %d symbols from %s (%d/%d)", len(defs), uri.Path(), i+1, len(ix.docOrder))
+	}
+
+	for _, def := range definitions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ix.resolve(ctx, def)
+	}
+
+	ix.emit(definitions)
+	if err := ix.emitter.Err(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+
+	indexLogger.Info("Indexed %d symbols", len(definitions))
+	return nil
+}
+
+// collectDefinitions lists the symbols declared in a document
+func (ix *Indexer) collectDefinitions(ctx context.Context, uri protocol.DocumentUri) ([]*definition, error) {
+	if err := ix.client.OpenFile(ctx, uri.Path()); err != nil {
+		return nil, err
+	}
+
+	symResult, err := ix.client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document symbols: %w", err)
+	}
+	symbols, err := symResult.Results()
+	if err != nil {
+		return nil, fmt.Errorf("failed to process document symbols: %w", err)
+	}
+
+	var defs []*definition
+	var walk func(symbols []protocol.DocumentSymbolResult)
+	walk = func(symbols []protocol.DocumentSymbolResult) {
+		for _, sym := range symbols {
+			switch v := sym.(type) {
+			case *protocol.DocumentSymbol:
+				defs = append(defs, &definition{uri: uri, rng: v.SelectionRange})
+				children := make([]protocol.DocumentSymbolResult, len(v.Children))
+				for i := range v.Children {
+					children[i] = &v.Children[i]
+				}
+				walk(children)
+			case *protocol.SymbolInformation:
+				// Only the start of the name is known, which is enough to query
+				rng := protocol.Range{Start: v.Location.Range.Start, End: v.Location.Range.Start}
+				defs = append(defs, &definition{uri: uri, rng: rng})
+			}
+		}
+	}
+	walk(symbols)
+
+	return defs, nil
+}
+
+// resolve fills in hover text and references for a definition
+func (ix *Indexer) resolve(ctx context.Context, def *definition) {
+	position := protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: def.uri},
+		Position:     def.rng.Start,
+	}
+
+	hover, err := ix.client.Hover(ctx, protocol.HoverParams{TextDocumentPositionParams: position})
+	if err != nil {
+		indexLogger.Debug("Hover failed at %s:%d: %v", def.uri.Path(), def.rng.Start.Line+1, err)
+	} else {
+		def.hover = hover.ToString()
+	}
+
+	refs, err := ix.client.References(ctx, protocol.ReferenceParams{
+		TextDocumentPositionParams: position,
+		Context:                    protocol.ReferenceContext{IncludeDeclaration: false},
+	})
+	if err != nil {
+		indexLogger.Debug("References failed at %s:%d: %v", def.uri.Path(), def.rng.Start.Line+1, err)
+		return
+	}
+	for _, ref := range refs {
+		// References outside the workspace have no document in the dump
+		if _, ok := ix.docs[ref.URI]; ok {
+			def.references = append(def.references, ref)
+		}
+	}
+}
+
+// rangeID returns the vertex id of a range, emitting it on first use
+func (ix *Indexer) rangeID(doc *document, r protocol.Range) int {
+	if id, ok := doc.ranges[r]; ok {
+		return id
+	}
+	id := ix.emitter.EmitRange(r)
+	doc.ranges[r] = id
+	doc.rangeOrder = append(doc.rangeOrder, id)
+	return id
+}
+
+// emit writes the collected graph. Documents and their ranges come first so
+// that every result can refer to them.
+func (ix *Indexer) emit(definitions []*definition) {
+	e := ix.emitter
+	e.EmitMetaData(protocol.URIFromPath(ix.root), ix.tool, ix.version)
+	project := e.EmitProject(string(ix.projectKind()))
+
+	var docIDs []int
+	for _, uri := range ix.docOrder {
+		doc := ix.docs[uri]
+		doc.id = e.EmitDocument(doc.uri, doc.languageID)
+		docIDs = append(docIDs, doc.id)
+	}
+	if len(docIDs) > 0 {
+		e.EmitContains(project, docIDs)
+	}
+
+	// Link every definition to its result set before any references, so a
+	// range that is both a definition and a reference keeps its own result set
+	defRanges := make([]int, len(definitions))
+	resultSets := make([]int, len(definitions))
+	linked := make(map[int]bool)
+	for i, def := range definitions {
+		defRanges[i] = ix.rangeID(ix.docs[def.uri], def.rng)
+		if linked[defRanges[i]] {
+			continue
+		}
+		resultSets[i] = e.EmitResultSet()
+		e.EmitEdge("next", defRanges[i], resultSets[i])
+		linked[defRanges[i]] = true
+	}
+
+	for i, def := range definitions {
+		if resultSets[i] == 0 {
+			// Duplicate of an earlier symbol at the same range
+			continue
+		}
+		doc := ix.docs[def.uri]
+		defRange, resultSet := defRanges[i], resultSets[i]
+
+		if def.hover != "" {
+			hover := e.EmitHoverResult(def.hover)
+			e.EmitEdge("textDocument/hover", resultSet, hover)
+		}
+
+		definitionResult := e.EmitDefinitionResult()
+		e.EmitEdge("textDocument/definition", resultSet, definitionResult)
+		e.EmitItem(definitionResult, []int{defRange}, doc.id, "")
+
+		referenceResult := e.EmitReferenceResult()
+		e.EmitEdge("textDocument/references", resultSet, referenceResult)
+		e.EmitItem(referenceResult, []int{defRange}, doc.id, "definitions")
+
+		// Group references by document so each gets a single item edge
+		refsByDoc := make(map[protocol.DocumentUri][]int)
+		for _, ref := range def.references {
+			refDoc := ix.docs[ref.URI]
+			refRange := ix.rangeID(refDoc, ref.Range)
+			if !linked[refRange] {
+				e.EmitEdge("next", refRange, resultSet)
+				linked[refRange] = true
+			}
+			refsByDoc[ref.URI] = append(refsByDoc[ref.URI], refRange)
+		}
+		refURIs := make([]string, 0, len(refsByDoc))
+		for uri := range refsByDoc {
+			refURIs = append(refURIs, string(uri))
+		}
+		sort.Strings(refURIs)
+		for _, uri := range refURIs {
+			refDoc := ix.docs[protocol.DocumentUri(uri)]
+			e.EmitItem(referenceResult, refsByDoc[refDoc.uri], refDoc.id, "references")
+		}
+	}
+
+	for _, uri := range ix.docOrder {
+		doc := ix.docs[uri]
+		if len(doc.rangeOrder) > 0 {
+			e.EmitContains(doc.id, doc.rangeOrder)
+		}
+	}
+}
+
+// projectKind is the most common language in the workspace
+func (ix *Indexer) projectKind() protocol.LanguageKind {
+	counts := make(map[protocol.LanguageKind]int)
+	var kind protocol.LanguageKind
+	for _, uri := range ix.docOrder {
+		languageID := ix.docs[uri].languageID
+		counts[languageID]++
+		if counts[languageID] > counts[kind] {
+			kind = languageID
+		}
+	}
+	return kind
+}
@@ -0,0 +1,167 @@
+package index
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// lsifVersion is the version of the LSIF specification the dump follows
+const lsifVersion = "0.4.3"
+
+// Emitter writes an LSIF dump as JSON lines. Each call emits one vertex or
+// edge and returns its id.
+type Emitter struct {
+	encoder *json.Encoder
+	nextID  int
+	err     error
+}
+
+// NewEmitter creates an emitter that writes to w
+func NewEmitter(w io.Writer) *Emitter {
+	return &Emitter{encoder: json.NewEncoder(w)}
+}
+
+// Err returns the first error encountered while writing
+func (e *Emitter) Err() error {
+	return e.err
+}
+
+type element struct {
+	ID    int    `json:"id"`
+	Type  string `json:"type"`
+	Label string `json:"label"`
+}
+
+func (e *Emitter) emit(elem any) {
+	if e.err != nil {
+		return
+	}
+	e.err = e.encoder.Encode(elem)
+}
+
+func (e *Emitter) newElement(elemType, label string) element {
+	e.nextID++
+	return element{ID: e.nextID, Type: elemType, Label: label}
+}
+
+type toolInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// EmitMetaData emits the metaData vertex, which must come first
+func (e *Emitter) EmitMetaData(projectRoot protocol.DocumentUri, tool, version string) int {
+	v := struct {
+		element
+		Version          string               `json:"version"`
+		ProjectRoot      protocol.DocumentUri `json:"projectRoot"`
+		PositionEncoding string               `json:"positionEncoding"`
+		ToolInfo         toolInfo             `json:"toolInfo"`
+	}{e.newElement("vertex", "metaData"), lsifVersion, projectRoot, "utf-16", toolInfo{tool, version}}
+	e.emit(v)
+	return v.ID
+}
+
+// EmitProject emits a project vertex
+func (e *Emitter) EmitProject(kind string) int {
+	v := struct {
+		element
+		Kind string `json:"kind"`
+	}{e.newElement("vertex", "project"), kind}
+	e.emit(v)
+	return v.ID
+}
+
+// EmitDocument emits a document vertex
+func (e *Emitter) EmitDocument(uri protocol.DocumentUri, languageID protocol.LanguageKind) int {
+	v := struct {
+		element
+		URI        protocol.DocumentUri  `json:"uri"`
+		LanguageID protocol.LanguageKind `json:"languageId"`
+	}{e.newElement("vertex", "document"), uri, languageID}
+	e.emit(v)
+	return v.ID
+}
+
+// EmitRange emits a range vertex
+func (e *Emitter) EmitRange(r protocol.Range) int {
+	v := struct {
+		element
+		Start protocol.Position `json:"start"`
+		End   protocol.Position `json:"end"`
+	}{e.newElement("vertex", "range"), r.Start, r.End}
+	e.emit(v)
+	return v.ID
+}
+
+// EmitResultSet emits a resultSet vertex
+func (e *Emitter) EmitResultSet() int {
+	v := e.newElement("vertex", "resultSet")
+	e.emit(v)
+	return v.ID
+}
+
+// EmitHoverResult emits a hoverResult vertex with markdown contents
+func (e *Emitter) EmitHoverResult(contents string) int {
+	v := struct {
+		element
+		Result struct {
+			Contents protocol.MarkupContent `json:"contents"`
+		} `json:"result"`
+	}{element: e.newElement("vertex", "hoverResult")}
+	v.Result.Contents = protocol.MarkupContent{Kind: protocol.Markdown, Value: contents}
+	e.emit(v)
+	return v.ID
+}
+
+// EmitDefinitionResult emits a definitionResult vertex
+func (e *Emitter) EmitDefinitionResult() int {
+	v := e.newElement("vertex", "definitionResult")
+	e.emit(v)
+	return v.ID
+}
+
+// EmitReferenceResult emits a referenceResult vertex
+func (e *Emitter) EmitReferenceResult() int {
+	v := e.newElement("vertex", "referenceResult")
+	e.emit(v)
+	return v.ID
+}
+
+// EmitEdge emits a 1:1 edge such as next or textDocument/hover
+func (e *Emitter) EmitEdge(label string, outV, inV int) int {
+	v := struct {
+		element
+		OutV int `json:"outV"`
+		InV  int `json:"inV"`
+	}{e.newElement("edge", label), outV, inV}
+	e.emit(v)
+	return v.ID
+}
+
+// EmitContains emits a contains edge from a project or document
+func (e *Emitter) EmitContains(outV int, inVs []int) int {
+	v := struct {
+		element
+		OutV int   `json:"outV"`
+		InVs []int `json:"inVs"`
+	}{e.newElement("edge", "contains"), outV, inVs}
+	e.emit(v)
+	return v.ID
+}
+
+// EmitItem emits an item edge from a definition or reference result to
+// ranges in a document. property is "definitions", "references" or empty.
+func (e *Emitter) EmitItem(outV int, inVs []int, document int, property string) int {
+	v := struct {
+		element
+		OutV     int    `json:"outV"`
+		InVs     []int  `json:"inVs"`
+		Document int    `json:"document"`
+		Property string `json:"property,omitempty"`
+	}{e.newElement("edge", "item"), outV, inVs, document, property}
+	e.emit(v)
+	return v.ID
+}
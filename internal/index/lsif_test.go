@@ -0,0 +1,68 @@
+package index
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitterWritesJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEmitter(&buf)
+
+	meta := e.EmitMetaData("file:///work", "mcp-language-server", "v1")
+	doc := e.EmitDocument("file:///work/main.go", protocol.LangGo)
+	rng := e.EmitRange(protocol.Range{
+		Start: protocol.Position{Line: 2, Character: 5},
+		End:   protocol.Position{Line: 2, Character: 9},
+	})
+	resultSet := e.EmitResultSet()
+	e.EmitEdge("next", rng, resultSet)
+	hover := e.EmitHoverResult("func main()")
+	e.EmitEdge("textDocument/hover", resultSet, hover)
+	refs := e.EmitReferenceResult()
+	e.EmitItem(refs, []int{rng}, doc, "definitions")
+	e.EmitContains(doc, []int{rng})
+	require.NoError(t, e.Err())
+
+	assert.Equal(t, 1, meta)
+	assert.Equal(t, 2, doc)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 10)
+
+	var elements []map[string]any
+	for _, line := range lines {
+		var elem map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &elem))
+		elements = append(elements, elem)
+	}
+
+	assert.Equal(t, "metaData", elements[0]["label"])
+	assert.Equal(t, lsifVersion, elements[0]["version"])
+
+	assert.Equal(t, "document", elements[1]["label"])
+	assert.Equal(t, "go", elements[1]["languageId"])
+
+	assert.Equal(t, "range", elements[2]["label"])
+	assert.Equal(t, map[string]any{"line": float64(2), "character": float64(5)}, elements[2]["start"])
+
+	assert.Equal(t, "edge", elements[4]["type"])
+	assert.Equal(t, "next", elements[4]["label"])
+	assert.Equal(t, float64(rng), elements[4]["outV"])
+	assert.Equal(t, float64(resultSet), elements[4]["inV"])
+
+	assert.Equal(t, map[string]any{"contents": map[string]any{"kind": "markdown", "value": "func main()"}}, elements[5]["result"])
+
+	assert.Equal(t, "item", elements[8]["label"])
+	assert.Equal(t, "definitions", elements[8]["property"])
+	assert.Equal(t, float64(doc), elements[8]["document"])
+
+	assert.Equal(t, "contains", elements[9]["label"])
+	assert.Equal(t, []any{float64(rng)}, elements[9]["inVs"])
+}
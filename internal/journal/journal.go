@@ -0,0 +1,380 @@
+// Package journal records what the editing tools change, so that their
+// edits can be undone, and checkpoints that name a point to undo back to.
+package journal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits on what the journal keeps. The oldest entries are dropped first.
+const (
+	MaxEntries = 50
+	MaxBytes   = 8 << 20
+	// MaxFileSize is the largest file whose content is recorded. An edit of
+	// a larger file can't be undone.
+	MaxFileSize = 2 << 20
+	// MaxCheckpoints is the number of checkpoints kept
+	MaxCheckpoints = 20
+)
+
+// Entry is one tool call's edits
+type Entry struct {
+	Seq   int       `json:"seq"`
+	Tool  string    `json:"tool"`
+	Time  time.Time `json:"time"`
+	Files []File    `json:"files"`
+	// Incomplete is set when a file the call edited couldn't be recorded, so
+	// the call can't be undone
+	Incomplete bool `json:"incomplete,omitempty"`
+}
+
+// File is a file an entry changed
+type File struct {
+	Path string `json:"path"`
+	// Before is the file's content before the call. Created is set instead
+	// when the call created the file.
+	Before  []byte `json:"before,omitempty"`
+	Created bool   `json:"created,omitempty"`
+	// After is the hash of the content the call left, or empty if it removed
+	// the file
+	After string `json:"after,omitempty"`
+}
+
+// Checkpoint names the point in the journal after the entry Seq, along with
+// the errors each open file had then
+type Checkpoint struct {
+	Name string    `json:"name"`
+	Seq  int       `json:"seq"`
+	Time time.Time `json:"time"`
+	// Errors counts the errors of each file by a key that ignores where they
+	// are
+	Errors map[string]map[string]int `json:"errors,omitempty"`
+}
+
+// State is what a session saves of the journal
+type State struct {
+	Entries     []Entry      `json:"entries,omitempty"`
+	Checkpoints []Checkpoint `json:"checkpoints,omitempty"`
+	// Seq is the last sequence number given out
+	Seq int `json:"seq,omitempty"`
+	// Dropped is the sequence number of the last entry dropped to stay
+	// within the limits
+	Dropped int `json:"dropped,omitempty"`
+}
+
+// Journal is safe for concurrent use
+type Journal struct {
+	mu    sync.Mutex
+	state State
+}
+
+// New returns an empty journal
+func New() *Journal {
+	return &Journal{}
+}
+
+// State returns a copy of the journal's state
+func (j *Journal) State() State {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return State{
+		Entries:     slices.Clone(j.state.Entries),
+		Checkpoints: slices.Clone(j.state.Checkpoints),
+		Seq:         j.state.Seq,
+		Dropped:     j.state.Dropped,
+	}
+}
+
+// Restore replaces the journal's state with a saved one
+func (j *Journal) Restore(state State) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.state = state
+}
+
+// Recording collects the files one tool call edits
+type Recording struct {
+	journal *Journal
+	tool    string
+	mu      sync.Mutex
+	files   []File
+	// incomplete is set when a file couldn't be recorded
+	incomplete bool
+}
+
+// Record starts recording the edits of a call to tool. The recording is
+// added to the journal by Finish.
+func (j *Journal) Record(tool string) *Recording {
+	return &Recording{journal: j, tool: tool}
+}
+
+// Before records the content of a file before it is edited. It is an
+// utilities.EditWatcher, and only the first call for a file counts.
+func (r *Recording) Before(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slices.ContainsFunc(r.files, func(f File) bool { return f.Path == path }) {
+		return
+	}
+	file := File{Path: path}
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		file.Created = true
+	case err != nil || info.Size() > MaxFileSize:
+		r.incomplete = true
+		return
+	default:
+		file.Before, err = os.ReadFile(path)
+		if err != nil {
+			r.incomplete = true
+			return
+		}
+	}
+	r.files = append(r.files, file)
+}
+
+// Finish adds the recorded edits to the journal. Files the call left as
+// they were are left out, and a call that changed nothing isn't added.
+func (r *Recording) Finish() {
+	r.mu.Lock()
+	var files []File
+	for _, file := range r.files {
+		after, err := hashFile(file.Path)
+		if err != nil {
+			r.incomplete = true
+			continue
+		}
+		if after == "" && file.Created || !file.Created && after == hash(file.Before) {
+			continue
+		}
+		file.After = after
+		files = append(files, file)
+	}
+	incomplete := r.incomplete
+	r.mu.Unlock()
+	if len(files) == 0 && !incomplete {
+		return
+	}
+	sort.Slice(files, func(i, k int) bool { return files[i].Path < files[k].Path })
+
+	j := r.journal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.state.Seq++
+	j.state.Entries = append(j.state.Entries, Entry{
+		Seq:        j.state.Seq,
+		Tool:       r.tool,
+		Time:       time.Now(),
+		Files:      files,
+		Incomplete: incomplete,
+	})
+	j.trim()
+}
+
+// trim drops the oldest entries beyond the limits
+func (j *Journal) trim() {
+	size := 0
+	for _, entry := range j.state.Entries {
+		size += entry.size()
+	}
+	for len(j.state.Entries) > 0 && (len(j.state.Entries) > MaxEntries || size > MaxBytes) {
+		size -= j.state.Entries[0].size()
+		j.state.Dropped = j.state.Entries[0].Seq
+		j.state.Entries = j.state.Entries[1:]
+	}
+}
+
+func (e Entry) size() int {
+	size := 0
+	for _, file := range e.Files {
+		size += len(file.Before)
+	}
+	return size
+}
+
+// Checkpoint names the current point in the journal, replacing any
+// checkpoint of the same name. errors are the errors of the open files, as
+// in Checkpoint.Errors.
+func (j *Journal) Checkpoint(name string, errors map[string]map[string]int) Checkpoint {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	checkpoint := Checkpoint{Name: name, Seq: j.state.Seq, Time: time.Now(), Errors: errors}
+	j.state.Checkpoints = slices.DeleteFunc(j.state.Checkpoints, func(c Checkpoint) bool { return c.Name == name })
+	j.state.Checkpoints = append(j.state.Checkpoints, checkpoint)
+	if len(j.state.Checkpoints) > MaxCheckpoints {
+		j.state.Checkpoints = j.state.Checkpoints[len(j.state.Checkpoints)-MaxCheckpoints:]
+	}
+	return checkpoint
+}
+
+// FindCheckpoint returns the checkpoint named name
+func (j *Journal) FindCheckpoint(name string) (Checkpoint, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.findCheckpoint(name)
+}
+
+func (j *Journal) findCheckpoint(name string) (Checkpoint, error) {
+	for _, checkpoint := range j.state.Checkpoints {
+		if checkpoint.Name == name {
+			return checkpoint, nil
+		}
+	}
+	var names []string
+	for _, checkpoint := range j.state.Checkpoints {
+		names = append(names, checkpoint.Name)
+	}
+	if len(names) == 0 {
+		return Checkpoint{}, fmt.Errorf("no checkpoint named %q; no checkpoints were taken", name)
+	}
+	return Checkpoint{}, fmt.Errorf("no checkpoint named %q; the checkpoints are %s", name, strings.Join(names, ", "))
+}
+
+// Restored is a file Undo wrote back or removed
+type Restored struct {
+	Path string
+	// Current is the content the file had before it was restored, nil if it
+	// didn't exist
+	Current []byte
+	// Content is the restored content, nil if the file was removed
+	Content []byte
+}
+
+// ChangedError is returned by Undo when files were changed after the edits
+// it would undo
+type ChangedError struct {
+	Files []string
+}
+
+func (e *ChangedError) Error() string {
+	return fmt.Sprintf("%s changed after the edits, undoing them would lose those changes", strings.Join(e.Files, ", "))
+}
+
+// Undo reverts the entries after the checkpoint named, or the last entry if
+// name is empty. Files changed since are refused with a ChangedError unless
+// force is set. It returns the undone entries, newest first, and the files
+// it restored.
+func (j *Journal) Undo(name string, force bool) ([]Entry, []Restored, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entries := j.state.Entries
+	if len(entries) == 0 {
+		return nil, nil, errors.New("there are no edits to undo")
+	}
+	undone := entries[len(entries)-1:]
+	if name != "" {
+		checkpoint, err := j.findCheckpoint(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if checkpoint.Seq < j.state.Dropped {
+			return nil, nil, fmt.Errorf("the edits made right after checkpoint %q were dropped from the journal, which keeps the last %d", name, MaxEntries)
+		}
+		i := sort.Search(len(entries), func(i int) bool { return entries[i].Seq > checkpoint.Seq })
+		if i == len(entries) {
+			return nil, nil, fmt.Errorf("there are no edits since checkpoint %q", name)
+		}
+		undone = entries[i:]
+	}
+	for _, entry := range undone {
+		if entry.Incomplete {
+			return nil, nil, fmt.Errorf("the edits of %s at %s can't be undone: a file it edited was too large to record", entry.Tool, entry.Time.Local().Format("15:04:05"))
+		}
+	}
+
+	// Each file goes back to the content it had before the first undone
+	// entry that changed it, if it still has the content the last one left
+	restore := make(map[string]File)
+	expected := make(map[string]string)
+	for _, entry := range undone {
+		for _, file := range entry.Files {
+			if _, ok := restore[file.Path]; !ok {
+				restore[file.Path] = file
+			}
+			expected[file.Path] = file.After
+		}
+	}
+	paths := make([]string, 0, len(restore))
+	for path := range restore {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	if !force {
+		var changed []string
+		for _, path := range paths {
+			if current, err := hashFile(path); err != nil || current != expected[path] {
+				changed = append(changed, path)
+			}
+		}
+		if len(changed) > 0 {
+			return nil, nil, &ChangedError{Files: changed}
+		}
+	}
+
+	var restored []Restored
+	for _, path := range paths {
+		file := restore[path]
+		current, err := os.ReadFile(path)
+		if err != nil {
+			current = nil
+		}
+		if file.Created {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, restored, fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		} else if err := writeFile(path, file.Before); err != nil {
+			return nil, restored, err
+		}
+		restored = append(restored, Restored{Path: path, Current: current, Content: file.Before})
+	}
+
+	// Checkpoints taken after the undone edits no longer name a point that
+	// can be returned to
+	seq := undone[0].Seq - 1
+	j.state.Checkpoints = slices.DeleteFunc(j.state.Checkpoints, func(c Checkpoint) bool { return c.Seq > seq })
+	j.state.Entries = entries[:len(entries)-len(undone)]
+	reversed := slices.Clone(undone)
+	slices.Reverse(reversed)
+	return reversed, restored, nil
+}
+
+// writeFile writes content back to path, keeping the mode of a file that
+// exists
+func writeFile(path string, content []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(path, content, mode); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", path, err)
+	}
+	return nil
+}
+
+func hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashFile returns the hash of a file's content, or an empty string if it
+// doesn't exist
+func hashFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return hash(content), nil
+}
@@ -0,0 +1,189 @@
+package journal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// edit records a tool call that writes files, removing those given nil
+func edit(t *testing.T, j *Journal, tool string, files map[string]*string) {
+	t.Helper()
+	record := j.Record(tool)
+	for path, content := range files {
+		record.Before(path)
+		if content == nil {
+			require.NoError(t, os.Remove(path))
+		} else {
+			require.NoError(t, os.WriteFile(path, []byte(*content), 0644))
+		}
+	}
+	record.Finish()
+}
+
+func text(s string) *string { return &s }
+
+func read(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestUndo(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")
+	require.NoError(t, os.WriteFile(a, []byte("a1"), 0600))
+	j := New()
+
+	_, _, err := j.Undo("", false)
+	assert.ErrorContains(t, err, "there are no edits to undo")
+
+	edit(t, j, "rename_symbol", map[string]*string{a: text("a2"), b: text("b2")})
+	edit(t, j, "add_import", map[string]*string{a: text("a3")})
+	// A call that leaves the files as they were isn't recorded
+	edit(t, j, "organize_imports", map[string]*string{a: text("a3")})
+	require.Len(t, j.State().Entries, 2)
+
+	undone, restored, err := j.Undo("", false)
+	require.NoError(t, err)
+	require.Len(t, undone, 1)
+	assert.Equal(t, "add_import", undone[0].Tool)
+	assert.Equal(t, []Restored{{Path: a, Current: []byte("a3"), Content: []byte("a2")}}, restored)
+	assert.Equal(t, "a2", read(t, a))
+
+	undone, restored, err = j.Undo("", false)
+	require.NoError(t, err)
+	assert.Equal(t, "rename_symbol", undone[0].Tool)
+	require.Len(t, restored, 2)
+	assert.Equal(t, "a1", read(t, a))
+	assert.NoFileExists(t, b, "created files are removed")
+	info, err := os.Stat(a)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.Empty(t, j.State().Entries)
+}
+
+func TestUndoRemovedFile(t *testing.T) {
+	dir := t.TempDir()
+	old, renamed := filepath.Join(dir, "old.go"), filepath.Join(dir, "new.go")
+	require.NoError(t, os.WriteFile(old, []byte("package x"), 0644))
+	j := New()
+
+	edit(t, j, "rename_file", map[string]*string{old: nil, renamed: text("package x")})
+	_, _, err := j.Undo("", false)
+	require.NoError(t, err)
+	assert.Equal(t, "package x", read(t, old))
+	assert.NoFileExists(t, renamed)
+}
+
+func TestUndoChangedFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.go")
+	require.NoError(t, os.WriteFile(file, []byte("before"), 0644))
+	j := New()
+	edit(t, j, "rename_symbol", map[string]*string{file: text("edited")})
+	require.NoError(t, os.WriteFile(file, []byte("changed by hand"), 0644))
+
+	_, _, err := j.Undo("", false)
+	var changed *ChangedError
+	require.ErrorAs(t, err, &changed)
+	assert.Equal(t, []string{file}, changed.Files)
+	assert.Equal(t, "changed by hand", read(t, file))
+	assert.Len(t, j.State().Entries, 1)
+
+	_, restored, err := j.Undo("", true)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("changed by hand"), restored[0].Current)
+	assert.Equal(t, "before", read(t, file))
+}
+
+func TestUndoToCheckpoint(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.go")
+	require.NoError(t, os.WriteFile(file, []byte("v1"), 0644))
+	j := New()
+
+	edit(t, j, "rename_symbol", map[string]*string{file: text("v2")})
+	j.Checkpoint("start", map[string]map[string]int{file: {"compiler\x00undefined: x": 1}})
+	_, _, err := j.Undo("start", false)
+	assert.ErrorContains(t, err, `there are no edits since checkpoint "start"`)
+	_, _, err = j.Undo("other", false)
+	assert.ErrorContains(t, err, `no checkpoint named "other"; the checkpoints are start`)
+
+	edit(t, j, "add_import", map[string]*string{file: text("v3")})
+	j.Checkpoint("later", nil)
+	edit(t, j, "inline_symbol", map[string]*string{file: text("v4")})
+
+	undone, restored, err := j.Undo("start", false)
+	require.NoError(t, err)
+	require.Len(t, undone, 2)
+	assert.Equal(t, "inline_symbol", undone[0].Tool)
+	assert.Equal(t, "add_import", undone[1].Tool)
+	assert.Equal(t, []Restored{{Path: file, Current: []byte("v4"), Content: []byte("v2")}}, restored)
+	assert.Equal(t, "v2", read(t, file))
+
+	// The checkpoint is kept, and those after it are gone with the edits
+	checkpoint, err := j.FindCheckpoint("start")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]int{file: {"compiler\x00undefined: x": 1}}, checkpoint.Errors)
+	_, err = j.FindCheckpoint("later")
+	assert.Error(t, err)
+	assert.Len(t, j.State().Entries, 1)
+}
+
+func TestTrim(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.go")
+	require.NoError(t, os.WriteFile(file, []byte("v0"), 0644))
+	j := New()
+	j.Checkpoint("first", nil)
+	for i := range MaxEntries + 1 {
+		edit(t, j, "rename_symbol", map[string]*string{file: text(string(rune('a' + i)))})
+	}
+	state := j.State()
+	assert.Len(t, state.Entries, MaxEntries)
+	assert.Equal(t, 1, state.Dropped)
+	_, _, err := j.Undo("first", false)
+	assert.ErrorContains(t, err, `the edits made right after checkpoint "first" were dropped`)
+}
+
+func TestTooLargeFile(t *testing.T) {
+	dir := t.TempDir()
+	large, small := filepath.Join(dir, "large.go"), filepath.Join(dir, "small.go")
+	require.NoError(t, os.WriteFile(large, make([]byte, MaxFileSize+1), 0644))
+	j := New()
+
+	edit(t, j, "rename_symbol", map[string]*string{large: text("x"), small: text("y")})
+	state := j.State()
+	require.Len(t, state.Entries, 1)
+	assert.True(t, state.Entries[0].Incomplete)
+	_, _, err := j.Undo("", false)
+	assert.ErrorContains(t, err, "can't be undone")
+	assert.Equal(t, "y", read(t, small))
+}
+
+func TestRestore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.go")
+	require.NoError(t, os.WriteFile(file, []byte("\xff binary"), 0644))
+	j := New()
+	edit(t, j, "rename_symbol", map[string]*string{file: text("edited")})
+	j.Checkpoint("after", nil)
+
+	// The state survives a round trip through JSON, as sessions save it
+	data, err := json.Marshal(j.State())
+	require.NoError(t, err)
+	var state State
+	require.NoError(t, json.Unmarshal(data, &state))
+	resumed := New()
+	resumed.Restore(state)
+
+	edit(t, resumed, "add_import", map[string]*string{file: text("edited again")})
+	undone, _, err := resumed.Undo("", false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, undone[0].Seq)
+	_, _, err = resumed.Undo("", false)
+	require.NoError(t, err)
+	assert.Equal(t, "\xff binary", read(t, file))
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 )
@@ -43,6 +44,24 @@ func (l LogLevel) String() string {
 	}
 }
 
+// ParseLevel parses a level name such as "debug" or "WARN"
+func ParseLevel(name string) (LogLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "DEBUG":
+		return LevelDebug, nil
+	case "INFO":
+		return LevelInfo, nil
+	case "WARN":
+		return LevelWarn, nil
+	case "ERROR":
+		return LevelError, nil
+	case "FATAL":
+		return LevelFatal, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
 // Component represents a specific part of the application for which logs can be filtered
 type Component string
 
@@ -59,6 +78,10 @@ const (
 	Watcher Component = "watcher"
 	// Tools component for LSP tools
 	Tools Component = "tools"
+	// Index component for offline index export
+	Index Component = "index"
+	// Runner component for test and build commands run on behalf of tools
+	Runner Component = "runner"
 )
 
 // DefaultMinLevel is the default minimum log level
@@ -83,22 +106,15 @@ func init() {
 	ComponentLevels[LSP] = DefaultMinLevel
 	ComponentLevels[Watcher] = DefaultMinLevel
 	ComponentLevels[Tools] = DefaultMinLevel
+	ComponentLevels[Index] = DefaultMinLevel
+	ComponentLevels[Runner] = DefaultMinLevel
 	ComponentLevels[LSPProcess] = DefaultMinLevel
 	ComponentLevels[LSPWire] = DefaultMinLevel
 
 	// Parse log level from environment variable
 	if level := os.Getenv("LOG_LEVEL"); level != "" {
-		switch strings.ToUpper(level) {
-		case "DEBUG":
-			DefaultMinLevel = LevelDebug
-		case "INFO":
-			DefaultMinLevel = LevelInfo
-		case "WARN":
-			DefaultMinLevel = LevelWarn
-		case "ERROR":
-			DefaultMinLevel = LevelError
-		case "FATAL":
-			DefaultMinLevel = LevelFatal
+		if parsed, err := ParseLevel(level); err == nil {
+			DefaultMinLevel = parsed
 		}
 
 		// Set all components to this level by default
@@ -116,21 +132,8 @@ func init() {
 			}
 
 			comp := Component(strings.TrimSpace(compAndLevel[0]))
-			levelStr := strings.ToUpper(strings.TrimSpace(compAndLevel[1]))
-
-			var level LogLevel
-			switch levelStr {
-			case "DEBUG":
-				level = LevelDebug
-			case "INFO":
-				level = LevelInfo
-			case "WARN":
-				level = LevelWarn
-			case "ERROR":
-				level = LevelError
-			case "FATAL":
-				level = LevelFatal
-			default:
+			level, err := ParseLevel(compAndLevel[1])
+			if err != nil {
 				continue
 			}
 
@@ -250,6 +253,29 @@ func SetGlobalLevel(level LogLevel) {
 	}
 }
 
+// Levels returns the default level and a copy of the per component levels
+func Levels() (LogLevel, map[Component]LogLevel) {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	levels := make(map[Component]LogLevel, len(ComponentLevels))
+	for comp, level := range ComponentLevels {
+		levels[comp] = level
+	}
+	return DefaultMinLevel, levels
+}
+
+// SetLevels restores levels returned by Levels
+func SetLevels(defaultLevel LogLevel, levels map[Component]LogLevel) {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	DefaultMinLevel = defaultLevel
+	for comp, level := range levels {
+		ComponentLevels[comp] = level
+	}
+}
+
 // SetWriter sets the writer for log output
 func SetWriter(w io.Writer) {
 	logMu.Lock()
@@ -290,3 +316,41 @@ func ResetTestLogging() {
 
 	TestOutput = nil
 }
+
+// logFiles are the files added with AddFileLogging
+var logFiles []*os.File
+
+// AddFileLogging writes logs to a file in addition to stderr and the files
+// added before, until the returned function is called. Test suites running
+// in parallel each add their own file, which then also holds the logs of the
+// suites running at the same time.
+func AddFileLogging(filePath string) (remove func() error, err error) {
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	logFiles = append(logFiles, file)
+	setFileWriters()
+	return func() error {
+		logMu.Lock()
+		defer logMu.Unlock()
+
+		logFiles = slices.DeleteFunc(logFiles, func(f *os.File) bool { return f == file })
+		setFileWriters()
+		return file.Close()
+	}, nil
+}
+
+// setFileWriters points the logs at stderr and logFiles. logMu must be held.
+func setFileWriters() {
+	writers := []io.Writer{os.Stderr}
+	for _, file := range logFiles {
+		writers = append(writers, file)
+	}
+	Writer = io.MultiWriter(writers...)
+	log.SetOutput(Writer)
+}
@@ -3,6 +3,8 @@ package logging
 import (
 	"bytes"
 	"maps"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -106,3 +108,59 @@ func TestLogger(t *testing.T) {
 		})
 	}
 }
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]LogLevel{"debug": LevelDebug, " WARN ": LevelWarn, "Fatal": LevelFatal} {
+		level, err := ParseLevel(name)
+		if err != nil || level != want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", name, level, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel accepted an unknown level")
+	}
+}
+
+func TestAddFileLogging(t *testing.T) {
+	originalWriter := Writer
+	defer SetWriter(originalWriter)
+
+	dir := t.TempDir()
+	removeFirst, err := AddFileLogging(filepath.Join(dir, "first.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	removeSecond, err := AddFileLogging(filepath.Join(dir, "second.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := NewLogger(Core)
+	logger.Info("both suites running")
+	if err := removeFirst(); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("second suite running")
+	if err := removeSecond(); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("no suite running")
+
+	for name, expected := range map[string][]string{
+		"first.log":  {"both suites running"},
+		"second.log": {"both suites running", "second suite running"},
+	} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		if len(lines) != len(expected) {
+			t.Fatalf("%s has %d lines, expected %d:\n%s", name, len(lines), len(expected), content)
+		}
+		for i, line := range lines {
+			if !strings.HasSuffix(line, "[INFO][core] "+expected[i]) {
+				t.Errorf("%s line %d is %q, expected %q", name, i+1, line, expected[i])
+			}
+		}
+	}
+}
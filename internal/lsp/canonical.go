@@ -0,0 +1,187 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// foldsCase is set where filesystems ignore the case of names by default
+// and filepath.EvalSymlinks leaves names in the case they were given. On
+// Windows EvalSymlinks already returns names in their case on disk.
+var foldsCase = runtime.GOOS == "darwin"
+
+// canonicalizer gives each file one URI, whichever symlink or, where the
+// filesystem ignores case, whichever case of its name it is reached by, so
+// that the client and the server don't track one file as two documents.
+// Paths are resolved, and those in the workspace are put back under the
+// workspace path as it was given, so a workspace reached through a symlink
+// keeps its path. A nil canonicalizer leaves paths as they are.
+type canonicalizer struct {
+	mu                 sync.RWMutex
+	root, resolvedRoot string
+	// paths caches the canonical paths of files that exist, and names the
+	// paths of files with their names in the case they have on disk
+	paths map[string]string
+	names map[string]string
+}
+
+func newCanonicalizer() *canonicalizer {
+	return &canonicalizer{paths: make(map[string]string), names: make(map[string]string)}
+}
+
+// setRoot sets the workspace whose files keep its path
+func (c *canonicalizer) setRoot(root string) {
+	if c == nil {
+		return
+	}
+	resolved, _ := c.resolve(filepath.Clean(root))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.root, c.resolvedRoot = filepath.Clean(root), resolved
+	c.paths = make(map[string]string)
+}
+
+// path returns the canonical form of an absolute path
+func (c *canonicalizer) path(path string) string {
+	path = filepath.Clean(path)
+	if c == nil {
+		return path
+	}
+	c.mu.RLock()
+	canonical, ok := c.paths[path]
+	root, resolvedRoot := c.root, c.resolvedRoot
+	c.mu.RUnlock()
+	if ok {
+		return canonical
+	}
+
+	canonical, exists := c.resolve(path)
+	if resolvedRoot != "" {
+		if rel, err := filepath.Rel(resolvedRoot, canonical); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			canonical = filepath.Join(root, rel)
+		}
+	}
+	// Files that don't exist yet may be created as or through symlinks
+	if exists {
+		c.mu.Lock()
+		c.paths[path] = canonical
+		c.mu.Unlock()
+	}
+	return canonical
+}
+
+// resolve follows the symlinks in path and puts its names in their case on
+// disk. For a path that doesn't exist, that is done for the directories of
+// it that do. It reports whether the path exists.
+func (c *canonicalizer) resolve(path string) (string, bool) {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return c.diskCase(resolved), true
+	}
+	dir := filepath.Dir(path)
+	if dir == path {
+		return path, false
+	}
+	resolved, _ := c.resolve(dir)
+	return filepath.Join(resolved, filepath.Base(path)), false
+}
+
+// diskCase returns an existing path with its names in the case they have on
+// disk, where the filesystem ignores case
+func (c *canonicalizer) diskCase(path string) string {
+	if !foldsCase {
+		return path
+	}
+	return c.caseOnDisk(path)
+}
+
+func (c *canonicalizer) caseOnDisk(path string) string {
+	dir := filepath.Dir(path)
+	if dir == path {
+		return path
+	}
+	c.mu.RLock()
+	cased, ok := c.names[path]
+	c.mu.RUnlock()
+	if ok {
+		return cased
+	}
+
+	dir = c.caseOnDisk(dir)
+	name := filepath.Base(path)
+	// A name that matches exactly is the file, even where case matters
+	entries, err := os.ReadDir(dir)
+	if err == nil && !slices.ContainsFunc(entries, func(entry os.DirEntry) bool { return entry.Name() == name }) {
+		for _, entry := range entries {
+			if strings.EqualFold(entry.Name(), name) {
+				name = entry.Name()
+				break
+			}
+		}
+	}
+	cased = filepath.Join(dir, name)
+	c.mu.Lock()
+	c.names[path] = cased
+	c.mu.Unlock()
+	return cased
+}
+
+// uri returns the canonical form of a file URI. Other URIs are returned as
+// they are.
+func (c *canonicalizer) uri(uri string) string {
+	if c == nil {
+		return uri
+	}
+	rest, fragment, hasFragment := strings.Cut(uri, "#")
+	parsed, err := protocol.ParseDocumentUri(rest)
+	if err != nil || parsed == "" {
+		return uri
+	}
+	canonical := string(protocol.URIFromPath(c.path(parsed.Path())))
+	if hasFragment {
+		canonical += "#" + fragment
+	}
+	return canonical
+}
+
+// fileURIPattern finds file URIs in JSON, leaving out those in strings with
+// escapes, such as URIs quoted in document text
+var fileURIPattern = regexp.MustCompile(`"file://[^"\\]*"`)
+
+// rewrite canonicalizes the file URIs in a message exchanged with the server
+func (c *canonicalizer) rewrite(msg *Message) {
+	msg.Params = c.rewriteURIs(msg.Params)
+	msg.Result = c.rewriteURIs(msg.Result)
+}
+
+// rewriteURIs canonicalizes the file URIs in a JSON value. Values whose URIs
+// are canonical already, as most are, aren't parsed.
+func (c *canonicalizer) rewriteURIs(raw json.RawMessage) json.RawMessage {
+	if c == nil || !bytes.Contains(raw, []byte("file://")) {
+		return raw
+	}
+	for _, match := range fileURIPattern.FindAll(raw, -1) {
+		if uri := string(match[1 : len(match)-1]); c.uri(uri) != uri {
+			return rewriteURIs(raw, c.uri)
+		}
+	}
+	return raw
+}
+
+// documentURI returns the canonical URI of the document at path
+func (c *Client) documentURI(path string) protocol.DocumentUri {
+	return protocol.URIFromPath(c.canonical.path(protocol.URIFromPath(path).Path()))
+}
+
+// canonicalURI returns the canonical form of a document URI
+func (c *Client) canonicalURI(uri protocol.DocumentUri) protocol.DocumentUri {
+	return protocol.DocumentUri(c.canonical.uri(string(uri)))
+}
@@ -0,0 +1,115 @@
+package lsp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// symlinkedWorkspace creates a workspace reached through a symlink, with a
+// directory real in it that link points to
+func symlinkedWorkspace(t *testing.T) (root, realRoot string) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	realRoot = filepath.Join(dir, "real-workspace")
+	root = filepath.Join(dir, "workspace")
+	require.NoError(t, os.MkdirAll(filepath.Join(realRoot, "real"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(realRoot, "real", "a.go"), []byte("package real\n"), 0644))
+	require.NoError(t, os.Symlink(realRoot, root))
+	require.NoError(t, os.Symlink(filepath.Join(realRoot, "real"), filepath.Join(realRoot, "link")))
+	return root, realRoot
+}
+
+func TestCanonicalizerPath(t *testing.T) {
+	root, realRoot := symlinkedWorkspace(t)
+	c := newCanonicalizer()
+	c.setRoot(root)
+
+	want := filepath.Join(root, "real", "a.go")
+	assert.Equal(t, want, c.path(filepath.Join(root, "link", "a.go")))
+	assert.Equal(t, want, c.path(filepath.Join(realRoot, "link", "a.go")))
+	assert.Equal(t, want, c.path(filepath.Join(realRoot, "real", "..", "real", "a.go")))
+	assert.Equal(t, want, c.path(want))
+
+	// Files that don't exist yet have their directories resolved
+	assert.Equal(t, filepath.Join(root, "real", "new.go"), c.path(filepath.Join(root, "link", "new.go")))
+
+	outside := filepath.Join(filepath.Dir(root), "other.go")
+	assert.Equal(t, outside, c.path(outside))
+
+	var unset *canonicalizer
+	assert.Equal(t, filepath.Join(root, "link", "a.go"), unset.path(filepath.Join(root, "link", "a.go")))
+}
+
+func TestCanonicalizerDiskCase(t *testing.T) {
+	saved := foldsCase
+	foldsCase = true
+	defer func() { foldsCase = saved }()
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "Pkg"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Pkg", "Main.go"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Pkg", "main.go"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Pkg", "Util.go"), nil, 0644))
+
+	c := newCanonicalizer()
+	assert.Equal(t, filepath.Join(dir, "Pkg", "Util.go"), c.diskCase(filepath.Join(dir, "pkg", "util.go")))
+	// Where case matters, a name that matches exactly is kept
+	assert.Equal(t, filepath.Join(dir, "Pkg", "main.go"), c.diskCase(filepath.Join(dir, "PKG", "main.go")))
+}
+
+func TestCanonicalizerMessages(t *testing.T) {
+	root, realRoot := symlinkedWorkspace(t)
+	c := newCanonicalizer()
+	c.setRoot(root)
+
+	link := string(protocol.URIFromPath(filepath.Join(realRoot, "link", "a.go")))
+	want := string(protocol.URIFromPath(filepath.Join(root, "real", "a.go")))
+	assert.Equal(t, want, c.uri(link))
+	assert.Equal(t, want+"#L1", c.uri(link+"#L1"))
+	assert.Equal(t, "untitled:Untitled-1", c.uri("untitled:Untitled-1"))
+
+	params, err := json.Marshal(map[string]any{
+		"uri":  link,
+		"text": `open("` + link + `")`,
+	})
+	require.NoError(t, err)
+	msg := &Message{Params: params}
+	c.rewrite(msg)
+	var rewritten map[string]string
+	require.NoError(t, json.Unmarshal(msg.Params, &rewritten))
+	assert.Equal(t, want, rewritten["uri"])
+	assert.Equal(t, `open("`+link+`")`, rewritten["text"])
+
+	// Messages whose URIs are canonical are left as they are
+	raw := json.RawMessage(`{"uri":  "` + want + `"}`)
+	msg = &Message{Params: raw}
+	c.rewrite(msg)
+	assert.Equal(t, string(raw), string(msg.Params))
+}
+
+func TestClientCanonicalDocuments(t *testing.T) {
+	root, realRoot := symlinkedWorkspace(t)
+	client := newClient(nil, nil, nil)
+	client.canonical.setRoot(root)
+	client.openFiles[string(protocol.URIFromPath(filepath.Join(root, "real", "a.go")))] = &OpenFileInfo{Version: 1}
+
+	assert.True(t, client.IsFileOpen(filepath.Join(root, "link", "a.go")))
+	assert.True(t, client.IsFileOpen(filepath.Join(realRoot, "real", "a.go")))
+
+	// Diagnostics the server publishes under the resolved path are found
+	// under any path of the file
+	published := &Message{Params: json.RawMessage(`{"uri":"` + string(protocol.URIFromPath(filepath.Join(realRoot, "real", "a.go"))) + `","diagnostics":[{"message":"unused"}]}`)}
+	client.canonical.rewrite(published)
+	HandleDiagnostics(client, published.Params)
+	diagnostics := client.GetFileDiagnostics(protocol.URIFromPath(filepath.Join(root, "link", "a.go")))
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, "unused", diagnostics[0].Message)
+	assert.Len(t, client.GetWorkspaceDiagnostics(), 1)
+}
@@ -8,14 +8,20 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/sandbox"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
 )
 
+// ClientVersion is the version reported to language servers in clientInfo
+var ClientVersion = "0.1.0"
+
 type Client struct {
 	Cmd    *exec.Cmd
 	stdin  io.WriteCloser
@@ -36,21 +42,176 @@ type Client struct {
 	// Notification handlers
 	notificationHandlers map[string]NotificationHandler
 	notificationMu       sync.RWMutex
+	// Listeners that see every notification, by the ID they were added
+	// under. Guarded by notificationMu.
+	notificationListeners map[int]NotificationListener
+	nextListener          int
 
 	// Diagnostic cache
 	diagnostics   map[protocol.DocumentUri][]protocol.Diagnostic
 	diagnosticsMu sync.RWMutex
+	// diagnosticsVersions counts the publishDiagnostics notifications per
+	// document, and diagnosticsNotify is closed and replaced on each one.
+	// Both are guarded by diagnosticsMu.
+	diagnosticsVersions map[protocol.DocumentUri]uint64
+	diagnosticsNotify   chan struct{}
 
 	// Files are currently opened by the LSP
 	openFiles   map[string]*OpenFileInfo
 	openFilesMu sync.RWMutex
+
+	// Notebooks currently opened by the LSP, guarded by openFilesMu
+	notebooks map[string]*Notebook
+
+	// Documents editors connected through a Proxy have open, by URI
+	editorHolds   map[protocol.DocumentUri]*editorHold
+	editorHoldsMu sync.Mutex
+
+	// Counts notifications that changed workspace content
+	contentVersion atomic.Int64
+
+	// Settings returned for workspace/configuration requests, merged over
+	// the defaults for the server
+	settings       map[string]any
+	serverDefaults map[string]any
+	egress         EgressPolicy
+	settingsMu     sync.RWMutex
+
+	// Work done progress reported by the server, by token
+	progress   map[string]*progress
+	progressMu sync.RWMutex
+
+	// ready is closed when a server that reports loading the workspace,
+	// like the Roslyn language server, has loaded it. It is nil for others.
+	ready   chan struct{}
+	readyMu sync.Mutex
+
+	// The workspace the server was initialized with
+	workspaceDir string
+
+	// The capabilities the server reported when it was initialized, and
+	// the whole result of initialize
+	capabilities     protocol.ServerCapabilities
+	initializeResult *protocol.InitializeResult
+
+	// Translates file URIs for a server that sees files at other paths
+	pathMap pathMapper
+
+	// Gives each file one URI on both sides of the connection
+	canonical *canonicalizer
+
+	// The kind of code the server reads out of Vue, Svelte and HTML files,
+	// and the virtual documents it is given for the open ones, by host
+	// file URI and back
+	embeddedKind      embeddedKind
+	embeddedDocuments map[protocol.DocumentUri]protocol.DocumentUri
+	embeddedHosts     map[protocol.DocumentUri]protocol.DocumentUri
+	embeddedMu        sync.RWMutex
+
+	// wait replaces Cmd.Wait when the process is already being waited for
+	wait func() error
+
+	// connMu guards the connection, stdin, stdout, stderr, Cmd, wait and
+	// done, which Restart replaces. writeMu keeps messages from
+	// interleaving.
+	connMu  sync.RWMutex
+	writeMu sync.Mutex
+	// done is closed when the message loop stops, because the server exited
+	// or the connection was closed
+	done chan struct{}
+	// stopping is set once the server is asked to shut down, so that its
+	// exit isn't taken for a crash
+	stopping atomic.Bool
+	// reconnect opens a new connection for Restart. It is nil when the
+	// connection can't be reopened.
+	reconnect func(ctx context.Context) (*connection, error)
+	// requestTimeout bounds each request when it is positive
+	requestTimeout atomic.Int64
+
+	// The contexts of the workspace/executeCommand requests in flight, by
+	// the ID they were added under, which edits the server sends while a
+	// command runs are applied with
+	commands    map[int]context.Context
+	nextCommand int
+	commandsMu  sync.Mutex
+
+	// The name the server's results are labelled with among those of
+	// other servers
+	name string
+	// Other servers working alongside this one, the server that this one
+	// is a delegate of, which server offered each code action listed last,
+	// by CodeActionKey, and which answered the last request of each
+	// method routed along a chain. delegatesMu guards delegates,
+	// actionOrigins and answeredBy.
+	delegates     []*delegate
+	parent        atomic.Pointer[Client]
+	actionOrigins map[string]*Client
+	answeredBy    map[string]*Client
+	delegatesMu   sync.RWMutex
+}
+
+// connection is one connection to a server: its streams and, when the
+// client started it, the process
+type connection struct {
+	stdin  io.WriteCloser
+	stdout io.Reader
+	stderr io.ReadCloser
+	cmd    *exec.Cmd
+	// wait replaces cmd.Wait when the process is already being waited for
+	wait func() error
 }
 
 func NewClient(command string, args ...string) (*Client, error) {
-	cmd := exec.Command(command, args...)
-	// Copy env
-	cmd.Env = os.Environ()
+	return NewClientWithOptions(ProcessOptions{}, command, args...)
+}
+
+// NewClientWithOptions starts a language server with extra environment
+// variables, PATH entries or a working directory, optionally through a
+// runner such as a container
+func NewClientWithOptions(opts ProcessOptions, command string, args ...string) (*Client, error) {
+	command, args = opts.Command(command, args...)
+	path, err := opts.LookPath(command)
+	if err != nil {
+		// Let exec report the error when the server is started
+		path = command
+	}
+	start := func(ctx context.Context) (*connection, error) {
+		// Checked on every start, since a pinned executable can be
+		// replaced while the server runs
+		if err := CheckExecutable(path, args, opts.Pinned); err != nil {
+			return nil, err
+		}
+		cmd := exec.Command(path, args...)
+		if opts.Sandbox != nil {
+			sandboxed, wrapped, err := opts.Sandbox.Command(path, args)
+			if err != nil {
+				return nil, fmt.Errorf("failed to sandbox the language server: %w", err)
+			}
+			cmd = exec.Command(sandboxed, wrapped...)
+			cmd.SysProcAttr = sandbox.SysProcAttr()
+		}
+		cmd.Env = opts.Environ()
+		cmd.Dir = opts.Dir
 
+		// Servers that listen on a socket only log to stdio
+		if opts.Connect != "" {
+			return startSocketServer(opts, cmd)
+		}
+		return startProcess(cmd)
+	}
+
+	conn, err := start(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	client := newClient(conn.stdin, conn.stdout, opts.PathMappings)
+	client.reconnect = start
+	client.attach(conn)
+	return client, nil
+}
+
+// startProcess starts a server that speaks the protocol over stdio
+func startProcess(cmd *exec.Cmd) (*connection, error) {
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
@@ -66,39 +227,67 @@ func NewClient(command string, args ...string) (*Client, error) {
 		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
 	}
 
-	client := &Client{
-		Cmd:                   cmd,
+	// Start the LSP server process
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start LSP server: %w", err)
+	}
+
+	// Handle stderr in a separate goroutine with proper logging
+	go logOutput(stderr, "stderr")
+
+	return &connection{stdin: stdin, stdout: stdout, stderr: stderr, cmd: cmd}, nil
+}
+
+// newClient creates a client that talks to a server over a pair of streams.
+// The caller starts the message loop with attach.
+func newClient(stdin io.WriteCloser, stdout io.Reader, mappings []PathMapping) *Client {
+	return &Client{
 		stdin:                 stdin,
 		stdout:                bufio.NewReader(stdout),
-		stderr:                stderr,
 		handlers:              make(map[string]chan *Message),
 		notificationHandlers:  make(map[string]NotificationHandler),
+		notificationListeners: make(map[int]NotificationListener),
 		serverRequestHandlers: make(map[string]ServerRequestHandler),
 		diagnostics:           make(map[protocol.DocumentUri][]protocol.Diagnostic),
+		diagnosticsVersions:   make(map[protocol.DocumentUri]uint64),
+		diagnosticsNotify:     make(chan struct{}),
 		openFiles:             make(map[string]*OpenFileInfo),
+		notebooks:             make(map[string]*Notebook),
+		editorHolds:           make(map[protocol.DocumentUri]*editorHold),
+		progress:              make(map[string]*progress),
+		pathMap:               pathMapper(mappings),
+		canonical:             newCanonicalizer(),
+		embeddedDocuments:     make(map[protocol.DocumentUri]protocol.DocumentUri),
+		embeddedHosts:         make(map[protocol.DocumentUri]protocol.DocumentUri),
+		done:                  make(chan struct{}),
 	}
+}
 
-	// Start the LSP server process
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start LSP server: %w", err)
-	}
-
-	// Handle stderr in a separate goroutine with proper logging
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			line := scanner.Text()
-			processLogger.Info("%s", line)
-		}
-		if err := scanner.Err(); err != nil {
-			lspLogger.Error("Error reading LSP server stderr: %v", err)
-		}
-	}()
-
-	// Start message handling loop
-	go client.handleMessages()
+// attach makes conn the client's connection and starts reading from it
+func (c *Client) attach(conn *connection) {
+	done := make(chan struct{})
+	stdout := bufio.NewReader(conn.stdout)
+	c.connMu.Lock()
+	c.stdin = conn.stdin
+	c.stdout = stdout
+	c.stderr = conn.stderr
+	c.Cmd = conn.cmd
+	c.wait = conn.wait
+	c.done = done
+	c.connMu.Unlock()
+	go c.handleMessages(stdout, done)
+}
 
-	return client, nil
+// logOutput logs each line a server process writes outside the protocol
+func logOutput(r io.Reader, name string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		processLogger.Info("%s", line)
+	}
+	if err := scanner.Err(); err != nil {
+		lspLogger.Error("Error reading LSP server %s: %v", name, err)
+	}
 }
 
 func (c *Client) RegisterNotificationHandler(method string, handler NotificationHandler) {
@@ -107,6 +296,22 @@ func (c *Client) RegisterNotificationHandler(method string, handler Notification
 	c.notificationHandlers[method] = handler
 }
 
+// AddNotificationListener calls listener with every notification from the
+// server, after its handler, until the returned function is called. Listeners
+// are called from the message loop, so they must not block.
+func (c *Client) AddNotificationListener(listener NotificationListener) func() {
+	c.notificationMu.Lock()
+	defer c.notificationMu.Unlock()
+	id := c.nextListener
+	c.nextListener++
+	c.notificationListeners[id] = listener
+	return func() {
+		c.notificationMu.Lock()
+		defer c.notificationMu.Unlock()
+		delete(c.notificationListeners, id)
+	}
+}
+
 func (c *Client) RegisterServerRequestHandler(method string, handler ServerRequestHandler) {
 	c.serverHandlersMu.Lock()
 	defer c.serverHandlersMu.Unlock()
@@ -114,11 +319,12 @@ func (c *Client) RegisterServerRequestHandler(method string, handler ServerReque
 }
 
 func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (*protocol.InitializeResult, error) {
+	c.canonical.setRoot(workspaceDir)
 	initParams := &protocol.InitializeParams{
 		WorkspaceFoldersInitializeParams: protocol.WorkspaceFoldersInitializeParams{
 			WorkspaceFolders: []protocol.WorkspaceFolder{
 				{
-					URI:  protocol.URI("file://" + workspaceDir),
+					URI:  protocol.URI(protocol.URIFromPath(workspaceDir)),
 					Name: workspaceDir,
 				},
 			},
@@ -128,10 +334,10 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 			ProcessID: int32(os.Getpid()),
 			ClientInfo: &protocol.ClientInfo{
 				Name:    "mcp-language-server",
-				Version: "0.1.0",
+				Version: ClientVersion,
 			},
 			RootPath: workspaceDir,
-			RootURI:  protocol.DocumentUri("file://" + workspaceDir),
+			RootURI:  protocol.URIFromPath(workspaceDir),
 			Capabilities: protocol.ClientCapabilities{
 				Workspace: protocol.WorkspaceClientCapabilities{
 					Configuration: true,
@@ -149,7 +355,20 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 						DidSave:             true,
 					},
 					Completion: protocol.CompletionClientCapabilities{
-						CompletionItem: protocol.ClientCompletionItemOptions{},
+						CompletionItem: protocol.ClientCompletionItemOptions{
+							DocumentationFormat: []protocol.MarkupKind{protocol.Markdown, protocol.PlainText},
+							ResolveSupport: &protocol.ClientCompletionItemResolveOptions{
+								Properties: []string{"documentation", "detail"},
+							},
+						},
+					},
+					SignatureHelp: &protocol.SignatureHelpClientCapabilities{
+						SignatureInformation: &protocol.ClientSignatureInformationOptions{
+							DocumentationFormat: []protocol.MarkupKind{protocol.Markdown, protocol.PlainText},
+							ParameterInformation: &protocol.ClientSignatureParameterInformationOptions{
+								LabelOffsetSupport: true,
+							},
+						},
 					},
 					CodeLens: &protocol.CodeLensClientCapabilities{
 						DynamicRegistration: true,
@@ -161,6 +380,10 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 								ValueSet: []protocol.CodeActionKind{},
 							},
 						},
+						DataSupport: true,
+						ResolveSupport: &protocol.ClientCodeActionResolveOptions{
+							Properties: []string{"edit"},
+						},
 					},
 					PublishDiagnostics: protocol.PublishDiagnosticsClientCapabilities{
 						VersionSupport: true,
@@ -175,7 +398,12 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 						Formats:        []protocol.TokenFormat{},
 					},
 				},
-				Window: protocol.WindowClientCapabilities{},
+				NotebookDocument: &protocol.NotebookDocumentClientCapabilities{
+					Synchronization: protocol.NotebookDocumentSyncClientCapabilities{},
+				},
+				Window: protocol.WindowClientCapabilities{
+					WorkDoneProgress: true,
+				},
 			},
 			InitializationOptions: map[string]any{
 				"codelenses": map[string]bool{
@@ -191,30 +419,66 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 		},
 	}
 
+	c.workspaceDir = workspaceDir
+
+	// The server may be wrapped in a runner, so look at every argument
+	path := c.commandLine()
+	c.embeddedKind = embeddedServerKind(path)
+	if strings.Contains(path, "intelephense") {
+		initParams.InitializationOptions = intelephenseInitializationOptions(c.environ())
+	}
+	if c.EgressPolicy().Offline && strings.Contains(path, "typescript-language-server") {
+		// Automatic type acquisition downloads @types packages from npm
+		initParams.InitializationOptions = map[string]any{"disableAutomaticTypingAcquisition": true}
+	}
+
+	// Servers may start reporting progress while they initialize
+	c.RegisterServerRequestHandler("window/workDoneProgress/create", HandleWorkDoneProgressCreate)
+	c.RegisterNotificationHandler("$/progress",
+		func(params json.RawMessage) { HandleProgress(c, params) })
+
 	var result protocol.InitializeResult
 	if err := c.Call(ctx, "initialize", initParams, &result); err != nil {
 		return nil, fmt.Errorf("initialize failed: %w", err)
 	}
+	c.capabilities = result.Capabilities
+	c.initializeResult = &result
 
 	if err := c.Initialized(ctx, protocol.InitializedParams{}); err != nil {
 		return nil, fmt.Errorf("initialized failed: %w", err)
 	}
 
 	// Register handlers
-	c.RegisterServerRequestHandler("workspace/applyEdit", HandleApplyEdit)
-	c.RegisterServerRequestHandler("workspace/configuration", HandleWorkspaceConfiguration)
+	c.RegisterServerRequestHandler("workspace/applyEdit",
+		func(params json.RawMessage) (any, error) { return HandleApplyEdit(c, params) })
+	c.RegisterServerRequestHandler("workspace/configuration",
+		func(params json.RawMessage) (any, error) { return HandleWorkspaceConfiguration(c, params) })
 	c.RegisterServerRequestHandler("client/registerCapability", HandleRegisterCapability)
 	c.RegisterNotificationHandler("window/showMessage", HandleServerMessage)
 	c.RegisterNotificationHandler("textDocument/publishDiagnostics",
 		func(params json.RawMessage) { HandleDiagnostics(c, params) })
 
 	// LSP sepecific Initialization
-	path := strings.ToLower(c.Cmd.Path)
 	if strings.Contains(path, "typescript-language-server") || strings.Contains(path, "vtsls") {
 		if err := initializeTypescriptLanguageServer(ctx, c, workspaceDir); err != nil {
 			return nil, err
 		}
 	}
+	if strings.Contains(path, "yaml-language-server") {
+		if err := initializeYAMLLanguageServer(ctx, c, workspaceDir); err != nil {
+			return nil, err
+		}
+	}
+	if isElixirLS(path) {
+		if err := initializeElixirLS(ctx, c, workspaceDir); err != nil {
+			return nil, err
+		}
+	}
+	if isRoslynLanguageServer(path) {
+		if err := initializeRoslynLanguageServer(ctx, c, workspaceDir); err != nil {
+			return nil, err
+		}
+	}
 
 	return &result, nil
 }
@@ -226,6 +490,17 @@ func (c *Client) Close() error {
 
 	// Attempt to close files but continue shutdown regardless
 	c.CloseAllFiles(ctx)
+	c.closeDelegates(ctx)
+	c.stopping.Store(true)
+
+	c.connMu.RLock()
+	stdin, cmd, wait := c.stdin, c.Cmd, c.wait
+	c.connMu.RUnlock()
+
+	// A server we connected to keeps running for other clients
+	if cmd == nil {
+		return stdin.Close()
+	}
 
 	// Force kill the LSP process if it doesn't exit within timeout
 	forcedKill := make(chan struct{})
@@ -233,8 +508,8 @@ func (c *Client) Close() error {
 		select {
 		case <-time.After(2 * time.Second):
 			lspLogger.Warn("LSP process did not exit within timeout, forcing kill")
-			if c.Cmd.Process != nil {
-				if err := c.Cmd.Process.Kill(); err != nil {
+			if cmd.Process != nil {
+				if err := cmd.Process.Kill(); err != nil {
 					lspLogger.Error("Failed to kill process: %v", err)
 				} else {
 					lspLogger.Info("Process killed successfully")
@@ -248,12 +523,15 @@ func (c *Client) Close() error {
 	}()
 
 	// Close stdin to signal the server
-	if err := c.stdin.Close(); err != nil {
+	if err := stdin.Close(); err != nil {
 		lspLogger.Error("Failed to close stdin: %v", err)
 	}
 
 	// Wait for process to exit
-	err := c.Cmd.Wait()
+	if wait == nil {
+		wait = cmd.Wait
+	}
+	err := wait()
 	close(forcedKill) // Stop the force kill goroutine
 
 	return err
@@ -268,61 +546,106 @@ const (
 )
 
 func (c *Client) WaitForServerReady(ctx context.Context) error {
-	// TODO: wait for specific messages or poll workspace/symbol
-	time.Sleep(time.Second * 1)
+	c.readyMu.Lock()
+	ready := c.ready
+	c.readyMu.Unlock()
+	if ready == nil {
+		// TODO: wait for specific messages or poll workspace/symbol
+		time.Sleep(time.Second * 1)
+		return nil
+	}
+
+	select {
+	case <-ready:
+	case <-time.After(projectLoadTimeout):
+		lspLogger.Warn("Language server hasn't finished loading the workspace after %v, continuing", projectLoadTimeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 	return nil
 }
 
 type OpenFileInfo struct {
-	Version int32
-	URI     protocol.DocumentUri
+	Version    int32
+	URI        protocol.DocumentUri
+	LanguageID protocol.LanguageKind
 }
 
 func (c *Client) OpenFile(ctx context.Context, filepath string) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := string(c.documentURI(filepath))
+
+	if IsNotebook(filepath) {
+		if c.IsFileOpen(filepath) {
+			return nil // Already open
+		}
+		return c.openNotebook(ctx, filepath)
+	}
 
 	c.openFilesMu.Lock()
 	if _, exists := c.openFiles[uri]; exists {
 		c.openFilesMu.Unlock()
+		c.holdForTools(protocol.DocumentUri(uri))
 		return nil // Already open
 	}
 	c.openFilesMu.Unlock()
 
 	// Skip files that do not exist or cannot be read
-	content, err := os.ReadFile(filepath)
+	content, err := utilities.ReadFile(filepath)
 	if err != nil {
 		return fmt.Errorf("error reading file: %w", err)
 	}
 
+	if err := c.openTextDocument(ctx, protocol.DocumentUri(uri), DetectLanguageIDFromContent(uri, string(content)), string(content)); err != nil {
+		return err
+	}
+
+	lspLogger.Debug("Opened file: %s", filepath)
+
+	return nil
+}
+
+// openTextDocument sends didOpen and records the document as open
+func (c *Client) openTextDocument(ctx context.Context, uri protocol.DocumentUri, languageID protocol.LanguageKind, text string) error {
 	params := protocol.DidOpenTextDocumentParams{
 		TextDocument: protocol.TextDocumentItem{
-			URI:        protocol.DocumentUri(uri),
-			LanguageID: DetectLanguageID(uri),
+			URI:        uri,
+			LanguageID: languageID,
 			Version:    1,
-			Text:       string(content),
+			Text:       text,
 		},
 	}
+	if virtualText, language, ok := c.embeddedText(uri, text); ok {
+		params.TextDocument.LanguageID = language
+		params.TextDocument.Text = virtualText
+	}
 
 	if err := c.Notify(ctx, "textDocument/didOpen", params); err != nil {
 		return err
 	}
 
 	c.openFilesMu.Lock()
-	c.openFiles[uri] = &OpenFileInfo{
-		Version: 1,
-		URI:     protocol.DocumentUri(uri),
+	c.openFiles[string(uri)] = &OpenFileInfo{
+		Version:    1,
+		URI:        uri,
+		LanguageID: languageID,
 	}
 	c.openFilesMu.Unlock()
-
-	lspLogger.Debug("Opened file: %s", filepath)
-
 	return nil
 }
 
 func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := string(c.documentURI(filepath))
 
-	content, err := os.ReadFile(filepath)
+	if IsNotebook(filepath) {
+		return c.notifyNotebookChange(ctx, filepath)
+	}
+
+	// Documents opened from memory don't follow the file on disk
+	if _, ok := readOverlay(filepath); ok {
+		return nil
+	}
+
+	content, err := utilities.ReadFile(filepath)
 	if err != nil {
 		return fmt.Errorf("error reading file: %w", err)
 	}
@@ -349,7 +672,7 @@ func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
 		ContentChanges: []protocol.TextDocumentContentChangeEvent{
 			{
 				Value: protocol.TextDocumentContentChangeWholeDocument{
-					Text: string(content),
+					Text: c.embeddedChange(protocol.DocumentUri(uri), string(content)),
 				},
 			},
 		},
@@ -359,7 +682,11 @@ func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
 }
 
 func (c *Client) CloseFile(ctx context.Context, filepath string) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := string(c.documentURI(filepath))
+
+	if IsNotebook(filepath) {
+		return c.closeNotebook(ctx, filepath)
+	}
 
 	c.openFilesMu.Lock()
 	if _, exists := c.openFiles[uri]; !exists {
@@ -377,6 +704,7 @@ func (c *Client) CloseFile(ctx context.Context, filepath string) error {
 	if err := c.Notify(ctx, "textDocument/didClose", params); err != nil {
 		return err
 	}
+	c.forgetEmbedded(params.TextDocument.URI)
 
 	c.openFilesMu.Lock()
 	delete(c.openFiles, uri)
@@ -385,8 +713,48 @@ func (c *Client) CloseFile(ctx context.Context, filepath string) error {
 	return nil
 }
 
+// WorkspaceDir is the workspace the server was initialized with
+func (c *Client) WorkspaceDir() string {
+	return c.workspaceDir
+}
+
+// ServerCapabilities returns the capabilities the server reported when it
+// was initialized
+func (c *Client) ServerCapabilities() protocol.ServerCapabilities {
+	return c.capabilities
+}
+
+// InitializeResult returns what the server answered initialize with, or nil
+// before it was initialized
+func (c *Client) InitializeResult() *protocol.InitializeResult {
+	return c.initializeResult
+}
+
+// ContentVersion changes whenever the server is told that workspace content
+// changed. Results computed from the whole workspace can be cached against it.
+func (c *Client) ContentVersion() int64 {
+	return c.contentVersion.Load()
+}
+
+// OpenFilePaths returns the paths of the files open in the server, leaving
+// out documents opened from memory
+func (c *Client) OpenFilePaths() []string {
+	c.openFilesMu.RLock()
+	var paths []string
+	for uri := range c.openFiles {
+		paths = append(paths, protocol.DocumentUri(uri).Path())
+	}
+	c.openFilesMu.RUnlock()
+	paths = slices.DeleteFunc(paths, func(path string) bool {
+		_, ok := readOverlay(path)
+		return ok
+	})
+	slices.Sort(paths)
+	return paths
+}
+
 func (c *Client) IsFileOpen(filepath string) bool {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := string(c.documentURI(filepath))
 	c.openFilesMu.RLock()
 	defer c.openFilesMu.RUnlock()
 	_, exists := c.openFiles[uri]
@@ -400,8 +768,7 @@ func (c *Client) CloseAllFiles(ctx context.Context) {
 
 	// First collect all URIs that need to be closed
 	for uri := range c.openFiles {
-		// Convert URI back to file path by trimming "file://" prefix
-		filePath := strings.TrimPrefix(uri, "file://")
+		filePath := protocol.DocumentUri(uri).Path()
 		filesToClose = append(filesToClose, filePath)
 	}
 	c.openFilesMu.Unlock()
@@ -418,8 +785,80 @@ func (c *Client) CloseAllFiles(ctx context.Context) {
 }
 
 func (c *Client) GetFileDiagnostics(uri protocol.DocumentUri) []protocol.Diagnostic {
+	uri = c.canonicalURI(uri)
+	notebook, isNotebook := c.notebookForURI(uri)
+
 	c.diagnosticsMu.RLock()
 	defer c.diagnosticsMu.RUnlock()
 
-	return c.diagnostics[uri]
+	if isNotebook && IsNotebook(string(uri)) {
+		return c.notebookDiagnostics(notebook)
+	}
+
+	return c.mergeDelegateDiagnostics(uri, c.diagnostics[uri])
+}
+
+// DiagnosticsVersion counts the diagnostics published for a document so far.
+// Pass it to WaitForDiagnostics to wait for a newer set.
+func (c *Client) DiagnosticsVersion(uri protocol.DocumentUri) uint64 {
+	uri = c.canonicalURI(uri)
+	c.diagnosticsMu.RLock()
+	defer c.diagnosticsMu.RUnlock()
+	return c.diagnosticsVersions[uri]
+}
+
+// WaitForDiagnostics waits until the server has published diagnostics newer
+// than the given versions for every document. It returns false if the
+// context is done first.
+func (c *Client) WaitForDiagnostics(ctx context.Context, versions map[protocol.DocumentUri]uint64) bool {
+	canonical := make(map[protocol.DocumentUri]uint64, len(versions))
+	for uri, version := range versions {
+		uri = c.canonicalURI(uri)
+		canonical[uri] = max(canonical[uri], version)
+	}
+	versions = canonical
+	for {
+		c.diagnosticsMu.RLock()
+		pending := false
+		for uri, version := range versions {
+			if c.diagnosticsVersions[uri] <= version {
+				pending = true
+				break
+			}
+		}
+		notify := c.diagnosticsNotify
+		c.diagnosticsMu.RUnlock()
+		if !pending {
+			return true
+		}
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// GetWorkspaceDiagnostics returns the cached diagnostics of every file that
+// has any. Diagnostics of notebook cells are gathered under their notebook.
+func (c *Client) GetWorkspaceDiagnostics() map[protocol.DocumentUri][]protocol.Diagnostic {
+	uris := c.diagnosticURIs()
+	for _, server := range c.Delegates() {
+		uris = append(uris, server.diagnosticURIs()...)
+	}
+
+	result := make(map[protocol.DocumentUri][]protocol.Diagnostic)
+	for _, uri := range uris {
+		if notebook, ok := c.notebookForURI(uri); ok {
+			uri = protocol.URIFromPath(notebook.Path)
+		}
+		if _, done := result[uri]; done {
+			continue
+		}
+		if diagnostics := c.GetFileDiagnostics(uri); len(diagnostics) > 0 {
+			result[uri] = diagnostics
+		}
+	}
+	return result
 }
@@ -0,0 +1,159 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// socketStartTimeout bounds how long a started server may take to listen
+const socketStartTimeout = 10 * time.Second
+
+// ParseAddress splits a server address into a network and address for
+// net.Dial. It accepts tcp://host:port, unix:///path and bare host:port.
+func ParseAddress(address string) (string, string, error) {
+	if !strings.Contains(address, "://") {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return "", "", fmt.Errorf("invalid address %q, expected tcp://host:port or unix:///path", address)
+		}
+		return "tcp", address, nil
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	switch u.Scheme {
+	case "tcp":
+		if _, _, err := net.SplitHostPort(u.Host); err != nil {
+			return "", "", fmt.Errorf("invalid address %q, expected tcp://host:port", address)
+		}
+		return "tcp", u.Host, nil
+	case "unix":
+		path := u.Path
+		if u.Host != "" {
+			// unix://relative/path
+			path = u.Host + u.Path
+		}
+		if path == "" {
+			return "", "", fmt.Errorf("invalid address %q, expected unix:///path", address)
+		}
+		return "unix", path, nil
+	}
+	return "", "", fmt.Errorf("unsupported address scheme %q, expected tcp or unix", u.Scheme)
+}
+
+// Connect attaches to a language server that is already listening at an
+// address, such as a daemon shared by several editors. Closing the client
+// disconnects without stopping the server.
+func Connect(ctx context.Context, address string, mappings []PathMapping) (*Client, error) {
+	network, addr, err := ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	client, err := ConnectFunc(ctx, func(ctx context.Context) (io.ReadWriteCloser, error) {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to LSP server: %w", err)
+		}
+		return conn, nil
+	}, mappings)
+	if err != nil {
+		return nil, err
+	}
+	lspLogger.Info("Connected to LSP server at %s", address)
+	return client, nil
+}
+
+// ConnectFunc talks to a server over the connection dial opens. Restart
+// calls dial again for a new connection.
+func ConnectFunc(ctx context.Context, dial func(ctx context.Context) (io.ReadWriteCloser, error), mappings []PathMapping) (*Client, error) {
+	reconnect := func(ctx context.Context) (*connection, error) {
+		conn, err := dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &connection{stdin: conn, stdout: conn}, nil
+	}
+	conn, err := reconnect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client := newClient(conn.stdin, conn.stdout, mappings)
+	client.reconnect = reconnect
+	client.attach(conn)
+	return client, nil
+}
+
+// ConnectStream talks to a server over a connection that is already open,
+// such as one end of a net.Pipe to a server in the same process. Closing the
+// client closes the connection.
+func ConnectStream(conn io.ReadWriteCloser, mappings []PathMapping) *Client {
+	client := newClient(conn, conn, mappings)
+	client.attach(&connection{stdin: conn, stdout: conn})
+	return client
+}
+
+// startSocketServer starts a server that listens at opts.Connect and
+// connects to it once it accepts connections
+func startSocketServer(opts ProcessOptions, cmd *exec.Cmd) (*connection, error) {
+	network, addr, err := ParseAddress(opts.Connect)
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start LSP server: %w", err)
+	}
+	go logOutput(stdout, "stdout")
+	go logOutput(stderr, "stderr")
+
+	// The process is waited for here to notice if it exits before we can
+	// connect, so Close waits on the channel instead of the command
+	exited := make(chan struct{})
+	var waitErr error
+	go func() {
+		waitErr = cmd.Wait()
+		close(exited)
+	}()
+
+	deadline := time.Now().Add(socketStartTimeout)
+	for {
+		conn, err := net.DialTimeout(network, addr, time.Second)
+		if err == nil {
+			lspLogger.Info("Connected to LSP server at %s", opts.Connect)
+			return &connection{
+				stdin:  conn,
+				stdout: conn,
+				cmd:    cmd,
+				wait: func() error {
+					<-exited
+					return waitErr
+				},
+			}, nil
+		}
+		select {
+		case <-exited:
+			return nil, fmt.Errorf("LSP server exited before listening on %s", opts.Connect)
+		case <-time.After(100 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			if cmd.Process != nil {
+				_ = cmd.Process.Kill()
+			}
+			return nil, fmt.Errorf("LSP server did not listen on %s: %w", opts.Connect, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		network string
+		addr    string
+	}{
+		{"tcp://localhost:9257", "tcp", "localhost:9257"},
+		{"127.0.0.1:9257", "tcp", "127.0.0.1:9257"},
+		{"unix:///tmp/clangd.sock", "unix", "/tmp/clangd.sock"},
+		{"unix://run/lsp.sock", "unix", "run/lsp.sock"},
+	}
+	for _, tt := range tests {
+		network, addr, err := ParseAddress(tt.address)
+		require.NoError(t, err, tt.address)
+		assert.Equal(t, tt.network, network, tt.address)
+		assert.Equal(t, tt.addr, addr, tt.address)
+	}
+
+	for _, address := range []string{"localhost", "tcp://localhost", "http://localhost:80", "unix://"} {
+		_, _, err := ParseAddress(address)
+		assert.Error(t, err, address)
+	}
+}
+
+func TestConnect(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "lsp.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// A server that answers one request with the URI it was sent, once as
+	// a URI and once as a plain path, which is not translated
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		msg, err := ReadMessage(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+		var params struct {
+			URI string `json:"uri"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		result, _ := json.Marshal(map[string]string{"uri": params.URI, "path": strings.TrimPrefix(params.URI, "file://")})
+		_ = WriteMessage(conn, &Message{JSONRPC: "2.0", ID: msg.ID, Result: result})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := Connect(ctx, "unix://"+socket, []PathMapping{{Local: "/home/me/project", Remote: "/work"}})
+	require.NoError(t, err)
+	defer client.Close()
+
+	var result struct {
+		URI  string `json:"uri"`
+		Path string `json:"path"`
+	}
+	err = client.Call(ctx, "test/echo", map[string]string{"uri": "file:///home/me/project/main.go"}, &result)
+	require.NoError(t, err)
+	// Translated to /work on the way out and back on the way in
+	assert.Equal(t, "/work/main.go", result.Path)
+	assert.Equal(t, "file:///home/me/project/main.go", result.URI)
+}
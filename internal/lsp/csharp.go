@@ -0,0 +1,169 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// solutionExtensions are the files the Roslyn language server loads a
+// solution from
+var solutionExtensions = []string{".sln", ".slnx", ".slnf"}
+
+// projectLoadTimeout bounds how long WaitForServerReady waits for the
+// Roslyn language server to load the solution. Large solutions can take
+// minutes; tools work with what is loaded after that.
+const projectLoadTimeout = 3 * time.Minute
+
+// projectSearchDepth is how deep projects are searched for in a workspace
+// without a solution
+const projectSearchDepth = 4
+
+// isRoslynLanguageServer reports whether the command runs the Roslyn
+// language server, which is Microsoft.CodeAnalysis.LanguageServer or one of
+// the wrappers named after it
+func isRoslynLanguageServer(command string) bool {
+	return strings.Contains(command, "microsoft.codeanalysis.languageserver") ||
+		strings.Contains(command, "roslyn")
+}
+
+// initializeRoslynLanguageServer opens the workspace's solution, or its
+// projects when there is none. Unlike OmniSharp, the Roslyn language server
+// loads nothing until told to, and it reports when it is done with
+// workspace/projectInitializationComplete, which WaitForServerReady waits
+// for.
+func initializeRoslynLanguageServer(ctx context.Context, client *Client, workspaceDir string) error {
+	loaded := make(chan struct{})
+	client.setReadySignal(loaded)
+	client.RegisterNotificationHandler("workspace/projectInitializationComplete", func(json.RawMessage) {
+		lspLogger.Info("Roslyn language server finished loading projects")
+		client.signalReady(loaded)
+	})
+	// Restoring packages is left to the user, who may not want dotnet
+	// restore to run, or to reach the network
+	client.RegisterServerRequestHandler("workspace/_roslyn_projectNeedsRestore", func(params json.RawMessage) (any, error) {
+		lspLogger.Warn("Roslyn language server reports projects that need dotnet restore: %s", string(params))
+		return nil, nil
+	})
+
+	solution, projects, err := roslynWorkspace(workspaceDir, client.settingsSection("dotnet.defaultSolution"))
+	if err != nil {
+		return err
+	}
+	switch {
+	case solution != "":
+		lspLogger.Info("Opening solution %s", solution)
+		return client.Notify(ctx, "solution/open", map[string]any{
+			"solution": protocol.URIFromPath(solution),
+		})
+	case len(projects) > 0:
+		lspLogger.Info("Opening %d projects", len(projects))
+		uris := make([]protocol.DocumentUri, len(projects))
+		for i, project := range projects {
+			uris[i] = protocol.URIFromPath(project)
+		}
+		return client.Notify(ctx, "project/open", map[string]any{"projects": uris})
+	default:
+		// Nothing will be loaded, so nothing will be reported
+		lspLogger.Warn("No solution or project found in %s", workspaceDir)
+		client.signalReady(loaded)
+		return nil
+	}
+}
+
+// roslynWorkspace chooses what the Roslyn language server loads: the
+// solution set with dotnet.defaultSolution, relative to the workspace,
+// else the solution in the workspace root, else the C# projects in the
+// workspace. Like the VS Code extension, "disable" loads nothing. Of
+// several solutions, the one named after the workspace is preferred.
+func roslynWorkspace(workspaceDir string, defaultSolution any) (string, []string, error) {
+	if name, ok := defaultSolution.(string); ok && name != "" {
+		if name == "disable" {
+			return "", nil, nil
+		}
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(workspaceDir, name)
+		}
+		if _, err := os.Stat(name); err != nil {
+			return "", nil, fmt.Errorf("dotnet.defaultSolution: %w", err)
+		}
+		return name, nil, nil
+	}
+
+	entries, err := os.ReadDir(workspaceDir)
+	if err != nil {
+		return "", nil, err
+	}
+	var solutions []string
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		for _, solutionExt := range solutionExtensions {
+			if !entry.IsDir() && ext == solutionExt {
+				solutions = append(solutions, entry.Name())
+			}
+		}
+	}
+	if len(solutions) > 0 {
+		sort.Strings(solutions)
+		chosen := solutions[0]
+		base := filepath.Base(workspaceDir)
+		for _, solution := range solutions {
+			if strings.TrimSuffix(solution, filepath.Ext(solution)) == base {
+				chosen = solution
+				break
+			}
+		}
+		if len(solutions) > 1 {
+			lspLogger.Info("Found solutions %v, using %s; set dotnet.defaultSolution to choose another", solutions, chosen)
+		}
+		return filepath.Join(workspaceDir, chosen), nil, nil
+	}
+
+	var projects []string
+	_ = filepath.WalkDir(workspaceDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			depth := strings.Count(strings.TrimPrefix(path, workspaceDir), string(filepath.Separator))
+			if path != workspaceDir && (strings.HasPrefix(name, ".") || name == "bin" || name == "obj" ||
+				name == "node_modules" || depth > projectSearchDepth) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.EqualFold(filepath.Ext(path), ".csproj") {
+			projects = append(projects, path)
+		}
+		return nil
+	})
+	return "", projects, nil
+}
+
+// setReadySignal makes WaitForServerReady wait until ready is closed, for
+// servers that report when they have loaded the workspace
+func (c *Client) setReadySignal(ready chan struct{}) {
+	c.readyMu.Lock()
+	defer c.readyMu.Unlock()
+	c.ready = ready
+}
+
+// signalReady closes ready once, however many times the server reports it
+func (c *Client) signalReady(ready chan struct{}) {
+	c.readyMu.Lock()
+	defer c.readyMu.Unlock()
+	select {
+	case <-ready:
+	default:
+		close(ready)
+	}
+}
@@ -0,0 +1,99 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, nil, 0644))
+	}
+}
+
+func TestRoslynWorkspace(t *testing.T) {
+	t.Run("Solution", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "Shop")
+		writeFiles(t, dir, "Build.sln", "Shop.slnx", "src/Shop/Shop.csproj")
+
+		// The solution named after the workspace
+		solution, projects, err := roslynWorkspace(dir, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "Shop.slnx"), solution)
+		assert.Empty(t, projects)
+
+		solution, _, err = roslynWorkspace(dir, "Build.sln")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "Build.sln"), solution)
+
+		_, _, err = roslynWorkspace(dir, "Missing.sln")
+		assert.ErrorContains(t, err, "dotnet.defaultSolution")
+
+		solution, projects, err = roslynWorkspace(dir, "disable")
+		require.NoError(t, err)
+		assert.Empty(t, solution)
+		assert.Empty(t, projects)
+	})
+
+	t.Run("Projects", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, "src/App/App.csproj", "tests/App.Tests/App.Tests.csproj",
+			"src/App/obj/Generated.csproj", ".git/x.csproj", "README.md")
+
+		solution, projects, err := roslynWorkspace(dir, map[string]any{})
+		require.NoError(t, err)
+		assert.Empty(t, solution)
+		assert.ElementsMatch(t, []string{
+			filepath.Join(dir, "src/App/App.csproj"),
+			filepath.Join(dir, "tests/App.Tests/App.Tests.csproj"),
+		}, projects)
+	})
+}
+
+func TestIsRoslynLanguageServer(t *testing.T) {
+	assert.True(t, isRoslynLanguageServer("/opt/roslyn/microsoft.codeanalysis.languageserver --stdio"))
+	assert.True(t, isRoslynLanguageServer("roslyn-language-server --stdio"))
+	assert.False(t, isRoslynLanguageServer("omnisharp -lsp"))
+}
+
+func TestWaitForServerReady(t *testing.T) {
+	client := newClient(nil, nil, nil)
+	ready := make(chan struct{})
+	client.setReadySignal(ready)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, client.WaitForServerReady(ctx), context.DeadlineExceeded)
+
+	// The server may report it more than once
+	client.signalReady(ready)
+	client.signalReady(ready)
+	assert.NoError(t, client.WaitForServerReady(context.Background()))
+}
+
+func TestRoslynProjectNeedsRestore(t *testing.T) {
+	client := newClient(nil, nil, nil)
+	client.SetSettings(map[string]any{"dotnet": map[string]any{"defaultSolution": "disable"}})
+	require.NoError(t, initializeRoslynLanguageServer(context.Background(), client, t.TempDir()))
+
+	// Nothing is loaded, so it is ready at once
+	assert.NoError(t, client.WaitForServerReady(context.Background()))
+
+	client.serverHandlersMu.RLock()
+	handler := client.serverRequestHandlers["workspace/_roslyn_projectNeedsRestore"]
+	client.serverHandlersMu.RUnlock()
+	require.NotNil(t, handler)
+	result, err := handler(json.RawMessage(`{"projectFilePaths":["/w/App.csproj"]}`))
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
@@ -7,7 +7,69 @@ import (
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 )
 
+// Terraform isn't one of the languages the LSP specification lists, these
+// are the IDs terraform-ls expects
+const (
+	LangTerraform     protocol.LanguageKind = "terraform"
+	LangTerraformVars protocol.LanguageKind = "terraform-vars"
+)
+
+// The LSP specification doesn't list these languages either, these are the
+// IDs their servers expect
+const (
+	LangKotlin         protocol.LanguageKind = "kotlin"
+	LangOCaml          protocol.LanguageKind = "ocaml"
+	LangOCamlInterface protocol.LanguageKind = "ocaml.interface"
+	LangZig            protocol.LanguageKind = "zig"
+)
+
+// languageFileNames are the languages of files recognized by their whole
+// name, as they have no extension or one that says nothing
+var languageFileNames = map[string]protocol.LanguageKind{
+	"dockerfile":    protocol.LangDockerfile,
+	"containerfile": protocol.LangDockerfile,
+	".bashrc":       protocol.LangShellScript,
+	".bash_profile": protocol.LangShellScript,
+	".bash_aliases": protocol.LangShellScript,
+	".bash_logout":  protocol.LangShellScript,
+	".profile":      protocol.LangShellScript,
+	".zshrc":        protocol.LangShellScript,
+	".zprofile":     protocol.LangShellScript,
+}
+
+// shebangInterpreters are the languages of scripts by the interpreter their
+// #! line names, without its version
+var shebangInterpreters = map[string]protocol.LanguageKind{
+	"sh":     protocol.LangShellScript,
+	"bash":   protocol.LangShellScript,
+	"dash":   protocol.LangShellScript,
+	"ksh":    protocol.LangShellScript,
+	"zsh":    protocol.LangShellScript,
+	"python": protocol.LangPython,
+	"node":   protocol.LangJavaScript,
+}
+
+// DetectLanguageID returns the language of a file from the language
+// overrides, else from its name
 func DetectLanguageID(uri string) protocol.LanguageKind {
+	if language := overriddenLanguage(uri); language != "" {
+		return language
+	}
+	return detectLanguageByName(uri)
+}
+
+// detectLanguageByName returns the language of a file from its name or
+// extension
+func detectLanguageByName(uri string) protocol.LanguageKind {
+	name := strings.ToLower(filepath.Base(uri))
+	if language, ok := languageFileNames[name]; ok {
+		return language
+	}
+	// Variants such as Dockerfile.dev
+	if strings.HasPrefix(name, "dockerfile.") || strings.HasPrefix(name, "containerfile.") {
+		return protocol.LangDockerfile
+	}
+
 	ext := strings.ToLower(filepath.Ext(uri))
 	switch ext {
 	case ".abap":
@@ -62,6 +124,8 @@ func DetectLanguageID(uri string) protocol.LanguageKind {
 		return protocol.LangIni
 	case ".java":
 		return protocol.LangJava
+	case ".kt", ".kts":
+		return LangKotlin
 	case ".js":
 		return protocol.LangJavaScript
 	case ".jsx":
@@ -80,6 +144,10 @@ func DetectLanguageID(uri string) protocol.LanguageKind {
 		return protocol.LangMarkdown
 	case ".m":
 		return protocol.LangObjectiveC
+	case ".ml":
+		return LangOCaml
+	case ".mli":
+		return LangOCamlInterface
 	case ".mm":
 		return protocol.LangObjectiveCPP
 	case ".pl":
@@ -116,6 +184,10 @@ func DetectLanguageID(uri string) protocol.LanguageKind {
 		return protocol.LangSQL
 	case ".swift":
 		return protocol.LangSwift
+	case ".tf":
+		return LangTerraform
+	case ".tfvars":
+		return LangTerraformVars
 	case ".ts":
 		return protocol.LangTypeScript
 	case ".tsx":
@@ -126,7 +198,66 @@ func DetectLanguageID(uri string) protocol.LanguageKind {
 		return protocol.LangXSL
 	case ".yaml", ".yml":
 		return protocol.LangYAML
+	case ".zig", ".zon":
+		return LangZig
 	default:
 		return protocol.LanguageKind("") // Unknown language
 	}
 }
+
+// DetectLanguageIDFromContent works like DetectLanguageID, and also reads
+// the file: a Vim or Emacs mode line overrides the file name, and scripts
+// without an extension are recognized by their #! line, such as
+// "#!/usr/bin/env bash". The language overrides come first.
+func DetectLanguageIDFromContent(uri, content string) protocol.LanguageKind {
+	if language := overriddenLanguage(uri); language != "" {
+		return language
+	}
+	if language := modeLineLanguage(content); language != "" {
+		return language
+	}
+	if language := detectLanguageByName(uri); language != "" {
+		return language
+	}
+	line, ok := strings.CutPrefix(content, "#!")
+	if !ok {
+		return ""
+	}
+	line, _, _ = strings.Cut(line, "\n")
+	fields := strings.Fields(line)
+	if len(fields) > 0 && filepath.Base(fields[0]) == "env" {
+		// Skip env's options, as in "env -S bash -e"
+		fields = fields[1:]
+		for len(fields) > 0 && strings.HasPrefix(fields[0], "-") {
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := strings.TrimRight(filepath.Base(fields[0]), "0123456789.")
+	return shebangInterpreters[interpreter]
+}
+
+// languageExtensions holds one file extension for each language that
+// DetectLanguageID recognizes
+var languageExtensions = []string{
+	".abap", ".bat", ".bib", ".clj", ".coffee", ".c", ".cpp", ".cs", ".css", ".d",
+	".pas", ".diff", ".dart", ".dockerfile", ".ex", ".erl", ".fs", ".gitcommit",
+	".gitrebase", ".go", ".groovy", ".hbs", ".hs", ".html", ".ini", ".java", ".js",
+	".jsx", ".kt", ".json", ".tex", ".less", ".lua", ".makefile", ".md", ".m", ".ml", ".mli", ".mm", ".pl",
+	".php", ".ps1", ".pug", ".py", ".r", ".cshtml", ".rb", ".rs", ".scss", ".sass",
+	".scala", ".shader", ".sh", ".sql", ".swift", ".tf", ".tfvars", ".ts", ".tsx", ".xml", ".xsl", ".yaml",
+	".zig",
+}
+
+// LanguageExtension returns a file extension for a language ID, or an empty
+// string if the language is unknown
+func LanguageExtension(languageID protocol.LanguageKind) string {
+	for _, ext := range languageExtensions {
+		if detectLanguageByName(ext) == languageID {
+			return ext
+		}
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publishDiagnostics(t *testing.T, client *Client, uri protocol.DocumentUri) {
+	params, err := json.Marshal(protocol.PublishDiagnosticsParams{URI: uri, Diagnostics: []protocol.Diagnostic{}})
+	require.NoError(t, err)
+	HandleDiagnostics(client, params)
+}
+
+func TestWaitForDiagnostics(t *testing.T) {
+	client := newClient(nil, nil, nil)
+	a := protocol.DocumentUri("file:///work/a.go")
+	b := protocol.DocumentUri("file:///work/b.go")
+
+	publishDiagnostics(t, client, a)
+	versions := map[protocol.DocumentUri]uint64{
+		a: client.DiagnosticsVersion(a),
+		b: client.DiagnosticsVersion(b),
+	}
+	assert.Equal(t, uint64(1), versions[a])
+
+	done := make(chan bool)
+	go func() {
+		done <- client.WaitForDiagnostics(context.Background(), versions)
+	}()
+
+	// Diagnostics for only one of the documents don't end the wait
+	publishDiagnostics(t, client, a)
+	select {
+	case <-done:
+		t.Fatal("wait ended before every document had new diagnostics")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	publishDiagnostics(t, client, b)
+	select {
+	case ok := <-done:
+		assert.True(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("wait didn't end after diagnostics were published")
+	}
+
+	// A done context ends the wait
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.False(t, client.WaitForDiagnostics(ctx, map[protocol.DocumentUri]uint64{a: client.DiagnosticsVersion(a)}))
+}
@@ -0,0 +1,179 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// overlays holds the content of documents opened from memory, keyed by path.
+// It takes precedence over the file on disk in ReadSourceFile so that tools
+// see the same text as the language server.
+var (
+	overlays   = make(map[string]string)
+	overlaysMu sync.RWMutex
+)
+
+// readOverlay returns the in-memory content of a document, if any
+func readOverlay(path string) (string, bool) {
+	overlaysMu.RLock()
+	defer overlaysMu.RUnlock()
+	content, ok := overlays[path]
+	return content, ok
+}
+
+// OpenDocument opens a document with the given content and language, whether
+// or not a file exists at path. A document that is already open is replaced.
+// Until CloseDocument is called, file changes on disk are not sent for it.
+func (c *Client) OpenDocument(ctx context.Context, path string, languageID protocol.LanguageKind, text string) error {
+	if IsNotebook(path) {
+		return fmt.Errorf("notebooks can't be opened from memory")
+	}
+	if languageID == "" {
+		languageID = DetectLanguageIDFromContent(path, text)
+	}
+	uri := c.documentURI(path)
+
+	// Tools read the document by its path, and restarts reopen it by the
+	// path it is open under
+	overlaysMu.Lock()
+	overlays[path] = text
+	overlays[uri.Path()] = text
+	overlaysMu.Unlock()
+
+	c.openFilesMu.Lock()
+	info, isOpen := c.openFiles[string(uri)]
+	sameLanguage := isOpen && info.LanguageID == languageID
+	var version int32
+	if sameLanguage {
+		info.Version++
+		version = info.Version
+	}
+	c.openFilesMu.Unlock()
+
+	if sameLanguage {
+		return c.Notify(ctx, "textDocument/didChange", protocol.DidChangeTextDocumentParams{
+			TextDocument: protocol.VersionedTextDocumentIdentifier{
+				TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: uri},
+				Version:                version,
+			},
+			ContentChanges: []protocol.TextDocumentContentChangeEvent{
+				{Value: protocol.TextDocumentContentChangeWholeDocument{Text: c.embeddedChange(uri, text)}},
+			},
+		})
+	}
+
+	// The language of an open document can't change, so reopen it
+	if isOpen {
+		if err := c.CloseFile(ctx, path); err != nil {
+			return err
+		}
+	}
+	return c.openTextDocument(ctx, uri, languageID, text)
+}
+
+// CloseDocument closes a document opened with OpenDocument. The next
+// OpenFile reads it from disk again. A document an editor has open through a
+// Proxy goes back to the editor's text instead.
+func (c *Client) CloseDocument(ctx context.Context, path string) error {
+	c.editorHoldsMu.Lock()
+	hold, held := c.editorHolds[c.documentURI(path)]
+	var languageID protocol.LanguageKind
+	var text string
+	if held {
+		languageID, text = hold.languageID, hold.text
+	}
+	c.editorHoldsMu.Unlock()
+	if held {
+		return c.OpenDocument(ctx, path, languageID, text)
+	}
+	return c.closeDocument(ctx, path)
+}
+
+func (c *Client) closeDocument(ctx context.Context, path string) error {
+	c.dropOverlay(path)
+	return c.CloseFile(ctx, path)
+}
+
+func (c *Client) dropOverlay(path string) {
+	overlaysMu.Lock()
+	delete(overlays, path)
+	delete(overlays, c.documentURI(path).Path())
+	overlaysMu.Unlock()
+}
+
+// editorHold is a document that editors connected through a Proxy have open
+type editorHold struct {
+	// editors counts the editors that have it open
+	editors    int
+	languageID protocol.LanguageKind
+	text       string
+	// tools is set when the tools had the document open as well, so that
+	// it stays open when the editors close it
+	tools bool
+}
+
+// openEditorDocument opens a document for an editor, with the editor's text
+func (c *Client) openEditorDocument(ctx context.Context, path string, languageID protocol.LanguageKind, text string) error {
+	uri := c.documentURI(path)
+	c.editorHoldsMu.Lock()
+	hold, held := c.editorHolds[uri]
+	if !held {
+		hold = &editorHold{tools: c.IsFileOpen(path)}
+		c.editorHolds[uri] = hold
+	}
+	hold.editors++
+	hold.languageID, hold.text = languageID, text
+	c.editorHoldsMu.Unlock()
+	return c.OpenDocument(ctx, path, languageID, text)
+}
+
+// changeEditorDocument sends the text an editor changed a document to
+func (c *Client) changeEditorDocument(ctx context.Context, path string, languageID protocol.LanguageKind, text string) error {
+	c.editorHoldsMu.Lock()
+	if hold, held := c.editorHolds[c.documentURI(path)]; held {
+		hold.languageID, hold.text = languageID, text
+	}
+	c.editorHoldsMu.Unlock()
+	return c.OpenDocument(ctx, path, languageID, text)
+}
+
+// closeEditorDocument closes a document for an editor. It stays open while
+// other editors have it open. A document the tools opened too stays open
+// with the content of the file on disk.
+func (c *Client) closeEditorDocument(ctx context.Context, path string) error {
+	uri := c.documentURI(path)
+	c.editorHoldsMu.Lock()
+	hold, held := c.editorHolds[uri]
+	if held {
+		hold.editors--
+		if hold.editors > 0 {
+			c.editorHoldsMu.Unlock()
+			return nil
+		}
+		delete(c.editorHolds, uri)
+	}
+	c.editorHoldsMu.Unlock()
+
+	if !held || !hold.tools {
+		return c.closeDocument(ctx, path)
+	}
+	c.dropOverlay(path)
+	if _, err := os.Stat(path); err != nil {
+		// The editor's buffer was never saved
+		return c.CloseFile(ctx, path)
+	}
+	return c.NotifyChange(ctx, path)
+}
+
+// holdForTools notes that the tools opened a document editors have open
+func (c *Client) holdForTools(uri protocol.DocumentUri) {
+	c.editorHoldsMu.Lock()
+	defer c.editorHoldsMu.Unlock()
+	if hold, held := c.editorHolds[uri]; held {
+		hold.tools = true
+	}
+}
@@ -0,0 +1,117 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenDocument(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	client := newClient(clientConn, clientConn, nil)
+	defer clientConn.Close()
+
+	// Record what the server is told
+	messages := make(chan *Message, 16)
+	go func() {
+		reader := bufio.NewReader(serverConn)
+		for {
+			msg, err := ReadMessage(reader)
+			if err != nil {
+				close(messages)
+				return
+			}
+			messages <- msg
+		}
+	}()
+	next := func() (string, map[string]any) {
+		msg := <-messages
+		require.NotNil(t, msg)
+		var params map[string]any
+		require.NoError(t, json.Unmarshal(msg.Params, &params))
+		return msg.Method, params
+	}
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "untitled-1.go")
+
+	require.NoError(t, client.OpenDocument(ctx, path, protocol.LangGo, "package main\n"))
+	method, params := next()
+	assert.Equal(t, "textDocument/didOpen", method)
+	assert.Equal(t, "go", params["textDocument"].(map[string]any)["languageId"])
+	content, err := ReadSourceFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(content))
+
+	// Same language, new content
+	require.NoError(t, client.OpenDocument(ctx, path, protocol.LangGo, "package other\n"))
+	method, params = next()
+	assert.Equal(t, "textDocument/didChange", method)
+	assert.EqualValues(t, 2, params["textDocument"].(map[string]any)["version"])
+	content, err = ReadSourceFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "package other\n", string(content))
+
+	// Disk changes are ignored while the document comes from memory
+	require.NoError(t, client.NotifyChange(ctx, path))
+
+	// A different language reopens the document
+	require.NoError(t, client.OpenDocument(ctx, path, "gotmpl", "{{ . }}\n"))
+	method, _ = next()
+	assert.Equal(t, "textDocument/didClose", method)
+	method, params = next()
+	assert.Equal(t, "textDocument/didOpen", method)
+	assert.Equal(t, "gotmpl", params["textDocument"].(map[string]any)["languageId"])
+
+	require.NoError(t, client.CloseDocument(ctx, path))
+	method, _ = next()
+	assert.Equal(t, "textDocument/didClose", method)
+	assert.False(t, client.IsFileOpen(path))
+	_, err = ReadSourceFile(path)
+	assert.Error(t, err, "the document was never written to disk")
+}
+
+func TestDetectLanguageID(t *testing.T) {
+	tests := []struct {
+		path     string
+		content  string
+		expected protocol.LanguageKind
+	}{
+		{"/ws/main.go", "", protocol.LangGo},
+		{"/ws/build.gradle.kts", "", LangKotlin},
+		{"/ws/build.zig", "", LangZig},
+		{"/ws/src/cart.mli", "", LangOCamlInterface},
+		{"/ws/Dockerfile", "FROM alpine\n", protocol.LangDockerfile},
+		{"/ws/docker/Dockerfile.dev", "", protocol.LangDockerfile},
+		{"/ws/Containerfile", "", protocol.LangDockerfile},
+		{"/ws/build.dockerfile", "", protocol.LangDockerfile},
+		{"/home/me/.bashrc", "", protocol.LangShellScript},
+		{"/ws/bin/deploy", "#!/bin/bash\nset -e\n", protocol.LangShellScript},
+		{"/ws/bin/release", "#!/usr/bin/env -S bash -e\n", protocol.LangShellScript},
+		{"/ws/bin/migrate", "#!/usr/bin/env python3.12\n", protocol.LangPython},
+		{"/ws/bin/serve", "#!/usr/bin/env node\n", protocol.LangJavaScript},
+		{"/ws/bin/tool", "#!/usr/bin/env perl\n", ""},
+		{"/ws/LICENSE", "MIT License\n", ""},
+		// The extension wins over the #! line
+		{"/ws/script.py", "#!/bin/sh\n", protocol.LangPython},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, DetectLanguageIDFromContent("file://"+tt.path, tt.content), tt.path)
+	}
+}
+
+func TestLanguageExtension(t *testing.T) {
+	assert.Equal(t, ".go", LanguageExtension(protocol.LangGo))
+	assert.Equal(t, ".py", LanguageExtension(protocol.LangPython))
+	assert.Equal(t, ".tfvars", LanguageExtension(LangTerraformVars))
+	assert.Equal(t, ".tsx", LanguageExtension(protocol.LangTypeScriptReact))
+	assert.Equal(t, "", LanguageExtension("no-such-language"))
+}
@@ -0,0 +1,144 @@
+package lsp
+
+import "strings"
+
+// blackholeProxy refuses connections: port 9 is the discard service, which
+// nothing listens on
+const blackholeProxy = "http://127.0.0.1:9"
+
+// EgressPolicy limits what a language server sends over the network
+type EgressPolicy struct {
+	// NoTelemetry turns off the telemetry settings the server is given and
+	// opts the tools it runs out of telemetry
+	NoTelemetry bool
+	// Offline also points the server's proxies at an address that refuses
+	// connections and tells package managers and toolchains not to download
+	// anything. It implies NoTelemetry.
+	Offline bool
+}
+
+// telemetryEnv opts tools out of telemetry
+var telemetryEnv = []string{
+	"DO_NOT_TRACK=1",
+	"DOTNET_CLI_TELEMETRY_OPTOUT=1",
+	"DOTNET_NOLOGO=1",
+	"NEXT_TELEMETRY_DISABLED=1",
+	"GATSBY_TELEMETRY_DISABLED=1",
+}
+
+// offlineEnv keeps toolchains and package managers off the network
+var offlineEnv = []string{
+	"GOPROXY=off",
+	"GOTOOLCHAIN=local",
+	"CARGO_NET_OFFLINE=true",
+	"NPM_CONFIG_OFFLINE=true",
+	"YARN_ENABLE_NETWORK=0",
+	"PIP_NO_INDEX=1",
+	"UV_OFFLINE=1",
+	"JAVA_TOOL_OPTIONS=-Dhttp.proxyHost=127.0.0.1 -Dhttp.proxyPort=9 -Dhttps.proxyHost=127.0.0.1 -Dhttps.proxyPort=9",
+}
+
+// proxyVariables are the proxy settings most HTTP clients follow, in both
+// cases since tools disagree on which they read
+var proxyVariables = []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "FTP_PROXY"}
+
+// telemetrySettings turn off the telemetry of servers that have it on by
+// default or ask the client, keyed by the server's name
+var telemetrySettings = map[string]map[string]any{
+	"yaml-language-server": {"redhat": map[string]any{"telemetry": map[string]any{"enabled": false}}},
+	"jdtls":                {"redhat": map[string]any{"telemetry": map[string]any{"enabled": false}}},
+}
+
+// offlineSettings stop servers from downloading schemas and packages,
+// keyed by the server's name
+var offlineSettings = map[string]map[string]any{
+	"yaml-language-server":       {"yaml": map[string]any{"schemaStore": map[string]any{"enable": false}}},
+	"typescript-language-server": {"typescript": map[string]any{"disableAutomaticTypeAcquisition": true}},
+	"vtsls":                      {"typescript": map[string]any{"disableAutomaticTypeAcquisition": true}},
+}
+
+// Env returns the environment variables that enforce the policy, to be
+// added to the server's environment
+func (p EgressPolicy) Env() []string {
+	var env []string
+	if p.NoTelemetry || p.Offline {
+		env = append(env, telemetryEnv...)
+	}
+	if p.Offline {
+		for _, key := range proxyVariables {
+			env = append(env, key+"="+blackholeProxy, strings.ToLower(key)+"="+blackholeProxy)
+		}
+		// Nothing bypasses the proxies
+		env = append(env, "NO_PROXY=", "no_proxy=")
+		env = append(env, offlineEnv...)
+	}
+	return env
+}
+
+// apply returns settings with the policy enforced for a server started with
+// a command line: telemetry settings turned off, and the settings of known
+// servers that reach the network overridden.
+func (p EgressPolicy) apply(commandLine string, settings map[string]any) map[string]any {
+	if !p.NoTelemetry && !p.Offline {
+		return settings
+	}
+	settings = denyTelemetry(settings)
+	for server, overrides := range telemetrySettings {
+		if strings.Contains(commandLine, server) {
+			settings = MergeSettings(settings, overrides)
+		}
+	}
+	if p.Offline {
+		for server, overrides := range offlineSettings {
+			if strings.Contains(commandLine, server) {
+				settings = MergeSettings(settings, overrides)
+			}
+		}
+	}
+	return settings
+}
+
+// denyTelemetry returns settings with every setting under a key that
+// mentions telemetry turned off: booleans become false and strings "off",
+// as in "telemetry.telemetryLevel". The settings aren't modified.
+func denyTelemetry(settings map[string]any) map[string]any {
+	denied := make(map[string]any, len(settings))
+	for key, value := range settings {
+		if strings.Contains(strings.ToLower(key), "telemetry") {
+			denied[key] = turnOff(value)
+		} else if object, ok := value.(map[string]any); ok {
+			denied[key] = denyTelemetry(object)
+		} else {
+			denied[key] = value
+		}
+	}
+	return denied
+}
+
+// turnOff turns off a setting and everything under it
+func turnOff(value any) any {
+	switch value := value.(type) {
+	case bool:
+		return false
+	case string:
+		return "off"
+	case map[string]any:
+		off := make(map[string]any, len(value))
+		for key, child := range value {
+			off[key] = turnOff(child)
+		}
+		return off
+	}
+	return value
+}
+
+// commandLine returns the lowercased command line the server was started
+// with, runner included, or "" for a server connected to
+func (c *Client) commandLine() string {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	if c.Cmd == nil {
+		return ""
+	}
+	return strings.ToLower(strings.Join(c.Cmd.Args, " "))
+}
@@ -0,0 +1,47 @@
+package lsp
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEgressPolicyEnv(t *testing.T) {
+	assert.Empty(t, EgressPolicy{}.Env())
+
+	env := EgressPolicy{NoTelemetry: true}.Env()
+	assert.Contains(t, env, "DO_NOT_TRACK=1")
+	assert.NotContains(t, env, "GOPROXY=off")
+
+	env = EgressPolicy{Offline: true}.Env()
+	assert.Contains(t, env, "DO_NOT_TRACK=1")
+	assert.Contains(t, env, "GOPROXY=off")
+	assert.Contains(t, env, "HTTPS_PROXY="+blackholeProxy)
+	assert.Contains(t, env, "https_proxy="+blackholeProxy)
+	assert.Contains(t, env, "NO_PROXY=")
+}
+
+func TestEgressPolicySettings(t *testing.T) {
+	client := newClient(nil, nil, nil)
+	client.Cmd = exec.Command("yaml-language-server", "--stdio")
+	client.SetSettings(map[string]any{
+		"telemetry": map[string]any{"telemetryLevel": "all", "enableCrashReporter": true},
+		"yaml":      map[string]any{"schemaStore": map[string]any{"enable": true}, "format": map[string]any{"enable": true}},
+		"redhat":    map[string]any{"telemetry": map[string]any{"enabled": true}},
+	})
+
+	// Without a policy the settings are passed on
+	assert.Equal(t, "all", client.settingsSection("telemetry.telemetryLevel"))
+
+	client.SetEgressPolicy(EgressPolicy{NoTelemetry: true})
+	assert.Equal(t, map[string]any{"telemetryLevel": "off", "enableCrashReporter": false}, client.settingsSection("telemetry"))
+	assert.Equal(t, false, client.settingsSection("redhat.telemetry.enabled"))
+	assert.Equal(t, true, client.settingsSection("yaml.schemaStore.enable"))
+
+	client.SetEgressPolicy(EgressPolicy{Offline: true})
+	assert.Equal(t, false, client.settingsSection("yaml.schemaStore.enable"))
+	assert.Equal(t, true, client.settingsSection("yaml.format.enable"))
+	// The user's settings aren't changed
+	assert.Equal(t, "all", client.Settings()["telemetry"].(map[string]any)["telemetryLevel"])
+}
@@ -0,0 +1,75 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// elixirLSDefaults turn off what makes ElixirLS slow to start: Dialyzer's
+// analysis and fetching dependencies, which may reach the network. Settings
+// can turn them back on.
+var elixirLSDefaults = map[string]any{
+	"elixirLS": map[string]any{
+		"dialyzerEnabled": false,
+		"fetchDeps":       false,
+	},
+}
+
+// isElixirLS reports whether the command runs ElixirLS, which is usually
+// started through its language_server.sh script
+func isElixirLS(command string) bool {
+	return strings.Contains(command, "elixir-ls") || strings.Contains(command, "elixirls") ||
+		strings.Contains(command, "language_server.sh")
+}
+
+// initializeElixirLS sends ElixirLS its settings, which it waits for before
+// compiling the project, and makes WaitForServerReady wait until the first
+// build's progress ends. Results from before the build are missing modules.
+func initializeElixirLS(ctx context.Context, client *Client, workspaceDir string) error {
+	if _, err := os.Stat(filepath.Join(workspaceDir, "mix.exs")); err == nil {
+		built := make(chan struct{})
+		client.setReadySignal(built)
+
+		var mu sync.Mutex
+		builds := map[string]bool{}
+		client.RegisterNotificationHandler("$/progress", func(params json.RawMessage) {
+			HandleProgress(client, params)
+			var progressParams struct {
+				Token json.RawMessage `json:"token"`
+				Value struct {
+					Kind  string `json:"kind"`
+					Title string `json:"title"`
+				} `json:"value"`
+			}
+			if err := json.Unmarshal(params, &progressParams); err != nil {
+				return
+			}
+			token := string(progressParams.Token)
+			mu.Lock()
+			defer mu.Unlock()
+			switch progressParams.Value.Kind {
+			case "begin":
+				title := strings.ToLower(progressParams.Value.Title)
+				if strings.Contains(title, "compil") || strings.Contains(title, "build") {
+					builds[token] = true
+				}
+			case "end":
+				if builds[token] {
+					lspLogger.Info("ElixirLS finished building the project")
+					client.signalReady(built)
+				}
+			}
+		})
+	}
+
+	client.SetServerDefaults(elixirLSDefaults)
+	return client.DidChangeConfiguration(ctx, protocol.DidChangeConfigurationParams{
+		Settings: client.settingsSection(""),
+	})
+}
@@ -0,0 +1,49 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bufferCloser records what the client sends
+type bufferCloser struct{ bytes.Buffer }
+
+func (b *bufferCloser) Close() error { return nil }
+
+func TestInitializeElixirLS(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mix.exs"), nil, 0644))
+	sent := &bufferCloser{}
+	client := newClient(sent, nil, nil)
+	client.SetSettings(map[string]any{"elixirLS": map[string]any{"fetchDeps": true}})
+	require.NoError(t, initializeElixirLS(context.Background(), client, dir))
+
+	// ElixirLS waits for its settings before building
+	assert.Contains(t, sent.String(), `"method":"workspace/didChangeConfiguration"`)
+	assert.Contains(t, sent.String(), `"dialyzerEnabled":false`)
+	assert.Contains(t, sent.String(), `"fetchDeps":true`)
+
+	client.notificationMu.RLock()
+	handleProgress := client.notificationHandlers["$/progress"]
+	client.notificationMu.RUnlock()
+	require.NotNil(t, handleProgress)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	handleProgress(json.RawMessage(`{"token":"a","value":{"kind":"begin","title":"Fetching deps"}}`))
+	handleProgress(json.RawMessage(`{"token":"b","value":{"kind":"begin","title":"Compiling"}}`))
+	handleProgress(json.RawMessage(`{"token":"a","value":{"kind":"end"}}`))
+	assert.ErrorIs(t, client.WaitForServerReady(ctx), context.DeadlineExceeded)
+	assert.Equal(t, []string{"Compiling"}, client.ActiveProgress())
+
+	handleProgress(json.RawMessage(`{"token":"b","value":{"kind":"end"}}`))
+	assert.NoError(t, client.WaitForServerReady(context.Background()))
+}
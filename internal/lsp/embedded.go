@@ -0,0 +1,267 @@
+package lsp
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Vue and Svelte components and HTML pages embed code in other languages.
+// A server for an embedded language, such as typescript-language-server for
+// <script> blocks, is given such a file as a virtual document of that
+// language: the file's path with the language's extension appended, as in
+// App.vue.ts, and its text with everything but the embedded code blanked
+// out. Positions are the same in both, so requests and responses only need
+// the document's URI swapped, which is done for every message like path
+// mapping. Tools keep addressing the file itself.
+
+// embeddedKind is the kind of code a server reads out of a host file
+type embeddedKind int
+
+const (
+	embeddedNone embeddedKind = iota
+	embeddedScript
+	embeddedStyle
+)
+
+// embeddedServers are the servers of languages that host files embed, by
+// name. Servers that understand the host files themselves, like
+// vue-language-server, aren't listed.
+var embeddedServers = map[string]embeddedKind{
+	"typescript-language-server": embeddedScript,
+	"vtsls":                      embeddedScript,
+	"vscode-css-language-server": embeddedStyle,
+}
+
+// embeddedHostExtensions are the files that embed code in other languages
+var embeddedHostExtensions = map[string]bool{
+	".vue":    true,
+	".svelte": true,
+	".html":   true,
+	".htm":    true,
+}
+
+var (
+	scriptBlockRegex = regexp.MustCompile(`(?is)<script\b([^>]*)>(.*?)</script\s*>`)
+	styleBlockRegex  = regexp.MustCompile(`(?is)<style\b([^>]*)>(.*?)</style\s*>`)
+	attributeRegex   = regexp.MustCompile(`(?i)\b(lang|type)\s*=\s*["']?([\w/+.-]+)`)
+)
+
+// embeddedServerKind returns the kind of embedded code a server reads, from
+// its command line
+func embeddedServerKind(command string) embeddedKind {
+	for _, field := range strings.Fields(command) {
+		if kind, ok := embeddedServers[ServerName(field)]; ok {
+			return kind
+		}
+	}
+	return embeddedNone
+}
+
+// IsEmbeddedHost reports whether a file embeds code in other languages
+func IsEmbeddedHost(path string) bool {
+	return embeddedHostExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// embeddedDocument returns the text of the virtual document of the code of
+// a kind embedded in a host file, the language of that code, and whether
+// there is any. Blocks in other languages, such as <script
+// type="application/json">, are left out.
+func embeddedDocument(text string, kind embeddedKind) (string, protocol.LanguageKind, bool) {
+	var blocks *regexp.Regexp
+	var language protocol.LanguageKind
+	switch kind {
+	case embeddedScript:
+		blocks, language = scriptBlockRegex, protocol.LangJavaScript
+	case embeddedStyle:
+		blocks, language = styleBlockRegex, protocol.LangCSS
+	default:
+		return "", "", false
+	}
+
+	var regions [][2]int
+	for _, m := range blocks.FindAllStringSubmatchIndex(text, -1) {
+		blockLanguage, ok := embeddedBlockLanguage(kind, text[m[2]:m[3]])
+		if !ok {
+			continue
+		}
+		// TypeScript in any block makes the whole document TypeScript
+		if len(regions) == 0 || blockLanguage == protocol.LangTypeScript {
+			language = blockLanguage
+		}
+		regions = append(regions, [2]int{m[4], m[5]})
+	}
+	if len(regions) == 0 {
+		return "", "", false
+	}
+	return blankOutside(text, regions), language, true
+}
+
+// embeddedBlockLanguage returns the language of a <script> or <style>
+// block from its attributes, and false for blocks that aren't code
+func embeddedBlockLanguage(kind embeddedKind, attributes string) (protocol.LanguageKind, bool) {
+	lang, mediaType := "", ""
+	for _, m := range attributeRegex.FindAllStringSubmatch(attributes, -1) {
+		if strings.EqualFold(m[1], "lang") {
+			lang = strings.ToLower(m[2])
+		} else {
+			mediaType = strings.ToLower(m[2])
+		}
+	}
+	if kind == embeddedStyle {
+		switch lang {
+		case "", "css":
+			return protocol.LangCSS, true
+		case "scss":
+			return protocol.LangSCSS, true
+		case "less":
+			return protocol.LangLess, true
+		}
+		return "", false
+	}
+	switch lang {
+	case "ts", "typescript":
+		return protocol.LangTypeScript, true
+	case "tsx":
+		return protocol.LangTypeScriptReact, true
+	case "jsx":
+		return protocol.LangJavaScriptReact, true
+	case "", "js", "javascript":
+	default:
+		return "", false
+	}
+	switch mediaType {
+	case "", "module", "text/javascript", "application/javascript":
+		return protocol.LangJavaScript, true
+	}
+	return "", false
+}
+
+// blankOutside replaces the text outside the regions with spaces, keeping
+// line breaks and the UTF-16 length of every line so that positions don't
+// change
+func blankOutside(text string, regions [][2]int) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	blank := func(s string) {
+		for _, r := range s {
+			switch {
+			case r == '\n' || r == '\r':
+				b.WriteRune(r)
+			case utf8.RuneLen(r) == 4:
+				// Outside the Basic Multilingual Plane, two UTF-16 units
+				b.WriteString("  ")
+			default:
+				b.WriteByte(' ')
+			}
+		}
+	}
+	previous := 0
+	for _, region := range regions {
+		blank(text[previous:region[0]])
+		b.WriteString(text[region[0]:region[1]])
+		previous = region[1]
+	}
+	blank(text[previous:])
+	return b.String()
+}
+
+// embeddedExtensions name the virtual documents of embedded code
+var embeddedExtensions = map[protocol.LanguageKind]string{
+	protocol.LangJavaScript:      ".js",
+	protocol.LangJavaScriptReact: ".jsx",
+	protocol.LangTypeScript:      ".ts",
+	protocol.LangTypeScriptReact: ".tsx",
+	protocol.LangCSS:             ".css",
+	protocol.LangSCSS:            ".scss",
+	protocol.LangLess:            ".less",
+}
+
+// embeddedText returns the text and language a file is given to the server
+// with, and false if the server reads the file as it is. Host files that
+// embed code the server reads are registered so that their URI is swapped
+// for the virtual document's in messages.
+func (c *Client) embeddedText(uri protocol.DocumentUri, text string) (string, protocol.LanguageKind, bool) {
+	if c.embeddedKind == embeddedNone || !IsEmbeddedHost(uri.Path()) {
+		return "", "", false
+	}
+	virtualText, language, ok := embeddedDocument(text, c.embeddedKind)
+	if !ok {
+		// The document stays blank until code is added
+		virtualText, language = blankOutside(text, nil), protocol.LangJavaScript
+		if c.embeddedKind == embeddedStyle {
+			language = protocol.LangCSS
+		}
+	}
+
+	c.embeddedMu.Lock()
+	defer c.embeddedMu.Unlock()
+	virtual := uri + protocol.DocumentUri(embeddedExtensions[language])
+	c.embeddedDocuments[uri] = virtual
+	c.embeddedHosts[virtual] = uri
+	return virtualText, language, true
+}
+
+// embeddedChange returns the text an open file's changed text is given to
+// the server with
+func (c *Client) embeddedChange(uri protocol.DocumentUri, text string) string {
+	c.embeddedMu.RLock()
+	_, ok := c.embeddedDocuments[uri]
+	c.embeddedMu.RUnlock()
+	if !ok {
+		return text
+	}
+	if virtualText, _, ok := embeddedDocument(text, c.embeddedKind); ok {
+		return virtualText
+	}
+	return blankOutside(text, nil)
+}
+
+// forgetEmbedded stops swapping a closed host file's URI
+func (c *Client) forgetEmbedded(uri protocol.DocumentUri) {
+	c.embeddedMu.Lock()
+	defer c.embeddedMu.Unlock()
+	if virtual, ok := c.embeddedDocuments[uri]; ok {
+		delete(c.embeddedHosts, virtual)
+		delete(c.embeddedDocuments, uri)
+	}
+}
+
+// embeddedOutgoing swaps host files' URIs for their virtual documents' in a
+// message to the server
+func (c *Client) embeddedOutgoing(msg *Message) {
+	c.embeddedMu.RLock()
+	defer c.embeddedMu.RUnlock()
+	if len(c.embeddedDocuments) == 0 {
+		return
+	}
+	translate := func(s string) string {
+		if virtual, ok := c.embeddedDocuments[protocol.DocumentUri(s)]; ok {
+			return string(virtual)
+		}
+		return s
+	}
+	msg.Params = rewriteURIs(msg.Params, translate)
+	msg.Result = rewriteURIs(msg.Result, translate)
+}
+
+// embeddedIncoming swaps virtual documents' URIs for their host files' in a
+// message from the server
+func (c *Client) embeddedIncoming(msg *Message) {
+	c.embeddedMu.RLock()
+	defer c.embeddedMu.RUnlock()
+	if len(c.embeddedHosts) == 0 {
+		return
+	}
+	translate := func(s string) string {
+		if host, ok := c.embeddedHosts[protocol.DocumentUri(s)]; ok {
+			return string(host)
+		}
+		return s
+	}
+	msg.Params = rewriteURIs(msg.Params, translate)
+	msg.Result = rewriteURIs(msg.Result, translate)
+}
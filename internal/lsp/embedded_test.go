@@ -0,0 +1,82 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const component = `<template>
+  <p>{{ greeting }} 👋</p>
+</template>
+<script type="application/json">{"skip": true}</script>
+<script setup lang="ts">
+const greeting: string = "hi"
+</script>
+<style>
+p { color: red; }
+</style>
+`
+
+func TestEmbeddedDocument(t *testing.T) {
+	text, language, ok := embeddedDocument(component, embeddedScript)
+	require.True(t, ok)
+	assert.Equal(t, protocol.LangTypeScript, language)
+	assert.Len(t, []rune(text), len([]rune(component))+1, "the emoji takes two UTF-16 units")
+	assert.Equal(t, "", strings.TrimSpace(lineOf(text, 1)))
+	assert.Equal(t, "", strings.TrimSpace(lineOf(text, 3)), "JSON isn't script")
+	assert.Equal(t, `const greeting: string = "hi"`, lineOf(text, 5))
+	assert.Equal(t, "", strings.TrimSpace(lineOf(text, 8)))
+
+	text, language, ok = embeddedDocument(component, embeddedStyle)
+	require.True(t, ok)
+	assert.Equal(t, protocol.LangCSS, language)
+	assert.Equal(t, "p { color: red; }", lineOf(text, 8))
+	assert.Equal(t, "", strings.TrimSpace(lineOf(text, 5)))
+
+	_, _, ok = embeddedDocument("<p>no script</p>", embeddedScript)
+	assert.False(t, ok)
+}
+
+func TestEmbeddedServerKind(t *testing.T) {
+	assert.Equal(t, embeddedScript, embeddedServerKind("npx typescript-language-server --stdio"))
+	assert.Equal(t, embeddedStyle, embeddedServerKind("/usr/bin/vscode-css-language-server --stdio"))
+	assert.Equal(t, embeddedNone, embeddedServerKind("vue-language-server --stdio"))
+}
+
+func TestEmbeddedURIs(t *testing.T) {
+	sent := &bufferCloser{}
+	client := newClient(sent, nil, nil)
+	client.embeddedKind = embeddedScript
+	uri := protocol.URIFromPath("/w/App.vue")
+	require.NoError(t, client.openTextDocument(context.Background(), uri, "vue", component))
+
+	// The server is given the script as a TypeScript document
+	msg, err := ReadMessage(bufio.NewReader(&sent.Buffer))
+	require.NoError(t, err)
+	var params protocol.DidOpenTextDocumentParams
+	require.NoError(t, json.Unmarshal(msg.Params, &params))
+	assert.Equal(t, uri+".ts", params.TextDocument.URI)
+	assert.Equal(t, protocol.LangTypeScript, params.TextDocument.LanguageID)
+	assert.NotContains(t, params.TextDocument.Text, "template")
+
+	// and its answers are about the file
+	incoming := &Message{Result: json.RawMessage(`[{"uri":"` + string(uri) + `.ts","range":{}}]`)}
+	client.embeddedIncoming(incoming)
+	assert.JSONEq(t, `[{"uri":"`+string(uri)+`","range":{}}]`, string(incoming.Result))
+
+	require.NoError(t, client.CloseFile(context.Background(), uri.Path()))
+	incoming = &Message{Result: json.RawMessage(`{"uri":"` + string(uri) + `.ts"}`)}
+	client.embeddedIncoming(incoming)
+	assert.Contains(t, string(incoming.Result), ".ts")
+}
+
+func lineOf(text string, line int) string {
+	return strings.Split(text, "\n")[line]
+}
@@ -0,0 +1,139 @@
+package lsp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrExecutableNotAllowed is returned for a language server executable that
+// fails the checks made before it is started
+var ErrExecutableNotAllowed = errors.New("language server executable not allowed")
+
+// shellMetacharacters don't appear in the names of real executables, only
+// in command lines meant for a shell
+const shellMetacharacters = "`$&|;<>(){}[]*?!~\"'\\\n\r\t "
+
+// commandMetacharacters chain, substitute or redirect commands when a shell
+// reads them
+const commandMetacharacters = "`$&|;<>\n\r"
+
+// shells interpret their arguments as commands
+var shells = map[string]bool{
+	"sh": true, "bash": true, "dash": true, "zsh": true, "ksh": true, "fish": true, "csh": true, "tcsh": true,
+	"cmd": true, "powershell": true, "pwsh": true,
+}
+
+// PinnedExecutable is a language server executable allowed to run, by path
+// and optionally by the SHA-256 of its content
+type PinnedExecutable struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// Validate checks that the pin names an absolute path and, if it has one, a
+// well-formed hash
+func (p PinnedExecutable) Validate() error {
+	if !filepath.IsAbs(p.Path) {
+		return fmt.Errorf("path %q must be absolute", p.Path)
+	}
+	if p.SHA256 != "" {
+		if b, err := hex.DecodeString(p.SHA256); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("sha256 of %s must be 64 hexadecimal digits", p.Path)
+		}
+	}
+	return nil
+}
+
+// CheckExecutable refuses to start a language server executable whose name
+// looks like a shell command line, that is a shell, which would interpret
+// the arguments as commands, or that pins don't allow. With pins, the
+// executable, after resolving symbolic links, has to be one of the pinned
+// paths and match its hash if it has one; pinning a shell allows it.
+func CheckExecutable(path string, args []string, pins []PinnedExecutable) error {
+	name := filepath.Base(path)
+	if strings.ContainsAny(name, shellMetacharacters) {
+		return fmt.Errorf("%w: %q contains shell metacharacters", ErrExecutableNotAllowed, path)
+	}
+
+	if len(pins) > 0 {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrExecutableNotAllowed, err)
+		}
+		for _, pin := range pins {
+			pinned, err := filepath.EvalSymlinks(pin.Path)
+			if err != nil || pinned != resolved {
+				continue
+			}
+			if pin.SHA256 == "" {
+				return nil
+			}
+			sum, err := fileSHA256(resolved)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrExecutableNotAllowed, err)
+			}
+			if !strings.EqualFold(sum, pin.SHA256) {
+				return fmt.Errorf("%w: the SHA-256 of %s is %s, not the pinned %s", ErrExecutableNotAllowed, resolved, sum, pin.SHA256)
+			}
+			return nil
+		}
+		return fmt.Errorf("%w: %s isn't among the pinned lspExecutables", ErrExecutableNotAllowed, resolved)
+	}
+
+	if shell := runsShell(path, args); shell != "" {
+		return fmt.Errorf("%w: %s is a shell, which would interpret its arguments as commands; pin it in lspExecutables to allow it", ErrExecutableNotAllowed, shell)
+	}
+	// ssh joins its arguments into a command line for the remote shell
+	if name := strings.TrimSuffix(strings.ToLower(filepath.Base(path)), ".exe"); name == "ssh" {
+		for _, arg := range args {
+			if strings.ContainsAny(arg, commandMetacharacters) {
+				return fmt.Errorf("%w: the remote shell would interpret the ssh argument %q", ErrExecutableNotAllowed, arg)
+			}
+		}
+	}
+	return nil
+}
+
+// runsShell returns the shell a command runs, directly or through env
+func runsShell(path string, args []string) string {
+	name := strings.ToLower(filepath.Base(path))
+	// Windows runs batch files with cmd
+	if ext := filepath.Ext(name); ext == ".bat" || ext == ".cmd" {
+		return "cmd"
+	}
+	name = strings.TrimSuffix(name, ".exe")
+	if shells[name] {
+		return name
+	}
+	if name != "env" {
+		return ""
+	}
+	// env takes options and variable assignments before the command
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") || strings.Contains(arg, "=") {
+			continue
+		}
+		return runsShell(arg, args[i+1:])
+	}
+	return ""
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 of a file's content
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
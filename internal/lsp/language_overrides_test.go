@@ -0,0 +1,69 @@
+package lsp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanguageOverrides(t *testing.T) {
+	SetLanguageOverrides("/ws", map[string]string{
+		"*.tpl":              "gotmpl",
+		"Tiltfile":           "starlark",
+		"deploy/**/*.tpl":    "helm",
+		"config/*.json":      "jsonc",
+		"scripts/**/*.local": "shellscript",
+	})
+	t.Cleanup(func() { SetLanguageOverrides("", nil) })
+
+	tests := []struct {
+		path     string
+		expected protocol.LanguageKind
+	}{
+		{"/ws/templates/page.tpl", "gotmpl"},
+		// The longest matching glob wins
+		{"/ws/deploy/chart/svc.tpl", "helm"},
+		{"/ws/services/api/Tiltfile", "starlark"},
+		{"/ws/config/settings.json", "jsonc"},
+		{"/ws/other/settings.json", protocol.LangJSON},
+		// Globs with a slash only match in the workspace
+		{"/elsewhere/config/settings.json", protocol.LangJSON},
+		{"/elsewhere/page.tpl", "gotmpl"},
+		{"/ws/main.go", protocol.LangGo},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, DetectLanguageID("file://"+tt.path), tt.path)
+	}
+
+	// Overrides win over mode lines
+	assert.Equal(t, protocol.LanguageKind("gotmpl"), DetectLanguageIDFromContent("file:///ws/a.tpl", "{{/* vim: set ft=html: */}}\n"))
+	// Extensions are still found for languages
+	assert.Equal(t, ".json", LanguageExtension(protocol.LangJSON))
+}
+
+func TestModeLineLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected protocol.LanguageKind
+	}{
+		{"VimSet", "#!/bin/sh\n# vim: set ft=bash ts=4:\necho hi\n", protocol.LangShellScript},
+		{"VimFiletype", "// vi: filetype=go\npackage main\n", protocol.LangGo},
+		{"EmacsMode", "# -*- mode: python; coding: utf-8 -*-\nimport os\n", protocol.LangPython},
+		{"EmacsShort", "/* -*- C++ -*- */\n#pragma once\n", protocol.LangCPP},
+		{"EmacsWithoutMode", "# -*- coding: utf-8 -*-\n", ""},
+		{"LastLines", strings.Repeat("x = 1\n", 100) + "# vim: ft=ruby\n", protocol.LangRuby},
+		{"Middle", strings.Repeat("x\n", 10) + "# vim: ft=ruby\n" + strings.Repeat("x\n", 10), ""},
+		{"None", "package main\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, modeLineLanguage(tt.content))
+		})
+	}
+
+	// A mode line overrides the extension
+	assert.Equal(t, protocol.LangCPP, DetectLanguageIDFromContent("file:///ws/vector.h", "// -*- c++ -*-\n"))
+}
@@ -0,0 +1,508 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// Jupyter notebooks are synced with notebookDocument/* notifications, where
+// every cell is its own text document. Tools don't see cells: they address a
+// notebook through a "percent format" view of it, in which each cell is
+// introduced by a "# %%" marker line followed by its source. Positions are
+// translated between the view and the cells.
+
+// jupyterNotebookType is the notebook type servers expect for .ipynb files
+const jupyterNotebookType = "jupyter-notebook"
+
+func init() {
+	utilities.EditNotebook = editNotebook
+}
+
+// IsNotebook reports whether the file is a Jupyter notebook
+func IsNotebook(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".ipynb")
+}
+
+// Notebook is a parsed Jupyter notebook
+type Notebook struct {
+	Path  string
+	Cells []NotebookCell
+}
+
+// NotebookCell is a single cell of a notebook
+type NotebookCell struct {
+	Kind       protocol.NotebookCellKind
+	URI        protocol.DocumentUri
+	LanguageID string
+	Text       string
+	// markerLine is the 0-indexed line of the cell's "# %%" marker in the
+	// notebook view. The cell's source starts on the following line.
+	markerLine int
+}
+
+// ipynbFile is the subset of the nbformat 4 schema needed to read cells
+type ipynbFile struct {
+	Cells []struct {
+		CellType string          `json:"cell_type"`
+		Source   json.RawMessage `json:"source"`
+	} `json:"cells"`
+	Metadata struct {
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+// ParseNotebook reads the cells of an .ipynb file
+func ParseNotebook(path string, data []byte) (*Notebook, error) {
+	var file ipynbFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid notebook: %w", err)
+	}
+
+	language := file.Metadata.LanguageInfo.Name
+	if language == "" {
+		language = file.Metadata.Kernelspec.Language
+	}
+	if language == "" {
+		language = "python"
+	}
+
+	notebook := &Notebook{Path: path}
+	line := 0
+	for i, c := range file.Cells {
+		// nbformat allows the source to be a string or a list of lines
+		var text string
+		var lines []string
+		if err := json.Unmarshal(c.Source, &lines); err == nil {
+			text = strings.Join(lines, "")
+		} else if err := json.Unmarshal(c.Source, &text); err != nil {
+			return nil, fmt.Errorf("invalid source in cell %d: %w", i, err)
+		}
+
+		cell := NotebookCell{
+			Kind:       protocol.Code,
+			URI:        notebookCellURI(path, i),
+			LanguageID: language,
+			Text:       text,
+			markerLine: line,
+		}
+		if c.CellType != "code" {
+			cell.Kind = protocol.Markup
+			cell.LanguageID = "markdown"
+		}
+		notebook.Cells = append(notebook.Cells, cell)
+		line += 1 + cell.lineCount()
+	}
+
+	return notebook, nil
+}
+
+// notebookCellURI builds the URI of a cell document. File URIs are used so
+// that they survive protocol.DocumentUri validation in server responses.
+func notebookCellURI(path string, index int) protocol.DocumentUri {
+	return protocol.URIFromPath(fmt.Sprintf("%s#cell%d", path, index))
+}
+
+func (cell *NotebookCell) lineCount() int {
+	return strings.Count(cell.Text, "\n") + 1
+}
+
+// Text renders the notebook view that tools read and address
+func (n *Notebook) Text() string {
+	var b strings.Builder
+	for i, cell := range n.Cells {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if cell.Kind == protocol.Markup {
+			b.WriteString("# %% [markdown]\n")
+		} else {
+			b.WriteString("# %%\n")
+		}
+		b.WriteString(cell.Text)
+	}
+	return b.String()
+}
+
+// cellAt returns the cell containing a 0-indexed view line and the number of
+// view lines before the cell's first source line
+func (n *Notebook) cellAt(line int) (*NotebookCell, int, bool) {
+	index, ok := n.cellIndexAt(line)
+	if !ok {
+		return nil, 0, false
+	}
+	cell := &n.Cells[index]
+	return cell, cell.markerLine + 1, true
+}
+
+// cellIndexAt returns the index of the cell containing a 0-indexed view line,
+// counting its marker line
+func (n *Notebook) cellIndexAt(line int) (int, bool) {
+	for i := range n.Cells {
+		cell := &n.Cells[i]
+		if line >= cell.markerLine && line <= cell.markerLine+cell.lineCount() {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// cellByURI returns the cell with the given document URI
+func (n *Notebook) cellByURI(uri protocol.DocumentUri) (*NotebookCell, bool) {
+	for i := range n.Cells {
+		if n.Cells[i].URI == uri {
+			return &n.Cells[i], true
+		}
+	}
+	return nil, false
+}
+
+// ReadSourceFile reads a file the way tools present it: notebooks are rendered
+// as their view, documents opened from memory come from there, everything
+// else is returned as is
+func ReadSourceFile(path string) ([]byte, error) {
+	if content, ok := readOverlay(path); ok {
+		return []byte(content), nil
+	}
+	content, err := utilities.ReadFile(path)
+	if err != nil || !IsNotebook(path) {
+		return content, err
+	}
+	notebook, err := ParseNotebook(path, content)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(notebook.Text()), nil
+}
+
+func (c *Client) openNotebook(ctx context.Context, path string) error {
+	uri := string(c.documentURI(path))
+
+	content, err := utilities.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+	// Cells are named after the notebook's canonical path
+	notebook, err := ParseNotebook(protocol.DocumentUri(uri).Path(), content)
+	if err != nil {
+		return err
+	}
+
+	params := protocol.DidOpenNotebookDocumentParams{
+		NotebookDocument: protocol.NotebookDocument{
+			URI:          protocol.URI(uri),
+			NotebookType: jupyterNotebookType,
+			Version:      1,
+			Cells:        make([]protocol.NotebookCell, len(notebook.Cells)),
+		},
+		CellTextDocuments: make([]protocol.TextDocumentItem, len(notebook.Cells)),
+	}
+	for i, cell := range notebook.Cells {
+		params.NotebookDocument.Cells[i] = protocol.NotebookCell{
+			Kind:     cell.Kind,
+			Document: cell.URI,
+		}
+		params.CellTextDocuments[i] = protocol.TextDocumentItem{
+			URI:        cell.URI,
+			LanguageID: protocol.LanguageKind(cell.LanguageID),
+			Version:    1,
+			Text:       cell.Text,
+		}
+	}
+
+	if err := c.DidOpenNotebookDocument(ctx, params); err != nil {
+		return err
+	}
+
+	c.openFilesMu.Lock()
+	c.openFiles[uri] = &OpenFileInfo{
+		Version: 1,
+		URI:     protocol.DocumentUri(uri),
+	}
+	c.notebooks[uri] = notebook
+	c.openFilesMu.Unlock()
+
+	lspLogger.Debug("Opened notebook: %s (%d cells)", path, len(notebook.Cells))
+
+	return nil
+}
+
+func (c *Client) closeNotebook(ctx context.Context, path string) error {
+	uri := string(c.documentURI(path))
+
+	c.openFilesMu.Lock()
+	notebook, exists := c.notebooks[uri]
+	c.openFilesMu.Unlock()
+	if !exists {
+		return nil // Already closed
+	}
+
+	params := protocol.DidCloseNotebookDocumentParams{
+		NotebookDocument: protocol.NotebookDocumentIdentifier{URI: protocol.URI(uri)},
+	}
+	for _, cell := range notebook.Cells {
+		params.CellTextDocuments = append(params.CellTextDocuments, protocol.TextDocumentIdentifier{URI: cell.URI})
+	}
+	if err := c.DidCloseNotebookDocument(ctx, params); err != nil {
+		return err
+	}
+
+	c.openFilesMu.Lock()
+	delete(c.openFiles, uri)
+	delete(c.notebooks, uri)
+	c.openFilesMu.Unlock()
+
+	return nil
+}
+
+// notifyNotebookChange resyncs a notebook after it changed on disk. Cells may
+// have been added, removed or reordered, so the notebook is reopened rather
+// than diffed.
+func (c *Client) notifyNotebookChange(ctx context.Context, path string) error {
+	if !c.IsFileOpen(path) {
+		return fmt.Errorf("cannot notify change for unopened file: %s", path)
+	}
+	if err := c.closeNotebook(ctx, path); err != nil {
+		return err
+	}
+	return c.openNotebook(ctx, path)
+}
+
+// notebookForURI finds the open notebook with the given view URI or cell URI
+func (c *Client) notebookForURI(uri protocol.DocumentUri) (*Notebook, bool) {
+	uri = c.canonicalURI(uri)
+	c.openFilesMu.RLock()
+	defer c.openFilesMu.RUnlock()
+
+	if notebook, ok := c.notebooks[string(uri)]; ok {
+		return notebook, true
+	}
+	for _, notebook := range c.notebooks {
+		if _, ok := notebook.cellByURI(uri); ok {
+			return notebook, true
+		}
+	}
+	return nil, false
+}
+
+// ServerLocation converts a location in a file as tools see it into the
+// document and position the language server knows about. For notebooks that
+// is a cell; lineOffset is the number of view lines before the cell's source
+// and must be added to lines in the server's answers. Other files are
+// returned unchanged.
+func (c *Client) ServerLocation(loc protocol.Location) (serverLoc protocol.Location, lineOffset int) {
+	notebook, ok := c.notebookForURI(loc.URI)
+	if !ok {
+		return loc, 0
+	}
+	cell, offset, ok := notebook.cellAt(int(loc.Range.Start.Line))
+	if !ok {
+		return loc, 0
+	}
+
+	toCell := func(pos protocol.Position) protocol.Position {
+		line := int(pos.Line) - offset
+		if line < 0 {
+			// The marker line maps to the start of the cell
+			return protocol.Position{}
+		}
+		return protocol.Position{Line: uint32(line), Character: pos.Character}
+	}
+	return protocol.Location{
+		URI:   cell.URI,
+		Range: protocol.Range{Start: toCell(loc.Range.Start), End: toCell(loc.Range.End)},
+	}, offset
+}
+
+// FileLocation converts a location returned by the language server into a
+// location in a file as tools see it. Locations in notebook cells are mapped
+// into the notebook view.
+func (c *Client) FileLocation(loc protocol.Location) protocol.Location {
+	notebook, ok := c.notebookForURI(loc.URI)
+	if !ok {
+		return loc
+	}
+	cell, ok := notebook.cellByURI(loc.URI)
+	if !ok {
+		return loc
+	}
+
+	offset := uint32(cell.markerLine + 1)
+	return protocol.Location{
+		URI: protocol.URIFromPath(notebook.Path),
+		Range: protocol.Range{
+			Start: protocol.Position{Line: loc.Range.Start.Line + offset, Character: loc.Range.Start.Character},
+			End:   protocol.Position{Line: loc.Range.End.Line + offset, Character: loc.Range.End.Character},
+		},
+	}
+}
+
+// notebookDiagnostics collects the diagnostics of every cell of a notebook,
+// with ranges mapped into the notebook view
+func (c *Client) notebookDiagnostics(notebook *Notebook) []protocol.Diagnostic {
+	var diagnostics []protocol.Diagnostic
+	for _, cell := range notebook.Cells {
+		offset := uint32(cell.markerLine + 1)
+		for _, diag := range c.diagnostics[cell.URI] {
+			diag.Range.Start.Line += offset
+			diag.Range.End.Line += offset
+			diagnostics = append(diagnostics, diag)
+		}
+	}
+	return diagnostics
+}
+
+// editNotebook applies edits addressed to a notebook view or to a cell
+// document to the cells' sources in the .ipynb file. It reports false for
+// other documents.
+func editNotebook(uri protocol.DocumentUri, edits []protocol.TextEdit) (bool, error) {
+	path := string(uri)
+	if strings.HasPrefix(path, "file://") {
+		path = uri.Path()
+	}
+	cellIndex := -1
+	if base, index, found := strings.Cut(path, "#cell"); found && IsNotebook(base) {
+		n, err := strconv.Atoi(index)
+		if err != nil {
+			return false, nil
+		}
+		path, cellIndex = base, n
+	} else if !IsNotebook(path) {
+		return false, nil
+	}
+	return true, EditNotebookFile(path, cellIndex, edits)
+}
+
+// EditNotebookFile applies text edits to the cells of the notebook at path
+// and writes it back, leaving outputs, metadata and other cells alone. With
+// a cellIndex of -1 positions refer to the notebook view, and each edit has
+// to stay within the source of one cell; otherwise they refer to that cell.
+func EditNotebookFile(path string, cellIndex int, edits []protocol.TextEdit) error {
+	data, err := utilities.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	notebook, err := ParseNotebook(path, data)
+	if err != nil {
+		return err
+	}
+
+	cellEdits := make(map[int][]protocol.TextEdit)
+	if cellIndex >= 0 {
+		if cellIndex >= len(notebook.Cells) {
+			return fmt.Errorf("%s has no cell %d", path, cellIndex)
+		}
+		cellEdits[cellIndex] = edits
+	} else {
+		for _, edit := range edits {
+			index, ok := notebook.cellIndexAt(int(edit.Range.Start.Line))
+			if !ok {
+				return fmt.Errorf("line %d is past the end of %s", edit.Range.Start.Line+1, path)
+			}
+			cell := notebook.Cells[index]
+			first, last := cell.markerLine+1, cell.markerLine+cell.lineCount()
+			if int(edit.Range.Start.Line) < first || int(edit.Range.End.Line) > last {
+				return fmt.Errorf("edit of lines %d-%d changes a '# %%%%' marker or spans cells; edit the source of one cell at a time", edit.Range.Start.Line+1, edit.Range.End.Line+1)
+			}
+			edit.Range.Start.Line -= uint32(first)
+			edit.Range.End.Line -= uint32(first)
+			cellEdits[index] = append(cellEdits[index], edit)
+		}
+	}
+
+	// The file is rewritten from a generic decoding so that fields this
+	// package doesn't know about survive
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw map[string]any
+	if err := decoder.Decode(&raw); err != nil {
+		return fmt.Errorf("invalid notebook: %w", err)
+	}
+	rawCells, _ := raw["cells"].([]any)
+	if len(rawCells) != len(notebook.Cells) {
+		return fmt.Errorf("invalid notebook: unexpected cells")
+	}
+	for index, edits := range cellEdits {
+		text, err := utilities.ApplyEditsToText(notebook.Cells[index].Text, edits)
+		if err != nil {
+			return fmt.Errorf("cell %d: %w", index, err)
+		}
+		rawCell, ok := rawCells[index].(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid notebook: cell %d is not an object", index)
+		}
+		// Keep the source as a list of lines if it was one, like Jupyter
+		// writes it
+		if _, isString := rawCell["source"].(string); isString {
+			rawCell["source"] = text
+		} else {
+			rawCell["source"] = notebookSourceLines(text)
+		}
+	}
+
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", notebookIndent(data))
+	if err := encoder.Encode(raw); err != nil {
+		return fmt.Errorf("failed to encode notebook: %w", err)
+	}
+	result := out.Bytes()
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		result = bytes.TrimSuffix(result, []byte("\n"))
+	}
+	// The encoder ends lines with LF, where the notebook may use CRLF
+	if newline := utilities.FileLineEnding(string(data)); newline != "\n" {
+		result = []byte(utilities.SetLineEndings(string(result), newline))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, result, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// notebookSourceLines splits cell source into lines that keep their newline
+func notebookSourceLines(text string) []string {
+	lines := []string{}
+	for text != "" {
+		line, rest, found := strings.Cut(text, "\n")
+		if found {
+			line += "\n"
+		}
+		lines = append(lines, line)
+		text = rest
+	}
+	return lines
+}
+
+// notebookIndent returns the indentation of the first nested line of a
+// notebook file. Jupyter uses a single space.
+func notebookIndent(data []byte) string {
+	_, rest, found := bytes.Cut(data, []byte("\n"))
+	if !found {
+		return " "
+	}
+	indent := rest[:len(rest)-len(bytes.TrimLeft(rest, " \t"))]
+	if len(indent) == 0 {
+		return " "
+	}
+	return string(indent)
+}
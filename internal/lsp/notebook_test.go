@@ -0,0 +1,131 @@
+package lsp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testNotebook = `{
+  "cells": [
+    {"cell_type": "markdown", "metadata": {}, "source": ["# Title\n", "Some text"]},
+    {"cell_type": "code", "metadata": {}, "outputs": [], "source": ["import os\n", "print(os.getcwd())"]},
+    {"cell_type": "code", "metadata": {}, "outputs": [], "source": "x = 1"}
+  ],
+  "metadata": {"language_info": {"name": "python"}},
+  "nbformat": 4,
+  "nbformat_minor": 5
+}`
+
+func TestParseNotebook(t *testing.T) {
+	notebook, err := ParseNotebook("/work/nb.ipynb", []byte(testNotebook))
+	require.NoError(t, err)
+	require.Len(t, notebook.Cells, 3)
+
+	assert.Equal(t, protocol.Markup, notebook.Cells[0].Kind)
+	assert.Equal(t, "markdown", notebook.Cells[0].LanguageID)
+	assert.Equal(t, protocol.Code, notebook.Cells[1].Kind)
+	assert.Equal(t, "python", notebook.Cells[1].LanguageID)
+	assert.Equal(t, "import os\nprint(os.getcwd())", notebook.Cells[1].Text)
+	assert.Equal(t, "x = 1", notebook.Cells[2].Text)
+
+	expected := "# %% [markdown]\n# Title\nSome text\n" +
+		"# %%\nimport os\nprint(os.getcwd())\n" +
+		"# %%\nx = 1"
+	assert.Equal(t, expected, notebook.Text())
+}
+
+func TestNotebookLocationMapping(t *testing.T) {
+	notebook, err := ParseNotebook("/work/nb.ipynb", []byte(testNotebook))
+	require.NoError(t, err)
+
+	client := &Client{
+		openFiles: make(map[string]*OpenFileInfo),
+		notebooks: map[string]*Notebook{"file:///work/nb.ipynb": notebook},
+	}
+
+	// "os" on the second line of the code cell, line 6 of the view
+	viewLoc := protocol.Location{
+		URI: "file:///work/nb.ipynb",
+		Range: protocol.Range{
+			Start: protocol.Position{Line: 5, Character: 6},
+			End:   protocol.Position{Line: 5, Character: 8},
+		},
+	}
+
+	serverLoc, offset := client.ServerLocation(viewLoc)
+	assert.Equal(t, notebook.Cells[1].URI, serverLoc.URI)
+	assert.Equal(t, uint32(1), serverLoc.Range.Start.Line)
+	assert.Equal(t, uint32(6), serverLoc.Range.Start.Character)
+	assert.Equal(t, 4, offset)
+
+	assert.Equal(t, viewLoc, client.FileLocation(serverLoc))
+
+	// Other files are left alone
+	other := protocol.Location{URI: "file:///work/main.py"}
+	serverLoc, offset = client.ServerLocation(other)
+	assert.Equal(t, other, serverLoc)
+	assert.Equal(t, 0, offset)
+	assert.Equal(t, other, client.FileLocation(other))
+}
+
+func TestNotebookCellURIRoundTrip(t *testing.T) {
+	uri := notebookCellURI("/work/nb.ipynb", 2)
+
+	// Cell URIs come back from the server through DocumentUri validation
+	parsed, err := protocol.ParseDocumentUri(string(uri))
+	require.NoError(t, err)
+	assert.Equal(t, uri, parsed)
+}
+
+func TestEditNotebook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nb.ipynb")
+	require.NoError(t, os.WriteFile(path, []byte(testNotebook), 0644))
+
+	// Lines of the view: "print(os.getcwd())" is line 6, "x = 1" line 8
+	handled, err := editNotebook(protocol.DocumentUri(path), []protocol.TextEdit{{
+		Range:   protocol.Range{Start: protocol.Position{Line: 5}, End: protocol.Position{Line: 5, Character: 18}},
+		NewText: "print(os.getcwd())\nprint(os.sep)",
+	}})
+	require.True(t, handled)
+	require.NoError(t, err)
+
+	// Servers address cells through their documents
+	handled, err = editNotebook(notebookCellURI(path, 2), []protocol.TextEdit{{
+		Range:   protocol.Range{Start: protocol.Position{Character: 0}, End: protocol.Position{Character: 1}},
+		NewText: "y",
+	}})
+	require.True(t, handled)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var file map[string]any
+	require.NoError(t, json.Unmarshal(data, &file), "notebook is no longer valid JSON")
+	cells := file["cells"].([]any)
+	code := cells[1].(map[string]any)
+	assert.Equal(t, []any{"import os\n", "print(os.getcwd())\n", "print(os.sep)"}, code["source"])
+	assert.Equal(t, []any{}, code["outputs"])
+	assert.Equal(t, "y = 1", cells[2].(map[string]any)["source"])
+	assert.Equal(t, float64(4), file["nbformat"])
+
+	notebook, err := ParseNotebook(path, data)
+	require.NoError(t, err)
+	assert.Equal(t, "# Title\nSome text", notebook.Cells[0].Text)
+
+	// Marker lines can't be edited
+	_, err = editNotebook(protocol.DocumentUri("file://"+path), []protocol.TextEdit{{
+		Range: protocol.Range{Start: protocol.Position{Line: 3}, End: protocol.Position{Line: 4, Character: 3}},
+	}})
+	assert.ErrorContains(t, err, "marker")
+
+	// Other files are left to the caller
+	handled, err = editNotebook("file:///work/main.py", nil)
+	assert.False(t, handled)
+	assert.NoError(t, err)
+}
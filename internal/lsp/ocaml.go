@@ -0,0 +1,27 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ServerArgs returns arguments to add to the server's for the workspace.
+// ocamllsp only reads the .merlin files of projects that aren't built with
+// dune when started with --fallback-read-dot-merlin.
+func ServerArgs(workspaceDir, command string, args []string) []string {
+	if ServerName(command) != "ocamllsp" {
+		return nil
+	}
+	for _, arg := range args {
+		if arg == "--fallback-read-dot-merlin" {
+			return nil
+		}
+	}
+	if _, err := os.Stat(filepath.Join(workspaceDir, ".merlin")); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(workspaceDir, "dune-project")); err == nil {
+		return nil
+	}
+	return []string{"--fallback-read-dot-merlin"}
+}
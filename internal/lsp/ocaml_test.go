@@ -0,0 +1,24 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerArgs(t *testing.T) {
+	dir := t.TempDir()
+	assert.Empty(t, ServerArgs(dir, "ocamllsp", nil))
+
+	// Projects that aren't built with dune are described by .merlin
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".merlin"), []byte("S src\nB _build\n"), 0644))
+	assert.Equal(t, []string{"--fallback-read-dot-merlin"}, ServerArgs(dir, "/opt/bin/ocamllsp", nil))
+	assert.Empty(t, ServerArgs(dir, "ocamllsp", []string{"--fallback-read-dot-merlin"}))
+	assert.Empty(t, ServerArgs(dir, "zls", nil))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dune-project"), []byte("(lang dune 3.0)\n"), 0644))
+	assert.Empty(t, ServerArgs(dir, "ocamllsp", nil))
+}
@@ -0,0 +1,145 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// PathMapping pairs a directory as this process sees it with the same
+// directory as the language server sees it, e.g. a workspace mounted at a
+// different path inside a container
+type PathMapping struct {
+	Local  string
+	Remote string
+}
+
+// pathMapper rewrites file URIs in messages exchanged with the language
+// server. When mappings overlap the most specific one wins.
+type pathMapper []PathMapping
+
+// toRemote translates a file URI or path from this process to the server
+func (m pathMapper) toRemote(s string) string {
+	return m.translate(s, func(mapping PathMapping) (string, string) { return mapping.Local, mapping.Remote })
+}
+
+// toLocal translates a file URI or path from the server to this process
+func (m pathMapper) toLocal(s string) string {
+	return m.translate(s, func(mapping PathMapping) (string, string) { return mapping.Remote, mapping.Local })
+}
+
+func (m pathMapper) translate(s string, direction func(PathMapping) (from, to string)) string {
+	result, longest := s, -1
+	for _, mapping := range m {
+		from, to := direction(mapping)
+		if rewritten, ok := replacePathPrefix(s, from, to); ok && len(from) > longest {
+			result, longest = rewritten, len(from)
+		}
+	}
+	return result
+}
+
+// ServerPath returns where the language server sees a local path
+func ServerPath(mappings []PathMapping, path string) string {
+	return pathMapper(mappings).toRemote(path)
+}
+
+// replacePathPrefix swaps the directory from for to at the start of a file
+// URI or path, only matching whole path components
+func replacePathPrefix(s, from, to string) (string, bool) {
+	scheme := ""
+	from = strings.TrimSuffix(filepath.ToSlash(from), "/")
+	to = strings.TrimSuffix(filepath.ToSlash(to), "/")
+	if rest, ok := strings.CutPrefix(s, "file://"); ok {
+		scheme, s = "file://", rest
+		from, to = uriPath(from), uriPath(to)
+	}
+	rest, ok := strings.CutPrefix(s, from)
+	if !ok || (rest != "" && rest[0] != '/') {
+		return "", false
+	}
+	return scheme + to + rest, true
+}
+
+// uriPath returns a slash separated path as it appears in a file URI,
+// escaped and with a Windows drive letter after a leading slash, as in
+// file:///C:/work
+func uriPath(path string) string {
+	if len(path) >= 2 && path[1] == ':' && unicode.IsLetter(rune(path[0])) {
+		path = "/" + strings.ToUpper(path[:1]) + path[1:]
+	}
+	return (&url.URL{Path: path}).EscapedPath()
+}
+
+// outgoing rewrites the file URIs in a message sent to the server
+func (m pathMapper) outgoing(msg *Message) {
+	if len(m) == 0 {
+		return
+	}
+	msg.Params = rewriteURIs(msg.Params, m.toRemote)
+	msg.Result = rewriteURIs(msg.Result, m.toRemote)
+}
+
+// incoming rewrites the file URIs in a message received from the server
+func (m pathMapper) incoming(msg *Message) {
+	if len(m) == 0 {
+		return
+	}
+	msg.Params = rewriteURIs(msg.Params, m.toLocal)
+	msg.Result = rewriteURIs(msg.Result, m.toLocal)
+}
+
+// rewriteURIs applies translate to every file URI in a JSON value, including
+// object keys, and to the plain path in rootPath. Other strings, such as
+// document text, are left alone. The original is returned if it can't be
+// parsed.
+func rewriteURIs(raw json.RawMessage, translate func(string) string) json.RawMessage {
+	if len(raw) == 0 || !bytes.Contains(raw, []byte("file://")) && !bytes.Contains(raw, []byte(`"rootPath"`)) {
+		return raw
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	// Keep numbers exact, since ids and versions are round tripped
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		lspLogger.Warn("Failed to parse message for path mapping: %v", err)
+		return raw
+	}
+
+	var walk func(value any, key string) any
+	walk = func(value any, key string) any {
+		switch v := value.(type) {
+		case map[string]any:
+			// WorkspaceEdit.changes is keyed by URI
+			mapped := make(map[string]any, len(v))
+			for k, item := range v {
+				item = walk(item, k)
+				if strings.HasPrefix(k, "file://") {
+					k = translate(k)
+				}
+				mapped[k] = item
+			}
+			return mapped
+		case []any:
+			for i, item := range v {
+				v[i] = walk(item, key)
+			}
+		case string:
+			if strings.HasPrefix(v, "file://") || key == "rootPath" {
+				return translate(v)
+			}
+		}
+		return value
+	}
+
+	rewritten, err := json.Marshal(walk(value, ""))
+	if err != nil {
+		lspLogger.Warn("Failed to encode message after path mapping: %v", err)
+		return raw
+	}
+	return rewritten
+}
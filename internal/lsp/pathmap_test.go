@@ -0,0 +1,79 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathMapperTranslate(t *testing.T) {
+	m := pathMapper{{Local: "/home/me/project", Remote: "/work"}}
+
+	assert.Equal(t, "file:///work/main.go", m.toRemote("file:///home/me/project/main.go"))
+	assert.Equal(t, "file:///work", m.toRemote("file:///home/me/project"))
+	assert.Equal(t, "/work/main.go", m.toRemote("/home/me/project/main.go"))
+	assert.Equal(t, "file:///home/me/project/main.go", m.toLocal("file:///work/main.go"))
+
+	// Only whole path components match
+	assert.Equal(t, "file:///home/me/project2/main.go", m.toRemote("file:///home/me/project2/main.go"))
+	assert.Equal(t, "file:///workspace/main.go", m.toLocal("file:///workspace/main.go"))
+}
+
+func TestPathMapperWindows(t *testing.T) {
+	m := pathMapper{{Local: "c:/Users/me/my project", Remote: "/work"}}
+
+	assert.Equal(t, "file:///work/main.go", m.toRemote("file:///C:/Users/me/my%20project/main.go"))
+	assert.Equal(t, "file:///C:/Users/me/my%20project/main.go", m.toLocal("file:///work/main.go"))
+	assert.Equal(t, "/work/main.go", m.toRemote("c:/Users/me/my project/main.go"))
+}
+
+func TestPathMapperMostSpecific(t *testing.T) {
+	m := pathMapper{
+		{Local: "/home/me/project", Remote: "/work"},
+		{Local: "/home/me/project/vendor", Remote: "/deps"},
+		{Local: "/home/me/sdk", Remote: "/work/sdk"},
+	}
+
+	assert.Equal(t, "file:///deps/lib.go", m.toRemote("file:///home/me/project/vendor/lib.go"))
+	assert.Equal(t, "file:///work/main.go", m.toRemote("file:///home/me/project/main.go"))
+	assert.Equal(t, "file:///home/me/sdk/fmt.go", m.toLocal("file:///work/sdk/fmt.go"))
+	assert.Equal(t, "file:///home/me/project/main.go", m.toLocal("file:///work/main.go"))
+	assert.Equal(t, "/work", ServerPath(m, "/home/me/project"))
+}
+
+func TestPathMapperMessages(t *testing.T) {
+	m := pathMapper{{Local: "/home/me/project", Remote: "/work"}}
+
+	msg, err := NewRequest(int32(1), "initialize", map[string]any{
+		"rootPath":  "/home/me/project",
+		"rootUri":   "file:///home/me/project",
+		"processId": 1234567890123,
+		"text":      "file:///home/me/project is left alone in text",
+	})
+	require.NoError(t, err)
+	m.outgoing(msg)
+
+	var params map[string]any
+	require.NoError(t, json.Unmarshal(msg.Params, &params))
+	assert.Equal(t, "/work", params["rootPath"])
+	assert.Equal(t, "file:///work", params["rootUri"])
+	assert.Equal(t, "file:///home/me/project is left alone in text", params["text"])
+	assert.Contains(t, string(msg.Params), "1234567890123")
+
+	// Workspace edits from the server are keyed by URI
+	incoming := &Message{
+		JSONRPC: "2.0",
+		Method:  "workspace/applyEdit",
+		Params:  json.RawMessage(`{"edit":{"changes":{"file:///work/a.go":[{"newText":"x"}]},"documentChanges":[{"textDocument":{"uri":"file:///work/b.go"}}]}}`),
+	}
+	m.incoming(incoming)
+	assert.JSONEq(t, `{"edit":{"changes":{"file:///home/me/project/a.go":[{"newText":"x"}]},"documentChanges":[{"textDocument":{"uri":"file:///home/me/project/b.go"}}]}}`, string(incoming.Params))
+
+	// Without mappings messages are untouched
+	raw := json.RawMessage(`{"uri": "file:///work/a.go"}`)
+	unmapped := &Message{Params: raw}
+	pathMapper(nil).incoming(unmapped)
+	assert.Equal(t, string(raw), string(unmapped.Params))
+}
@@ -0,0 +1,36 @@
+package lsp
+
+import (
+	"os"
+)
+
+// intelephenseLicenceKeyVars are the environment variables a licence key
+// for Intelephense's premium features is read from, in the spelling of its
+// initialization option and the American one
+var intelephenseLicenceKeyVars = []string{"INTELEPHENSE_LICENCE_KEY", "INTELEPHENSE_LICENSE_KEY"}
+
+// intelephenseInitializationOptions returns the initialization options for
+// Intelephense, which takes its licence key only at initialization. Without
+// a key the free features are available. env is the server's environment,
+// so that keys set with --lsp-env count.
+func intelephenseInitializationOptions(env []string) map[string]any {
+	options := map[string]any{}
+	for _, key := range intelephenseLicenceKeyVars {
+		if licenceKey := getEnv(env, key); licenceKey != "" {
+			options["licenceKey"] = licenceKey
+			break
+		}
+	}
+	return options
+}
+
+// environ returns the environment of the server process, or of this
+// process for a server that was connected to
+func (c *Client) environ() []string {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	if c.Cmd != nil && c.Cmd.Env != nil {
+		return c.Cmd.Env
+	}
+	return os.Environ()
+}
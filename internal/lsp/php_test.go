@@ -0,0 +1,16 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntelephenseInitializationOptions(t *testing.T) {
+	assert.Empty(t, intelephenseInitializationOptions([]string{"PATH=/usr/bin"}))
+	assert.Equal(t, map[string]any{"licenceKey": "ABC123"},
+		intelephenseInitializationOptions([]string{"INTELEPHENSE_LICENSE_KEY=ABC123"}))
+	// The spelling of the option wins
+	assert.Equal(t, map[string]any{"licenceKey": "UK"},
+		intelephenseInitializationOptions([]string{"INTELEPHENSE_LICENSE_KEY=US", "INTELEPHENSE_LICENCE_KEY=UK"}))
+}
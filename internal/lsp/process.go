@@ -0,0 +1,118 @@
+package lsp
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/sandbox"
+)
+
+// ProcessOptions control the environment the language server is started in
+type ProcessOptions struct {
+	// Env holds KEY=VALUE pairs that are added to, or replace, the inherited
+	// environment
+	Env []string
+	// Dir is the working directory of the server. It defaults to the current
+	// directory.
+	Dir string
+	// Path lists directories searched before the inherited PATH, both for the
+	// server executable and by the server itself
+	Path []string
+	// Runner is a command, with arguments, that the server command is
+	// appended to, such as "docker run -i image" or "ssh host --". Env, Dir
+	// and Path apply to the runner.
+	Runner []string
+	// PathMappings translate file URIs between this process and a server
+	// that sees the files at different paths
+	PathMappings []PathMapping
+	// Connect is the address a started server listens on, for servers that
+	// speak the protocol over a socket rather than stdio
+	Connect string
+	// Pinned, when not empty, lists the only executables the server may be
+	// started with. See CheckExecutable.
+	Pinned []PinnedExecutable
+	// Sandbox, when set, is the policy the server is sandboxed with
+	Sandbox *sandbox.Policy
+}
+
+// Command returns the executable and arguments that start the server,
+// wrapped in the runner if there is one
+func (o ProcessOptions) Command(command string, args ...string) (string, []string) {
+	if len(o.Runner) == 0 {
+		return command, args
+	}
+	wrapped := append(append(append([]string{}, o.Runner[1:]...), command), args...)
+	return o.Runner[0], wrapped
+}
+
+// Environ returns the environment of a server started with these options
+func (o ProcessOptions) Environ() []string {
+	env := os.Environ()
+	for _, entry := range o.Env {
+		key, _, _ := strings.Cut(entry, "=")
+		env = setEnv(env, key, entry)
+	}
+	if len(o.Path) > 0 {
+		path := strings.Join(o.Path, string(os.PathListSeparator))
+		if current := getEnv(env, "PATH"); current != "" {
+			path += string(os.PathListSeparator) + current
+		}
+		env = setEnv(env, "PATH", "PATH="+path)
+	}
+	if o.Sandbox != nil && o.Sandbox.Cache != "" {
+		// The rest of the user cache directory can't be written in the
+		// sandbox
+		env = setEnv(env, "XDG_CACHE_HOME", "XDG_CACHE_HOME="+o.Sandbox.Cache)
+	}
+	return env
+}
+
+// LookPath resolves a command the way the server's environment would, so
+// executables in the extra PATH entries are found first
+func (o ProcessOptions) LookPath(command string) (string, error) {
+	if strings.ContainsRune(command, filepath.Separator) || strings.ContainsRune(command, '/') {
+		return exec.LookPath(command)
+	}
+	for _, dir := range o.Path {
+		candidate := filepath.Join(dir, command)
+		if runtime.GOOS == "windows" && filepath.Ext(candidate) == "" {
+			candidate += ".exe"
+		}
+		if path, err := exec.LookPath(candidate); err == nil {
+			return path, nil
+		}
+	}
+	return exec.LookPath(command)
+}
+
+// setEnv replaces every entry for key with entry, or appends it
+func setEnv(env []string, key, entry string) []string {
+	result := make([]string, 0, len(env)+1)
+	for _, existing := range env {
+		if k, _, _ := strings.Cut(existing, "="); !envKeyEqual(k, key) {
+			result = append(result, existing)
+		}
+	}
+	return append(result, entry)
+}
+
+func getEnv(env []string, key string) string {
+	value := ""
+	for _, entry := range env {
+		if k, v, ok := strings.Cut(entry, "="); ok && envKeyEqual(k, key) {
+			value = v
+		}
+	}
+	return value
+}
+
+// envKeyEqual compares variable names, which are case insensitive on Windows
+func envKeyEqual(a, b string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
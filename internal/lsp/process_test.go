@@ -0,0 +1,60 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessOptionsEnviron(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("MCP_LSP_TEST", "inherited")
+
+	env := ProcessOptions{
+		Env:  []string{"MCP_LSP_TEST=override", "MCP_LSP_NEW=a=b"},
+		Path: []string{"/toolchain/bin", "/other/bin"},
+	}.Environ()
+
+	assert.Equal(t, "override", getEnv(env, "MCP_LSP_TEST"))
+	assert.Equal(t, "a=b", getEnv(env, "MCP_LSP_NEW"))
+	sep := string(os.PathListSeparator)
+	assert.Equal(t, "/toolchain/bin"+sep+"/other/bin"+sep+"/usr/bin", getEnv(env, "PATH"))
+
+	count := 0
+	for _, entry := range env {
+		if strings.HasPrefix(entry, "MCP_LSP_TEST=") {
+			count++
+		}
+	}
+	assert.Equal(t, 1, count, "overridden variables should appear once")
+}
+
+func TestProcessOptionsLookPath(t *testing.T) {
+	dir := t.TempDir()
+	server := filepath.Join(dir, "fake-language-server")
+	require.NoError(t, os.WriteFile(server, []byte("#!/bin/sh\n"), 0755))
+
+	opts := ProcessOptions{Path: []string{dir}}
+	path, err := opts.LookPath("fake-language-server")
+	require.NoError(t, err)
+	assert.Equal(t, server, path)
+
+	_, err = ProcessOptions{}.LookPath("fake-language-server")
+	assert.Error(t, err)
+}
+
+func TestProcessOptionsCommand(t *testing.T) {
+	command, args := ProcessOptions{}.Command("gopls", "serve")
+	assert.Equal(t, "gopls", command)
+	assert.Equal(t, []string{"serve"}, args)
+
+	opts := ProcessOptions{Runner: []string{"docker", "run", "-i", "image"}}
+	command, args = opts.Command("gopls", "serve")
+	assert.Equal(t, "docker", command)
+	assert.Equal(t, []string{"run", "-i", "image", "gopls", "serve"}, args)
+	assert.Equal(t, []string{"docker", "run", "-i", "image"}, opts.Runner)
+}
@@ -0,0 +1,83 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// progress is the latest state of a work done progress reported by the server
+type progress struct {
+	title      string
+	message    string
+	percentage *uint32
+}
+
+// HandleWorkDoneProgressCreate accepts the tokens the server creates to
+// report progress with
+func HandleWorkDoneProgressCreate(params json.RawMessage) (any, error) {
+	return nil, nil
+}
+
+// HandleProgress tracks the work done progress the server reports, such as
+// loading or indexing the workspace
+func HandleProgress(client *Client, params json.RawMessage) {
+	var progressParams struct {
+		Token json.RawMessage `json:"token"`
+		Value struct {
+			Kind       string  `json:"kind"`
+			Title      string  `json:"title"`
+			Message    string  `json:"message"`
+			Percentage *uint32 `json:"percentage"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(params, &progressParams); err != nil {
+		lspLogger.Error("Error unmarshaling progress params: %v", err)
+		return
+	}
+	token := string(progressParams.Token)
+	value := progressParams.Value
+
+	client.progressMu.Lock()
+	defer client.progressMu.Unlock()
+	switch value.Kind {
+	case "begin":
+		client.progress[token] = &progress{title: value.Title, message: value.Message, percentage: value.Percentage}
+		lspLogger.Debug("Progress started: %s", value.Title)
+	case "report":
+		if p, ok := client.progress[token]; ok {
+			if value.Message != "" {
+				p.message = value.Message
+			}
+			if value.Percentage != nil {
+				p.percentage = value.Percentage
+			}
+		}
+	case "end":
+		if p, ok := client.progress[token]; ok {
+			lspLogger.Debug("Progress finished: %s", p.title)
+		}
+		delete(client.progress, token)
+	}
+}
+
+// ActiveProgress describes the work the server reports as in progress, for
+// example "Indexing: 40/100 (40%)"
+func (c *Client) ActiveProgress() []string {
+	c.progressMu.RLock()
+	defer c.progressMu.RUnlock()
+
+	active := make([]string, 0, len(c.progress))
+	for _, p := range c.progress {
+		description := p.title
+		if p.message != "" {
+			description += ": " + p.message
+		}
+		if p.percentage != nil {
+			description += fmt.Sprintf(" (%d%%)", *p.percentage)
+		}
+		active = append(active, description)
+	}
+	sort.Strings(active)
+	return active
+}
@@ -0,0 +1,26 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	client := newClient(nil, nil, nil)
+	assert.Empty(t, client.ActiveProgress())
+
+	HandleProgress(client, []byte(`{"token":"load","value":{"kind":"begin","title":"Loading packages"}}`))
+	HandleProgress(client, []byte(`{"token":1,"value":{"kind":"begin","title":"Indexing","percentage":0}}`))
+	HandleProgress(client, []byte(`{"token":1,"value":{"kind":"report","message":"40/100","percentage":40}}`))
+	assert.Equal(t, []string{"Indexing: 40/100 (40%)", "Loading packages"}, client.ActiveProgress())
+
+	HandleProgress(client, []byte(`{"token":"load","value":{"kind":"end"}}`))
+	assert.Equal(t, []string{"Indexing: 40/100 (40%)"}, client.ActiveProgress())
+
+	// Tokens are told apart by type
+	HandleProgress(client, []byte(`{"token":"1","value":{"kind":"end"}}`))
+	assert.Len(t, client.ActiveProgress(), 1)
+	HandleProgress(client, []byte(`{"token":1,"value":{"kind":"end"}}`))
+	assert.Empty(t, client.ActiveProgress())
+}
@@ -0,0 +1,363 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// proxiedNotifications are the notifications from the server that editors
+// connected to a Proxy are sent
+var proxiedNotifications = map[string]bool{
+	"textDocument/publishDiagnostics": true,
+	"window/showMessage":              true,
+	"window/logMessage":               true,
+}
+
+// editorQueueSize is how many messages are held for an editor that is slow
+// to read them. Notifications beyond it are dropped.
+const editorQueueSize = 256
+
+// Proxy lets editors speak LSP to the language server a Client is connected
+// to, so that a person's editor and the tools share one server and see the
+// same state.
+//
+// The documents an editor opens are opened through the client as overlays,
+// so the tools read the editor's unsaved text, and the editor sees the
+// diagnostics of documents the tools opened. A document stays open until
+// every editor and the tools are done with it. The editor is told the
+// capabilities the server was initialized with. Requests the server makes,
+// like workspace/applyEdit, are answered by the client and not passed on.
+type Proxy struct {
+	client *Client
+}
+
+// NewProxy creates a proxy to the server of client, which must be
+// initialized
+func NewProxy(client *Client) *Proxy {
+	return &Proxy{client: client}
+}
+
+// Serve speaks LSP with an editor over conn until the editor exits or the
+// connection is closed. The documents the editor left open are closed.
+func (p *Proxy) Serve(conn io.ReadWriteCloser) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &editorConn{
+		client:    p.client,
+		conn:      conn,
+		out:       make(chan *Message, editorQueueSize),
+		done:      make(chan struct{}),
+		documents: make(map[string]*editorDocument),
+		requests:  make(map[string]context.CancelFunc),
+	}
+	go e.writeMessages()
+	defer func() {
+		if e.removeListener != nil {
+			e.removeListener()
+		}
+		cancel()
+		close(e.done)
+		_ = conn.Close()
+		for path := range e.documents {
+			if err := p.client.closeEditorDocument(context.Background(), path); err != nil {
+				lspLogger.Warn("Failed to close %s for the editor: %v", path, err)
+			}
+		}
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		msg, err := ReadMessage(reader)
+		if errors.Is(err, ErrMalformedMessage) {
+			lspLogger.Warn("Skipping message from the editor: %v", err)
+			continue
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		switch {
+		case msg.Method == "":
+			// Responses to requests, and the proxy makes none
+		case msg.ID == nil || msg.ID.Value == nil:
+			if msg.Method == "exit" {
+				return nil
+			}
+			e.handleNotification(ctx, msg)
+		default:
+			e.handleRequest(ctx, msg)
+		}
+	}
+}
+
+// editorConn is the connection of one editor to a Proxy
+type editorConn struct {
+	client *Client
+	conn   io.ReadWriteCloser
+	// out holds the messages for the editor until writeMessages writes
+	// them, and done is closed when the editor is gone
+	out  chan *Message
+	done chan struct{}
+
+	// documents the editor has open, by path. Only the read loop uses them.
+	documents map[string]*editorDocument
+
+	// Stops passing notifications from the server on to the editor, which
+	// starts once the editor is initialized
+	removeListener func()
+
+	// Cancels the requests being forwarded, by the editor's request ID
+	requests   map[string]context.CancelFunc
+	requestsMu sync.Mutex
+}
+
+// editorDocument is the text of a document as the editor has it
+type editorDocument struct {
+	languageID protocol.LanguageKind
+	text       string
+}
+
+func (e *editorConn) writeMessages() {
+	for {
+		select {
+		case msg := <-e.out:
+			if err := WriteMessage(e.conn, msg); err != nil {
+				lspLogger.Debug("Failed to write to the editor: %v", err)
+			}
+		case <-e.done:
+			return
+		}
+	}
+}
+
+// send queues a message for the editor. Unless wait is set, a message that
+// doesn't fit the queue is dropped rather than holding up the caller.
+func (e *editorConn) send(msg *Message, wait bool) {
+	if wait {
+		select {
+		case e.out <- msg:
+		case <-e.done:
+		}
+		return
+	}
+	select {
+	case e.out <- msg:
+	case <-e.done:
+	default:
+		lspLogger.Warn("Editor is not reading, dropped %s", msg.Method)
+	}
+}
+
+func (e *editorConn) notify(method string, params any) {
+	msg, err := NewNotification(method, params)
+	if err != nil {
+		lspLogger.Error("Failed to create %s for the editor: %v", method, err)
+		return
+	}
+	e.send(msg, false)
+}
+
+func (e *editorConn) respond(id *MessageID, result any, respErr *ResponseError) {
+	response := &Message{JSONRPC: "2.0", ID: id, Error: respErr}
+	if respErr == nil {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			response.Error = &ResponseError{Code: -32603, Message: fmt.Sprintf("failed to marshal response: %v", err)}
+		} else {
+			response.Result = raw
+		}
+	}
+	e.send(response, true)
+}
+
+func (e *editorConn) handleRequest(ctx context.Context, msg *Message) {
+	switch msg.Method {
+	case "initialize":
+		// The server is already initialized, so the editor gets what it
+		// answered then
+		if result := e.client.InitializeResult(); result != nil {
+			e.respond(msg.ID, result, nil)
+		} else {
+			e.respond(msg.ID, nil, &ResponseError{Code: int(protocol.ServerNotInitialized), Message: "language server is not initialized"})
+		}
+		return
+	case "shutdown":
+		// The server keeps running for the tools
+		e.respond(msg.ID, nil, nil)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	key := msg.ID.String()
+	e.requestsMu.Lock()
+	e.requests[key] = cancel
+	e.requestsMu.Unlock()
+
+	go func() {
+		defer func() {
+			e.requestsMu.Lock()
+			delete(e.requests, key)
+			e.requestsMu.Unlock()
+			cancel()
+		}()
+		var result json.RawMessage
+		err := e.client.Call(ctx, msg.Method, json.RawMessage(msg.Params), &result)
+		if err != nil {
+			e.respond(msg.ID, nil, editorError(ctx, err))
+			return
+		}
+		if len(result) == 0 {
+			result = json.RawMessage("null")
+		}
+		e.respond(msg.ID, result, nil)
+	}()
+}
+
+// start passes notifications from the server on to the editor, after the
+// diagnostics the server published before it connected
+func (e *editorConn) start() {
+	e.removeListener = e.client.AddNotificationListener(func(method string, params json.RawMessage) {
+		if proxiedNotifications[method] {
+			e.send(&Message{JSONRPC: "2.0", Method: method, Params: params}, false)
+		}
+	})
+	for uri, diagnostics := range e.client.GetWorkspaceDiagnostics() {
+		e.notify("textDocument/publishDiagnostics", protocol.PublishDiagnosticsParams{
+			URI:         uri,
+			Diagnostics: diagnostics,
+		})
+	}
+}
+
+// editorError is the error an editor is answered with for a request the
+// server failed
+func editorError(ctx context.Context, err error) *ResponseError {
+	var respErr *ResponseError
+	switch {
+	case errors.As(err, &respErr):
+		return respErr
+	case errors.Is(err, ErrContentModified):
+		return &ResponseError{Code: int(protocol.ContentModified), Message: err.Error()}
+	case errors.Is(err, ErrServerCancelled):
+		return &ResponseError{Code: int(protocol.ServerCancelled), Message: err.Error()}
+	case ctx.Err() != nil:
+		return &ResponseError{Code: int(protocol.RequestCancelled), Message: err.Error()}
+	default:
+		return &ResponseError{Code: -32603, Message: err.Error()}
+	}
+}
+
+func (e *editorConn) handleNotification(ctx context.Context, msg *Message) {
+	var err error
+	switch msg.Method {
+	case "initialized":
+		if e.removeListener == nil {
+			e.start()
+		}
+	case "$/cancelRequest":
+		var params struct {
+			ID MessageID `json:"id"`
+		}
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			e.requestsMu.Lock()
+			if cancel, ok := e.requests[params.ID.String()]; ok {
+				cancel()
+			}
+			e.requestsMu.Unlock()
+		}
+	case "textDocument/didOpen":
+		var params protocol.DidOpenTextDocumentParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			path := params.TextDocument.URI.Path()
+			doc := &editorDocument{languageID: params.TextDocument.LanguageID, text: params.TextDocument.Text}
+			if _, reopened := e.documents[path]; reopened {
+				err = e.client.changeEditorDocument(ctx, path, doc.languageID, doc.text)
+			} else {
+				err = e.client.openEditorDocument(ctx, path, doc.languageID, doc.text)
+			}
+			e.documents[path] = doc
+		}
+	case "textDocument/didChange":
+		var params protocol.DidChangeTextDocumentParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			path := params.TextDocument.URI.Path()
+			doc, ok := e.documents[path]
+			if !ok {
+				err = fmt.Errorf("%s is not open", path)
+				break
+			}
+			for _, change := range params.ContentChanges {
+				doc.text = applyContentChange(doc.text, change)
+			}
+			err = e.client.changeEditorDocument(ctx, path, doc.languageID, doc.text)
+		}
+	case "textDocument/didClose":
+		var params protocol.DidCloseTextDocumentParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			path := params.TextDocument.URI.Path()
+			if _, ok := e.documents[path]; ok {
+				delete(e.documents, path)
+				err = e.client.closeEditorDocument(ctx, path)
+			}
+		}
+	case "textDocument/didSave":
+		err = e.client.Notify(ctx, msg.Method, json.RawMessage(msg.Params))
+	default:
+		// The client keeps the server's configuration and tells it of file
+		// changes itself
+		lspLogger.Debug("Ignoring %s from the editor", msg.Method)
+	}
+	if err != nil {
+		lspLogger.Warn("Failed to handle %s from the editor: %v", msg.Method, err)
+	}
+}
+
+// applyContentChange applies a change an editor made to the text of a
+// document
+func applyContentChange(text string, change protocol.TextDocumentContentChangeEvent) string {
+	switch value := change.Value.(type) {
+	case protocol.TextDocumentContentChangePartial:
+		if value.Range == nil {
+			return value.Text
+		}
+		start := textOffset(text, value.Range.Start)
+		end := textOffset(text, value.Range.End)
+		if end < start {
+			start, end = end, start
+		}
+		return text[:start] + value.Text + text[end:]
+	case protocol.TextDocumentContentChangeWholeDocument:
+		return value.Text
+	}
+	return text
+}
+
+// textOffset converts a position to a byte offset in text. A position past
+// the end of its line is the end of the line, and one past the last line is
+// the end of the text.
+func textOffset(text string, position protocol.Position) int {
+	offset := 0
+	for line := uint32(0); line < position.Line; line++ {
+		next := strings.IndexByte(text[offset:], '\n')
+		if next < 0 {
+			return len(text)
+		}
+		offset += next + 1
+	}
+	line := text[offset:]
+	if end := strings.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	}
+	return offset + utilities.ByteOffset(strings.TrimSuffix(line, "\r"), int(position.Character))
+}
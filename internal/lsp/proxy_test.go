@@ -0,0 +1,136 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyContentChange(t *testing.T) {
+	partial := func(startLine, startChar, endLine, endChar uint32, text string) protocol.TextDocumentContentChangeEvent {
+		return protocol.TextDocumentContentChangeEvent{Value: protocol.TextDocumentContentChangePartial{
+			Range: &protocol.Range{
+				Start: protocol.Position{Line: startLine, Character: startChar},
+				End:   protocol.Position{Line: endLine, Character: endChar},
+			},
+			Text: text,
+		}}
+	}
+
+	tests := []struct {
+		name   string
+		text   string
+		change protocol.TextDocumentContentChangeEvent
+		want   string
+	}{
+		{"insert", "a\nb\n", partial(1, 0, 1, 0, "x"), "a\nxb\n"},
+		{"replace across lines", "one\ntwo\nthree\n", partial(0, 1, 2, 2, "-"), "o-ree\n"},
+		{"columns in UTF-16", "s := \"héllo😀!\"\n", partial(0, 13, 0, 14, ""), "s := \"héllo😀\"\n"},
+		{"CRLF", "a\r\nbc\r\n", partial(1, 5, 1, 5, "d"), "a\r\nbcd\r\n"},
+		{"past the end", "a\n", partial(3, 0, 3, 0, "z"), "a\nz"},
+		{"whole document", "old", protocol.TextDocumentContentChangeEvent{
+			Value: protocol.TextDocumentContentChangeWholeDocument{Text: "new"},
+		}, "new"},
+		{"no range", "old", protocol.TextDocumentContentChangeEvent{
+			Value: protocol.TextDocumentContentChangePartial{Text: "new"},
+		}, "new"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, applyContentChange(tt.text, tt.change))
+		})
+	}
+}
+
+func TestEditorDocuments(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	client := newClient(clientConn, clientConn, nil)
+	defer clientConn.Close()
+
+	// Record what the server is told
+	messages := make(chan *Message, 16)
+	go func() {
+		reader := bufio.NewReader(serverConn)
+		for {
+			msg, err := ReadMessage(reader)
+			if err != nil {
+				close(messages)
+				return
+			}
+			messages <- msg
+		}
+	}()
+	next := func() (string, string) {
+		msg := <-messages
+		require.NotNil(t, msg)
+		var params struct {
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		require.NoError(t, json.Unmarshal(msg.Params, &params))
+		if len(params.ContentChanges) == 0 {
+			return msg.Method, ""
+		}
+		return msg.Method, params.ContentChanges[0].Text
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared.go")
+	require.NoError(t, os.WriteFile(shared, []byte("package disk\n"), 0644))
+	unsaved := filepath.Join(dir, "unsaved.go")
+
+	// The tools have shared.go open when the editor opens it
+	require.NoError(t, client.OpenFile(ctx, shared))
+	method, _ := next()
+	require.Equal(t, "textDocument/didOpen", method)
+	require.NoError(t, client.openEditorDocument(ctx, shared, protocol.LangGo, "package editor\n"))
+	method, text := next()
+	assert.Equal(t, "textDocument/didChange", method)
+	assert.Equal(t, "package editor\n", text)
+
+	// Closing it in the editor leaves it open for the tools, with the
+	// content on disk
+	require.NoError(t, client.closeEditorDocument(ctx, shared))
+	method, text = next()
+	assert.Equal(t, "textDocument/didChange", method)
+	assert.Equal(t, "package disk\n", text)
+	assert.True(t, client.IsFileOpen(shared))
+	content, err := ReadSourceFile(shared)
+	require.NoError(t, err)
+	assert.Equal(t, "package disk\n", string(content))
+
+	// A document two editors have open is closed by the last of them
+	require.NoError(t, client.openEditorDocument(ctx, unsaved, protocol.LangGo, "package a\n"))
+	method, _ = next()
+	require.Equal(t, "textDocument/didOpen", method)
+	require.NoError(t, client.openEditorDocument(ctx, unsaved, protocol.LangGo, "package a\n"))
+	method, _ = next()
+	require.Equal(t, "textDocument/didChange", method)
+	require.NoError(t, client.closeEditorDocument(ctx, unsaved))
+	assert.True(t, client.IsFileOpen(unsaved))
+
+	// The tools analyzing other content for it give it back to the editor
+	require.NoError(t, client.OpenDocument(ctx, unsaved, protocol.LangGo, "package snippet\n"))
+	method, _ = next()
+	require.Equal(t, "textDocument/didChange", method)
+	require.NoError(t, client.CloseDocument(ctx, unsaved))
+	method, text = next()
+	assert.Equal(t, "textDocument/didChange", method)
+	assert.Equal(t, "package a\n", text)
+
+	require.NoError(t, client.closeEditorDocument(ctx, unsaved))
+	method, _ = next()
+	assert.Equal(t, "textDocument/didClose", method)
+	assert.False(t, client.IsFileOpen(unsaved))
+}
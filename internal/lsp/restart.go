@@ -0,0 +1,116 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Done returns a channel that is closed when the connection to the server
+// is lost, because the server exited or crashed or the client was closed.
+// After Restart it returns the channel of the new connection.
+func (c *Client) Done() <-chan struct{} {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	return c.done
+}
+
+// Crashed reports whether the connection was lost without the server being
+// asked to shut down
+func (c *Client) Crashed() bool {
+	select {
+	case <-c.Done():
+		return !c.stopping.Load()
+	default:
+		return false
+	}
+}
+
+// SetRequestTimeout bounds how long each request waits for its response,
+// so that a server that drops a response doesn't hang its caller. Zero
+// waits as long as the caller's context allows.
+func (c *Client) SetRequestTimeout(timeout time.Duration) {
+	c.requestTimeout.Store(int64(timeout))
+}
+
+// Restart stops the server if it is still running, starts it again, or
+// reconnects to it, and initializes it with the same workspace. Open files
+// are opened again, with their content in memory if they were opened from
+// it, and the diagnostics and progress of the old server are dropped.
+func (c *Client) Restart(ctx context.Context) (*protocol.InitializeResult, error) {
+	if c.reconnect == nil {
+		return nil, errors.New("the language server can't be restarted over this connection")
+	}
+
+	c.disconnect()
+	conn, err := c.reconnect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restart LSP server: %w", err)
+	}
+	c.stopping.Store(false)
+	c.attach(conn)
+
+	c.openFilesMu.Lock()
+	var paths []string
+	languages := make(map[string]protocol.LanguageKind)
+	for uri, info := range c.openFiles {
+		path := protocol.DocumentUri(uri).Path()
+		paths = append(paths, path)
+		languages[path] = info.LanguageID
+	}
+	clear(c.openFiles)
+	clear(c.notebooks)
+	c.openFilesMu.Unlock()
+	c.diagnosticsMu.Lock()
+	clear(c.diagnostics)
+	c.diagnosticsMu.Unlock()
+	c.progressMu.Lock()
+	clear(c.progress)
+	c.progressMu.Unlock()
+
+	result, err := c.InitializeLSPClient(ctx, c.workspaceDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		if content, ok := readOverlay(path); ok {
+			err = c.OpenDocument(ctx, path, languages[path], content)
+		} else {
+			err = c.OpenFile(ctx, path)
+		}
+		if err != nil {
+			lspLogger.Error("Failed to reopen %s after restart: %v", path, err)
+		}
+	}
+	lspLogger.Info("Restarted LSP server, reopened %d files", len(paths))
+	return result, nil
+}
+
+// disconnect closes the connection and stops the process serving it,
+// without asking the server to shut down
+func (c *Client) disconnect() {
+	c.stopping.Store(true)
+	c.connMu.RLock()
+	stdin, cmd, wait, done := c.stdin, c.Cmd, c.wait, c.done
+	c.connMu.RUnlock()
+
+	if err := stdin.Close(); err != nil {
+		lspLogger.Debug("Failed to close connection: %v", err)
+	}
+	if cmd != nil {
+		if cmd.Process != nil {
+			// The process may already have exited
+			_ = cmd.Process.Kill()
+		}
+		if wait == nil {
+			wait = cmd.Wait
+		}
+		if err := wait(); err != nil {
+			lspLogger.Debug("LSP server exited: %v", err)
+		}
+	}
+	<-done
+}
@@ -0,0 +1,601 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// A delegate is another language server that works alongside the client's
+// on the documents of some languages, such as ESLint's next to tsserver.
+// The documents of its languages are opened in both, and the diagnostics
+// and code actions of both are merged. Other requests about the documents
+// go to the first delegate with the capability they need, in the order the
+// delegates were added, and to the client's server after them, so that each
+// language has its own chain of servers.
+type delegate struct {
+	client *Client
+	// languages are the language IDs of the documents it is given, or nil
+	// for every document
+	languages []protocol.LanguageKind
+}
+
+// handles reports whether the delegate is given documents of a language
+func (d *delegate) handles(language protocol.LanguageKind) bool {
+	return d.languages == nil || slices.Contains(d.languages, language)
+}
+
+// maxActionOrigins bounds how many code actions are remembered for routing
+// their resolve requests back to the server that offered them
+const maxActionOrigins = 1000
+
+// SetName sets the name the server's diagnostics and code actions are
+// labelled with when they are merged with those of other servers
+func (c *Client) SetName(name string) {
+	c.name = name
+}
+
+// Name returns the name set with SetName
+func (c *Client) Name() string {
+	return c.name
+}
+
+// AddDelegate makes an initialized client work alongside this one on the
+// documents of the given language IDs, or of every language if there are
+// none. It comes after the delegates added before it in the chain of
+// servers of those languages. The documents of those languages that are already open are opened
+// in it. The delegate is shut down with the client.
+func (c *Client) AddDelegate(ctx context.Context, client *Client, languages []protocol.LanguageKind) error {
+	d := &delegate{client: client, languages: languages}
+	client.parent.Store(c)
+	c.delegatesMu.Lock()
+	c.delegates = append(c.delegates, d)
+	c.delegatesMu.Unlock()
+
+	c.openFilesMu.RLock()
+	open := make([]OpenFileInfo, 0, len(c.openFiles))
+	for _, info := range c.openFiles {
+		open = append(open, *info)
+	}
+	c.openFilesMu.RUnlock()
+	for _, info := range open {
+		if !d.handles(info.LanguageID) {
+			continue
+		}
+		path := info.URI.Path()
+		text, ok := readOverlay(path)
+		if !ok {
+			content, err := utilities.ReadFile(path)
+			if err != nil {
+				lspLogger.Warn("Failed to open %s in %s: %v", path, client.name, err)
+				continue
+			}
+			text = string(content)
+		}
+		if err := client.openTextDocument(ctx, info.URI, info.LanguageID, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delegates returns the clients added with AddDelegate
+func (c *Client) Delegates() []*Client {
+	c.delegatesMu.RLock()
+	defer c.delegatesMu.RUnlock()
+	clients := make([]*Client, len(c.delegates))
+	for i, d := range c.delegates {
+		clients[i] = d.client
+	}
+	return clients
+}
+
+// hasDelegates reports whether other servers work alongside this one
+func (c *Client) hasDelegates() bool {
+	c.delegatesMu.RLock()
+	defer c.delegatesMu.RUnlock()
+	return len(c.delegates) > 0
+}
+
+// forward sends a notification sent to the server on to the delegates it
+// concerns: document notifications to those the document is open in, or
+// of its language when it is opened, and changes to workspace files to
+// every delegate. Delegates that fail are logged, not reported.
+func (c *Client) forward(ctx context.Context, method string, params any) {
+	var document protocol.TextDocumentItem
+	switch method {
+	case "textDocument/didOpen", "textDocument/didChange", "textDocument/didClose", "textDocument/didSave", "textDocument/willSave":
+		var notification struct {
+			TextDocument protocol.TextDocumentItem `json:"textDocument"`
+		}
+		if err := remarshal(params, &notification); err != nil {
+			lspLogger.Warn("Not forwarding %s: %v", method, err)
+			return
+		}
+		document = notification.TextDocument
+	case "workspace/didChangeWatchedFiles", "workspace/didCreateFiles", "workspace/didRenameFiles", "workspace/didDeleteFiles":
+	default:
+		return
+	}
+
+	c.delegatesMu.RLock()
+	delegates := slices.Clone(c.delegates)
+	c.delegatesMu.RUnlock()
+	for _, d := range delegates {
+		var err error
+		switch method {
+		case "textDocument/didOpen":
+			if !d.handles(document.LanguageID) {
+				continue
+			}
+			err = d.client.openTextDocument(ctx, document.URI, document.LanguageID, document.Text)
+		case "textDocument/didClose":
+			if !d.client.isURIOpen(document.URI) {
+				continue
+			}
+			err = d.client.Notify(ctx, method, params)
+			d.client.openFilesMu.Lock()
+			delete(d.client.openFiles, string(document.URI))
+			d.client.openFilesMu.Unlock()
+		default:
+			if document.URI != "" && !d.client.isURIOpen(document.URI) {
+				continue
+			}
+			err = d.client.Notify(ctx, method, params)
+		}
+		if err != nil {
+			lspLogger.Warn("Failed to send %s to %s: %v", method, d.client.name, err)
+		}
+	}
+}
+
+// isURIOpen reports whether a document is open in the server
+func (c *Client) isURIOpen(uri protocol.DocumentUri) bool {
+	c.openFilesMu.RLock()
+	defer c.openFilesMu.RUnlock()
+	_, ok := c.openFiles[string(uri)]
+	return ok
+}
+
+// routedCapabilities are the capabilities, by their name in
+// ServerCapabilities, that requests about a document need. These requests
+// are routed along the document's chain of servers.
+var routedCapabilities = map[string]string{
+	"textDocument/hover":                     "hoverProvider",
+	"textDocument/definition":                "definitionProvider",
+	"textDocument/declaration":               "declarationProvider",
+	"textDocument/typeDefinition":            "typeDefinitionProvider",
+	"textDocument/implementation":            "implementationProvider",
+	"textDocument/references":                "referencesProvider",
+	"textDocument/documentHighlight":         "documentHighlightProvider",
+	"textDocument/documentSymbol":            "documentSymbolProvider",
+	"textDocument/completion":                "completionProvider",
+	"textDocument/signatureHelp":             "signatureHelpProvider",
+	"textDocument/codeLens":                  "codeLensProvider",
+	"textDocument/documentLink":              "documentLinkProvider",
+	"textDocument/documentColor":             "colorProvider",
+	"textDocument/colorPresentation":         "colorProvider",
+	"textDocument/formatting":                "documentFormattingProvider",
+	"textDocument/rangeFormatting":           "documentRangeFormattingProvider",
+	"textDocument/onTypeFormatting":          "documentOnTypeFormattingProvider",
+	"textDocument/rename":                    "renameProvider",
+	"textDocument/prepareRename":             "renameProvider",
+	"textDocument/foldingRange":              "foldingRangeProvider",
+	"textDocument/selectionRange":            "selectionRangeProvider",
+	"textDocument/prepareCallHierarchy":      "callHierarchyProvider",
+	"callHierarchy/incomingCalls":            "callHierarchyProvider",
+	"callHierarchy/outgoingCalls":            "callHierarchyProvider",
+	"textDocument/prepareTypeHierarchy":      "typeHierarchyProvider",
+	"typeHierarchy/supertypes":               "typeHierarchyProvider",
+	"typeHierarchy/subtypes":                 "typeHierarchyProvider",
+	"textDocument/linkedEditingRange":        "linkedEditingRangeProvider",
+	"textDocument/semanticTokens/full":       "semanticTokensProvider",
+	"textDocument/semanticTokens/range":      "semanticTokensProvider",
+	"textDocument/semanticTokens/full/delta": "semanticTokensProvider",
+	"textDocument/moniker":                   "monikerProvider",
+	"textDocument/inlayHint":                 "inlayHintProvider",
+	"textDocument/inlineValue":               "inlineValueProvider",
+	"textDocument/diagnostic":                "diagnosticProvider",
+	"textDocument/inlineCompletion":          "inlineCompletionProvider",
+}
+
+// resolvedRequests are the requests that resolve an item another request
+// listed, by that request. They go to the server that answered it last.
+var resolvedRequests = map[string]string{
+	"codeLens/resolve":       "textDocument/codeLens",
+	"completionItem/resolve": "textDocument/completion",
+	"inlayHint/resolve":      "textDocument/inlayHint",
+	"documentLink/resolve":   "textDocument/documentLink",
+}
+
+// routed reports whether the delegates take part in requests of a method
+func routed(method string) bool {
+	switch method {
+	case "textDocument/codeAction", "codeAction/resolve", "workspace/executeCommand":
+		return true
+	}
+	_, capability := routedCapabilities[method]
+	_, resolve := resolvedRequests[method]
+	return capability || resolve
+}
+
+// provides reports whether the server announced the capability a request
+// needs, by its name in ServerCapabilities
+func (c *Client) provides(capability string) bool {
+	encoded, err := json.Marshal(c.capabilities)
+	if err != nil {
+		return false
+	}
+	var capabilities map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &capabilities); err != nil {
+		return false
+	}
+	value, ok := capabilities[capability]
+	return ok && string(value) != "false" && string(value) != "null"
+}
+
+// chain returns the servers that may answer a request about a document, in
+// the order they are tried: the delegates the document is open in that
+// have the capability it needs, in the order they were added, and then this
+// server, which answers whatever they can't
+func (c *Client) chain(method string, uri protocol.DocumentUri) []*Client {
+	var servers []*Client
+	for _, server := range c.Delegates() {
+		if server.isURIOpen(uri) && server.provides(routedCapabilities[method]) {
+			servers = append(servers, server)
+		}
+	}
+	return append(servers, c)
+}
+
+// callChain makes a request along the chain of servers of its document,
+// moving on to the next when a server has exited or doesn't know the
+// method. The server that answered is remembered for resolving the items
+// it listed.
+func (c *Client) callChain(ctx context.Context, method string, params any, result any) error {
+	var document struct {
+		TextDocument protocol.TextDocumentIdentifier `json:"textDocument"`
+		Item         protocol.TextDocumentIdentifier `json:"item"`
+	}
+	if err := remarshal(params, &document); err != nil {
+		return err
+	}
+	uri := document.TextDocument.URI
+	if uri == "" {
+		uri = document.Item.URI
+	}
+
+	servers := c.chain(method, uri)
+	for i, server := range servers {
+		var err error
+		if server == c {
+			err = c.call(ctx, method, params, result)
+		} else {
+			err = server.Call(ctx, method, params, result)
+		}
+		if err == nil {
+			c.delegatesMu.Lock()
+			if c.answeredBy == nil {
+				c.answeredBy = make(map[string]*Client)
+			}
+			c.answeredBy[method] = server
+			c.delegatesMu.Unlock()
+			return nil
+		}
+		if i == len(servers)-1 || !unanswered(err) {
+			return err
+		}
+		lspLogger.Warn("%s couldn't answer %s, trying %s: %v", server.name, method, servers[i+1].name, err)
+	}
+	return nil
+}
+
+// unanswered reports whether a request failed because the server couldn't
+// take it at all, rather than failing on its content
+func unanswered(err error) bool {
+	var responseErr *ResponseError
+	return errors.Is(err, ErrServerExited) ||
+		errors.As(err, &responseErr) && responseErr.Code == int(protocol.MethodNotFound)
+}
+
+// callRouted makes a request that the delegates take part in: code actions
+// are gathered from every server the document is open in, resolving one or
+// running a command goes to the server that offered it, and other requests
+// about a document go along its chain of servers
+func (c *Client) callRouted(ctx context.Context, method string, params any, result any) error {
+	if _, ok := routedCapabilities[method]; ok {
+		return c.callChain(ctx, method, params, result)
+	}
+	if listed, ok := resolvedRequests[method]; ok {
+		c.delegatesMu.RLock()
+		server := c.answeredBy[listed]
+		c.delegatesMu.RUnlock()
+		if server != nil && server != c {
+			return server.Call(ctx, method, params, result)
+		}
+		return c.call(ctx, method, params, result)
+	}
+
+	switch method {
+	case "textDocument/codeAction":
+		return c.mergedCodeActions(ctx, params, result)
+	case "codeAction/resolve":
+		var action protocol.CodeAction
+		if err := remarshal(params, &action); err != nil {
+			return err
+		}
+		c.delegatesMu.RLock()
+		server := c.actionOrigins[CodeActionKey(action)]
+		c.delegatesMu.RUnlock()
+		if server != nil && server != c {
+			return server.Call(ctx, method, params, result)
+		}
+	case "workspace/executeCommand":
+		var command protocol.ExecuteCommandParams
+		if err := remarshal(params, &command); err != nil {
+			return err
+		}
+		for _, server := range c.Delegates() {
+			if provider := server.capabilities.ExecuteCommandProvider; provider != nil && slices.Contains(provider.Commands, command.Command) {
+				// Edits the command makes come from the delegate
+				defer server.trackCommand(ctx)()
+				return server.Call(ctx, method, params, result)
+			}
+		}
+	}
+	return c.call(ctx, method, params, result)
+}
+
+// mergedCodeActions asks every server the document is open in that offers
+// code actions for them, giving each the diagnostics it published itself,
+// and lists each action once. Which server offered each is remembered, so
+// that it can be resolved by it and named by CodeActionServer.
+func (c *Client) mergedCodeActions(ctx context.Context, params any, result any) error {
+	var request protocol.CodeActionParams
+	if err := remarshal(params, &request); err != nil {
+		return err
+	}
+
+	servers := []*Client{c}
+	for _, server := range c.Delegates() {
+		if server.isURIOpen(request.TextDocument.URI) && server.capabilities.CodeActionProvider != nil {
+			servers = append(servers, server)
+		}
+	}
+
+	var merged []json.RawMessage
+	offered := make(map[string]*Client)
+	for _, server := range servers {
+		own := request
+		own.Context.Diagnostics = []protocol.Diagnostic{}
+		for _, diag := range server.ownDiagnostics(request.TextDocument.URI) {
+			if slices.ContainsFunc(request.Context.Diagnostics, func(given protocol.Diagnostic) bool { return SameDiagnostic(given, diag) }) {
+				own.Context.Diagnostics = append(own.Context.Diagnostics, diag)
+			}
+		}
+
+		var actions []json.RawMessage
+		var err error
+		if server == c {
+			err = c.call(ctx, "textDocument/codeAction", own, &actions)
+		} else {
+			err = server.Call(ctx, "textDocument/codeAction", own, &actions)
+		}
+		if err != nil {
+			// The first server's failure is the request's, the others' are
+			// only missing from it
+			if server == c {
+				return err
+			}
+			lspLogger.Warn("Failed to get code actions from %s: %v", server.name, err)
+			continue
+		}
+		for _, raw := range actions {
+			var item protocol.Or_Result_textDocument_codeAction_Item0_Elem
+			if err := json.Unmarshal(raw, &item); err != nil {
+				continue
+			}
+			var key string
+			switch v := item.Value.(type) {
+			case protocol.CodeAction:
+				key = CodeActionKey(v)
+			case protocol.Command:
+				key = CodeActionKey(protocol.CodeAction{Title: v.Title, Command: &v})
+			}
+			if _, ok := offered[key]; ok {
+				continue
+			}
+			offered[key] = server
+			merged = append(merged, raw)
+		}
+	}
+
+	c.delegatesMu.Lock()
+	if c.actionOrigins == nil || len(c.actionOrigins)+len(offered) > maxActionOrigins {
+		c.actionOrigins = make(map[string]*Client)
+	}
+	for key, server := range offered {
+		c.actionOrigins[key] = server
+	}
+	c.delegatesMu.Unlock()
+
+	if merged == nil {
+		merged = []json.RawMessage{}
+	}
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	return setResult(raw, result)
+}
+
+// CodeActionServer returns the name of the server that offered a code
+// action, when the actions of several servers are merged, or "" when only
+// one server offers them. A bare command is passed as a code action that
+// only holds it.
+func (c *Client) CodeActionServer(action protocol.CodeAction) string {
+	if !c.hasDelegates() {
+		return ""
+	}
+	c.delegatesMu.RLock()
+	defer c.delegatesMu.RUnlock()
+	if server := c.actionOrigins[CodeActionKey(action)]; server != nil {
+		return server.name
+	}
+	return ""
+}
+
+// CodeActionKey identifies a code action by everything but the diagnostics
+// it fixes, so that the same action offered for several diagnostics, or by
+// several servers, is listed once
+func CodeActionKey(action protocol.CodeAction) string {
+	action.Diagnostics = nil
+	key, _ := json.Marshal(action)
+	return string(key)
+}
+
+// SameDiagnostic reports whether two diagnostics are the same problem: one
+// with the same severity and message on the same line, whatever reported
+// them
+func SameDiagnostic(a, b protocol.Diagnostic) bool {
+	return a.Range.Start.Line == b.Range.Start.Line &&
+		a.Severity == b.Severity &&
+		strings.TrimSpace(a.Message) == strings.TrimSpace(b.Message)
+}
+
+// MergeDiagnostic adds diag to diagnostics unless the same one is already
+// there, in which case that one is labelled with diag's source as well, as
+// in "gopls, build". It reports whether diag was added. diagnostics is
+// copied before it is changed, since it may be a client's.
+func MergeDiagnostic(diagnostics []protocol.Diagnostic, diag protocol.Diagnostic) ([]protocol.Diagnostic, bool) {
+	for i, existing := range diagnostics {
+		if !SameDiagnostic(existing, diag) {
+			continue
+		}
+		if diag.Source != "" && !slices.Contains(strings.Split(existing.Source, ", "), diag.Source) {
+			diagnostics = slices.Clone(diagnostics)
+			if existing.Source == "" {
+				diagnostics[i].Source = diag.Source
+			} else {
+				diagnostics[i].Source = existing.Source + ", " + diag.Source
+			}
+		}
+		return diagnostics, false
+	}
+	return append(slices.Clip(diagnostics), diag), true
+}
+
+// mergeDelegateDiagnostics merges the diagnostics the delegates published
+// for a document into the server's. Diagnostics a server doesn't label
+// with a source are labelled with its name.
+func (c *Client) mergeDelegateDiagnostics(uri protocol.DocumentUri, diagnostics []protocol.Diagnostic) []protocol.Diagnostic {
+	delegates := c.Delegates()
+	if len(delegates) == 0 {
+		return diagnostics
+	}
+	merged := labelDiagnostics(diagnostics, c.name)
+	for _, server := range delegates {
+		for _, diag := range labelDiagnostics(server.ownDiagnostics(uri), server.name) {
+			merged, _ = MergeDiagnostic(merged, diag)
+		}
+	}
+	return merged
+}
+
+// labelDiagnostics returns a copy of diagnostics in which those without a
+// source have name as theirs
+func labelDiagnostics(diagnostics []protocol.Diagnostic, name string) []protocol.Diagnostic {
+	labelled := slices.Clone(diagnostics)
+	for i := range labelled {
+		if labelled[i].Source == "" {
+			labelled[i].Source = name
+		}
+	}
+	return labelled
+}
+
+// ownDiagnostics returns the diagnostics the server itself published for a
+// document, leaving out those of its delegates
+func (c *Client) ownDiagnostics(uri protocol.DocumentUri) []protocol.Diagnostic {
+	uri = c.canonicalURI(uri)
+	c.diagnosticsMu.RLock()
+	defer c.diagnosticsMu.RUnlock()
+	return c.diagnostics[uri]
+}
+
+// diagnosticURIs returns the documents the server has published
+// diagnostics for
+func (c *Client) diagnosticURIs() []protocol.DocumentUri {
+	c.diagnosticsMu.RLock()
+	defer c.diagnosticsMu.RUnlock()
+	uris := make([]protocol.DocumentUri, 0, len(c.diagnostics))
+	for uri, diagnostics := range c.diagnostics {
+		if len(diagnostics) > 0 {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+// diagnosticsPublished counts diagnostics a delegate published for a
+// document as the server's, so that WaitForDiagnostics sees them
+func (c *Client) diagnosticsPublished(uri protocol.DocumentUri) {
+	uri = c.canonicalURI(uri)
+	c.diagnosticsMu.Lock()
+	c.diagnosticsVersions[uri]++
+	close(c.diagnosticsNotify)
+	c.diagnosticsNotify = make(chan struct{})
+	c.diagnosticsMu.Unlock()
+}
+
+// closeDelegates shuts the delegates down
+func (c *Client) closeDelegates(ctx context.Context) {
+	c.delegatesMu.Lock()
+	delegates := c.delegates
+	c.delegates = nil
+	c.delegatesMu.Unlock()
+	for _, d := range delegates {
+		if err := d.client.Shutdown(ctx); err != nil {
+			lspLogger.Debug("Shutdown of %s failed: %v", d.client.name, err)
+		}
+		if err := d.client.Exit(ctx); err != nil {
+			lspLogger.Debug("Exit notification to %s failed: %v", d.client.name, err)
+		}
+		if err := d.client.Close(); err != nil {
+			lspLogger.Error("Failed to close %s: %v", d.client.name, err)
+		}
+	}
+}
+
+// remarshal converts params, which may be a struct or raw JSON, to v
+func remarshal(params any, v any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal params: %w", err)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("failed to unmarshal params: %w", err)
+	}
+	return nil
+}
+
+// setResult stores a raw result in result the way Call does
+func setResult(raw json.RawMessage, result any) error {
+	if result == nil {
+		return nil
+	}
+	if rawMsg, ok := result.(*json.RawMessage); ok {
+		*rawMsg = raw
+		return nil
+	}
+	if err := json.Unmarshal(raw, result); err != nil {
+		return fmt.Errorf("failed to unmarshal result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeDiagnostic(t *testing.T) {
+	line := protocol.Range{Start: protocol.Position{Line: 3}, End: protocol.Position{Line: 3, Character: 1}}
+	lspDiagnostics := []protocol.Diagnostic{
+		{Range: line, Severity: protocol.SeverityError, Message: "undefined: x", Source: "compiler"},
+	}
+	build := protocol.Diagnostic{Range: line, Severity: protocol.SeverityError, Message: "undefined: x", Source: "build"}
+
+	merged, added := MergeDiagnostic(lspDiagnostics, build)
+	assert.False(t, added)
+	assert.Equal(t, []string{"compiler, build"}, diagnosticSources(merged))
+	assert.Equal(t, "compiler", lspDiagnostics[0].Source, "the language server's diagnostics are left alone")
+
+	// Reported again by the same source
+	merged, added = MergeDiagnostic(merged, build)
+	assert.False(t, added)
+	assert.Equal(t, []string{"compiler, build"}, diagnosticSources(merged))
+
+	other := build
+	other.Range.Start.Line = 4
+	merged, added = MergeDiagnostic(merged, other)
+	assert.True(t, added)
+	assert.Equal(t, []string{"compiler, build", "build"}, diagnosticSources(merged))
+}
+
+func TestDelegateDiagnostics(t *testing.T) {
+	uri := protocol.DocumentUri("file:///work/app.ts")
+	client := newClient(nil, nil, nil)
+	client.SetName("typescript-language-server")
+	delegate := newClient(nil, nil, nil)
+	delegate.SetName("eslint")
+	require.NoError(t, client.AddDelegate(context.Background(), delegate, nil))
+
+	publish := func(c *Client, diagnostics ...protocol.Diagnostic) {
+		params, err := json.Marshal(protocol.PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+		require.NoError(t, err)
+		HandleDiagnostics(c, params)
+	}
+	unused := protocol.Diagnostic{Severity: protocol.SeverityWarning, Message: "'x' is declared but never used"}
+	publish(client, unused)
+
+	// Diagnostics the delegate publishes count as the client's
+	version := client.DiagnosticsVersion(uri)
+	console := protocol.Diagnostic{Range: protocol.Range{Start: protocol.Position{Line: 1}}, Severity: protocol.SeverityWarning, Message: "Unexpected console statement", Source: "eslint"}
+	publish(delegate, unused, console)
+	assert.True(t, client.WaitForDiagnostics(context.Background(), map[protocol.DocumentUri]uint64{uri: version}))
+
+	diagnostics := client.GetFileDiagnostics(uri)
+	assert.Equal(t, []string{"typescript-language-server, eslint", "eslint"}, diagnosticSources(diagnostics))
+	assert.Equal(t, map[protocol.DocumentUri][]protocol.Diagnostic{uri: diagnostics}, client.GetWorkspaceDiagnostics())
+	assert.Equal(t, []protocol.Diagnostic{unused, console}, delegate.GetFileDiagnostics(uri), "the delegate's own diagnostics are left alone")
+}
+
+func diagnosticSources(diagnostics []protocol.Diagnostic) []string {
+	var sources []string
+	for _, diag := range diagnostics {
+		sources = append(sources, diag.Source)
+	}
+	return sources
+}
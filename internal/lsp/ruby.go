@@ -0,0 +1,47 @@
+package lsp
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// bundledServers are the Ruby language servers that are run with bundle
+// exec when the workspace's bundle includes their gem, so that the
+// project's versions of the server and its plugins are used
+var bundledServers = map[string]string{
+	"solargraph": "solargraph",
+	"ruby-lsp":   "ruby-lsp",
+}
+
+// BundleExec returns the runner that starts a Ruby language server through
+// bundle exec, with the environment that points bundler at the workspace's
+// Gemfile, or nil if the workspace's Gemfile.lock doesn't include the
+// server's gem
+func BundleExec(workspaceDir, command string) (runner []string, env []string) {
+	gem, ok := bundledServers[ServerName(command)]
+	if !ok || !lockedGem(filepath.Join(workspaceDir, "Gemfile.lock"), gem) {
+		return nil, nil
+	}
+	return []string{"bundle", "exec"}, []string{"BUNDLE_GEMFILE=" + filepath.Join(workspaceDir, "Gemfile")}
+}
+
+// lockedGem reports whether a Gemfile.lock lists a gem among its specs,
+// where gems are indented by four spaces and followed by their version, as
+// in "    solargraph (0.50.0)"
+func lockedGem(lockFile, gem string) bool {
+	file, err := os.Open(lockFile)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "    "+gem+" (") {
+			return true
+		}
+	}
+	return false
+}
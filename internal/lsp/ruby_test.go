@@ -0,0 +1,34 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundleExec(t *testing.T) {
+	dir := t.TempDir()
+
+	// Without a Gemfile.lock the server runs on its own
+	runner, env := BundleExec(dir, "solargraph")
+	assert.Nil(t, runner)
+	assert.Nil(t, env)
+
+	lock := "GEM\n  remote: https://rubygems.org/\n  specs:\n" +
+		"    rake (13.2.1)\n    solargraph (0.50.0)\n      rubocop (~> 1.38)\n\n" +
+		"DEPENDENCIES\n  solargraph\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Gemfile.lock"), []byte(lock), 0644))
+
+	runner, env = BundleExec(dir, "/usr/local/bin/solargraph")
+	assert.Equal(t, []string{"bundle", "exec"}, runner)
+	assert.Equal(t, []string{"BUNDLE_GEMFILE=" + filepath.Join(dir, "Gemfile")}, env)
+
+	// ruby-lsp isn't in the bundle, and other servers are never bundled
+	runner, _ = BundleExec(dir, "ruby-lsp")
+	assert.Nil(t, runner)
+	runner, _ = BundleExec(dir, "rake")
+	assert.Nil(t, runner)
+}
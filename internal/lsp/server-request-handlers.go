@@ -1,7 +1,9 @@
 package lsp
 
 import (
+	"context"
 	"encoding/json"
+	"sync"
 
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 	"github.com/isaacphi/mcp-language-server/internal/utilities"
@@ -18,37 +20,45 @@ func RegisterFileWatchHandler(handler FileWatchHandler) {
 	fileWatchHandler = handler
 }
 
+// ApplyEditWatcher is called with each edit the server asks the client to
+// apply, before it is applied
+type ApplyEditWatcher func(edit protocol.WorkspaceEdit)
+
+var (
+	applyEditWatchersMu sync.Mutex
+	applyEditWatchers   = make(map[int]ApplyEditWatcher)
+	nextApplyEditWatch  int
+)
+
+// WatchApplyEdits registers a watcher for workspace/applyEdit requests, such
+// as those sent while a command runs. Call the returned function to stop.
+func WatchApplyEdits(watcher ApplyEditWatcher) func() {
+	applyEditWatchersMu.Lock()
+	defer applyEditWatchersMu.Unlock()
+	id := nextApplyEditWatch
+	nextApplyEditWatch++
+	applyEditWatchers[id] = watcher
+	return func() {
+		applyEditWatchersMu.Lock()
+		defer applyEditWatchersMu.Unlock()
+		delete(applyEditWatchers, id)
+	}
+}
+
 // Requests
 
-func HandleWorkspaceConfiguration(params json.RawMessage) (any, error) {
+// HandleWorkspaceConfiguration answers workspace/configuration requests from
+// the client's settings
+func HandleWorkspaceConfiguration(client *Client, params json.RawMessage) (any, error) {
 	var configParams protocol.ParamConfiguration
 	if err := json.Unmarshal(params, &configParams); err != nil {
 		lspLogger.Error("Error unmarshaling configuration params: %v", err)
 		return []map[string]any{{}}, nil
 	}
 
-	result := make([]map[string]any, 0, len(configParams.Items))
-
+	result := make([]any, 0, len(configParams.Items))
 	for _, item := range configParams.Items {
-		config := make(map[string]any)
-		
-		// Handle TypeScript-specific configurations
-		if item.Section == "" {
-			// Return full configuration including TypeScript settings
-			config["typescript"] = map[string]any{
-				"preferences": map[string]any{
-					"noErrorTruncation": false,
-				},
-			}
-		} else if item.Section == "typescript" {
-			config["preferences"] = map[string]any{
-				"noErrorTruncation": false,
-			}
-		} else if item.Section == "typescript.preferences" {
-			config["noErrorTruncation"] = false
-		}
-
-		result = append(result, config)
+		result = append(result, client.settingsSection(item.Section))
 	}
 
 	return result, nil
@@ -90,14 +100,55 @@ func HandleRegisterCapability(params json.RawMessage) (any, error) {
 	return nil, nil
 }
 
-func HandleApplyEdit(params json.RawMessage) (any, error) {
+// trackCommand notes the context of a workspace/executeCommand request
+// until the returned function is called
+func (c *Client) trackCommand(ctx context.Context) func() {
+	c.commandsMu.Lock()
+	defer c.commandsMu.Unlock()
+	if c.commands == nil {
+		c.commands = make(map[int]context.Context)
+	}
+	id := c.nextCommand
+	c.nextCommand++
+	c.commands[id] = ctx
+	return func() {
+		c.commandsMu.Lock()
+		defer c.commandsMu.Unlock()
+		delete(c.commands, id)
+	}
+}
+
+// commandContext returns the context to apply an edit the server sent with:
+// that of the command running, whose tool call the edit belongs to. When
+// several commands run at once the edit can't be told apart, so it is
+// checked and watched as if no tool had sent it.
+func (c *Client) commandContext() context.Context {
+	c.commandsMu.Lock()
+	defer c.commandsMu.Unlock()
+	if len(c.commands) == 1 {
+		for _, ctx := range c.commands {
+			// The edit is applied even if the command's request was
+			// cancelled meanwhile
+			return context.WithoutCancel(ctx)
+		}
+	}
+	return context.Background()
+}
+
+func HandleApplyEdit(c *Client, params json.RawMessage) (any, error) {
 	var workspaceEdit protocol.ApplyWorkspaceEditParams
 	if err := json.Unmarshal(params, &workspaceEdit); err != nil {
 		return protocol.ApplyWorkspaceEditResult{Applied: false}, err
 	}
 
+	applyEditWatchersMu.Lock()
+	for _, watcher := range applyEditWatchers {
+		watcher(workspaceEdit.Edit)
+	}
+	applyEditWatchersMu.Unlock()
+
 	// Apply the edits
-	err := utilities.ApplyWorkspaceEdit(workspaceEdit.Edit)
+	err := utilities.ApplyWorkspaceEdit(c.commandContext(), workspaceEdit.Edit)
 	if err != nil {
 		lspLogger.Error("Error applying workspace edit: %v", err)
 		return protocol.ApplyWorkspaceEditResult{
@@ -152,7 +203,13 @@ func HandleDiagnostics(client *Client, params json.RawMessage) {
 	// Save diagnostics in client
 	client.diagnosticsMu.Lock()
 	client.diagnostics[diagParams.URI] = diagParams.Diagnostics
+	client.diagnosticsVersions[diagParams.URI]++
+	close(client.diagnosticsNotify)
+	client.diagnosticsNotify = make(chan struct{})
 	client.diagnosticsMu.Unlock()
+	if parent := client.parent.Load(); parent != nil {
+		parent.diagnosticsPublished(diagParams.URI)
+	}
 
 	lspLogger.Info("Received diagnostics for %s: %d items", diagParams.URI, len(diagParams.Diagnostics))
 }
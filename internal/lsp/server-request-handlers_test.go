@@ -0,0 +1,54 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApplyEditCommandContext checks that edits the server sends while a
+// command runs are applied with the context of that command's tool call
+func TestApplyEditCommandContext(t *testing.T) {
+	client := newClient(nil, nil, nil)
+	path := filepath.Join(t.TempDir(), "long.go")
+	params := func() json.RawMessage {
+		require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("line\n", 40)), 0o644))
+		params, err := json.Marshal(protocol.ApplyWorkspaceEditParams{Edit: protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+				protocol.URIFromPath(path): {{Range: protocol.Range{End: protocol.Position{Line: 40}}}},
+			},
+		}})
+		require.NoError(t, err)
+		return params
+	}
+	applied := func() bool {
+		result, err := HandleApplyEdit(client, params())
+		require.NoError(t, err)
+		return result.(protocol.ApplyWorkspaceEditResult).Applied
+	}
+
+	// Without a command the edit isn't forced
+	assert.False(t, applied())
+
+	var seen []string
+	ctx := utilities.WatchEdits(utilities.ForceEdits(context.Background()), func(path string) { seen = append(seen, path) })
+	done := client.trackCommand(ctx)
+	assert.True(t, applied())
+	assert.Equal(t, []string{path}, seen)
+
+	// With another command running, the edit can't be attributed
+	other := client.trackCommand(context.Background())
+	assert.False(t, applied())
+	other()
+	done()
+	assert.False(t, applied())
+	assert.Len(t, seen, 1)
+}
@@ -0,0 +1,111 @@
+package lsp
+
+import (
+	"context"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// defaultSettings are sent to every server unless settings override them
+func defaultSettings() map[string]any {
+	return map[string]any{
+		"typescript": map[string]any{
+			"preferences": map[string]any{
+				"noErrorTruncation": false,
+			},
+		},
+	}
+}
+
+// SetServerDefaults sets settings that suit the particular server and
+// workspace, such as the schemas of the workspace's YAML files. They are
+// merged over the defaults, and the settings are merged over them.
+func (c *Client) SetServerDefaults(settings map[string]any) {
+	c.settingsMu.Lock()
+	defer c.settingsMu.Unlock()
+	c.serverDefaults = settings
+}
+
+// SetSettings replaces the settings the server gets from
+// workspace/configuration requests. They are merged over the defaults.
+func (c *Client) SetSettings(settings map[string]any) {
+	c.settingsMu.Lock()
+	defer c.settingsMu.Unlock()
+	c.settings = settings
+}
+
+// SetEgressPolicy sets the policy that the settings the server gets are
+// made to follow, whatever they say
+func (c *Client) SetEgressPolicy(policy EgressPolicy) {
+	c.settingsMu.Lock()
+	defer c.settingsMu.Unlock()
+	c.egress = policy
+}
+
+// EgressPolicy returns the policy set with SetEgressPolicy
+func (c *Client) EgressPolicy() EgressPolicy {
+	c.settingsMu.RLock()
+	defer c.settingsMu.RUnlock()
+	return c.egress
+}
+
+// Settings returns the settings set with SetSettings
+func (c *Client) Settings() map[string]any {
+	c.settingsMu.RLock()
+	defer c.settingsMu.RUnlock()
+	return c.settings
+}
+
+// UpdateSettings replaces the settings and tells the server with
+// workspace/didChangeConfiguration. Servers that pull their configuration
+// ask for it again in response.
+func (c *Client) UpdateSettings(ctx context.Context, settings map[string]any) error {
+	c.SetSettings(settings)
+	return c.DidChangeConfiguration(ctx, protocol.DidChangeConfigurationParams{
+		Settings: c.settingsSection(""),
+	})
+}
+
+// settingsSection returns the value of a dotted section of the settings, such
+// as "gopls" or "typescript.preferences", or all of them for "". Missing
+// sections are empty objects.
+func (c *Client) settingsSection(section string) any {
+	c.settingsMu.RLock()
+	serverDefaults, egress := c.serverDefaults, c.egress
+	c.settingsMu.RUnlock()
+	var value any = egress.apply(c.commandLine(), MergeSettings(MergeSettings(defaultSettings(), serverDefaults), c.Settings()))
+	if section == "" {
+		return value
+	}
+	for _, key := range strings.Split(section, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return map[string]any{}
+		}
+		if value, ok = object[key]; !ok {
+			return map[string]any{}
+		}
+	}
+	return value
+}
+
+// MergeSettings returns base with overrides merged into it recursively.
+// Objects are merged key by key, any other value replaces the base value.
+// Neither argument is modified.
+func MergeSettings(base, overrides map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(overrides))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		baseObject, baseIsObject := merged[key].(map[string]any)
+		object, isObject := value.(map[string]any)
+		if baseIsObject && isObject {
+			merged[key] = MergeSettings(baseObject, object)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged
+}
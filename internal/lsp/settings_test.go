@@ -0,0 +1,64 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceConfiguration(t *testing.T) {
+	client := newClient(nil, nil, nil)
+	client.SetSettings(map[string]any{
+		"gopls": map[string]any{"staticcheck": true},
+		"typescript": map[string]any{
+			"preferences": map[string]any{"quoteStyle": "single"},
+		},
+	})
+
+	params, err := json.Marshal(map[string]any{
+		"items": []map[string]any{
+			{"section": "gopls"},
+			{"section": "typescript.preferences"},
+			{"section": "gopls.staticcheck"},
+			{"section": "rust-analyzer"},
+		},
+	})
+	require.NoError(t, err)
+
+	result, err := HandleWorkspaceConfiguration(client, params)
+	require.NoError(t, err)
+	assert.Equal(t, []any{
+		map[string]any{"staticcheck": true},
+		// User settings are merged over the defaults
+		map[string]any{"noErrorTruncation": false, "quoteStyle": "single"},
+		true,
+		map[string]any{},
+	}, result)
+}
+
+func TestServerDefaults(t *testing.T) {
+	client := newClient(nil, nil, nil)
+	client.SetServerDefaults(map[string]any{
+		"yaml": map[string]any{"schemas": map[string]any{"kubernetes": []string{"/k8s/app.yaml"}}},
+	})
+	client.SetSettings(map[string]any{
+		"yaml": map[string]any{"schemas": map[string]any{"kubernetes": []string{"/deploy/*.yaml"}}, "format": map[string]any{"enable": true}},
+	})
+
+	// Settings are merged over the server defaults
+	assert.Equal(t, map[string]any{
+		"schemas": map[string]any{"kubernetes": []string{"/deploy/*.yaml"}},
+		"format":  map[string]any{"enable": true},
+	}, client.settingsSection("yaml"))
+	assert.Equal(t, map[string]any{"noErrorTruncation": false}, client.settingsSection("typescript.preferences"))
+}
+
+func TestMergeSettings(t *testing.T) {
+	base := map[string]any{"a": map[string]any{"b": 1, "c": 2}, "d": 3}
+	merged := MergeSettings(base, map[string]any{"a": map[string]any{"c": 4}, "d": map[string]any{"e": 5}})
+
+	assert.Equal(t, map[string]any{"a": map[string]any{"b": 1, "c": 4}, "d": map[string]any{"e": 5}}, merged)
+	assert.Equal(t, map[string]any{"a": map[string]any{"b": 1, "c": 2}, "d": 3}, base, "base must not change")
+}
@@ -0,0 +1,86 @@
+package lsp
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// kotlinServers run on the JVM and find it through JAVA_HOME
+var kotlinServers = map[string]bool{
+	"kotlin-language-server": true,
+	"kotlin-lsp":             true,
+}
+
+// ToolchainEnv returns the environment variables that the Kotlin servers and
+// sourcekit-lsp need and that the server's environment doesn't set:
+// JAVA_HOME for the Kotlin servers, from the Gradle project's
+// org.gradle.java.home or the java on the PATH, and on Linux
+// SOURCEKIT_TOOLCHAIN_PATH for sourcekit-lsp, from the swift on the PATH.
+// Other servers get nothing.
+func ToolchainEnv(workspaceDir, command string, opts ProcessOptions) []string {
+	env := opts.Environ()
+	switch name := ServerName(command); {
+	case kotlinServers[name]:
+		if getEnv(env, "JAVA_HOME") != "" {
+			return nil
+		}
+		if javaHome := gradleJavaHome(workspaceDir); javaHome != "" {
+			return []string{"JAVA_HOME=" + javaHome}
+		}
+		if javaHome := toolchainRoot(opts, "java", "bin"); javaHome != "" {
+			// JDKs, unlike the stubs some systems put on the PATH, have a
+			// release file
+			if _, err := os.Stat(filepath.Join(javaHome, "release")); err == nil {
+				return []string{"JAVA_HOME=" + javaHome}
+			}
+		}
+	case name == "sourcekit-lsp":
+		// On macOS sourcekit-lsp finds the toolchain with xcrun
+		if runtime.GOOS == "darwin" || getEnv(env, "SOURCEKIT_TOOLCHAIN_PATH") != "" {
+			return nil
+		}
+		if toolchain := toolchainRoot(opts, "swift", filepath.Join("usr", "bin")); toolchain != "" {
+			return []string{"SOURCEKIT_TOOLCHAIN_PATH=" + toolchain}
+		}
+	}
+	return nil
+}
+
+// gradleJavaHome returns the JDK a Gradle project builds with, set as
+// org.gradle.java.home in its gradle.properties
+func gradleJavaHome(workspaceDir string) string {
+	file, err := os.Open(filepath.Join(workspaceDir, "gradle.properties"))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok && strings.TrimSpace(key) == "org.gradle.java.home" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// toolchainRoot finds an executable on the PATH, following symlinks such as
+// those of update-alternatives, and returns the directory it is in binDir
+// of, or "" if it isn't found there
+func toolchainRoot(opts ProcessOptions, executable, binDir string) string {
+	path, err := opts.LookPath(executable)
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	dir := filepath.Dir(path)
+	if !strings.HasSuffix(dir, string(filepath.Separator)+binDir) {
+		return ""
+	}
+	return strings.TrimSuffix(dir, string(filepath.Separator)+binDir)
+}
@@ -0,0 +1,59 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeExecutable creates an executable file and the directories above it
+func writeExecutable(t *testing.T, path string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"), 0755))
+}
+
+func TestToolchainEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses symlinks and shell scripts")
+	}
+	t.Setenv("JAVA_HOME", "")
+	t.Setenv("SOURCEKIT_TOOLCHAIN_PATH", "")
+	// Symlinks are resolved, including any in the temporary directory
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	workspace := filepath.Join(dir, "workspace")
+	require.NoError(t, os.MkdirAll(workspace, 0755))
+
+	// A JDK linked onto the PATH, as update-alternatives does
+	jdk := filepath.Join(dir, "jvm", "jdk-21")
+	writeExecutable(t, filepath.Join(jdk, "bin", "java"))
+	require.NoError(t, os.WriteFile(filepath.Join(jdk, "release"), nil, 0644))
+	pathDir := filepath.Join(dir, "path")
+	require.NoError(t, os.MkdirAll(pathDir, 0755))
+	require.NoError(t, os.Symlink(filepath.Join(jdk, "bin", "java"), filepath.Join(pathDir, "java")))
+	opts := ProcessOptions{Path: []string{pathDir}}
+
+	assert.Equal(t, []string{"JAVA_HOME=" + jdk}, ToolchainEnv(workspace, "kotlin-language-server", opts))
+	assert.Empty(t, ToolchainEnv(workspace, "gopls", opts))
+
+	// The JDK the Gradle project builds with is preferred
+	properties := "org.gradle.jvmargs=-Xmx2g\norg.gradle.java.home=/opt/jdk-17\n"
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, "gradle.properties"), []byte(properties), 0644))
+	assert.Equal(t, []string{"JAVA_HOME=/opt/jdk-17"}, ToolchainEnv(workspace, "/usr/local/bin/kotlin-lsp", opts))
+
+	// A JAVA_HOME that is already set is kept
+	opts.Env = []string{"JAVA_HOME=/usr/lib/jvm/default"}
+	assert.Empty(t, ToolchainEnv(workspace, "kotlin-language-server", opts))
+
+	if runtime.GOOS != "darwin" {
+		toolchain := filepath.Join(dir, "swift-6.0")
+		writeExecutable(t, filepath.Join(toolchain, "usr", "bin", "swift"))
+		opts = ProcessOptions{Path: []string{filepath.Join(toolchain, "usr", "bin")}}
+		assert.Equal(t, []string{"SOURCEKIT_TOOLCHAIN_PATH=" + toolchain}, ToolchainEnv(workspace, "sourcekit-lsp", opts))
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"strings"
+	"time"
 
 	"github.com/isaacphi/mcp-language-server/internal/logging"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
@@ -21,6 +23,13 @@ var processLogger = logging.NewLogger(logging.LSPProcess)
 var (
 	ErrContentModified = errors.New("content modified")
 	ErrServerCancelled = errors.New("server cancelled")
+	// ErrServerExited is returned for requests that can't be answered because
+	// the connection to the server was lost
+	ErrServerExited = errors.New("language server exited")
+	// ErrMalformedMessage is returned by ReadMessage for a message whose
+	// content isn't valid JSON-RPC. The message is consumed, so reading can
+	// go on.
+	ErrMalformedMessage = errors.New("malformed message")
 )
 
 // WriteMessage writes an LSP message to the given writer
@@ -49,6 +58,19 @@ func WriteMessage(w io.Writer, msg *Message) error {
 	return nil
 }
 
+// write sends a message to the server, translating paths for it
+func (c *Client) write(msg *Message) error {
+	c.canonical.rewrite(msg)
+	c.embeddedOutgoing(msg)
+	c.pathMap.outgoing(msg)
+	c.connMu.RLock()
+	stdin := c.stdin
+	c.connMu.RUnlock()
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return WriteMessage(stdin, msg)
+}
+
 // ReadMessage reads a single LSP message from the given reader
 func ReadMessage(r *bufio.Reader) (*Message, error) {
 	// Read headers
@@ -86,7 +108,7 @@ func ReadMessage(r *bufio.Reader) (*Message, error) {
 	// Parse message
 	var msg Message
 	if err := json.Unmarshal(content, &msg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrMalformedMessage, err)
 	}
 
 	// Log higher-level information about the message type
@@ -101,19 +123,29 @@ func ReadMessage(r *bufio.Reader) (*Message, error) {
 	return &msg, nil
 }
 
-// handleMessages reads and dispatches messages in a loop
-func (c *Client) handleMessages() {
+// handleMessages reads and dispatches messages from stdout until the
+// connection is lost, then closes done
+func (c *Client) handleMessages(stdout *bufio.Reader, done chan struct{}) {
+	defer close(done)
 	for {
-		msg, err := ReadMessage(c.stdout)
+		msg, err := ReadMessage(stdout)
+		if errors.Is(err, ErrMalformedMessage) {
+			// A request whose response this was times out instead
+			lspLogger.Error("Skipping message: %v", err)
+			continue
+		}
 		if err != nil {
 			// Check if this is due to normal shutdown (EOF when closing connection)
-			if strings.Contains(err.Error(), "EOF") {
+			if strings.Contains(err.Error(), "EOF") || errors.Is(err, net.ErrClosed) || errors.Is(err, io.ErrClosedPipe) {
 				lspLogger.Info("LSP connection closed (EOF)")
 			} else {
 				lspLogger.Error("Error reading message: %v", err)
 			}
 			return
 		}
+		c.pathMap.incoming(msg)
+		c.canonical.rewrite(msg)
+		c.embeddedIncoming(msg)
 
 		// Handle server->client request (has both Method and ID)
 		if msg.Method != "" && msg.ID != nil && msg.ID.Value != nil {
@@ -157,7 +189,7 @@ func (c *Client) handleMessages() {
 			}
 
 			// Send response back to server
-			if err := WriteMessage(c.stdin, response); err != nil {
+			if err := c.write(response); err != nil {
 				lspLogger.Error("Error sending response to server: %v", err)
 			}
 
@@ -176,6 +208,11 @@ func (c *Client) handleMessages() {
 			} else {
 				lspLogger.Debug("No handler for notification: %s", msg.Method)
 			}
+			c.notificationMu.RLock()
+			for _, listener := range c.notificationListeners {
+				listener(msg.Method, msg.Params)
+			}
+			c.notificationMu.RUnlock()
 			continue
 		}
 
@@ -198,12 +235,33 @@ func (c *Client) handleMessages() {
 	}
 }
 
-// Call makes a request and waits for the response
+// Call makes a request and waits for the response. With delegates, code
+// action requests are answered by every server that can, resolving a code
+// action or running a command by the server that offered it, and other
+// requests about a document by the first server in its chain that can.
 func (c *Client) Call(ctx context.Context, method string, params any, result any) error {
+	if routed(method) && c.hasDelegates() {
+		return c.callRouted(ctx, method, params, result)
+	}
+	return c.call(ctx, method, params, result)
+}
+
+// call makes a request of this server and waits for the response
+func (c *Client) call(ctx context.Context, method string, params any, result any) error {
 	id := c.nextID.Add(1)
 
 	lspLogger.Debug("Making call: method=%s id=%v", method, id)
 
+	if timeout := time.Duration(c.requestTimeout.Load()); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if method == "shutdown" {
+		c.stopping.Store(true)
+	}
+	done := c.Done()
+
 	msg, err := NewRequest(id, method, params)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
@@ -224,14 +282,35 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 	}()
 
 	// Send request
-	if err := WriteMessage(c.stdin, msg); err != nil {
+	if err := c.write(msg); err != nil {
+		select {
+		case <-done:
+			return fmt.Errorf("%s: %w", method, ErrServerExited)
+		default:
+		}
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 
 	lspLogger.Debug("Waiting for response to request ID: %v", msg.ID)
 
 	// Wait for response
-	resp := <-ch
+	var resp *Message
+	select {
+	case resp = <-ch:
+	case <-done:
+		// The response may have been read just before the connection closed
+		select {
+		case resp = <-ch:
+		default:
+			return fmt.Errorf("%s: %w", method, ErrServerExited)
+		}
+	case <-ctx.Done():
+		// Let the server stop working on a result nobody waits for
+		if err := c.Notify(context.Background(), "$/cancelRequest", protocol.CancelParams{ID: id}); err != nil {
+			lspLogger.Debug("Failed to cancel request %v: %v", msg.ID, err)
+		}
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	}
 
 	lspLogger.Debug("Received response for request ID: %v", msg.ID)
 
@@ -243,7 +322,7 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 		case protocol.ServerCancelled:
 			return ErrServerCancelled
 		default:
-			return fmt.Errorf("request failed: %s (code: %d)", resp.Error.Message, resp.Error.Code)
+			return fmt.Errorf("request failed: %w", resp.Error)
 		}
 	}
 
@@ -263,21 +342,34 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 	return nil
 }
 
-// Notify sends a notification (a request without an ID that doesn't expect a response)
+// Notify sends a notification (a request without an ID that doesn't expect a
+// response), and passes it on to the delegates it concerns
 func (c *Client) Notify(ctx context.Context, method string, params any) error {
 	lspLogger.Debug("Sending notification: method=%s", method)
+	if method == "exit" {
+		c.stopping.Store(true)
+	}
 
 	msg, err := NewNotification(method, params)
 	if err != nil {
 		return fmt.Errorf("failed to create notification: %w", err)
 	}
 
-	if err := WriteMessage(c.stdin, msg); err != nil {
+	if err := c.write(msg); err != nil {
 		return fmt.Errorf("failed to send notification: %w", err)
 	}
 
+	switch method {
+	case "textDocument/didChange", "workspace/didChangeWatchedFiles", "notebookDocument/didOpen":
+		c.contentVersion.Add(1)
+	}
+	if c.hasDelegates() {
+		c.forward(ctx, method, params)
+	}
+
 	return nil
 }
 
 type NotificationHandler func(params json.RawMessage)
+type NotificationListener func(method string, params json.RawMessage)
 type ServerRequestHandler func(params json.RawMessage) (any, error)
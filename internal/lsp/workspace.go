@@ -0,0 +1,188 @@
+package lsp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// workspaceRequirement names the project files that a language server loads
+// a workspace from
+type workspaceRequirement struct {
+	servers     []string
+	markers     []string
+	description string
+}
+
+var workspaceRequirements = []workspaceRequirement{
+	{
+		[]string{"gopls"},
+		[]string{"go.mod", "go.work"},
+		"Go module (go.mod or go.work)",
+	},
+	{
+		[]string{"typescript-language-server", "vtsls"},
+		[]string{"tsconfig.json", "jsconfig.json", "package.json"},
+		"TypeScript or JavaScript project (tsconfig.json, jsconfig.json or package.json)",
+	},
+	{
+		[]string{"rust-analyzer"},
+		[]string{"Cargo.toml", "rust-project.json"},
+		"Cargo project (Cargo.toml or rust-project.json)",
+	},
+	{
+		[]string{"clangd"},
+		[]string{"compile_commands.json", "compile_flags.txt", "build/compile_commands.json"},
+		"compilation database (compile_commands.json or compile_flags.txt)",
+	},
+	{
+		[]string{"pyright", "pyright-langserver", "basedpyright", "basedpyright-langserver", "pylsp", "jedi-language-server"},
+		[]string{"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt", "pyrightconfig.json"},
+		"Python project (pyproject.toml, setup.py, setup.cfg, requirements.txt or pyrightconfig.json)",
+	},
+	{
+		[]string{"intelephense"},
+		[]string{"composer.json"},
+		"Composer project (composer.json)",
+	},
+	{
+		[]string{"solargraph", "ruby-lsp"},
+		[]string{"Gemfile", ".solargraph.yml"},
+		"Ruby project (Gemfile or .solargraph.yml)",
+	},
+	{
+		[]string{"kotlin-language-server", "kotlin-lsp"},
+		[]string{"settings.gradle.kts", "settings.gradle", "build.gradle.kts", "build.gradle", "pom.xml"},
+		"Gradle or Maven project (settings.gradle.kts, settings.gradle, build.gradle.kts, build.gradle or pom.xml)",
+	},
+	{
+		[]string{"sourcekit-lsp"},
+		[]string{"Package.swift", "buildServer.json", "compile_commands.json"},
+		"Swift package or build server configuration (Package.swift, buildServer.json or compile_commands.json)",
+	},
+	{
+		[]string{"zls"},
+		[]string{"build.zig"},
+		"Zig build (build.zig)",
+	},
+	{
+		[]string{"elixir-ls", "language_server.sh"},
+		[]string{"mix.exs"},
+		"Mix project (mix.exs)",
+	},
+	{
+		[]string{"ocamllsp"},
+		[]string{"dune-project", "dune-workspace", ".merlin"},
+		"dune project or .merlin file (dune-project, dune-workspace or .merlin)",
+	},
+	{
+		[]string{"terraform-ls"},
+		[]string{"main.tf", "versions.tf", "terraform.tf", ".terraform.lock.hcl"},
+		"Terraform root module (main.tf, versions.tf, terraform.tf or .terraform.lock.hcl)",
+	},
+}
+
+// workspaceSearchDepth is how deep subdirectories are searched for project
+// files missing from the workspace root
+const workspaceSearchDepth = 2
+
+// CheckWorkspace returns warnings about a workspace that the language server
+// is unlikely to load, such as a Go workspace without go.mod. servers are
+// names the server may be known by, such as its command and the name it
+// reports; unknown servers are only checked for an empty workspace.
+func CheckWorkspace(workspaceDir string, servers ...string) []string {
+	entries, err := os.ReadDir(workspaceDir)
+	if err != nil {
+		return []string{fmt.Sprintf("The workspace %s can't be read: %v", workspaceDir, err)}
+	}
+	if len(entries) == 0 {
+		return []string{fmt.Sprintf("The workspace %s is empty, so the language server has no code to load", workspaceDir)}
+	}
+
+	requirement := findWorkspaceRequirement(servers)
+	if requirement == nil || hasMarker(workspaceDir, requirement.markers, true) {
+		return nil
+	}
+
+	if found := findMarkerBelow(workspaceDir, requirement.markers, workspaceSearchDepth); found != "" {
+		return []string{fmt.Sprintf("No %s in the workspace %s, but %s has one. "+
+			"The language server may not load the code; consider starting it with --workspace %s",
+			requirement.description, workspaceDir, found, found)}
+	}
+	return []string{fmt.Sprintf("No %s found in the workspace %s. "+
+		"The language server may not load the code, so tools can fail or find nothing",
+		requirement.description, workspaceDir)}
+}
+
+// findWorkspaceRequirement returns the requirement of the first server known
+// by one of the names
+func findWorkspaceRequirement(servers []string) *workspaceRequirement {
+	for _, server := range servers {
+		name := ServerName(server)
+		for i, requirement := range workspaceRequirements {
+			for _, candidate := range requirement.servers {
+				if name == candidate {
+					return &workspaceRequirements[i]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ServerName normalizes a name a language server may be known by, such as
+// its command or the name it reports, for comparison with names like
+// "gopls" or "clangd"
+func ServerName(server string) string {
+	name := strings.ToLower(filepath.Base(server))
+	return strings.TrimSuffix(strings.TrimSuffix(name, ".exe"), ".cmd")
+}
+
+// hasMarker reports whether dir, or one of its parents if parents is set,
+// contains one of the marker files
+func hasMarker(dir string, markers []string, parents bool) bool {
+	for {
+		for _, marker := range markers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if !parents || parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// findMarkerBelow returns the first subdirectory of dir, up to depth levels
+// down, that contains one of the marker files. Hidden and dependency
+// directories are skipped.
+func findMarkerBelow(dir string, markers []string, depth int) string {
+	if depth == 0 {
+		return ""
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	var subdirs []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" || name == "target" {
+			continue
+		}
+		subdir := filepath.Join(dir, name)
+		if hasMarker(subdir, markers, false) {
+			return subdir
+		}
+		subdirs = append(subdirs, subdir)
+	}
+	for _, subdir := range subdirs {
+		if found := findMarkerBelow(subdir, markers, depth-1); found != "" {
+			return found
+		}
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	warnings := CheckWorkspace(dir, "gopls")
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "is empty")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), nil, 0644))
+	warnings = CheckWorkspace(dir, "/usr/local/bin/gopls")
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "No Go module (go.mod or go.work) found")
+
+	// Servers without known requirements are only checked for an empty workspace
+	assert.Empty(t, CheckWorkspace(dir, "some-language-server"))
+
+	// A project in a subdirectory is suggested as the workspace
+	service := filepath.Join(dir, "services", "api")
+	require.NoError(t, os.MkdirAll(service, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(service, "Cargo.toml"), nil, 0644))
+	warnings = CheckWorkspace(dir, "rust-analyzer")
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "--workspace "+service)
+
+	// The name the server reports is used when the command isn't known
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), nil, 0644))
+	assert.Empty(t, CheckWorkspace(dir, "node", "typescript-language-server"))
+
+	// Project files in a parent directory count
+	assert.Empty(t, CheckWorkspace(service, "rust-analyzer"))
+
+	// A Terraform root module is recognized by its usual file names
+	infra := filepath.Join(dir, "infra")
+	require.NoError(t, os.MkdirAll(infra, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(infra, "network.tf"), nil, 0644))
+	warnings = CheckWorkspace(infra, "terraform-ls")
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "No Terraform root module")
+	require.NoError(t, os.WriteFile(filepath.Join(infra, "versions.tf"), nil, 0644))
+	assert.Empty(t, CheckWorkspace(infra, "terraform-ls"))
+}
@@ -0,0 +1,123 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// cloudFormationSchema is the JSON schema of CloudFormation templates
+const cloudFormationSchema = "https://raw.githubusercontent.com/awslabs/goformation/master/schema/cloudformation.schema.json"
+
+// cloudFormationTags are the intrinsic functions of CloudFormation's short
+// form, which yaml-language-server otherwise reports as unknown tags
+var cloudFormationTags = []string{
+	"!And sequence", "!Base64", "!Cidr sequence", "!Condition", "!Equals sequence",
+	"!FindInMap sequence", "!GetAtt", "!GetAtt sequence", "!GetAZs", "!If sequence",
+	"!ImportValue", "!Join sequence", "!Not sequence", "!Or sequence", "!Ref",
+	"!Select sequence", "!Split sequence", "!Sub", "!Sub sequence",
+}
+
+// yamlHeaderBytes is how much of a YAML file is read to tell what it is
+const yamlHeaderBytes = 64 * 1024
+
+// yamlScanLimit is how many YAML files are looked at for schemas, so that
+// large repositories don't slow down startup
+const yamlScanLimit = 5000
+
+var (
+	kubernetesAPIVersionRegex = regexp.MustCompile(`(?m)^apiVersion:`)
+	kubernetesKindRegex       = regexp.MustCompile(`(?m)^kind:`)
+	cloudFormationRegex       = regexp.MustCompile(`(?m)^AWSTemplateFormatVersion:|^\s+Type:\s*["']?AWS::`)
+)
+
+// initializeYAMLLanguageServer associates the Kubernetes manifests and
+// CloudFormation templates of the workspace with their schemas, which
+// yaml-language-server doesn't do on its own, and sends it the settings
+func initializeYAMLLanguageServer(ctx context.Context, client *Client, workspaceDir string) error {
+	settings := yamlSchemaSettings(workspaceDir)
+	if settings == nil {
+		return nil
+	}
+	client.SetServerDefaults(settings)
+	return client.DidChangeConfiguration(ctx, protocol.DidChangeConfigurationParams{
+		Settings: client.settingsSection(""),
+	})
+}
+
+// yamlSchemaSettings returns yaml-language-server settings that associate
+// the Kubernetes manifests and CloudFormation templates in a workspace with
+// their schemas, or nil if there are none. Helm templates aren't YAML until
+// rendered, so they are left alone.
+func yamlSchemaSettings(workspaceDir string) map[string]any {
+	var kubernetes, cloudFormation []string
+	scanned := 0
+	errStop := errors.New("stop")
+	_ = filepath.WalkDir(workspaceDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != workspaceDir && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := strings.ToLower(filepath.Ext(path)); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		if scanned++; scanned > yamlScanLimit {
+			return errStop
+		}
+		header, err := readHeader(path, yamlHeaderBytes)
+		if err != nil || strings.Contains(header, "{{") {
+			return nil
+		}
+		rel, err := filepath.Rel(workspaceDir, path)
+		if err != nil {
+			return nil
+		}
+		// Patterns are relative to the workspace root
+		pattern := "/" + filepath.ToSlash(rel)
+		switch {
+		case cloudFormationRegex.MatchString(header):
+			cloudFormation = append(cloudFormation, pattern)
+		case kubernetesAPIVersionRegex.MatchString(header) && kubernetesKindRegex.MatchString(header):
+			kubernetes = append(kubernetes, pattern)
+		}
+		return nil
+	})
+
+	if len(kubernetes) == 0 && len(cloudFormation) == 0 {
+		return nil
+	}
+	schemas := map[string]any{}
+	yaml := map[string]any{"schemas": schemas}
+	if len(kubernetes) > 0 {
+		schemas["kubernetes"] = kubernetes
+	}
+	if len(cloudFormation) > 0 {
+		schemas[cloudFormationSchema] = cloudFormation
+		yaml["customTags"] = cloudFormationTags
+	}
+	return map[string]any{"yaml": yaml}
+}
+
+// readHeader reads up to limit bytes from the start of a file
+func readHeader(path string, limit int64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, limit))
+	return string(data), err
+}
@@ -0,0 +1,40 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYAMLSchemaSettings(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"k8s/deployment.yaml":       "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n",
+		"k8s/all.yml":               "---\napiVersion: v1\nkind: Service\n---\napiVersion: v1\nkind: ConfigMap\n",
+		"infra/stack.yaml":          "AWSTemplateFormatVersion: \"2010-09-09\"\nResources:\n  Bucket:\n    Type: AWS::S3::Bucket\n",
+		"infra/sam.yaml":            "Transform: AWS::Serverless-2016-10-31\nResources:\n  Fn:\n    Type: 'AWS::Serverless::Function'\n",
+		"chart/templates/svc.yaml":  "apiVersion: v1\nkind: Service\nmetadata:\n  name: {{ .Release.Name }}\n",
+		".github/workflows/ci.yaml": "name: CI\non: push\n",
+		"config.yaml":               "server:\n  kind: http\n",
+		"node_modules/x/k.yaml":     "apiVersion: v1\nkind: Pod\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	settings := yamlSchemaSettings(dir)
+	require.NotNil(t, settings)
+	yaml := settings["yaml"].(map[string]any)
+	schemas := yaml["schemas"].(map[string]any)
+	assert.ElementsMatch(t, []string{"/k8s/deployment.yaml", "/k8s/all.yml"}, schemas["kubernetes"])
+	assert.ElementsMatch(t, []string{"/infra/stack.yaml", "/infra/sam.yaml"}, schemas[cloudFormationSchema])
+	assert.Contains(t, yaml["customTags"], "!Ref")
+
+	// Workspaces without either get no settings
+	assert.Nil(t, yamlSchemaSettings(filepath.Join(dir, ".github")))
+}
@@ -0,0 +1,264 @@
+package lsptest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+)
+
+// FaultKind is a failure a Proxy injects
+type FaultKind int
+
+const (
+	// DropResponse discards the response, so the request is never answered
+	DropResponse FaultKind = iota
+	// MalformedResponse replaces the response with content that isn't JSON
+	MalformedResponse
+	// DelayResponse holds the response back for Fault.Delay
+	DelayResponse
+	// ErrorResponse answers with Fault.Error instead of the server's result
+	ErrorResponse
+	// Crash closes the connection when the request arrives, as if the server
+	// died while handling it
+	Crash
+)
+
+// Fault is a failure injected into the requests of a method
+type Fault struct {
+	Kind FaultKind
+	// Method is the request method the fault applies to, or every method
+	// when empty
+	Method string
+	// Times is how many requests the fault applies to, or every one when 0
+	Times int
+	// Delay is how long DelayResponse holds a response back
+	Delay time.Duration
+	// Error is the error ErrorResponse answers with
+	Error *lsp.ResponseError
+}
+
+// injectedFault counts the requests a fault has applied to
+type injectedFault struct {
+	Fault
+	applied int
+}
+
+// Proxy forwards messages between clients and a server, injecting faults
+// into the requests it sees. Each connection Dial opens gets a connection of
+// its own to the server, so that a client can restart after a crash while
+// faults keep applying.
+type Proxy struct {
+	upstream func(ctx context.Context) (io.ReadWriteCloser, error)
+
+	mu     sync.Mutex
+	faults []*injectedFault
+	// conns are the open connections, closed by Crash
+	conns []io.Closer
+}
+
+// NewProxy returns a proxy to the server connections upstream opens, such as
+// Server.Dial or the stdio of a server process
+func NewProxy(upstream func(ctx context.Context) (io.ReadWriteCloser, error)) *Proxy {
+	return &Proxy{upstream: upstream}
+}
+
+// Inject adds a fault, which applies to requests sent from then on. Faults
+// apply in the order they were added; the first that matches a request is
+// used.
+func (p *Proxy) Inject(fault Fault) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.faults = append(p.faults, &injectedFault{Fault: fault})
+}
+
+// Reset removes every fault
+func (p *Proxy) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.faults = nil
+}
+
+// Crash closes every open connection at once, as if the server exited
+func (p *Proxy) Crash() {
+	p.mu.Lock()
+	conns := p.conns
+	p.conns = nil
+	p.mu.Unlock()
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+}
+
+// fault returns the fault that applies to a request of method, if any, and
+// counts it
+func (p *Proxy) fault(method string) (Fault, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, f := range p.faults {
+		if (f.Method == "" || f.Method == method) && (f.Times == 0 || f.applied < f.Times) {
+			f.applied++
+			return f.Fault, true
+		}
+	}
+	return Fault{}, false
+}
+
+// Dial opens a connection to the server through the proxy
+func (p *Proxy) Dial(ctx context.Context) (io.ReadWriteCloser, error) {
+	server, err := p.upstream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clientConn, forwarded := net.Pipe()
+	c := &proxyConn{proxy: p, client: forwarded, server: server, faults: make(map[string]Fault)}
+	p.mu.Lock()
+	p.conns = append(p.conns, c)
+	p.mu.Unlock()
+	go c.forwardRequests()
+	go c.forwardResponses()
+	return clientConn, nil
+}
+
+// proxyConn is one connection through the proxy
+type proxyConn struct {
+	proxy  *Proxy
+	client net.Conn
+	server io.ReadWriteCloser
+
+	mu sync.Mutex
+	// faults are the faults of the requests waiting for a response, by ID
+	faults    map[string]Fault
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+}
+
+func (c *proxyConn) Close() error {
+	c.closeOnce.Do(func() {
+		_ = c.client.Close()
+		_ = c.server.Close()
+	})
+	return nil
+}
+
+// forwardRequests copies messages from the client to the server, noting the
+// requests that are to fail
+func (c *proxyConn) forwardRequests() {
+	defer c.Close()
+	reader := bufio.NewReader(c.client)
+	for {
+		msg, err := lsp.ReadMessage(reader)
+		if err != nil {
+			return
+		}
+		if msg.Method != "" && msg.ID != nil && msg.ID.Value != nil {
+			if fault, ok := c.proxy.fault(msg.Method); ok {
+				if fault.Kind == Crash {
+					return
+				}
+				c.mu.Lock()
+				c.faults[msg.ID.String()] = fault
+				c.mu.Unlock()
+			}
+		}
+		if err := lsp.WriteMessage(c.server, msg); err != nil {
+			return
+		}
+	}
+}
+
+// forwardResponses copies messages from the server to the client, applying
+// the faults of the requests they answer
+func (c *proxyConn) forwardResponses() {
+	defer c.Close()
+	reader := bufio.NewReader(c.server)
+	for {
+		msg, err := lsp.ReadMessage(reader)
+		if err != nil {
+			return
+		}
+		fault, ok := Fault{}, false
+		if msg.Method == "" && msg.ID != nil && msg.ID.Value != nil {
+			c.mu.Lock()
+			fault, ok = c.faults[msg.ID.String()]
+			delete(c.faults, msg.ID.String())
+			c.mu.Unlock()
+		}
+		if !ok {
+			if err := c.write(msg); err != nil {
+				return
+			}
+			continue
+		}
+
+		switch fault.Kind {
+		case DropResponse:
+		case MalformedResponse:
+			if err := c.writeRaw([]byte(`{"jsonrpc": "2.0", "id": ` + msg.ID.String() + `, "result": {`)); err != nil {
+				return
+			}
+		case DelayResponse:
+			time.AfterFunc(fault.Delay, func() { _ = c.write(msg) })
+		case ErrorResponse:
+			msg.Result = nil
+			msg.Error = fault.Error
+			if err := c.write(msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *proxyConn) write(msg *lsp.Message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return lsp.WriteMessage(c.client, msg)
+}
+
+// writeRaw sends content that may not be a valid message
+func (c *proxyConn) writeRaw(content []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := fmt.Fprintf(c.client, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+	_, err := c.client.Write(content)
+	return err
+}
+
+// Dial opens an in-memory connection to the server, which is served until
+// it is closed. The server answers the latest connection.
+func (s *Server) Dial(ctx context.Context) (io.ReadWriteCloser, error) {
+	clientConn, serverConn := net.Pipe()
+	go func() {
+		defer serverConn.Close()
+		_ = s.Serve(serverConn)
+	}()
+	return clientConn, nil
+}
+
+// StartProxied connects a client to the server through a Proxy and
+// initializes it with workspaceDir. The client can be restarted, which
+// connects it again through the proxy. It is closed when the test ends.
+func StartProxied(t testing.TB, server *Server, workspaceDir string) (*lsp.Client, *Proxy) {
+	t.Helper()
+	proxy := NewProxy(server.Dial)
+	client, err := lsp.ConnectFunc(context.Background(), proxy.Dial, nil)
+	if err != nil {
+		t.Fatalf("lsptest: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = client.Close()
+		proxy.Crash()
+	})
+	if _, err := client.InitializeLSPClient(context.Background(), workspaceDir); err != nil {
+		t.Fatalf("lsptest: %v", err)
+	}
+	return client, proxy
+}
@@ -0,0 +1,120 @@
+package lsptest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hoverServer() *Server {
+	server := NewServer(protocol.ServerCapabilities{})
+	server.Respond("textDocument/hover", protocol.Hover{
+		Contents: protocol.Or_Hover_contents{Value: protocol.MarkupContent{Kind: protocol.Markdown, Value: "package main"}},
+	})
+	return server
+}
+
+func TestProxyFaults(t *testing.T) {
+	client, proxy := StartProxied(t, hoverServer(), t.TempDir())
+	client.SetRequestTimeout(200 * time.Millisecond)
+	ctx := context.Background()
+	hover := func() error {
+		_, err := client.Hover(ctx, protocol.HoverParams{})
+		return err
+	}
+
+	t.Run("dropped response", func(t *testing.T) {
+		proxy.Inject(Fault{Kind: DropResponse, Method: "textDocument/hover", Times: 1})
+		assert.ErrorIs(t, hover(), context.DeadlineExceeded)
+		assert.NoError(t, hover())
+	})
+
+	t.Run("malformed response", func(t *testing.T) {
+		proxy.Inject(Fault{Kind: MalformedResponse, Method: "textDocument/hover", Times: 1})
+		assert.ErrorIs(t, hover(), context.DeadlineExceeded)
+		// The client skips the message and keeps reading
+		assert.NoError(t, hover())
+	})
+
+	t.Run("delayed response", func(t *testing.T) {
+		proxy.Inject(Fault{Kind: DelayResponse, Method: "textDocument/hover", Times: 1, Delay: 50 * time.Millisecond})
+		start := time.Now()
+		assert.NoError(t, hover())
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+		proxy.Inject(Fault{Kind: DelayResponse, Method: "textDocument/hover", Times: 1, Delay: time.Second})
+		assert.ErrorIs(t, hover(), context.DeadlineExceeded)
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		proxy.Inject(Fault{
+			Kind:   ErrorResponse,
+			Method: "textDocument/hover",
+			Times:  1,
+			Error:  &lsp.ResponseError{Code: int(protocol.ContentModified), Message: "busy"},
+		})
+		assert.ErrorIs(t, hover(), lsp.ErrContentModified)
+		assert.NoError(t, hover())
+	})
+
+	t.Run("other methods", func(t *testing.T) {
+		proxy.Inject(Fault{Kind: DropResponse, Method: "textDocument/definition"})
+		assert.NoError(t, hover())
+		proxy.Reset()
+	})
+}
+
+func TestProxyCrash(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+	server := hoverServer()
+	client, proxy := StartProxied(t, server, dir)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, client.OpenFile(ctx, path))
+
+	// Pending requests fail as soon as the connection is lost, rather than
+	// waiting for their context
+	proxy.Inject(Fault{Kind: Crash, Method: "textDocument/hover", Times: 1})
+	_, err := client.Hover(ctx, protocol.HoverParams{})
+	assert.ErrorIs(t, err, lsp.ErrServerExited)
+	select {
+	case <-client.Done():
+	case <-ctx.Done():
+		t.Fatal("the connection wasn't reported lost")
+	}
+	assert.True(t, client.Crashed())
+	_, err = client.Hover(ctx, protocol.HoverParams{})
+	assert.ErrorIs(t, err, lsp.ErrServerExited)
+
+	// A restart initializes the server again and reopens the files
+	_, err = client.Restart(ctx)
+	require.NoError(t, err)
+	assert.False(t, client.Crashed())
+	assert.Len(t, server.Received("initialize"), 2)
+	assert.Eventually(t, func() bool { return len(server.Received("textDocument/didOpen")) == 2 }, time.Second, time.Millisecond)
+	assert.True(t, client.IsFileOpen(path))
+	_, err = client.Hover(ctx, protocol.HoverParams{})
+	assert.NoError(t, err)
+
+	// Shutting down isn't a crash
+	require.NoError(t, client.Shutdown(ctx))
+	require.NoError(t, client.Exit(ctx))
+	proxy.Crash()
+	<-client.Done()
+	assert.False(t, client.Crashed())
+}
+
+func TestRestartStream(t *testing.T) {
+	client := Start(t, hoverServer(), t.TempDir())
+	_, err := client.Restart(context.Background())
+	assert.ErrorContains(t, err, "can't be restarted")
+}
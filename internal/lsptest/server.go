@@ -0,0 +1,278 @@
+// Package lsptest runs a scripted language server in the same process, so
+// that code using an lsp.Client can be tested in milliseconds without
+// starting gopls or another real server. Requests are answered from
+// canned results or handler functions per method, and everything the
+// client sends is recorded for assertions. A Proxy between the client and
+// the server injects faults, such as dropped or malformed responses and
+// crashes, to test how the client copes with a misbehaving server.
+package lsptest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// JSON-RPC error codes used in responses
+const (
+	codeMethodNotFound = -32601
+	codeInternalError  = -32603
+)
+
+// Handler answers a request. Returning an *lsp.ResponseError sends its
+// code, and other errors are sent as internal errors.
+type Handler func(params json.RawMessage) (any, error)
+
+// NotificationHandler is called for each notification of a method, after
+// it has been recorded
+type NotificationHandler func(params json.RawMessage)
+
+// Message is a request or notification the client sent
+type Message struct {
+	Method string
+	Params json.RawMessage
+}
+
+// Server is a scripted language server. Configure it with Handle, Respond
+// and OnNotification, then connect a client with Start or Serve.
+type Server struct {
+	mu            sync.Mutex
+	capabilities  protocol.ServerCapabilities
+	handlers      map[string]Handler
+	notifications map[string]NotificationHandler
+	received      []Message
+	// pending holds the channels waiting for responses to Request, by ID
+	pending map[string]chan *lsp.Message
+	nextID  int32
+
+	writeMu sync.Mutex
+	writer  io.Writer
+}
+
+// NewServer returns a server that answers initialize with the given
+// capabilities and shutdown with null. Other requests fail with "method
+// not found" until they are given a handler.
+func NewServer(capabilities protocol.ServerCapabilities) *Server {
+	s := &Server{
+		capabilities:  capabilities,
+		handlers:      make(map[string]Handler),
+		notifications: make(map[string]NotificationHandler),
+		pending:       make(map[string]chan *lsp.Message),
+	}
+	s.Handle("initialize", func(json.RawMessage) (any, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return protocol.InitializeResult{
+			Capabilities: s.capabilities,
+			ServerInfo:   &protocol.ServerInfo{Name: "lsptest"},
+		}, nil
+	})
+	s.Respond("shutdown", nil)
+	return s
+}
+
+// Handle answers requests for method with handler, replacing any earlier
+// handler or canned result
+func (s *Server) Handle(method string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = handler
+}
+
+// Respond answers every request for method with result
+func (s *Server) Respond(method string, result any) {
+	s.Handle(method, func(json.RawMessage) (any, error) { return result, nil })
+}
+
+// OnNotification calls handler for each notification of method, for
+// instance to publish diagnostics when a file is opened
+func (s *Server) OnNotification(method string, handler NotificationHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifications[method] = handler
+}
+
+// Received returns the requests and notifications of method the client has
+// sent, in order. An empty method returns every message.
+func (s *Server) Received(method string) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var messages []Message
+	for _, msg := range s.received {
+		if method == "" || msg.Method == method {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// Notify sends a notification to the client, such as
+// textDocument/publishDiagnostics
+func (s *Server) Notify(method string, params any) error {
+	msg, err := lsp.NewNotification(method, params)
+	if err != nil {
+		return err
+	}
+	return s.write(msg)
+}
+
+// PublishDiagnostics sends the diagnostics of a file to the client
+func (s *Server) PublishDiagnostics(uri protocol.DocumentUri, diagnostics []protocol.Diagnostic) error {
+	if diagnostics == nil {
+		diagnostics = []protocol.Diagnostic{}
+	}
+	return s.Notify("textDocument/publishDiagnostics", protocol.PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: diagnostics,
+	})
+}
+
+// Request sends a request to the client, such as workspace/applyEdit, and
+// decodes its response into result
+func (s *Server) Request(ctx context.Context, method string, params any, result any) error {
+	s.mu.Lock()
+	s.nextID++
+	id := fmt.Sprintf("lsptest-%d", s.nextID)
+	ch := make(chan *lsp.Message, 1)
+	s.pending[id] = ch
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	msg, err := lsp.NewRequest(id, method, params)
+	if err != nil {
+		return err
+	}
+	if err := s.write(msg); err != nil {
+		return err
+	}
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Serve answers the messages read from conn until it is closed. Requests
+// are handled concurrently, so a handler may call Request.
+func (s *Server) Serve(conn io.ReadWriter) error {
+	s.writeMu.Lock()
+	s.writer = conn
+	s.writeMu.Unlock()
+
+	reader := bufio.NewReader(conn)
+	for {
+		msg, err := lsp.ReadMessage(reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrClosedPipe) || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		s.dispatch(msg)
+	}
+}
+
+// dispatch records and answers one message from the client
+func (s *Server) dispatch(msg *lsp.Message) {
+	isCall := msg.ID != nil && msg.ID.Value != nil
+	if !isCall || msg.Method != "" {
+		s.mu.Lock()
+		s.received = append(s.received, Message{Method: msg.Method, Params: msg.Params})
+		s.mu.Unlock()
+	}
+
+	switch {
+	case isCall && msg.Method == "":
+		// A response to Request
+		s.mu.Lock()
+		ch, ok := s.pending[msg.ID.String()]
+		s.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+	case isCall:
+		s.mu.Lock()
+		handler, ok := s.handlers[msg.Method]
+		s.mu.Unlock()
+		go s.answer(msg, handler, ok)
+	default:
+		s.mu.Lock()
+		handler, ok := s.notifications[msg.Method]
+		s.mu.Unlock()
+		if ok {
+			handler(msg.Params)
+		}
+	}
+}
+
+// answer sends the response to a request
+func (s *Server) answer(msg *lsp.Message, handler Handler, ok bool) {
+	response := &lsp.Message{JSONRPC: "2.0", ID: msg.ID}
+	if !ok {
+		response.Error = &lsp.ResponseError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+	} else if result, err := handler(msg.Params); err != nil {
+		var responseErr *lsp.ResponseError
+		if !errors.As(err, &responseErr) {
+			responseErr = &lsp.ResponseError{Code: codeInternalError, Message: err.Error()}
+		}
+		response.Error = responseErr
+	} else if response.Result, err = json.Marshal(result); err != nil {
+		response.Error = &lsp.ResponseError{Code: codeInternalError, Message: err.Error()}
+	}
+	_ = s.write(response)
+}
+
+func (s *Server) write(msg *lsp.Message) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if s.writer == nil {
+		return errors.New("lsptest: no client is connected")
+	}
+	return lsp.WriteMessage(s.writer, msg)
+}
+
+// Start connects a client to the server over an in-memory pipe and
+// initializes it with workspaceDir. The client is closed when the test
+// ends.
+func Start(t testing.TB, server *Server, workspaceDir string) *lsp.Client {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		if err := server.Serve(serverConn); err != nil {
+			t.Errorf("lsptest: %v", err)
+		}
+	}()
+
+	client := lsp.ConnectStream(clientConn, nil)
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = serverConn.Close()
+		<-served
+	})
+	if _, err := client.InitializeLSPClient(context.Background(), workspaceDir); err != nil {
+		t.Fatalf("lsptest: %v", err)
+	}
+	return client
+}
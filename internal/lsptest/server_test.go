@@ -0,0 +1,76 @@
+package lsptest
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+	uri := protocol.DocumentUri("file://" + path)
+
+	server := NewServer(protocol.ServerCapabilities{HoverProvider: &protocol.Or_ServerCapabilities_hoverProvider{Value: true}})
+	server.Respond("textDocument/hover", protocol.Hover{
+		Contents: protocol.Or_Hover_contents{Value: protocol.MarkupContent{Kind: protocol.Markdown, Value: "package main"}},
+	})
+	server.Handle("textDocument/definition", func(json.RawMessage) (any, error) {
+		return nil, &lsp.ResponseError{Code: int(protocol.ContentModified), Message: "busy"}
+	})
+	server.OnNotification("textDocument/didOpen", func(params json.RawMessage) {
+		var open protocol.DidOpenTextDocumentParams
+		require.NoError(t, json.Unmarshal(params, &open))
+		go func() {
+			_ = server.PublishDiagnostics(open.TextDocument.URI, []protocol.Diagnostic{{Message: "unused"}})
+		}()
+	})
+
+	client := Start(t, server, dir)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Requests and notifications are recorded
+	initialize := server.Received("initialize")
+	require.Len(t, initialize, 1)
+	assert.Contains(t, string(initialize[0].Params), dir)
+	assert.Eventually(t, func() bool { return len(server.Received("initialized")) == 1 }, time.Second, time.Millisecond)
+
+	// Canned results
+	require.NoError(t, client.OpenFile(ctx, path))
+	hover, err := client.Hover(ctx, protocol.HoverParams{})
+	require.NoError(t, err)
+	assert.Equal(t, "package main", hover.ToString())
+
+	// Errors keep their codes
+	_, err = client.Definition(ctx, protocol.DefinitionParams{})
+	assert.ErrorIs(t, err, lsp.ErrContentModified)
+
+	// Requests without a handler aren't found
+	_, err = client.References(ctx, protocol.ReferenceParams{})
+	assert.ErrorContains(t, err, "method not found: textDocument/references")
+
+	// Notifications reach the client
+	assert.Eventually(t, func() bool { return len(client.GetFileDiagnostics(uri)) == 1 }, time.Second, time.Millisecond)
+
+	// Requests to the client are answered
+	var applied protocol.ApplyWorkspaceEditResult
+	require.NoError(t, server.Request(ctx, "workspace/applyEdit", protocol.ApplyWorkspaceEditParams{
+		Edit: protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			uri: {{Range: protocol.Range{Start: protocol.Position{Line: 1}, End: protocol.Position{Line: 1}}, NewText: "func main() {}\n"}},
+		}},
+	}, &applied))
+	assert.True(t, applied.Applied)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "package main\nfunc main() {}\n", string(content))
+}
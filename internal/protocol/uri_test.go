@@ -0,0 +1,41 @@
+package protocol
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDocumentUriWindows(t *testing.T) {
+	for _, raw := range []string{
+		"file:///c%3A/Users/me/main.go", // VS Code escapes the colon
+		"file:///c:/Users/me/main.go",
+		"file:///C:/Users/me/main.go",
+		"file://C:/Users/me/main.go",
+	} {
+		uri, err := ParseDocumentUri(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, DocumentUri("file:///C:/Users/me/main.go"), uri, raw)
+		assert.Equal(t, filepath.FromSlash("C:/Users/me/main.go"), uri.Path(), raw)
+	}
+}
+
+func TestURIFromPath(t *testing.T) {
+	uri := URIFromPath("c:/Users/me/my project/main.go")
+	assert.Equal(t, DocumentUri("file:///C:/Users/me/my%20project/main.go"), uri)
+	assert.Equal(t, filepath.FromSlash("C:/Users/me/my project/main.go"), uri.Path())
+
+	if runtime.GOOS == "windows" {
+		uri = URIFromPath(`C:\Users\me\main.go`)
+		assert.Equal(t, DocumentUri("file:///C:/Users/me/main.go"), uri)
+		assert.Equal(t, `C:\Users\me\main.go`, uri.Path())
+		return
+	}
+	uri = URIFromPath("/home/me/my project/main.go")
+	assert.Equal(t, DocumentUri("file:///home/me/my%20project/main.go"), uri)
+	assert.Equal(t, "/home/me/my project/main.go", uri.Path())
+	assert.Equal(t, "/home/me/my project", uri.DirPath())
+}
@@ -0,0 +1,185 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// BuildDiagnosticSource is the source of diagnostics parsed from build output
+const BuildDiagnosticSource = "build"
+
+// buildCommands are tried in order; the first whose marker file exists in the
+// workspace is used
+var buildCommands = []struct {
+	marker  string
+	command string
+}{
+	{"go.mod", "go build ./..."},
+	{"Cargo.toml", "cargo build"},
+	{"package.json", "npm run build"},
+	{"Makefile", "make"},
+}
+
+// DetectBuildCommand picks a build command from the project files in the
+// workspace root. It returns an empty string if the project type is unknown.
+func DetectBuildCommand(workspaceDir string) string {
+	for _, candidate := range buildCommands {
+		if _, err := os.Stat(filepath.Join(workspaceDir, candidate.marker)); err == nil {
+			return candidate.command
+		}
+	}
+	return ""
+}
+
+// BuildCommand splits a configured build command into arguments. An empty
+// template or "auto" detects the command from the workspace.
+func BuildCommand(workspaceDir, template string) ([]string, error) {
+	if template == "" || template == "auto" {
+		template = DetectBuildCommand(workspaceDir)
+		if template == "" {
+			return nil, fmt.Errorf("could not detect a build command for %s, configure one with --build-command", workspaceDir)
+		}
+	}
+	args := strings.Fields(template)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty build command")
+	}
+	return args, nil
+}
+
+// BuildDiagnostics are the problems reported by a build, in the same form as
+// language server diagnostics
+type BuildDiagnostics struct {
+	Files map[protocol.DocumentUri][]protocol.Diagnostic
+	// Unlocated holds errors that don't point at a source line, such as
+	// link errors
+	Unlocated []string
+}
+
+// Count returns the number of located diagnostics
+func (d *BuildDiagnostics) Count() int {
+	count := 0
+	for _, diagnostics := range d.Files {
+		count += len(diagnostics)
+	}
+	return count
+}
+
+var (
+	// path:line:col: severity: message, as printed by go, gcc, clang and
+	// javac (without column), among others
+	fileLineColRegex = regexp.MustCompile(`^\s*([^\s:(][^:(]*?):(\d+):(?:(\d+):)?\s*(?:(fatal error|error|warning|note|info)(?:\[([^\]]*)\])?:\s*)?(.+)$`)
+	// path(line,col): severity CODE: message, as printed by tsc and msbuild
+	parenLocationRegex = regexp.MustCompile(`^\s*(.+?)\((\d+),(\d+)\):\s*(error|warning)\s*([A-Z]+\d+)?:\s*(.+)$`)
+	// rustc prints the message first and the location on a later line
+	rustHeaderRegex   = regexp.MustCompile(`^(error|warning)(?:\[(E\d+)\])?:\s*(.+)$`)
+	rustLocationRegex = regexp.MustCompile(`^\s*-->\s*(.+?):(\d+):(\d+)\s*$`)
+	// Errors with no location that language servers never see
+	unlocatedErrorRegex = regexp.MustCompile(`(?i)^(fatal|error)\b|undefined reference to|ld returned|linker command failed|collect2:|undefined symbols? for architecture`)
+)
+
+// ParseBuildOutput extracts diagnostics from compiler output. Relative paths
+// are resolved against dir, and only locations that exist on disk are kept so
+// that unrelated output isn't mistaken for a diagnostic.
+func ParseBuildOutput(output, dir string) *BuildDiagnostics {
+	result := &BuildDiagnostics{Files: make(map[protocol.DocumentUri][]protocol.Diagnostic)}
+	seen := make(map[string]bool)
+
+	add := func(path, line, col, severity, code, message string) bool {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			return false
+		}
+		lineNum, _ := strconv.Atoi(line)
+		colNum, _ := strconv.Atoi(col)
+		if lineNum > 0 {
+			lineNum--
+		}
+		if colNum > 0 {
+			colNum--
+		}
+		message = strings.TrimSpace(message)
+		key := fmt.Sprintf("%s:%d:%d:%s", path, lineNum, colNum, message)
+		if seen[key] {
+			return true
+		}
+		seen[key] = true
+
+		diag := protocol.Diagnostic{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: uint32(lineNum), Character: uint32(colNum)},
+				End:   protocol.Position{Line: uint32(lineNum), Character: uint32(colNum)},
+			},
+			Severity: buildSeverity(severity),
+			Source:   BuildDiagnosticSource,
+			Message:  message,
+		}
+		if code != "" {
+			diag.Code = code
+		}
+		uri := protocol.URIFromPath(filepath.Clean(path))
+		result.Files[uri] = append(result.Files[uri], diag)
+		return true
+	}
+
+	// pending is a rustc message waiting for its --> location line
+	var pending []string
+	flushPending := func() {
+		if pending != nil && pending[0] == "error" {
+			result.Unlocated = append(result.Unlocated, pending[2])
+		}
+		pending = nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if m := rustLocationRegex.FindStringSubmatch(line); m != nil && pending != nil {
+			if add(m[1], m[2], m[3], pending[0], pending[1], pending[2]) {
+				pending = nil
+			}
+			continue
+		}
+		if m := rustHeaderRegex.FindStringSubmatch(line); m != nil {
+			flushPending()
+			pending = []string{m[1], m[2], m[3]}
+			continue
+		}
+		if m := parenLocationRegex.FindStringSubmatch(line); m != nil {
+			if add(m[1], m[2], m[3], m[4], m[5], m[6]) {
+				continue
+			}
+		}
+		if m := fileLineColRegex.FindStringSubmatch(line); m != nil {
+			if add(m[1], m[2], m[3], m[4], m[5], m[6]) {
+				continue
+			}
+		}
+		if unlocatedErrorRegex.MatchString(strings.TrimSpace(line)) {
+			result.Unlocated = append(result.Unlocated, strings.TrimSpace(line))
+		}
+	}
+	flushPending()
+
+	return result
+}
+
+func buildSeverity(severity string) protocol.DiagnosticSeverity {
+	switch severity {
+	case "warning":
+		return protocol.SeverityWarning
+	case "note", "info":
+		return protocol.SeverityInformation
+	default:
+		// Tools like the go compiler don't label errors
+		return protocol.SeverityError
+	}
+}
@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSourceFiles(t *testing.T, names ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("\n"), 0644))
+	}
+	return dir
+}
+
+func TestParseBuildOutputGoAndGCC(t *testing.T) {
+	dir := writeSourceFiles(t, "internal/app/main.go", "src/util.c")
+	output := `# example.com/app/internal/app
+internal/app/main.go:12:5: undefined: foo
+src/util.c:3:10: warning: unused variable 'x' [-Wunused-variable]
+src/util.c:7:1: error: expected ';' before '}' token
+/usr/bin/ld: util.o: in function ` + "`main'" + `: undefined reference to ` + "`missing'" + `
+collect2: error: ld returned 1 exit status
+ok  	example.com/app/internal/other	0.1s
+`
+	diags := ParseBuildOutput(output, dir)
+	require.Equal(t, 3, diags.Count())
+
+	goDiags := diags.Files[protocol.DocumentUri("file://"+filepath.Join(dir, "internal/app/main.go"))]
+	require.Len(t, goDiags, 1)
+	assert.Equal(t, "undefined: foo", goDiags[0].Message)
+	assert.Equal(t, protocol.SeverityError, goDiags[0].Severity)
+	assert.Equal(t, uint32(11), goDiags[0].Range.Start.Line)
+	assert.Equal(t, uint32(4), goDiags[0].Range.Start.Character)
+	assert.Equal(t, BuildDiagnosticSource, goDiags[0].Source)
+
+	cDiags := diags.Files[protocol.DocumentUri("file://"+filepath.Join(dir, "src/util.c"))]
+	require.Len(t, cDiags, 2)
+	assert.Equal(t, protocol.SeverityWarning, cDiags[0].Severity)
+
+	assert.Len(t, diags.Unlocated, 2)
+	assert.Contains(t, diags.Unlocated[0], "undefined reference to")
+}
+
+func TestParseBuildOutputRustAndTypeScript(t *testing.T) {
+	dir := writeSourceFiles(t, "src/main.rs", "web/app.ts")
+	output := `error[E0308]: mismatched types
+ --> src/main.rs:4:18
+  |
+4 |     let x: i32 = "a";
+  |                  ^^^ expected ` + "`i32`" + `
+error: could not compile ` + "`app`" + ` due to previous error
+web/app.ts(10,3): error TS2322: Type 'string' is not assignable to type 'number'.
+`
+	diags := ParseBuildOutput(output, dir)
+	require.Equal(t, 2, diags.Count())
+
+	rustDiags := diags.Files[protocol.DocumentUri("file://"+filepath.Join(dir, "src/main.rs"))]
+	require.Len(t, rustDiags, 1)
+	assert.Equal(t, "mismatched types", rustDiags[0].Message)
+	assert.Equal(t, "E0308", rustDiags[0].Code)
+	assert.Equal(t, uint32(3), rustDiags[0].Range.Start.Line)
+
+	tsDiags := diags.Files[protocol.DocumentUri("file://"+filepath.Join(dir, "web/app.ts"))]
+	require.Len(t, tsDiags, 1)
+	assert.Equal(t, "TS2322", tsDiags[0].Code)
+
+	assert.Equal(t, []string{"could not compile `app` due to previous error"}, diags.Unlocated)
+}
+
+func TestBuildCommand(t *testing.T) {
+	dir := writeSourceFiles(t, "Cargo.toml")
+	args, err := BuildCommand(dir, "auto")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cargo", "build"}, args)
+
+	args, err = BuildCommand(dir, "make -j4 all")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"make", "-j4", "all"}, args)
+
+	_, err = BuildCommand(t.TempDir(), "")
+	assert.Error(t, err)
+}
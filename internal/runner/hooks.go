@@ -0,0 +1,34 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FilesPlaceholder marks where the edited files go in a post-edit hook
+// command
+const FilesPlaceholder = "{files}"
+
+// HookCommand builds the command line of a post-edit hook. template is split
+// on whitespace; {files} is replaced by the files, or the files are appended
+// if the template has no placeholder.
+func HookCommand(template string, files []string) ([]string, error) {
+	fields := strings.Fields(template)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty hook command")
+	}
+	var args []string
+	replaced := false
+	for _, field := range fields {
+		if field == FilesPlaceholder {
+			replaced = true
+			args = append(args, files...)
+			continue
+		}
+		args = append(args, field)
+	}
+	if !replaced {
+		args = append(args, files...)
+	}
+	return args, nil
+}
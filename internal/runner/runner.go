@@ -0,0 +1,149 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/logging"
+)
+
+var runnerLogger = logging.NewLogger(logging.Runner)
+
+// DefaultMaxOutput is the number of bytes of command output kept in a result
+const DefaultMaxOutput = 32 * 1024
+
+// waitDelay is how long to wait for a killed command's children to release
+// its output before giving up on them
+const waitDelay = 5 * time.Second
+
+// Result is the outcome of a command that ran to completion or was stopped
+type Result struct {
+	Args     []string
+	Dir      string
+	ExitCode int
+	// Output is the combined stdout and stderr of the command
+	Output string
+	// Truncated is set when the middle of the output was dropped
+	Truncated bool
+	TimedOut  bool
+	Duration  time.Duration
+}
+
+// Run executes a command in dir and captures its output, keeping the start
+// and, since failures are usually reported last, most of the end of the output
+// when it is longer than maxOutput bytes. A non-zero exit status is reported
+// in the result; an error means the command could not be run at all.
+func Run(ctx context.Context, dir string, args []string, timeout time.Duration, maxOutput int) (*Result, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	output := newHeadTailBuffer(maxOutput)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.WaitDelay = waitDelay
+
+	runnerLogger.Info("Running %s in %s", strings.Join(args, " "), dir)
+	start := time.Now()
+	err := cmd.Run()
+	result := &Result{
+		Args:     args,
+		Dir:      dir,
+		Duration: time.Since(start),
+		TimedOut: errors.Is(ctx.Err(), context.DeadlineExceeded),
+	}
+	result.Output, result.Truncated = output.String()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case result.TimedOut || errors.Is(err, exec.ErrWaitDelay):
+		result.ExitCode = -1
+	default:
+		return nil, fmt.Errorf("failed to run %s: %w", args[0], err)
+	}
+
+	runnerLogger.Info("%s exited with status %d after %s", args[0], result.ExitCode, result.Duration.Round(time.Millisecond))
+	return result, nil
+}
+
+// Format renders the result for a tool response
+func (r *Result) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Command: %s\n", strings.Join(r.Args, " "))
+	fmt.Fprintf(&b, "Directory: %s\n", r.Dir)
+	switch {
+	case r.TimedOut:
+		fmt.Fprintf(&b, "Status: timed out after %s\n", r.Duration.Round(time.Second))
+	case r.ExitCode == 0:
+		fmt.Fprintf(&b, "Status: passed (exit status 0) in %s\n", r.Duration.Round(time.Millisecond))
+	default:
+		fmt.Fprintf(&b, "Status: failed (exit status %d) in %s\n", r.ExitCode, r.Duration.Round(time.Millisecond))
+	}
+	if r.Truncated {
+		b.WriteString("Output was truncated, the middle has been omitted\n")
+	}
+	b.WriteString("\nOutput:\n")
+	if r.Output == "" {
+		b.WriteString("(none)\n")
+	} else {
+		b.WriteString(r.Output)
+		if !strings.HasSuffix(r.Output, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// headTailBuffer keeps the first quarter and the last three quarters of a
+// bounded amount of output
+type headTailBuffer struct {
+	head      []byte
+	tail      []byte
+	headLimit int
+	tailLimit int
+	dropped   bool
+}
+
+func newHeadTailBuffer(limit int) *headTailBuffer {
+	if limit <= 0 {
+		limit = DefaultMaxOutput
+	}
+	return &headTailBuffer{headLimit: limit / 4, tailLimit: limit - limit/4}
+}
+
+func (b *headTailBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.headLimit - len(b.head); room > 0 {
+		take := min(room, len(p))
+		b.head = append(b.head, p[:take]...)
+		p = p[take:]
+	}
+	b.tail = append(b.tail, p...)
+	if over := len(b.tail) - b.tailLimit; over > 0 {
+		b.tail = append(b.tail[:0], b.tail[over:]...)
+		b.dropped = true
+	}
+	return n, nil
+}
+
+// String returns the kept output and whether anything was dropped
+func (b *headTailBuffer) String() (string, bool) {
+	if !b.dropped {
+		return string(b.head) + string(b.tail), false
+	}
+	return string(b.head) + "\n...\n" + string(b.tail), true
+}
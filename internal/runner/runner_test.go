@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunReportsExitStatus(t *testing.T) {
+	dir := t.TempDir()
+	result, err := Run(context.Background(), dir, []string{"sh", "-c", "echo out; echo err >&2; exit 3"}, time.Minute, DefaultMaxOutput)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Equal(t, dir, result.Dir)
+	assert.Contains(t, result.Output, "out\n")
+	assert.Contains(t, result.Output, "err\n")
+	assert.False(t, result.Truncated)
+	assert.Contains(t, result.Format(), "Status: failed (exit status 3)")
+}
+
+func TestRunTimesOut(t *testing.T) {
+	result, err := Run(context.Background(), t.TempDir(), []string{"sleep", "10"}, 100*time.Millisecond, DefaultMaxOutput)
+	require.NoError(t, err)
+	assert.True(t, result.TimedOut)
+	assert.Contains(t, result.Format(), "Status: timed out")
+}
+
+func TestRunMissingCommand(t *testing.T) {
+	_, err := Run(context.Background(), t.TempDir(), []string{"definitely-not-a-command"}, time.Minute, DefaultMaxOutput)
+	assert.Error(t, err)
+}
+
+func TestHeadTailBufferKeepsEnds(t *testing.T) {
+	b := newHeadTailBuffer(100)
+	for i := 0; i < 50; i++ {
+		_, _ = b.Write([]byte("0123456789"))
+	}
+	_, _ = b.Write([]byte("FAIL"))
+
+	output, truncated := b.String()
+	assert.True(t, truncated)
+	assert.True(t, strings.HasPrefix(output, "0123456789"))
+	assert.True(t, strings.HasSuffix(output, "FAIL"))
+	assert.LessOrEqual(t, len(output), 100+len("\n...\n"))
+}
+
+func TestTestCommand(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg", "store"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "store", "store_test.go"), []byte("package store\n"), 0644))
+
+	args, err := TestCommand(dir, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "test", "./..."}, args)
+
+	args, err = TestCommand(dir, "auto", "pkg/store/store_test.go")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "test", "./pkg/store"}, args)
+
+	args, err = TestCommand(dir, "make test", "unit")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"make", "test", "unit"}, args)
+
+	args, err = TestCommand(dir, "pytest -k {target} -q", "parse")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pytest", "-k", "parse", "-q"}, args)
+
+	_, err = TestCommand(dir, "", "-exec=rm")
+	assert.Error(t, err)
+
+	_, err = TestCommand(t.TempDir(), "", "")
+	assert.Error(t, err)
+}
+
+func TestHookCommand(t *testing.T) {
+	files := []string{"a.go", "pkg/b.go"}
+
+	args, err := HookCommand("goimports -w", files)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"goimports", "-w", "a.go", "pkg/b.go"}, args)
+
+	args, err = HookCommand("npx prettier --write {files} --log-level warn", files)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"npx", "prettier", "--write", "a.go", "pkg/b.go", "--log-level", "warn"}, args)
+
+	_, err = HookCommand("  ", files)
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TargetPlaceholder marks where the target goes in a configured command
+const TargetPlaceholder = "{target}"
+
+// testCommands are tried in order; the first whose marker file exists in the
+// workspace is used
+var testCommands = []struct {
+	markers []string
+	command string
+}{
+	{[]string{"go.mod"}, "go test {target}"},
+	{[]string{"Cargo.toml"}, "cargo test {target}"},
+	{[]string{"pytest.ini", "pyproject.toml", "setup.py", "setup.cfg", "tox.ini"}, "python -m pytest {target}"},
+	{[]string{"package.json"}, "npm test -- {target}"},
+}
+
+// DetectTestCommand picks a test command from the project files in the
+// workspace root. It returns an empty string if the project type is unknown.
+func DetectTestCommand(workspaceDir string) string {
+	for _, candidate := range testCommands {
+		for _, marker := range candidate.markers {
+			if _, err := os.Stat(filepath.Join(workspaceDir, marker)); err == nil {
+				return candidate.command
+			}
+		}
+	}
+	return ""
+}
+
+// TestCommand builds the command line that runs the tests for target.
+// template is split on whitespace; {target} is replaced by the target, or the
+// target is appended if the template has no placeholder. An empty template or
+// "auto" detects the command from the workspace.
+func TestCommand(workspaceDir, template, target string) ([]string, error) {
+	if template == "" || template == "auto" {
+		template = DetectTestCommand(workspaceDir)
+		if template == "" {
+			return nil, fmt.Errorf("could not detect a test command for %s, configure one with --test-command", workspaceDir)
+		}
+	}
+	if strings.HasPrefix(target, "-") {
+		return nil, fmt.Errorf("target must not start with -: %s", target)
+	}
+
+	fields := strings.Fields(template)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty test command")
+	}
+	if fields[0] == "go" && target == "" {
+		target = "./..."
+	} else if fields[0] == "go" {
+		target = goPackageTarget(workspaceDir, target)
+	}
+
+	var args []string
+	replaced := false
+	for _, field := range fields {
+		if field == TargetPlaceholder {
+			replaced = true
+			if target != "" {
+				args = append(args, target)
+			}
+			continue
+		}
+		if strings.Contains(field, TargetPlaceholder) {
+			replaced = true
+			field = strings.ReplaceAll(field, TargetPlaceholder, target)
+		}
+		args = append(args, field)
+	}
+	if !replaced && target != "" {
+		args = append(args, target)
+	}
+	return args, nil
+}
+
+// goPackageTarget turns a file or directory into the package pattern go test
+// expects. Package patterns are passed through.
+func goPackageTarget(workspaceDir, target string) string {
+	path := target
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workspaceDir, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return target
+	}
+	if !info.IsDir() {
+		path = filepath.Dir(path)
+	}
+	rel, err := filepath.Rel(workspaceDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return target
+	}
+	if rel == "." {
+		return "."
+	}
+	return "./" + filepath.ToSlash(rel)
+}
@@ -0,0 +1,148 @@
+// Package sandbox runs language servers with access to the filesystem
+// limited to the workspace and the toolchain, and without network access,
+// for operators pointing agents at code they don't trust.
+package sandbox
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Subcommand is the command of this program that runs a command in the
+// sandbox, which the server starts sandboxed language servers through
+const Subcommand = "sandbox"
+
+// ErrUnsupported is returned where the sandbox can't be set up
+var ErrUnsupported = errors.New("sandboxing is not supported on this system")
+
+// systemDirs are readable in every sandbox: the programs, libraries and
+// configuration of the system and the kernel's views of processes and
+// devices
+var systemDirs = []string{"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32", "/etc", "/opt", "/nix", "/proc", "/sys", "/dev"}
+
+// toolchainVariables name directories that toolchains are installed in
+var toolchainVariables = []string{
+	"GOROOT", "GOPATH", "GOMODCACHE", "CARGO_HOME", "RUSTUP_HOME", "JAVA_HOME", "NVM_DIR",
+	"PYENV_ROOT", "VIRTUAL_ENV", "CONDA_PREFIX", "GEM_HOME", "SOURCEKIT_TOOLCHAIN_PATH",
+}
+
+// toolchainHomes are the directories under the home directory that
+// toolchains install to by default
+var toolchainHomes = []string{"go", ".cargo", ".rustup", ".nvm", ".local", ".npm", ".pyenv", ".rbenv", ".gem", ".m2", ".gradle", ".dotnet", ".ghcup"}
+
+// Policy lists what a sandboxed process may access. Any other file is out
+// of reach, and so is the network.
+type Policy struct {
+	// Read lists directories and files that may be read and executed
+	Read []string
+	// Write lists directories and files that may also be changed
+	Write []string
+	// Cache is a writable cache directory of the sandboxed program's own,
+	// which it is given as XDG_CACHE_HOME
+	Cache string
+}
+
+// ForWorkspace returns the policy of a language server working on a
+// workspace: the workspace, the temporary directory and a cache directory
+// of the server's own are writable, and the system, the toolchains in env
+// and on its PATH, and the directory the server is installed in are
+// readable. The rest of the user cache directory stays out of reach, as it
+// holds this program's saved sessions among others.
+func ForWorkspace(workspaceDir, executable string, env []string) Policy {
+	policy := Policy{Write: []string{workspaceDir, os.TempDir(), "/dev/null"}}
+	if cache, err := serverCacheDir(executable); err == nil {
+		policy.Cache = cache
+		policy.Write = append(policy.Write, cache)
+	}
+
+	policy.Read = append(policy.Read, systemDirs...)
+	lookup := func(key string) string {
+		for _, entry := range env {
+			if k, v, ok := strings.Cut(entry, "="); ok && k == key {
+				return v
+			}
+		}
+		return ""
+	}
+	for _, key := range toolchainVariables {
+		if dir := lookup(key); dir != "" {
+			policy.Read = append(policy.Read, filepath.SplitList(dir)...)
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, dir := range toolchainHomes {
+			policy.Read = append(policy.Read, filepath.Join(home, dir))
+		}
+	}
+	policy.Read = append(policy.Read, filepath.SplitList(lookup("PATH"))...)
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		policy.Read = append(policy.Read, installDir(resolved))
+	}
+	return policy.clean()
+}
+
+// serverCacheDir creates the cache directory of a sandboxed server, named
+// after its executable
+func serverCacheDir(executable string) (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(executable)
+	if executable == "" {
+		name = "server"
+	}
+	dir := filepath.Join(cache, "mcp-language-server", "sandbox", name)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// installDir returns the directory a program is installed in. A bin
+// directory is usually next to the libraries the program loads, as with
+// npm packages, so its parent is taken, unless that is the root.
+func installDir(executable string) string {
+	dir := filepath.Dir(executable)
+	if parent := filepath.Dir(dir); filepath.Base(dir) == "bin" && parent != filepath.Dir(parent) {
+		return parent
+	}
+	return dir
+}
+
+// clean drops relative and duplicate paths, which can't be allowed in a
+// sandbox that changes neither directory nor root
+func (p Policy) clean() Policy {
+	keep := func(paths []string) []string {
+		var kept []string
+		for _, path := range paths {
+			if filepath.IsAbs(path) && !slices.Contains(kept, filepath.Clean(path)) {
+				kept = append(kept, filepath.Clean(path))
+			}
+		}
+		return kept
+	}
+	return Policy{Read: keep(p.Read), Write: keep(p.Write), Cache: p.Cache}
+}
+
+// Command returns the executable and arguments that run a command in the
+// sandbox: this program's sandbox subcommand. The process has to be started
+// with the attributes of SysProcAttr.
+func (p Policy) Command(path string, args []string) (string, []string, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return "", nil, err
+	}
+	wrapped := []string{Subcommand}
+	for _, dir := range p.Read {
+		wrapped = append(wrapped, "--read", dir)
+	}
+	for _, dir := range p.Write {
+		wrapped = append(wrapped, "--write", dir)
+	}
+	wrapped = append(append(wrapped, "--", path), args...)
+	return self, wrapped, nil
+}
@@ -0,0 +1,233 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Access rights, with the Landlock ABI versions that added them
+const (
+	readAccess = unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_READ_DIR
+	// fileAccess are the rights that apply to files rather than directories
+	fileAccess = unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_WRITE_FILE | unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_TRUNCATE | unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+	accessV1 = unix.LANDLOCK_ACCESS_FS_MAKE_SYM<<1 - 1
+	accessV2 = accessV1 | unix.LANDLOCK_ACCESS_FS_REFER
+	accessV3 = accessV2 | unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	accessV5 = accessV3 | unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+)
+
+// Check reports whether the kernel can sandbox processes: it needs Landlock
+// to restrict the filesystem, seccomp to keep unix sockets out of reach and
+// user namespaces to cut off the network
+func Check() error {
+	if _, err := landlockABI(); err != nil {
+		return err
+	}
+	if _, err := socketFilter(); err != nil {
+		return err
+	}
+	cmd := exec.Command("true")
+	cmd.SysProcAttr = SysProcAttr()
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: failed to create user and network namespaces: %v", ErrUnsupported, err)
+	}
+	return nil
+}
+
+// landlockABI returns the version of Landlock the kernel implements
+func landlockABI() (int, error) {
+	version, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0, fmt.Errorf("%w: Landlock is not enabled in the kernel: %v", ErrUnsupported, errno)
+	}
+	return int(version), nil
+}
+
+// SysProcAttr puts a process in new user and network namespaces, where the
+// only network is a loopback device that is down. The user namespace maps
+// the process's own user and group, so files keep their owner.
+func SysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}},
+	}
+}
+
+// Exec restricts this process to the policy and replaces it with a
+// command. It only returns on failure.
+func Exec(policy Policy, path string, args []string) error {
+	abi, err := landlockABI()
+	if err != nil {
+		return err
+	}
+	handled := uint64(accessV1)
+	switch {
+	case abi >= 5:
+		handled = accessV5
+	case abi >= 3:
+		handled = accessV3
+	case abi >= 2:
+		handled = accessV2
+	}
+
+	// Landlock restricts the calling thread, which the command then
+	// replaces the process from
+	runtime.LockOSThread()
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	ruleset, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create Landlock ruleset: %v", errno)
+	}
+	defer unix.Close(int(ruleset))
+	for _, dir := range policy.Read {
+		if err := allow(int(ruleset), dir, readAccess&handled); err != nil {
+			return err
+		}
+	}
+	for _, dir := range policy.Write {
+		if err := allow(int(ruleset), dir, handled); err != nil {
+			return err
+		}
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no_new_privs: %v", err)
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, ruleset, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to enforce Landlock ruleset: %v", errno)
+	}
+	if err := denyUnixSockets(); err != nil {
+		return err
+	}
+
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(resolved, append([]string{path}, args...), os.Environ())
+}
+
+// allow adds a rule giving access beneath a path. Paths that don't exist
+// are skipped.
+func allow(ruleset int, path string, access uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil
+	}
+	defer unix.Close(fd)
+	var stat unix.Stat_t
+	if err := unix.Fstat(fd, &stat); err != nil {
+		return fmt.Errorf("failed to stat %s: %v", path, err)
+	}
+	if stat.Mode&unix.S_IFMT != unix.S_IFDIR {
+		access &= fileAccess
+	}
+	rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+	if _, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(ruleset), unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to allow %s in the sandbox: %v", path, errno)
+	}
+	return nil
+}
+
+// denyUnixSockets installs a seccomp filter that stops the process from
+// creating unix sockets. Landlock doesn't cover connecting to a socket
+// file, and the network namespace only separates abstract sockets, so
+// without it the session bus, the Docker socket and the sockets of this
+// program's daemon would be in reach. Pairs of connected sockets, which
+// programs use to talk to their children, can still be created.
+func denyUnixSockets() error {
+	filter, err := socketFilter()
+	if err != nil {
+		return err
+	}
+	program := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&program)), 0, 0); err != nil {
+		return fmt.Errorf("failed to install seccomp filter: %v", err)
+	}
+	return nil
+}
+
+// socketFilter returns a seccomp program that fails socket(AF_UNIX, ...)
+// with EACCES, as well as io_uring_setup, since io_uring can create sockets
+// without the system call. System calls of another architecture, which
+// would number them differently, kill the process.
+func socketFilter() ([]unix.SockFilter, error) {
+	var arch uint32
+	switch runtime.GOARCH {
+	case "amd64":
+		arch = unix.AUDIT_ARCH_X86_64
+	case "arm64":
+		arch = unix.AUDIT_ARCH_AARCH64
+	case "riscv64":
+		arch = unix.AUDIT_ARCH_RISCV64
+	case "ppc64le":
+		arch = unix.AUDIT_ARCH_PPC64LE
+	default:
+		return nil, fmt.Errorf("%w: unix sockets can't be filtered on %s", ErrUnsupported, runtime.GOARCH)
+	}
+
+	// Offsets into struct seccomp_data; the architectures above are little
+	// endian, so the low half of the first argument comes first
+	const (
+		nrOffset   = 0
+		archOffset = 4
+		arg0Offset = 16
+	)
+	load := func(offset uint32) unix.SockFilter {
+		return unix.SockFilter{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: offset}
+	}
+	ret := func(action uint32) unix.SockFilter {
+		return unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: action}
+	}
+	// Jumps are patched to their targets once the program is complete
+	const (
+		toAllow = iota + 1
+		toDeny
+	)
+	jump := func(op uint16, value uint32, target uint8) unix.SockFilter {
+		return unix.SockFilter{Code: unix.BPF_JMP | op | unix.BPF_K, K: value, Jt: target}
+	}
+
+	filter := []unix.SockFilter{
+		load(archOffset),
+		{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, K: arch, Jt: 1},
+		ret(unix.SECCOMP_RET_KILL_PROCESS),
+		load(nrOffset),
+	}
+	patched := len(filter)
+	if runtime.GOARCH == "amd64" {
+		// x32 system calls are numbered from bit 30
+		filter = append(filter, jump(unix.BPF_JGE, 0x40000000, toDeny))
+	}
+	filter = append(filter,
+		jump(unix.BPF_JEQ, unix.SYS_IO_URING_SETUP, toDeny),
+		unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, K: unix.SYS_SOCKET, Jf: toAllow},
+		load(arg0Offset),
+		jump(unix.BPF_JEQ, unix.AF_UNIX, toDeny),
+	)
+	allow := len(filter)
+	filter = append(filter, ret(unix.SECCOMP_RET_ALLOW), ret(unix.SECCOMP_RET_ERRNO|uint32(unix.EACCES)))
+
+	for i := patched; i < allow; i++ {
+		if filter[i].Code&0x07 != unix.BPF_JMP {
+			continue
+		}
+		for _, offset := range []*uint8{&filter[i].Jt, &filter[i].Jf} {
+			switch *offset {
+			case toAllow:
+				*offset = uint8(allow - i - 1)
+			case toDeny:
+				*offset = uint8(allow - i)
+			}
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,88 @@
+package sandbox
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain runs the test binary as the sandbox subcommand when the tests
+// start it that way
+func TestMain(m *testing.M) {
+	if len(os.Args) == 3 && os.Args[1] == "dial" {
+		conn, err := net.Dial("unix", os.Args[2])
+		if err != nil {
+			os.Stderr.WriteString(err.Error())
+			os.Exit(1)
+		}
+		conn.Close()
+		os.Exit(0)
+	}
+	if dirs, ok := os.LookupEnv("SANDBOX_TEST_WRITE"); ok {
+		// The test binary is readable too, for tests that run it sandboxed
+		read := append(slices.Clone(systemDirs), filepath.Dir(os.Args[0]))
+		policy := Policy{Read: read, Write: strings.Split(dirs, ",")}
+		if err := Exec(policy, os.Args[1], os.Args[2:]); err != nil {
+			os.Stderr.WriteString(err.Error())
+			os.Exit(2)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+func TestExec(t *testing.T) {
+	if err := Check(); err != nil {
+		t.Skip(err)
+	}
+	allowed, denied := t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(denied, "secret"), []byte("secret"), 0o644))
+
+	run := func(command ...string) (string, error) {
+		cmd := exec.Command(os.Args[0], command...)
+		cmd.Env = append(os.Environ(), "SANDBOX_TEST_WRITE="+allowed)
+		cmd.SysProcAttr = SysProcAttr()
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	_, err := run("sh", "-c", "echo ok > "+filepath.Join(allowed, "file"))
+	assert.NoError(t, err)
+	_, err = run("cat", filepath.Join(denied, "secret"))
+	assert.Error(t, err)
+	_, err = run("touch", filepath.Join(denied, "file"))
+	assert.Error(t, err)
+
+	// The network namespace only has a loopback device
+	out, err := run("cat", "/proc/net/dev")
+	require.NoError(t, err)
+	assert.Contains(t, out, "lo:")
+	assert.Equal(t, 3, strings.Count(out, "\n"), out)
+}
+
+func TestExecDeniesUnixSockets(t *testing.T) {
+	if err := Check(); err != nil {
+		t.Skip(err)
+	}
+	// The socket is in a writable directory, which Landlock alone wouldn't
+	// keep it from being connected to
+	dir := t.TempDir()
+	path := filepath.Join(dir, "daemon.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	cmd := exec.Command(os.Args[0], os.Args[0], "dial", path)
+	cmd.Env = append(os.Environ(), "SANDBOX_TEST_WRITE="+dir)
+	cmd.SysProcAttr = SysProcAttr()
+	out, err := cmd.CombinedOutput()
+	assert.Error(t, err)
+	assert.Contains(t, string(out), "dial unix")
+	assert.Contains(t, string(out), "permission denied")
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package sandbox
+
+import "syscall"
+
+// Check reports whether the system can sandbox processes, which only Linux
+// can
+func Check() error {
+	return ErrUnsupported
+}
+
+// SysProcAttr returns no attributes where processes can't be sandboxed
+func SysProcAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+// Exec fails where processes can't be sandboxed
+func Exec(policy Policy, path string, args []string) error {
+	return ErrUnsupported
+}
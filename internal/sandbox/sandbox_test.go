@@ -0,0 +1,54 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForWorkspace(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", "")
+	workspace := t.TempDir()
+	server := filepath.Join(t.TempDir(), "node", "bin", "server")
+	require.NoError(t, os.MkdirAll(filepath.Dir(server), 0o755))
+	require.NoError(t, os.WriteFile(server, nil, 0o755))
+
+	policy := ForWorkspace(workspace, server, []string{"PATH=/opt/tools/bin:relative", "GOPATH=/gopath", "HOME=" + home})
+
+	assert.Contains(t, policy.Write, workspace)
+	assert.Contains(t, policy.Write, os.TempDir())
+	// Only the server's own cache directory is writable, not the sessions
+	// kept next to it
+	assert.Equal(t, filepath.Join(home, ".cache", "mcp-language-server", "sandbox", "server"), policy.Cache)
+	assert.Contains(t, policy.Write, policy.Cache)
+	assert.DirExists(t, policy.Cache)
+	assert.NotContains(t, policy.Write, filepath.Join(home, ".cache"))
+	assert.Contains(t, policy.Read, "/usr")
+	assert.Contains(t, policy.Read, "/gopath")
+	assert.Contains(t, policy.Read, "/opt/tools/bin")
+	assert.Contains(t, policy.Read, filepath.Join(home, ".cargo"))
+	assert.NotContains(t, policy.Read, "relative")
+	resolved, err := filepath.EvalSymlinks(filepath.Dir(filepath.Dir(server)))
+	require.NoError(t, err)
+	assert.Contains(t, policy.Read, resolved)
+}
+
+func TestInstallDir(t *testing.T) {
+	assert.Equal(t, "/opt/node", installDir("/opt/node/bin/server"))
+	assert.Equal(t, "/opt/tools", installDir("/opt/tools/server"))
+	assert.Equal(t, "/bin", installDir("/bin/server"))
+}
+
+func TestCommand(t *testing.T) {
+	self, args, err := Policy{Read: []string{"/usr"}, Write: []string{"/work"}}.Command("gopls", []string{"serve"})
+	require.NoError(t, err)
+	executable, err := os.Executable()
+	require.NoError(t, err)
+	assert.Equal(t, executable, self)
+	assert.Equal(t, []string{"sandbox", "--read", "/usr", "--write", "/work", "--", "gopls", "serve"}, args)
+}
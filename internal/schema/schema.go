@@ -0,0 +1,356 @@
+// Package schema validates JSON documents against the subset of JSON Schema
+// that the server's configuration schemas use: type, properties,
+// additionalProperties, required, items, enum, minimum, minLength and
+// pattern. Errors name the path of the value, what was expected and, for
+// misspelled properties, the property that was likely meant.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema is a JSON schema, or the part of one that applies to a value
+type Schema struct {
+	Type                 typeList           `json:"type"`
+	Description          string             `json:"description"`
+	Properties           map[string]*Schema `json:"properties"`
+	AdditionalProperties *additional        `json:"additionalProperties"`
+	Required             []string           `json:"required"`
+	Items                *Schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+	MinLength            *int               `json:"minLength"`
+	Pattern              string             `json:"pattern"`
+
+	pattern *regexp.Regexp
+}
+
+// typeList is the type keyword, a type name or a list of them
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = typeList{name}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("type must be a string or a list of strings")
+	}
+	*t = names
+	return nil
+}
+
+// additional is the additionalProperties keyword, false or a schema
+type additional struct {
+	allowed bool
+	schema  *Schema
+}
+
+func (a *additional) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.allowed); err == nil {
+		return nil
+	}
+	a.allowed = true
+	return json.Unmarshal(data, &a.schema)
+}
+
+// Error is a value that doesn't match the schema
+type Error struct {
+	// Path locates the value, like postEditHooks[1].command, or is empty
+	// for the document
+	Path    string
+	Message string
+}
+
+func (e Error) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Parse reads a schema and compiles its patterns
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	return &s, nil
+}
+
+func (s *Schema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return err
+		}
+		s.pattern = re
+	}
+	children := []*Schema{s.Items}
+	for _, child := range s.Properties {
+		children = append(children, child)
+	}
+	if s.AdditionalProperties != nil {
+		children = append(children, s.AdditionalProperties.schema)
+	}
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		if err := child.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks a JSON document against the schema and returns every
+// mismatch, with the properties of objects in sorted order. A document that
+// isn't JSON is one error.
+func (s *Schema) Validate(document []byte) []Error {
+	var value any
+	if err := json.Unmarshal(document, &value); err != nil {
+		return []Error{{Message: describeSyntaxError(document, err)}}
+	}
+	var errs []Error
+	s.validate("", value, &errs)
+	return errs
+}
+
+// describeSyntaxError adds the line and column to a JSON syntax error
+func describeSyntaxError(document []byte, err error) string {
+	syntaxErr, ok := err.(*json.SyntaxError)
+	// The offset is just past the character that is wrong
+	if !ok || syntaxErr.Offset < 1 || syntaxErr.Offset > int64(len(document)) {
+		return fmt.Sprintf("invalid JSON: %v", err)
+	}
+	offset := syntaxErr.Offset - 1
+	before := document[:offset]
+	line := strings.Count(string(before), "\n") + 1
+	column := int(offset) - strings.LastIndex(string(before), "\n")
+	return fmt.Sprintf("invalid JSON at line %d, column %d: %v", line, column, err)
+}
+
+func (s *Schema) validate(path string, value any, errs *[]Error) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, Error{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(name string) bool { return hasType(value, name) }) {
+		fail("expected %s, got %s", strings.Join(s.Type, " or "), typeName(value))
+		return
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(allowed any) bool { return equal(allowed, value) }) {
+		fail("%s is not one of %s%s", describe(value), describeAll(s.Enum), suggestValue(value, s.Enum))
+		return
+	}
+
+	switch value := value.(type) {
+	case string:
+		if s.MinLength != nil && len([]rune(value)) < *s.MinLength {
+			if *s.MinLength == 1 {
+				fail("must not be empty")
+			} else {
+				fail("must be at least %d characters long", *s.MinLength)
+			}
+		}
+		if s.pattern != nil && !s.pattern.MatchString(value) {
+			message := fmt.Sprintf("%s doesn't match %s", describe(value), s.Pattern)
+			if s.Description != "" {
+				message += " (" + s.Description + ")"
+			}
+			fail("%s", message)
+		}
+	case float64:
+		if s.Minimum != nil && value < *s.Minimum {
+			fail("must be at least %s, got %s", formatNumber(*s.Minimum), formatNumber(value))
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range value {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := value[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := joinPath(path, key)
+			if property, ok := s.Properties[key]; ok {
+				property.validate(child, value[key], errs)
+				continue
+			}
+			switch {
+			case s.AdditionalProperties == nil:
+			case !s.AdditionalProperties.allowed:
+				*errs = append(*errs, Error{Path: path, Message: fmt.Sprintf("unknown property %q%s", key, s.suggestProperty(key))})
+			case s.AdditionalProperties.schema != nil:
+				s.AdditionalProperties.schema.validate(child, value[key], errs)
+			}
+		}
+	}
+}
+
+// hasType reports whether a decoded JSON value is of a JSON Schema type
+func hasType(value any, name string) bool {
+	switch value := value.(type) {
+	case nil:
+		return name == "null"
+	case bool:
+		return name == "boolean"
+	case string:
+		return name == "string"
+	case float64:
+		return name == "number" || name == "integer" && value == math.Trunc(value)
+	case []any:
+		return name == "array"
+	case map[string]any:
+		return name == "object"
+	}
+	return false
+}
+
+// typeName names the JSON type of a decoded value
+func typeName(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string " + describe(value)
+	case float64:
+		return "number " + formatNumber(value)
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func equal(a, b any) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
+
+func describe(value any) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func describeAll(values []any) string {
+	described := make([]string, len(values))
+	for i, value := range values {
+		described[i] = describe(value)
+	}
+	return strings.Join(described, ", ")
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// joinPath adds a property to a path, quoting names that aren't
+// identifiers
+func joinPath(path, key string) string {
+	if !identifier.MatchString(key) {
+		key = strconv.Quote(key)
+		return path + "[" + key + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// suggestProperty names the known property closest to an unknown one
+func (s *Schema) suggestProperty(key string) string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	if match := closest(key, names); match != "" {
+		return fmt.Sprintf(", did you mean %q?", match)
+	}
+	return ""
+}
+
+// suggestValue names the allowed string closest to a string value
+func suggestValue(value any, allowed []any) string {
+	text, ok := value.(string)
+	if !ok {
+		return ""
+	}
+	var names []string
+	for _, a := range allowed {
+		if name, ok := a.(string); ok {
+			names = append(names, name)
+		}
+	}
+	if match := closest(text, names); match != "" {
+		return fmt.Sprintf(", did you mean %q?", match)
+	}
+	return ""
+}
+
+// closest returns the candidate with the smallest edit distance to a word,
+// ignoring case, if it is close enough to be a likely typo
+func closest(word string, candidates []string) string {
+	sort.Strings(candidates)
+	best, bestDistance := "", math.MaxInt
+	for _, candidate := range candidates {
+		d := distance(strings.ToLower(word), strings.ToLower(candidate))
+		if d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if bestDistance > max(2, len(word)/3) {
+		return ""
+	}
+	return best
+}
+
+// distance is the Levenshtein distance between two strings
+func distance(a, b string) int {
+	x, y := []rune(a), []rune(b)
+	previous := make([]int, len(y)+1)
+	current := make([]int, len(y)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		current[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(y)]
+}
@@ -0,0 +1,109 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "name": {"type": "string", "minLength": 1},
+    "level": {"type": "string", "enum": ["debug", "info", "warn"]},
+    "count": {"type": "integer", "minimum": 0},
+    "ratio": {"type": ["number", "null"]},
+    "digest": {"type": "string", "pattern": "^[0-9a-f]{4}$", "description": "four hex digits"},
+    "tags": {"type": "array", "items": {"type": "string"}},
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+    "hooks": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["command"],
+        "additionalProperties": false,
+        "properties": {"command": {"type": "string"}}
+      }
+    }
+  }
+}`
+
+func messages(errs []Error) []string {
+	var all []string
+	for _, err := range errs {
+		all = append(all, err.Error())
+	}
+	return all
+}
+
+func TestValidateAccepts(t *testing.T) {
+	s, err := Parse([]byte(testSchema))
+	require.NoError(t, err)
+
+	errs := s.Validate([]byte(`{
+		"name": "x", "level": "info", "count": 3, "ratio": null, "digest": "beef",
+		"tags": ["a"], "labels": {"a b": "c"}, "hooks": [{"command": "go fmt"}]
+	}`))
+	assert.Empty(t, errs)
+}
+
+func TestValidateReportsEveryMismatch(t *testing.T) {
+	s, err := Parse([]byte(testSchema))
+	require.NoError(t, err)
+
+	errs := s.Validate([]byte(`{
+		"nmae": "x",
+		"name": "",
+		"level": "inof",
+		"count": 1.5,
+		"ratio": "1",
+		"digest": "xyz",
+		"tags": ["a", 2],
+		"labels": {"a b": true},
+		"hooks": [{"comand": "go fmt"}]
+	}`))
+	assert.Equal(t, []string{
+		"count: expected integer, got number 1.5",
+		`digest: "xyz" doesn't match ^[0-9a-f]{4}$ (four hex digits)`,
+		`hooks[0]: missing required property "command"`,
+		`hooks[0]: unknown property "comand", did you mean "command"?`,
+		`labels["a b"]: expected string, got boolean`,
+		`level: "inof" is not one of "debug", "info", "warn", did you mean "info"?`,
+		"name: must not be empty",
+		`unknown property "nmae", did you mean "name"?`,
+		`ratio: expected number or null, got string "1"`,
+		"tags[1]: expected string, got number 2",
+	}, messages(errs))
+}
+
+func TestValidateUnknownPropertyWithoutSuggestion(t *testing.T) {
+	s, err := Parse([]byte(testSchema))
+	require.NoError(t, err)
+
+	errs := s.Validate([]byte(`{"completelyDifferent": 1}`))
+	assert.Equal(t, []string{`unknown property "completelyDifferent"`}, messages(errs))
+}
+
+func TestValidateInvalidJSON(t *testing.T) {
+	s, err := Parse([]byte(testSchema))
+	require.NoError(t, err)
+
+	errs := s.Validate([]byte("{\n  \"name\": ,\n}"))
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "invalid JSON at line 2, column 11")
+}
+
+func TestParseRejectsInvalidPattern(t *testing.T) {
+	_, err := Parse([]byte(`{"properties": {"a": {"pattern": "("}}}`))
+	assert.Error(t, err)
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, distance("abc", "abc"))
+	assert.Equal(t, 1, distance("logLevl", "logLevel"))
+	assert.Equal(t, 1, distance("formt", "format"))
+	assert.Equal(t, 2, distance("Level", "lvel"))
+	assert.Equal(t, 3, distance("", "abc"))
+}
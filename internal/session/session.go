@@ -0,0 +1,131 @@
+// Package session keeps what a session has built up, so that a client that
+// reconnects with the same session ID continues where it left off.
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/journal"
+	"github.com/isaacphi/mcp-language-server/internal/runner"
+)
+
+// MaxAge is how long a session's state is kept after it was last saved
+const MaxAge = 7 * 24 * time.Hour
+
+// State is what a session restores
+type State struct {
+	Workspace string `json:"workspace"`
+	// OpenFiles are the files open in the language server
+	OpenFiles []string `json:"openFiles,omitempty"`
+	// Settings are the language server's settings, as changed during the
+	// session
+	Settings map[string]any `json:"settings,omitempty"`
+	// Build holds the diagnostics of the session's last build
+	Build         *runner.BuildDiagnostics `json:"build,omitempty"`
+	BuildFinished time.Time                `json:"buildFinished,omitzero"`
+	// Journal holds the edits undo_edits can revert and the checkpoints
+	Journal *journal.State `json:"journal,omitempty"`
+	Saved   time.Time      `json:"saved"`
+}
+
+// Store keeps the state of each session in a JSON file of its own in a
+// directory
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStore returns a store backed by dir, which is created when the first
+// state is saved
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultDir is the store shared by every server run by the current user
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "mcp-language-server", "sessions"), nil
+}
+
+// path is the file of a session. The ID is hashed with the workspace so that
+// any ID can be used, and the same ID in two workspaces names two sessions.
+func (s *Store) path(workspace, id string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(workspace) + "\x00" + id))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+// Load returns the saved state of a session, or nil if there is none or it
+// is older than MaxAge
+func (s *Store) Load(workspace, id string) (*State, error) {
+	data, err := os.ReadFile(s.path(workspace, id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session state: %v", err)
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid session state: %v", err)
+	}
+	if state.Workspace != filepath.Clean(workspace) || time.Since(state.Saved) > MaxAge {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// Save replaces the saved state of a session, and removes the state of
+// sessions that have expired
+func (s *Store) Save(id string, state State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state.Workspace = filepath.Clean(state.Workspace)
+	state.Saved = time.Now().UTC()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create session directory: %v", err)
+	}
+	// Replace the file in one step so a reconnecting session never reads it
+	// half written
+	path := s.path(state.Workspace, id)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write session state: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write session state: %v", err)
+	}
+	s.prune()
+	return nil
+}
+
+// prune removes the files of sessions not saved for MaxAge
+func (s *Store) prune() {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > MaxAge {
+			_ = os.Remove(filepath.Join(s.dir, entry.Name()))
+		}
+	}
+}
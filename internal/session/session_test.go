@@ -0,0 +1,66 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/journal"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/runner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	store := NewStore(dir)
+
+	state, err := store.Load("/work/project", "abc")
+	require.NoError(t, err)
+	assert.Nil(t, state)
+
+	build := &runner.BuildDiagnostics{
+		Files:     map[protocol.DocumentUri][]protocol.Diagnostic{"file:///work/project/main.go": {{Message: "undefined: x"}}},
+		Unlocated: []string{"ld: symbol not found"},
+	}
+	require.NoError(t, store.Save("abc", State{
+		Workspace: "/work/project/",
+		OpenFiles: []string{"/work/project/main.go"},
+		Settings:  map[string]any{"gopls": map[string]any{"staticcheck": true}},
+		Build:     build,
+		Journal:   &journal.State{Entries: []journal.Entry{{Seq: 1, Tool: "rename_symbol", Files: []journal.File{{Path: "/work/project/main.go", Before: []byte("package main\n")}}}}, Seq: 1},
+	}))
+
+	// The state is read back from the file
+	state, err = NewStore(dir).Load("/work/project", "abc")
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Equal(t, []string{"/work/project/main.go"}, state.OpenFiles)
+	assert.Equal(t, map[string]any{"gopls": map[string]any{"staticcheck": true}}, state.Settings)
+	assert.Equal(t, build, state.Build)
+	require.NotNil(t, state.Journal)
+	assert.Equal(t, []byte("package main\n"), state.Journal.Entries[0].Files[0].Before)
+
+	// Sessions are per workspace
+	state, err = store.Load("/work/other", "abc")
+	require.NoError(t, err)
+	assert.Nil(t, state)
+}
+
+func TestStoreExpiry(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+	require.NoError(t, store.Save("old", State{Workspace: "/work"}))
+	old := store.path("/work", "old")
+	expired := time.Now().Add(-MaxAge - time.Hour)
+	require.NoError(t, os.Chtimes(old, expired, expired))
+
+	// Saving another session removes the expired one
+	require.NoError(t, store.Save("new", State{Workspace: "/work"}))
+	assert.NoFileExists(t, old)
+	state, err := store.Load("/work", "old")
+	require.NoError(t, err)
+	assert.Nil(t, state)
+}
@@ -0,0 +1,185 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// maxAnnotations limits the hover requests made for one file
+const maxAnnotations = 300
+
+// annotateWorkers is the number of hover requests in flight at once
+const annotateWorkers = 8
+
+// Annotation modes
+const (
+	AnnotateSymbols        = "symbols"
+	AnnotateAllIdentifiers = "all_identifiers"
+)
+
+// annotation is the hover summary for an identifier in a file
+type annotation struct {
+	name     string
+	position protocol.Position
+	text     string
+}
+
+// AnnotateFile returns a file with the type and a documentation summary of
+// identifiers shown below the lines they are on. In symbols mode the
+// declarations from document symbols are annotated; in all_identifiers mode
+// every distinct name is annotated where it first appears. The hover
+// requests are sent concurrently, so that a whole file costs about as long
+// as a few hovers.
+func AnnotateFile(ctx context.Context, client *lsp.Client, filePath, mode string) (string, error) {
+	if mode == "" {
+		mode = AnnotateSymbols
+	}
+	if mode != AnnotateSymbols && mode != AnnotateAllIdentifiers {
+		return "", codedErrorf(InvalidArgument, "mode must be %q or %q, got %q", AnnotateSymbols, AnnotateAllIdentifiers, mode)
+	}
+	if err := client.OpenFile(ctx, filePath); err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+	content, err := lsp.ReadSourceFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	uri := protocol.URIFromPath(filePath)
+
+	var annotations []annotation
+	if mode == AnnotateSymbols {
+		symbols, err := documentSymbolsFlat(ctx, client, uri, make(map[protocol.DocumentUri][]flatSymbol))
+		if err != nil {
+			return "", err
+		}
+		annotations = symbolAnnotations(lines, symbols)
+	} else {
+		annotations = identifierAnnotations(lines)
+	}
+	truncated := len(annotations) > maxAnnotations
+	if truncated {
+		annotations = annotations[:maxAnnotations]
+	}
+
+	hoverAnnotations(ctx, client, uri, annotations)
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	byLine := make(map[uint32][]annotation)
+	annotated := 0
+	for _, a := range annotations {
+		if a.text != "" {
+			byLine[a.position.Line] = append(byLine[a.position.Line], a)
+			annotated++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "%s: %d of %d %s annotated", filePath, annotated, len(annotations), mode)
+	if truncated {
+		fmt.Fprintf(&out, " (stopped at %d; use symbols mode or a smaller file for more)", maxAnnotations)
+	}
+	out.WriteString("\n\n")
+	padding := len(strconv.Itoa(len(lines)))
+	for i, line := range lines {
+		fmt.Fprintf(&out, "%*d|%s\n", padding, i+1, line)
+		for _, a := range byLine[uint32(i)] {
+			fmt.Fprintf(&out, "%s|  ^ %s: %s\n", strings.Repeat(" ", padding), a.name, a.text)
+		}
+	}
+	return out.String(), nil
+}
+
+// symbolAnnotations returns an annotation for each declaration, named as it
+// is written at its selection position
+func symbolAnnotations(lines []string, symbols []flatSymbol) []annotation {
+	var annotations []annotation
+	seen := make(map[protocol.Position]bool)
+	for _, sym := range symbols {
+		if seen[sym.selection] {
+			continue
+		}
+		seen[sym.selection] = true
+		name := sym.name
+		if int(sym.selection.Line) < len(lines) {
+			line := lines[sym.selection.Line]
+			rest := line[utilities.ByteOffset(line, int(sym.selection.Character)):]
+			if loc := identifierPattern.FindStringIndex(rest); loc != nil && loc[0] == 0 {
+				name = rest[:loc[1]]
+			}
+		}
+		annotations = append(annotations, annotation{name: name, position: sym.selection})
+	}
+	return annotations
+}
+
+// identifierAnnotations returns an annotation for the first occurrence of
+// each distinct name. Names in comments and strings are included and are
+// dropped later when hover has nothing to say about them.
+func identifierAnnotations(lines []string) []annotation {
+	var annotations []annotation
+	seen := make(map[string]bool)
+	for i, line := range lines {
+		for _, loc := range identifierPattern.FindAllStringIndex(line, -1) {
+			name := line[loc[0]:loc[1]]
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			annotations = append(annotations, annotation{
+				name:     name,
+				position: protocol.Position{Line: uint32(i), Character: uint32(utilities.UTF16Column(line, loc[0]))},
+			})
+		}
+	}
+	return annotations
+}
+
+// hoverAnnotations fills in the text of each annotation from hover,
+// sending up to annotateWorkers requests at once
+func hoverAnnotations(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, annotations []annotation) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(annotateWorkers, len(annotations)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				position := annotations[i].position
+				var doc symbolDocumentation
+				doc.addHover(hoverText(ctx, client, protocol.Location{URI: uri, Range: protocol.Range{Start: position, End: position}}))
+				annotations[i].text = annotationText(doc)
+			}
+		}()
+	}
+	for i := range annotations {
+		if ctx.Err() != nil {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// annotationText is the one-line signature of a hover with the first
+// sentence of its documentation
+func annotationText(doc symbolDocumentation) string {
+	text := oneLineSignature(doc.signature)
+	if summary := firstSentence(doc.documentation); summary != "" {
+		if text == "" {
+			return summary
+		}
+		text += " // " + summary
+	}
+	return text
+}
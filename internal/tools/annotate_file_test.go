@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymbolAnnotations(t *testing.T) {
+	lines := []string{
+		"package store",
+		"",
+		"func (s *Store) Add(item int) {}",
+	}
+	symbols := []flatSymbol{
+		{name: "(*Store).Add", kind: protocol.Method, selection: protocol.Position{Line: 2, Character: 16}},
+		{name: "(*Store).Add", kind: protocol.Method, selection: protocol.Position{Line: 2, Character: 16}},
+		{name: "missing", kind: protocol.Variable, selection: protocol.Position{Line: 9}},
+	}
+	annotations := symbolAnnotations(lines, symbols)
+	assert.Equal(t, []annotation{
+		{name: "Add", position: protocol.Position{Line: 2, Character: 16}},
+		{name: "missing", position: protocol.Position{Line: 9}},
+	}, annotations)
+}
+
+func TestIdentifierAnnotations(t *testing.T) {
+	lines := []string{
+		"é := total(items)",
+		"return total(é)",
+	}
+	var names []string
+	for _, a := range identifierAnnotations(lines) {
+		names = append(names, a.name)
+	}
+	assert.Equal(t, []string{"é", "total", "items", "return"}, names)
+	assert.Equal(t, protocol.Position{Line: 0, Character: 5}, identifierAnnotations(lines)[1].position)
+}
+
+func TestAnnotationText(t *testing.T) {
+	assert.Equal(t, "func total(items []int) int // Total sums the items.",
+		annotationText(symbolDocumentation{signature: "func total(items []int) int", documentation: "Total sums the items. Empty lists sum to zero."}))
+	assert.Equal(t, "var items []int", annotationText(symbolDocumentation{signature: "var items []int"}))
+	assert.Equal(t, "", annotationText(symbolDocumentation{}))
+}
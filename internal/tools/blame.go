@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/git"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// blameLines returns git blame data for lines start to end of a file, keyed
+// by line number. Files outside a git repository have no blame data.
+func blameLines(ctx context.Context, path string, start, end int) map[int]git.Commit {
+	repo, err := git.Open(ctx, filepath.Dir(path))
+	if err != nil {
+		toolsLogger.Debug("No git blame for %s: %v", path, err)
+		return nil
+	}
+	lines, err := repo.Blame(ctx, path, start, end)
+	if err != nil {
+		toolsLogger.Debug("No git blame for %s: %v", path, err)
+		return nil
+	}
+	commits := make(map[int]git.Commit, len(lines))
+	for _, line := range lines {
+		commits[line.Line] = line.Commit
+	}
+	return commits
+}
+
+// describeCommit summarizes a commit as e.g. "3 days ago by Alice in 1a2b3c4d (Fix parser)"
+func describeCommit(commit git.Commit, now time.Time) string {
+	if commit.Uncommitted() {
+		return "uncommitted changes"
+	}
+	return fmt.Sprintf("%s by %s in %s (%s)", git.FormatAge(commit.Time, now), commit.Author, commit.ShortHash(), commit.Summary)
+}
+
+// blameSummary describes who changed lines start to end of a file and when,
+// as lines to add to a definition's location info
+func blameSummary(ctx context.Context, path string, start, end int) string {
+	commits := blameLines(ctx, path, start, end)
+	if len(commits) == 0 {
+		return ""
+	}
+
+	var newest git.Commit
+	authors := make(map[string]int)
+	for _, commit := range commits {
+		if commit.Uncommitted() {
+			newest = commit
+			continue
+		}
+		authors[commit.Author]++
+		if !newest.Uncommitted() && commit.Time.After(newest.Time) {
+			newest = commit
+		}
+	}
+
+	names := make([]string, 0, len(authors))
+	for name := range authors {
+		names = append(names, name)
+	}
+	// Most lines first
+	sort.Slice(names, func(i, j int) bool {
+		if authors[names[i]] != authors[names[j]] {
+			return authors[names[i]] > authors[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	summary := fmt.Sprintf("Last Changed: %s\n", describeCommit(newest, time.Now()))
+	if len(names) > 0 {
+		summary += fmt.Sprintf("Authors: %s\n", strings.Join(names, ", "))
+	}
+	return summary
+}
+
+// referenceBlame lists who last changed each line holding a reference
+func referenceBlame(ctx context.Context, path string, refs []protocol.Location) string {
+	if len(refs) == 0 {
+		return ""
+	}
+	start, end := int(refs[0].Range.Start.Line)+1, int(refs[0].Range.Start.Line)+1
+	for _, ref := range refs {
+		start = min(start, int(ref.Range.Start.Line)+1)
+		end = max(end, int(ref.Range.Start.Line)+1)
+	}
+	commits := blameLines(ctx, path, start, end)
+	if len(commits) == 0 {
+		return ""
+	}
+
+	now := time.Now()
+	var result strings.Builder
+	seen := make(map[int]bool)
+	for _, ref := range refs {
+		line := int(ref.Range.Start.Line) + 1
+		commit, ok := commits[line]
+		if !ok || seen[line] {
+			continue
+		}
+		seen[line] = true
+		fmt.Fprintf(&result, "Changed L%d: %s\n", line, describeCommit(commit, now))
+	}
+	return result.String()
+}
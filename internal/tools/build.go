@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/runner"
+)
+
+// lastBuild keeps the diagnostics of the most recent build so that workspace
+// diagnostics can include problems the language server doesn't report
+var lastBuild struct {
+	sync.Mutex
+	diagnostics *runner.BuildDiagnostics
+	finished    time.Time
+}
+
+// LastBuildDiagnostics returns the diagnostics of the most recent build, or
+// nil if no build has run
+func LastBuildDiagnostics() (*runner.BuildDiagnostics, time.Time) {
+	lastBuild.Lock()
+	defer lastBuild.Unlock()
+	return lastBuild.diagnostics, lastBuild.finished
+}
+
+// SetLastBuildDiagnostics restores the diagnostics of a build that finished
+// earlier, such as in a session that is resumed
+func SetLastBuildDiagnostics(diagnostics *runner.BuildDiagnostics, finished time.Time) {
+	lastBuild.Lock()
+	defer lastBuild.Unlock()
+	lastBuild.diagnostics = diagnostics
+	lastBuild.finished = finished
+}
+
+// RunBuild runs the project's build command in the workspace and parses the
+// compiler output into diagnostics. commandTemplate is the configured build
+// command; see runner.BuildCommand.
+func RunBuild(ctx context.Context, workspaceDir, commandTemplate string, timeout time.Duration) (string, error) {
+	args, err := runner.BuildCommand(workspaceDir, commandTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := runner.Run(ctx, workspaceDir, args, timeout, runner.DefaultMaxOutput)
+	if err != nil {
+		return "", err
+	}
+
+	diagnostics := runner.ParseBuildOutput(result.Output, workspaceDir)
+	if !result.TimedOut {
+		lastBuild.Lock()
+		lastBuild.diagnostics = diagnostics
+		lastBuild.finished = time.Now()
+		lastBuild.Unlock()
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Build diagnostics: %d in %d files", diagnostics.Count(), len(diagnostics.Files))
+	if len(diagnostics.Unlocated) > 0 {
+		fmt.Fprintf(&out, ", %d without a location", len(diagnostics.Unlocated))
+	}
+	out.WriteString("\n\n")
+	out.WriteString(formatDiagnosticsByFile(diagnostics.Files))
+	out.WriteString(formatUnlocatedErrors(diagnostics.Unlocated))
+	out.WriteString(result.Format())
+	return out.String(), nil
+}
+
+// formatDiagnosticsByFile lists diagnostics grouped by file in the layout of
+// the diagnostics tool, without source context
+func formatDiagnosticsByFile(files map[protocol.DocumentUri][]protocol.Diagnostic) string {
+	out := newResultWriter(nil)
+	writeDiagnosticsByFile(out, files)
+	return out.String()
+}
+
+// writeDiagnosticsByFile adds the diagnostics of each file to a result as a
+// section of its own
+func writeDiagnosticsByFile(out *resultWriter, files map[protocol.DocumentUri][]protocol.Diagnostic) {
+	uris := make([]string, 0, len(files))
+	for uri := range files {
+		uris = append(uris, string(uri))
+	}
+	sort.Strings(uris)
+
+	for _, uri := range uris {
+		diagnostics := append([]protocol.Diagnostic(nil), files[protocol.DocumentUri(uri)]...)
+		sort.SliceStable(diagnostics, func(i, j int) bool {
+			return diagnostics[i].Range.Start.Line < diagnostics[j].Range.Start.Line
+		})
+		var section strings.Builder
+		fmt.Fprintf(&section, "---\n\n%s\nDiagnostics in File: %d\n", protocol.DocumentUri(uri).Path(), len(diagnostics))
+		for _, diag := range diagnostics {
+			section.WriteString(formatDiagnosticSummary(diag) + "\n")
+		}
+		section.WriteString("\n")
+		out.write(section.String())
+	}
+}
+
+func formatUnlocatedErrors(errors []string) string {
+	if len(errors) == 0 {
+		return ""
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "---\n\nBuild errors without a location: %d\n", len(errors))
+	for _, err := range errors {
+		out.WriteString(err + "\n")
+	}
+	out.WriteString("\n")
+	return out.String()
+}
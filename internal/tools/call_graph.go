@@ -0,0 +1,225 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// maxCallGraphNodes bounds the size of an exported call graph
+const maxCallGraphNodes = 500
+
+// CallGraphNode is a function or method in an exported call graph
+type CallGraphNode struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+	File   string `json:"file"`
+	// Line is 1-indexed
+	Line int `json:"line"`
+}
+
+// CallGraphEdge records that From calls To at CallSites places
+type CallGraphEdge struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	CallSites int    `json:"callSites"`
+}
+
+// CallGraph is the set of calls reachable from one or more root functions
+type CallGraph struct {
+	Roots     []string        `json:"roots"`
+	Nodes     []CallGraphNode `json:"nodes"`
+	Edges     []CallGraphEdge `json:"edges"`
+	Truncated bool            `json:"truncated,omitempty"`
+}
+
+// ExportCallGraph follows outgoing calls from a root symbol up to maxDepth
+// levels deep and renders the result as "dot" or "json"
+func ExportCallGraph(ctx context.Context, client *lsp.Client, symbolName string, maxDepth int, format string) (string, error) {
+	if format != "dot" && format != "json" {
+		return "", codedErrorf(InvalidArgument, "unsupported format %q, expected dot or json", format)
+	}
+
+	symbolName, results, err := QuerySymbol(ctx, client, symbolName)
+	if err != nil {
+		return "", err
+	}
+
+	var roots []protocol.CallHierarchyItem
+	for _, symbol := range results {
+		if !callHierarchySymbolMatches(symbolName, symbol) {
+			continue
+		}
+		exactLoc, err := GetExactSymbolLocation(symbol)
+		if err != nil {
+			toolsLogger.Warn("Error getting exact location of %s: %v", symbol.GetName(), err)
+			continue
+		}
+		items, err := client.PrepareCallHierarchy(ctx, protocol.CallHierarchyPrepareParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: exactLoc.URI},
+				Position:     exactLoc.Range.Start,
+			},
+		})
+		if err != nil {
+			toolsLogger.Warn("Error preparing call hierarchy for %s: %v", symbol.GetName(), err)
+			continue
+		}
+		roots = append(roots, items...)
+	}
+	if len(roots) == 0 {
+		return "", codedErrorf(SymbolNotFound, "%s not found", symbolName)
+	}
+
+	graph := buildCallGraph(ctx, client, roots, maxDepth)
+	if format == "dot" {
+		return graph.DOT(), nil
+	}
+	data, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode call graph: %w", err)
+	}
+	return string(data), nil
+}
+
+// buildCallGraph walks outgoing calls breadth first so that the closest
+// calls are kept when the graph is truncated
+func buildCallGraph(ctx context.Context, client *lsp.Client, roots []protocol.CallHierarchyItem, maxDepth int) *CallGraph {
+	graph := &CallGraph{}
+	// indexes maps callGraphKey to the node's position in graph.Nodes
+	indexes := make(map[string]int)
+	// edges counts call sites between pairs of node indexes
+	edges := make(map[[2]int]int)
+
+	addNode := func(item protocol.CallHierarchyItem) (int, bool) {
+		key := callGraphKey(item)
+		if index, ok := indexes[key]; ok {
+			return index, false
+		}
+		index := len(graph.Nodes)
+		indexes[key] = index
+		graph.Nodes = append(graph.Nodes, CallGraphNode{
+			ID:     fmt.Sprintf("n%d", index),
+			Name:   item.Name,
+			Kind:   protocol.TableKindMap[item.Kind],
+			Detail: item.Detail,
+			File:   item.URI.Path(),
+			Line:   int(item.SelectionRange.Start.Line) + 1,
+		})
+		return index, true
+	}
+
+	type queued struct {
+		item  protocol.CallHierarchyItem
+		index int
+		depth int
+	}
+	var queue []queued
+	for _, root := range roots {
+		index, isNew := addNode(root)
+		graph.Roots = append(graph.Roots, graph.Nodes[index].ID)
+		if isNew {
+			queue = append(queue, queued{root, index, 0})
+		}
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current.depth >= maxDepth || ctx.Err() != nil {
+			continue
+		}
+
+		calls, err := client.OutgoingCalls(ctx, protocol.CallHierarchyOutgoingCallsParams{Item: current.item})
+		if err != nil {
+			toolsLogger.Warn("Error getting calls from %s: %v", current.item.Name, err)
+			continue
+		}
+		// ensure output is deterministic for tests
+		sort.Slice(calls, func(i, j int) bool {
+			return calls[i].To.Name < calls[j].To.Name
+		})
+
+		for _, call := range calls {
+			if _, seen := indexes[callGraphKey(call.To)]; !seen && len(graph.Nodes) >= maxCallGraphNodes {
+				graph.Truncated = true
+				continue
+			}
+			index, isNew := addNode(call.To)
+			edges[[2]int{current.index, index}] += len(call.FromRanges)
+			if isNew {
+				queue = append(queue, queued{call.To, index, current.depth + 1})
+			}
+		}
+	}
+
+	pairs := make([][2]int, 0, len(edges))
+	for pair := range edges {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	for _, pair := range pairs {
+		graph.Edges = append(graph.Edges, CallGraphEdge{
+			From:      graph.Nodes[pair[0]].ID,
+			To:        graph.Nodes[pair[1]].ID,
+			CallSites: edges[pair],
+		})
+	}
+
+	return graph
+}
+
+// callGraphKey identifies a function by where its name is declared
+func callGraphKey(item protocol.CallHierarchyItem) string {
+	return fmt.Sprintf("%s:%d:%d", item.URI, item.SelectionRange.Start.Line, item.SelectionRange.Start.Character)
+}
+
+// DOT renders the graph in Graphviz format
+func (g *CallGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph calls {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+
+	roots := make(map[string]bool)
+	for _, id := range g.Roots {
+		roots[id] = true
+	}
+	for _, node := range g.Nodes {
+		label := fmt.Sprintf("%s\\n%s:%d", node.Name, node.File, node.Line)
+		attrs := fmt.Sprintf("label=%s", dotQuote(label))
+		if roots[node.ID] {
+			attrs += ", style=bold"
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", node.ID, attrs)
+	}
+	for _, edge := range g.Edges {
+		if edge.CallSites > 1 {
+			fmt.Fprintf(&b, "  %s -> %s [label=\"%d\"];\n", edge.From, edge.To, edge.CallSites)
+		} else {
+			fmt.Fprintf(&b, "  %s -> %s;\n", edge.From, edge.To)
+		}
+	}
+	if g.Truncated {
+		fmt.Fprintf(&b, "  // truncated at %d nodes\n", maxCallGraphNodes)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote quotes a DOT string, keeping \n escapes intact
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallGraphDOT(t *testing.T) {
+	graph := &CallGraph{
+		Roots: []string{"n0"},
+		Nodes: []CallGraphNode{
+			{ID: "n0", Name: "main", File: "/work/main.go", Line: 5},
+			{ID: "n1", Name: "helper", File: "/work/util.go", Line: 12},
+			{ID: "n2", Name: `quote"d`, File: "/work/util.go", Line: 20},
+		},
+		Edges: []CallGraphEdge{
+			{From: "n0", To: "n1", CallSites: 1},
+			{From: "n1", To: "n2", CallSites: 3},
+		},
+	}
+
+	expected := `digraph calls {
+  rankdir=LR;
+  node [shape=box];
+  n0 [label="main\n/work/main.go:5", style=bold];
+  n1 [label="helper\n/work/util.go:12"];
+  n2 [label="quote\"d\n/work/util.go:20"];
+  n0 -> n1;
+  n1 -> n2 [label="3"];
+}
+`
+	assert.Equal(t, expected, graph.DOT())
+}
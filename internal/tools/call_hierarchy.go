@@ -32,25 +32,7 @@ func getCallHierarchy(
 	var result strings.Builder
 
 	for _, symbol := range results {
-		var separator string
-		if strings.Contains(symbolName, ".") {
-			separator = "."
-		} else if strings.Contains(symbolName, "::") {
-			separator = "::"
-		}
-
-		// Handle different matching strategies based on the search term
-		if separator != "" {
-			// For qualified names like "Type.Method", check for various matches
-			parts := strings.Split(symbolName, separator)
-			methodName := parts[len(parts)-1]
-
-			// Try matching the unqualified method name for languages that don't use qualified names in symbols
-			if symbol.GetName() != symbolName && symbol.GetName() != methodName {
-				continue
-			}
-		} else if symbol.GetName() != symbolName {
-			// For unqualified names, exact match only
+		if !callHierarchySymbolMatches(symbolName, symbol) {
 			continue
 		}
 
@@ -85,6 +67,29 @@ func getCallHierarchy(
 	return result.String(), nil
 }
 
+// callHierarchySymbolMatches checks whether a workspace symbol is the one asked for
+func callHierarchySymbolMatches(symbolName string, symbol protocol.WorkspaceSymbolResult) bool {
+	var separator string
+	if strings.Contains(symbolName, ".") {
+		separator = "."
+	} else if strings.Contains(symbolName, "::") {
+		separator = "::"
+	}
+
+	// Handle different matching strategies based on the search term
+	if separator != "" {
+		// For qualified names like "Type.Method", check for various matches
+		parts := strings.Split(symbolName, separator)
+		methodName := parts[len(parts)-1]
+
+		// Try matching the unqualified method name for languages that don't use qualified names in symbols
+		return symbol.GetName() == symbolName || symbol.GetName() == methodName
+	}
+
+	// For unqualified names, exact match only
+	return symbol.GetName() == symbolName
+}
+
 func recurseIncomingCalls(ctx context.Context, client *lsp.Client, item protocol.CallHierarchyItem, result *strings.Builder, depth int, maxDepth int) {
 
 	var prefix string
@@ -108,7 +113,7 @@ func recurseIncomingCalls(ctx context.Context, client *lsp.Client, item protocol
 
 	result.WriteString(prefix)
 	result.WriteString("File: ")
-	result.WriteString(strings.TrimPrefix(string(item.URI), "file://"))
+	result.WriteString(item.URI.Path())
 	result.WriteRune('\n')
 
 	result.WriteString(prefix)
@@ -167,7 +172,7 @@ func recurseOutgoingCalls(ctx context.Context, client *lsp.Client, item protocol
 
 	result.WriteString(prefix)
 	result.WriteString("File: ")
-	result.WriteString(strings.TrimPrefix(string(item.URI), "file://"))
+	result.WriteString(item.URI.Path())
 	result.WriteRune('\n')
 
 	result.WriteString(prefix)
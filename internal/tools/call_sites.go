@@ -0,0 +1,500 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// textPos is a position in a file's lines, as a byte offset in its line
+type textPos struct {
+	line, offset int
+}
+
+// lspPosition converts the position to an LSP one
+func (p textPos) lspPosition(lines []string) protocol.Position {
+	return protocol.Position{Line: uint32(p.line), Character: uint32(utilities.UTF16Column(lines[p.line], p.offset))}
+}
+
+// callArgument is an argument expression of a call
+type callArgument struct {
+	text       string
+	start, end textPos
+}
+
+// callSite is a call of a function with its arguments
+type callSite struct {
+	path string
+	// name is where the called name starts and open just past the opening
+	// parenthesis
+	name, open textPos
+	args       []callArgument
+}
+
+// symbolReferences finds the symbols matching a name and the references to
+// them, excluding their declarations. found is false when no symbol matched.
+func symbolReferences(ctx context.Context, client *lsp.Client, symbolName string) (resolvedName string, results []protocol.WorkspaceSymbolResult, refs []protocol.Location, found bool, err error) {
+	resolvedName, results, err = QuerySymbol(ctx, client, symbolName)
+	if err != nil {
+		return "", nil, nil, false, err
+	}
+	seen := make(map[protocol.Location]bool)
+	for _, symbol := range results {
+		if !callHierarchySymbolMatches(resolvedName, symbol) {
+			continue
+		}
+		found = true
+		loc, err := GetExactSymbolLocation(symbol)
+		if err != nil {
+			toolsLogger.Warn("Error getting exact location of %s: %v", symbol.GetName(), err)
+			continue
+		}
+		if err := client.OpenFile(ctx, client.FileLocation(loc).URI.Path()); err != nil {
+			toolsLogger.Error("Error opening file: %v", err)
+			continue
+		}
+		symbolRefs, err := client.References(ctx, protocol.ReferenceParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: loc.URI},
+				Position:     loc.Range.Start,
+			},
+			Context: protocol.ReferenceContext{IncludeDeclaration: false},
+		})
+		if err != nil {
+			return "", nil, nil, found, fmt.Errorf("failed to get references: %w", err)
+		}
+		for _, ref := range symbolRefs {
+			ref = client.FileLocation(ref)
+			if !seen[ref] {
+				seen[ref] = true
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return resolvedName, results, refs, found, nil
+}
+
+// findCallSites sorts the references to a function into calls, with their
+// arguments parsed, and other references, such as the function passed as a
+// value. Imports and generated files are left out.
+func findCallSites(ctx context.Context, client *lsp.Client, symbolName string) (string, []callSite, []protocol.Location, map[string][]string, error) {
+	resolvedName, results, refs, found, err := symbolReferences(ctx, client, symbolName)
+	if err != nil {
+		return "", nil, nil, nil, err
+	}
+	if !found {
+		return "", nil, nil, nil, codedErrorf(SymbolNotFound, "%s not found%s", symbolName, emptyResultHints(client, similarSymbolsHint(symbolName, results)))
+	}
+
+	files := make(map[string][]string)
+	var calls []callSite
+	var others []protocol.Location
+	for _, ref := range refs {
+		path := ref.URI.Path()
+		if excludeGenerated() && isGeneratedFile(path) {
+			continue
+		}
+		lines, ok := files[path]
+		if !ok {
+			content, err := lsp.ReadSourceFile(path)
+			if err != nil {
+				toolsLogger.Debug("Skipping %s: %v", path, err)
+			}
+			lines = strings.Split(string(content), "\n")
+			files[path] = lines
+		}
+		line := int(ref.Range.Start.Line)
+		if line >= len(lines) || int(ref.Range.End.Line) >= len(lines) || isImportLine(lines[line]) {
+			continue
+		}
+		name := textPos{line, utilities.ByteOffset(lines[line], int(ref.Range.Start.Character))}
+		end := textPos{int(ref.Range.End.Line), utilities.ByteOffset(lines[ref.Range.End.Line], int(ref.Range.End.Character))}
+		call, ok := parseCall(lines, end)
+		if !ok {
+			others = append(others, ref)
+			continue
+		}
+		call.path, call.name = path, name
+		calls = append(calls, call)
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].path != calls[j].path {
+			return calls[i].path < calls[j].path
+		}
+		return calls[i].name.line < calls[j].name.line || calls[i].name.line == calls[j].name.line && calls[i].name.offset < calls[j].name.offset
+	})
+	return resolvedName, calls, others, files, nil
+}
+
+// parseCall parses the argument list of a call whose callee ends at pos,
+// after any type arguments such as Go's [T] or the <T> of other languages.
+// It fails when no argument list follows or it isn't closed.
+func parseCall(lines []string, pos textPos) (callSite, bool) {
+	s := &callScanner{lines: lines, pos: pos}
+	s.skipSpace()
+	if s.peek() == ':' && s.peekAt(1) == ':' && s.peekAt(2) == '<' {
+		// Rust's turbofish
+		s.advance()
+		s.advance()
+	}
+	if c := s.peek(); c == '[' || c == '<' {
+		if !s.skipBalanced() {
+			return callSite{}, false
+		}
+		s.skipSpace()
+	}
+	if s.peek() != '(' {
+		return callSite{}, false
+	}
+	s.advance()
+
+	call := callSite{open: s.pos}
+	var current strings.Builder
+	s.skipGap()
+	// end follows the last character of the argument that isn't space or a
+	// comment
+	start, end := s.pos, s.pos
+	depth := 0
+	finish := func() {
+		call.args = append(call.args, callArgument{text: collapseSpace(current.String()), start: start, end: end})
+		current.Reset()
+	}
+	for !s.done() {
+		c := s.peek()
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			from := s.pos
+			s.skipString(c)
+			current.WriteString(s.text(from, s.pos))
+			end = s.pos
+			continue
+		case c == '/' && s.peekAt(1) == '/':
+			s.skipLine()
+			current.WriteByte('\n')
+			continue
+		case c == '/' && s.peekAt(1) == '*':
+			s.skipBlockComment()
+			continue
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case (c == ')' || c == ']' || c == '}') && depth > 0:
+			depth--
+		case c == ')':
+			// A comma may follow the last argument
+			if strings.TrimSpace(current.String()) != "" {
+				finish()
+			}
+			return call, true
+		case c == ',' && depth == 0:
+			finish()
+			s.advance()
+			s.skipGap()
+			start, end = s.pos, s.pos
+			continue
+		}
+		if c == 0 {
+			current.WriteByte('\n')
+			s.advance()
+			continue
+		}
+		current.WriteByte(c)
+		s.advance()
+		if c != ' ' && c != '\t' && c != '\r' {
+			end = s.pos
+		}
+	}
+	return callSite{}, false
+}
+
+// collapseSpace joins the lines of an argument with single spaces
+func collapseSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// callScanner walks the lines of a file byte by byte. A line's end reads as
+// 0.
+type callScanner struct {
+	lines []string
+	pos   textPos
+}
+
+func (s *callScanner) done() bool {
+	return s.pos.line >= len(s.lines)
+}
+
+func (s *callScanner) peekAt(n int) byte {
+	pos := s.pos
+	for ; n > 0 && pos.line < len(s.lines); n-- {
+		if pos.offset >= len(s.lines[pos.line]) {
+			pos = textPos{pos.line + 1, 0}
+		} else {
+			pos.offset++
+		}
+	}
+	if pos.line >= len(s.lines) || pos.offset >= len(s.lines[pos.line]) {
+		return 0
+	}
+	return s.lines[pos.line][pos.offset]
+}
+
+func (s *callScanner) peek() byte {
+	return s.peekAt(0)
+}
+
+func (s *callScanner) advance() {
+	if s.done() {
+		return
+	}
+	if s.pos.offset >= len(s.lines[s.pos.line]) {
+		s.pos = textPos{s.pos.line + 1, 0}
+	} else {
+		s.pos.offset++
+	}
+}
+
+func (s *callScanner) skipSpace() {
+	for !s.done() {
+		switch s.peek() {
+		case ' ', '\t', '\r', 0:
+			s.advance()
+		default:
+			return
+		}
+	}
+}
+
+// skipGap moves past space and comments
+func (s *callScanner) skipGap() {
+	for s.skipSpace(); !s.done(); s.skipSpace() {
+		switch {
+		case s.peek() == '/' && s.peekAt(1) == '/':
+			s.skipLine()
+		case s.peek() == '/' && s.peekAt(1) == '*':
+			s.skipBlockComment()
+		default:
+			return
+		}
+	}
+}
+
+func (s *callScanner) skipLine() {
+	s.pos = textPos{s.pos.line + 1, 0}
+}
+
+func (s *callScanner) skipBlockComment() {
+	for s.advance(); !s.done(); s.advance() {
+		if s.peek() == '*' && s.peekAt(1) == '/' {
+			s.advance()
+			s.advance()
+			return
+		}
+	}
+}
+
+// skipString moves past a string or character literal. Only backquoted
+// strings run over several lines.
+func (s *callScanner) skipString(quote byte) {
+	line := s.pos.line
+	for s.advance(); !s.done(); s.advance() {
+		c := s.peek()
+		switch {
+		case c == '\\' && quote != '`':
+			s.advance()
+		case c == quote:
+			s.advance()
+			return
+		case c == 0 && quote != '`' && s.pos.line == line:
+			// An unclosed quote, such as an apostrophe in Rust lifetimes
+			return
+		}
+	}
+}
+
+// skipBalanced moves past a bracketed list, such as type arguments
+func (s *callScanner) skipBalanced() bool {
+	open := s.peek()
+	closing := map[byte]byte{'[': ']', '<': '>'}[open]
+	depth := 0
+	for ; !s.done(); s.advance() {
+		switch s.peek() {
+		case open:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				s.advance()
+				return true
+			}
+		case ';', '{', '}':
+			// Not type arguments, such as a comparison
+			return false
+		}
+	}
+	return false
+}
+
+// text returns the source between two positions
+func (s *callScanner) text(from, to textPos) string {
+	if from.line == to.line {
+		return s.lines[from.line][from.offset:to.offset]
+	}
+	parts := []string{s.lines[from.line][from.offset:]}
+	for line := from.line + 1; line < to.line; line++ {
+		parts = append(parts, s.lines[line])
+	}
+	if to.line < len(s.lines) {
+		parts = append(parts, s.lines[to.line][:to.offset])
+	}
+	return strings.Join(parts, "\n")
+}
+
+// keywordArgument matches arguments passed by name, as in Python and Kotlin
+var keywordArgument = regexp.MustCompile(`^([A-Za-z_]\w*)\s*=[^=]`)
+
+// parameterLabels returns the labels of a function's parameters from the
+// signature help at a call
+func parameterLabels(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, position protocol.Position) (string, []string) {
+	help := signatureHelp(ctx, client, uri, position)
+	if help == nil || len(help.Signatures) == 0 {
+		return "", nil
+	}
+	active := help.ActiveSignature
+	if int(active) >= len(help.Signatures) {
+		active = 0
+	}
+	signature := help.Signatures[active]
+	var labels []string
+	for _, param := range signature.Parameters {
+		switch v := param.Label.Value.(type) {
+		case string:
+			labels = append(labels, v)
+		case protocol.Tuple_ParameterInformation_label_Item1:
+			if int(v.Fld0) <= int(v.Fld1) && int(v.Fld1) <= len(signature.Label) {
+				labels = append(labels, signature.Label[v.Fld0:v.Fld1])
+			}
+		}
+	}
+	return signature.Label, labels
+}
+
+// argumentLabel names the parameter an argument is passed for: its keyword,
+// or the parameter at its position, the last one taking any extra arguments
+func argumentLabel(arg callArgument, i int, labels []string) string {
+	if m := keywordArgument.FindStringSubmatch(arg.text); m != nil {
+		return m[1]
+	}
+	switch {
+	case i < len(labels):
+		return labels[i]
+	case len(labels) > 0 && strings.Contains(labels[len(labels)-1], "..."):
+		return labels[len(labels)-1]
+	}
+	return fmt.Sprintf("argument %d", i+1)
+}
+
+// FindCallSites lists every call of a function with its argument
+// expressions, each labeled with the parameter it is passed for, so that
+// callers can be updated when its signature changes. References that aren't
+// calls are listed after them.
+func FindCallSites(ctx context.Context, client *lsp.Client, symbolName string) (string, error) {
+	resolvedName, calls, others, files, err := findCallSites(ctx, client, symbolName)
+	if err != nil {
+		return "", err
+	}
+	if len(calls) == 0 && len(others) == 0 {
+		return fmt.Sprintf("No calls of %s were found", resolvedName), nil
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Calls of %s: %d\n", resolvedName, len(calls))
+	var labels []string
+	if len(calls) > 0 {
+		first := calls[0]
+		var signature string
+		signature, labels = parameterLabels(ctx, client, protocol.URIFromPath(first.path), first.open.lspPosition(files[first.path]))
+		if signature != "" {
+			fmt.Fprintf(&out, "Signature: %s\n", signature)
+		}
+	}
+	for _, call := range calls {
+		lines := files[call.path]
+		fmt.Fprintf(&out, "\n%s:%d:%d: %s\n", call.path, call.name.line+1, call.name.offset+1, strings.TrimSpace(lines[call.name.line]))
+		if len(call.args) == 0 {
+			out.WriteString("  (no arguments)\n")
+		}
+		for i, arg := range call.args {
+			fmt.Fprintf(&out, "  %s: %s\n", argumentLabel(arg, i, labels), arg.text)
+		}
+	}
+	if len(others) > 0 {
+		fmt.Fprintf(&out, "\nOther references, which aren't calls and need updating by hand: %d\n", len(others))
+		for _, ref := range others {
+			lines := files[ref.URI.Path()]
+			fmt.Fprintf(&out, "%s:%d: %s\n", ref.URI.Path(), ref.Range.Start.Line+1, strings.TrimSpace(lines[ref.Range.Start.Line]))
+		}
+	}
+	return out.String(), nil
+}
+
+// AddCallArgument inserts an argument into every call of a function, at a
+// 1-indexed position or, for position 0, after the last argument, and
+// returns the diff. Calls with fewer arguments than the position get it
+// last. The function's own declaration isn't changed.
+func AddCallArgument(ctx context.Context, client *lsp.Client, symbolName, argument string, position int) (string, error) {
+	argument = strings.TrimSpace(argument)
+	if argument == "" {
+		return "", codedErrorf(InvalidArgument, "argument must not be empty")
+	}
+	if position < 0 {
+		return "", codedErrorf(InvalidArgument, "position must be at least 1, or 0 for the end, got %d", position)
+	}
+	resolvedName, calls, others, files, err := findCallSites(ctx, client, symbolName)
+	if err != nil {
+		return "", err
+	}
+	if len(calls) == 0 {
+		return fmt.Sprintf("No calls of %s were found", resolvedName), nil
+	}
+
+	edit := protocol.WorkspaceEdit{Changes: make(map[protocol.DocumentUri][]protocol.TextEdit)}
+	for _, call := range calls {
+		lines := files[call.path]
+		var insert textPos
+		var text string
+		switch {
+		case position > 0 && position <= len(call.args):
+			insert, text = call.args[position-1].start, argument+", "
+		case len(call.args) == 0:
+			insert, text = call.open, argument
+		default:
+			insert, text = call.args[len(call.args)-1].end, ", "+argument
+		}
+		pos := insert.lspPosition(lines)
+		uri := protocol.URIFromPath(call.path)
+		edit.Changes[uri] = append(edit.Changes[uri], protocol.TextEdit{Range: protocol.Range{Start: pos, End: pos}, NewText: text})
+	}
+
+	changes := &appliedChanges{before: make(map[string]string)}
+	changes.snapshot(edit)
+	if err := utilities.ApplyWorkspaceEdit(ctx, edit); err != nil {
+		return "", fmt.Errorf("failed to apply changes: %w", err)
+	}
+	syncChangedFiles(ctx, client, changes.files())
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Added %s to %d calls of %s. Change the declaration of %s to match.\n", argument, len(calls), resolvedName, resolvedName)
+	if len(others) > 0 {
+		fmt.Fprintf(&out, "\nThese references aren't calls and weren't changed:\n")
+		for _, ref := range others {
+			fmt.Fprintf(&out, "%s:%d: %s\n", ref.URI.Path(), ref.Range.Start.Line+1, strings.TrimSpace(files[ref.URI.Path()][ref.Range.Start.Line]))
+		}
+	}
+	out.WriteString("\n" + changes.diff())
+	return out.String(), nil
+}
@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCall(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		args   []string
+		ok     bool
+	}{
+		{"no arguments", "Run()", nil, true},
+		{"nested", `Run(ctx, f(a, b), []int{1, 2}, "x, y")`, []string{"ctx", "f(a, b)", "[]int{1, 2}", `"x, y"`}, true},
+		{"multiline", "Run(\n\tctx, // the context\n\topts,\n)", []string{"ctx", "opts"}, true},
+		{"comment in argument", "Run(a /* first */, b)", []string{"a", "b"}, true},
+		{"escaped quote", `Run("a\")", b)`, []string{`"a\")"`, "b"}, true},
+		{"go type arguments", "Run[int, string](a)", []string{"a"}, true},
+		{"typescript type arguments", "Run<T>(a)", []string{"a"}, true},
+		{"rust turbofish", "Run::<T>(a)", []string{"a"}, true},
+		{"keyword arguments", "Run(a, retries=3)", []string{"a", "retries=3"}, true},
+		{"passed as a value", "apply(Run, a)", nil, false},
+		{"comparison", "if Run < 3 {", nil, false},
+		{"unclosed", "Run(a,", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := strings.Split(tt.source, "\n")
+			call, ok := parseCall(lines, textPos{0, len("Run")})
+			require.Equal(t, tt.ok, ok)
+			var args []string
+			for _, arg := range call.args {
+				args = append(args, arg.text)
+			}
+			assert.Equal(t, tt.args, args)
+		})
+	}
+
+	// Arguments span their code without the space and comments around them
+	lines := []string{"Run(  ctx , // c", "\topts )"}
+	call, ok := parseCall(lines, textPos{0, 3})
+	require.True(t, ok)
+	assert.Equal(t, callArgument{text: "ctx", start: textPos{0, 6}, end: textPos{0, 9}}, call.args[0])
+	assert.Equal(t, callArgument{text: "opts", start: textPos{1, 1}, end: textPos{1, 5}}, call.args[1])
+}
+
+func TestArgumentLabel(t *testing.T) {
+	labels := []string{"ctx context.Context", "names ...string"}
+	assert.Equal(t, "ctx context.Context", argumentLabel(callArgument{text: "ctx"}, 0, labels))
+	assert.Equal(t, "names ...string", argumentLabel(callArgument{text: `"b"`}, 2, labels))
+	assert.Equal(t, "retries", argumentLabel(callArgument{text: "retries=3"}, 1, nil))
+	assert.Equal(t, "argument 2", argumentLabel(callArgument{text: "a == b"}, 1, nil))
+}
+
+func TestCallSites(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"go.mod":   "module example.com/fetch\n\ngo 1.24\n",
+		"fetch.go": "package fetch\n\nfunc Fetch(url string, retries int) error { return nil }\n",
+		"main.go":  "package fetch\n\nfunc main() {\n\tFetch(\"a\", 1)\n\tFetch(\n\t\t\"b\",\n\t\t2,\n\t)\n\tgo apply(Fetch)\n}\n",
+	})
+	path := func(name string) string { return filepath.Join(dir, name) }
+	at := func(name string, line, character uint32) protocol.Location {
+		return protocol.Location{URI: protocol.URIFromPath(path(name)), Range: protocol.Range{
+			Start: protocol.Position{Line: line, Character: character},
+			End:   protocol.Position{Line: line, Character: character + 5},
+		}}
+	}
+
+	server := lsptest.NewServer(protocol.ServerCapabilities{})
+	server.Respond("workspace/symbol", []protocol.SymbolInformation{{Name: "Fetch", Kind: protocol.Function, Location: at("fetch.go", 2, 5)}})
+	server.Respond("textDocument/references", []protocol.Location{at("main.go", 3, 1), at("main.go", 4, 1), at("main.go", 8, 10)})
+	server.Respond("textDocument/signatureHelp", protocol.SignatureHelp{Signatures: []protocol.SignatureInformation{{
+		Label:      "Fetch(url string, retries int) error",
+		Parameters: []protocol.ParameterInformation{{Label: protocol.Or_ParameterInformation_label{Value: "url string"}}, {Label: protocol.Or_ParameterInformation_label{Value: "retries int"}}},
+	}}})
+	client := lsptest.Start(t, server, dir)
+	ctx := context.Background()
+
+	t.Run("find", func(t *testing.T) {
+		result, err := FindCallSites(ctx, client, "Fetch")
+		require.NoError(t, err)
+		assert.Contains(t, result, "Calls of Fetch: 2\nSignature: Fetch(url string, retries int) error\n")
+		assert.Contains(t, result, path("main.go")+":4:2: Fetch(\"a\", 1)\n  url string: \"a\"\n  retries int: 1\n")
+		assert.Contains(t, result, path("main.go")+":5:2: Fetch(\n  url string: \"b\"\n  retries int: 2\n")
+		assert.Contains(t, result, "need updating by hand: 1\n"+path("main.go")+":9: go apply(Fetch)\n")
+	})
+
+	t.Run("add argument", func(t *testing.T) {
+		_, err := AddCallArgument(ctx, client, "Fetch", "ctx", 1)
+		require.NoError(t, err)
+		content, err := os.ReadFile(path("main.go"))
+		require.NoError(t, err)
+		assert.Equal(t, "package fetch\n\nfunc main() {\n\tFetch(ctx, \"a\", 1)\n\tFetch(\n\t\tctx, \"b\",\n\t\t2,\n\t)\n\tgo apply(Fetch)\n}\n", string(content))
+
+		_, err = AddCallArgument(ctx, client, "Fetch", "nil", 0)
+		require.NoError(t, err)
+		content, err = os.ReadFile(path("main.go"))
+		require.NoError(t, err)
+		assert.Equal(t, "package fetch\n\nfunc main() {\n\tFetch(ctx, \"a\", 1, nil)\n\tFetch(\n\t\tctx, \"b\",\n\t\t2, nil,\n\t)\n\tgo apply(Fetch)\n}\n", string(content))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := AddCallArgument(ctx, client, "Fetch", " ", 0)
+		assert.Equal(t, InvalidArgument, ErrorCodeOf(err))
+	})
+}
@@ -0,0 +1,215 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/git"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// maxChangedDiagnosticFiles bounds the number of changed files opened in the
+// language server for one request
+const maxChangedDiagnosticFiles = 100
+
+// changedDiagnosticsTimeout bounds the wait for the language server to
+// publish diagnostics for the files opened for a request
+const changedDiagnosticsTimeout = 10 * time.Second
+
+// changedFile is a file that differs from the base ref, with its diagnostics
+type changedFile struct {
+	path string
+	// lines are the changed lines. They are ignored for new files, where
+	// every line counts as changed.
+	lines       []git.LineRange
+	isNew       bool
+	diagnostics []protocol.Diagnostic
+}
+
+// onChangedLine reports whether a diagnostic starts on a changed line
+func (f *changedFile) onChangedLine(diag protocol.Diagnostic) bool {
+	if f.isNew {
+		return true
+	}
+	line := int(diag.Range.Start.Line) + 1
+	for _, r := range f.lines {
+		if line >= r.Start && line <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// GetChangedFilesDiagnostics returns diagnostics only for the files that
+// differ from baseRef in the working tree, including untracked files.
+// Diagnostics on the changed lines themselves are listed first.
+func GetChangedFilesDiagnostics(ctx context.Context, client *lsp.Client, workspaceDir, baseRef string) (string, error) {
+	repo, err := git.Open(ctx, workspaceDir)
+	if err != nil {
+		return "", fmt.Errorf("workspace is not a git repository: %w", err)
+	}
+	changes, err := repo.ChangedLines(ctx, baseRef, workspaceDir)
+	if err != nil {
+		return "", err
+	}
+	untracked, err := repo.Untracked(ctx, workspaceDir)
+	if err != nil {
+		return "", err
+	}
+
+	var files []*changedFile
+	for path, lines := range changes {
+		files = append(files, &changedFile{path: path, lines: lines})
+	}
+	for _, path := range untracked {
+		files = append(files, &changedFile{path: path, isNew: true})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+
+	// Only files the language server may understand are opened. Files that
+	// weren't open already are closed again afterwards, and their first
+	// diagnostics are waited for.
+	var checked []*changedFile
+	var opened []string
+	pending := make(map[protocol.DocumentUri]uint64)
+	skipped := 0
+	for _, file := range files {
+		if !lsp.IsNotebook(file.path) && lsp.DetectLanguageID("file://"+file.path) == "" {
+			continue
+		}
+		if len(checked) == maxChangedDiagnosticFiles {
+			skipped++
+			continue
+		}
+		uri := protocol.URIFromPath(file.path)
+		wasOpen := client.IsFileOpen(file.path)
+		version := client.DiagnosticsVersion(uri)
+		if err := client.OpenFile(ctx, file.path); err != nil {
+			toolsLogger.Debug("Skipping %s: %v", file.path, err)
+			continue
+		}
+		if !wasOpen {
+			opened = append(opened, file.path)
+			// Notebook diagnostics are published for each cell
+			if !lsp.IsNotebook(file.path) {
+				pending[uri] = version
+			}
+		}
+		checked = append(checked, file)
+	}
+	defer func() {
+		for _, path := range opened {
+			if err := client.CloseFile(ctx, path); err != nil {
+				toolsLogger.Debug("Error closing %s: %v", path, err)
+			}
+		}
+	}()
+	if len(checked) == 0 {
+		return fmt.Sprintf("No changed source files relative to %s", baseRef), nil
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, changedDiagnosticsTimeout)
+	if !client.WaitForDiagnostics(waitCtx, pending) {
+		toolsLogger.Debug("Timed out waiting for diagnostics of changed files")
+	}
+	cancel()
+
+	build, _ := LastBuildDiagnostics()
+	for _, file := range checked {
+		uri := protocol.URIFromPath(file.path)
+		// Notebook cells only publish diagnostics
+		if !lsp.IsNotebook(file.path) {
+			_, err := client.Diagnostic(ctx, protocol.DocumentDiagnosticParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			})
+			if err != nil {
+				toolsLogger.Debug("Failed to get diagnostics for %s: %v", file.path, err)
+			}
+		}
+		file.diagnostics = client.GetFileDiagnostics(uri)
+		if build != nil {
+			for _, diag := range build.Files[protocol.URIFromPath(filepath.Clean(file.path))] {
+				file.diagnostics, _ = lsp.MergeDiagnostic(file.diagnostics, diag)
+			}
+		}
+	}
+
+	result := formatChangedFilesDiagnostics(baseRef, checked)
+	if skipped > 0 {
+		result += fmt.Sprintf("%d more changed files were not checked\n", skipped)
+	}
+	return result, nil
+}
+
+// formatChangedFilesDiagnostics lists files with diagnostics on changed lines
+// first, then files with diagnostics elsewhere, then the clean files
+func formatChangedFilesDiagnostics(baseRef string, files []*changedFile) string {
+	type fileReport struct {
+		file      *changedFile
+		onChanged []protocol.Diagnostic
+		elsewhere []protocol.Diagnostic
+	}
+	var reports []fileReport
+	var clean []string
+	total, totalOnChanged := 0, 0
+	for _, file := range files {
+		if len(file.diagnostics) == 0 {
+			clean = append(clean, file.path)
+			continue
+		}
+		report := fileReport{file: file}
+		for _, diag := range file.diagnostics {
+			if file.onChangedLine(diag) {
+				report.onChanged = append(report.onChanged, diag)
+			} else {
+				report.elsewhere = append(report.elsewhere, diag)
+			}
+		}
+		total += len(file.diagnostics)
+		totalOnChanged += len(report.onChanged)
+		reports = append(reports, report)
+	}
+	sort.SliceStable(reports, func(i, j int) bool {
+		return len(reports[i].onChanged) > len(reports[j].onChanged)
+	})
+
+	byLine := func(diagnostics []protocol.Diagnostic) {
+		sort.SliceStable(diagnostics, func(i, j int) bool {
+			return diagnostics[i].Range.Start.Line < diagnostics[j].Range.Start.Line
+		})
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Changed files relative to %s: %d\nDiagnostics: %d (%d on changed lines)\n\n", baseRef, len(files), total, totalOnChanged)
+	for _, report := range reports {
+		status := "modified"
+		if report.file.isNew {
+			status = "new"
+		}
+		fmt.Fprintf(&out, "---\n\n%s (%s)\nDiagnostics in File: %d\n", report.file.path, status, len(report.file.diagnostics))
+		if len(report.onChanged) > 0 {
+			byLine(report.onChanged)
+			out.WriteString("On changed lines:\n")
+			for _, diag := range report.onChanged {
+				out.WriteString(formatDiagnosticSummary(diag) + "\n")
+			}
+		}
+		if len(report.elsewhere) > 0 {
+			byLine(report.elsewhere)
+			out.WriteString("Elsewhere in file:\n")
+			for _, diag := range report.elsewhere {
+				out.WriteString(formatDiagnosticSummary(diag) + "\n")
+			}
+		}
+		out.WriteString("\n")
+	}
+	if len(clean) > 0 {
+		fmt.Fprintf(&out, "---\n\nFiles without diagnostics: %d\n%s\n\n", len(clean), strings.Join(clean, "\n"))
+	}
+	return out.String()
+}
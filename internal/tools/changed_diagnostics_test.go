@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/git"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatChangedFilesDiagnostics(t *testing.T) {
+	diagAt := func(line uint32, message string) protocol.Diagnostic {
+		return protocol.Diagnostic{
+			Range:    protocol.Range{Start: protocol.Position{Line: line}},
+			Severity: protocol.SeverityError,
+			Message:  message,
+		}
+	}
+	files := []*changedFile{
+		{
+			path:        "/ws/a.go",
+			lines:       []git.LineRange{{Start: 10, End: 12}},
+			diagnostics: []protocol.Diagnostic{diagAt(0, "unused import")},
+		},
+		{
+			path:        "/ws/b.go",
+			lines:       []git.LineRange{{Start: 3, End: 3}},
+			diagnostics: []protocol.Diagnostic{diagAt(20, "old problem"), diagAt(2, "undefined: x")},
+		},
+		{path: "/ws/c.go", isNew: true},
+	}
+
+	out := formatChangedFilesDiagnostics("main", files)
+	assert.Contains(t, out, "Changed files relative to main: 3\nDiagnostics: 3 (1 on changed lines)")
+
+	// The file with a diagnostic on a changed line comes first
+	b := strings.Index(out, "/ws/b.go (modified)")
+	a := strings.Index(out, "/ws/a.go (modified)")
+	assert.True(t, b >= 0 && a > b, out)
+	assert.Contains(t, out, "On changed lines:\nERROR at L3:C1: undefined: x\nElsewhere in file:\nERROR at L21:C1: old problem\n")
+	assert.Contains(t, out, "Files without diagnostics: 1\n/ws/c.go")
+}
+
+func TestChangedFileOnChangedLine(t *testing.T) {
+	file := &changedFile{lines: []git.LineRange{{Start: 5, End: 7}}}
+	assert.True(t, file.onChangedLine(protocol.Diagnostic{Range: protocol.Range{Start: protocol.Position{Line: 4}}}))
+	assert.False(t, file.onChangedLine(protocol.Diagnostic{Range: protocol.Range{Start: protocol.Position{Line: 7}}}))
+
+	newFile := &changedFile{isNew: true}
+	assert.True(t, newFile.onChangedLine(protocol.Diagnostic{Range: protocol.Range{Start: protocol.Position{Line: 100}}}))
+}
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Chooser asks the user to pick one of several options and returns the
+// index of the one picked
+type Chooser func(ctx context.Context, question string, options []string) (int, error)
+
+// chooserKey holds the Chooser of a context
+type chooserKey struct{}
+
+// WithChooser returns a context under which ambiguous operations ask chooser,
+// which the MCP server sets to one that asks through the session's client.
+// Without a Chooser, or when the client can't be asked, they fail with an
+// AmbiguousError listing the options.
+func WithChooser(ctx context.Context, chooser Chooser) context.Context {
+	return context.WithValue(ctx, chooserKey{}, chooser)
+}
+
+// ErrCannotAsk is returned by a Chooser when the client doesn't support
+// being asked
+var ErrCannotAsk = errors.New("the client can't be asked to choose")
+
+// ErrNotChosen is returned by a Chooser when the user declined or cancelled
+var ErrNotChosen = errors.New("no option was chosen")
+
+// AmbiguousError lists the options of an operation that could mean several
+// things, with a hint on how to pick one in the tool call
+type AmbiguousError struct {
+	Question string
+	Options  []string
+	Hint     string
+}
+
+func (e *AmbiguousError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", e.Question)
+	for i, option := range e.Options {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, option)
+	}
+	if e.Hint != "" {
+		b.WriteString(e.Hint)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// chooseOption returns the index of the option to use. A single option is
+// used as is; between several the user is asked, and if they can't be, an
+// AmbiguousError is returned.
+func chooseOption(ctx context.Context, question string, options []string, hint string) (int, error) {
+	if len(options) == 1 {
+		return 0, nil
+	}
+	if choose, ok := ctx.Value(chooserKey{}).(Chooser); ok && choose != nil {
+		i, err := choose(ctx, question, options)
+		switch {
+		case err == nil && i >= 0 && i < len(options):
+			toolsLogger.Info("Chose %q for: %s", options[i], question)
+			return i, nil
+		case err == nil:
+			return -1, fmt.Errorf("invalid choice %d", i)
+		case !errors.Is(err, ErrCannotAsk):
+			return -1, err
+		}
+	}
+	return -1, &AmbiguousError{Question: question, Options: options, Hint: hint}
+}
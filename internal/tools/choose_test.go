@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChooseOption(t *testing.T) {
+	ctx := context.Background()
+	options := []string{"Foo at /ws/a.go L3:C6", "Foo at /ws/b.go L9:C6"}
+
+	// A single option needs no choice
+	i, err := chooseOption(ctx, "Which Foo?", options[:1], "")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, i)
+
+	// Without a way to ask, the options are listed
+	_, err = chooseOption(ctx, "Which Foo?", options, "Pass a position.")
+	var ambiguous *AmbiguousError
+	if assert.ErrorAs(t, err, &ambiguous) {
+		assert.Equal(t, "Which Foo?\n1. Foo at /ws/a.go L3:C6\n2. Foo at /ws/b.go L9:C6\nPass a position.", err.Error())
+	}
+
+	ctx = WithChooser(context.Background(), func(ctx context.Context, question string, options []string) (int, error) {
+		return -1, ErrCannotAsk
+	})
+	_, err = chooseOption(ctx, "Which Foo?", options, "")
+	assert.ErrorAs(t, err, &ambiguous)
+
+	ctx = WithChooser(context.Background(), func(ctx context.Context, question string, options []string) (int, error) {
+		return 1, nil
+	})
+	i, err = chooseOption(ctx, "Which Foo?", options, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, i)
+
+	// Declining ends the operation without listing the options again
+	ctx = WithChooser(context.Background(), func(ctx context.Context, question string, options []string) (int, error) {
+		return -1, ErrNotChosen
+	})
+	_, err = chooseOption(ctx, "Which Foo?", options, "")
+	assert.True(t, errors.Is(err, ErrNotChosen))
+}
@@ -0,0 +1,267 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// codeActionsAt lists the code actions the server offers for a range,
+// restricted to the given kinds and their sub-kinds. Commands offered on
+// their own are returned as code actions that only hold the command.
+func codeActionsAt(ctx context.Context, client *lsp.Client, filePath string, rng protocol.Range, kinds []protocol.CodeActionKind) ([]protocol.CodeAction, error) {
+	if lsp.IsNotebook(filePath) {
+		return nil, codedErrorf(UnsupportedCapability, "code actions are not supported for notebooks")
+	}
+	if err := client.OpenFile(ctx, filePath); err != nil {
+		return nil, fmt.Errorf("could not open file: %w", err)
+	}
+
+	uri := protocol.URIFromPath(filePath)
+	// Servers attach quick fixes to the diagnostics they are given
+	diagnostics := []protocol.Diagnostic{}
+	for _, diag := range client.GetFileDiagnostics(uri) {
+		if diag.Range.Start.Line <= rng.End.Line && diag.Range.End.Line >= rng.Start.Line {
+			diagnostics = append(diagnostics, diag)
+		}
+	}
+	triggerKind := protocol.CodeActionInvoked
+	results, err := client.CodeAction(ctx, protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        rng,
+		Context: protocol.CodeActionContext{
+			Diagnostics: diagnostics,
+			Only:        kinds,
+			TriggerKind: &triggerKind,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get code actions: %w", err)
+	}
+
+	var actions []protocol.CodeAction
+	// Servers may offer the same fix once for each diagnostic it applies to
+	offered := make(map[string]bool)
+	for _, result := range results {
+		switch v := result.Value.(type) {
+		case protocol.CodeAction:
+			if len(kinds) > 0 && !codeActionKindIn(v.Kind, kinds) || offered[lsp.CodeActionKey(v)] {
+				continue
+			}
+			offered[lsp.CodeActionKey(v)] = true
+			actions = append(actions, v)
+		case protocol.Command:
+			// Bare commands carry no kind, so only keep them when unfiltered
+			if len(kinds) == 0 {
+				actions = append(actions, protocol.CodeAction{Title: v.Title, Command: &v})
+			}
+		}
+	}
+	return actions, nil
+}
+
+// codeActionKindIn reports whether kind is one of kinds or nested under one,
+// e.g. "refactor.extract.function" under "refactor.extract"
+func codeActionKindIn(kind protocol.CodeActionKind, kinds []protocol.CodeActionKind) bool {
+	for _, k := range kinds {
+		if kind == k || strings.HasPrefix(string(kind), string(k)+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// appliedChanges holds the content of the files a code action changed, from
+// before it was applied
+type appliedChanges struct {
+	mu     sync.Mutex
+	before map[string]string
+}
+
+// snapshot records the current content of the files an edit will change,
+// keeping the earliest content if a file changes more than once
+func (c *appliedChanges) snapshot(edit protocol.WorkspaceEdit) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, file := range workspaceEditFiles(edit) {
+		if _, ok := c.before[file]; ok {
+			continue
+		}
+		content, err := utilities.ReadFile(file)
+		if err != nil {
+			// The edit creates the file
+			content = nil
+		}
+		c.before[file] = string(content)
+	}
+	for _, change := range edit.DocumentChanges {
+		if change.RenameFile != nil {
+			if content, err := utilities.ReadFile(change.RenameFile.OldURI.Path()); err == nil {
+				c.before[change.RenameFile.OldURI.Path()] = string(content)
+			}
+		}
+	}
+}
+
+// files lists the changed files in order
+func (c *appliedChanges) files() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	files := make([]string, 0, len(c.before))
+	for file := range c.before {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// renamed records that a file changed by the action was moved afterwards
+func (c *appliedChanges) renamed(from, to string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if before, ok := c.before[from]; ok {
+		delete(c.before, from)
+		c.before[to] = before
+	}
+}
+
+// diff returns a unified diff of every changed file
+func (c *appliedChanges) diff() string {
+	var out strings.Builder
+	for _, file := range c.files() {
+		after, err := utilities.ReadFile(file)
+		if err != nil {
+			after = nil
+		}
+		out.WriteString(utilities.UnifiedDiff(file, c.before[file], string(after)))
+	}
+	return out.String()
+}
+
+// applyCodeAction resolves a code action if its edit was left out, applies
+// the edit and runs its command. Edits the server sends back while the
+// command runs are recorded along with the action's own edit.
+func applyCodeAction(ctx context.Context, client *lsp.Client, action protocol.CodeAction) (*appliedChanges, error) {
+	if action.Disabled != nil {
+		return nil, codedErrorf(UnsupportedCapability, "%s is not available: %s", action.Title, action.Disabled.Reason)
+	}
+	if action.Edit == nil && action.Data != nil {
+		resolved, err := client.ResolveCodeAction(ctx, action)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve code action: %w", err)
+		}
+		action = resolved
+	}
+
+	changes := &appliedChanges{before: make(map[string]string)}
+	if action.Edit != nil {
+		changes.snapshot(*action.Edit)
+		if err := utilities.ApplyWorkspaceEdit(ctx, *action.Edit); err != nil {
+			return nil, fmt.Errorf("failed to apply changes: %w", err)
+		}
+	}
+	if action.Command != nil {
+		stop := lsp.WatchApplyEdits(changes.snapshot)
+		_, err := client.ExecuteCommand(ctx, protocol.ExecuteCommandParams{
+			Command:   action.Command.Command,
+			Arguments: action.Command.Arguments,
+		})
+		stop()
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute command %s: %w", action.Command.Command, err)
+		}
+	}
+
+	syncChangedFiles(ctx, client, changes.files())
+	return changes, nil
+}
+
+// serverCommand builds a command of the language server from its
+// arguments, to run with applyCodeAction
+func serverCommand(command string, arguments ...any) (protocol.Command, error) {
+	encoded := make([]json.RawMessage, len(arguments))
+	for i, argument := range arguments {
+		data, err := json.Marshal(argument)
+		if err != nil {
+			return protocol.Command{}, fmt.Errorf("failed to encode arguments of %s: %w", command, err)
+		}
+		encoded[i] = data
+	}
+	return protocol.Command{Title: command, Command: command, Arguments: encoded}, nil
+}
+
+// syncChangedFiles sends the new content of changed files that are open, so
+// that requests made straight away see it
+func syncChangedFiles(ctx context.Context, client *lsp.Client, files []string) {
+	for _, file := range files {
+		if !client.IsFileOpen(file) {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			if err := client.CloseFile(ctx, file); err != nil {
+				toolsLogger.Debug("Error closing %s: %v", file, err)
+			}
+			continue
+		}
+		if err := client.NotifyChange(ctx, file); err != nil {
+			toolsLogger.Debug("Error syncing %s: %v", file, err)
+		}
+	}
+}
+
+// lineColumnRange converts a 1-indexed, end-inclusive selection to an LSP
+// range. The end column points at the last selected character.
+func lineColumnRange(startLine, startColumn, endLine, endColumn int) (protocol.Range, error) {
+	if startLine < 1 || startColumn < 1 || endLine < startLine || endColumn < 1 || (endLine == startLine && endColumn < startColumn) {
+		return protocol.Range{}, codedErrorf(PositionInvalid, "invalid range L%d:C%d - L%d:C%d", startLine, startColumn, endLine, endColumn)
+	}
+	return protocol.Range{
+		Start: protocol.Position{Line: uint32(startLine - 1), Character: uint32(startColumn - 1)},
+		End:   protocol.Position{Line: uint32(endLine - 1), Character: uint32(endColumn)},
+	}, nil
+}
+
+// formatCodeActions lists code actions by kind and title, and by the server
+// offering them when several servers do
+func formatCodeActions(client *lsp.Client, actions []protocol.CodeAction) string {
+	var out strings.Builder
+	for i, action := range actions {
+		fmt.Fprintf(&out, "%d. %s", i+1, codeActionTitle(client, action))
+		if action.Disabled != nil {
+			fmt.Fprintf(&out, " - unavailable: %s", action.Disabled.Reason)
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+// codeActionTitles returns the titles of code actions, for choosing one
+func codeActionTitles(client *lsp.Client, actions []protocol.CodeAction) []string {
+	titles := make([]string, len(actions))
+	for i, action := range actions {
+		titles[i] = codeActionTitle(client, action)
+	}
+	return titles
+}
+
+// codeActionTitle describes a code action by its title and kind, and the
+// server offering it when several servers do, as in "Fix this problem
+// (quickfix) from eslint"
+func codeActionTitle(client *lsp.Client, action protocol.CodeAction) string {
+	title := action.Title
+	if action.Kind != "" {
+		title += fmt.Sprintf(" (%s)", action.Kind)
+	}
+	if server := client.CodeActionServer(action); server != "" {
+		title += " from " + server
+	}
+	return title
+}
@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeActionKindIn(t *testing.T) {
+	kinds := []protocol.CodeActionKind{protocol.RefactorExtract}
+	assert.True(t, codeActionKindIn("refactor.extract", kinds))
+	assert.True(t, codeActionKindIn("refactor.extract.function", kinds))
+	assert.False(t, codeActionKindIn("refactor.extractor", kinds))
+	assert.False(t, codeActionKindIn("refactor.inline", kinds))
+}
+
+func TestLineColumnRange(t *testing.T) {
+	rng, err := lineColumnRange(2, 5, 3, 10)
+	require.NoError(t, err)
+	assert.Equal(t, protocol.Range{
+		Start: protocol.Position{Line: 1, Character: 4},
+		End:   protocol.Position{Line: 2, Character: 10},
+	}, rng)
+
+	_, err = lineColumnRange(3, 1, 2, 1)
+	assert.Error(t, err)
+}
+
+func TestAppliedChangesDiff(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{"a.go": "package a\n\nvar x = 1\n"})
+	path := filepath.Join(dir, "a.go")
+	created := filepath.Join(dir, "b.go")
+
+	changes := &appliedChanges{before: make(map[string]string)}
+	changes.snapshot(protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			protocol.DocumentUri("file://" + path):    {},
+			protocol.DocumentUri("file://" + created): {},
+		},
+	})
+	require.NoError(t, os.WriteFile(path, []byte("package a\n\nvar y = 1\n"), 0644))
+	require.NoError(t, os.WriteFile(created, []byte("package a\n"), 0644))
+
+	assert.Equal(t, []string{path, created}, changes.files())
+	assert.Equal(t, "--- "+path+"\n+++ "+path+"\n@@ -1,3 +1,3 @@\n package a\n \n-var x = 1\n+var y = 1\n"+
+		"--- "+created+"\n+++ "+created+"\n@@ -0,0 +1,1 @@\n+package a\n", changes.diff())
+}
+
+func TestCodeActionsAtDeduplicates(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{"main.go": "package main\n\nfunc main() {\n\tfmt.Println()\n}\n"})
+	path := filepath.Join(dir, "main.go")
+	uri := protocol.URIFromPath(path)
+
+	fix := func(text string, diagnostics ...protocol.Diagnostic) protocol.CodeAction {
+		return protocol.CodeAction{
+			Title:       "Add import",
+			Kind:        protocol.QuickFix,
+			Diagnostics: diagnostics,
+			Edit: &protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+				uri: {{Range: protocol.Range{Start: protocol.Position{Line: 1}, End: protocol.Position{Line: 1}}, NewText: text}},
+			}},
+		}
+	}
+	// The same fix for the language server's diagnostic and for the one
+	// run_build merged into it, and another with the same title and other
+	// edits
+	lspDiagnostic := protocol.Diagnostic{Range: lineRange(3, 3), Message: "undefined: fmt", Source: "compiler"}
+	buildDiagnostic := protocol.Diagnostic{Range: lineRange(3, 3), Message: "undefined: fmt", Source: "build"}
+	fromLSP := fix("import \"fmt\"\n", lspDiagnostic)
+	fromBuild := fix("import \"fmt\"\n", buildDiagnostic)
+	other := fix("import fmt \"github.com/example/fmt\"\n", lspDiagnostic)
+
+	assert.Equal(t, lsp.CodeActionKey(fromLSP), lsp.CodeActionKey(fromBuild))
+	assert.NotEqual(t, lsp.CodeActionKey(fromLSP), lsp.CodeActionKey(other))
+
+	server := lsptest.NewServer(protocol.ServerCapabilities{})
+	server.Respond("textDocument/codeAction", []protocol.CodeAction{fromLSP, fromBuild, other})
+	client := lsptest.Start(t, server, dir)
+
+	actions, err := codeActionsAt(context.Background(), client, path, lineRange(3, 3), nil)
+	require.NoError(t, err)
+	require.Len(t, actions, 2)
+	assert.Equal(t, fromLSP.Edit, actions[0].Edit)
+	assert.Equal(t, other.Edit, actions[1].Edit)
+}
+
+func TestCodeActionsAtMergesServers(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{"app.ts": "let x: any = 1;\nconsole.log(x);\n"})
+	path := filepath.Join(dir, "app.ts")
+	uri := protocol.URIFromPath(path)
+	ctx := context.Background()
+
+	// tsserver and ESLint both report the any, only ESLint the console call
+	anyDiagnostic := protocol.Diagnostic{Range: lineRange(0, 0), Severity: protocol.SeverityWarning, Message: "Unexpected any"}
+	consoleDiagnostic := protocol.Diagnostic{Range: lineRange(1, 1), Severity: protocol.SeverityWarning, Message: "Unexpected console statement"}
+	typescriptAny := anyDiagnostic
+	typescriptAny.Source = "typescript"
+
+	removeAny := protocol.CodeAction{Title: "Remove the type annotation", Kind: protocol.QuickFix, Edit: &protocol.WorkspaceEdit{}}
+	data := json.RawMessage(`{"rule":"no-console"}`)
+	disableRule := protocol.CodeAction{Title: "Disable no-console for this line", Kind: protocol.QuickFix, Data: &data}
+
+	typescript := lsptest.NewServer(protocol.ServerCapabilities{CodeActionProvider: true})
+	typescript.OnNotification("textDocument/didOpen", func(json.RawMessage) {
+		_ = typescript.PublishDiagnostics(uri, []protocol.Diagnostic{typescriptAny})
+	})
+	typescript.Respond("textDocument/codeAction", []protocol.CodeAction{removeAny})
+	client := lsptest.Start(t, typescript, dir)
+	client.SetName("typescript-language-server")
+
+	eslint := lsptest.NewServer(protocol.ServerCapabilities{
+		CodeActionProvider:     true,
+		ExecuteCommandProvider: &protocol.ExecuteCommandOptions{Commands: []string{"eslint.applyDisableLine"}},
+	})
+	eslint.OnNotification("textDocument/didOpen", func(json.RawMessage) {
+		_ = eslint.PublishDiagnostics(uri, []protocol.Diagnostic{anyDiagnostic, consoleDiagnostic})
+	})
+	eslint.Respond("textDocument/codeAction", []protocol.CodeAction{removeAny, disableRule})
+	eslint.Handle("codeAction/resolve", func(json.RawMessage) (any, error) {
+		resolved := disableRule
+		resolved.Command = &protocol.Command{Title: "Disable", Command: "eslint.applyDisableLine"}
+		return resolved, nil
+	})
+	eslint.Respond("workspace/executeCommand", nil)
+	delegate := lsptest.Start(t, eslint, dir)
+	delegate.SetName("eslint")
+	require.NoError(t, client.AddDelegate(ctx, delegate, []protocol.LanguageKind{"typescript"}))
+
+	require.NoError(t, client.OpenFile(ctx, path))
+	require.Eventually(t, func() bool { return len(client.GetFileDiagnostics(uri)) == 2 }, 5*time.Second, 10*time.Millisecond)
+	var sources []string
+	for _, diag := range client.GetFileDiagnostics(uri) {
+		sources = append(sources, diag.Source)
+	}
+	assert.Equal(t, []string{"typescript, eslint", "eslint"}, sources)
+
+	actions, err := codeActionsAt(ctx, client, path, lineRange(0, 1), []protocol.CodeActionKind{protocol.QuickFix})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"Remove the type annotation (quickfix) from typescript-language-server",
+		"Disable no-console for this line (quickfix) from eslint",
+	}, codeActionTitles(client, actions))
+
+	// Each server is given the diagnostics it published itself
+	var params protocol.CodeActionParams
+	requests := eslint.Received("textDocument/codeAction")
+	require.Len(t, requests, 1)
+	require.NoError(t, json.Unmarshal(requests[0].Params, &params))
+	assert.Equal(t, []protocol.Diagnostic{anyDiagnostic, consoleDiagnostic}, params.Context.Diagnostics)
+	requests = typescript.Received("textDocument/codeAction")
+	require.Len(t, requests, 1)
+	require.NoError(t, json.Unmarshal(requests[0].Params, &params))
+	assert.Equal(t, []protocol.Diagnostic{typescriptAny}, params.Context.Diagnostics)
+
+	// ESLint's action is resolved and run by ESLint
+	_, err = applyCodeAction(ctx, client, actions[1])
+	require.NoError(t, err)
+	assert.Len(t, eslint.Received("codeAction/resolve"), 1)
+	assert.Len(t, eslint.Received("workspace/executeCommand"), 1)
+	assert.Empty(t, typescript.Received("codeAction/resolve"))
+	assert.Empty(t, typescript.Received("workspace/executeCommand"))
+}
@@ -0,0 +1,307 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// functionMetrics measures a function
+type functionMetrics struct {
+	name string
+	path string
+	line int // 0-indexed
+	// lines counts the lines of the function that have code
+	lines int
+	// nesting is the depth of the most deeply nested block in the body
+	nesting int
+	// complexity is one plus the number of branches, loops and boolean
+	// operators, an estimate of the cyclomatic complexity
+	complexity int
+}
+
+// branchPattern matches the keywords and operators that add a path through
+// a function. else if counts once, for its if.
+var branchPattern = regexp.MustCompile(`\b(if|elif|for|foreach|while|case|catch|except|when)\b|&&|\|\|`)
+
+// pythonBooleans are Python's boolean operators
+var pythonBooleans = regexp.MustCompile(`\b(and|or)\b`)
+
+// CodeMetrics measures the functions and methods in a file, in the files
+// under a directory, or of a symbol: their lines of code, how deeply their
+// blocks nest and an estimate of their cyclomatic complexity, and lists the
+// limit most complex. Functions are found with document symbols; the
+// metrics come from their source with comments and strings left out, which
+// makes them rough but comparable across a codebase.
+func CodeMetrics(ctx context.Context, client *lsp.Client, workspaceDir, path, symbolName string, limit int) (string, error) {
+	if (path == "") == (symbolName == "") {
+		return "", codedErrorf(InvalidArgument, "give either a path or a symbolName")
+	}
+	if limit < 1 {
+		return "", codedErrorf(InvalidArgument, "limit must be at least 1, got %d", limit)
+	}
+	rel := func(file string) string {
+		if r, err := filepath.Rel(workspaceDir, file); err == nil {
+			return r
+		}
+		return file
+	}
+
+	var metrics []functionMetrics
+	var scope string
+	failed := 0
+	cache := make(map[protocol.DocumentUri][]flatSymbol)
+	if symbolName != "" {
+		found, err := symbolMetrics(ctx, client, symbolName, cache)
+		if err != nil {
+			return "", err
+		}
+		metrics, scope = found, symbolName
+	} else {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workspaceDir, path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", codedErrorf(InvalidArgument, "invalid path: %v", err)
+		}
+		files := []string{path}
+		if info.IsDir() {
+			files = nil
+			err = heuristics.WalkSourceFiles(ctx, path, func(file string) error {
+				if !heuristics.IsStructured(file) && lsp.DetectLanguageID("file://"+file) != "" && !(excludeGenerated() && isGeneratedFile(file)) {
+					files = append(files, file)
+				}
+				return nil
+			})
+			if err != nil {
+				return "", fmt.Errorf("failed to walk %s: %w", path, err)
+			}
+		}
+		for _, file := range files {
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			found, err := fileMetrics(ctx, client, file, cache, nil)
+			if err != nil {
+				toolsLogger.Warn("Skipping %s: %v", file, err)
+				failed++
+				continue
+			}
+			metrics = append(metrics, found...)
+		}
+		scope = rel(path)
+	}
+	if len(metrics) == 0 {
+		return fmt.Sprintf("No functions found in %s", scope), nil
+	}
+
+	sort.SliceStable(metrics, func(i, j int) bool {
+		a, b := metrics[i], metrics[j]
+		switch {
+		case a.complexity != b.complexity:
+			return a.complexity > b.complexity
+		case a.nesting != b.nesting:
+			return a.nesting > b.nesting
+		case a.lines != b.lines:
+			return a.lines > b.lines
+		case a.path != b.path:
+			return a.path < b.path
+		}
+		return a.line < b.line
+	})
+	totalComplexity, totalLines := 0, 0
+	for _, m := range metrics {
+		totalComplexity += m.complexity
+		totalLines += m.lines
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "%s: %d functions, %d lines of code, average complexity %.1f\n",
+		scope, len(metrics), totalLines, float64(totalComplexity)/float64(len(metrics)))
+	if failed > 0 {
+		fmt.Fprintf(&out, "Skipped %d files the language server could not analyze\n", failed)
+	}
+	shown := metrics[:min(len(metrics), limit)]
+	if len(shown) < len(metrics) {
+		fmt.Fprintf(&out, "The %d most complex:\n", len(shown))
+	}
+	out.WriteString("\ncomplexity  nesting  lines  function\n")
+	for _, m := range shown {
+		fmt.Fprintf(&out, "%10d  %7d  %5d  %s (%s:%d)\n", m.complexity, m.nesting, m.lines, m.name, rel(m.path), m.line+1)
+	}
+	return out.String(), nil
+}
+
+// symbolMetrics measures the functions declared with a name
+func symbolMetrics(ctx context.Context, client *lsp.Client, symbolName string, cache map[protocol.DocumentUri][]flatSymbol) ([]functionMetrics, error) {
+	resolvedName, results, err := QuerySymbol(ctx, client, symbolName)
+	if err != nil {
+		return nil, err
+	}
+	var metrics []functionMetrics
+	for _, result := range results {
+		if !callHierarchySymbolMatches(resolvedName, result) {
+			continue
+		}
+		loc, err := GetExactSymbolLocation(result)
+		if err != nil {
+			continue
+		}
+		loc = client.FileLocation(loc)
+		found, err := fileMetrics(ctx, client, loc.URI.Path(), cache, func(sym flatSymbol) bool {
+			return sym.selection.Line == loc.Range.Start.Line
+		})
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, found...)
+	}
+	if len(metrics) == 0 {
+		return nil, codedErrorf(SymbolNotFound, "function %s not found%s", symbolName, emptyResultHints(client, similarSymbolsHint(symbolName, results)))
+	}
+	return metrics, nil
+}
+
+// fileMetrics measures the functions and methods of a file that keep
+// accepts, or all of them when keep is nil
+func fileMetrics(ctx context.Context, client *lsp.Client, path string, cache map[protocol.DocumentUri][]flatSymbol, keep func(flatSymbol) bool) ([]functionMetrics, error) {
+	symbols, err := documentSymbolsFlat(ctx, client, protocol.URIFromPath(path), cache)
+	if err != nil {
+		return nil, err
+	}
+	content, err := lsp.ReadSourceFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(content), "\n")
+	language := lsp.DetectLanguageID("file://" + path)
+
+	var metrics []functionMetrics
+	for _, sym := range symbols {
+		switch sym.kind {
+		case protocol.Function, protocol.Method, protocol.Constructor:
+		default:
+			continue
+		}
+		if keep != nil && !keep(sym) {
+			continue
+		}
+		start, end := int(sym.rng.Start.Line), int(sym.rng.End.Line)
+		if start >= len(lines) {
+			continue
+		}
+		m := measureFunction(language, lines[start:min(end+1, len(lines))])
+		m.name = symbolLabel(sym.container, sym.name)
+		m.path, m.line = path, int(sym.selection.Line)
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// measureFunction computes the metrics of a function from its source lines.
+// Nesting is counted with braces, or with indentation in languages without
+// them.
+func measureFunction(language protocol.LanguageKind, lines []string) functionMetrics {
+	hashComments := language == protocol.LangPython || language == protocol.LangRuby || language == protocol.LangShellScript
+	var m functionMetrics
+	code := make([]string, len(lines))
+	inBlockComment := false
+	braces := false
+	for i, line := range lines {
+		code[i], inBlockComment = stripComments(line, hashComments, inBlockComment)
+		if strings.TrimSpace(code[i]) == "" {
+			continue
+		}
+		m.lines++
+		m.complexity += len(branchPattern.FindAllString(code[i], -1))
+		if language == protocol.LangPython {
+			m.complexity += len(pythonBooleans.FindAllString(code[i], -1))
+		}
+		braces = braces || strings.Contains(code[i], "{")
+	}
+	m.complexity++
+
+	if braces {
+		depth, deepest := 0, 0
+		for _, line := range code {
+			for _, c := range line {
+				switch c {
+				case '{':
+					depth++
+					deepest = max(deepest, depth)
+				case '}':
+					depth--
+				}
+			}
+		}
+		// The outermost braces are the function's body
+		m.nesting = max(deepest-1, 0)
+		return m
+	}
+
+	var indents []int
+	for _, line := range code[1:] {
+		if strings.TrimSpace(line) != "" {
+			indents = append(indents, len(line)-len(strings.TrimLeft(line, " \t")))
+		}
+	}
+	if len(indents) == 0 {
+		return m
+	}
+	body, unit := indents[0], 0
+	for _, indent := range indents {
+		if d := indent - body; d > 0 && (unit == 0 || d < unit) {
+			unit = d
+		}
+	}
+	if unit > 0 {
+		for _, indent := range indents {
+			m.nesting = max(m.nesting, (indent-body)/unit)
+		}
+	}
+	return m
+}
+
+// stripComments removes comments and the contents of string literals from a
+// line. inBlock tells whether the line starts inside a block comment, and
+// whether the next one does is returned.
+func stripComments(line string, hashComments, inBlock bool) (string, bool) {
+	var out strings.Builder
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if inBlock {
+			if c == '*' && i+1 < len(line) && line[i+1] == '/' {
+				inBlock = false
+				i++
+			}
+			continue
+		}
+		switch {
+		case c == '/' && i+1 < len(line) && line[i+1] == '/', hashComments && c == '#':
+			return out.String(), false
+		case c == '/' && i+1 < len(line) && line[i+1] == '*':
+			inBlock = true
+			i++
+		case c == '"' || c == '\'' || c == '`':
+			out.WriteByte(c)
+			for i++; i < len(line) && line[i] != c; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+			out.WriteByte(c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String(), inBlock
+}
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripComments(t *testing.T) {
+	code, inBlock := stripComments(`if a && b { // or c || d`, false, false)
+	assert.Equal(t, "if a && b { ", code)
+	assert.False(t, inBlock)
+
+	code, inBlock = stripComments(`x := "if { \" }" /* if`, false, false)
+	assert.Equal(t, `x := "" `, code)
+	assert.True(t, inBlock)
+
+	code, inBlock = stripComments(`while */ y # for`, true, true)
+	assert.Equal(t, " y ", code)
+	assert.False(t, inBlock)
+}
+
+func TestMeasureFunction(t *testing.T) {
+	goSource := `func Sum(items []int) int {
+	total := 0
+	// if this were a loop
+	for _, item := range items {
+		if item > 0 && item < 100 {
+			total += item
+		}
+	}
+	return total
+}`
+	m := measureFunction(protocol.LangGo, strings.Split(goSource, "\n"))
+	assert.Equal(t, 9, m.lines)
+	assert.Equal(t, 2, m.nesting)
+	assert.Equal(t, 4, m.complexity)
+
+	pySource := `def load(path):
+    if not path or path == "-":
+        return None
+    for line in open(path):
+        while line:
+            line = line[1:]
+`
+	m = measureFunction(protocol.LangPython, strings.Split(pySource, "\n"))
+	assert.Equal(t, 6, m.lines)
+	assert.Equal(t, 2, m.nesting)
+	assert.Equal(t, 5, m.complexity)
+}
+
+func TestCodeMetrics(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"main.go": "package main\n\nfunc main() {\n\trun()\n}\n\nfunc run() {\n\tif true {\n\t}\n}\n",
+	})
+	function := func(name string, start, end uint32) protocol.DocumentSymbol {
+		return protocol.DocumentSymbol{Name: name, Kind: protocol.Function,
+			Range:          protocol.Range{Start: protocol.Position{Line: start}, End: protocol.Position{Line: end, Character: 1}},
+			SelectionRange: protocol.Range{Start: protocol.Position{Line: start, Character: 5}}}
+	}
+	server := lsptest.NewServer(protocol.ServerCapabilities{})
+	server.Respond("textDocument/documentSymbol", []protocol.DocumentSymbol{function("main", 2, 4), function("run", 6, 9)})
+	client := lsptest.Start(t, server, dir)
+	ctx := context.Background()
+
+	result, err := CodeMetrics(ctx, client, dir, "main.go", "", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "main.go: 2 functions, 7 lines of code, average complexity 1.5\nThe 1 most complex:\n"+
+		"\ncomplexity  nesting  lines  function\n         2        1      4  run (main.go:7)\n", result)
+
+	_, err = CodeMetrics(ctx, client, dir, "main.go", "run", 10)
+	assert.Equal(t, InvalidArgument, ErrorCodeOf(err))
+	_, err = CodeMetrics(ctx, client, dir, filepath.Join(dir, "missing"), "", 10)
+	assert.Equal(t, InvalidArgument, ErrorCodeOf(err))
+}
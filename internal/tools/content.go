@@ -3,7 +3,6 @@ package tools
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
@@ -14,7 +13,7 @@ func GetContentInfo(ctx context.Context, client *lsp.Client, filePath string, li
 	// Open the file if not already open
 	err := client.OpenFile(ctx, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", fmt.Errorf("could not open file: %w", err)
 	}
 
 	// Convert 1-indexed line/column to 0-indexed for LSP protocol
@@ -24,7 +23,7 @@ func GetContentInfo(ctx context.Context, client *lsp.Client, filePath string, li
 	}
 
 	location := protocol.Location{
-		URI: protocol.DocumentUri("file://" + filePath),
+		URI: protocol.URIFromPath(filePath),
 		Range: protocol.Range{
 			Start: position,
 			End:   position,
@@ -41,7 +40,7 @@ func GetContentInfo(ctx context.Context, client *lsp.Client, filePath string, li
 			"File: %s\n"+
 			"Range: L%d:C%d - L%d:C%d\n\n",
 		symbol.GetName(),
-		strings.TrimPrefix(string(loc.URI), "file://"),
+		loc.URI.Path(),
 		loc.Range.Start.Line+1,
 		loc.Range.Start.Character+1,
 		loc.Range.End.Line+1,
@@ -0,0 +1,606 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// DefaultContextBudget is the token budget of context_for when none is given
+const DefaultContextBudget = 4000
+
+// minContextBudget leaves room for the header and some of the definition
+const minContextBudget = 100
+
+const (
+	// maxContextIdentifiers bounds the identifiers in the definition that are
+	// resolved to find the types and functions it uses
+	maxContextIdentifiers = 40
+	// maxContextReferences bounds the references looked at for symbols
+	// without a call hierarchy
+	maxContextReferences = 100
+	// maxContextOmitted bounds the items listed as left out
+	maxContextOmitted = 10
+	// contextWindowLines are shown either side of a line outside any symbol
+	contextWindowLines = 10
+	// contextExcerptLines are shown either side of a call site when all of
+	// the caller doesn't fit
+	contextExcerptLines = 2
+	// contextHeadLines of a declaration are shown when all of it doesn't fit
+	contextHeadLines = 12
+)
+
+// contextSection groups the code collected around a symbol
+type contextSection int
+
+const (
+	contextTypes contextSection = iota
+	contextUsers
+	contextCallees
+)
+
+var contextSectionTitles = map[contextSection]string{
+	contextTypes:   "Types used",
+	contextUsers:   "Used by",
+	contextCallees: "Called functions",
+}
+
+// contextSectionWeights rank the sections against each other
+var contextSectionWeights = map[contextSection]float64{
+	contextTypes:   3,
+	contextUsers:   3,
+	contextCallees: 2,
+}
+
+// contextItem is a declaration considered for the context of a symbol
+type contextItem struct {
+	section   contextSection
+	kind      protocol.SymbolKind
+	name      string
+	file      string
+	rng       protocol.Range
+	uses      int
+	proximity int
+	// selection is where the name of the declaration starts
+	selection protocol.Position
+	// text is the whole declaration and excerpt a part of it shown when the
+	// whole doesn't fit, or "" if there is none
+	text    string
+	excerpt string
+}
+
+func (item *contextItem) score() float64 {
+	return contextSectionWeights[item.section] * math.Sqrt(float64(item.uses)) / float64(1+item.proximity)
+}
+
+func (item *contextItem) label() string {
+	name := item.name
+	if item.kind != 0 {
+		name = protocol.TableKindMap[item.kind] + " " + name
+	}
+	return fmt.Sprintf("%s - %s L%d-L%d", name, item.file, item.rng.Start.Line+1, item.rng.End.Line+1)
+}
+
+// GetContextFor assembles the code most relevant to understanding a symbol,
+// given by name or by a line in a file, within a budget of tokens: its
+// definition and documentation, then the types it uses, the code that uses
+// it and the functions it calls, ranked by how often they are used and how
+// close they are to the symbol. Parts that don't fit are listed by location.
+func GetContextFor(ctx context.Context, client *lsp.Client, symbolName, filePath string, line, budgetTokens int) (string, error) {
+	if budgetTokens < minContextBudget {
+		return "", codedErrorf(InvalidArgument, "budgetTokens must be at least %d", minContextBudget)
+	}
+	symbolCache := make(map[protocol.DocumentUri][]flatSymbol)
+	files := make(map[string][]string)
+
+	target, found, err := contextTarget(ctx, client, symbolName, filePath, line, symbolCache, files)
+	if err != nil || target == nil {
+		return found, err
+	}
+	uri := protocol.URIFromPath(target.file)
+
+	definition, err := contextSnippet(files, target.file, target.rng)
+	if err != nil {
+		return "", err
+	}
+	// Windows of lines have no kind, and nothing to ask the server about
+	documentation := ""
+	if target.kind != 0 {
+		documentation = cleanDocumentation(strings.TrimSpace(hoverText(ctx, client, protocol.Location{
+			URI:   uri,
+			Range: protocol.Range{Start: target.selection, End: target.selection},
+		})))
+	}
+
+	items := make(map[string]*contextItem)
+	contextUsedDeclarations(ctx, client, target, symbolCache, files, items)
+	if target.kind != 0 {
+		contextUsersOf(ctx, client, target, symbolCache, files, items)
+	}
+	ranked := make([]*contextItem, 0, len(items))
+	for _, item := range items {
+		item.proximity = contextProximity(target.file, item.file, client.WorkspaceDir())
+		ranked = append(ranked, item)
+	}
+
+	// The header is budgeted for with the largest token count it can show
+	header := contextHeader(target, budgetTokens, budgetTokens)
+	definitionTitle := "Definition"
+	if target.kind == 0 {
+		definitionTitle = "Lines"
+	}
+	definitionSection := fmt.Sprintf("\n## %s\n%s", definitionTitle, definition)
+	remaining := budgetTokens - estimateTokens(header)
+	if estimateTokens(definitionSection) > remaining {
+		definitionSection = truncateToTokens(definitionSection, remaining)
+	}
+	remaining -= estimateTokens(definitionSection)
+
+	documentationSection := ""
+	if documentation != "" {
+		documentationSection = "\n## Documentation\n" + documentation + "\n"
+		if estimateTokens(documentationSection) > remaining {
+			documentationSection = ""
+		} else {
+			remaining -= estimateTokens(documentationSection)
+		}
+	}
+
+	chosen, omitted, remaining := selectContext(ranked, remaining)
+
+	var body strings.Builder
+	body.WriteString(definitionSection)
+	body.WriteString(documentationSection)
+	for _, section := range []contextSection{contextTypes, contextUsers, contextCallees} {
+		first := true
+		for _, text := range chosen[section] {
+			if first {
+				fmt.Fprintf(&body, "\n## %s\n", contextSectionTitles[section])
+				first = false
+			}
+			body.WriteString(text)
+		}
+	}
+	body.WriteString(omittedContext(omitted, remaining))
+
+	return contextHeader(target, estimateTokens(header+body.String()), budgetTokens) + body.String(), nil
+}
+
+func contextHeader(target *contextItem, tokens, budgetTokens int) string {
+	return fmt.Sprintf("Context for %s (about %d of %d tokens)\n", target.label(), tokens, budgetTokens)
+}
+
+// omittedContext lists the items left out by location, as far as the budget
+// allows
+func omittedContext(omitted []*contextItem, budgetTokens int) string {
+	if len(omitted) == 0 {
+		return ""
+	}
+	var result strings.Builder
+	result.WriteString("\n## Left out to stay within the budget\n")
+	for i, item := range omitted {
+		line := fmt.Sprintf("- %s: %s\n", contextSectionTitles[item.section], item.label())
+		if i == maxContextOmitted || estimateTokens(result.String()+line) > budgetTokens {
+			fmt.Fprintf(&result, "- ... %d more\n", len(omitted)-i)
+			break
+		}
+		result.WriteString(line)
+	}
+	return result.String()
+}
+
+// contextTarget finds the declaration that context is gathered for. A line
+// outside any declaration gives a window of lines with no kind. When nothing
+// is found the returned item is nil and the string says so.
+func contextTarget(ctx context.Context, client *lsp.Client, symbolName, filePath string, line int,
+	symbolCache map[protocol.DocumentUri][]flatSymbol, files map[string][]string,
+) (*contextItem, string, error) {
+	var loc protocol.Location
+	switch {
+	case symbolName != "":
+		resolvedName, results, err := QuerySymbol(ctx, client, symbolName)
+		if err != nil {
+			return nil, "", err
+		}
+		var candidates []protocol.Location
+		var options []string
+		for _, symbol := range results {
+			if !callHierarchySymbolMatches(resolvedName, symbol) {
+				continue
+			}
+			symbolLoc, err := GetExactSymbolLocation(symbol)
+			if err != nil {
+				toolsLogger.Warn("Error getting exact location of %s: %v", symbol.GetName(), err)
+				continue
+			}
+			candidates = append(candidates, symbolLoc)
+			options = append(options, fmt.Sprintf("%s at %s L%d:C%d", symbol.GetName(), symbolLoc.URI.Path(), symbolLoc.Range.Start.Line+1, symbolLoc.Range.Start.Character+1))
+		}
+		if len(candidates) == 0 {
+			return nil, fmt.Sprintf("%s not found", resolvedName) + emptyResultHints(client, similarSymbolsHint(resolvedName, results)), nil
+		}
+		i, err := chooseOption(ctx, fmt.Sprintf("Several symbols are named %s. Which one should context be gathered for?", resolvedName), options,
+			"Call context_for again with the filePath and line of the one you mean.")
+		if err != nil {
+			return nil, "", err
+		}
+		loc = candidates[i]
+		filePath = loc.URI.Path()
+	case filePath != "":
+		if line < 1 {
+			return nil, "", codedErrorf(PositionInvalid, "line must be at least 1")
+		}
+	default:
+		return nil, "", codedErrorf(InvalidArgument, "either symbolName or filePath and line are required")
+	}
+	if lsp.IsNotebook(filePath) {
+		return nil, "", codedErrorf(UnsupportedCapability, "context_for is not supported for notebooks")
+	}
+
+	lines, err := contextFileLines(files, filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	if symbolName == "" {
+		if line > len(lines) {
+			return nil, "", codedErrorf(PositionInvalid, "line %d is beyond the end of %s (%d lines)", line, filePath, len(lines))
+		}
+		// Look from the first character on the line, which is inside any
+		// declaration the line belongs to
+		text := lines[line-1]
+		character := utilities.UTF16Column(text, len(text)-len(strings.TrimLeft(text, " \t")))
+		loc = protocol.Location{
+			URI:   protocol.URIFromPath(filePath),
+			Range: protocol.Range{Start: protocol.Position{Line: uint32(line - 1), Character: uint32(character)}},
+		}
+	}
+
+	var declaration flatSymbol
+	var ok bool
+	if symbolName != "" {
+		declaration, ok = declarationAt(ctx, client, loc, symbolCache)
+	} else {
+		declaration, ok = enclosingFunction(ctx, client, loc, symbolCache)
+	}
+	if !ok {
+		if symbolName != "" {
+			return nil, "", codedErrorf(SymbolNotFound, "no declaration found at %s L%d", filePath, loc.Range.Start.Line+1)
+		}
+		start := max(line-1-contextWindowLines, 0)
+		end := min(line-1+contextWindowLines, len(lines)-1)
+		return &contextItem{
+			name: fmt.Sprintf("lines around L%d", line),
+			file: filePath,
+			rng:  protocol.Range{Start: protocol.Position{Line: uint32(start)}, End: protocol.Position{Line: uint32(end), Character: ^uint32(0)}},
+		}, "", nil
+	}
+
+	name := declaration.name
+	if declaration.container != "" {
+		name = declaration.container + "." + name
+	}
+	return &contextItem{
+		kind:      declaration.kind,
+		name:      name,
+		file:      filePath,
+		rng:       declaration.rng,
+		selection: declaration.selection,
+	}, "", nil
+}
+
+// declarationAt finds the innermost declaration containing a location
+func declarationAt(ctx context.Context, client *lsp.Client, loc protocol.Location, cache map[protocol.DocumentUri][]flatSymbol) (flatSymbol, bool) {
+	symbols, err := documentSymbolsFlat(ctx, client, loc.URI, cache)
+	if err != nil {
+		toolsLogger.Debug("Error getting symbols for %s: %v", loc.URI, err)
+		cache[loc.URI] = nil
+		return flatSymbol{}, false
+	}
+	var innermost flatSymbol
+	found := false
+	// Children follow their parents, so the last match is the innermost
+	for _, sym := range symbols {
+		if sym.selection == loc.Range.Start || containsPosition(sym.rng, loc.Range.Start) {
+			innermost, found = sym, true
+		}
+	}
+	return innermost, found
+}
+
+// contextUsedDeclarations resolves the identifiers in the target to the types
+// and functions they refer to
+func contextUsedDeclarations(ctx context.Context, client *lsp.Client, target *contextItem,
+	symbolCache map[protocol.DocumentUri][]flatSymbol, files map[string][]string, items map[string]*contextItem,
+) {
+	lines := files[target.file]
+	uri := protocol.URIFromPath(target.file)
+	uses := make(map[string]int)
+	type occurrence struct {
+		name     string
+		position protocol.Position
+	}
+	var firsts []occurrence
+	for l := int(target.rng.Start.Line); l <= int(target.rng.End.Line) && l < len(lines); l++ {
+		text := lines[l]
+		for _, match := range identifierPattern.FindAllStringIndex(text, -1) {
+			name := text[match[0]:match[1]]
+			uses[name]++
+			if uses[name] == 1 && len(firsts) < maxContextIdentifiers {
+				firsts = append(firsts, occurrence{name, protocol.Position{Line: uint32(l), Character: uint32(utilities.UTF16Column(text, match[0]))}})
+			}
+		}
+	}
+
+	for _, first := range firsts {
+		if first.position == target.selection {
+			continue
+		}
+		result, err := client.Definition(ctx, protocol.DefinitionParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+				Position:     first.position,
+			},
+		})
+		if err != nil {
+			toolsLogger.Debug("Error getting definition of %s: %v", first.name, err)
+			continue
+		}
+		for _, loc := range definitionLocations(result) {
+			// Declarations inside the target are already shown
+			if loc.URI == uri && rangeWithin(loc.Range, target.rng) {
+				continue
+			}
+			declaration, ok := declarationAt(ctx, client, loc, symbolCache)
+			if !ok {
+				continue
+			}
+			var section contextSection
+			switch declaration.kind {
+			case protocol.Class, protocol.Interface, protocol.Struct, protocol.Enum:
+				section = contextTypes
+			case protocol.Function, protocol.Method, protocol.Constructor:
+				section = contextCallees
+			default:
+				continue
+			}
+			path := loc.URI.Path()
+			key := fmt.Sprintf("%s:%d:%d", path, declaration.rng.Start.Line, declaration.rng.Start.Character)
+			if item, ok := items[key]; ok {
+				item.uses += uses[first.name]
+				continue
+			}
+			name := declaration.name
+			if declaration.container != "" {
+				name = declaration.container + "." + name
+			}
+			rng := protocol.Range{Start: declaration.rng.Start, End: declaration.rng.End}
+			head := rng
+			head.End.Line = min(head.End.Line, head.Start.Line+contextHeadLines-1)
+			items[key] = newContextItem(files, section, declaration.kind, name, path, rng, head, uses[first.name])
+		}
+	}
+}
+
+// contextUsersOf finds the code that uses the target: its callers, or the
+// declarations enclosing its references when the server has no call
+// hierarchy for it
+func contextUsersOf(ctx context.Context, client *lsp.Client, target *contextItem,
+	symbolCache map[protocol.DocumentUri][]flatSymbol, files map[string][]string, items map[string]*contextItem,
+) {
+	uri := protocol.URIFromPath(target.file)
+	position := protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Position:     target.selection,
+	}
+	record := func(declaration flatSymbol, path string, at protocol.Range, uses int) {
+		key := fmt.Sprintf("%s:%d:%d", path, declaration.rng.Start.Line, declaration.rng.Start.Character)
+		if item, ok := items[key]; ok {
+			item.uses += uses
+			return
+		}
+		name := declaration.name
+		if declaration.container != "" {
+			name = declaration.container + "." + name
+		}
+		excerpt := protocol.Range{
+			Start: protocol.Position{Line: max(at.Start.Line, declaration.rng.Start.Line+contextExcerptLines) - contextExcerptLines},
+			End:   protocol.Position{Line: min(at.Start.Line+contextExcerptLines, declaration.rng.End.Line)},
+		}
+		items[key] = newContextItem(files, contextUsers, declaration.kind, name, path, declaration.rng, excerpt, uses)
+	}
+
+	prepared, err := client.PrepareCallHierarchy(ctx, protocol.CallHierarchyPrepareParams{TextDocumentPositionParams: position})
+	if err != nil {
+		toolsLogger.Debug("Error preparing call hierarchy for %s: %v", target.name, err)
+	}
+	foundCalls := false
+	for _, item := range prepared {
+		calls, err := client.IncomingCalls(ctx, protocol.CallHierarchyIncomingCallsParams{Item: item})
+		if err != nil {
+			toolsLogger.Debug("Error getting callers of %s: %v", item.Name, err)
+			continue
+		}
+		for _, call := range calls {
+			if len(call.FromRanges) == 0 || (call.From.URI == uri && rangeWithin(call.From.Range, target.rng)) {
+				continue
+			}
+			foundCalls = true
+			declaration := flatSymbol{name: call.From.Name, kind: call.From.Kind, rng: call.From.Range, selection: call.From.SelectionRange.Start}
+			record(declaration, call.From.URI.Path(), call.FromRanges[0], len(call.FromRanges))
+		}
+	}
+	if foundCalls {
+		return
+	}
+
+	refs, err := client.References(ctx, protocol.ReferenceParams{
+		TextDocumentPositionParams: position,
+		Context:                    protocol.ReferenceContext{IncludeDeclaration: false},
+	})
+	if err != nil {
+		toolsLogger.Debug("Error getting references to %s: %v", target.name, err)
+		return
+	}
+	for i, ref := range refs {
+		if i == maxContextReferences {
+			break
+		}
+		if ref.URI == uri && rangeWithin(ref.Range, target.rng) {
+			continue
+		}
+		declaration, ok := enclosingFunction(ctx, client, ref, symbolCache)
+		if !ok {
+			continue
+		}
+		record(declaration, ref.URI.Path(), ref.Range, 1)
+	}
+}
+
+// newContextItem reads the text of a declaration and of an excerpt of it
+func newContextItem(files map[string][]string, section contextSection, kind protocol.SymbolKind, name, path string, rng, excerpt protocol.Range, uses int) *contextItem {
+	item := &contextItem{section: section, kind: kind, name: name, file: path, rng: rng, uses: max(uses, 1)}
+	text, err := contextSnippet(files, path, rng)
+	if err != nil {
+		toolsLogger.Debug("Error reading %s: %v", path, err)
+		return item
+	}
+	item.text = fmt.Sprintf("### %s\n%s", item.label(), text)
+	if excerpt.Start.Line > rng.Start.Line || excerpt.End.Line < rng.End.Line {
+		if text, err := contextSnippet(files, path, excerpt); err == nil {
+			item.excerpt = fmt.Sprintf("### %s (excerpt L%d-L%d)\n%s", item.label(), excerpt.Start.Line+1, excerpt.End.Line+1, text)
+		}
+	}
+	return item
+}
+
+// selectContext picks items in order of score until the budget runs out,
+// showing an item's excerpt when all of it doesn't fit. It returns the text
+// picked for each section, the items left out and the budget left over.
+// Section headings count towards the budget.
+func selectContext(items []*contextItem, budgetTokens int) (map[contextSection][]string, []*contextItem, int) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].score() != items[j].score() {
+			return items[i].score() > items[j].score()
+		}
+		if items[i].file != items[j].file {
+			return items[i].file < items[j].file
+		}
+		return items[i].rng.Start.Line < items[j].rng.Start.Line
+	})
+
+	chosen := make(map[contextSection][]string)
+	var omitted []*contextItem
+	for _, item := range items {
+		if item.text == "" {
+			continue
+		}
+		heading := 0
+		if len(chosen[item.section]) == 0 {
+			heading = estimateTokens(fmt.Sprintf("\n## %s\n", contextSectionTitles[item.section]))
+		}
+		switch {
+		case heading+estimateTokens(item.text) <= budgetTokens:
+			chosen[item.section] = append(chosen[item.section], item.text)
+			budgetTokens -= heading + estimateTokens(item.text)
+		case item.excerpt != "" && heading+estimateTokens(item.excerpt) <= budgetTokens:
+			chosen[item.section] = append(chosen[item.section], item.excerpt)
+			budgetTokens -= heading + estimateTokens(item.excerpt)
+		default:
+			omitted = append(omitted, item)
+		}
+	}
+	return chosen, omitted, budgetTokens
+}
+
+// contextProximity is how far a file is from the target's: 0 for the same
+// file, 1 for the same directory and one more for each directory between
+// them. Files outside the workspace, such as libraries, come last.
+func contextProximity(target, path, workspaceDir string) int {
+	if path == target {
+		return 0
+	}
+	distance := 1
+	if rel, err := filepath.Rel(filepath.Dir(target), filepath.Dir(path)); err == nil && rel != "." {
+		distance += len(strings.Split(rel, string(filepath.Separator)))
+	}
+	if workspaceDir != "" && outsideWorkspace(workspaceDir, path) {
+		distance += 10
+	}
+	return distance
+}
+
+// estimateTokens approximates the number of tokens in text, at about four
+// characters per token
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// truncateToTokens cuts text at a line boundary to fit a number of tokens,
+// saying how much was left out
+func truncateToTokens(text string, tokens int) string {
+	lines := strings.SplitAfter(text, "\n")
+	var result strings.Builder
+	for i, line := range lines {
+		note := fmt.Sprintf("... %d more lines left out to stay within the budget\n", len(lines)-i)
+		if estimateTokens(result.String()+line+note) > tokens {
+			return result.String() + note
+		}
+		result.WriteString(line)
+	}
+	return result.String()
+}
+
+// contextFileLines returns the lines of a file, reading it once per call
+func contextFileLines(files map[string][]string, path string) ([]string, error) {
+	if lines, ok := files[path]; ok {
+		return lines, nil
+	}
+	content, err := lsp.ReadSourceFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	files[path] = lines
+	return lines, nil
+}
+
+// contextSnippet returns whole lines of a range with line numbers
+func contextSnippet(files map[string][]string, path string, rng protocol.Range) (string, error) {
+	lines, err := contextFileLines(files, path)
+	if err != nil {
+		return "", err
+	}
+	start, end := int(rng.Start.Line), int(rng.End.Line)
+	if start >= len(lines) {
+		return "", codedErrorf(PositionInvalid, "L%d is beyond the end of %s", start+1, path)
+	}
+	end = min(end, len(lines)-1)
+	return addLineNumbers(strings.Join(lines[start:end+1], "\n"), start+1), nil
+}
+
+// definitionLocations flattens the forms a definition result can take
+func definitionLocations(result protocol.Or_Result_textDocument_definition) []protocol.Location {
+	switch v := result.Value.(type) {
+	case protocol.Definition:
+		switch locs := v.Value.(type) {
+		case protocol.Location:
+			return []protocol.Location{locs}
+		case []protocol.Location:
+			return locs
+		}
+	case []protocol.DefinitionLink:
+		locs := make([]protocol.Location, len(v))
+		for i, link := range v {
+			locs[i] = protocol.Location{URI: link.TargetURI, Range: link.TargetSelectionRange}
+		}
+		return locs
+	}
+	return nil
+}
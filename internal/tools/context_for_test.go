@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectContext(t *testing.T) {
+	long := strings.Repeat("x", 400)
+	items := []*contextItem{
+		{section: contextCallees, name: "far", file: "/ws/other/b.go", uses: 1, proximity: 3, text: "far callee\n"},
+		{section: contextTypes, name: "Config", file: "/ws/a.go", uses: 4, proximity: 0, text: "type Config\n"},
+		{section: contextUsers, name: "main", file: "/ws/main.go", uses: 1, proximity: 1, text: long, excerpt: "main excerpt\n"},
+		{section: contextUsers, name: "big", file: "/ws/z.go", uses: 1, proximity: 1, text: long},
+	}
+
+	chosen, omitted, remaining := selectContext(items, 20)
+	assert.Equal(t, []string{"type Config\n"}, chosen[contextTypes])
+	// The excerpt stands in for a caller that doesn't fit
+	assert.Equal(t, []string{"main excerpt\n"}, chosen[contextUsers])
+	assert.Empty(t, chosen[contextCallees])
+	// Section headings count towards the budget
+	assert.Equal(t, 6, remaining)
+	if assert.Len(t, omitted, 2) {
+		assert.Equal(t, "big", omitted[0].name)
+		assert.Equal(t, "far", omitted[1].name)
+	}
+}
+
+func TestContextProximity(t *testing.T) {
+	assert.Equal(t, 0, contextProximity("/ws/pkg/a.go", "/ws/pkg/a.go", "/ws"))
+	assert.Equal(t, 1, contextProximity("/ws/pkg/a.go", "/ws/pkg/b.go", "/ws"))
+	assert.Equal(t, 3, contextProximity("/ws/pkg/a.go", "/ws/other/b.go", "/ws"))
+	assert.Greater(t, contextProximity("/ws/pkg/a.go", "/usr/lib/go/src/fmt/print.go", "/ws"), 10)
+}
+
+func TestTruncateToTokens(t *testing.T) {
+	text := strings.Repeat("0123456789\n", 20)
+	truncated := truncateToTokens(text, 20)
+	assert.LessOrEqual(t, estimateTokens(truncated), 20)
+	assert.True(t, strings.HasPrefix(truncated, "0123456789\n"))
+	assert.Contains(t, truncated, "more lines left out to stay within the budget")
+
+	assert.Equal(t, "short\n", truncateToTokens("short\n", 20))
+}
+
+func TestDefinitionLocations(t *testing.T) {
+	loc := protocol.Location{URI: "file:///ws/a.go", Range: protocol.Range{Start: protocol.Position{Line: 3}}}
+	assert.Equal(t, []protocol.Location{loc}, definitionLocations(protocol.Or_Result_textDocument_definition{
+		Value: protocol.Definition{Value: loc},
+	}))
+	assert.Equal(t, []protocol.Location{loc}, definitionLocations(protocol.Or_Result_textDocument_definition{
+		Value: []protocol.DefinitionLink{{TargetURI: loc.URI, TargetSelectionRange: loc.Range}},
+	}))
+	assert.Empty(t, definitionLocations(protocol.Or_Result_textDocument_definition{}))
+}
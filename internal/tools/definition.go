@@ -5,16 +5,62 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 )
 
 func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string) (string, error) {
-	symbolName, results, err := QuerySymbol(ctx, client, symbolName)
+	symbolName, results, definitions, err := readDefinitions(ctx, client, symbolName, false)
 	if err != nil {
 		return "", err
 	}
 
+	if len(definitions) == 0 {
+		return fmt.Sprintf("%s not found", symbolName) + emptyResultHints(client, similarSymbolsHint(symbolName, results)), nil
+	}
+
+	return strings.Join(definitions, ""), nil
+}
+
+// HeuristicDefinitionNotice is prepended to definitions the language server could not provide
+const HeuristicDefinitionNotice = "NOTE: The language server did not find this symbol. " +
+	"These results come from text heuristics over files it does not handle.\n\n"
+
+// ReadDefinitionWithFallback works like ReadDefinition, but when the language
+// server finds nothing it also looks for declarations in configuration, build
+// and markup files using text heuristics. If blame is set, definitions found
+// by the language server are annotated with who last changed them.
+func ReadDefinitionWithFallback(ctx context.Context, client *lsp.Client, workspaceDir, symbolName string, blame bool) (string, error) {
+	querySymbolName, results, definitions, err := readDefinitions(ctx, client, symbolName, blame)
+	if err != nil {
+		return "", err
+	}
+	if len(definitions) > 0 {
+		return strings.Join(definitions, ""), nil
+	}
+
+	definitions, err = heuristicDefinitions(ctx, workspaceDir, symbolName, heuristics.IsStructured)
+	if err != nil {
+		toolsLogger.Error("Error searching structured files: %v", err)
+	}
+	if len(definitions) == 0 {
+		return fmt.Sprintf("%s not found", querySymbolName) + emptyResultHints(client, similarSymbolsHint(querySymbolName, results)), nil
+	}
+
+	return HeuristicDefinitionNotice + strings.Join(definitions, ""), nil
+}
+
+// readDefinitions returns the formatted definitions of a symbol along with the
+// name that was queried and the symbols the server found for it, optionally
+// with git blame information
+func readDefinitions(ctx context.Context, client *lsp.Client, symbolName string, blame bool) (string, []protocol.WorkspaceSymbolResult, []string, error) {
+	symbolName, results, err := QuerySymbol(ctx, client, symbolName)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	sortSymbolsByRelevance(results)
+
 	var definitions []string
 	for _, symbol := range results {
 		kind := ""
@@ -69,7 +115,7 @@ func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string)
 		}
 
 		toolsLogger.Debug("Found symbol: %s", symbol.GetName())
-		loc := symbol.GetLocation()
+		loc := client.FileLocation(symbol.GetLocation())
 
 		err := client.OpenFile(ctx, loc.URI.Path())
 		if err != nil {
@@ -84,9 +130,9 @@ func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string)
 				"File: %s\n"+
 				kind+
 				container+
-				"Range: L%d:C%d - L%d:C%d\n\n",
+				"Range: L%d:C%d - L%d:C%d\n",
 			symbol.GetName(),
-			strings.TrimPrefix(string(loc.URI), "file://"),
+			loc.URI.Path(),
 			loc.Range.Start.Line+1,
 			loc.Range.Start.Character+1,
 			loc.Range.End.Line+1,
@@ -98,14 +144,15 @@ func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string)
 			continue
 		}
 
+		if blame && !lsp.IsNotebook(loc.URI.Path()) {
+			locationInfo += blameSummary(ctx, loc.URI.Path(), int(loc.Range.Start.Line)+1, int(loc.Range.End.Line)+1)
+		}
+		locationInfo += "\n"
+
 		definition = addLineNumbers(definition, int(loc.Range.Start.Line)+1)
 
 		definitions = append(definitions, banner+locationInfo+definition+"\n")
 	}
 
-	if len(definitions) == 0 {
-		return fmt.Sprintf("%s not found", symbolName), nil
-	}
-
-	return strings.Join(definitions, ""), nil
+	return symbolName, results, definitions, nil
 }
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 )
@@ -23,7 +24,7 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 
 	err := client.OpenFile(ctx, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", fmt.Errorf("could not open file: %w", err)
 	}
 
 	// Wait for diagnostics
@@ -31,15 +32,17 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 	time.Sleep(time.Second * 3)
 
 	// Convert the file path to URI format
-	uri := protocol.DocumentUri("file://" + filePath)
+	uri := protocol.URIFromPath(filePath)
 
-	// Request fresh diagnostics
-	diagParams := protocol.DocumentDiagnosticParams{
-		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
-	}
-	_, err = client.Diagnostic(ctx, diagParams)
-	if err != nil {
-		toolsLogger.Error("Failed to get diagnostics: %v", err)
+	// Request fresh diagnostics. Notebook cells only publish diagnostics.
+	if !lsp.IsNotebook(filePath) {
+		diagParams := protocol.DocumentDiagnosticParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		}
+		_, err = client.Diagnostic(ctx, diagParams)
+		if err != nil {
+			toolsLogger.Error("Failed to get diagnostics: %v", err)
+		}
 	}
 
 	// Get diagnostics from the cache
@@ -60,28 +63,7 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 	var diagLocations []protocol.Location
 
 	for _, diag := range diagnostics {
-		severity := getSeverityString(diag.Severity)
-		location := fmt.Sprintf("L%d:C%d",
-			diag.Range.Start.Line+1,
-			diag.Range.Start.Character+1)
-
-		summary := fmt.Sprintf("%s at %s: %s",
-			severity,
-			location,
-			diag.Message)
-
-		// Add source and code if available
-		if diag.Source != "" {
-			summary += fmt.Sprintf(" (Source: %s", diag.Source)
-			if diag.Code != nil {
-				summary += fmt.Sprintf(", Code: %v", diag.Code)
-			}
-			summary += ")"
-		} else if diag.Code != nil {
-			summary += fmt.Sprintf(" (Code: %v)", diag.Code)
-		}
-
-		diagSummaries = append(diagSummaries, summary)
+		diagSummaries = append(diagSummaries, formatDiagnosticSummary(diag))
 
 		// Create a location for this diagnostic to use with line ranges
 		diagLocations = append(diagLocations, protocol.Location{
@@ -91,11 +73,16 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 	}
 
 	// Format content with context
-	fileContent, err := os.ReadFile(filePath)
+	fileContent, err := lsp.ReadSourceFile(filePath)
 	if err != nil {
 		return fileInfo + "\nError reading file: " + err.Error(), nil
 	}
 
+	// Lines of binary and minified files aren't worth showing
+	if omitted := heuristics.OpaqueKind(fileContent); omitted != "" {
+		return fileInfo + strings.Join(diagSummaries, "\n") + "\n\n" + heuristics.OmittedMarker(omitted) + "\n", nil
+	}
+
 	lines := strings.Split(string(fileContent), "\n")
 
 	// Collect lines to display
@@ -135,6 +122,34 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 	return result, nil
 }
 
+// formatDiagnosticSummary describes a diagnostic on one line
+func formatDiagnosticSummary(diag protocol.Diagnostic) string {
+	severity := getSeverityString(diag.Severity)
+	location := fmt.Sprintf("L%d:C%d",
+		diag.Range.Start.Line+1,
+		diag.Range.Start.Character+1)
+
+	summary := fmt.Sprintf("%s at %s: %s",
+		severity,
+		location,
+		diag.Message)
+
+	// Add source and code if available
+	if diag.Source != "" {
+		summary += fmt.Sprintf(" (Source: %s", diag.Source)
+		if diag.Code != nil {
+			summary += fmt.Sprintf(", Code: %v", diag.Code)
+		}
+		summary += ")"
+	} else if diag.Code != nil {
+		summary += fmt.Sprintf(" (Code: %v)", diag.Code)
+	}
+	if suggestion := installSuggestion(diag); suggestion != "" {
+		summary += " Install it with: " + suggestion
+	}
+	return summary
+}
+
 func getSeverityString(severity protocol.DiagnosticSeverity) string {
 	switch severity {
 	case protocol.SeverityError:
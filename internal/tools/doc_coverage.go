@@ -0,0 +1,186 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// maxUndocumentedListed limits the undocumented declarations listed
+const maxUndocumentedListed = 300
+
+// DocCoverage reports how many of the exported declarations under a file or
+// directory have doc comments and lists those that don't, by line. Exported
+// declarations are found as for SummarizePackage, and a declaration is
+// documented when a comment sits directly above it, skipping attributes and
+// decorators, or follows it on its line, or for Python when a docstring
+// opens its body. Test files, and generated files unless they are included,
+// are left out.
+func DocCoverage(ctx context.Context, client *lsp.Client, workspaceDir, path string) (string, error) {
+	if path == "" {
+		path = workspaceDir
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workspaceDir, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", codedErrorf(InvalidArgument, "invalid path: %v", err)
+	}
+
+	var files []string
+	if info.IsDir() {
+		err = heuristics.WalkSourceFiles(ctx, path, func(file string) error {
+			if !isTestFile(file) && !heuristics.IsStructured(file) && lsp.DetectLanguageID("file://"+file) != "" &&
+				!(excludeGenerated() && isGeneratedFile(file)) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to walk %s: %w", path, err)
+		}
+	} else {
+		files = []string{path}
+	}
+	rel := func(file string) string {
+		if r, err := filepath.Rel(workspaceDir, file); err == nil {
+			return r
+		}
+		return file
+	}
+	if len(files) == 0 {
+		return fmt.Sprintf("No source files in %s", rel(path)), nil
+	}
+
+	var body strings.Builder
+	total, documented, listed, failed := 0, 0, 0, 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		symbols, lines, err := exportedSymbols(ctx, client, file)
+		if err != nil {
+			toolsLogger.Warn("Skipping %s: %v", file, err)
+			failed++
+			continue
+		}
+		language := lsp.DetectLanguageID("file://" + file)
+		var missing []apiSymbol
+		for _, sym := range symbols {
+			if hasDocComment(language, lines, int(sym.selection.Line)) {
+				documented++
+			} else {
+				missing = append(missing, sym)
+			}
+		}
+		total += len(symbols)
+		if len(missing) == 0 {
+			continue
+		}
+		fmt.Fprintf(&body, "\n%s (%d of %d documented)\n", rel(file), len(symbols)-len(missing), len(symbols))
+		for _, sym := range missing {
+			if listed == maxUndocumentedListed {
+				break
+			}
+			listed++
+			name := sym.name
+			if sym.container != "" && !strings.Contains(name, ".") {
+				name = sym.container + "." + name
+			}
+			fmt.Fprintf(&body, "  L%d: %s %s\n", sym.selection.Line+1, strings.ToLower(protocol.TableKindMap[sym.kind]), name)
+		}
+	}
+
+	var out strings.Builder
+	if total == 0 {
+		fmt.Fprintf(&out, "No exported declarations found in %s (%d files)\n", rel(path), len(files)-failed)
+	} else {
+		fmt.Fprintf(&out, "%s: %d of %d exported declarations documented (%.0f%%) in %d files\n",
+			rel(path), documented, total, 100*float64(documented)/float64(total), len(files)-failed)
+	}
+	if failed > 0 {
+		fmt.Fprintf(&out, "Skipped %d files the language server could not analyze\n", failed)
+	}
+	if undocumented := total - documented; undocumented > 0 {
+		fmt.Fprintf(&out, "\nUndocumented: %d", undocumented)
+		if listed < undocumented {
+			fmt.Fprintf(&out, ", the first %d listed", listed)
+		}
+		out.WriteString("\n" + body.String())
+	}
+	return out.String(), nil
+}
+
+// hasDocComment reports whether the declaration on a line is documented by a
+// comment above it or at the end of the line, or by a Python docstring
+func hasDocComment(language protocol.LanguageKind, lines []string, line int) bool {
+	if line >= len(lines) {
+		return false
+	}
+	hashComments := language == protocol.LangPython || language == protocol.LangRuby || language == protocol.LangShellScript
+	if code, comment, ok := strings.Cut(lines[line], "//"); ok && !hashComments && strings.TrimSpace(code) != "" && strings.TrimSpace(comment) != "" &&
+		strings.Count(code, `"`)%2 == 0 {
+		return true
+	}
+	if language == protocol.LangPython && hasDocstring(lines, line) {
+		return true
+	}
+
+	for above := line - 1; above >= 0; above-- {
+		text := strings.TrimSpace(lines[above])
+		switch {
+		case isCommentLine(text, hashComments):
+			return true
+		case strings.HasPrefix(text, "@") || strings.HasPrefix(text, "#[") || strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]"):
+			// Decorators, annotations and attributes sit between a
+			// declaration and its documentation
+			continue
+		case language == protocol.LangGo && above == line-1 && strings.HasSuffix(text, "(") &&
+			(strings.HasPrefix(text, "const ") || strings.HasPrefix(text, "var ") || strings.HasPrefix(text, "type ")):
+			// A grouped declaration is documented by the group's comment
+			continue
+		}
+		return false
+	}
+	return false
+}
+
+// isCommentLine reports whether a trimmed line is part of a comment
+func isCommentLine(text string, hashComments bool) bool {
+	if hashComments && strings.HasPrefix(text, "#") {
+		return true
+	}
+	return strings.HasPrefix(text, "//") || strings.HasPrefix(text, "/*") || strings.HasPrefix(text, "*") || strings.HasSuffix(text, "*/")
+}
+
+// hasDocstring reports whether the body of the Python function or class
+// defined on a line, whose signature may run over several lines, opens with
+// a string
+func hasDocstring(lines []string, line int) bool {
+	definition := strings.TrimPrefix(strings.TrimSpace(lines[line]), "async ")
+	if !strings.HasPrefix(definition, "def ") && !strings.HasPrefix(definition, "class ") {
+		return false
+	}
+	end := line
+	for ; end < len(lines) && end < line+maxExampleLines; end++ {
+		if strings.HasSuffix(strings.TrimSpace(strings.SplitN(lines[end], "#", 2)[0]), ":") {
+			break
+		}
+	}
+	for next := end + 1; next < len(lines); next++ {
+		text := strings.TrimSpace(lines[next])
+		if text == "" {
+			continue
+		}
+		text = strings.TrimLeft(text, "rRbBuU")
+		return strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'")
+	}
+	return false
+}
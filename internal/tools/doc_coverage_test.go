@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasDocComment(t *testing.T) {
+	tests := []struct {
+		name     string
+		language protocol.LanguageKind
+		source   string
+		line     int
+		want     bool
+	}{
+		{"go comment", protocol.LangGo, "// Add appends an item\nfunc Add() {}", 1, true},
+		{"go blank line", protocol.LangGo, "// Package store\n\nfunc Add() {}", 2, false},
+		{"go trailing comment", protocol.LangGo, "const (\n\tMax = 10 // the most items\n)", 1, true},
+		{"go url in string", protocol.LangGo, "var Home = \"https://example.com\"", 0, false},
+		{"go group comment", protocol.LangGo, "// Modes of a store\nconst (\n\tRead = iota\n\tWrite\n)", 2, true},
+		{"go later in group", protocol.LangGo, "// Modes of a store\nconst (\n\tRead = iota\n\tWrite\n)", 3, false},
+		{"no comment", protocol.LangGo, "}\n\nfunc Add() {}", 2, false},
+		{"jsdoc with decorator", protocol.LangTypeScript, "/**\n * A cart\n */\n@Injectable()\nexport class Cart {}", 4, true},
+		{"rust attribute", protocol.LangRust, "/// Parses input\n#[inline]\npub fn parse() {}", 2, true},
+		{"python docstring", protocol.LangPython, "def load(\n    path,\n):\n    \"\"\"Load a file.\"\"\"", 0, true},
+		{"python hash comment", protocol.LangPython, "# The cache\nCACHE = {}", 1, true},
+		{"python floor division", protocol.LangPython, "HALF = TOTAL // 2", 0, false},
+		{"python constant", protocol.LangPython, "LIMIT = 3\n\"text\"", 0, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, hasDocComment(tt.language, strings.Split(tt.source, "\n"), tt.line), tt.name)
+	}
+}
@@ -0,0 +1,375 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// symbolDocumentation is what the language server knows about a symbol,
+// merged from hover, completion and signature help
+type symbolDocumentation struct {
+	signature     string
+	language      string
+	documentation string
+	parameters    []string
+}
+
+// GetDocumentation returns the documentation of a symbol, given either its
+// name or a position in a file. Hover, resolved completion items and
+// signature help are merged, and markdown noise such as links and escapes is
+// stripped. Line and column are 1-indexed.
+func GetDocumentation(ctx context.Context, client *lsp.Client, symbolName, filePath string, line, column int) (string, error) {
+	var loc protocol.Location
+	switch {
+	case symbolName != "":
+		resolvedName, results, err := QuerySymbol(ctx, client, symbolName)
+		if err != nil {
+			return "", err
+		}
+		var candidates []protocol.Location
+		var options []string
+		for _, symbol := range results {
+			if !callHierarchySymbolMatches(resolvedName, symbol) {
+				continue
+			}
+			symbolLoc, err := GetExactSymbolLocation(symbol)
+			if err != nil {
+				toolsLogger.Warn("Error getting exact location of %s: %v", symbol.GetName(), err)
+				continue
+			}
+			fileLoc := client.FileLocation(symbolLoc)
+			candidates = append(candidates, symbolLoc)
+			options = append(options, fmt.Sprintf("%s at %s L%d:C%d", symbol.GetName(), fileLoc.URI.Path(), fileLoc.Range.Start.Line+1, fileLoc.Range.Start.Character+1))
+		}
+		if len(candidates) == 0 {
+			return fmt.Sprintf("%s not found", resolvedName), nil
+		}
+		i, err := chooseOption(ctx, fmt.Sprintf("Several symbols are named %s. Which one should be documented?", resolvedName), options,
+			"Call documentation again with the filePath, line and column of the one you mean.")
+		if err != nil {
+			return "", err
+		}
+		loc = candidates[i]
+		if err := client.OpenFile(ctx, client.FileLocation(loc).URI.Path()); err != nil {
+			return "", fmt.Errorf("could not open file: %w", err)
+		}
+	case filePath != "":
+		if line < 1 || column < 1 {
+			return "", codedErrorf(InvalidArgument, "line and column are required with filePath")
+		}
+		if err := client.OpenFile(ctx, filePath); err != nil {
+			return "", fmt.Errorf("could not open file: %w", err)
+		}
+		position := protocol.Position{Line: uint32(line - 1), Character: uint32(column - 1)}
+		// Notebook positions refer to the notebook view, the server knows about cells
+		loc, _ = client.ServerLocation(protocol.Location{
+			URI:   protocol.URIFromPath(filePath),
+			Range: protocol.Range{Start: position, End: position},
+		})
+	default:
+		return "", codedErrorf(InvalidArgument, "either symbolName or filePath is required")
+	}
+
+	// Positions are worked out on the line of the file and converted to the
+	// server's coordinates, which differ inside notebook cells
+	fileLoc := client.FileLocation(loc)
+	lineText, err := ExtractTextFromLocation(protocol.Location{
+		URI: fileLoc.URI,
+		Range: protocol.Range{
+			Start: protocol.Position{Line: fileLoc.Range.Start.Line},
+			End:   protocol.Position{Line: fileLoc.Range.Start.Line + 1},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read line: %w", err)
+	}
+	lineText = strings.TrimRight(lineText, "\r\n")
+	name, nameEnd := identifierAt(lineText, utilities.ByteOffset(lineText, int(fileLoc.Range.Start.Character)))
+	if name == "" {
+		return fmt.Sprintf("No symbol at %s L%d:C%d", fileLoc.URI.Path(), fileLoc.Range.Start.Line+1, fileLoc.Range.Start.Character+1), nil
+	}
+	serverPosition := func(offset int) (protocol.DocumentUri, protocol.Position) {
+		position := protocol.Position{Line: fileLoc.Range.Start.Line, Character: uint32(utilities.UTF16Column(lineText, offset))}
+		serverLoc, _ := client.ServerLocation(protocol.Location{
+			URI:   fileLoc.URI,
+			Range: protocol.Range{Start: position, End: position},
+		})
+		return serverLoc.URI, serverLoc.Range.Start
+	}
+
+	doc := &symbolDocumentation{}
+	doc.addHover(hoverText(ctx, client, loc))
+
+	// Completion items often carry documentation that hover leaves out
+	uri, nameEndPos := serverPosition(nameEnd)
+	doc.addCompletion(resolvedCompletion(ctx, client, uri, nameEndPos, name))
+
+	// Signature help describes parameters, and needs a position inside the
+	// argument list
+	if rest := strings.TrimLeft(lineText[nameEnd:], " \t"); strings.HasPrefix(rest, "(") {
+		uri, parenPos := serverPosition(len(lineText) - len(rest) + 1)
+		doc.addSignatureHelp(signatureHelp(ctx, client, uri, parenPos))
+	}
+
+	if doc.signature == "" && doc.documentation == "" && len(doc.parameters) == 0 {
+		return fmt.Sprintf("No documentation available for %s", name), nil
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Symbol: %s\nFile: %s\nLine: %d\n", name, fileLoc.URI.Path(), fileLoc.Range.Start.Line+1)
+	if doc.signature != "" {
+		fmt.Fprintf(&result, "\nSignature:\n```%s\n%s\n```\n", doc.language, doc.signature)
+	}
+	if doc.documentation != "" {
+		fmt.Fprintf(&result, "\nDocumentation:\n%s\n", doc.documentation)
+	}
+	if len(doc.parameters) > 0 {
+		result.WriteString("\nParameters:\n")
+		for _, param := range doc.parameters {
+			fmt.Fprintf(&result, "- %s\n", param)
+		}
+	}
+	return result.String(), nil
+}
+
+func hoverText(ctx context.Context, client *lsp.Client, loc protocol.Location) string {
+	params := protocol.HoverParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: loc.URI},
+			Position:     loc.Range.Start,
+		},
+	}
+	// Some servers return "content modified" while indexing, so retry
+	for range 3 {
+		hover, err := client.Hover(ctx, params)
+		if err == nil {
+			return hover.ToString()
+		}
+		if !errors.Is(err, lsp.ErrContentModified) {
+			toolsLogger.Debug("Hover failed: %v", err)
+			return ""
+		}
+	}
+	return ""
+}
+
+// resolvedCompletion finds the completion item for name at the end of the
+// identifier and resolves it, which fills in its documentation
+func resolvedCompletion(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, position protocol.Position, name string) *protocol.CompletionItem {
+	result, err := client.Completion(ctx, protocol.CompletionParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     position,
+		},
+	})
+	if err != nil {
+		toolsLogger.Debug("Completion failed: %v", err)
+		return nil
+	}
+
+	var items []protocol.CompletionItem
+	switch v := result.Value.(type) {
+	case protocol.CompletionList:
+		items = v.Items
+	case []protocol.CompletionItem:
+		items = v
+	}
+	for _, item := range items {
+		if item.Label != name && item.FilterText != name && !strings.HasPrefix(item.Label, name+"(") {
+			continue
+		}
+		resolved, err := client.ResolveCompletionItem(ctx, item)
+		if err != nil {
+			toolsLogger.Debug("Resolving completion item failed: %v", err)
+			return &item
+		}
+		return &resolved
+	}
+	return nil
+}
+
+func signatureHelp(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, position protocol.Position) *protocol.SignatureHelp {
+	help, err := client.SignatureHelp(ctx, protocol.SignatureHelpParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     position,
+		},
+	})
+	if err != nil {
+		toolsLogger.Debug("Signature help failed: %v", err)
+		return nil
+	}
+	return &help
+}
+
+// addHover takes the first code block of a hover as the signature and the
+// remaining text as documentation
+func (d *symbolDocumentation) addHover(text string) {
+	blocks, prose := splitCodeBlocks(text)
+	if len(blocks) > 0 {
+		d.language = blocks[0].language
+		d.signature = blocks[0].code
+	}
+	d.documentation = cleanDocumentation(prose)
+}
+
+func (d *symbolDocumentation) addCompletion(item *protocol.CompletionItem) {
+	if item == nil {
+		return
+	}
+	if d.signature == "" && item.Detail != "" {
+		d.signature = item.Detail
+	}
+	if d.documentation == "" && item.Documentation != nil {
+		d.documentation = cleanDocumentation(markupText(item.Documentation.Value))
+	}
+}
+
+func (d *symbolDocumentation) addSignatureHelp(help *protocol.SignatureHelp) {
+	if help == nil || len(help.Signatures) == 0 {
+		return
+	}
+	active := help.ActiveSignature
+	if int(active) >= len(help.Signatures) {
+		active = 0
+	}
+	signature := help.Signatures[active]
+	if d.signature == "" {
+		d.signature = signature.Label
+	}
+	if d.documentation == "" && signature.Documentation != nil {
+		d.documentation = cleanDocumentation(markupText(signature.Documentation.Value))
+	}
+	for _, param := range signature.Parameters {
+		if param.Documentation == nil {
+			continue
+		}
+		paramDoc := cleanDocumentation(markupText(param.Documentation.Value))
+		if paramDoc == "" {
+			continue
+		}
+		var label string
+		switch v := param.Label.Value.(type) {
+		case string:
+			label = v
+		case protocol.Tuple_ParameterInformation_label_Item1:
+			if int(v.Fld0) <= int(v.Fld1) && int(v.Fld1) <= len(signature.Label) {
+				label = signature.Label[v.Fld0:v.Fld1]
+			}
+		}
+		d.parameters = append(d.parameters, fmt.Sprintf("%s: %s", label, strings.ReplaceAll(paramDoc, "\n", " ")))
+	}
+}
+
+// markupText returns the text of a string or MarkupContent documentation value
+func markupText(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case protocol.MarkupContent:
+		return v.Value
+	}
+	return ""
+}
+
+// identifierAt returns the identifier around a column of a line and the
+// offset just past its end
+func identifierAt(line string, column int) (string, int) {
+	isIdent := func(c byte) bool {
+		return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+	}
+	if column > len(line) {
+		column = len(line)
+	}
+	start, end := column, column
+	for start > 0 && isIdent(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isIdent(line[end]) {
+		end++
+	}
+	return line[start:end], end
+}
+
+type codeBlock struct {
+	language string
+	code     string
+}
+
+// splitCodeBlocks separates fenced code blocks from the rest of a markdown text
+func splitCodeBlocks(text string) ([]codeBlock, string) {
+	var blocks []codeBlock
+	var prose []string
+	var current *codeBlock
+	var code []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			if current == nil {
+				current = &codeBlock{language: strings.TrimPrefix(trimmed, "```")}
+				code = nil
+			} else {
+				current.code = strings.TrimSpace(strings.Join(code, "\n"))
+				blocks = append(blocks, *current)
+				current = nil
+			}
+			continue
+		}
+		if current != nil {
+			code = append(code, line)
+		} else {
+			prose = append(prose, line)
+		}
+	}
+	return blocks, strings.Join(prose, "\n")
+}
+
+var (
+	// Lines that only hold a link, like gopls' "[`fmt.Println` on pkg.go.dev](...)"
+	linkLineRegex     = regexp.MustCompile(`^\s*\[[^\]]*\]\([^)]*\)\s*$`)
+	markdownLinkRegex = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	htmlTagRegex      = regexp.MustCompile(`<!--.*?-->|</?[a-zA-Z][^>]*>`)
+	markdownEscape    = regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!<>|])`)
+	ruleLineRegex     = regexp.MustCompile(`^\s*(-{3,}|\*{3,}|_{3,})\s*$`)
+)
+
+// cleanDocumentation strips markdown noise that costs context without adding
+// meaning: links become their text, link-only lines, HTML tags, rules and
+// escapes are removed, and runs of blank lines are collapsed
+func cleanDocumentation(text string) string {
+	var lines []string
+	blank := false
+	inCode := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+		} else if !inCode {
+			if linkLineRegex.MatchString(line) || ruleLineRegex.MatchString(line) {
+				line = ""
+			}
+			line = markdownLinkRegex.ReplaceAllString(line, "$1")
+			line = htmlTagRegex.ReplaceAllString(line, "")
+			line = markdownEscape.ReplaceAllString(line, "$1")
+			line = strings.TrimRight(line, " \t")
+		}
+
+		if line == "" && !inCode {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanDocumentation(t *testing.T) {
+	input := "Println formats using the default formats\\.\n\n\n" +
+		"See [fmt.Printf](https://pkg.go.dev/fmt#Printf) for details.<br/>\n" +
+		"---\n" +
+		"[`fmt.Println` on pkg.go.dev](https://pkg.go.dev/fmt#Println)\n" +
+		"```go\nfmt.Println(\"[a](b)\")\n```\n"
+
+	expected := "Println formats using the default formats.\n\n" +
+		"See fmt.Printf for details.\n\n" +
+		"```go\nfmt.Println(\"[a](b)\")\n```"
+	assert.Equal(t, expected, cleanDocumentation(input))
+}
+
+func TestSymbolDocumentationAddHover(t *testing.T) {
+	doc := &symbolDocumentation{}
+	doc.addHover("```go\nfunc Println(a ...any) (n int, err error)\n```\n\n---\n\nPrintln writes to standard output\\.\n")
+	assert.Equal(t, "go", doc.language)
+	assert.Equal(t, "func Println(a ...any) (n int, err error)", doc.signature)
+	assert.Equal(t, "Println writes to standard output.", doc.documentation)
+
+	// Completion only fills in what hover didn't provide
+	doc.addCompletion(&protocol.CompletionItem{
+		Detail:        "func(a ...any)",
+		Documentation: &protocol.Or_CompletionItem_documentation{Value: "Other docs"},
+	})
+	assert.Equal(t, "func Println(a ...any) (n int, err error)", doc.signature)
+	assert.Equal(t, "Println writes to standard output.", doc.documentation)
+}
+
+func TestSymbolDocumentationAddSignatureHelp(t *testing.T) {
+	doc := &symbolDocumentation{}
+	doc.addSignatureHelp(&protocol.SignatureHelp{
+		Signatures: []protocol.SignatureInformation{{
+			Label:         "greet(name: str, loud: bool)",
+			Documentation: &protocol.Or_SignatureInformation_documentation{Value: protocol.MarkupContent{Kind: protocol.Markdown, Value: "Say *hello*."}},
+			Parameters: []protocol.ParameterInformation{
+				{
+					Label:         protocol.Or_ParameterInformation_label{Value: protocol.Tuple_ParameterInformation_label_Item1{Fld0: 6, Fld1: 15}},
+					Documentation: &protocol.Or_ParameterInformation_documentation{Value: "who to greet"},
+				},
+				{Label: protocol.Or_ParameterInformation_label{Value: "loud: bool"}},
+			},
+		}},
+	})
+	assert.Equal(t, "greet(name: str, loud: bool)", doc.signature)
+	assert.Equal(t, "Say *hello*.", doc.documentation)
+	assert.Equal(t, []string{"name: str: who to greet"}, doc.parameters)
+}
+
+func TestIdentifierAt(t *testing.T) {
+	name, end := identifierAt("\tresult := strings.ToUpper(s)", 20)
+	assert.Equal(t, "ToUpper", name)
+	assert.Equal(t, 26, end)
+
+	name, _ = identifierAt("a + b", 2)
+	assert.Equal(t, "", name)
+}
+
+func TestUTF16Columns(t *testing.T) {
+	// "é" is two bytes and one UTF-16 unit, "𝔘" four bytes and two units
+	line := `s := "é𝔘" + name`
+	offset := strings.Index(line, "name")
+	assert.Equal(t, 16, offset)
+	assert.Equal(t, 13, utilities.UTF16Column(line, offset))
+	assert.Equal(t, offset, utilities.ByteOffset(line, 13))
+
+	name, end := identifierAt(line, utilities.ByteOffset(line, 14))
+	assert.Equal(t, "name", name)
+	assert.Equal(t, 17, utilities.UTF16Column(line, end))
+
+	assert.Equal(t, len(line), utilities.ByteOffset(line, 100))
+	assert.Equal(t, 0, utilities.UTF16Column(line, 0))
+}
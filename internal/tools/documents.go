@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// untitledCount numbers the documents opened from content without a path
+var untitledCount atomic.Int64
+
+// WithDocument runs fn against a document whose content or language comes
+// from the caller instead of the file on disk. With content and no filePath,
+// an untitled document is created in the workspace root; it is never written
+// to disk. With only languageID, the file on disk is opened as that language.
+// The document is closed again afterwards. Without either, fn simply gets
+// filePath.
+func WithDocument(ctx context.Context, client *lsp.Client, workspaceDir, filePath, content, languageID string, fn func(filePath string) (string, error)) (string, error) {
+	if content == "" && languageID == "" {
+		if filePath == "" {
+			return "", codedErrorf(InvalidArgument, "either filePath or content is required")
+		}
+		return fn(filePath)
+	}
+
+	language := protocol.LanguageKind(languageID)
+	switch {
+	case filePath == "" && content == "":
+		return "", codedErrorf(InvalidArgument, "either filePath or content is required")
+	case filePath == "":
+		if language == "" {
+			return "", codedErrorf(InvalidArgument, "languageId is required for content without a filePath")
+		}
+		ext := lsp.LanguageExtension(language)
+		if ext == "" {
+			return "", codedErrorf(InvalidArgument, "unknown languageId %q, give a filePath with a matching extension instead", languageID)
+		}
+		for {
+			filePath = filepath.Join(workspaceDir, fmt.Sprintf("untitled-%d%s", untitledCount.Add(1), ext))
+			if _, err := os.Stat(filePath); os.IsNotExist(err) {
+				break
+			}
+		}
+	case content == "":
+		data, err := utilities.ReadFile(filePath)
+		if err != nil {
+			return "", fmt.Errorf("error reading file: %w", err)
+		}
+		content = string(data)
+	}
+
+	if err := client.OpenDocument(ctx, filePath, language, content); err != nil {
+		return "", fmt.Errorf("could not open document: %w", err)
+	}
+	defer func() {
+		// Closed even if the call was cancelled
+		if err := client.CloseDocument(context.WithoutCancel(ctx), filePath); err != nil {
+			toolsLogger.Error("Error closing document %s: %v", filePath, err)
+		}
+	}()
+
+	return fn(filePath)
+}
@@ -0,0 +1,371 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// maxDuplicateBucket is the most places a window of tokens is compared
+// across. Windows repeated more often are boilerplate, such as runs of
+// similar declarations, and are skipped rather than compared pairwise.
+const maxDuplicateBucket = 64
+
+// duplicateKeywords keep their names when tokens are normalized, so that
+// code matching in everything but the names of its variables, functions
+// and types counts as duplicated while code with different control flow
+// doesn't
+var duplicateKeywords = map[string]bool{
+	"if": true, "else": true, "elif": true, "for": true, "foreach": true, "while": true, "do": true, "switch": true,
+	"case": true, "default": true, "break": true, "continue": true, "return": true, "goto": true, "try": true,
+	"catch": true, "except": true, "finally": true, "throw": true, "raise": true, "func": true, "function": true,
+	"def": true, "fn": true, "class": true, "struct": true, "interface": true, "enum": true, "type": true,
+	"var": true, "let": true, "const": true, "new": true, "go": true, "defer": true, "select": true, "range": true,
+	"match": true, "with": true, "yield": true, "await": true, "async": true, "import": true, "package": true,
+	"true": true, "false": true, "nil": true, "null": true, "None": true, "self": true, "this": true,
+}
+
+// sourceToken is a normalized token and the 0-indexed line it is on
+type sourceToken struct {
+	text string
+	line int
+}
+
+// tokenizedFile is a file as normalized tokens
+type tokenizedFile struct {
+	path   string
+	tokens []sourceToken
+	lines  []string
+}
+
+// duplicateBlock is code that occurs in several places
+type duplicateBlock struct {
+	tokens int
+	// copies are the places it occurs, by path and first line
+	copies []duplicateCopy
+}
+
+// duplicateCopy is one place a duplicated block occurs
+type duplicateCopy struct {
+	file       *tokenizedFile
+	start, end int // token indexes, end exclusive
+}
+
+func (c duplicateCopy) startLine() int { return c.file.tokens[c.start].line }
+func (c duplicateCopy) endLine() int   { return c.file.tokens[c.end-1].line }
+
+// FindDuplicates reports blocks of at least minTokens tokens that occur more
+// than once in the files under path, the workspace by default. Tokens are
+// compared with names, strings and numbers normalized and comments left
+// out, so copies that were renamed or reformatted still match. Files are
+// read in parallel, and files ignored by git and generated files are
+// skipped. The largest maxResults blocks are described.
+func FindDuplicates(ctx context.Context, workspaceDir, path string, minTokens, maxResults int) (string, error) {
+	if minTokens < 10 {
+		return "", codedErrorf(InvalidArgument, "minTokens must be at least 10, got %d", minTokens)
+	}
+	if maxResults < 1 {
+		return "", codedErrorf(InvalidArgument, "maxResults must be at least 1, got %d", maxResults)
+	}
+	if path == "" {
+		path = workspaceDir
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workspaceDir, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", codedErrorf(InvalidArgument, "invalid path: %v", err)
+	}
+	rel := func(file string) string {
+		if r, err := filepath.Rel(workspaceDir, file); err == nil {
+			return r
+		}
+		return file
+	}
+
+	var mu sync.Mutex
+	var files []*tokenizedFile
+	add := func(file string) error {
+		if heuristics.IsStructured(file) || lsp.DetectLanguageID("file://"+file) == "" || (excludeGenerated() && isGeneratedFile(file)) {
+			return nil
+		}
+		content, err := os.ReadFile(file)
+		if err != nil || heuristics.OpaqueKind(content) != "" {
+			return nil
+		}
+		tokenized := tokenizeSource(file, string(content))
+		if len(tokenized.tokens) < minTokens {
+			return nil
+		}
+		mu.Lock()
+		files = append(files, tokenized)
+		mu.Unlock()
+		return nil
+	}
+	if info.IsDir() {
+		err = heuristics.ScanSourceFiles(ctx, path, add)
+	} else {
+		err = add(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	// Files are read in any order, but results shouldn't depend on it
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+
+	blocks := findDuplicateBlocks(files, minTokens)
+	if len(blocks) == 0 {
+		return fmt.Sprintf("No duplicated blocks of %d or more tokens found in %d files under %s", minTokens, len(files), rel(path)), nil
+	}
+
+	duplicatedLines := 0
+	for _, block := range blocks {
+		for _, c := range block.copies[1:] {
+			duplicatedLines += c.endLine() - c.startLine() + 1
+		}
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "Found %d duplicated blocks of %d or more tokens in %d files under %s, %d lines in copies beyond the first",
+		len(blocks), minTokens, len(files), rel(path), duplicatedLines)
+	shown := blocks[:min(len(blocks), maxResults)]
+	if len(shown) < len(blocks) {
+		fmt.Fprintf(&out, "; the %d largest are shown", len(shown))
+	}
+	out.WriteString("\n")
+	for i, block := range shown {
+		first := block.copies[0]
+		fmt.Fprintf(&out, "\n%d. %d tokens, %d lines, %d copies:\n", i+1, block.tokens, first.endLine()-first.startLine()+1, len(block.copies))
+		for _, c := range block.copies {
+			fmt.Fprintf(&out, "   %s:%d-%d\n", rel(c.file.path), c.startLine()+1, c.endLine()+1)
+		}
+		end := min(first.endLine(), first.startLine()+maxExampleLines-1)
+		out.WriteString(addLineNumbers(strings.Join(first.file.lines[first.startLine():end+1], "\n"), first.startLine()+1))
+		if end < first.endLine() {
+			out.WriteString("...\n")
+		}
+	}
+	return out.String(), nil
+}
+
+// findDuplicateBlocks finds the maximal runs of at least minTokens tokens
+// that occur in more than one place, largest first. Windows of minTokens
+// tokens are hashed to find candidates, which are then compared and
+// extended token by token.
+func findDuplicateBlocks(files []*tokenizedFile, minTokens int) []duplicateBlock {
+	type position struct {
+		file  int
+		index int
+	}
+	buckets := make(map[uint64][]position)
+	for f, file := range files {
+		for i, hash := range windowHashes(file.tokens, minTokens) {
+			buckets[hash] = append(buckets[hash], position{f, i})
+		}
+	}
+
+	equal := func(a, b position) bool {
+		return files[a.file].tokens[a.index].text == files[b.file].tokens[b.index].text
+	}
+	groups := make(map[string]*duplicateBlock)
+	var order []string
+	for _, bucket := range buckets {
+		if len(bucket) < 2 || len(bucket) > maxDuplicateBucket {
+			continue
+		}
+		for i, a := range bucket {
+			for _, b := range bucket[i+1:] {
+				// A pair whose previous tokens match too is part of a
+				// longer block found from an earlier window
+				if a.index > 0 && b.index > 0 && equal(position{a.file, a.index - 1}, position{b.file, b.index - 1}) {
+					continue
+				}
+				length := 0
+				for a.index+length < len(files[a.file].tokens) && b.index+length < len(files[b.file].tokens) &&
+					equal(position{a.file, a.index + length}, position{b.file, b.index + length}) {
+					length++
+				}
+				if a.file == b.file {
+					// Copies must not overlap, as in a run of repeated lines
+					length = min(length, b.index-a.index)
+				}
+				if length < minTokens {
+					continue
+				}
+				first := duplicateCopy{files[a.file], a.index, a.index + length}
+				second := duplicateCopy{files[b.file], b.index, b.index + length}
+				key := fmt.Sprintf("%s:%d:%d", first.file.path, first.start, length)
+				block, ok := groups[key]
+				if !ok {
+					block = &duplicateBlock{tokens: length, copies: []duplicateCopy{first}}
+					groups[key] = block
+					order = append(order, key)
+				}
+				block.copies = append(block.copies, second)
+			}
+		}
+	}
+
+	// A block also found from a pair of its later copies is listed once,
+	// from its first copy
+	later := make(map[string]bool)
+	for _, block := range groups {
+		for _, c := range block.copies[1:] {
+			later[fmt.Sprintf("%s:%d:%d", c.file.path, c.start, block.tokens)] = true
+		}
+	}
+	blocks := make([]duplicateBlock, 0, len(order))
+	for _, key := range order {
+		if later[key] {
+			continue
+		}
+		block := groups[key]
+		sort.Slice(block.copies, func(i, j int) bool {
+			a, b := block.copies[i], block.copies[j]
+			if a.file.path != b.file.path {
+				return a.file.path < b.file.path
+			}
+			return a.start < b.start
+		})
+		// Copies found from several pairs are listed once
+		copies := block.copies[:1]
+		for _, c := range block.copies[1:] {
+			if last := copies[len(copies)-1]; c.file != last.file || c.start != last.start {
+				copies = append(copies, c)
+			}
+		}
+		block.copies = copies
+		blocks = append(blocks, *block)
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		a, b := blocks[i], blocks[j]
+		switch {
+		case a.tokens != b.tokens:
+			return a.tokens > b.tokens
+		case a.copies[0].file.path != b.copies[0].file.path:
+			return a.copies[0].file.path < b.copies[0].file.path
+		}
+		return a.copies[0].start < b.copies[0].start
+	})
+	return blocks
+}
+
+// windowHashes returns the rolling hash of every window of size tokens
+func windowHashes(tokens []sourceToken, size int) []uint64 {
+	if len(tokens) < size {
+		return nil
+	}
+	const base = 1099511628211
+	tokenHash := func(text string) uint64 {
+		h := uint64(14695981039346656037)
+		for i := 0; i < len(text); i++ {
+			h = (h ^ uint64(text[i])) * base
+		}
+		return h
+	}
+	// power is base^(size-1), which the token leaving a window was
+	// multiplied by
+	power := uint64(1)
+	for range size - 1 {
+		power *= base
+	}
+	hashes := make([]uint64, 0, len(tokens)-size+1)
+	var hash uint64
+	for i, token := range tokens {
+		if i >= size {
+			hash -= tokenHash(tokens[i-size].text) * power
+		}
+		hash = hash*base + tokenHash(token.text)
+		if i >= size-1 {
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
+
+// tokenizeSource splits a file into normalized tokens: names other than
+// keywords become "$", strings "\"" and numbers "0", and comments are left
+// out
+func tokenizeSource(path, content string) *tokenizedFile {
+	language := lsp.DetectLanguageID("file://" + path)
+	hashComments := language == protocol.LangPython || language == protocol.LangRuby || language == protocol.LangShellScript
+	file := &tokenizedFile{path: path, lines: strings.Split(content, "\n")}
+	line := 0
+	isName := func(c byte) bool {
+		return c == '_' || c == '$' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c >= 0x80
+	}
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '\n':
+			line++
+		case c == ' ' || c == '\t' || c == '\r':
+		case c == '/' && i+1 < len(content) && content[i+1] == '/', hashComments && c == '#':
+			for i+1 < len(content) && content[i+1] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				end = len(content) - i - 2
+			}
+			line += strings.Count(content[i:i+2+end], "\n")
+			i += end + 3
+		case c == '"' || c == '\'' || c == '`':
+			end := quotedEnd(content, i, c)
+			file.tokens = append(file.tokens, sourceToken{`"`, line})
+			line += strings.Count(content[i:end], "\n")
+			i = end
+		case '0' <= c && c <= '9':
+			for i+1 < len(content) && (isName(content[i+1]) || content[i+1] == '.') {
+				i++
+			}
+			file.tokens = append(file.tokens, sourceToken{"0", line})
+		case isName(c):
+			start := i
+			for i+1 < len(content) && isName(content[i+1]) {
+				i++
+			}
+			word := content[start : i+1]
+			if !duplicateKeywords[word] {
+				word = "$"
+			}
+			file.tokens = append(file.tokens, sourceToken{word, line})
+		default:
+			file.tokens = append(file.tokens, sourceToken{string(c), line})
+		}
+	}
+	return file
+}
+
+// quotedEnd returns the index of the quote closing the string that opens at
+// start. Only backquoted strings run over several lines; other unclosed
+// strings end before the line break.
+func quotedEnd(content string, start int, quote byte) int {
+	for i := start + 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case '\n':
+			if quote != '`' {
+				return i - 1
+			}
+		case quote:
+			return i
+		}
+	}
+	return len(content) - 1
+}
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenizeSource(t *testing.T) {
+	file := tokenizeSource("a.go", "if count > 10 { // check\n\treturn \"a\\\"b\" /* c\n d */ + `x\ny`\n}\n")
+	var texts []string
+	for _, token := range file.tokens {
+		texts = append(texts, token.text)
+	}
+	assert.Equal(t, []string{"if", "$", ">", "0", "{", "return", `"`, "+", `"`, "}"}, texts)
+	assert.Equal(t, 4, file.tokens[len(file.tokens)-1].line)
+
+	python := tokenizeSource("a.py", "x = 'it''s' # it's\ny = 2\n")
+	assert.Len(t, python.tokens, 7)
+	assert.Equal(t, 1, python.tokens[4].line)
+}
+
+func TestFindDuplicates(t *testing.T) {
+	block := func(name, item string) string {
+		return "func " + name + "(items []int) int {\n\ttotal := 0\n\tfor _, " + item + " := range items {\n\t\tif " + item + " > 0 {\n\t\t\ttotal += " + item + "\n\t\t}\n\t}\n\treturn total\n}\n"
+	}
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"a.go":      "package a\n\n" + block("SumA", "item"),
+		"b/b.go":    "package b\n\n// SumB is a renamed copy\n" + block("SumB", "value"),
+		"c/c.go":    "package c\n\n" + block("SumC", "x") + "\nfunc Other() {}\n",
+		"d/tiny.go": "package d\n",
+	})
+	ctx := context.Background()
+
+	// The package clauses match too, since names are normalized
+	result, err := FindDuplicates(ctx, dir, "", 20, 10)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(result, "Found 1 duplicated blocks of 20 or more tokens in 3 files under ., 23 lines in copies beyond the first\n"), result)
+	assert.Contains(t, result, "\n1. 39 tokens, 11 lines, 3 copies:\n   a.go:1-11\n   b/b.go:1-12\n   c/c.go:1-11\n1|package a\n2|\n3|func SumA(items []int) int {\n")
+
+	result, err = FindDuplicates(ctx, dir, "", 100, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "No duplicated blocks of 100 or more tokens found in 0 files under .", result)
+
+	_, err = FindDuplicates(ctx, dir, "", 5, 10)
+	assert.Equal(t, InvalidArgument, ErrorCodeOf(err))
+}
@@ -1,10 +1,8 @@
 package tools
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"os"
 	"sort"
 	"strings"
 
@@ -22,7 +20,7 @@ type TextEdit struct {
 func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, edits []TextEdit) (string, error) {
 	err := client.OpenFile(ctx, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", fmt.Errorf("could not open file: %w", err)
 	}
 
 	// Create a sorted copy of edits for reporting
@@ -62,7 +60,7 @@ func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, ed
 		// Get the range covering the requested lines
 		rng, err := getRange(edit.StartLine, edit.EndLine, filePath)
 		if err != nil {
-			return "", fmt.Errorf("invalid position: %v", err)
+			return "", WithCode(PositionInvalid, fmt.Errorf("invalid position: %w", err))
 		}
 
 		// Always do a replacement
@@ -78,8 +76,8 @@ func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, ed
 		},
 	}
 
-	if err := utilities.ApplyWorkspaceEdit(edit); err != nil {
-		return "", fmt.Errorf("failed to apply text edits: %v", err)
+	if err := utilities.ApplyWorkspaceEdit(ctx, edit); err != nil {
+		return "", fmt.Errorf("failed to apply text edits: %w", err)
 	}
 
 	return fmt.Sprintf("Successfully applied text edits. %d lines removed, %d lines added.", linesRemovedSorted, linesAddedSorted), nil
@@ -87,25 +85,26 @@ func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, ed
 
 // getRange creates a protocol.Range that covers the specified start and end lines
 func getRange(startLine, endLine int, filePath string) (protocol.Range, error) {
-	content, err := os.ReadFile(filePath)
+	readFile := utilities.ReadFile
+	if lsp.IsNotebook(filePath) {
+		// Notebook lines are those of the view that read tools show
+		readFile = lsp.ReadSourceFile
+	}
+	content, err := readFile(filePath)
 	if err != nil {
 		return protocol.Range{}, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	// Detect line ending style
-	var lineEnding string
-	if bytes.Contains(content, []byte("\r\n")) {
-		lineEnding = "\r\n"
-	} else {
-		lineEnding = "\n"
+	// Split lines without the line endings, counting a CR before a line
+	// feed as part of the ending, as the edits are applied
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
 	}
 
-	// Split lines without the line endings
-	lines := strings.Split(string(content), lineEnding)
-
 	// Handle start line positioning
 	if startLine < 1 {
-		return protocol.Range{}, fmt.Errorf("start line must be >= 1, got %d", startLine)
+		return protocol.Range{}, codedErrorf(PositionInvalid, "start line must be >= 1, got %d", startLine)
 	}
 
 	// Convert to 0-based line numbers
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// ErrorCode classifies a tool failure, so that agents can act on the kind of
+// failure without parsing the message
+type ErrorCode string
+
+const (
+	// ServerNotReady means the language server can't answer yet, for example
+	// while it loads the workspace or restarts after exiting. Retrying later
+	// may work.
+	ServerNotReady ErrorCode = "SERVER_NOT_READY"
+	// SymbolNotFound means no symbol matched the name or position
+	SymbolNotFound ErrorCode = "SYMBOL_NOT_FOUND"
+	// PositionInvalid means a line, column or range is outside the file
+	PositionInvalid ErrorCode = "POSITION_INVALID"
+	// UnsupportedCapability means the language server or the file type
+	// doesn't support the operation
+	UnsupportedCapability ErrorCode = "UNSUPPORTED_CAPABILITY"
+	// Timeout means the operation took too long or was cancelled
+	Timeout ErrorCode = "TIMEOUT"
+	// EditConflict means edits overlap each other or the files changed
+	EditConflict ErrorCode = "EDIT_CONFLICT"
+	// Ambiguous means the request matched several things, which are listed
+	Ambiguous ErrorCode = "AMBIGUOUS"
+	// InvalidArgument means an argument is missing or malformed
+	InvalidArgument ErrorCode = "INVALID_ARGUMENT"
+	// FileTooLarge means a file is larger than the server reads whole
+	FileTooLarge ErrorCode = "FILE_TOO_LARGE"
+	// LargeDeletion means the edits would delete most of a file, which they
+	// only do when forced
+	LargeDeletion ErrorCode = "LARGE_DELETION"
+	// ToolDisabled means the tool is turned off by the server's configuration
+	// or the workspace isn't trusted
+	ToolDisabled ErrorCode = "TOOL_DISABLED"
+	// InternalError is any other failure
+	InternalError ErrorCode = "INTERNAL_ERROR"
+)
+
+// CodedError is an error with the code it is reported with
+type CodedError struct {
+	Code ErrorCode
+	Err  error
+}
+
+func (e *CodedError) Error() string { return e.Err.Error() }
+
+func (e *CodedError) Unwrap() error { return e.Err }
+
+// codedErrorf returns a formatted error with a code
+func codedErrorf(code ErrorCode, format string, args ...any) error {
+	return &CodedError{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// WithCode gives an error a code, unless it is nil
+func WithCode(code ErrorCode, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &CodedError{Code: code, Err: err}
+}
+
+// ErrorCodeOf returns the code of an error, worked out from the errors it
+// wraps when none was given explicitly
+func ErrorCodeOf(err error) ErrorCode {
+	var coded *CodedError
+	var ambiguous *AmbiguousError
+	var response *lsp.ResponseError
+	switch {
+	case errors.As(err, &coded):
+		return coded.Code
+	case errors.As(err, &ambiguous):
+		return Ambiguous
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return Timeout
+	case errors.Is(err, utilities.ErrEditConflict):
+		return EditConflict
+	case errors.Is(err, utilities.ErrFileTooLarge):
+		return FileTooLarge
+	case errors.Is(err, utilities.ErrLargeDeletion):
+		return LargeDeletion
+	case errors.Is(err, lsp.ErrContentModified), errors.Is(err, lsp.ErrServerCancelled), errors.Is(err, lsp.ErrServerExited):
+		return ServerNotReady
+	case errors.As(err, &response):
+		switch response.Code {
+		case int(protocol.MethodNotFound):
+			return UnsupportedCapability
+		case int(protocol.ServerNotInitialized):
+			return ServerNotReady
+		case int(protocol.InvalidParams):
+			return InvalidArgument
+		case int(protocol.RequestCancelled):
+			return Timeout
+		}
+	}
+	return InternalError
+}
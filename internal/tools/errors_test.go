@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorCodeOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		code ErrorCode
+	}{
+		{codedErrorf(SymbolNotFound, "Foo not found"), SymbolNotFound},
+		// Codes survive wrapping
+		{fmt.Errorf("failed to get content: %w", codedErrorf(PositionInvalid, "line number out of range")), PositionInvalid},
+		{&AmbiguousError{Question: "Which Foo?"}, Ambiguous},
+		{fmt.Errorf("textDocument/hover: %w", context.DeadlineExceeded), Timeout},
+		{fmt.Errorf("failed to apply edit: %w", fmt.Errorf("wrapped: %w", utilities.ErrEditConflict)), EditConflict},
+		{fmt.Errorf("could not open file: %w", utilities.ErrFileTooLarge), FileTooLarge},
+		{fmt.Errorf("failed to apply edit: %w", utilities.ErrLargeDeletion), LargeDeletion},
+		{lsp.ErrContentModified, ServerNotReady},
+		{fmt.Errorf("request failed: %w", &lsp.ResponseError{Code: -32601, Message: "method not found"}), UnsupportedCapability},
+		{fmt.Errorf("request failed: %w", &lsp.ResponseError{Code: -32002, Message: "not initialized"}), ServerNotReady},
+		{fmt.Errorf("request failed: %w", &lsp.ResponseError{Code: -32603, Message: "internal"}), InternalError},
+		{errors.New("something else"), InternalError},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.code, ErrorCodeOf(tt.err), tt.err.Error())
+	}
+}
@@ -14,14 +14,14 @@ func ExecuteCodeLens(ctx context.Context, client *lsp.Client, filePath string, i
 	// Open the file
 	err := client.OpenFile(ctx, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", fmt.Errorf("could not open file: %w", err)
 	}
 	// TODO: find a more appropriate way to wait
 	time.Sleep(time.Second)
 
 	// Get code lenses
 	docIdentifier := protocol.TextDocumentIdentifier{
-		URI: protocol.DocumentUri("file://" + filePath),
+		URI: protocol.URIFromPath(filePath),
 	}
 
 	params := protocol.CodeLensParams{
@@ -29,7 +29,7 @@ func ExecuteCodeLens(ctx context.Context, client *lsp.Client, filePath string, i
 	}
 	codeLenses, err := client.CodeLens(ctx, params)
 	if err != nil {
-		return "", fmt.Errorf("failed to get code lenses: %v", err)
+		return "", fmt.Errorf("failed to get code lenses: %w", err)
 	}
 
 	if len(codeLenses) == 0 {
@@ -37,7 +37,7 @@ func ExecuteCodeLens(ctx context.Context, client *lsp.Client, filePath string, i
 	}
 
 	if index < 1 || index > len(codeLenses) {
-		return "", fmt.Errorf("invalid code lens index: %d. Available range: 1-%d", index, len(codeLenses))
+		return "", codedErrorf(InvalidArgument, "invalid code lens index: %d. Available range: 1-%d", index, len(codeLenses))
 	}
 
 	lens := codeLenses[index-1]
@@ -46,7 +46,7 @@ func ExecuteCodeLens(ctx context.Context, client *lsp.Client, filePath string, i
 	if lens.Command == nil {
 		resolvedLens, err := client.ResolveCodeLens(ctx, lens)
 		if err != nil {
-			return "", fmt.Errorf("failed to resolve code lens: %v", err)
+			return "", fmt.Errorf("failed to resolve code lens: %w", err)
 		}
 		lens = resolvedLens
 	}
@@ -61,7 +61,7 @@ func ExecuteCodeLens(ctx context.Context, client *lsp.Client, filePath string, i
 		Arguments: lens.Command.Arguments,
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to execute code lens command: %v", err)
+		return "", fmt.Errorf("failed to execute code lens command: %w", err)
 	}
 
 	return fmt.Sprintf("Successfully executed code lens command: %s", lens.Command.Title), nil
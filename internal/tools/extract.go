@@ -0,0 +1,196 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// extractTarget describes what an extract refactoring produces
+type extractTarget struct {
+	name string
+	// kinds are the code action kinds servers use for the refactoring
+	kinds []protocol.CodeActionKind
+	// titleWords identify the refactoring among plain refactor.extract
+	// actions, which some servers use for everything
+	titleWords []string
+	// defaultNames are the names servers give the new symbol
+	defaultNames []string
+}
+
+var (
+	extractFunction = extractTarget{
+		name:         "function",
+		kinds:        []protocol.CodeActionKind{"refactor.extract.function", "refactor.extract.method"},
+		titleWords:   []string{"function", "method"},
+		defaultNames: []string{"newFunction", "newMethod", "fun_name", "extracted_function", "new_function"},
+	}
+	extractVariable = extractTarget{
+		name:         "variable",
+		kinds:        []protocol.CodeActionKind{"refactor.extract.variable", "refactor.extract.constant"},
+		titleWords:   []string{"variable", "constant", "local"},
+		defaultNames: []string{"newLocal", "newVar", "var_name", "newConst", "new_var", "x"},
+	}
+)
+
+// ExtractFunction moves the selected code into a new function named newName
+// using the language server's extract refactoring
+func ExtractFunction(ctx context.Context, client *lsp.Client, filePath string, startLine, startColumn, endLine, endColumn int, newName string) (string, error) {
+	return extract(ctx, client, filePath, startLine, startColumn, endLine, endColumn, newName, extractFunction)
+}
+
+// ExtractVariable moves the selected expression into a new variable named
+// newName using the language server's extract refactoring
+func ExtractVariable(ctx context.Context, client *lsp.Client, filePath string, startLine, startColumn, endLine, endColumn int, newName string) (string, error) {
+	return extract(ctx, client, filePath, startLine, startColumn, endLine, endColumn, newName, extractVariable)
+}
+
+func extract(ctx context.Context, client *lsp.Client, filePath string, startLine, startColumn, endLine, endColumn int, newName string, target extractTarget) (string, error) {
+	rng, err := lineColumnRange(startLine, startColumn, endLine, endColumn)
+	if err != nil {
+		return "", err
+	}
+	actions, err := codeActionsAt(ctx, client, filePath, rng, []protocol.CodeActionKind{protocol.RefactorExtract})
+	if err != nil {
+		return "", err
+	}
+	action, ok := pickExtractAction(actions, target)
+	if !ok {
+		if len(actions) == 0 {
+			return fmt.Sprintf("The language server offers no extract refactorings for %s L%d:C%d - L%d:C%d", filePath, startLine, startColumn, endLine, endColumn), nil
+		}
+		return fmt.Sprintf("The language server offers no extract %s refactoring here. Available:\n%s", target.name, formatCodeActions(client, actions)), nil
+	}
+
+	changes, err := applyCodeAction(ctx, client, action)
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Applied: %s\n", action.Title)
+
+	if newName != "" {
+		if err := renameExtracted(ctx, client, filePath, changes, newName, target); err != nil {
+			fmt.Fprintf(&result, "Could not rename the new %s to %s: %v\n", target.name, newName, err)
+		} else {
+			fmt.Fprintf(&result, "Named the new %s %s\n", target.name, newName)
+		}
+	}
+
+	diff := changes.diff()
+	if diff == "" {
+		result.WriteString("No changes were made\n")
+		return result.String(), nil
+	}
+	result.WriteString("\n" + diff)
+	return result.String(), nil
+}
+
+// pickExtractAction chooses the action for an extract target, preferring
+// specific kinds, then plain refactor.extract actions whose titles match.
+// Servers list the innermost scope first, so the first match wins.
+func pickExtractAction(actions []protocol.CodeAction, target extractTarget) (protocol.CodeAction, bool) {
+	for _, action := range actions {
+		if action.Disabled == nil && codeActionKindIn(action.Kind, target.kinds) {
+			return action, true
+		}
+	}
+	for _, action := range actions {
+		if action.Disabled != nil || action.Kind != protocol.RefactorExtract && action.Kind != "" {
+			continue
+		}
+		title := strings.ToLower(action.Title)
+		for _, word := range target.titleWords {
+			if strings.Contains(title, word) {
+				return action, true
+			}
+		}
+	}
+	return protocol.CodeAction{}, false
+}
+
+// renameExtracted finds the name the server gave the extracted symbol and
+// renames it
+func renameExtracted(ctx context.Context, client *lsp.Client, filePath string, changes *appliedChanges, newName string, target extractTarget) error {
+	before, ok := changes.before[filePath]
+	if !ok {
+		return fmt.Errorf("%s was not changed", filePath)
+	}
+	after, err := lsp.ReadSourceFile(filePath)
+	if err != nil {
+		return err
+	}
+	generated, position, ok := generatedIdentifier(before, string(after), target.defaultNames)
+	if !ok {
+		return fmt.Errorf("could not find the generated name")
+	}
+	if generated == newName {
+		return nil
+	}
+
+	edit, err := client.Rename(ctx, protocol.RenameParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filePath)},
+		Position:     position,
+		NewName:      newName,
+	})
+	if err != nil {
+		return err
+	}
+	changes.snapshot(edit)
+	if err := utilities.ApplyWorkspaceEdit(ctx, edit); err != nil {
+		return fmt.Errorf("failed to apply changes: %w", err)
+	}
+	syncChangedFiles(ctx, client, workspaceEditFiles(edit))
+	return nil
+}
+
+var identifierRegex = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// generatedIdentifier finds the identifier a refactoring introduced: a name
+// servers use by default if one appears, otherwise the new identifier used
+// most often. It returns the position of its first use.
+func generatedIdentifier(before, after string, defaultNames []string) (string, protocol.Position, bool) {
+	existing := make(map[string]bool)
+	for _, name := range identifierRegex.FindAllString(before, -1) {
+		existing[name] = true
+	}
+
+	counts := make(map[string]int)
+	first := make(map[string]protocol.Position)
+	var order []string
+	for i, line := range strings.Split(after, "\n") {
+		for _, loc := range identifierRegex.FindAllStringIndex(line, -1) {
+			name := line[loc[0]:loc[1]]
+			if existing[name] {
+				continue
+			}
+			if counts[name] == 0 {
+				first[name] = protocol.Position{Line: uint32(i), Character: uint32(loc[0])}
+				order = append(order, name)
+			}
+			counts[name]++
+		}
+	}
+	if len(order) == 0 {
+		return "", protocol.Position{}, false
+	}
+
+	for _, name := range defaultNames {
+		if counts[name] > 0 {
+			return name, first[name], true
+		}
+	}
+	best := order[0]
+	for _, name := range order[1:] {
+		if counts[name] > counts[best] {
+			best = name
+		}
+	}
+	return best, first[best], true
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickExtractAction(t *testing.T) {
+	actions := []protocol.CodeAction{
+		{Title: "Extract variable", Kind: "refactor.extract.variable"},
+		{Title: "Extract function", Kind: "refactor.extract.function", Disabled: &protocol.CodeActionDisabled{Reason: "invalid selection"}},
+		{Title: "Extract method", Kind: "refactor.extract.method"},
+	}
+	action, ok := pickExtractAction(actions, extractFunction)
+	assert.True(t, ok)
+	assert.Equal(t, "Extract method", action.Title)
+
+	action, ok = pickExtractAction(actions, extractVariable)
+	assert.True(t, ok)
+	assert.Equal(t, "Extract variable", action.Title)
+
+	// Servers that only use refactor.extract are matched by title
+	action, ok = pickExtractAction([]protocol.CodeAction{
+		{Title: "Extract into variable", Kind: "refactor.extract"},
+		{Title: "Extract into function", Kind: "refactor.extract"},
+	}, extractFunction)
+	assert.True(t, ok)
+	assert.Equal(t, "Extract into function", action.Title)
+
+	_, ok = pickExtractAction([]protocol.CodeAction{{Title: "Extract interface", Kind: "refactor.extract.interface"}}, extractFunction)
+	assert.False(t, ok)
+}
+
+func TestGeneratedIdentifier(t *testing.T) {
+	before := "func main() {\n\ttotal := a + b\n\tprint(total)\n}\n"
+	after := "func main() {\n\ttotal := newFunction(a, b)\n\tprint(total)\n}\n\nfunc newFunction(a, b int) int {\n\treturn a + b\n}\n"
+
+	name, position, ok := generatedIdentifier(before, after, extractFunction.defaultNames)
+	assert.True(t, ok)
+	assert.Equal(t, "newFunction", name)
+	assert.Equal(t, protocol.Position{Line: 1, Character: 10}, position)
+
+	// Unknown names fall back to the most used new identifier
+	after = "func main() {\n\ttotal := helper1(a, b)\n\tprint(total)\n}\n\nfunc helper1(a, b int) int {\n\treturn a + b\n}\n"
+	name, _, ok = generatedIdentifier(before, after, extractFunction.defaultNames)
+	assert.True(t, ok)
+	assert.Equal(t, "helper1", name)
+
+	_, _, ok = generatedIdentifier(before, before, extractFunction.defaultNames)
+	assert.False(t, ok)
+}
@@ -0,0 +1,347 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// FallbackNotice is prepended to every result produced without a language server
+const FallbackNotice = "NOTE: No language server is running. These results come from text heuristics, " +
+	"not semantic analysis, and may be incomplete or contain false matches.\n\n"
+
+// maxFallbackSearchResults bounds the output of text searches
+const maxFallbackSearchResults = 200
+
+// maxStreamedSearchResults bounds a search whose results are streamed
+const maxStreamedSearchResults = 10000
+
+// symbolNameMatches checks a heuristic symbol against a possibly qualified name like "Type.Method"
+func symbolNameMatches(sym heuristics.Symbol, symbolName string) bool {
+	if sym.Name == symbolName {
+		return true
+	}
+	for _, sep := range []string{".", "::"} {
+		if strings.Contains(symbolName, sep) {
+			parts := strings.Split(symbolName, sep)
+			name := parts[len(parts)-1]
+			container := parts[len(parts)-2]
+			return sym.Name == name && (sym.Container == "" || sym.Container == container)
+		}
+	}
+	return false
+}
+
+// findHeuristicSymbols scans the workspace for declarations of symbolName in
+// files accepted by include
+func findHeuristicSymbols(ctx context.Context, workspaceDir, symbolName string, include func(path string) bool) ([]heuristics.Symbol, []string, error) {
+	parts := strings.FieldsFunc(symbolName, func(r rune) bool { return r == '.' || r == ':' })
+	if len(parts) == 0 {
+		return nil, nil, codedErrorf(InvalidArgument, "empty symbol name")
+	}
+	name := parts[len(parts)-1]
+	word := heuristics.WordPattern(name)
+
+	type found struct {
+		symbol heuristics.Symbol
+		path   string
+	}
+	var mu sync.Mutex
+	var matches []found
+	err := heuristics.ScanSourceFiles(ctx, workspaceDir, func(path string) error {
+		if !include(path) {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil || !word.Match(content) {
+			return nil
+		}
+		for _, sym := range heuristics.ExtractSymbols(path, string(content)) {
+			if symbolNameMatches(sym, symbolName) {
+				mu.Lock()
+				matches = append(matches, found{sym, path})
+				mu.Unlock()
+			}
+		}
+		return nil
+	})
+	// Files are scanned concurrently; keep each file's declarations in order
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].path < matches[j].path })
+	symbols := make([]heuristics.Symbol, len(matches))
+	paths := make([]string, len(matches))
+	for i, match := range matches {
+		symbols[i], paths[i] = match.symbol, match.path
+	}
+	return symbols, paths, err
+}
+
+// FallbackReadDefinition finds declarations of a symbol by scanning source files
+func FallbackReadDefinition(ctx context.Context, workspaceDir, symbolName string) (string, error) {
+	definitions, err := heuristicDefinitions(ctx, workspaceDir, symbolName, heuristics.IsSupported)
+	if err != nil {
+		return "", err
+	}
+
+	if len(definitions) == 0 {
+		return FallbackNotice + fmt.Sprintf("%s not found", symbolName), nil
+	}
+
+	return FallbackNotice + strings.Join(definitions, ""), nil
+}
+
+// heuristicDefinitions formats the declarations of a symbol found in files accepted by include
+func heuristicDefinitions(ctx context.Context, workspaceDir, symbolName string, include func(path string) bool) ([]string, error) {
+	symbols, paths, err := findHeuristicSymbols(ctx, workspaceDir, symbolName, include)
+	if err != nil {
+		return nil, err
+	}
+
+	var definitions []string
+	for i, sym := range symbols {
+		content, err := os.ReadFile(paths[i])
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(string(content), "\n")
+		if sym.EndLine >= len(lines) {
+			continue
+		}
+
+		container := ""
+		if sym.Container != "" {
+			container = fmt.Sprintf("Container Name: %s\n", sym.Container)
+		}
+		locationInfo := fmt.Sprintf(
+			"Symbol: %s\n"+
+				"File: %s\n"+
+				"Kind: %s\n"+
+				container+
+				"Range: L%d:C%d - L%d:C%d\n\n",
+			sym.Name,
+			paths[i],
+			protocol.TableKindMap[sym.Kind],
+			sym.Line+1,
+			1,
+			sym.EndLine+1,
+			len(lines[sym.EndLine])+1,
+		)
+		definition := addLineNumbers(strings.Join(lines[sym.Line:sym.EndLine+1], "\n"), sym.Line+1)
+		definitions = append(definitions, "---\n\n"+locationInfo+definition+"\n")
+	}
+	return definitions, nil
+}
+
+// FallbackFindReferences finds whole-word occurrences of a symbol name in the workspace
+func FallbackFindReferences(ctx context.Context, workspaceDir, symbolName string) (string, error) {
+	parts := strings.FieldsFunc(symbolName, func(r rune) bool { return r == '.' || r == ':' })
+	if len(parts) == 0 {
+		return "", codedErrorf(InvalidArgument, "empty symbol name")
+	}
+	name := parts[len(parts)-1]
+
+	matches, err := heuristics.Search(ctx, workspaceDir, heuristics.WordPattern(name), maxFallbackSearchResults)
+	if err != nil {
+		return "", err
+	}
+	generatedFiles := make(map[string]bool)
+	if excludeGenerated() {
+		kept := matches[:0]
+		for _, m := range matches {
+			if isGeneratedFile(m.Path) {
+				generatedFiles[m.Path] = true
+				continue
+			}
+			kept = append(kept, m)
+		}
+		matches = kept
+	}
+	if len(matches) == 0 {
+		return FallbackNotice + fmt.Sprintf("No references found for symbol: %s", symbolName) + generatedNote(len(generatedFiles)), nil
+	}
+
+	return FallbackNotice + formatMatchesByFile(matches, "References in File") + generatedNote(len(generatedFiles)), nil
+}
+
+// SearchWorkspace finds lines in the workspace matching a literal string or
+// regular expression. Files are searched concurrently. With a stream, each
+// file's matches are sent on as they are found rather than collected and
+// sorted, so more matches are allowed.
+func SearchWorkspace(ctx context.Context, workspaceDir, pattern string, isRegex bool, stream ResultStream) (string, error) {
+	if pattern == "" {
+		return "", codedErrorf(InvalidArgument, "pattern must not be empty")
+	}
+	expr := pattern
+	if !isRegex {
+		expr = regexp.QuoteMeta(pattern)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return "", fmt.Errorf("invalid regular expression: %w", err)
+	}
+
+	maxResults := maxFallbackSearchResults
+	if stream != nil {
+		maxResults = maxStreamedSearchResults
+	}
+	out := newResultWriter(stream)
+	// Without a stream, matches are collected so they can be sorted by path
+	var matches []heuristics.Match
+	count := 0
+	err = heuristics.SearchFiles(ctx, workspaceDir, re, func(fileMatches []heuristics.Match) error {
+		fileMatches = fileMatches[:min(len(fileMatches), maxResults-count)]
+		count += len(fileMatches)
+		if stream != nil {
+			writeMatchesByFile(out, fileMatches, "Matches in File")
+		} else {
+			matches = append(matches, fileMatches...)
+		}
+		if count >= maxResults {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return fmt.Sprintf("No matches found for: %s", pattern), nil
+	}
+	writeMatchesByFile(out, matches, "Matches in File")
+
+	if count >= maxResults {
+		out.printf("\nResults truncated to %d matches.\n", maxResults)
+	}
+	return out.String(), nil
+}
+
+// formatMatchesByFile groups matches by file in the same layout as the references tool
+func formatMatchesByFile(matches []heuristics.Match, header string) string {
+	out := newResultWriter(nil)
+	writeMatchesByFile(out, matches, header)
+	return out.String()
+}
+
+// writeMatchesByFile adds the matches in each file to a result as a section
+// of its own
+func writeMatchesByFile(out *resultWriter, matches []heuristics.Match, header string) {
+	byFile := make(map[string][]heuristics.Match)
+	for _, m := range matches {
+		byFile[m.Path] = append(byFile[m.Path], m)
+	}
+	paths := make([]string, 0, len(byFile))
+	for path := range byFile {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		fileMatches := byFile[path]
+		var section strings.Builder
+		if omitted := fileMatches[0].Omitted; omitted != "" {
+			// Binary and minified files are reported without their lines
+			m := fileMatches[0]
+			fmt.Fprintf(&section, "---\n\n%s\nFirst match at: L%d:C%d\n%s\n\n", path, m.Line+1, m.Column+1, heuristics.OmittedMarker(omitted))
+			out.write(section.String())
+			continue
+		}
+		fmt.Fprintf(&section, "---\n\n%s\n%s: %d\n", path, header, len(fileMatches))
+
+		locStrings := make([]string, 0, len(fileMatches))
+		for _, m := range fileMatches {
+			locStrings = append(locStrings, fmt.Sprintf("L%d:C%d", m.Line+1, m.Column+1))
+		}
+		section.WriteString("At: " + strings.Join(locStrings, ", ") + "\n\n")
+
+		for _, m := range fileMatches {
+			section.WriteString(addLineNumbers(m.Text, m.Line+1))
+		}
+		section.WriteString("\n")
+		out.write(section.String())
+	}
+}
+
+// GetOutline lists the declarations in a file found by text heuristics
+func GetOutline(filePath string) (string, error) {
+	outline, err := heuristicOutline(filePath)
+	if err != nil {
+		return "", err
+	}
+	return FallbackNotice + outline, nil
+}
+
+// heuristicOutline formats the symbols found by text heuristics, nesting each
+// symbol under the declarations that enclose it
+func heuristicOutline(filePath string) (string, error) {
+	content, err := lsp.ReadSourceFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if !heuristics.IsSupported(filePath) {
+		return "", codedErrorf(UnsupportedCapability, "outline is not supported for %s files", filepath.Base(filePath))
+	}
+
+	symbols := heuristics.ExtractSymbols(filePath, string(content))
+	if len(symbols) == 0 {
+		return fmt.Sprintf("No symbols found in %s", filePath), nil
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "%s\nSymbols in File: %d\n\n", filePath, len(symbols))
+	var enclosing []heuristics.Symbol
+	for _, sym := range symbols {
+		for len(enclosing) > 0 {
+			top := enclosing[len(enclosing)-1]
+			if top.EndLine >= sym.Line && top.Line != sym.Line {
+				break
+			}
+			enclosing = enclosing[:len(enclosing)-1]
+		}
+		indent := strings.Repeat("  ", len(enclosing))
+		fmt.Fprintf(&result, "%s%s %s (L%d-L%d)\n", indent, protocol.TableKindMap[sym.Kind], sym.Name, sym.Line+1, sym.EndLine+1)
+		if sym.EndLine > sym.Line {
+			enclosing = append(enclosing, sym)
+		}
+	}
+	return result.String(), nil
+}
+
+// FallbackGetContentInfo returns the declaration enclosing a position using text heuristics
+func FallbackGetContentInfo(filePath string, line, column int) (string, error) {
+	content, err := utilities.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	symbols := heuristics.ExtractSymbols(filePath, string(content))
+	sym, ok := heuristics.FindEnclosingSymbol(symbols, line-1)
+	if !ok || sym.EndLine >= len(lines) {
+		return "", codedErrorf(SymbolNotFound, "symbol not found")
+	}
+
+	locationInfo := fmt.Sprintf(
+		"Symbol: %s\n"+
+			"File: %s\n"+
+			"Range: L%d:C%d - L%d:C%d\n\n",
+		sym.Name,
+		filePath,
+		sym.Line+1,
+		1,
+		sym.EndLine+1,
+		len(lines[sym.EndLine])+1,
+	)
+
+	definition := addLineNumbers(strings.Join(lines[sym.Line:sym.EndLine+1], "\n"), sym.Line+1)
+	return FallbackNotice + locationInfo + definition, nil
+}
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolsUnderFaults(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() {\n\trun()\n}\n"), 0o644))
+
+	server := lsptest.NewServer(protocol.ServerCapabilities{})
+	server.Respond("textDocument/hover", protocol.Hover{
+		Contents: protocol.Or_Hover_contents{Value: protocol.MarkupContent{Kind: protocol.Markdown, Value: "func run()"}},
+	})
+	client, proxy := lsptest.StartProxied(t, server, dir)
+	client.SetRequestTimeout(time.Second)
+	ctx := context.Background()
+	contentModified := lsptest.Fault{
+		Kind:   lsptest.ErrorResponse,
+		Method: "textDocument/hover",
+		Error:  &lsp.ResponseError{Code: int(protocol.ContentModified), Message: "content modified"},
+	}
+
+	t.Run("retries while the content changes", func(t *testing.T) {
+		fault := contentModified
+		fault.Times = 2
+		proxy.Inject(fault)
+		result, err := GetHoverInfo(ctx, client, path, 4, 2)
+		require.NoError(t, err)
+		assert.Contains(t, result, "func run()")
+		assert.Len(t, server.Received("textDocument/hover"), 3)
+	})
+
+	t.Run("gives up after three attempts", func(t *testing.T) {
+		fault := contentModified
+		fault.Times = 3
+		proxy.Inject(fault)
+		_, err := GetHoverInfo(ctx, client, path, 4, 2)
+		assert.Equal(t, ServerNotReady, ErrorCodeOf(err))
+	})
+
+	t.Run("dropped response times out", func(t *testing.T) {
+		proxy.Inject(lsptest.Fault{Kind: lsptest.DropResponse, Method: "textDocument/hover", Times: 1})
+		_, err := GetHoverInfo(ctx, client, path, 4, 2)
+		assert.Equal(t, Timeout, ErrorCodeOf(err))
+	})
+
+	t.Run("crash fails fast and restarts", func(t *testing.T) {
+		proxy.Inject(lsptest.Fault{Kind: lsptest.Crash, Method: "textDocument/hover", Times: 1})
+		start := time.Now()
+		_, err := GetHoverInfo(ctx, client, path, 4, 2)
+		assert.Equal(t, ServerNotReady, ErrorCodeOf(err))
+		assert.Less(t, time.Since(start), time.Second)
+
+		_, err = client.Restart(ctx)
+		require.NoError(t, err)
+		result, err := GetHoverInfo(ctx, client, path, 4, 2)
+		require.NoError(t, err)
+		assert.Contains(t, result, "func run()")
+	})
+}
@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// generationTitleWords pick out code generation among quick fixes and
+// rewrites, which servers also use for it
+var generationTitleWords = []string{"implement", "generate", "add missing", "fill", "stub", "declare", "constructor", "getter", "setter", "override"}
+
+// GenerateCode lists the code the language server can generate at a
+// position, such as interface implementations, missing struct fields,
+// constructors or accessors. If kind is given, the matching action is
+// applied and its diff returned; kind may be a code action kind like
+// "source.generate" or words from the action's title. When several actions
+// match, the user is asked which one to apply. Line and column are
+// 1-indexed.
+func GenerateCode(ctx context.Context, client *lsp.Client, filePath string, line, column int, kind string) (string, error) {
+	rng, err := lineColumnRange(line, column, line, column)
+	if err != nil {
+		return "", err
+	}
+	// The range of a position is empty
+	rng.End = rng.Start
+
+	actions, err := codeActionsAt(ctx, client, filePath, rng, []protocol.CodeActionKind{protocol.Source, protocol.QuickFix, protocol.RefactorRewrite})
+	if err != nil {
+		return "", err
+	}
+	actions = generationActions(actions)
+	if len(actions) == 0 {
+		return fmt.Sprintf("The language server can't generate code at %s L%d:C%d", filePath, line, column), nil
+	}
+
+	if kind == "" {
+		return fmt.Sprintf("Code that can be generated at %s L%d:C%d:\n%s", filePath, line, column, formatCodeActions(client, actions)), nil
+	}
+
+	matches := matchCodeActions(actions, kind)
+	if len(matches) == 0 {
+		return fmt.Sprintf("No code generation matching %q at %s L%d:C%d. Available:\n%s", kind, filePath, line, column, formatCodeActions(client, actions)), nil
+	}
+	i, err := chooseOption(ctx, fmt.Sprintf("Several code generations match %q at %s L%d:C%d. Which one should be applied?", kind, filePath, line, column),
+		codeActionTitles(client, matches), "Call generate_code again with more of the title of the one you mean as kind.")
+	if err != nil {
+		return "", err
+	}
+	action := matches[i]
+	changes, err := applyCodeAction(ctx, client, action)
+	if err != nil {
+		return "", err
+	}
+	diff := changes.diff()
+	if diff == "" {
+		return fmt.Sprintf("Applied: %s\nNo changes were made\n", action.Title), nil
+	}
+	return fmt.Sprintf("Applied: %s\n\n%s", action.Title, diff), nil
+}
+
+// generationActions keeps source actions other than import organizing and
+// fix-all, and quick fixes or rewrites whose titles describe generating code
+func generationActions(actions []protocol.CodeAction) []protocol.CodeAction {
+	var generation []protocol.CodeAction
+	for _, action := range actions {
+		if codeActionKindIn(action.Kind, []protocol.CodeActionKind{protocol.SourceOrganizeImports, protocol.SourceFixAll}) {
+			continue
+		}
+		if codeActionKindIn(action.Kind, []protocol.CodeActionKind{protocol.Source}) {
+			generation = append(generation, action)
+			continue
+		}
+		title := strings.ToLower(action.Title)
+		for _, word := range generationTitleWords {
+			if strings.Contains(title, word) {
+				generation = append(generation, action)
+				break
+			}
+		}
+	}
+	return generation
+}
+
+// matchCodeActions returns the available actions of a kind, or failing
+// that, those whose titles contain the query
+func matchCodeActions(actions []protocol.CodeAction, query string) []protocol.CodeAction {
+	var matches []protocol.CodeAction
+	for _, action := range actions {
+		if action.Disabled == nil && codeActionKindIn(action.Kind, []protocol.CodeActionKind{protocol.CodeActionKind(query)}) {
+			matches = append(matches, action)
+		}
+	}
+	if len(matches) > 0 {
+		return matches
+	}
+	query = strings.ToLower(query)
+	for _, action := range actions {
+		if action.Disabled == nil && strings.Contains(strings.ToLower(action.Title), query) {
+			matches = append(matches, action)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerationActions(t *testing.T) {
+	actions := generationActions([]protocol.CodeAction{
+		{Title: "Organize Imports", Kind: "source.organizeImports"},
+		{Title: "Generate getters and setters", Kind: "source.generate.accessors"},
+		{Title: "Implement interface 'Reader'", Kind: "quickfix"},
+		{Title: "Remove unused variable", Kind: "quickfix"},
+		{Title: "Fill Config", Kind: "refactor.rewrite.fillStruct"},
+	})
+
+	var titles []string
+	for _, action := range actions {
+		titles = append(titles, action.Title)
+	}
+	assert.Equal(t, []string{"Generate getters and setters", "Implement interface 'Reader'", "Fill Config"}, titles)
+}
+
+func TestMatchCodeActions(t *testing.T) {
+	actions := []protocol.CodeAction{
+		{Title: "Generate constructor", Kind: "source.generate.constructor", Disabled: &protocol.CodeActionDisabled{Reason: "no fields"}},
+		{Title: "Generate toString()", Kind: "source.generate.toString"},
+		{Title: "Generate hashCode()", Kind: "source.generate.hashCode"},
+		{Title: "Implement interface 'Reader'", Kind: "quickfix"},
+	}
+	titles := func(actions []protocol.CodeAction) []string {
+		var titles []string
+		for _, action := range actions {
+			titles = append(titles, action.Title)
+		}
+		return titles
+	}
+
+	// Every available action of the kind matches, to choose between
+	assert.Equal(t, []string{"Generate toString()", "Generate hashCode()"}, titles(matchCodeActions(actions, "source.generate")))
+	assert.Equal(t, []string{"Implement interface 'Reader'"}, titles(matchCodeActions(actions, "implement interface")))
+	assert.Empty(t, matchCodeActions(actions, "constructor"))
+}
@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// generatedHeaderBytes is how much of the start of a file is searched for a
+// generated code marker
+const generatedHeaderBytes = 4096
+
+// generatedNameSuffixes end the names of files that code generators write
+var generatedNameSuffixes = []string{
+	".pb.go", ".pb.gw.go", "_pb2.py", "_pb2_grpc.py", "_pb2.pyi", ".pb.h", ".pb.cc",
+	"_pb.js", "_pb.d.ts", "_grpc_pb.js", ".g.dart", ".freezed.dart", ".g.cs", ".designer.cs",
+}
+
+// generatedMarkers start the comments that generators put at the top of
+// files, such as Go's "Code generated by X. DO NOT EDIT."
+var generatedMarkers = []string{
+	"Code generated",
+	"Generated by",
+	"@generated",
+	"<auto-generated",
+	"Autogenerated",
+	"This file was automatically generated",
+	"DO NOT EDIT",
+}
+
+type generatedCacheEntry struct {
+	modTime   time.Time
+	generated bool
+}
+
+// generatedCode holds how generated files are recognized and whether they
+// are included in results
+var generatedCode = struct {
+	sync.RWMutex
+	workspaceDir string
+	globs        []string
+	include      bool
+	cache        map[string]generatedCacheEntry
+}{cache: make(map[string]generatedCacheEntry)}
+
+// SetGeneratedCode configures generated code detection. globs name more
+// generated files, matched against absolute paths and paths relative to the
+// workspace. If include is set, generated files are treated like any other.
+func SetGeneratedCode(workspaceDir string, globs []string, include bool) error {
+	for _, glob := range globs {
+		if !doublestar.ValidatePattern(glob) {
+			return fmt.Errorf("invalid glob %q", glob)
+		}
+	}
+	generatedCode.Lock()
+	defer generatedCode.Unlock()
+	generatedCode.workspaceDir = workspaceDir
+	generatedCode.globs = globs
+	generatedCode.include = include
+	generatedCode.cache = make(map[string]generatedCacheEntry)
+	return nil
+}
+
+// excludeGenerated reports whether results in generated files are left out
+// or put last
+func excludeGenerated() bool {
+	generatedCode.RLock()
+	defer generatedCode.RUnlock()
+	return !generatedCode.include
+}
+
+// generatedNote tells how many files of generated code were left out of a
+// result
+func generatedNote(files int) string {
+	if files == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n%d generated files were left out. Start the server with --include-generated to include them.\n", files)
+}
+
+// isGeneratedFile reports whether a file was written by a code generator,
+// going by its name, the configured globs and the marker comments at its
+// top. The result is cached until the file changes.
+func isGeneratedFile(path string) bool {
+	base := filepath.Base(path)
+	for _, suffix := range generatedNameSuffixes {
+		if strings.HasSuffix(base, suffix) {
+			return true
+		}
+	}
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	if strings.HasSuffix(stem, "_generated") || strings.HasSuffix(stem, ".generated") {
+		return true
+	}
+
+	generatedCode.RLock()
+	workspaceDir, globs := generatedCode.workspaceDir, generatedCode.globs
+	cached, ok := generatedCode.cache[path]
+	generatedCode.RUnlock()
+
+	rel, err := filepath.Rel(workspaceDir, path)
+	if err != nil || workspaceDir == "" {
+		rel = path
+	}
+	for _, glob := range globs {
+		if match, _ := doublestar.PathMatch(glob, path); match {
+			return true
+		}
+		if match, _ := doublestar.PathMatch(glob, rel); match {
+			return true
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if ok && cached.modTime.Equal(info.ModTime()) {
+		return cached.generated
+	}
+	generated := hasGeneratedMarker(path)
+	generatedCode.Lock()
+	generatedCode.cache[path] = generatedCacheEntry{modTime: info.ModTime(), generated: generated}
+	generatedCode.Unlock()
+	return generated
+}
+
+// hasGeneratedMarker looks for a comment starting with a generated code
+// marker at the top of a file. Markers elsewhere in a comment, as in this
+// one, don't count.
+func hasGeneratedMarker(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = file.Close() }()
+	header := make([]byte, generatedHeaderBytes)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return false
+	}
+	for _, line := range strings.Split(string(header[:n]), "\n") {
+		line = strings.TrimSpace(line)
+		comment := strings.TrimLeft(strings.TrimPrefix(line, "<!--"), "/*#-;% ")
+		if comment == line {
+			// Not a comment
+			continue
+		}
+		for _, marker := range generatedMarkers {
+			if strings.HasPrefix(comment, marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
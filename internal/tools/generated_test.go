@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsGeneratedFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, SetGeneratedCode(dir, []string{"gen/**", "**/*.mock.ts"}, false))
+	defer func() { _ = SetGeneratedCode("", nil, false) }()
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"api/service.pb.go", "package api\n", true},
+		{"api/models_generated.ts", "export {}\n", true},
+		{"gen/client.go", "package gen\n", true},
+		{"web/api.mock.ts", "export {}\n", true},
+		{"store/enum_string.go", "// Code generated by \"stringer -type=Kind\"; DO NOT EDIT.\n\npackage store\n", true},
+		{"schema.py", "# -*- coding: utf-8 -*-\n# Generated by the protocol buffer compiler.  DO NOT EDIT!\n", true},
+		{"Form.cs", "//------\n// <auto-generated>\n//------\n", true},
+		{"lib.js", "/**\n * @generated\n */\n", true},
+		{"store/store.go", "package store\n\n// The mocks are Code generated by mockgen.\nfunc New() {}\n", false},
+		{"notes.go", "package notes\n\nconst warning = \"DO NOT EDIT\"\n", false},
+	}
+	for _, tt := range tests {
+		path := write(tt.name, tt.content)
+		assert.Equal(t, tt.want, isGeneratedFile(path), tt.name)
+	}
+
+	// The header check follows changes to the file
+	path := write("store/store.go", "// Code generated by hand. DO NOT EDIT.\npackage store\n")
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(path, later, later))
+	assert.True(t, isGeneratedFile(path))
+}
+
+func TestSetGeneratedCode(t *testing.T) {
+	defer func() { _ = SetGeneratedCode("", nil, false) }()
+	assert.Error(t, SetGeneratedCode("/ws", []string{"gen/[a"}, false))
+	require.NoError(t, SetGeneratedCode("/ws", nil, true))
+	assert.False(t, excludeGenerated())
+	require.NoError(t, SetGeneratedCode("/ws", nil, false))
+	assert.True(t, excludeGenerated())
+}
+
+func TestGeneratedNote(t *testing.T) {
+	assert.Equal(t, "", generatedNote(0))
+	assert.Contains(t, generatedNote(3), "3 generated files were left out")
+}
@@ -14,14 +14,14 @@ import (
 func GetCodeLens(ctx context.Context, client *lsp.Client, filePath string) (string, error) {
 	err := client.OpenFile(ctx, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", fmt.Errorf("could not open file: %w", err)
 	}
 	// TODO: find a more appropriate way to wait
 	time.Sleep(time.Second)
 
 	// Create document identifier
 	docIdentifier := protocol.TextDocumentIdentifier{
-		URI: protocol.DocumentUri("file://" + filePath),
+		URI: protocol.URIFromPath(filePath),
 	}
 
 	// Request code lens from LSP
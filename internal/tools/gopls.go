@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// ListKnownPackages lists the packages a Go file could import, with gopls's
+// gopls.list_known_packages command, keeping those whose import path
+// contains query when it is given
+func ListKnownPackages(ctx context.Context, client *lsp.Client, filePath, query string) (string, error) {
+	if err := client.OpenFile(ctx, filePath); err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+	// gopls's command arguments are objects whose fields aren't renamed in JSON
+	command, err := serverCommand("gopls.list_known_packages", struct{ URI protocol.DocumentUri }{protocol.URIFromPath(filePath)})
+	if err != nil {
+		return "", err
+	}
+	params := protocol.ExecuteCommandParams{Command: command.Command, Arguments: command.Arguments}
+	var result struct{ Packages []string }
+	if err := client.Call(ctx, "workspace/executeCommand", params, &result); err != nil {
+		return "", fmt.Errorf("failed to list known packages: %w", err)
+	}
+
+	var packages []string
+	for _, pkg := range result.Packages {
+		if strings.Contains(strings.ToLower(pkg), strings.ToLower(query)) {
+			packages = append(packages, pkg)
+		}
+	}
+	if len(packages) == 0 {
+		if query != "" {
+			return fmt.Sprintf("No packages matching %q can be imported by %s", query, filePath), nil
+		}
+		return fmt.Sprintf("No packages can be imported by %s", filePath), nil
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "Packages that %s can import (%d):\n", filePath, len(packages))
+	for _, pkg := range packages {
+		out.WriteString(pkg + "\n")
+	}
+	return out.String(), nil
+}
+
+// AddImport adds an import to a Go file with gopls's gopls.add_import
+// command, which sends the edit back for the client to apply, and returns
+// the diff
+func AddImport(ctx context.Context, client *lsp.Client, filePath, importPath string) (string, error) {
+	if importPath == "" {
+		return "", codedErrorf(InvalidArgument, "importPath must not be empty")
+	}
+	if err := client.OpenFile(ctx, filePath); err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+	command, err := serverCommand("gopls.add_import", struct {
+		ImportPath string
+		URI        protocol.DocumentUri
+	}{importPath, protocol.URIFromPath(filePath)})
+	if err != nil {
+		return "", err
+	}
+	changes, err := applyCodeAction(ctx, client, protocol.CodeAction{Title: command.Title, Command: &command})
+	if err != nil {
+		return "", err
+	}
+	diff := changes.diff()
+	if diff == "" {
+		return fmt.Sprintf("%s already imports %s\n", filePath, importPath), nil
+	}
+	return fmt.Sprintf("Imported %s in %s\n\n%s", importPath, filePath, diff), nil
+}
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoplsCommands(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"go.mod":  "module example.com/app\n\ngo 1.24\n",
+		"main.go": "package main\n\nfunc main() {\n\tfmt.Println(strings.ToUpper(\"hi\"))\n}\n",
+	})
+	path := filepath.Join(dir, "main.go")
+	uri := protocol.URIFromPath(path)
+	ctx := context.Background()
+
+	var server *lsptest.Server
+	server = lsptest.NewServer(protocol.ServerCapabilities{})
+	server.Handle("workspace/executeCommand", func(raw json.RawMessage) (any, error) {
+		var params struct {
+			Command   string
+			Arguments []struct {
+				URI        protocol.DocumentUri
+				ImportPath string
+			}
+		}
+		require.NoError(t, json.Unmarshal(raw, &params))
+		require.Len(t, params.Arguments, 1)
+		assert.Equal(t, uri, params.Arguments[0].URI)
+		switch params.Command {
+		case "gopls.list_known_packages":
+			return map[string]any{"Packages": []string{"example.com/app/internal/text", "fmt", "strings", "text/template"}}, nil
+		case "gopls.add_import":
+			if params.Arguments[0].ImportPath != "fmt" {
+				return nil, nil
+			}
+			edit := protocol.ApplyWorkspaceEditParams{Edit: protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+				uri: {{Range: protocol.Range{Start: protocol.Position{Line: 1}, End: protocol.Position{Line: 1}}, NewText: "\nimport \"fmt\"\n"}},
+			}}}
+			var applied protocol.ApplyWorkspaceEditResult
+			return nil, server.Request(context.Background(), "workspace/applyEdit", edit, &applied)
+		}
+		return nil, nil
+	})
+	client := lsptest.Start(t, server, dir)
+
+	t.Run("list known packages", func(t *testing.T) {
+		result, err := ListKnownPackages(ctx, client, path, "")
+		require.NoError(t, err)
+		assert.Equal(t, "Packages that "+path+" can import (4):\nexample.com/app/internal/text\nfmt\nstrings\ntext/template\n", result)
+	})
+
+	t.Run("filter by query", func(t *testing.T) {
+		result, err := ListKnownPackages(ctx, client, path, "Text")
+		require.NoError(t, err)
+		assert.Equal(t, "Packages that "+path+" can import (2):\nexample.com/app/internal/text\ntext/template\n", result)
+
+		result, err = ListKnownPackages(ctx, client, path, "yaml")
+		require.NoError(t, err)
+		assert.Equal(t, `No packages matching "yaml" can be imported by `+path, result)
+	})
+
+	t.Run("add import", func(t *testing.T) {
+		result, err := AddImport(ctx, client, path, "fmt")
+		require.NoError(t, err)
+		assert.Contains(t, result, "Imported fmt in "+path)
+		assert.Contains(t, result, "+import \"fmt\"")
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(strings.ToUpper(\"hi\"))\n}\n", string(content))
+	})
+
+	t.Run("already imported", func(t *testing.T) {
+		result, err := AddImport(ctx, client, path, "strings")
+		require.NoError(t, err)
+		assert.Equal(t, path+" already imports strings\n", result)
+	})
+
+	t.Run("empty import path", func(t *testing.T) {
+		_, err := AddImport(ctx, client, path, "")
+		assert.Equal(t, InvalidArgument, ErrorCodeOf(err))
+	})
+}
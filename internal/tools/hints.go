@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// maxHintNames limits the names listed in a single hint
+const maxHintNames = 5
+
+// identifierPattern matches identifiers in most languages
+var identifierPattern = regexp.MustCompile(`[\p{L}_$][\p{L}\p{N}_$]*`)
+
+// emptyResultHints formats hints for a result that came back empty, so that
+// an empty answer isn't mistaken for proof that a symbol doesn't exist. Work
+// the server reports as in progress is always mentioned. It returns "" when
+// there is nothing to suggest.
+func emptyResultHints(client *lsp.Client, hints ...string) string {
+	if active := client.ActiveProgress(); len(active) > 0 {
+		hints = append([]string{fmt.Sprintf("The language server is still working (%s), so results may be incomplete. Try again once it finishes.",
+			strings.Join(active, "; "))}, hints...)
+	}
+
+	var result strings.Builder
+	for _, hint := range hints {
+		if hint == "" {
+			continue
+		}
+		if result.Len() == 0 {
+			result.WriteString("\n\nHints:")
+		}
+		result.WriteString("\n- " + hint)
+	}
+	return result.String()
+}
+
+// workspaceHint warns that a file is outside the workspace the server loaded
+func workspaceHint(client *lsp.Client, filePath string) string {
+	workspaceDir := client.WorkspaceDir()
+	if workspaceDir == "" || !outsideWorkspace(workspaceDir, filePath) {
+		return ""
+	}
+	return fmt.Sprintf("%s is outside the workspace %s, so the language server may not have loaded it.", filePath, workspaceDir)
+}
+
+// outsideWorkspace reports whether a path is outside the workspace directory
+func outsideWorkspace(workspaceDir, path string) bool {
+	rel, err := filepath.Rel(workspaceDir, path)
+	return err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// similarSymbolsHint lists the names the server's fuzzy workspace/symbol
+// search found for a name without any exact match
+func similarSymbolsHint(symbolName string, results []protocol.WorkspaceSymbolResult) string {
+	var names []string
+	seen := map[string]bool{symbolName: true}
+	for _, symbol := range results {
+		name := symbol.GetName()
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return "The language server found no symbol with a similar name. The declaring file may be outside the workspace " +
+			"or of a type the server doesn't handle; the fallback tools search files by text."
+	}
+	if len(names) > maxHintNames {
+		names = names[:maxHintNames]
+	}
+	return fmt.Sprintf("Similar symbols in the workspace: %s. Symbols are looked up by their exact name, "+
+		"or Type.Method for methods.", strings.Join(names, ", "))
+}
+
+// lineCommentMarkers start lines that are comments in common languages
+var lineCommentMarkers = []string{"//", "/*", "*", "#", "--", ";"}
+
+// lineIdentifier is an identifier on a line, with its 1-indexed column
+type lineIdentifier struct {
+	name   string
+	column int
+}
+
+// lineIdentifiers returns the identifiers on a line nearest a 1-indexed
+// column first. Columns count UTF-16 code units like LSP positions.
+func lineIdentifiers(line string, column int) []lineIdentifier {
+	// Words in comments aren't worth hovering over
+	trimmed := strings.TrimSpace(line)
+	for _, marker := range lineCommentMarkers {
+		if strings.HasPrefix(trimmed, marker) {
+			return nil
+		}
+	}
+	if i := strings.Index(line, " //"); i >= 0 {
+		line = line[:i]
+	}
+
+	var identifiers []lineIdentifier
+	for _, match := range identifierPattern.FindAllStringIndex(line, -1) {
+		identifiers = append(identifiers, lineIdentifier{
+			name:   line[match[0]:match[1]],
+			column: utilities.UTF16Column(line, match[0]) + 1,
+		})
+	}
+
+	distance := func(id lineIdentifier) int {
+		end := id.column + utilities.UTF16Column(id.name, len(id.name)) - 1
+		switch {
+		case column < id.column:
+			return id.column - column
+		case column > end:
+			return column - end
+		}
+		return 0
+	}
+	sort.SliceStable(identifiers, func(i, j int) bool {
+		return distance(identifiers[i]) < distance(identifiers[j])
+	})
+	return identifiers
+}
+
+// nearbyIdentifiersHint suggests positions on a line that hover could
+// answer for
+func nearbyIdentifiersHint(line string, column int) string {
+	identifiers := lineIdentifiers(line, column)
+	if len(identifiers) == 0 {
+		return ""
+	}
+	if len(identifiers) > maxHintNames {
+		identifiers = identifiers[:maxHintNames]
+	}
+	positions := make([]string, len(identifiers))
+	for i, id := range identifiers {
+		positions[i] = fmt.Sprintf("%s (column %d)", id.name, id.column)
+	}
+	return "Identifiers on this line, nearest first: " + strings.Join(positions, ", ") + "."
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineIdentifiers(t *testing.T) {
+	// Nearest first, with columns counted in UTF-16 code units
+	assert.Equal(t, []lineIdentifier{
+		{name: "Println", column: 6},
+		{name: "fmt", column: 2},
+		{name: "𝔁", column: 15},
+		{name: "count", column: 20},
+	}, lineIdentifiers("\tfmt.Println(\"𝔁\", count)", 8))
+	assert.Equal(t, []lineIdentifier{
+		{name: "x", column: 1},
+		{name: "é", column: 7},
+		{name: "y", column: 10},
+	}, lineIdentifiers("x := \"é\"+y", 1))
+
+	// Comments are skipped
+	assert.Empty(t, lineIdentifiers("  // A comment", 1))
+	assert.Empty(t, lineIdentifiers("# A comment", 1))
+	assert.Equal(t, []lineIdentifier{{name: "x", column: 1}}, lineIdentifiers("x++ // A comment", 1))
+}
+
+func TestSimilarSymbolsHint(t *testing.T) {
+	results := []protocol.WorkspaceSymbolResult{
+		&protocol.SymbolInformation{Name: "ReadFile"},
+		&protocol.SymbolInformation{Name: "ReadFiles"},
+		&protocol.SymbolInformation{Name: "ReadFile"},
+	}
+	assert.Contains(t, similarSymbolsHint("Readfile", results), "Similar symbols in the workspace: ReadFile, ReadFiles.")
+	assert.Contains(t, similarSymbolsHint("Readfile", nil), "found no symbol with a similar name")
+}
+
+func TestEmptyResultHints(t *testing.T) {
+	client := &lsp.Client{}
+	assert.Equal(t, "", emptyResultHints(client, "", ""))
+	assert.Equal(t, "\n\nHints:\n- one\n- two", emptyResultHints(client, "one", "", "two"))
+	assert.Equal(t, "", workspaceHint(client, "/anywhere/file.go"))
+}
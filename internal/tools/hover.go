@@ -15,7 +15,7 @@ func GetHoverInfo(ctx context.Context, client *lsp.Client, filePath string, line
 	// Open the file if not already open
 	err := client.OpenFile(ctx, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", fmt.Errorf("could not open file: %w", err)
 	}
 
 	params := protocol.HoverParams{}
@@ -25,25 +25,30 @@ func GetHoverInfo(ctx context.Context, client *lsp.Client, filePath string, line
 		Line:      uint32(line - 1),
 		Character: uint32(column - 1),
 	}
-	uri := protocol.DocumentUri("file://" + filePath)
+	uri := protocol.URIFromPath(filePath)
+
+	// Notebook positions refer to the notebook view, the server knows about cells
+	serverLocation, _ := client.ServerLocation(protocol.Location{
+		URI:   uri,
+		Range: protocol.Range{Start: position, End: position},
+	})
 	params.TextDocument = protocol.TextDocumentIdentifier{
-		URI: uri,
+		URI: serverLocation.URI,
 	}
-	params.Position = position
+	params.Position = serverLocation.Range.Start
 
 	// Execute the hover request
 	// - some LSP (rust) will return "content modified", so retry it
 	var hoverResult protocol.Hover
-	for i := range 3 {
+	for range 3 {
 		hoverResult, err = client.Hover(ctx, params)
-		if err == nil {
+		if !errors.Is(err, lsp.ErrContentModified) {
 			break
-		} else if errors.Is(err, lsp.ErrContentModified) {
-			continue
-		} else if i == 2 {
-			return "", fmt.Errorf("failed to get hover information: %v", err)
 		}
 	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get hover information: %w", err)
+	}
 
 	var result strings.Builder
 
@@ -68,7 +73,13 @@ func GetHoverInfo(ctx context.Context, client *lsp.Client, filePath string, line
 		if err != nil {
 			toolsLogger.Warn("failed to extract line at position: %v", err)
 		}
-		result.WriteString(fmt.Sprintf("No hover information available for this position on the following line:\n%s", lineText))
+		message := fmt.Sprintf("No hover information available for this position on the following line:\n%s", lineText)
+		if hints := emptyResultHints(client,
+			workspaceHint(client, filePath),
+			nearbyIdentifiersHint(strings.TrimRight(lineText, "\r\n"), column)); hints != "" {
+			message = strings.TrimRight(message, "\n") + hints
+		}
+		result.WriteString(message)
 	} else {
 		result.WriteString(contentText)
 	}
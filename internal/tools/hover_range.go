@@ -0,0 +1,236 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// maxRangeProbes limits the names hovered over when the server can't hover
+// over a range
+const maxRangeProbes = 5
+
+// rangeHoverParams are hover params with a range instead of a position, as
+// servers with the experimental hoverRange capability accept them
+type rangeHoverParams struct {
+	TextDocument protocol.TextDocumentIdentifier `json:"textDocument"`
+	Position     protocol.Range                  `json:"position"`
+}
+
+// rangeIdentifier is an identifier in an expression
+type rangeIdentifier struct {
+	name     string
+	position protocol.Position
+	// offset is where the identifier ends in the expression's text
+	offset int
+}
+
+// GetRangeHover returns type information for the expression in a range. A
+// server with the experimental hoverRange capability, such as
+// rust-analyzer, is asked about the range itself. Otherwise the names in
+// the expression are hovered over, the outermost first, since its type is
+// usually the expression's type or, for a call, the function returning it.
+func GetRangeHover(ctx context.Context, client *lsp.Client, filePath string, startLine, startColumn, endLine, endColumn int) (string, error) {
+	rng, err := lineColumnRange(startLine, startColumn, endLine, endColumn)
+	if err != nil {
+		return "", err
+	}
+	if err := client.OpenFile(ctx, filePath); err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+	content, err := lsp.ReadSourceFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	expression, identifiers, err := rangeExpression(strings.Split(string(content), "\n"), rng)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(expression) == "" {
+		return "", codedErrorf(PositionInvalid, "the range L%d:C%d - L%d:C%d is empty", startLine, startColumn, endLine, endColumn)
+	}
+	uri := protocol.URIFromPath(filePath)
+
+	if experimentalCapability(client.ServerCapabilities(), "hoverRange") {
+		serverLocation, _ := client.ServerLocation(protocol.Location{URI: uri, Range: rng})
+		var hover protocol.Hover
+		err := client.Call(ctx, "textDocument/hover", rangeHoverParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: serverLocation.URI},
+			Position:     serverLocation.Range,
+		}, &hover)
+		if err != nil {
+			toolsLogger.Debug("Range hover failed, hovering over names instead: %v", err)
+		} else if text := hover.ToString(); text != "" {
+			return fmt.Sprintf("Type of `%s`:\n\n%s", expression, text), nil
+		}
+	}
+
+	outermost := outermostIdentifier(expression, identifiers)
+	probes := make([]int, 0, len(identifiers))
+	if outermost >= 0 {
+		probes = append(probes, outermost)
+	}
+	for i := len(identifiers) - 1; i >= 0; i-- {
+		if i != outermost {
+			probes = append(probes, i)
+		}
+	}
+	if len(probes) > maxRangeProbes {
+		probes = probes[:maxRangeProbes]
+	}
+
+	for _, i := range probes {
+		id := identifiers[i]
+		text, err := hoverAt(ctx, client, uri, id.position)
+		if err != nil {
+			toolsLogger.Debug("Hover over %s failed: %v", id.name, err)
+			continue
+		}
+		if text == "" {
+			continue
+		}
+		if len(identifiers) == 1 && strings.TrimSpace(expression) == id.name {
+			return fmt.Sprintf("Type of `%s`:\n\n%s", expression, text), nil
+		}
+
+		var result strings.Builder
+		which := "a name in"
+		if i == outermost {
+			which = "the outermost name in"
+		}
+		fmt.Fprintf(&result, "The language server can't hover over a range, so this is the hover for `%s` (L%d:C%d), %s `%s`.",
+			id.name, id.position.Line+1, id.position.Character+1, which, expression)
+		if strings.HasPrefix(strings.TrimSpace(expression[id.offset:]), "(") {
+			result.WriteString(" It is called, so the expression's type is the result of the function described.")
+		}
+		result.WriteString("\n\n" + text)
+		return result.String(), nil
+	}
+
+	message := fmt.Sprintf("No type information for `%s`: the language server has no hover for the range or the names in it", expression)
+	return message + emptyResultHints(client, workspaceHint(client, filePath)), nil
+}
+
+// rangeExpression returns the text of a range and the identifiers in it.
+// Columns count UTF-16 code units like LSP positions.
+func rangeExpression(lines []string, rng protocol.Range) (string, []rangeIdentifier, error) {
+	if int(rng.End.Line) >= len(lines) {
+		return "", nil, codedErrorf(PositionInvalid, "line %d is beyond the end of the file (%d lines)", rng.End.Line+1, len(lines))
+	}
+
+	var text strings.Builder
+	var identifiers []rangeIdentifier
+	for line := rng.Start.Line; line <= rng.End.Line; line++ {
+		content := strings.TrimRight(lines[line], "\r")
+		start, end := 0, len(content)
+		if line == rng.Start.Line {
+			start = utilities.ByteOffset(content, int(rng.Start.Character))
+		}
+		if line == rng.End.Line {
+			end = utilities.ByteOffset(content, int(rng.End.Character))
+		}
+		if start > end {
+			start = end
+		}
+		if line != rng.Start.Line {
+			text.WriteString("\n")
+		}
+		for _, match := range identifierPattern.FindAllStringIndex(content[start:end], -1) {
+			identifiers = append(identifiers, rangeIdentifier{
+				name:     content[start+match[0] : start+match[1]],
+				position: protocol.Position{Line: line, Character: uint32(utilities.UTF16Column(content, start+match[0]))},
+				offset:   text.Len() + match[1],
+			})
+		}
+		text.WriteString(content[start:end])
+	}
+	return text.String(), identifiers, nil
+}
+
+// outermostIdentifier returns the index of the identifier the expression
+// ends with once trailing calls and index expressions are removed, such as
+// Baz in foo.Bar(x).Baz(), or -1 if there is none
+func outermostIdentifier(expression string, identifiers []rangeIdentifier) int {
+	end := len(expression)
+	for {
+		// A trailing "?" or "!" unwraps the value before it
+		end = len(strings.TrimRight(expression[:end], " \t\n;,?!"))
+		if end == 0 {
+			return -1
+		}
+		closing := expression[end-1]
+		var opening byte
+		switch closing {
+		case ')':
+			opening = '('
+		case ']':
+			opening = '['
+		default:
+			for i, id := range identifiers {
+				if id.offset == end {
+					return i
+				}
+			}
+			return -1
+		}
+
+		depth := 0
+		for end--; end >= 0; end-- {
+			switch expression[end] {
+			case closing:
+				depth++
+			case opening:
+				depth--
+			}
+			if depth == 0 {
+				break
+			}
+		}
+		if end < 0 {
+			return -1
+		}
+	}
+}
+
+// hoverAt returns the hover text at a position, retrying while the server
+// reports the content as modified
+func hoverAt(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, position protocol.Position) (string, error) {
+	serverLocation, _ := client.ServerLocation(protocol.Location{
+		URI:   uri,
+		Range: protocol.Range{Start: position, End: position},
+	})
+	params := protocol.HoverParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: serverLocation.URI},
+			Position:     serverLocation.Range.Start,
+		},
+	}
+	var err error
+	for range 3 {
+		var hover protocol.Hover
+		hover, err = client.Hover(ctx, params)
+		if err == nil {
+			return hover.ToString(), nil
+		}
+		if !errors.Is(err, lsp.ErrContentModified) {
+			break
+		}
+	}
+	return "", err
+}
+
+// experimentalCapability reports whether the server set an experimental
+// capability to true
+func experimentalCapability(capabilities protocol.ServerCapabilities, name string) bool {
+	experimental, ok := capabilities.Experimental.(map[string]any)
+	if !ok {
+		return false
+	}
+	enabled, _ := experimental[name].(bool)
+	return enabled
+}
@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeExpression(t *testing.T) {
+	lines := []string{
+		"\ttotal := cart.Items(ctx).",
+		"\t\tSum(\"é\", price)",
+	}
+	rng := protocol.Range{
+		Start: protocol.Position{Line: 0, Character: 10},
+		End:   protocol.Position{Line: 1, Character: 17},
+	}
+	expression, identifiers, err := rangeExpression(lines, rng)
+	require.NoError(t, err)
+	assert.Equal(t, "cart.Items(ctx).\n\t\tSum(\"é\", price)", expression)
+
+	var names []string
+	for _, id := range identifiers {
+		names = append(names, id.name)
+		assert.Equal(t, id.name, expression[id.offset-len(id.name):id.offset])
+	}
+	assert.Equal(t, []string{"cart", "Items", "ctx", "Sum", "é", "price"}, names)
+	assert.Equal(t, protocol.Position{Line: 1, Character: 2}, identifiers[3].position)
+	assert.Equal(t, protocol.Position{Line: 1, Character: 11}, identifiers[5].position)
+
+	_, _, err = rangeExpression(lines, protocol.Range{End: protocol.Position{Line: 5}})
+	assert.Error(t, err)
+}
+
+func TestOutermostIdentifier(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{"foo.Bar(x).Baz()", "Baz"},
+		{"cart.items[i]", "items"},
+		{"client.send(req).await?", "await"},
+		{"parse(input)?", "parse"},
+		{"a + b", "b"},
+		{"value;", "value"},
+		{"(a + b)", ""},
+		{"\"text\"", ""},
+	}
+	for _, tt := range tests {
+		_, identifiers, err := rangeExpression([]string{tt.expression}, protocol.Range{
+			End: protocol.Position{Character: uint32(len(tt.expression))},
+		})
+		require.NoError(t, err)
+		got := ""
+		if i := outermostIdentifier(tt.expression, identifiers); i >= 0 {
+			got = identifiers[i].name
+		}
+		assert.Equal(t, tt.want, got, tt.expression)
+	}
+}
+
+func TestExperimentalCapability(t *testing.T) {
+	assert.True(t, experimentalCapability(protocol.ServerCapabilities{Experimental: map[string]any{"hoverRange": true}}, "hoverRange"))
+	assert.False(t, experimentalCapability(protocol.ServerCapabilities{Experimental: map[string]any{"hoverActions": true}}, "hoverRange"))
+	assert.False(t, experimentalCapability(protocol.ServerCapabilities{}, "hoverRange"))
+}
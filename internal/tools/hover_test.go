@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHoverInfo(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() {\n\trun()\n}\n"), 0o644))
+
+	server := lsptest.NewServer(protocol.ServerCapabilities{})
+	server.Handle("textDocument/hover", func(params json.RawMessage) (any, error) {
+		var hover protocol.HoverParams
+		if err := json.Unmarshal(params, &hover); err != nil {
+			return nil, err
+		}
+		if hover.Position.Line != 3 {
+			return nil, nil
+		}
+		return protocol.Hover{Contents: protocol.Or_Hover_contents{
+			Value: protocol.MarkupContent{Kind: protocol.Markdown, Value: "func run()"},
+		}}, nil
+	})
+	client := lsptest.Start(t, server, dir)
+	ctx := context.Background()
+
+	result, err := GetHoverInfo(ctx, client, path, 4, 2)
+	require.NoError(t, err)
+	assert.Contains(t, result, "func run()")
+	hovers := server.Received("textDocument/hover")
+	require.Len(t, hovers, 1)
+	var params protocol.HoverParams
+	require.NoError(t, json.Unmarshal(hovers[0].Params, &params))
+	assert.Equal(t, protocol.Position{Line: 3, Character: 1}, params.Position, "positions are sent 0-indexed")
+
+	result, err = GetHoverInfo(ctx, client, path, 3, 6)
+	require.NoError(t, err)
+	assert.Contains(t, result, "No hover information available for this position on the following line:\nfunc main() {")
+}
+
+// TestHoverServerChain checks that requests about a document go to the
+// first server in its language's chain that can answer them
+func TestHoverServerChain(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "main.ts")
+	notes := filepath.Join(dir, "notes.md")
+	require.NoError(t, os.WriteFile(script, []byte("run();\n"), 0o644))
+	require.NoError(t, os.WriteFile(notes, []byte("# Notes\n"), 0o644))
+	ctx := context.Background()
+
+	hover := func(text string) lsptest.Handler {
+		return func(json.RawMessage) (any, error) {
+			return protocol.Hover{Contents: protocol.Or_Hover_contents{
+				Value: protocol.MarkupContent{Kind: protocol.Markdown, Value: text},
+			}}, nil
+		}
+	}
+	provided := &protocol.Or_ServerCapabilities_hoverProvider{Value: true}
+
+	// tsserver serves every language, deno comes first for TypeScript but
+	// leaves definitions to it, and biome only formats
+	tsserver := lsptest.NewServer(protocol.ServerCapabilities{
+		HoverProvider:      provided,
+		DefinitionProvider: &protocol.Or_ServerCapabilities_definitionProvider{Value: true},
+	})
+	tsserver.Handle("textDocument/hover", hover("from tsserver"))
+	tsserver.Respond("textDocument/definition", []protocol.Location{})
+	client := lsptest.Start(t, tsserver, dir)
+
+	deno := lsptest.NewServer(protocol.ServerCapabilities{
+		HoverProvider:      provided,
+		DefinitionProvider: &protocol.Or_ServerCapabilities_definitionProvider{Value: true},
+	})
+	deno.Handle("textDocument/hover", hover("from deno"))
+	require.NoError(t, client.AddDelegate(ctx, lsptest.Start(t, deno, dir), []protocol.LanguageKind{"typescript"}))
+
+	biome := lsptest.NewServer(protocol.ServerCapabilities{
+		DocumentFormattingProvider: &protocol.Or_ServerCapabilities_documentFormattingProvider{Value: true},
+	})
+	biome.Respond("textDocument/formatting", []protocol.TextEdit{})
+	require.NoError(t, client.AddDelegate(ctx, lsptest.Start(t, biome, dir), []protocol.LanguageKind{"typescript"}))
+
+	result, err := GetHoverInfo(ctx, client, script, 1, 1)
+	require.NoError(t, err)
+	assert.Contains(t, result, "from deno")
+	assert.Empty(t, tsserver.Received("textDocument/hover"))
+	assert.Empty(t, biome.Received("textDocument/hover"))
+
+	// Other languages aren't given to deno
+	result, err = GetHoverInfo(ctx, client, notes, 1, 1)
+	require.NoError(t, err)
+	assert.Contains(t, result, "from tsserver")
+	assert.Len(t, deno.Received("textDocument/hover"), 1)
+
+	// deno doesn't know the method after all, so tsserver is asked next
+	document := protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(script)}
+	_, err = client.Definition(ctx, protocol.DefinitionParams{TextDocumentPositionParams: protocol.TextDocumentPositionParams{TextDocument: document}})
+	require.NoError(t, err)
+	assert.Len(t, deno.Received("textDocument/definition"), 1)
+	assert.Len(t, tsserver.Received("textDocument/definition"), 1)
+
+	_, err = client.Formatting(ctx, protocol.DocumentFormattingParams{TextDocument: document})
+	require.NoError(t, err)
+	assert.Len(t, biome.Received("textDocument/formatting"), 1)
+	assert.Empty(t, tsserver.Received("textDocument/formatting"))
+}
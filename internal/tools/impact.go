@@ -0,0 +1,306 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// maxImpactResults bounds each ranked list in an impact report
+const maxImpactResults = 50
+
+// flatSymbol is a document symbol with its nesting flattened away
+type flatSymbol struct {
+	name      string
+	kind      protocol.SymbolKind
+	container string
+	rng       protocol.Range
+	// selection is the start of the symbol's name
+	selection protocol.Position
+}
+
+// affectedFunction accumulates how strongly a function depends on a change
+type affectedFunction struct {
+	name       string
+	kind       protocol.SymbolKind
+	file       string
+	line       int
+	references int
+	calls      int
+}
+
+func (f *affectedFunction) score() int {
+	// Direct callers are weighted twice since they also show up as references
+	return f.references + 2*f.calls
+}
+
+// GetImpact finds the symbols declared in lines startLine to endLine of a
+// file, then ranks the functions and files that reference or call them.
+// Lines are 1-indexed and inclusive.
+func GetImpact(ctx context.Context, client *lsp.Client, filePath string, startLine, endLine int) (string, error) {
+	if lsp.IsNotebook(filePath) {
+		return "", codedErrorf(UnsupportedCapability, "impact analysis is not supported for notebooks")
+	}
+	if startLine < 1 || endLine < startLine {
+		return "", codedErrorf(PositionInvalid, "invalid line range %d-%d", startLine, endLine)
+	}
+
+	symbolCache := make(map[protocol.DocumentUri][]flatSymbol)
+	uri := protocol.URIFromPath(filePath)
+	symbols, err := documentSymbolsFlat(ctx, client, uri, symbolCache)
+	if err != nil {
+		return "", err
+	}
+
+	editRange := protocol.Range{
+		Start: protocol.Position{Line: uint32(startLine - 1)},
+		End:   protocol.Position{Line: uint32(endLine - 1), Character: ^uint32(0)},
+	}
+	touched := innermostOverlapping(symbols, editRange)
+	if len(touched) == 0 {
+		return fmt.Sprintf("No symbols found in %s L%d-L%d", filePath, startLine, endLine), nil
+	}
+
+	functions := make(map[string]*affectedFunction)
+	files := make(map[string]int)
+	record := func(loc protocol.Location, isCall bool) {
+		path := loc.URI.Path()
+		files[path]++
+		enclosing, ok := enclosingFunction(ctx, client, loc, symbolCache)
+		if !ok {
+			return
+		}
+		key := fmt.Sprintf("%s:%d:%d", loc.URI, enclosing.selection.Line, enclosing.selection.Character)
+		fn, ok := functions[key]
+		if !ok {
+			name := enclosing.name
+			if enclosing.container != "" {
+				name = enclosing.container + "." + name
+			}
+			fn = &affectedFunction{name: name, kind: enclosing.kind, file: path, line: int(enclosing.selection.Line) + 1}
+			functions[key] = fn
+		}
+		if isCall {
+			fn.calls++
+		} else {
+			fn.references++
+		}
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Symbols touched by %s L%d-L%d:\n", filePath, startLine, endLine)
+	totalRefs := 0
+	for _, sym := range touched {
+		name := sym.name
+		if sym.container != "" {
+			name = sym.container + "." + name
+		}
+		fmt.Fprintf(&result, "  %s %s (L%d-L%d)\n", protocol.TableKindMap[sym.kind], name, sym.rng.Start.Line+1, sym.rng.End.Line+1)
+
+		position := protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     sym.selection,
+		}
+		refs, err := client.References(ctx, protocol.ReferenceParams{
+			TextDocumentPositionParams: position,
+			Context:                    protocol.ReferenceContext{IncludeDeclaration: false},
+		})
+		if err != nil {
+			toolsLogger.Warn("Error getting references to %s: %v", sym.name, err)
+		}
+		for _, ref := range refs {
+			// Uses inside the changed symbol are part of the change itself
+			if ref.URI == uri && containsPosition(sym.rng, ref.Range.Start) {
+				continue
+			}
+			totalRefs++
+			record(ref, false)
+		}
+
+		items, err := client.PrepareCallHierarchy(ctx, protocol.CallHierarchyPrepareParams{TextDocumentPositionParams: position})
+		if err != nil {
+			toolsLogger.Debug("Error preparing call hierarchy for %s: %v", sym.name, err)
+			continue
+		}
+		for _, item := range items {
+			calls, err := client.IncomingCalls(ctx, protocol.CallHierarchyIncomingCallsParams{Item: item})
+			if err != nil {
+				toolsLogger.Debug("Error getting callers of %s: %v", item.Name, err)
+				continue
+			}
+			for _, call := range calls {
+				for _, fromRange := range call.FromRanges {
+					if call.From.URI == uri && containsPosition(sym.rng, fromRange.Start) {
+						continue
+					}
+					record(protocol.Location{URI: call.From.URI, Range: fromRange}, true)
+				}
+			}
+		}
+	}
+	fmt.Fprintf(&result, "References: %d\n\n", totalRefs)
+
+	if len(functions) == 0 && len(files) == 0 {
+		result.WriteString("No references or callers found outside the changed lines\n")
+		return result.String(), nil
+	}
+
+	ranked := make([]*affectedFunction, 0, len(functions))
+	for _, fn := range functions {
+		ranked = append(ranked, fn)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score() != ranked[j].score() {
+			return ranked[i].score() > ranked[j].score()
+		}
+		if ranked[i].file != ranked[j].file {
+			return ranked[i].file < ranked[j].file
+		}
+		return ranked[i].line < ranked[j].line
+	})
+	fmt.Fprintf(&result, "Affected functions (most affected first): %d\n", len(ranked))
+	for i, fn := range ranked {
+		if i == maxImpactResults {
+			fmt.Fprintf(&result, "  ... %d more\n", len(ranked)-maxImpactResults)
+			break
+		}
+		fmt.Fprintf(&result, "  %d. %s %s - %s:%d (%d references, %d call sites)\n",
+			i+1, protocol.TableKindMap[fn.kind], fn.name, fn.file, fn.line, fn.references, fn.calls)
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if files[paths[i]] != files[paths[j]] {
+			return files[paths[i]] > files[paths[j]]
+		}
+		return paths[i] < paths[j]
+	})
+	fmt.Fprintf(&result, "\nAffected files (most affected first): %d\n", len(paths))
+	for i, path := range paths {
+		if i == maxImpactResults {
+			fmt.Fprintf(&result, "  ... %d more\n", len(paths)-maxImpactResults)
+			break
+		}
+		fmt.Fprintf(&result, "  %d. %s (%d)\n", i+1, path, files[path])
+	}
+
+	return result.String(), nil
+}
+
+// documentSymbolsFlat lists every symbol in a document, parents before
+// children, caching the result for the duration of one tool call
+func documentSymbolsFlat(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, cache map[protocol.DocumentUri][]flatSymbol) ([]flatSymbol, error) {
+	if symbols, ok := cache[uri]; ok {
+		return symbols, nil
+	}
+
+	if err := client.OpenFile(ctx, uri.Path()); err != nil {
+		return nil, fmt.Errorf("could not open file: %w", err)
+	}
+	symResult, err := client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document symbols: %w", err)
+	}
+	results, err := symResult.Results()
+	if err != nil {
+		return nil, fmt.Errorf("failed to process document symbols: %w", err)
+	}
+
+	var symbols []flatSymbol
+	var walk func(results []protocol.DocumentSymbolResult, container string)
+	walk = func(results []protocol.DocumentSymbolResult, container string) {
+		for _, sym := range results {
+			switch v := sym.(type) {
+			case *protocol.DocumentSymbol:
+				symbols = append(symbols, flatSymbol{v.Name, v.Kind, container, v.Range, v.SelectionRange.Start})
+				children := make([]protocol.DocumentSymbolResult, len(v.Children))
+				for i := range v.Children {
+					children[i] = &v.Children[i]
+				}
+				walk(children, v.Name)
+			case *protocol.SymbolInformation:
+				selection := v.Location.Range.Start
+				if loc, err := GetExactSymbolLocation(v); err == nil {
+					selection = loc.Range.Start
+				}
+				symbols = append(symbols, flatSymbol{v.Name, v.Kind, v.ContainerName, v.Location.Range, selection})
+			}
+		}
+	}
+	walk(results, "")
+
+	cache[uri] = symbols
+	return symbols, nil
+}
+
+// innermostOverlapping returns the symbols overlapping r that don't contain
+// another overlapping symbol
+func innermostOverlapping(symbols []flatSymbol, r protocol.Range) []flatSymbol {
+	var overlapping []flatSymbol
+	for _, sym := range symbols {
+		if sym.rng.Start.Line <= r.End.Line && sym.rng.End.Line >= r.Start.Line {
+			overlapping = append(overlapping, sym)
+		}
+	}
+
+	var innermost []flatSymbol
+	for i, sym := range overlapping {
+		hasInner := false
+		for j, other := range overlapping {
+			if i != j && other.rng != sym.rng && rangeWithin(other.rng, sym.rng) {
+				hasInner = true
+				break
+			}
+		}
+		if !hasInner {
+			innermost = append(innermost, sym)
+		}
+	}
+	return innermost
+}
+
+// rangeWithin reports whether inner lies entirely inside outer
+func rangeWithin(inner, outer protocol.Range) bool {
+	before := func(a, b protocol.Position) bool {
+		return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+	}
+	return !before(inner.Start, outer.Start) && !before(outer.End, inner.End)
+}
+
+// enclosingFunction finds the innermost function or method around a location,
+// falling back to the innermost symbol of any kind
+func enclosingFunction(ctx context.Context, client *lsp.Client, loc protocol.Location, cache map[protocol.DocumentUri][]flatSymbol) (flatSymbol, bool) {
+	symbols, err := documentSymbolsFlat(ctx, client, loc.URI, cache)
+	if err != nil {
+		toolsLogger.Debug("Error getting symbols for %s: %v", loc.URI, err)
+		cache[loc.URI] = nil
+		return flatSymbol{}, false
+	}
+
+	var function, innermost flatSymbol
+	var foundFunction, foundAny bool
+	// Children follow their parents, so the last match is the innermost
+	for _, sym := range symbols {
+		if !containsPosition(sym.rng, loc.Range.Start) {
+			continue
+		}
+		innermost, foundAny = sym, true
+		switch sym.kind {
+		case protocol.Function, protocol.Method, protocol.Constructor:
+			function, foundFunction = sym, true
+		}
+	}
+	if foundFunction {
+		return function, true
+	}
+	return innermost, foundAny
+}
@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func lineRange(start, end uint32) protocol.Range {
+	return protocol.Range{
+		Start: protocol.Position{Line: start},
+		End:   protocol.Position{Line: end, Character: 1},
+	}
+}
+
+func TestInnermostOverlapping(t *testing.T) {
+	symbols := []flatSymbol{
+		{name: "Server", kind: protocol.Class, rng: lineRange(0, 30)},
+		{name: "Start", kind: protocol.Method, container: "Server", rng: lineRange(2, 10)},
+		{name: "Stop", kind: protocol.Method, container: "Server", rng: lineRange(12, 20)},
+		{name: "helper", kind: protocol.Function, rng: lineRange(32, 40)},
+	}
+
+	names := func(symbols []flatSymbol) []string {
+		var names []string
+		for _, sym := range symbols {
+			names = append(names, sym.name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"Start"}, names(innermostOverlapping(symbols, lineRange(4, 5))))
+	assert.Equal(t, []string{"Start", "Stop"}, names(innermostOverlapping(symbols, lineRange(8, 14))))
+	// Lines between methods only touch the class
+	assert.Equal(t, []string{"Server"}, names(innermostOverlapping(symbols, lineRange(25, 26))))
+	assert.Empty(t, innermostOverlapping(symbols, lineRange(50, 51)))
+}
@@ -0,0 +1,446 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+)
+
+// ImportGraphPackage is a package or module in the workspace together with
+// its dependencies. Imports and ImportedBy only name workspace packages,
+// everything else is listed under External.
+type ImportGraphPackage struct {
+	Name       string   `json:"name"`
+	Language   string   `json:"language"`
+	Files      []string `json:"files"`
+	Imports    []string `json:"imports,omitempty"`
+	ImportedBy []string `json:"importedBy,omitempty"`
+	External   []string `json:"external,omitempty"`
+}
+
+// ImportGraph is the package import graph of a workspace
+type ImportGraph struct {
+	Packages []ImportGraphPackage `json:"packages"`
+}
+
+// importLanguage groups files whose imports are resolved the same way
+type importLanguage string
+
+const (
+	importLangGo     importLanguage = "go"
+	importLangPython importLanguage = "python"
+	importLangJS     importLanguage = "javascript"
+	importLangC      importLanguage = "c"
+	importLangRust   importLanguage = "rust"
+)
+
+func importLanguageForFile(filePath string) importLanguage {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".go":
+		return importLangGo
+	case ".py", ".pyi":
+		return importLangPython
+	case ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs":
+		return importLangJS
+	case ".c", ".h", ".cpp", ".cc", ".cxx", ".hpp", ".hh", ".hxx":
+		return importLangC
+	case ".rs":
+		return importLangRust
+	}
+	return ""
+}
+
+// importGraphBuilder resolves the imports of each file to the package they name
+type importGraphBuilder struct {
+	// goModule is the module path declared in the workspace's go.mod
+	goModule string
+	packages map[string]*ImportGraphPackage
+	// known holds package names per language so imports can be matched against them
+	known   map[importLanguage]map[string]bool
+	imports map[string]map[string]bool
+	extern  map[string]map[string]bool
+}
+
+// BuildImportGraph parses the imports of every Go, Python, JavaScript/TypeScript,
+// C/C++ and Rust file in the workspace and groups them by package
+func BuildImportGraph(ctx context.Context, workspaceDir string) (*ImportGraph, error) {
+	b := &importGraphBuilder{
+		goModule: readGoModulePath(filepath.Join(workspaceDir, "go.mod")),
+		packages: make(map[string]*ImportGraphPackage),
+		known:    make(map[importLanguage]map[string]bool),
+		imports:  make(map[string]map[string]bool),
+		extern:   make(map[string]map[string]bool),
+	}
+
+	type sourceFile struct {
+		rel     string
+		lang    importLanguage
+		pkg     string
+		imports []heuristics.Import
+	}
+	var files []sourceFile
+
+	err := heuristics.WalkSourceFiles(ctx, workspaceDir, func(filePath string) error {
+		lang := importLanguageForFile(filePath)
+		if lang == "" {
+			return nil
+		}
+		rel, err := filepath.Rel(workspaceDir, filePath)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			toolsLogger.Debug("Skipping %s: %v", filePath, err)
+			return nil
+		}
+
+		pkg := b.packageForFile(lang, rel)
+		files = append(files, sourceFile{rel, lang, pkg, heuristics.ExtractImports(filePath, string(content))})
+		if b.known[lang] == nil {
+			b.known[lang] = make(map[string]bool)
+		}
+		b.known[lang][pkg] = true
+		key := importGraphKey(lang, pkg)
+		if _, ok := b.packages[key]; !ok {
+			b.packages[key] = &ImportGraphPackage{Name: pkg, Language: string(lang)}
+			b.imports[key] = make(map[string]bool)
+			b.extern[key] = make(map[string]bool)
+		}
+		b.packages[key].Files = append(b.packages[key].Files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk workspace: %w", err)
+	}
+
+	// Imports can only be resolved once every package is known
+	for _, file := range files {
+		key := importGraphKey(file.lang, file.pkg)
+		for _, imp := range file.imports {
+			internal, external := b.resolve(file.lang, file.rel, file.pkg, imp.Path)
+			if internal != "" && internal != file.pkg {
+				b.imports[key][internal] = true
+			} else if external != "" {
+				b.extern[key][external] = true
+			}
+		}
+	}
+
+	graph := &ImportGraph{}
+	keys := make([]string, 0, len(b.packages))
+	for key := range b.packages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	importedBy := make(map[string][]string)
+	for _, key := range keys {
+		pkg := b.packages[key]
+		pkg.Imports = sortedKeys(b.imports[key])
+		pkg.External = sortedKeys(b.extern[key])
+		for _, imported := range pkg.Imports {
+			importedKey := importGraphKey(importLanguage(pkg.Language), imported)
+			importedBy[importedKey] = append(importedBy[importedKey], pkg.Name)
+		}
+	}
+	for _, key := range keys {
+		pkg := b.packages[key]
+		pkg.ImportedBy = importedBy[key]
+		graph.Packages = append(graph.Packages, *pkg)
+	}
+
+	return graph, nil
+}
+
+func importGraphKey(lang importLanguage, pkg string) string {
+	return string(lang) + ":" + pkg
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readGoModulePath returns the module path declared in a go.mod file, if any
+func readGoModulePath(goModPath string) string {
+	f, err := os.Open(goModPath)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// packageForFile names the package a file belongs to in the style of its
+// language: an import path for Go, a dotted module for Python, a module path
+// without extension for JavaScript, the file itself for C and a crate path
+// for Rust
+func (b *importGraphBuilder) packageForFile(lang importLanguage, rel string) string {
+	dir := path.Dir(rel)
+	withoutExt := strings.TrimSuffix(rel, path.Ext(rel))
+	base := path.Base(withoutExt)
+
+	switch lang {
+	case importLangGo:
+		if b.goModule == "" {
+			return dir
+		}
+		if dir == "." {
+			return b.goModule
+		}
+		return b.goModule + "/" + dir
+
+	case importLangPython:
+		module := withoutExt
+		if base == "__init__" {
+			module = dir
+		}
+		return strings.ReplaceAll(module, "/", ".")
+
+	case importLangJS:
+		if base == "index" {
+			return dir
+		}
+		return withoutExt
+
+	case importLangRust:
+		module := strings.TrimPrefix(withoutExt, "src/")
+		switch base {
+		case "lib", "main", "mod":
+			module = path.Dir(module)
+		}
+		if module == "." {
+			return "crate"
+		}
+		return "crate::" + strings.ReplaceAll(module, "/", "::")
+	}
+
+	return rel
+}
+
+// resolve maps an import as written in a file to the workspace package it
+// refers to, or failing that to the name of the external dependency
+func (b *importGraphBuilder) resolve(lang importLanguage, rel, pkg, imp string) (internal, external string) {
+	known := b.known[lang]
+
+	switch lang {
+	case importLangGo:
+		if b.goModule != "" && (imp == b.goModule || strings.HasPrefix(imp, b.goModule+"/")) {
+			return imp, ""
+		}
+		return "", imp
+
+	case importLangPython:
+		name := imp
+		if strings.HasPrefix(imp, ".") {
+			rest := strings.TrimLeft(imp, ".")
+			parts := strings.Split(path.Dir(rel), "/")
+			if parts[0] == "." {
+				parts = nil
+			}
+			up := len(imp) - len(rest) - 1
+			if up > len(parts) {
+				return "", ""
+			}
+			parts = parts[:len(parts)-up]
+			if rest != "" {
+				parts = append(parts, rest)
+			}
+			name = strings.Join(parts, ".")
+		}
+		if match := longestKnownPrefix(known, name, "."); match != "" {
+			return match, ""
+		}
+		if strings.HasPrefix(imp, ".") {
+			return "", ""
+		}
+		return "", strings.SplitN(imp, ".", 2)[0]
+
+	case importLangJS:
+		if strings.HasPrefix(imp, ".") {
+			resolved := path.Clean(path.Join(path.Dir(rel), imp))
+			resolved = strings.TrimSuffix(resolved, path.Ext(resolved))
+			for _, candidate := range []string{resolved, path.Join(resolved, "index")} {
+				if known[candidate] {
+					return candidate, ""
+				}
+			}
+			// Relative imports of assets such as stylesheets are not dependencies
+			return "", ""
+		}
+		parts := strings.Split(imp, "/")
+		if strings.HasPrefix(imp, "@") && len(parts) > 1 {
+			return "", parts[0] + "/" + parts[1]
+		}
+		return "", parts[0]
+
+	case importLangC:
+		for _, candidate := range []string{path.Join(path.Dir(rel), imp), path.Clean(imp)} {
+			if known[candidate] {
+				return candidate, ""
+			}
+		}
+		return "", imp
+
+	case importLangRust:
+		parts := strings.Split(imp, "::")
+		switch parts[0] {
+		case "crate":
+		case "self", "super":
+			current := strings.Split(pkg, "::")
+			for len(parts) > 0 && (parts[0] == "self" || parts[0] == "super") {
+				if parts[0] == "super" && len(current) > 1 {
+					current = current[:len(current)-1]
+				}
+				parts = parts[1:]
+			}
+			parts = append(current, parts...)
+		default:
+			return "", parts[0]
+		}
+		if match := longestKnownPrefix(known, strings.Join(parts, "::"), "::"); match != "" {
+			return match, ""
+		}
+		return "", ""
+	}
+
+	return "", imp
+}
+
+// longestKnownPrefix finds the longest known package that name is or is inside
+func longestKnownPrefix(known map[string]bool, name, sep string) string {
+	for name != "" {
+		if known[name] {
+			return name
+		}
+		i := strings.LastIndex(name, sep)
+		if i < 0 {
+			return ""
+		}
+		name = name[:i]
+	}
+	return ""
+}
+
+// GetImportGraph summarizes the import graph of the workspace and includes it
+// as JSON. If packageName is set, only that package's dependencies and the
+// packages that import it are reported.
+func GetImportGraph(ctx context.Context, workspaceDir, packageName string) (string, error) {
+	graph, err := BuildImportGraph(ctx, workspaceDir)
+	if err != nil {
+		return "", err
+	}
+	if len(graph.Packages) == 0 {
+		return "No source files with imports found in workspace", nil
+	}
+
+	if packageName == "" {
+		var result strings.Builder
+		fmt.Fprintf(&result, "Packages: %d\n\n", len(graph.Packages))
+		for _, pkg := range graph.Packages {
+			fmt.Fprintf(&result, "%s (%s, %d files): imports %d, imported by %d, external %d\n",
+				pkg.Name, pkg.Language, len(pkg.Files), len(pkg.Imports), len(pkg.ImportedBy), len(pkg.External))
+		}
+		data, err := json.MarshalIndent(graph, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode import graph: %w", err)
+		}
+		result.WriteString("\n---\n\n")
+		result.Write(data)
+		return result.String(), nil
+	}
+
+	matches := graph.Find(packageName)
+	if len(matches) > 0 {
+		var result strings.Builder
+		for _, pkg := range matches {
+			fmt.Fprintf(&result, "Package: %s (%s)\n", pkg.Name, pkg.Language)
+			writeImportList(&result, "Imported by", pkg.ImportedBy)
+			writeImportList(&result, "Imports", pkg.Imports)
+			writeImportList(&result, "External", pkg.External)
+			result.WriteString("\n")
+		}
+		data, err := json.MarshalIndent(matches, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode import graph: %w", err)
+		}
+		result.WriteString("---\n\n")
+		result.Write(data)
+		return result.String(), nil
+	}
+
+	// The package may be an external dependency
+	importers := graph.ExternalImporters(packageName)
+	if len(importers) == 0 {
+		return "", codedErrorf(SymbolNotFound, "package %s not found in workspace or its dependencies", packageName)
+	}
+	var result strings.Builder
+	fmt.Fprintf(&result, "Package: %s (external)\n", packageName)
+	writeImportList(&result, "Imported by", importers)
+	return result.String(), nil
+}
+
+func writeImportList(result *strings.Builder, header string, names []string) {
+	fmt.Fprintf(result, "%s: %d\n", header, len(names))
+	for _, name := range names {
+		fmt.Fprintf(result, "  %s\n", name)
+	}
+}
+
+// Find returns the workspace packages named name. A trailing part of a name
+// such as "internal/lsp" for a Go package is accepted when there is no exact
+// match.
+func (g *ImportGraph) Find(name string) []ImportGraphPackage {
+	var exact, suffix []ImportGraphPackage
+	for _, pkg := range g.Packages {
+		switch {
+		case pkg.Name == name:
+			exact = append(exact, pkg)
+		case strings.HasSuffix(pkg.Name, "/"+name),
+			strings.HasSuffix(pkg.Name, "."+name),
+			strings.HasSuffix(pkg.Name, "::"+name):
+			suffix = append(suffix, pkg)
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return suffix
+}
+
+// ExternalImporters lists the workspace packages that depend on an external
+// package or any package inside it
+func (g *ImportGraph) ExternalImporters(name string) []string {
+	var importers []string
+	for _, pkg := range g.Packages {
+		for _, ext := range pkg.External {
+			if ext == name || strings.HasPrefix(ext, name+"/") {
+				importers = append(importers, pkg.Name)
+				break
+			}
+		}
+	}
+	return importers
+}
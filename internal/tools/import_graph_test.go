@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeWorkspaceFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestBuildImportGraph(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"go.mod":              "module example.com/app\n\ngo 1.24\n",
+		"main.go":             "package main\n\nimport (\n\t\"fmt\"\n\t\"example.com/app/internal/store\"\n)\n",
+		"internal/store/a.go": "package store\n\nimport \"example.com/app/internal/util\"\n",
+		"internal/util/u.go":  "package util\n\nimport \"strings\"\n",
+		"web/src/index.ts":    "import { api } from './api';\nimport React from 'react';\n",
+		"web/src/api.ts":      "import axios from 'axios';\n",
+		"py/pkg/__init__.py":  "",
+		"py/pkg/models.py":    "from . import helpers\nimport requests\n",
+		"py/pkg/helpers.py":   "",
+	})
+
+	graph, err := BuildImportGraph(context.Background(), dir)
+	require.NoError(t, err)
+
+	byName := make(map[string]ImportGraphPackage)
+	for _, pkg := range graph.Packages {
+		byName[pkg.Name] = pkg
+	}
+
+	main := byName["example.com/app"]
+	assert.Equal(t, []string{"example.com/app/internal/store"}, main.Imports)
+	assert.Equal(t, []string{"fmt"}, main.External)
+
+	util := byName["example.com/app/internal/util"]
+	assert.Equal(t, []string{"example.com/app/internal/store"}, util.ImportedBy)
+
+	web := byName["web/src"]
+	assert.Equal(t, "javascript", web.Language)
+	assert.Equal(t, []string{"web/src/api"}, web.Imports)
+	assert.Equal(t, []string{"react"}, web.External)
+	assert.Equal(t, []string{"web/src"}, byName["web/src/api"].ImportedBy)
+
+	models := byName["py.pkg.models"]
+	assert.Equal(t, []string{"py.pkg"}, models.Imports)
+	assert.Equal(t, []string{"requests"}, models.External)
+
+	found := graph.Find("internal/util")
+	require.Len(t, found, 1)
+	assert.Equal(t, "example.com/app/internal/util", found[0].Name)
+
+	assert.Equal(t, []string{"web/src/api"}, graph.ExternalImporters("axios"))
+}
@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// InlineSymbol applies the language server's inline refactoring at a
+// position, such as inlining a call to a trivial wrapper or a variable used
+// once, and returns the diff. Line and column are 1-indexed.
+func InlineSymbol(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
+	rng, err := lineColumnRange(line, column, line, column)
+	if err != nil {
+		return "", err
+	}
+	rng.End = rng.Start
+
+	actions, err := codeActionsAt(ctx, client, filePath, rng, []protocol.CodeActionKind{protocol.RefactorInline})
+	if err != nil {
+		return "", err
+	}
+	available := inlineActions(actions)
+	if len(available) == 0 {
+		return noInlineActionMessage(client, actions, filePath, line, column), nil
+	}
+	i, err := chooseOption(ctx, fmt.Sprintf("Several inline refactorings are available at %s L%d:C%d. Which one should be applied?", filePath, line, column),
+		codeActionTitles(client, available), "Call inline_symbol again with the position of the exact call or variable to inline.")
+	if err != nil {
+		return "", err
+	}
+	action := available[i]
+
+	changes, err := applyCodeAction(ctx, client, action)
+	if err != nil {
+		return "", err
+	}
+	diff := changes.diff()
+	if diff == "" {
+		return fmt.Sprintf("Applied: %s\nNo changes were made\n", action.Title), nil
+	}
+	return fmt.Sprintf("Applied: %s\n\n%s", action.Title, diff), nil
+}
+
+// inlineActions returns the inline actions the server hasn't disabled
+func inlineActions(actions []protocol.CodeAction) []protocol.CodeAction {
+	var available []protocol.CodeAction
+	for _, action := range actions {
+		if action.Disabled == nil {
+			available = append(available, action)
+		}
+	}
+	return available
+}
+
+// noInlineActionMessage explains why nothing was inlined, listing disabled
+// actions with their reasons if there are any
+func noInlineActionMessage(client *lsp.Client, actions []protocol.CodeAction, filePath string, line, column int) string {
+	if len(actions) > 0 {
+		return fmt.Sprintf("No inline refactoring is available at %s L%d:C%d:\n%s", filePath, line, column, formatCodeActions(client, actions))
+	}
+	return fmt.Sprintf("The language server offers no inline refactoring at %s L%d:C%d", filePath, line, column)
+}
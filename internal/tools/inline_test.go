@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInlineActions(t *testing.T) {
+	actions := []protocol.CodeAction{
+		{Title: "Inline call to helper", Kind: "refactor.inline.call", Disabled: &protocol.CodeActionDisabled{Reason: "recursive call"}},
+		{Title: "Inline variable", Kind: "refactor.inline.variable"},
+		{Title: "Inline constant", Kind: "refactor.inline"},
+	}
+	available := inlineActions(actions)
+	if assert.Len(t, available, 2) {
+		assert.Equal(t, "Inline variable", available[0].Title)
+		assert.Equal(t, "Inline constant", available[1].Title)
+	}
+
+	assert.Empty(t, inlineActions(actions[:1]))
+	assert.Empty(t, inlineActions(nil))
+}
+
+func TestNoInlineActionMessage(t *testing.T) {
+	message := noInlineActionMessage(&lsp.Client{}, nil, "/ws/main.go", 3, 7)
+	assert.Equal(t, "The language server offers no inline refactoring at /ws/main.go L3:C7", message)
+
+	// Disabled actions are listed with the server's reason
+	message = noInlineActionMessage(&lsp.Client{}, []protocol.CodeAction{
+		{Title: "Inline call to helper", Kind: "refactor.inline.call", Disabled: &protocol.CodeActionDisabled{Reason: "recursive call"}},
+	}, "/ws/main.go", 3, 7)
+	assert.Contains(t, message, "No inline refactoring is available at /ws/main.go L3:C7:")
+	assert.Contains(t, message, "1. Inline call to helper (refactor.inline.call) - unavailable: recursive call")
+}
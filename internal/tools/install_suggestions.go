@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// missingImportCodes are the pyright rules that report an import whose
+// package isn't installed
+var missingImportCodes = map[string]bool{
+	"reportMissingImports":      true,
+	"reportMissingModuleSource": true,
+}
+
+// missingImportRegex finds the module in pyright's messages, e.g. Import
+// "yaml" could not be resolved from source
+var missingImportRegex = regexp.MustCompile(`Import "([\w.]+)" could not be resolved`)
+
+// pypiDistributions maps modules to the PyPI distributions that provide
+// them, where the names differ. Dotted modules are matched before their
+// parents.
+var pypiDistributions = map[string]string{
+	"attr":            "attrs",
+	"bs4":             "beautifulsoup4",
+	"Crypto":          "pycryptodome",
+	"cv2":             "opencv-python",
+	"dateutil":        "python-dateutil",
+	"discord":         "discord.py",
+	"docx":            "python-docx",
+	"dotenv":          "python-dotenv",
+	"fitz":            "PyMuPDF",
+	"gi":              "PyGObject",
+	"git":             "GitPython",
+	"google.protobuf": "protobuf",
+	"grpc":            "grpcio",
+	"jose":            "python-jose",
+	"jwt":             "PyJWT",
+	"kafka":           "kafka-python",
+	"ldap":            "python-ldap",
+	"magic":           "python-magic",
+	"mpl_toolkits":    "matplotlib",
+	"multipart":       "python-multipart",
+	"MySQLdb":         "mysqlclient",
+	"nacl":            "PyNaCl",
+	"OpenSSL":         "pyOpenSSL",
+	"PIL":             "Pillow",
+	"pkg_resources":   "setuptools",
+	"pptx":            "python-pptx",
+	"serial":          "pyserial",
+	"skimage":         "scikit-image",
+	"sklearn":         "scikit-learn",
+	"slugify":         "python-slugify",
+	"socks":           "PySocks",
+	"telegram":        "python-telegram-bot",
+	"usb":             "pyusb",
+	"win32api":        "pywin32",
+	"win32con":        "pywin32",
+	"wx":              "wxPython",
+	"Xlib":            "python-xlib",
+	"yaml":            "PyYAML",
+	"zmq":             "pyzmq",
+}
+
+// namespacePackages are shared by many distributions, so a module under
+// one that isn't in pypiDistributions gets no suggestion
+var namespacePackages = map[string]bool{
+	"azure":  true,
+	"google": true,
+}
+
+// pythonInstallers pick the install command from the lock file of the
+// project's package manager, pip when there is none
+var pythonInstallers = []struct {
+	lockFile string
+	command  string
+}{
+	{"uv.lock", "uv add"},
+	{"poetry.lock", "poetry add"},
+	{"pdm.lock", "pdm add"},
+	{"Pipfile.lock", "pipenv install"},
+}
+
+// installSuggestions holds whether diagnostics about missing Python
+// packages suggest installing them, and the command to do so
+var installSuggestions = struct {
+	sync.RWMutex
+	enabled bool
+	command string
+}{enabled: true, command: "pip install"}
+
+// SetInstallSuggestions configures the install suggestions added to
+// pyright's diagnostics about unresolved imports. The command depends on the
+// lock files in the workspace. Suggestions can be turned off where packages
+// can't be installed, such as air-gapped environments.
+func SetInstallSuggestions(workspaceDir string, enabled bool) {
+	command := "pip install"
+	for _, installer := range pythonInstallers {
+		if _, err := os.Stat(filepath.Join(workspaceDir, installer.lockFile)); err == nil {
+			command = installer.command
+			break
+		}
+	}
+	installSuggestions.Lock()
+	defer installSuggestions.Unlock()
+	installSuggestions.enabled = enabled
+	installSuggestions.command = command
+}
+
+// installSuggestion returns the command that installs the package a
+// diagnostic reports missing, or "" if it isn't such a diagnostic
+func installSuggestion(diag protocol.Diagnostic) string {
+	installSuggestions.RLock()
+	defer installSuggestions.RUnlock()
+	if !installSuggestions.enabled {
+		return ""
+	}
+	if code, ok := diag.Code.(string); !ok || !missingImportCodes[code] {
+		return ""
+	}
+	m := missingImportRegex.FindStringSubmatch(diag.Message)
+	if m == nil {
+		return ""
+	}
+	distribution := pypiDistribution(m[1])
+	if distribution == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s %s", installSuggestions.command, distribution)
+}
+
+// pypiDistribution guesses the PyPI distribution that provides a module
+func pypiDistribution(module string) string {
+	for name := module; ; {
+		if distribution, ok := pypiDistributions[name]; ok {
+			return distribution
+		}
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	top, _, _ := strings.Cut(module, ".")
+	if namespacePackages[top] {
+		return ""
+	}
+	return top
+}
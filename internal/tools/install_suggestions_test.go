@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstallSuggestion(t *testing.T) {
+	t.Cleanup(func() { SetInstallSuggestions(t.TempDir(), true) })
+	missing := func(code, message string) protocol.Diagnostic {
+		return protocol.Diagnostic{Severity: protocol.SeverityError, Source: "Pyright", Code: code, Message: message}
+	}
+
+	SetInstallSuggestions(t.TempDir(), true)
+	tests := []struct {
+		name     string
+		diag     protocol.Diagnostic
+		expected string
+	}{
+		{"same name", missing("reportMissingImports", `Import "requests" could not be resolved`), "pip install requests"},
+		{"submodule", missing("reportMissingImports", `Import "numpy.linalg" could not be resolved`), "pip install numpy"},
+		{"renamed distribution", missing("reportMissingModuleSource", `Import "yaml" could not be resolved from source`), "pip install PyYAML"},
+		{"dotted mapping", missing("reportMissingImports", `Import "google.protobuf.message" could not be resolved`), "pip install protobuf"},
+		{"namespace package", missing("reportMissingImports", `Import "google.cloud.storage" could not be resolved`), ""},
+		{"relative import", missing("reportMissingImports", `Import ".models" could not be resolved`), ""},
+		{"other rule", missing("reportAttributeAccessIssue", `Import "yaml" could not be resolved`), ""},
+		{"no code", protocol.Diagnostic{Message: `Import "yaml" could not be resolved`}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, installSuggestion(tt.diag))
+		})
+	}
+
+	t.Run("summary", func(t *testing.T) {
+		assert.Equal(t, `ERROR at L1:C1: Import "cv2" could not be resolved (Source: Pyright, Code: reportMissingImports) Install it with: pip install opencv-python`,
+			formatDiagnosticSummary(missing("reportMissingImports", `Import "cv2" could not be resolved`)))
+	})
+
+	t.Run("package manager", func(t *testing.T) {
+		dir := writeWorkspaceFiles(t, map[string]string{"pyproject.toml": "", "uv.lock": ""})
+		SetInstallSuggestions(dir, true)
+		assert.Equal(t, "uv add PyYAML", installSuggestion(missing("reportMissingImports", `Import "yaml" could not be resolved`)))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		SetInstallSuggestions(t.TempDir(), false)
+		assert.Empty(t, installSuggestion(missing("reportMissingImports", `Import "yaml" could not be resolved`)))
+	})
+}
@@ -0,0 +1,313 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// declaredSymbol is a type or interface found by name, with the document
+// symbol that declares it
+type declaredSymbol struct {
+	path string
+	sym  flatSymbol
+}
+
+// member is a method or property of a type or interface
+type member struct {
+	name string
+	path string
+	line int // 0-indexed
+	// pointer is set for Go methods with a pointer receiver
+	pointer bool
+}
+
+// CheckInterface reports whether a type satisfies an interface and, if it
+// doesn't, which methods it lacks. The language server's implementations of
+// the interface decide whether the type satisfies it; comparing the names
+// of the members of both tells what is missing. For Go, methods only *T has,
+// and errors the server reports about the two, are listed too.
+func CheckInterface(ctx context.Context, client *lsp.Client, typeName, interfaceName string) (string, error) {
+	cache := make(map[protocol.DocumentUri][]flatSymbol)
+	iface, err := findDeclaredSymbol(ctx, client, interfaceName, true, cache)
+	if err != nil {
+		return "", err
+	}
+	typ, err := findDeclaredSymbol(ctx, client, typeName, false, cache)
+	if err != nil {
+		return "", err
+	}
+
+	required, embedded := interfaceMembers(iface, cache)
+	methods := typeMembers(ctx, client, typ, cache)
+	implemented, implErr := implementsInterface(ctx, client, iface, typ)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Type: %s (%s:%d)\n", typ.sym.name, typ.path, typ.sym.selection.Line+1)
+	fmt.Fprintf(&out, "Interface: %s (%s:%d)\n\n", iface.sym.name, iface.path, iface.sym.selection.Line+1)
+
+	var missing []member
+	var pointerOnly []member
+	for _, method := range required {
+		found, ok := methods[method.name]
+		switch {
+		case !ok:
+			missing = append(missing, method)
+		case found.pointer:
+			pointerOnly = append(pointerOnly, found)
+		}
+	}
+
+	switch {
+	case implErr != nil:
+		fmt.Fprintf(&out, "The language server couldn't list the implementations of %s (%v), so only the names of the members were compared.\n", iface.sym.name, implErr)
+		if len(missing) == 0 {
+			fmt.Fprintf(&out, "%s has a member named like each of the %d members of %s.\n", typ.sym.name, len(required), iface.sym.name)
+		}
+	case implemented:
+		fmt.Fprintf(&out, "%s satisfies %s: the language server lists it among the implementations.\n", typ.sym.name, iface.sym.name)
+		if len(missing) > 0 {
+			fmt.Fprintf(&out, "The members not declared on %s itself are inherited or come from embedded types.\n", typ.sym.name)
+			missing = nil
+		}
+	case len(missing) == 0 && len(pointerOnly) == 0:
+		fmt.Fprintf(&out, "%s doesn't satisfy %s according to the language server, although it has a member named like each of the %d members of the interface, so their signatures probably differ:\n",
+			typ.sym.name, iface.sym.name, len(required))
+		for _, method := range required {
+			fmt.Fprintf(&out, "\n%s\n  interface: %s\n  type:      %s\n", method.name, memberLine(method), memberLine(methods[method.name]))
+		}
+	default:
+		fmt.Fprintf(&out, "%s doesn't satisfy %s.\n", typ.sym.name, iface.sym.name)
+	}
+
+	if len(missing) > 0 {
+		fmt.Fprintf(&out, "\nMissing members (%d of %d):\n", len(missing), len(required))
+		for _, method := range missing {
+			fmt.Fprintf(&out, "- %s\n", memberLine(method))
+		}
+	}
+	if len(pointerOnly) > 0 && !implemented {
+		fmt.Fprintf(&out, "\nThese methods have pointer receivers, so *%s has them but %s doesn't:\n", typ.sym.name, typ.sym.name)
+		for _, method := range pointerOnly {
+			fmt.Fprintf(&out, "- %s (%s:%d)\n", memberLine(method), method.path, method.line+1)
+		}
+	}
+	if len(embedded) > 0 && !implemented {
+		fmt.Fprintf(&out, "\n%s also embeds %s, whose members aren't listed.\n", iface.sym.name, strings.Join(embedded, ", "))
+	}
+	if diagnostics := conformanceDiagnostics(client, typ.sym.name, iface.sym.name); diagnostics != "" {
+		out.WriteString("\nErrors reported about them:\n" + diagnostics)
+	}
+	return out.String(), nil
+}
+
+// findDeclaredSymbol finds the declaration of a type or, with wantInterface,
+// an interface by name. Interfaces are preferred for wantInterface and other
+// kinds of types otherwise.
+func findDeclaredSymbol(ctx context.Context, client *lsp.Client, name string, wantInterface bool, cache map[protocol.DocumentUri][]flatSymbol) (declaredSymbol, error) {
+	resolvedName, results, err := QuerySymbol(ctx, client, name)
+	if err != nil {
+		return declaredSymbol{}, err
+	}
+	var fallback *declaredSymbol
+	for _, result := range results {
+		if !callHierarchySymbolMatches(resolvedName, result) {
+			continue
+		}
+		loc, err := GetExactSymbolLocation(result)
+		if err != nil {
+			continue
+		}
+		symbols, err := documentSymbolsFlat(ctx, client, loc.URI, cache)
+		if err != nil {
+			toolsLogger.Debug("Skipping %s: %v", loc.URI, err)
+			continue
+		}
+		for _, sym := range symbols {
+			if sym.selection.Line != loc.Range.Start.Line || sym.name != result.GetName() {
+				continue
+			}
+			found := declaredSymbol{path: loc.URI.Path(), sym: sym}
+			if (sym.kind == protocol.Interface) == wantInterface {
+				return found, nil
+			}
+			if fallback == nil && isTypeKind(sym.kind) {
+				fallback = &found
+			}
+		}
+	}
+	if fallback != nil {
+		return *fallback, nil
+	}
+	what := "type"
+	if wantInterface {
+		what = "interface"
+	}
+	return declaredSymbol{}, codedErrorf(SymbolNotFound, "%s %s not found%s", what, name, emptyResultHints(client, similarSymbolsHint(name, results)))
+}
+
+// isTypeKind reports whether a symbol kind declares a type
+func isTypeKind(kind protocol.SymbolKind) bool {
+	switch kind {
+	case protocol.Class, protocol.Struct, protocol.Interface, protocol.Enum, protocol.TypeParameter:
+		return true
+	}
+	return false
+}
+
+// isMemberKind reports whether a symbol kind can satisfy an interface member
+func isMemberKind(kind protocol.SymbolKind) bool {
+	switch kind {
+	case protocol.Method, protocol.Function, protocol.Property, protocol.Field, protocol.Constructor:
+		return true
+	}
+	return false
+}
+
+// childrenOf lists the symbols declared directly in a symbol
+func childrenOf(parent declaredSymbol, cache map[protocol.DocumentUri][]flatSymbol) []flatSymbol {
+	var children []flatSymbol
+	for _, sym := range cache[protocol.URIFromPath(parent.path)] {
+		if sym.container == parent.sym.name && rangeWithin(sym.rng, parent.sym.rng) && sym.rng != parent.sym.rng {
+			children = append(children, sym)
+		}
+	}
+	return children
+}
+
+// interfaceMembers lists the members an interface declares, and the names
+// of the interfaces it embeds, which Go servers list as its fields
+func interfaceMembers(iface declaredSymbol, cache map[protocol.DocumentUri][]flatSymbol) ([]member, []string) {
+	isGo := filepath.Ext(iface.path) == ".go"
+	var members []member
+	var embedded []string
+	for _, sym := range childrenOf(iface, cache) {
+		switch {
+		case isGo && sym.kind != protocol.Method:
+			embedded = append(embedded, sym.name)
+		case isMemberKind(sym.kind):
+			members = append(members, member{name: sym.name, path: iface.path, line: int(sym.selection.Line)})
+		}
+	}
+	return members, embedded
+}
+
+// typeMembers returns the members of a type by name. For Go, those are the
+// methods declared in the type's package with it as receiver; a method with
+// a value receiver wins over one with a pointer receiver.
+func typeMembers(ctx context.Context, client *lsp.Client, typ declaredSymbol, cache map[protocol.DocumentUri][]flatSymbol) map[string]member {
+	members := make(map[string]member)
+	if filepath.Ext(typ.path) != ".go" {
+		for _, sym := range childrenOf(typ, cache) {
+			if isMemberKind(sym.kind) {
+				members[sym.name] = member{name: sym.name, path: typ.path, line: int(sym.selection.Line)}
+			}
+		}
+		return members
+	}
+
+	dir := filepath.Dir(typ.path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		toolsLogger.Debug("Can't list the package of %s: %v", typ.sym.name, err)
+		return members
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		symbols, err := documentSymbolsFlat(ctx, client, protocol.URIFromPath(path), cache)
+		if err != nil {
+			toolsLogger.Debug("Skipping %s: %v", path, err)
+			continue
+		}
+		for _, sym := range symbols {
+			receiver, pointer, method, ok := goMethodName(sym.name)
+			if !ok || receiver != typ.sym.name {
+				continue
+			}
+			if existing, ok := members[method]; ok && !existing.pointer {
+				continue
+			}
+			members[method] = member{name: method, path: path, line: int(sym.selection.Line), pointer: pointer}
+		}
+	}
+	return members
+}
+
+// goMethodName splits a method name as gopls gives it, like "(*Store).Add"
+// or "(List[T]).Len", into the receiver type, whether the receiver is a
+// pointer, and the method
+func goMethodName(name string) (receiver string, pointer bool, method string, ok bool) {
+	if !strings.HasPrefix(name, "(") {
+		return "", false, "", false
+	}
+	end := strings.Index(name, ").")
+	if end < 0 {
+		return "", false, "", false
+	}
+	receiver = name[1:end]
+	pointer = strings.HasPrefix(receiver, "*")
+	receiver = strings.TrimPrefix(receiver, "*")
+	if i := strings.Index(receiver, "["); i >= 0 {
+		receiver = receiver[:i]
+	}
+	return receiver, pointer, name[end+2:], true
+}
+
+// implementsInterface reports whether the language server lists the type
+// among the implementations of the interface
+func implementsInterface(ctx context.Context, client *lsp.Client, iface, typ declaredSymbol) (bool, error) {
+	uri := protocol.URIFromPath(iface.path)
+	result, err := client.Implementation(ctx, protocol.ImplementationParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     iface.sym.selection,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, loc := range definitionLocations(protocol.Or_Result_textDocument_definition{Value: result.Value}) {
+		if loc.URI.Path() == typ.path && containsPosition(typ.sym.rng, loc.Range.Start) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// memberLine returns the source line that declares a member
+func memberLine(m member) string {
+	content, err := lsp.ReadSourceFile(m.path)
+	if err == nil {
+		if lines := strings.Split(string(content), "\n"); m.line < len(lines) {
+			if line := strings.TrimSpace(lines[m.line]); line != "" {
+				return line
+			}
+		}
+	}
+	return m.name
+}
+
+// conformanceDiagnostics lists the errors the server reported that mention
+// both the type and the interface, such as Go's "X does not implement Y"
+func conformanceDiagnostics(client *lsp.Client, typeName, interfaceName string) string {
+	var lines []string
+	for uri, diagnostics := range client.GetWorkspaceDiagnostics() {
+		for _, diag := range diagnostics {
+			if diag.Severity == protocol.SeverityError && strings.Contains(diag.Message, typeName) && strings.Contains(diag.Message, interfaceName) {
+				lines = append(lines, fmt.Sprintf("- %s:%d: %s\n", uri.Path(), diag.Range.Start.Line+1, diag.Message))
+			}
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoMethodName(t *testing.T) {
+	tests := []struct {
+		name, receiver, method string
+		pointer, ok            bool
+	}{
+		{"(*Store).Add", "Store", "Add", true, true},
+		{"(List[T]).Len", "List", "Len", false, true},
+		{"(*Map[K, V]).Set", "Map", "Set", true, true},
+		{"Add", "", "", false, false},
+	}
+	for _, tt := range tests {
+		receiver, pointer, method, ok := goMethodName(tt.name)
+		assert.Equal(t, tt.ok, ok, tt.name)
+		assert.Equal(t, tt.receiver, receiver, tt.name)
+		assert.Equal(t, tt.pointer, pointer, tt.name)
+		assert.Equal(t, tt.method, method, tt.name)
+	}
+}
+
+func TestCheckInterface(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"go.mod":   "module example.com/store\n\ngo 1.24\n",
+		"store.go": "package store\n\ntype Store interface {\n\tGet(key string) string\n\tPut(key, value string)\n}\n",
+		"mem.go":   "package store\n\ntype Mem struct{}\n\nfunc (m *Mem) Get(key string) string { return \"\" }\n",
+		"disk.go":  "package store\n\ntype Disk struct{}\n\nfunc (Disk) Get(key string) string { return \"\" }\nfunc (Disk) Put(key, value string) {}\n",
+	})
+	uri := func(name string) protocol.DocumentUri { return protocol.URIFromPath(filepath.Join(dir, name)) }
+	lines := func(start, end uint32) protocol.Range {
+		return protocol.Range{Start: protocol.Position{Line: start}, End: protocol.Position{Line: end, Character: 60}}
+	}
+	symbol := func(name string, kind protocol.SymbolKind, rng protocol.Range, children ...protocol.DocumentSymbol) protocol.DocumentSymbol {
+		return protocol.DocumentSymbol{Name: name, Kind: kind, Range: rng, SelectionRange: protocol.Range{Start: protocol.Position{Line: rng.Start.Line, Character: 5}}, Children: children}
+	}
+	documents := map[protocol.DocumentUri][]protocol.DocumentSymbol{
+		uri("store.go"): {symbol("Store", protocol.Interface, lines(2, 5),
+			symbol("Get", protocol.Method, lines(3, 3)),
+			symbol("Put", protocol.Method, lines(4, 4)))},
+		uri("mem.go"): {symbol("Mem", protocol.Struct, lines(2, 2)), symbol("(*Mem).Get", protocol.Method, lines(4, 4))},
+		uri("disk.go"): {symbol("Disk", protocol.Struct, lines(2, 2)), symbol("(Disk).Get", protocol.Method, lines(4, 4)),
+			symbol("(Disk).Put", protocol.Method, lines(5, 5))},
+	}
+	declarations := map[string]protocol.Location{
+		"Store": {URI: uri("store.go"), Range: lines(2, 5)},
+		"Mem":   {URI: uri("mem.go"), Range: lines(2, 2)},
+		"Disk":  {URI: uri("disk.go"), Range: lines(2, 2)},
+	}
+
+	server := lsptest.NewServer(protocol.ServerCapabilities{})
+	server.Handle("workspace/symbol", func(raw json.RawMessage) (any, error) {
+		var params protocol.WorkspaceSymbolParams
+		require.NoError(t, json.Unmarshal(raw, &params))
+		loc, ok := declarations[params.Query]
+		if !ok {
+			return []protocol.SymbolInformation{}, nil
+		}
+		return []protocol.SymbolInformation{{Name: params.Query, Kind: protocol.Struct, Location: loc}}, nil
+	})
+	server.Handle("textDocument/documentSymbol", func(raw json.RawMessage) (any, error) {
+		var params protocol.DocumentSymbolParams
+		require.NoError(t, json.Unmarshal(raw, &params))
+		return documents[params.TextDocument.URI], nil
+	})
+	server.Respond("textDocument/implementation", []protocol.Location{{URI: uri("disk.go"), Range: protocol.Range{Start: protocol.Position{Line: 2, Character: 5}}}})
+	client := lsptest.Start(t, server, dir)
+	ctx := context.Background()
+
+	t.Run("missing methods", func(t *testing.T) {
+		result, err := CheckInterface(ctx, client, "Mem", "Store")
+		require.NoError(t, err)
+		assert.Contains(t, result, "Mem doesn't satisfy Store.\n")
+		assert.Contains(t, result, "Missing members (1 of 2):\n- Put(key, value string)\n")
+		assert.Contains(t, result, "so *Mem has them but Mem doesn't:\n- func (m *Mem) Get(key string) string { return \"\" } ("+filepath.Join(dir, "mem.go")+":5)\n")
+	})
+
+	t.Run("satisfied", func(t *testing.T) {
+		result, err := CheckInterface(ctx, client, "Disk", "Store")
+		require.NoError(t, err)
+		assert.Contains(t, result, "Disk satisfies Store: the language server lists it among the implementations.\n")
+		assert.NotContains(t, result, "Missing")
+	})
+
+	t.Run("unknown interface", func(t *testing.T) {
+		_, err := CheckInterface(ctx, client, "Disk", "Cache")
+		assert.Equal(t, SymbolNotFound, ErrorCodeOf(err))
+	})
+}
@@ -3,8 +3,6 @@ package tools
 import (
 	"context"
 	"fmt"
-	"net/url"
-	"os"
 	"slices"
 	"strings"
 
@@ -18,9 +16,12 @@ type match struct {
 }
 
 func identifyOverlappingSymbols(ctx context.Context, client *lsp.Client, startLocation protocol.Location) ([]match, error) {
+	// Notebook positions refer to the notebook view, the server knows about cells
+	serverLocation, lineOffset := client.ServerLocation(startLocation)
+
 	symParams := protocol.DocumentSymbolParams{
 		TextDocument: protocol.TextDocumentIdentifier{
-			URI: startLocation.URI,
+			URI: serverLocation.URI,
 		},
 	}
 
@@ -44,8 +45,11 @@ func identifyOverlappingSymbols(ctx context.Context, client *lsp.Client, startLo
 	var searchSymbols func(symbols []protocol.DocumentSymbolResult)
 	searchSymbols = func(symbols []protocol.DocumentSymbolResult) {
 		for _, sym := range symbols {
-			if containsPosition(sym.GetRange(), startLocation.Range.Start) {
-				matchingSymbols = append(matchingSymbols, match{sym, sym.GetRange()})
+			if containsPosition(sym.GetRange(), serverLocation.Range.Start) {
+				symRange := sym.GetRange()
+				symRange.Start.Line += uint32(lineOffset)
+				symRange.End.Line += uint32(lineOffset)
+				matchingSymbols = append(matchingSymbols, match{sym, symRange})
 			}
 
 			// Handle nested symbols if it's a DocumentSymbol
@@ -80,15 +84,11 @@ func GetFullDefinition(ctx context.Context, client *lsp.Client, startLocation pr
 		symbol := matchingSymbols[0].Symbol
 		symbolRange := matchingSymbols[0].Range
 
-		// Convert URI to filesystem path
-		filePath, err := url.PathUnescape(strings.TrimPrefix(string(startLocation.URI), "file://"))
-		if err != nil {
-			return "", protocol.Location{}, nil, fmt.Errorf("failed to unescape URI: %w", err)
-		}
+		filePath := startLocation.URI.Path()
 
 		// Read the file to get the full lines of the definition
 		// because we may have a start and end column
-		content, err := os.ReadFile(filePath)
+		content, err := lsp.ReadSourceFile(filePath)
 		if err != nil {
 			return "", protocol.Location{}, nil, fmt.Errorf("failed to read file: %w", err)
 		}
@@ -100,7 +100,7 @@ func GetFullDefinition(ctx context.Context, client *lsp.Client, startLocation pr
 
 		// Get the line at the end of the range
 		if int(symbolRange.End.Line) >= len(lines) {
-			return "", protocol.Location{}, nil, fmt.Errorf("line number out of range")
+			return "", protocol.Location{}, nil, codedErrorf(PositionInvalid, "line number out of range")
 		}
 
 		line := lines[symbolRange.End.Line]
@@ -146,14 +146,14 @@ func GetFullDefinition(ctx context.Context, client *lsp.Client, startLocation pr
 
 		// Return the text within the range
 		if int(symbolRange.End.Line) >= len(lines) {
-			return "", protocol.Location{}, nil, fmt.Errorf("end line out of range")
+			return "", protocol.Location{}, nil, codedErrorf(PositionInvalid, "end line out of range")
 		}
 
 		selectedLines := lines[symbolRange.Start.Line : symbolRange.End.Line+1]
 		return strings.Join(selectedLines, "\n"), protocol.Location{URI: startLocation.URI, Range: symbolRange}, symbol, nil
 	}
 
-	return "", protocol.Location{}, nil, fmt.Errorf("symbol not found")
+	return "", protocol.Location{}, nil, codedErrorf(SymbolNotFound, "symbol not found")
 }
 
 // GetLineRangesToDisplay determines which lines should be displayed for a set of locations
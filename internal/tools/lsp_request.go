@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+)
+
+// reservedMethods are managed by the MCP server itself. Sending them directly
+// would end the session or leave open documents out of sync.
+var reservedMethods = map[string]bool{
+	"initialize":  true,
+	"initialized": true,
+	"shutdown":    true,
+	"exit":        true,
+}
+
+// SendLSPRequest forwards a request, or a notification, to the language
+// server and returns the raw JSON result. params must be a JSON object or
+// array, or empty for none.
+func SendLSPRequest(ctx context.Context, client *lsp.Client, method, params string, notification bool) (string, error) {
+	method = strings.TrimSpace(method)
+	if method == "" {
+		return "", codedErrorf(InvalidArgument, "method is required")
+	}
+	if reservedMethods[method] || strings.HasPrefix(method, "textDocument/did") || strings.HasPrefix(method, "notebookDocument/did") {
+		return "", codedErrorf(InvalidArgument, "%s is managed by the MCP server and can't be sent directly", method)
+	}
+
+	var rawParams json.RawMessage
+	if params = strings.TrimSpace(params); params != "" {
+		if !json.Valid([]byte(params)) {
+			return "", codedErrorf(InvalidArgument, "params is not valid JSON")
+		}
+		if params[0] != '{' && params[0] != '[' {
+			return "", codedErrorf(InvalidArgument, "params must be a JSON object or array")
+		}
+		rawParams = json.RawMessage(params)
+	}
+
+	if notification {
+		if err := client.Notify(ctx, method, rawParams); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Sent notification %s", method), nil
+	}
+
+	var result json.RawMessage
+	if err := client.Call(ctx, method, rawParams, &result); err != nil {
+		return "", err
+	}
+	if len(result) == 0 {
+		return "null", nil
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, result, "", "  "); err != nil {
+		return string(result), nil
+	}
+	return indented.String(), nil
+}
@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendLSPRequestValidation(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		method string
+		params string
+		err    string
+	}{
+		{"", "", "method is required"},
+		{"shutdown", "", "managed by the MCP server"},
+		{"textDocument/didOpen", "{}", "managed by the MCP server"},
+		{"rust-analyzer/expandMacro", "{", "not valid JSON"},
+		{"rust-analyzer/expandMacro", "42", "must be a JSON object or array"},
+	}
+	for _, tt := range tests {
+		// Validation fails before the client is used
+		_, err := SendLSPRequest(ctx, nil, tt.method, tt.params, false)
+		if assert.Error(t, err, tt.method) {
+			assert.Contains(t, err.Error(), tt.err)
+		}
+	}
+}
+
+func TestUpdateLSPSettingsValidation(t *testing.T) {
+	for _, settings := range []string{"", "[]", "true", "{"} {
+		// Validation fails before the client is used
+		_, err := UpdateLSPSettings(context.Background(), nil, settings)
+		assert.Error(t, err, settings)
+	}
+}
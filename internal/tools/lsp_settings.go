@@ -0,0 +1,35 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+)
+
+// UpdateLSPSettings merges a JSON object of settings into the language
+// server's settings, keyed by section like {"gopls": {"staticcheck": true}},
+// and tells the server about the change. It returns the merged settings.
+func UpdateLSPSettings(ctx context.Context, client *lsp.Client, settings string) (string, error) {
+	settings = strings.TrimSpace(settings)
+	if settings == "" {
+		return "", codedErrorf(InvalidArgument, "settings are required")
+	}
+	var update map[string]any
+	if err := json.Unmarshal([]byte(settings), &update); err != nil {
+		return "", WithCode(InvalidArgument, fmt.Errorf("settings must be a JSON object: %w", err))
+	}
+
+	merged := lsp.MergeSettings(client.Settings(), update)
+	if err := client.UpdateSettings(ctx, merged); err != nil {
+		return "", fmt.Errorf("failed to send settings: %w", err)
+	}
+
+	result, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Sent workspace/didChangeConfiguration. Settings are now:\n%s", result), nil
+}
@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// moveToNewFileKinds are the code action kinds servers use to move
+// declarations into a file of their own
+var moveToNewFileKinds = []protocol.CodeActionKind{protocol.RefactorMove, "refactor.extract.toNewFile"}
+
+// MoveSymbol moves the declaration at a position to a new file at
+// destination. The language server's "move to new file" refactoring creates
+// the file and fixes imports, then the file is renamed to the destination
+// with workspace/willRenameFiles so that imports follow. Line and column are
+// 1-indexed.
+func MoveSymbol(ctx context.Context, client *lsp.Client, filePath string, line, column int, destination string) (string, error) {
+	if !filepath.IsAbs(destination) {
+		destination = filepath.Join(filepath.Dir(filePath), destination)
+	}
+	destination = filepath.Clean(destination)
+	if _, err := os.Stat(destination); err == nil {
+		return "", codedErrorf(EditConflict, "%s already exists, declarations can only be moved to a new file", destination)
+	}
+	if filepath.Ext(filePath) != filepath.Ext(destination) {
+		return "", codedErrorf(InvalidArgument, "destination must have the same extension as %s", filepath.Base(filePath))
+	}
+	// A Go package is a directory, so moving elsewhere would change packages
+	if filepath.Ext(filePath) == ".go" && filepath.Dir(filePath) != filepath.Dir(destination) {
+		return "", codedErrorf(InvalidArgument, "Go declarations can only be moved within their package directory")
+	}
+
+	symbols, err := documentSymbolsFlat(ctx, client, protocol.URIFromPath(filePath), make(map[protocol.DocumentUri][]flatSymbol))
+	if err != nil {
+		return "", err
+	}
+	position := protocol.Position{Line: uint32(line - 1), Character: uint32(column - 1)}
+	var declaration *flatSymbol
+	// Symbols are ordered parents first, so keep the outermost match: the
+	// whole declaration rather than a field or local inside it
+	for i, sym := range symbols {
+		if containsPosition(sym.rng, position) {
+			declaration = &symbols[i]
+			break
+		}
+	}
+	if declaration == nil {
+		return fmt.Sprintf("No declaration found at %s L%d:C%d", filePath, line, column), nil
+	}
+
+	actions, err := codeActionsAt(ctx, client, filePath, declaration.rng, moveToNewFileKinds)
+	if err != nil {
+		return "", err
+	}
+	action, ok := pickMoveToNewFileAction(actions)
+	if !ok {
+		if len(actions) > 0 {
+			return fmt.Sprintf("The language server can't move %s to a new file. Available:\n%s", declaration.name, formatCodeActions(client, actions)), nil
+		}
+		return fmt.Sprintf("The language server offers no move refactoring for %s", declaration.name), nil
+	}
+
+	changes, err := applyCodeAction(ctx, client, action)
+	if err != nil {
+		return "", err
+	}
+	created := ""
+	for _, file := range changes.files() {
+		if changes.before[file] == "" && file != filePath {
+			created = file
+			break
+		}
+	}
+	if created == "" {
+		return "", fmt.Errorf("%s did not create a file", action.Title)
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Applied: %s\n", action.Title)
+	if created != destination {
+		if err := moveCreatedFile(ctx, client, changes, created, destination); err != nil {
+			fmt.Fprintf(&result, "Could not move %s to %s: %v\n", created, destination, err)
+			destination = created
+		}
+	}
+	fmt.Fprintf(&result, "Moved %s to %s\n\n", declaration.name, destination)
+
+	touched := make(map[string]bool)
+	for _, file := range changes.files() {
+		touched[file] = true
+	}
+	result.WriteString(verifyFiles(ctx, client, touched))
+	result.WriteString("\n" + changes.diff())
+	return result.String(), nil
+}
+
+// pickMoveToNewFileAction chooses an action that moves code into a new file,
+// as opposed to moves that need an existing destination chosen interactively
+func pickMoveToNewFileAction(actions []protocol.CodeAction) (protocol.CodeAction, bool) {
+	for _, action := range actions {
+		title := strings.ToLower(action.Title)
+		if action.Disabled == nil && (strings.Contains(title, "new file") || action.Kind == "refactor.move.newFile" || action.Kind == "refactor.extract.toNewFile") {
+			return action, true
+		}
+	}
+	return protocol.CodeAction{}, false
+}
+
+// moveCreatedFile renames a file that a refactoring created, letting the
+// server update imports of it, and records the move in changes
+func moveCreatedFile(ctx context.Context, client *lsp.Client, changes *appliedChanges, from, to string) error {
+	params := protocol.RenameFilesParams{
+		Files: []protocol.FileRename{{OldURI: string(protocol.URIFromPath(from)), NewURI: string(protocol.URIFromPath(to))}},
+	}
+	edit, err := client.WillRenameFiles(ctx, params)
+	if err != nil {
+		toolsLogger.Debug("willRenameFiles unavailable: %v", err)
+	} else {
+		changes.snapshot(edit)
+		if err := utilities.ApplyWorkspaceEdit(ctx, edit); err != nil {
+			return fmt.Errorf("failed to apply edits from the language server: %w", err)
+		}
+	}
+
+	if client.IsFileOpen(from) {
+		if err := client.CloseFile(ctx, from); err != nil {
+			toolsLogger.Debug("Error closing %s: %v", from, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+	if err := client.DidRenameFiles(ctx, params); err != nil {
+		toolsLogger.Debug("Error sending didRenameFiles: %v", err)
+	}
+	changes.renamed(from, to)
+	syncChangedFiles(ctx, client, workspaceEditFiles(edit))
+	return nil
+}
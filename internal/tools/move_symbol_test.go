@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickMoveToNewFileAction(t *testing.T) {
+	action, ok := pickMoveToNewFileAction([]protocol.CodeAction{
+		{Title: "Move to file", Kind: "refactor.move.file"},
+		{Title: "Move to a new file", Kind: "refactor.move.newFile"},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, "Move to a new file", action.Title)
+
+	action, ok = pickMoveToNewFileAction([]protocol.CodeAction{
+		{Title: "Extract declarations to new file", Kind: "refactor.extract.toNewFile"},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, "Extract declarations to new file", action.Title)
+
+	_, ok = pickMoveToNewFileAction([]protocol.CodeAction{{Title: "Move to file", Kind: "refactor.move.file"}})
+	assert.False(t, ok)
+}
+
+func TestAppliedChangesRenamed(t *testing.T) {
+	changes := &appliedChanges{before: map[string]string{"/ws/a.ts": "old", "/ws/newFile.ts": ""}}
+	changes.renamed("/ws/newFile.ts", "/ws/lib/helpers.ts")
+	assert.Equal(t, []string{"/ws/a.ts", "/ws/lib/helpers.ts"}, changes.files())
+
+	// Files the action didn't change are left alone
+	changes.renamed("/ws/other.ts", "/ws/moved.ts")
+	assert.Equal(t, []string{"/ws/a.ts", "/ws/lib/helpers.ts"}, changes.files())
+}
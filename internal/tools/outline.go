@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// HeuristicOutlineNotice is prepended to outlines the language server could not provide
+const HeuristicOutlineNotice = "NOTE: The language server returned no symbols for this file. " +
+	"This outline comes from text heuristics, not semantic analysis.\n\n"
+
+// GetDocumentOutline lists the symbols in a file. The language server is asked
+// first; files it fails on or returns nothing for, such as configuration,
+// build and markup files it doesn't handle, are outlined with text heuristics
+// instead.
+func GetDocumentOutline(ctx context.Context, client *lsp.Client, filePath string) (string, error) {
+	outline, err := lspOutline(ctx, client, filePath)
+	if err == nil && outline != "" {
+		return outline, nil
+	}
+	if err != nil {
+		toolsLogger.Debug("Language server outline failed for %s: %v", filePath, err)
+	}
+	if !heuristics.IsSupported(filePath) {
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("No symbols found in %s", filePath), nil
+	}
+
+	outline, err = heuristicOutline(filePath)
+	if err != nil {
+		return "", err
+	}
+	return HeuristicOutlineNotice + outline, nil
+}
+
+// lspOutline formats textDocument/documentSymbol results. It returns an empty
+// string if the server found no symbols.
+func lspOutline(ctx context.Context, client *lsp.Client, filePath string) (string, error) {
+	if err := client.OpenFile(ctx, filePath); err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+
+	symResult, err := client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{
+			URI: protocol.URIFromPath(filePath),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get document symbols: %w", err)
+	}
+
+	symbols, err := symResult.Results()
+	if err != nil {
+		return "", fmt.Errorf("failed to process document symbols: %w", err)
+	}
+	if len(symbols) == 0 {
+		return "", nil
+	}
+
+	var lines []string
+	var walk func(symbols []protocol.DocumentSymbolResult, depth int)
+	walk = func(symbols []protocol.DocumentSymbolResult, depth int) {
+		for _, sym := range symbols {
+			var kind protocol.SymbolKind
+			var children []protocol.DocumentSymbolResult
+			switch v := sym.(type) {
+			case *protocol.DocumentSymbol:
+				kind = v.Kind
+				for i := range v.Children {
+					children = append(children, &v.Children[i])
+				}
+			case *protocol.SymbolInformation:
+				kind = v.Kind
+			}
+
+			rng := sym.GetRange()
+			lines = append(lines, fmt.Sprintf("%s%s %s (L%d-L%d)",
+				strings.Repeat("  ", depth),
+				protocol.TableKindMap[kind],
+				sym.GetName(),
+				rng.Start.Line+1,
+				rng.End.Line+1,
+			))
+			walk(children, depth+1)
+		}
+	}
+	walk(symbols, 0)
+
+	return fmt.Sprintf("%s\nSymbols in File: %d\n\n%s\n", filePath, len(lines), strings.Join(lines, "\n")), nil
+}
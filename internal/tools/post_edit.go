@@ -0,0 +1,257 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/runner"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// Post-edit hook actions the language server performs
+const (
+	FormatAction          = "format"
+	OrganizeImportsAction = "organizeImports"
+)
+
+// defaultHookTimeout bounds a hook that doesn't set its own timeout
+const defaultHookTimeout = 60 * time.Second
+
+// maxHookOutput is the number of bytes of a hook command's output kept
+const maxHookOutput = 4 * 1024
+
+// PostEditHook is run after a tool edits files, on the edited files its glob
+// matches. It either runs a command or has the language server format the
+// files or organize their imports.
+type PostEditHook struct {
+	// Glob is matched against paths relative to the workspace if it contains
+	// a slash, and against file names otherwise. Empty matches every file.
+	Glob string `json:"glob"`
+	// Command is split on whitespace and run in the workspace. {files} is
+	// replaced by the files, relative to the workspace, which are otherwise
+	// appended.
+	Command string `json:"command"`
+	// Action is FormatAction or OrganizeImportsAction
+	Action string `json:"action"`
+	// Timeout is in seconds
+	Timeout int `json:"timeout"`
+}
+
+// Validate checks that the hook does one thing it knows how to do
+func (h PostEditHook) Validate() error {
+	switch {
+	case h.Glob != "" && !doublestar.ValidatePattern(h.Glob):
+		return fmt.Errorf("invalid glob %q", h.Glob)
+	case (h.Command == "") == (h.Action == ""):
+		return fmt.Errorf("a hook needs either a command or an action")
+	case h.Action != "" && h.Action != FormatAction && h.Action != OrganizeImportsAction:
+		return fmt.Errorf("unknown action %q, expected %q or %q", h.Action, FormatAction, OrganizeImportsAction)
+	case h.Command != "" && strings.TrimSpace(h.Command) == "":
+		return fmt.Errorf("empty command")
+	case h.Timeout < 0:
+		return fmt.Errorf("negative timeout %d", h.Timeout)
+	}
+	return nil
+}
+
+// name describes the hook in results
+func (h PostEditHook) name() string {
+	if h.Command != "" {
+		return h.Command
+	}
+	return h.Action
+}
+
+// matches reports whether the hook applies to a file
+func (h PostEditHook) matches(workspaceDir, path string) bool {
+	if h.Glob == "" {
+		return true
+	}
+	target := filepath.Base(path)
+	if strings.Contains(h.Glob, "/") {
+		rel, err := filepath.Rel(workspaceDir, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return false
+		}
+		target = filepath.ToSlash(rel)
+	}
+	matched, _ := doublestar.Match(h.Glob, target)
+	return matched
+}
+
+// RunPostEditHooks runs the hooks in order on the edited files that still
+// exist and describes what each did. It returns "" when no hook applied. A
+// hook that fails is reported and doesn't stop the others. client may be nil,
+// in which case actions are skipped.
+func RunPostEditHooks(ctx context.Context, client *lsp.Client, workspaceDir string, hooks []PostEditHook, files []string) string {
+	var existing []string
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			existing = append(existing, file)
+		}
+	}
+
+	var out strings.Builder
+	for _, hook := range hooks {
+		var matched []string
+		for _, file := range existing {
+			if hook.matches(workspaceDir, file) {
+				matched = append(matched, file)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		timeout := defaultHookTimeout
+		if hook.Timeout > 0 {
+			timeout = time.Duration(hook.Timeout) * time.Second
+		}
+
+		var text string
+		var err error
+		if hook.Command != "" {
+			text, err = runHookCommand(ctx, client, workspaceDir, hook.Command, matched, timeout)
+		} else if client == nil {
+			err = fmt.Errorf("no language server is running")
+		} else {
+			hookCtx, cancel := context.WithTimeout(ctx, timeout)
+			text, err = runHookAction(hookCtx, client, hook.Action, matched)
+			cancel()
+		}
+		if err != nil {
+			text = fmt.Sprintf("failed: %v\n", err)
+		}
+		fmt.Fprintf(&out, "%s: %s", hook.name(), text)
+	}
+	if out.Len() == 0 {
+		return ""
+	}
+	return "\n\nPost-edit hooks:\n" + out.String()
+}
+
+// runHookCommand runs a hook command on files and syncs the files it changed
+// with the language server
+func runHookCommand(ctx context.Context, client *lsp.Client, workspaceDir, command string, files []string, timeout time.Duration) (string, error) {
+	relative := make([]string, len(files))
+	for i, file := range files {
+		relative[i] = file
+		if rel, err := filepath.Rel(workspaceDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			relative[i] = rel
+		}
+	}
+	args, err := runner.HookCommand(command, relative)
+	if err != nil {
+		return "", err
+	}
+	result, err := runner.Run(ctx, workspaceDir, args, timeout, maxHookOutput)
+	if client != nil {
+		syncChangedFiles(ctx, client, files)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	switch {
+	case result.TimedOut:
+		fmt.Fprintf(&out, "timed out after %s\n", result.Duration.Round(time.Second))
+	case result.ExitCode == 0:
+		out.WriteString("passed\n")
+	default:
+		fmt.Fprintf(&out, "failed (exit status %d)\n", result.ExitCode)
+	}
+	if output := strings.TrimRight(result.Output, "\n"); output != "" {
+		out.WriteString(output + "\n")
+	}
+	return out.String(), nil
+}
+
+// runHookAction has the language server format files or organize their
+// imports, and lists the files that changed
+func runHookAction(ctx context.Context, client *lsp.Client, action string, files []string) (string, error) {
+	var changed []string
+	for _, file := range files {
+		if lsp.IsNotebook(file) {
+			continue
+		}
+		before, err := utilities.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		switch action {
+		case FormatAction:
+			err = formatFile(ctx, client, file)
+		case OrganizeImportsAction:
+			err = organizeFileImports(ctx, client, file)
+		}
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", file, err)
+		}
+		if after, err := utilities.ReadFile(file); err == nil && string(after) != string(before) {
+			changed = append(changed, file)
+		}
+	}
+	if len(changed) == 0 {
+		return "no changes\n", nil
+	}
+	return fmt.Sprintf("changed %s\n", strings.Join(changed, ", ")), nil
+}
+
+// formatFile applies the language server's formatting of a file
+func formatFile(ctx context.Context, client *lsp.Client, file string) error {
+	if err := client.OpenFile(ctx, file); err != nil {
+		return fmt.Errorf("could not open file: %w", err)
+	}
+	content, err := utilities.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	// Files indented with tabs are formatted with tabs
+	insertSpaces := !strings.HasPrefix(string(content), "\t") && !strings.Contains(string(content), "\n\t")
+	uri := protocol.URIFromPath(file)
+	edits, err := client.Formatting(ctx, protocol.DocumentFormattingParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Options:      protocol.FormattingOptions{TabSize: 4, InsertSpaces: insertSpaces},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to format: %w", err)
+	}
+	if len(edits) == 0 {
+		return nil
+	}
+	if err := utilities.ApplyWorkspaceEdit(ctx, protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentUri][]protocol.TextEdit{uri: edits},
+	}); err != nil {
+		return fmt.Errorf("failed to apply formatting: %w", err)
+	}
+	syncChangedFiles(ctx, client, []string{file})
+	return nil
+}
+
+// organizeFileImports applies the language server's organize imports action
+// for a file, if it offers one
+func organizeFileImports(ctx context.Context, client *lsp.Client, file string) error {
+	content, err := utilities.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	whole := protocol.Range{End: protocol.Position{Line: uint32(strings.Count(string(content), "\n"))}}
+	actions, err := codeActionsAt(ctx, client, file, whole, []protocol.CodeActionKind{protocol.SourceOrganizeImports})
+	if err != nil {
+		return err
+	}
+	for _, action := range actions {
+		if action.Disabled == nil {
+			_, err := applyCodeAction(ctx, client, action)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostEditHookValidate(t *testing.T) {
+	assert.NoError(t, PostEditHook{Glob: "*.go", Command: "goimports -w"}.Validate())
+	assert.NoError(t, PostEditHook{Action: FormatAction}.Validate())
+	assert.Error(t, PostEditHook{Glob: "*.go"}.Validate())
+	assert.Error(t, PostEditHook{Command: "gofmt -w", Action: FormatAction}.Validate())
+	assert.Error(t, PostEditHook{Action: "lint"}.Validate())
+	assert.Error(t, PostEditHook{Glob: "[", Command: "gofmt -w"}.Validate())
+	assert.Error(t, PostEditHook{Command: "gofmt -w", Timeout: -1}.Validate())
+}
+
+func TestPostEditHookMatches(t *testing.T) {
+	workspace := "/ws"
+	assert.True(t, PostEditHook{}.matches(workspace, "/ws/a.go"))
+	assert.True(t, PostEditHook{Glob: "*.go"}.matches(workspace, "/ws/pkg/a.go"))
+	assert.False(t, PostEditHook{Glob: "*.ts"}.matches(workspace, "/ws/pkg/a.go"))
+	assert.True(t, PostEditHook{Glob: "web/**/*.ts"}.matches(workspace, "/ws/web/src/app.ts"))
+	assert.False(t, PostEditHook{Glob: "web/**/*.ts"}.matches(workspace, "/ws/api/app.ts"))
+	assert.False(t, PostEditHook{Glob: "web/**/*.ts"}.matches(workspace, "/other/web/app.ts"))
+}
+
+func TestRunPostEditHooks(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	goFile := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(goFile, []byte("package main\n"), 0644))
+	missing := filepath.Join(dir, "renamed.go")
+
+	hooks := []PostEditHook{
+		{Glob: "*.go", Command: "echo checked {files} ok"},
+		{Glob: "*.ts", Command: "echo never"},
+		{Glob: "*.go", Command: "false"},
+		{Action: FormatAction},
+	}
+	report := RunPostEditHooks(context.Background(), nil, dir, hooks, []string{goFile, missing})
+	assert.Contains(t, report, "Post-edit hooks:\n")
+	assert.Contains(t, report, "echo checked {files} ok: passed\nchecked main.go ok\n")
+	assert.NotContains(t, report, "never")
+	assert.Contains(t, report, "false: failed (exit status 1)\n")
+	assert.Contains(t, report, "format: failed: no language server is running\n")
+
+	assert.Equal(t, "", RunPostEditHooks(context.Background(), nil, dir, hooks[1:2], []string{goFile}))
+}
@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Result orders
+const (
+	// OrderRelevance puts the results most likely to matter first
+	OrderRelevance = "relevance"
+	// OrderPath sorts results by file path
+	OrderPath = "path"
+)
+
+// RankingWeights score the files that results are in when they are ordered
+// by relevance. Each weight is added to the score of a file with the
+// property, higher scores come first, and ties are broken by path.
+type RankingWeights struct {
+	// NonTest favors files that aren't tests
+	NonTest float64 `json:"nonTest"`
+	// SamePackage favors files in the directory of the symbol's definition
+	SamePackage float64 `json:"samePackage"`
+	// CallSite favors files that use the symbol rather than only import it
+	CallSite float64 `json:"callSite"`
+}
+
+// DefaultRankingWeights put non-test files first, then files of the same
+// package, then files that do more than import the symbol
+var DefaultRankingWeights = RankingWeights{NonTest: 4, SamePackage: 2, CallSite: 1}
+
+var rankingWeights = struct {
+	sync.RWMutex
+	weights RankingWeights
+}{weights: DefaultRankingWeights}
+
+// SetRankingWeights changes the weights used to order results; nil restores
+// the defaults
+func SetRankingWeights(weights *RankingWeights) {
+	rankingWeights.Lock()
+	defer rankingWeights.Unlock()
+	if weights == nil {
+		rankingWeights.weights = DefaultRankingWeights
+	} else {
+		rankingWeights.weights = *weights
+	}
+}
+
+func currentRankingWeights() RankingWeights {
+	rankingWeights.RLock()
+	defer rankingWeights.RUnlock()
+	return rankingWeights.weights
+}
+
+// validOrder checks an order argument, defaulting to relevance
+func validOrder(order string) (string, error) {
+	switch order {
+	case "":
+		return OrderRelevance, nil
+	case OrderRelevance, OrderPath:
+		return order, nil
+	}
+	return "", codedErrorf(InvalidArgument, "order must be %q or %q, got %q", OrderRelevance, OrderPath, order)
+}
+
+// rankedFile is a file of results with its relevance score
+type rankedFile struct {
+	path  string
+	score float64
+}
+
+// referenceFileScore scores a file of references to a symbol defined in
+// definitionDir. lines are the file's lines, or nil if it couldn't be read.
+func referenceFileScore(path, definitionDir string, lines []string, refs []protocol.Location, weights RankingWeights) float64 {
+	score := 0.0
+	if !isTestFile(path) {
+		score += weights.NonTest
+	}
+	if definitionDir != "" && filepath.Dir(path) == definitionDir {
+		score += weights.SamePackage
+	}
+	for _, ref := range refs {
+		if int(ref.Range.Start.Line) < len(lines) && !isImportLine(lines[ref.Range.Start.Line]) {
+			score += weights.CallSite
+			break
+		}
+	}
+	return score
+}
+
+// sortRankedFiles orders files by score, highest first, then by path
+func sortRankedFiles(files []rankedFile) {
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].score != files[j].score {
+			return files[i].score > files[j].score
+		}
+		return files[i].path < files[j].path
+	})
+}
+
+// isImportLine reports whether a line is an import, include or use
+// statement in one of the common languages
+func isImportLine(line string) bool {
+	line = strings.TrimSpace(line)
+	for _, prefix := range []string{"import ", "import(", "from ", "#include", "use ", "pub use ", "using ", "require ", "extern crate "} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	// export { x } from "./x" and const x = require("x")
+	return (strings.HasPrefix(line, "export ") && strings.Contains(line, " from ")) ||
+		strings.Contains(line, "= require(")
+}
+
+// sortSymbolsByRelevance moves symbols defined in generated files, unless
+// they are included, and in test files, when the non-test weight favors
+// others, after the rest, keeping the server's order otherwise
+func sortSymbolsByRelevance(symbols []protocol.WorkspaceSymbolResult) {
+	excludeTests := currentRankingWeights().NonTest > 0
+	excludeGenerated := excludeGenerated()
+	rank := func(symbol protocol.WorkspaceSymbolResult) int {
+		path := symbol.GetLocation().URI.Path()
+		rank := 0
+		if excludeGenerated && isGeneratedFile(path) {
+			rank += 2
+		}
+		if excludeTests && isTestFile(path) {
+			rank++
+		}
+		return rank
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return rank(symbols[i]) < rank(symbols[j])
+	})
+}
@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsImportLine(t *testing.T) {
+	for _, line := range []string{
+		`import "fmt"`,
+		`from store import Store`,
+		`import { Store } from "./store";`,
+		`#include "store.h"`,
+		`	use crate::store::Store;`,
+		`using Store.Models;`,
+		`export { Store } from "./store";`,
+		`const store = require("./store");`,
+	} {
+		assert.True(t, isImportLine(line), line)
+	}
+	for _, line := range []string{
+		`store := NewStore()`,
+		`export function open(store: Store) {}`,
+		`fromStore(store)`,
+	} {
+		assert.False(t, isImportLine(line), line)
+	}
+}
+
+func TestReferenceFileScore(t *testing.T) {
+	weights := DefaultRankingWeights
+	call := []protocol.Location{{Range: protocol.Range{Start: protocol.Position{Line: 1}}}}
+	lines := []string{`import "store"`, `s := store.New()`}
+
+	assert.Equal(t, 7.0, referenceFileScore("/ws/store/cache.go", "/ws/store", lines, call, weights))
+	assert.Equal(t, 5.0, referenceFileScore("/ws/api/handler.go", "/ws/store", lines, call, weights))
+	assert.Equal(t, 3.0, referenceFileScore("/ws/store/store_test.go", "/ws/store", lines, call, weights))
+	imports := []protocol.Location{{}}
+	assert.Equal(t, 4.0, referenceFileScore("/ws/api/handler.go", "/ws/store", lines, imports, weights))
+	assert.Equal(t, 4.0, referenceFileScore("/ws/api/handler.go", "/ws/store", nil, call, weights))
+}
+
+func TestSortRankedFiles(t *testing.T) {
+	files := []rankedFile{{"/ws/b.go", 1}, {"/ws/c_test.go", 0}, {"/ws/a.go", 1}, {"/ws/d.go", 5}}
+	sortRankedFiles(files)
+	assert.Equal(t, []rankedFile{{"/ws/d.go", 5}, {"/ws/a.go", 1}, {"/ws/b.go", 1}, {"/ws/c_test.go", 0}}, files)
+}
+
+func TestSortSymbolsByRelevance(t *testing.T) {
+	symbol := func(path string) protocol.WorkspaceSymbolResult {
+		return &protocol.SymbolInformation{Name: "New", Location: protocol.Location{URI: protocol.DocumentUri("file://" + path)}}
+	}
+	symbols := []protocol.WorkspaceSymbolResult{symbol("/ws/store_test.go"), symbol("/ws/b.go"), symbol("/ws/a.go")}
+	sortSymbolsByRelevance(symbols)
+	var paths []string
+	for _, sym := range symbols {
+		paths = append(paths, sym.GetLocation().URI.Path())
+	}
+	assert.Equal(t, []string{"/ws/b.go", "/ws/a.go", "/ws/store_test.go"}, paths)
+
+	SetRankingWeights(&RankingWeights{})
+	defer SetRankingWeights(nil)
+	symbols = []protocol.WorkspaceSymbolResult{symbol("/ws/store_test.go"), symbol("/ws/b.go")}
+	sortSymbolsByRelevance(symbols)
+	assert.Equal(t, "/ws/store_test.go", symbols[0].GetLocation().URI.Path())
+}
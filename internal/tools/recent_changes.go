@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/git"
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+const (
+	// maxRecentChangeFiles bounds the number of files in a recent changes report
+	maxRecentChangeFiles = 100
+	// maxRecentCommits is the number of commits listed for each file
+	maxRecentCommits = 3
+)
+
+// changedSymbol is a symbol with the changed lines inside it
+type changedSymbol struct {
+	name  string
+	kind  protocol.SymbolKind
+	rng   protocol.Range
+	lines []git.LineRange
+}
+
+// GetRecentChanges lists the symbols under path whose lines changed since a
+// date, comparing the working tree with the last commit before it so that
+// uncommitted edits are included. since may be anything git accepts, such as
+// "3 days ago" or "2024-01-31".
+func GetRecentChanges(ctx context.Context, client *lsp.Client, workspaceDir, path, since string) (string, error) {
+	if path == "" {
+		path = workspaceDir
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workspaceDir, path)
+	}
+
+	repo, err := git.Open(ctx, workspaceDir)
+	if err != nil {
+		return "", fmt.Errorf("workspace is not a git repository: %w", err)
+	}
+	base, err := repo.BaseBefore(ctx, since)
+	if err != nil {
+		return "", err
+	}
+	changes, err := repo.ChangedLines(ctx, base, path)
+	if err != nil {
+		return "", err
+	}
+	if len(changes) == 0 {
+		return fmt.Sprintf("No changes under %s since %s", path, since), nil
+	}
+
+	paths := make([]string, 0, len(changes))
+	for file := range changes {
+		paths = append(paths, file)
+	}
+	sort.Strings(paths)
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Files changed under %s since %s: %d\n\n", path, since, len(paths))
+	symbolCache := make(map[protocol.DocumentUri][]flatSymbol)
+	now := time.Now()
+	for i, file := range paths {
+		if i == maxRecentChangeFiles {
+			fmt.Fprintf(&result, "... %d more files\n", len(paths)-maxRecentChangeFiles)
+			break
+		}
+		fmt.Fprintf(&result, "---\n\n%s\n", file)
+
+		commits, err := repo.Log(ctx, since, file, maxRecentCommits)
+		if err != nil {
+			toolsLogger.Debug("Error getting log for %s: %v", file, err)
+		}
+		for _, commit := range commits {
+			fmt.Fprintf(&result, "Commit: %s\n", describeCommit(commit, now))
+		}
+
+		symbols, outside := changedSymbols(ctx, client, file, changes[file], symbolCache)
+		if len(symbols) > 0 {
+			result.WriteString("Changed symbols:\n")
+		}
+		for _, sym := range symbols {
+			fmt.Fprintf(&result, "  %s %s (L%d-L%d): %s\n", protocol.TableKindMap[sym.kind], sym.name,
+				sym.rng.Start.Line+1, sym.rng.End.Line+1, formatLineRanges(sym.lines))
+		}
+		if len(outside) > 0 {
+			fmt.Fprintf(&result, "Changed outside any symbol: %s\n", formatLineRanges(outside))
+		}
+		result.WriteString("\n")
+	}
+	return result.String(), nil
+}
+
+// changedSymbols maps changed lines to the innermost symbols containing them,
+// in file order. Symbols come from the language server, or from text
+// heuristics for files it doesn't handle. Lines outside every symbol are
+// returned separately.
+func changedSymbols(ctx context.Context, client *lsp.Client, path string, changes []git.LineRange, cache map[protocol.DocumentUri][]flatSymbol) ([]*changedSymbol, []git.LineRange) {
+	symbols, err := documentSymbolsFlat(ctx, client, protocol.URIFromPath(path), cache)
+	if err != nil || len(symbols) == 0 {
+		toolsLogger.Debug("Using heuristic symbols for %s: %v", path, err)
+		symbols = heuristicFlatSymbols(path)
+	}
+
+	var ordered []*changedSymbol
+	byRange := make(map[protocol.Range]*changedSymbol)
+	var outside []git.LineRange
+	for _, change := range changes {
+		changeRange := protocol.Range{
+			Start: protocol.Position{Line: uint32(change.Start - 1)},
+			End:   protocol.Position{Line: uint32(change.End - 1), Character: ^uint32(0)},
+		}
+		touched := innermostOverlapping(symbols, changeRange)
+		if len(touched) == 0 {
+			outside = append(outside, change)
+			continue
+		}
+		for _, sym := range touched {
+			changed, ok := byRange[sym.rng]
+			if !ok {
+				name := sym.name
+				if sym.container != "" {
+					name = sym.container + "." + name
+				}
+				changed = &changedSymbol{name: name, kind: sym.kind, rng: sym.rng}
+				byRange[sym.rng] = changed
+				ordered = append(ordered, changed)
+			}
+			changed.lines = append(changed.lines, change)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].rng.Start.Line < ordered[j].rng.Start.Line })
+	return ordered, outside
+}
+
+// heuristicFlatSymbols extracts the declarations of a file with text heuristics
+func heuristicFlatSymbols(path string) []flatSymbol {
+	content, err := utilities.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var symbols []flatSymbol
+	for _, sym := range heuristics.ExtractSymbols(path, string(content)) {
+		start := protocol.Position{Line: uint32(sym.Line), Character: uint32(sym.Column)}
+		symbols = append(symbols, flatSymbol{
+			name:      sym.Name,
+			kind:      sym.Kind,
+			container: sym.Container,
+			rng:       protocol.Range{Start: start, End: protocol.Position{Line: uint32(sym.EndLine), Character: ^uint32(0)}},
+			selection: start,
+		})
+	}
+	return symbols
+}
+
+// formatLineRanges formats line ranges as e.g. "L3, L10-L12"
+func formatLineRanges(ranges []git.LineRange) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		if r.Start == r.End {
+			parts[i] = fmt.Sprintf("L%d", r.Start)
+		} else {
+			parts[i] = fmt.Sprintf("L%d-L%d", r.Start, r.End)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
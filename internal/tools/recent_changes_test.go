@@ -0,0 +1,31 @@
+package tools
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/git"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatLineRanges(t *testing.T) {
+	assert.Equal(t, "L3, L10-L12", formatLineRanges([]git.LineRange{{Start: 3, End: 3}, {Start: 10, End: 12}}))
+	assert.Equal(t, "", formatLineRanges(nil))
+}
+
+func TestHeuristicFlatSymbols(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"main.go": "package main\n\nfunc A() {\n\treturn\n}\n\nfunc B() {\n}\n",
+	})
+
+	symbols := heuristicFlatSymbols(filepath.Join(dir, "main.go"))
+	changed := innermostOverlapping(symbols, protocol.Range{
+		Start: protocol.Position{Line: 3},
+		End:   protocol.Position{Line: 3, Character: ^uint32(0)},
+	})
+	if assert.Len(t, changed, 1) {
+		assert.Equal(t, "A", changed[0].name)
+		assert.Equal(t, protocol.Function, changed[0].kind)
+	}
+}
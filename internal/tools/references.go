@@ -4,15 +4,24 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 )
 
-func FindReferences(ctx context.Context, client *lsp.Client, symbolName string) (string, error) {
+// FindReferences lists the references to a symbol with surrounding context.
+// If blame is set, each file also reports who last changed the referencing
+// lines and when. Files are ordered by path, or by relevance as scored by
+// the ranking weights. With a stream, large results are sent on in parts.
+func FindReferences(ctx context.Context, client *lsp.Client, symbolName string, blame bool, order string, stream ResultStream) (string, error) {
+	order, err := validOrder(order)
+	if err != nil {
+		return "", err
+	}
+
 	// Get context lines from environment variable
 	contextLines := 5
 	if envLines := os.Getenv("LSP_CONTEXT_LINES"); envLines != "" {
@@ -27,7 +36,17 @@ func FindReferences(ctx context.Context, client *lsp.Client, symbolName string)
 		return "", err
 	}
 
-	var allReferences []string
+	out := newResultWriter(stream)
+	files := 0
+	// addFile adds the references in a file, separated from the previous file
+	addFile := func(section string) {
+		if files > 0 {
+			section = "\n" + section
+		}
+		files++
+		out.write(section)
+	}
+	generatedFiles := make(map[string]bool)
 	for _, symbol := range results {
 		// Handle different matching strategies based on the search term
 		if strings.Contains(symbolName, ".") {
@@ -60,34 +79,48 @@ func FindReferences(ctx context.Context, client *lsp.Client, symbolName string)
 			},
 		}
 		// File is likely to be opened already, but may not be.
-		err := client.OpenFile(ctx, loc.URI.Path())
+		err := client.OpenFile(ctx, client.FileLocation(loc).URI.Path())
 		if err != nil {
 			toolsLogger.Error("Error opening file: %v", err)
 			continue
 		}
 		refs, err := client.References(ctx, refsParams)
 		if err != nil {
-			return "", fmt.Errorf("failed to get references: %v", err)
+			return "", fmt.Errorf("failed to get references: %w", err)
 		}
 
 		// Group references by file
 		refsByFile := make(map[protocol.DocumentUri][]protocol.Location)
 		for _, ref := range refs {
+			ref = client.FileLocation(ref)
 			refsByFile[ref.URI] = append(refsByFile[ref.URI], ref)
 		}
 
-		// Get sorted list of URIs
-		uris := make([]string, 0, len(refsByFile))
-		for uri := range refsByFile {
-			uris = append(uris, string(uri))
+		// Order the files, most relevant first unless sorted by path
+		definitionDir := client.FileLocation(loc).URI.DirPath()
+		weights := currentRankingWeights()
+		ranked := make([]rankedFile, 0, len(refsByFile))
+		for uri, fileRefs := range refsByFile {
+			file := rankedFile{path: uri.Path()}
+			if excludeGenerated() && isGeneratedFile(file.path) {
+				generatedFiles[file.path] = true
+				continue
+			}
+			if order == OrderRelevance {
+				var lines []string
+				if content, err := lsp.ReadSourceFile(file.path); err == nil {
+					lines = strings.Split(string(content), "\n")
+				}
+				file.score = referenceFileScore(file.path, definitionDir, lines, fileRefs, weights)
+			}
+			ranked = append(ranked, file)
 		}
-		sort.Strings(uris)
+		sortRankedFiles(ranked)
 
-		// Process each file's references in sorted order
-		for _, uriStr := range uris {
-			uri := protocol.DocumentUri(uriStr)
-			fileRefs := refsByFile[uri]
-			filePath := strings.TrimPrefix(uriStr, "file://")
+		// Process each file's references in order
+		for _, file := range ranked {
+			filePath := file.path
+			fileRefs := refsByFile[protocol.URIFromPath(filePath)]
 
 			// Format file header
 			fileInfo := fmt.Sprintf("---\n\n%s\nReferences in File: %d\n",
@@ -96,13 +129,14 @@ func FindReferences(ctx context.Context, client *lsp.Client, symbolName string)
 			)
 
 			// Format locations with context
-			fileContent, err := os.ReadFile(filePath)
+			fileContent, err := lsp.ReadSourceFile(filePath)
 			if err != nil {
 				// Log error but continue with other files
-				allReferences = append(allReferences, fileInfo+"\nError reading file: "+err.Error())
+				addFile(fileInfo + "\nError reading file: " + err.Error())
 				continue
 			}
 
+			omitted := heuristics.OpaqueKind(fileContent)
 			lines := strings.Split(string(fileContent), "\n")
 
 			// Track reference locations for header display
@@ -129,16 +163,41 @@ func FindReferences(ctx context.Context, client *lsp.Client, symbolName string)
 			if len(locStrings) > 0 {
 				formattedOutput += "At: " + strings.Join(locStrings, ", ") + "\n"
 			}
+			if blame && !lsp.IsNotebook(filePath) {
+				formattedOutput += referenceBlame(ctx, filePath, fileRefs)
+			}
 
-			// Format the content with ranges
-			formattedOutput += "\n" + FormatLinesWithRanges(lines, lineRanges)
-			allReferences = append(allReferences, formattedOutput)
+			// Format the content with ranges, unless the file is binary or
+			// minified
+			if omitted != "" {
+				formattedOutput += "\n" + heuristics.OmittedMarker(omitted) + "\n"
+			} else {
+				formattedOutput += "\n" + FormatLinesWithRanges(lines, lineRanges)
+			}
+			addFile(formattedOutput)
 		}
 	}
 
-	if len(allReferences) == 0 {
-		return fmt.Sprintf("No references found for symbol: %s", symbolName), nil
+	if files == 0 {
+		if len(generatedFiles) > 0 {
+			return fmt.Sprintf("No references found for symbol: %s", symbolName) + generatedNote(len(generatedFiles)), nil
+		}
+		return fmt.Sprintf("No references found for symbol: %s", symbolName) + emptyResultHints(client, referencesHint(client, symbolName, results)), nil
 	}
 
-	return strings.Join(allReferences, "\n"), nil
+	out.write(generatedNote(len(generatedFiles)))
+	return out.String(), nil
+}
+
+// referencesHint explains an empty references result: the symbol either
+// wasn't found, or was found without references
+func referencesHint(client *lsp.Client, symbolName string, results []protocol.WorkspaceSymbolResult) string {
+	for _, symbol := range results {
+		if symbol.GetName() == symbolName || strings.HasSuffix(symbolName, "."+symbol.GetName()) {
+			return fmt.Sprintf("%s was found at %s, but the language server reported no references to it. "+
+				"References from files the server hasn't loaded aren't included.",
+				symbol.GetName(), client.FileLocation(symbol.GetLocation()).URI.Path())
+		}
+	}
+	return similarSymbolsHint(symbolName, results)
 }
@@ -17,11 +17,11 @@ func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line
 	// Open the file if not already open
 	err := client.OpenFile(ctx, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", fmt.Errorf("could not open file: %w", err)
 	}
 
 	// Convert 1-indexed line/column to 0-indexed for LSP protocol
-	uri := protocol.DocumentUri("file://" + filePath)
+	uri := protocol.URIFromPath(filePath)
 	position := protocol.Position{
 		Line:      uint32(line - 1),
 		Character: uint32(column - 1),
@@ -42,7 +42,7 @@ func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line
 	// Execute the rename operation
 	workspaceEdit, err := client.Rename(ctx, params)
 	if err != nil {
-		return "", fmt.Errorf("failed to rename symbol: %v", err)
+		return "", fmt.Errorf("failed to rename symbol: %w", err)
 	}
 
 	// Count the changes that will be made
@@ -113,8 +113,8 @@ func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line
 	}
 
 	// Apply the workspace edit to files:workspaceEdit
-	if err := utilities.ApplyWorkspaceEdit(workspaceEdit); err != nil {
-		return "", fmt.Errorf("failed to apply changes: %v", err)
+	if err := utilities.ApplyWorkspaceEdit(ctx, workspaceEdit); err != nil {
+		return "", fmt.Errorf("failed to apply changes: %w", err)
 	}
 
 	if fileCount == 0 || changeCount == 0 {
@@ -0,0 +1,370 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+var goPackageClauseRegex = regexp.MustCompile(`^package\s+(\w+)`)
+
+// renamePackageDiagnosticsTimeout bounds each wait for the language server
+// to publish diagnostics of the moved files
+const renamePackageDiagnosticsTimeout = 10 * time.Second
+
+// RenamePackage moves a package or module directory and updates the code
+// that refers to it. The language server is asked for the edits a directory
+// rename needs through workspace/willRenameFiles, which covers e.g.
+// TypeScript import specifiers. For Go, import paths are rewritten and the
+// package clause is renamed with the language server so that qualified uses
+// follow. The files involved are then checked for errors.
+func RenamePackage(ctx context.Context, client *lsp.Client, workspaceDir, oldPath, newPath string) (string, error) {
+	oldDir, newDir := oldPath, newPath
+	if !filepath.IsAbs(oldDir) {
+		oldDir = filepath.Join(workspaceDir, oldDir)
+	}
+	if !filepath.IsAbs(newDir) {
+		newDir = filepath.Join(workspaceDir, newDir)
+	}
+	oldDir, newDir = filepath.Clean(oldDir), filepath.Clean(newDir)
+
+	if info, err := os.Stat(oldDir); err != nil || !info.IsDir() {
+		return "", codedErrorf(InvalidArgument, "%s is not a directory", oldDir)
+	}
+	if _, err := os.Stat(newDir); err == nil {
+		return "", codedErrorf(EditConflict, "%s already exists", newDir)
+	}
+	for _, dir := range []string{oldDir, newDir} {
+		if rel, err := filepath.Rel(workspaceDir, dir); err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			return "", codedErrorf(InvalidArgument, "%s is not inside the workspace", dir)
+		}
+	}
+	if strings.HasPrefix(newDir, oldDir+string(filepath.Separator)) {
+		return "", codedErrorf(InvalidArgument, "cannot move %s into itself", oldDir)
+	}
+
+	var movedFiles []string
+	isGo := false
+	err := filepath.WalkDir(oldDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			rel, _ := filepath.Rel(oldDir, path)
+			movedFiles = append(movedFiles, rel)
+			isGo = isGo || filepath.Ext(path) == ".go"
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list %s: %w", oldDir, err)
+	}
+	oldImportPath := goImportPath(oldDir)
+
+	var result strings.Builder
+	touched := make(map[string]bool)
+
+	// Let the server compute the edits it needs, then move the files
+	renameParams := protocol.RenameFilesParams{
+		Files: []protocol.FileRename{{OldURI: string(protocol.URIFromPath(oldDir)), NewURI: string(protocol.URIFromPath(newDir))}},
+	}
+	edit, err := client.WillRenameFiles(ctx, renameParams)
+	if err != nil {
+		toolsLogger.Debug("willRenameFiles unavailable: %v", err)
+	} else if files := workspaceEditFiles(edit); len(files) > 0 {
+		if err := utilities.ApplyWorkspaceEdit(ctx, edit); err != nil {
+			return "", fmt.Errorf("failed to apply edits from the language server: %w", err)
+		}
+		for _, file := range files {
+			touched[file] = true
+		}
+		fmt.Fprintf(&result, "Language server updated references in %d files\n", len(files))
+	}
+
+	for _, rel := range movedFiles {
+		if path := filepath.Join(oldDir, rel); client.IsFileOpen(path) {
+			if err := client.CloseFile(ctx, path); err != nil {
+				toolsLogger.Debug("Error closing %s: %v", path, err)
+			}
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(newDir), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(newDir), err)
+	}
+	if err := os.Rename(oldDir, newDir); err != nil {
+		return "", fmt.Errorf("failed to move directory: %w", err)
+	}
+	if err := client.DidRenameFiles(ctx, renameParams); err != nil {
+		toolsLogger.Debug("Error sending didRenameFiles: %v", err)
+	}
+	fmt.Fprintf(&result, "Moved %s to %s (%d files)\n", oldDir, newDir, len(movedFiles))
+
+	// Files that edits pointed at before the move now live in the new directory
+	for file := range touched {
+		if rel, err := filepath.Rel(oldDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			delete(touched, file)
+			touched[filepath.Join(newDir, rel)] = true
+		}
+	}
+	for _, rel := range movedFiles {
+		touched[filepath.Join(newDir, rel)] = true
+	}
+
+	if isGo && oldImportPath != "" {
+		newImportPath := goImportPath(newDir)
+		if newImportPath != "" && newImportPath != oldImportPath {
+			changed, err := rewriteGoImportPaths(ctx, workspaceDir, oldImportPath, newImportPath)
+			if err != nil {
+				return result.String(), fmt.Errorf("failed to rewrite imports: %w", err)
+			}
+			for _, file := range changed {
+				touched[file] = true
+			}
+			fmt.Fprintf(&result, "Rewrote imports of %s to %s in %d files\n", oldImportPath, newImportPath, len(changed))
+		}
+
+		oldName, newName := filepath.Base(oldDir), filepath.Base(newDir)
+		if oldName != newName {
+			renamed, err := renameGoPackageClause(ctx, client, newDir, oldName, newName)
+			switch {
+			case err != nil:
+				fmt.Fprintf(&result, "Package clause was not renamed: %v\n", err)
+			case len(renamed) > 0:
+				for _, file := range renamed {
+					touched[file] = true
+				}
+				fmt.Fprintf(&result, "Renamed package %s to %s in %d files\n", oldName, newName, len(renamed))
+			}
+		}
+	}
+
+	result.WriteString("\n")
+	result.WriteString(verifyFiles(ctx, client, touched))
+	return result.String(), nil
+}
+
+// goImportPath returns the import path of a Go package directory from the
+// closest go.mod above it
+func goImportPath(dir string) string {
+	for moduleDir := dir; ; moduleDir = filepath.Dir(moduleDir) {
+		if modulePath := readGoModulePath(filepath.Join(moduleDir, "go.mod")); modulePath != "" {
+			rel, err := filepath.Rel(moduleDir, dir)
+			if err != nil {
+				return ""
+			}
+			if rel == "." {
+				return modulePath
+			}
+			return modulePath + "/" + filepath.ToSlash(rel)
+		}
+		if filepath.Dir(moduleDir) == moduleDir {
+			return ""
+		}
+	}
+}
+
+// rewriteGoImportPaths replaces imports of oldPath and its subpackages with
+// newPath in every Go file of the workspace, returning the files changed.
+// The edits are applied as one workspace edit, so they are checked and
+// watched like those of the language server.
+func rewriteGoImportPaths(ctx context.Context, workspaceDir, oldPath, newPath string) ([]string, error) {
+	edit := protocol.WorkspaceEdit{Changes: make(map[protocol.DocumentUri][]protocol.TextEdit)}
+	err := heuristics.WalkSourceFiles(ctx, workspaceDir, func(path string) error {
+		if filepath.Ext(path) != ".go" {
+			return nil
+		}
+		content, err := utilities.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		lines := strings.Split(string(content), "\n")
+		var edits []protocol.TextEdit
+		for _, imp := range heuristics.ExtractImports(path, string(content)) {
+			var replacement string
+			if imp.Path == oldPath {
+				replacement = newPath
+			} else if rest, ok := strings.CutPrefix(imp.Path, oldPath+"/"); ok {
+				replacement = newPath + "/" + rest
+			} else {
+				continue
+			}
+			line := lines[imp.Line]
+			start := strings.Index(line, `"`+imp.Path+`"`)
+			if start < 0 {
+				continue
+			}
+			start++
+			end := start + len(imp.Path)
+			edits = append(edits, protocol.TextEdit{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: uint32(imp.Line), Character: uint32(utilities.UTF16Column(line, start))},
+					End:   protocol.Position{Line: uint32(imp.Line), Character: uint32(utilities.UTF16Column(line, end))},
+				},
+				NewText: replacement,
+			})
+		}
+		if len(edits) > 0 {
+			edit.Changes[protocol.URIFromPath(path)] = edits
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(edit.Changes) == 0 {
+		return nil, nil
+	}
+	if err := utilities.ApplyWorkspaceEdit(ctx, edit); err != nil {
+		return nil, err
+	}
+	return workspaceEditFiles(edit), nil
+}
+
+// renameGoPackageClause renames a package with the language server, starting
+// from the package clause of one of its files, so that qualified uses in
+// other packages are updated too. Packages whose name doesn't match their old
+// directory are left alone.
+func renameGoPackageClause(ctx context.Context, client *lsp.Client, dir, oldName, newName string) ([]string, error) {
+	if !token.IsIdentifier(newName) {
+		return nil, codedErrorf(InvalidArgument, "%s is not a valid package name", newName)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".go" || strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		content, err := utilities.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for i, line := range strings.Split(string(content), "\n") {
+			m := goPackageClauseRegex.FindStringSubmatchIndex(line)
+			if m == nil {
+				continue
+			}
+			if line[m[2]:m[3]] != oldName {
+				return nil, nil
+			}
+
+			// Give the server time to see the moved files: diagnostics of
+			// the opened file are published once it has loaded its package
+			uri := protocol.URIFromPath(path)
+			version := client.DiagnosticsVersion(uri)
+			if err := client.OpenFile(ctx, path); err != nil {
+				return nil, fmt.Errorf("could not open file: %w", err)
+			}
+			waitCtx, cancel := context.WithTimeout(ctx, renamePackageDiagnosticsTimeout)
+			if !client.WaitForDiagnostics(waitCtx, map[protocol.DocumentUri]uint64{uri: version}) {
+				toolsLogger.Debug("Timed out waiting for diagnostics of %s", path)
+			}
+			cancel()
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			edit, err := client.Rename(ctx, protocol.RenameParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(path)},
+				Position:     protocol.Position{Line: uint32(i), Character: uint32(m[2])},
+				NewName:      newName,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := utilities.ApplyWorkspaceEdit(ctx, edit); err != nil {
+				return nil, fmt.Errorf("failed to apply changes: %w", err)
+			}
+			return workspaceEditFiles(edit), nil
+		}
+	}
+	return nil, nil
+}
+
+// workspaceEditFiles lists the files a workspace edit changes or creates
+func workspaceEditFiles(edit protocol.WorkspaceEdit) []string {
+	files := make(map[string]bool)
+	for uri := range edit.Changes {
+		files[uri.Path()] = true
+	}
+	for _, change := range edit.DocumentChanges {
+		switch {
+		case change.TextDocumentEdit != nil:
+			files[change.TextDocumentEdit.TextDocument.URI.Path()] = true
+		case change.CreateFile != nil:
+			files[change.CreateFile.URI.Path()] = true
+		case change.RenameFile != nil:
+			files[change.RenameFile.NewURI.Path()] = true
+		}
+	}
+	return sortedKeys(files)
+}
+
+// verifyFiles reports the errors the language server finds in files after
+// they were changed
+func verifyFiles(ctx context.Context, client *lsp.Client, files map[string]bool) string {
+	var opened []string
+	pending := make(map[protocol.DocumentUri]uint64)
+	for file := range files {
+		if lsp.DetectLanguageID("file://"+file) == "" {
+			continue
+		}
+		uri := protocol.URIFromPath(file)
+		version := client.DiagnosticsVersion(uri)
+		// Files that were already open may hold content from before the edits
+		if client.IsFileOpen(file) {
+			if err := client.NotifyChange(ctx, file); err != nil {
+				toolsLogger.Debug("Error syncing %s: %v", file, err)
+			}
+		} else if err := client.OpenFile(ctx, file); err != nil {
+			continue
+		}
+		opened = append(opened, file)
+		pending[uri] = version
+	}
+	if len(opened) == 0 {
+		return "No source files to check\n"
+	}
+	sort.Strings(opened)
+
+	waitCtx, cancel := context.WithTimeout(ctx, renamePackageDiagnosticsTimeout)
+	if !client.WaitForDiagnostics(waitCtx, pending) {
+		toolsLogger.Debug("Timed out waiting for diagnostics of renamed package files")
+	}
+	cancel()
+
+	errors := make(map[protocol.DocumentUri][]protocol.Diagnostic)
+	count := 0
+	for _, file := range opened {
+		uri := protocol.URIFromPath(file)
+		if _, err := client.Diagnostic(ctx, protocol.DocumentDiagnosticParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		}); err != nil {
+			toolsLogger.Debug("Failed to get diagnostics for %s: %v", file, err)
+		}
+		for _, diag := range client.GetFileDiagnostics(uri) {
+			if diag.Severity == protocol.SeverityError {
+				errors[uri] = append(errors[uri], diag)
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return fmt.Sprintf("Checked %d files: no errors\n", len(opened))
+	}
+	return fmt.Sprintf("Checked %d files: %d errors\n\n", len(opened), count) + formatDiagnosticsByFile(errors)
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoImportPath(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"go.mod":                "module example.com/app\n",
+		"internal/store/a.go":   "package store\n",
+		"tools/go.mod":          "module example.com/tools\n",
+		"tools/cmd/gen/main.go": "package main\n",
+	})
+
+	assert.Equal(t, "example.com/app", goImportPath(dir))
+	assert.Equal(t, "example.com/app/internal/store", goImportPath(filepath.Join(dir, "internal", "store")))
+	assert.Equal(t, "example.com/tools/cmd/gen", goImportPath(filepath.Join(dir, "tools", "cmd", "gen")))
+}
+
+func TestRewriteGoImportPaths(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"go.mod": "module example.com/app\n",
+		"main.go": "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/store\"\n\tcache \"example.com/app/store/cache\"\n" +
+			"\t\"example.com/app/storefront\"\n)\n",
+		"other.go": "package main\n\nimport \"example.com/app/store\"\n",
+		"util.go":  "package main\n\nimport \"strings\"\n",
+	})
+
+	// The rewrite is a workspace edit, which post-edit hooks and validation
+	// see
+	var watched []string
+	ctx := utilities.WatchEdits(context.Background(), func(path string) { watched = append(watched, path) })
+	changed, err := rewriteGoImportPaths(ctx, dir, "example.com/app/store", "example.com/app/internal/db")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{filepath.Join(dir, "main.go"), filepath.Join(dir, "other.go")}, changed)
+	assert.ElementsMatch(t, changed, watched)
+
+	content, err := os.ReadFile(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/internal/db\"\n\tcache \"example.com/app/internal/db/cache\"\n"+
+		"\t\"example.com/app/storefront\"\n)\n", string(content))
+
+	content, err = os.ReadFile(filepath.Join(dir, "other.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nimport \"example.com/app/internal/db\"\n", string(content))
+}
+
+func TestRewriteGoImportPathsLineEndings(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"go.mod":  "module example.com/app\n",
+		"main.go": "package main\r\n\r\nimport \"example.com/app/store\"\r\n",
+	})
+
+	_, err := rewriteGoImportPaths(context.Background(), dir, "example.com/app/store", "example.com/app/db")
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\r\n\r\nimport \"example.com/app/db\"\r\n", string(content))
+
+	saved := utilities.LineEnding
+	utilities.LineEnding = "\n"
+	defer func() { utilities.LineEnding = saved }()
+	_, err = rewriteGoImportPaths(context.Background(), dir, "example.com/app/db", "example.com/app/store")
+	require.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nimport \"example.com/app/store\"\n", string(content))
+}
+
+func TestWorkspaceEditFiles(t *testing.T) {
+	edit := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			"file:///ws/b.ts": {{NewText: "x"}},
+		},
+		DocumentChanges: []protocol.DocumentChange{
+			{TextDocumentEdit: &protocol.TextDocumentEdit{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: "file:///ws/a.ts"},
+				},
+			}},
+			{RenameFile: &protocol.RenameFile{OldURI: "file:///ws/old.ts", NewURI: "file:///ws/new.ts"}},
+		},
+	}
+	assert.Equal(t, []string{"/ws/a.ts", "/ws/b.ts", "/ws/new.ts"}, workspaceEditFiles(edit))
+}
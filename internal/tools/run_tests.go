@@ -0,0 +1,24 @@
+package tools
+
+import (
+	"context"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/runner"
+)
+
+// RunTests runs the project's test command for target in the workspace and
+// reports its exit status and output. commandTemplate is the configured test
+// command; see runner.TestCommand.
+func RunTests(ctx context.Context, workspaceDir, commandTemplate, target string, timeout time.Duration) (string, error) {
+	args, err := runner.TestCommand(workspaceDir, commandTemplate, target)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := runner.Run(ctx, workspaceDir, args, timeout, runner.DefaultMaxOutput)
+	if err != nil {
+		return "", err
+	}
+	return result.Format(), nil
+}
@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// expandedMacro is rust-analyzer's answer to rust-analyzer/expandMacro
+type expandedMacro struct {
+	Name      string `json:"name"`
+	Expansion string `json:"expansion"`
+}
+
+// ExpandMacro shows what the macro call at a position expands to, with
+// rust-analyzer's rust-analyzer/expandMacro extension. Macro calls in the
+// expansion are expanded too.
+func ExpandMacro(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
+	params, err := rustPositionParams(ctx, client, filePath, line, column)
+	if err != nil {
+		return "", err
+	}
+	var expanded *expandedMacro
+	if err := client.Call(ctx, "rust-analyzer/expandMacro", params, &expanded); err != nil {
+		return "", fmt.Errorf("failed to expand macro: %w", err)
+	}
+	if expanded == nil {
+		return "", codedErrorf(SymbolNotFound, "no macro call at %s:%d:%d", filePath, line, column)
+	}
+	return fmt.Sprintf("Macro: %s! at %s:%d:%d\nExpansion:\n%s\n", expanded.Name, filePath, line, column, strings.TrimRight(expanded.Expansion, "\n")), nil
+}
+
+// ViewSyntaxTree shows the syntax tree rust-analyzer parsed a file into,
+// with its rust-analyzer/viewSyntaxTree extension. Recent versions answer
+// with the tree as JSON, which is indented; older ones with text.
+func ViewSyntaxTree(ctx context.Context, client *lsp.Client, filePath string) (string, error) {
+	if err := client.OpenFile(ctx, filePath); err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+	serverLocation, _ := client.ServerLocation(protocol.Location{URI: protocol.URIFromPath(filePath)})
+	params := map[string]any{"textDocument": protocol.TextDocumentIdentifier{URI: serverLocation.URI}}
+	var tree string
+	if err := client.Call(ctx, "rust-analyzer/viewSyntaxTree", params, &tree); err != nil {
+		return "", fmt.Errorf("failed to view syntax tree: %w", err)
+	}
+	var indented bytes.Buffer
+	if json.Indent(&indented, []byte(tree), "", "  ") == nil {
+		tree = indented.String()
+	}
+	return fmt.Sprintf("Syntax tree of %s\n%s\n", filePath, strings.TrimRight(tree, "\n")), nil
+}
+
+// irMethods are the rust-analyzer extensions that show the intermediate
+// representations of the function at a position
+var irMethods = map[string]string{
+	"hir": "rust-analyzer/viewHir",
+	"mir": "rust-analyzer/viewMir",
+}
+
+// ViewIR shows the HIR or MIR rust-analyzer lowers the function at a
+// position to, with its rust-analyzer/viewHir and rust-analyzer/viewMir
+// extensions
+func ViewIR(ctx context.Context, client *lsp.Client, filePath string, line, column int, kind string) (string, error) {
+	method, ok := irMethods[kind]
+	if !ok {
+		return "", codedErrorf(InvalidArgument, "kind must be hir or mir, not %q", kind)
+	}
+	params, err := rustPositionParams(ctx, client, filePath, line, column)
+	if err != nil {
+		return "", err
+	}
+	var ir string
+	if err := client.Call(ctx, method, params, &ir); err != nil {
+		return "", fmt.Errorf("failed to view %s: %w", strings.ToUpper(kind), err)
+	}
+	return fmt.Sprintf("%s at %s:%d:%d\n%s\n", strings.ToUpper(kind), filePath, line, column, strings.TrimRight(ir, "\n")), nil
+}
+
+// rustPositionParams opens a file and converts a 1-indexed position in it
+// to the parameters of rust-analyzer's position based extensions
+func rustPositionParams(ctx context.Context, client *lsp.Client, filePath string, line, column int) (protocol.TextDocumentPositionParams, error) {
+	if line < 1 || column < 1 {
+		return protocol.TextDocumentPositionParams{}, codedErrorf(PositionInvalid, "line and column must be at least 1")
+	}
+	if err := client.OpenFile(ctx, filePath); err != nil {
+		return protocol.TextDocumentPositionParams{}, fmt.Errorf("could not open file: %w", err)
+	}
+	position := protocol.Position{Line: uint32(line - 1), Character: uint32(column - 1)}
+	serverLocation, _ := client.ServerLocation(protocol.Location{
+		URI:   protocol.URIFromPath(filePath),
+		Range: protocol.Range{Start: position, End: position},
+	})
+	return protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: serverLocation.URI},
+		Position:     serverLocation.Range.Start,
+	}, nil
+}
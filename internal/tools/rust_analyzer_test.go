@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRustAnalyzerExtensions(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"src/main.rs": "fn main() {\n    let v = vec![1, 2];\n}\n",
+	})
+	path := filepath.Join(dir, "src/main.rs")
+	ctx := context.Background()
+
+	server := lsptest.NewServer(protocol.ServerCapabilities{})
+	server.Handle("rust-analyzer/expandMacro", func(params json.RawMessage) (any, error) {
+		var position protocol.TextDocumentPositionParams
+		require.NoError(t, json.Unmarshal(params, &position))
+		if position.Position != (protocol.Position{Line: 1, Character: 12}) {
+			return nil, nil
+		}
+		return expandedMacro{Name: "vec", Expansion: "<[_]>::into_vec(\n    Box::new([1, 2]),\n)"}, nil
+	})
+	server.Respond("rust-analyzer/viewSyntaxTree", `{"kind":"SOURCE_FILE","start":[0,0,0],"children":[]}`)
+	server.Respond("rust-analyzer/viewHir", "fn main() {\n    let v = <[_]>::into_vec(..);\n}\n")
+	client := lsptest.Start(t, server, dir)
+
+	t.Run("expand macro", func(t *testing.T) {
+		result, err := ExpandMacro(ctx, client, path, 2, 13)
+		require.NoError(t, err)
+		assert.Equal(t, "Macro: vec! at "+path+":2:13\nExpansion:\n<[_]>::into_vec(\n    Box::new([1, 2]),\n)\n", result)
+	})
+
+	t.Run("no macro", func(t *testing.T) {
+		_, err := ExpandMacro(ctx, client, path, 1, 1)
+		assert.Equal(t, SymbolNotFound, ErrorCodeOf(err))
+	})
+
+	t.Run("invalid position", func(t *testing.T) {
+		_, err := ExpandMacro(ctx, client, path, 0, 1)
+		assert.Equal(t, PositionInvalid, ErrorCodeOf(err))
+	})
+
+	t.Run("syntax tree", func(t *testing.T) {
+		result, err := ViewSyntaxTree(ctx, client, path)
+		require.NoError(t, err)
+		assert.Contains(t, result, "Syntax tree of "+path+"\n{\n  \"kind\": \"SOURCE_FILE\",")
+	})
+
+	t.Run("hir", func(t *testing.T) {
+		result, err := ViewIR(ctx, client, path, 1, 4, "hir")
+		require.NoError(t, err)
+		assert.Equal(t, "HIR at "+path+":1:4\nfn main() {\n    let v = <[_]>::into_vec(..);\n}\n", result)
+	})
+
+	t.Run("unknown representation", func(t *testing.T) {
+		_, err := ViewIR(ctx, client, path, 1, 4, "llvm")
+		assert.Equal(t, InvalidArgument, ErrorCodeOf(err))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := ViewIR(ctx, client, path, 1, 4, "mir")
+		assert.Equal(t, UnsupportedCapability, ErrorCodeOf(err))
+	})
+}
@@ -0,0 +1,237 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// DefaultSemanticResults is how many results semantic_search returns unless
+// asked for another number
+const DefaultSemanticResults = 10
+
+// maxSemanticQueryWords limits the words of a query looked up as symbol names
+const maxSemanticQueryWords = 5
+
+// semanticExcerptLines is how much of a result's text is shown
+const semanticExcerptLines = 6
+
+// SemanticResult is a piece of code an embeddings index matched to a query
+type SemanticResult struct {
+	Path   string `json:"path"`
+	Symbol string `json:"symbol,omitempty"`
+	// Lines are 1-indexed and inclusive
+	StartLine int     `json:"startLine"`
+	EndLine   int     `json:"endLine"`
+	Score     float64 `json:"score"`
+	Text      string  `json:"text,omitempty"`
+}
+
+// SemanticSearcher finds code by meaning rather than by name, usually with
+// an index of embeddings built from the chunks subcommand's output
+type SemanticSearcher interface {
+	Search(ctx context.Context, query string, limit int) ([]SemanticResult, error)
+}
+
+// HTTPSemanticSearcher queries an embeddings index over HTTP. The query is
+// posted as {"query": "...", "limit": 10} and the endpoint answers with
+// {"results": [...]}, each result having the fields of SemanticResult.
+type HTTPSemanticSearcher struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewHTTPSemanticSearcher creates a searcher for the endpoint
+func NewHTTPSemanticSearcher(endpoint string) *HTTPSemanticSearcher {
+	return &HTTPSemanticSearcher{endpoint: endpoint, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Search implements SemanticSearcher
+func (s *HTTPSemanticSearcher) Search(ctx context.Context, query string, limit int) ([]SemanticResult, error) {
+	body, err := json.Marshal(map[string]any{"query": query, "limit": limit})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the embeddings index: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("the embeddings index answered %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	var response struct {
+		Results []SemanticResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode the embeddings index's answer: %w", err)
+	}
+	return response.Results, nil
+}
+
+// semanticStopWords are query words too common to look up as symbol names
+var semanticStopWords = map[string]bool{
+	"about": true, "does": true, "from": true, "have": true, "into": true, "that": true, "them": true,
+	"then": true, "there": true, "this": true, "what": true, "when": true, "where": true, "which": true,
+	"with": true, "code": true, "function": true, "functions": true,
+}
+
+// SemanticSearch finds code related to a query with the searcher and merges
+// the results with the workspace symbols named like the words of the query.
+// Results both agree on are marked, and symbols the searcher missed are
+// listed after its results.
+func SemanticSearch(ctx context.Context, client *lsp.Client, searcher SemanticSearcher, query string, limit int) (string, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", codedErrorf(InvalidArgument, "query must not be empty")
+	}
+	if limit < 1 {
+		return "", codedErrorf(InvalidArgument, "limit must be at least 1")
+	}
+
+	results, err := searcher.Search(ctx, query, limit)
+	if err != nil {
+		return "", err
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	symbols := semanticQuerySymbols(ctx, client, query)
+	workspaceDir := client.WorkspaceDir()
+	matched := make([][]string, len(results))
+	var unmatched []protocol.WorkspaceSymbolResult
+	for _, symbol := range symbols {
+		loc := symbol.GetLocation()
+		found := false
+		for i, result := range results {
+			path := result.Path
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(workspaceDir, path)
+			}
+			line := int(loc.Range.Start.Line) + 1
+			if loc.URI.Path() == path && line >= result.StartLine && line <= result.EndLine {
+				matched[i] = append(matched[i], symbol.GetName())
+				found = true
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, symbol)
+		}
+	}
+
+	var output strings.Builder
+	if len(results) == 0 {
+		fmt.Fprintf(&output, "The embeddings index found nothing for %q\n", query)
+	}
+	for i, result := range results {
+		location := fmt.Sprintf("%s:%d-%d", result.Path, result.StartLine, result.EndLine)
+		if result.Symbol != "" {
+			location += " " + result.Symbol
+		}
+		fmt.Fprintf(&output, "%d. %s (score %.2f)", i+1, location, result.Score)
+		if len(matched[i]) > 0 {
+			fmt.Fprintf(&output, ", also named like the query: %s", strings.Join(matched[i], ", "))
+		}
+		output.WriteString("\n")
+		if result.Text != "" {
+			lines := strings.Split(strings.TrimRight(result.Text, "\n"), "\n")
+			if len(lines) > semanticExcerptLines {
+				lines = append(lines[:semanticExcerptLines], "...")
+			}
+			for _, line := range lines {
+				output.WriteString("   " + line + "\n")
+			}
+		}
+	}
+
+	if len(unmatched) > 0 {
+		output.WriteString("\nWorkspace symbols named like words of the query:\n")
+		for _, symbol := range unmatched {
+			loc := symbol.GetLocation()
+			path := loc.URI.Path()
+			if rel, err := filepath.Rel(workspaceDir, path); err == nil && workspaceDir != "" {
+				path = rel
+			}
+			fmt.Fprintf(&output, "- %s %s at %s:%d\n", protocol.TableKindMap[workspaceSymbolKind(symbol)], symbol.GetName(), path, loc.Range.Start.Line+1)
+		}
+	}
+	return output.String(), nil
+}
+
+// semanticQueryWords returns the words of a query worth looking up as
+// symbol names
+func semanticQueryWords(query string) []string {
+	var words []string
+	seen := make(map[string]bool)
+	for _, word := range identifierPattern.FindAllString(query, -1) {
+		lower := strings.ToLower(word)
+		if len(word) < 4 || semanticStopWords[lower] || seen[lower] {
+			continue
+		}
+		seen[lower] = true
+		words = append(words, word)
+		if len(words) == maxSemanticQueryWords {
+			break
+		}
+	}
+	return words
+}
+
+// semanticQuerySymbols looks up the words of a query as symbol names. Only
+// symbols in the workspace are kept, a few for each word.
+func semanticQuerySymbols(ctx context.Context, client *lsp.Client, query string) []protocol.WorkspaceSymbolResult {
+	workspaceDir := client.WorkspaceDir()
+	var symbols []protocol.WorkspaceSymbolResult
+	found := make(map[protocol.Location]bool)
+	for _, word := range semanticQueryWords(query) {
+		results, err := doQuerySymbol(ctx, client, word)
+		if err != nil {
+			toolsLogger.Debug("Symbol search for %q failed: %v", word, err)
+			continue
+		}
+		kept := 0
+		for _, symbol := range results {
+			loc := symbol.GetLocation()
+			if found[loc] || (workspaceDir != "" && outsideWorkspace(workspaceDir, loc.URI.Path())) {
+				continue
+			}
+			found[loc] = true
+			symbols = append(symbols, symbol)
+			if kept++; kept == maxHintNames {
+				break
+			}
+		}
+	}
+	return symbols
+}
+
+// workspaceSymbolKind returns the kind of a workspace symbol result
+func workspaceSymbolKind(symbol protocol.WorkspaceSymbolResult) protocol.SymbolKind {
+	switch v := symbol.(type) {
+	case *protocol.WorkspaceSymbol:
+		return v.Kind
+	case *protocol.SymbolInformation:
+		return v.Kind
+	}
+	return 0
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPSemanticSearcher(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		_, _ = w.Write([]byte(`{"results": [{"path": "client/retry.go", "symbol": "withRetry", "startLine": 12, "endLine": 30, "score": 0.83, "text": "func withRetry() {}"}]}`))
+	}))
+	defer server.Close()
+
+	results, err := NewHTTPSemanticSearcher(server.URL).Search(context.Background(), "retry failed requests", 5)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"query": "retry failed requests", "limit": float64(5)}, received)
+	assert.Equal(t, []SemanticResult{{
+		Path:      "client/retry.go",
+		Symbol:    "withRetry",
+		StartLine: 12,
+		EndLine:   30,
+		Score:     0.83,
+		Text:      "func withRetry() {}",
+	}}, results)
+}
+
+func TestHTTPSemanticSearcherError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "index not built", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := NewHTTPSemanticSearcher(server.URL).Search(context.Background(), "query", 5)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+	assert.Contains(t, err.Error(), "index not built")
+}
+
+func TestSemanticQueryWords(t *testing.T) {
+	assert.Equal(t, []string{"failed", "HTTP", "requests", "retried"},
+		semanticQueryWords("where are failed HTTP requests retried? where, HTTP"))
+	assert.Empty(t, semanticQueryWords("what does this do"))
+}
@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// streamThreshold is how much of a result is collected before it is sent
+// on as a part
+const streamThreshold = 64 * 1024
+
+// ResultStream receives the parts of a large result in order, as they are
+// produced, so that the whole result is never held in memory. An error
+// means no more parts can be sent.
+type ResultStream func(part string) error
+
+// resultWriter collects a result from self-contained sections, such as
+// the references in one file. With a stream, what has been collected is
+// sent once it grows past streamThreshold, so sections are never split.
+type resultWriter struct {
+	stream ResultStream
+	out    strings.Builder
+	// parts counts the parts sent to the stream
+	parts int
+}
+
+func newResultWriter(stream ResultStream) *resultWriter {
+	return &resultWriter{stream: stream}
+}
+
+// write adds a section to the result
+func (w *resultWriter) write(section string) {
+	w.out.WriteString(section)
+	if w.stream == nil || w.out.Len() < streamThreshold {
+		return
+	}
+	if err := w.stream(w.out.String()); err != nil {
+		// Keep what can't be sent for the final result
+		toolsLogger.Warn("Streaming stopped, collecting the rest of the result: %v", err)
+		w.stream = nil
+		return
+	}
+	w.parts++
+	w.out.Reset()
+}
+
+// printf adds a formatted section to the result
+func (w *resultWriter) printf(format string, args ...any) {
+	w.write(fmt.Sprintf(format, args...))
+}
+
+// String returns the part of the result that wasn't streamed, saying how
+// many parts were
+func (w *resultWriter) String() string {
+	if w.parts == 0 {
+		return w.out.String()
+	}
+	return fmt.Sprintf("[The first %d parts of this result were sent as progress notifications. This is the last part.]\n\n", w.parts) + w.out.String()
+}
@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultWriter(t *testing.T) {
+	section := strings.Repeat("x", streamThreshold/2) + "\n"
+
+	out := newResultWriter(nil)
+	out.write(section)
+	out.write(section)
+	out.write(section)
+	assert.Equal(t, strings.Repeat(section, 3), out.String())
+
+	var parts []string
+	out = newResultWriter(func(part string) error {
+		parts = append(parts, part)
+		return nil
+	})
+	for range 5 {
+		out.write(section)
+	}
+	out.write("end\n")
+	require.Len(t, parts, 2)
+	assert.Equal(t, section+section, parts[0])
+	assert.Equal(t, section+section, parts[1])
+	assert.Equal(t, "[The first 2 parts of this result were sent as progress notifications. This is the last part.]\n\n"+section+"end\n", out.String())
+
+	// Once sending fails, the rest is collected
+	calls := 0
+	out = newResultWriter(func(part string) error {
+		calls++
+		return errors.New("client went away")
+	})
+	for range 4 {
+		out.write(section)
+	}
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, strings.Repeat(section, 4), out.String())
+}
+
+func TestSearchWorkspaceStream(t *testing.T) {
+	dir := t.TempDir()
+	line := "needle " + strings.Repeat("x", 200) + "\n"
+	for i := range 30 {
+		content := strings.Repeat(line, 20)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%02d.txt", i)), []byte(content), 0o644))
+	}
+
+	text, err := SearchWorkspace(context.Background(), dir, "needle", false, nil)
+	require.NoError(t, err)
+	assert.Contains(t, text, "Results truncated to 200 matches")
+	var paths []string
+	for _, section := range strings.Split(text, "---\n\n")[1:] {
+		paths = append(paths, section[:strings.Index(section, "\n")])
+	}
+	assert.True(t, sort.StringsAreSorted(paths), "unstreamed results are sorted by path: %v", paths)
+
+	var parts []string
+	text, err = SearchWorkspace(context.Background(), dir, "needle", false, func(part string) error {
+		parts = append(parts, part)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, parts)
+	assert.NotContains(t, text, "Results truncated")
+	all := strings.Join(parts, "") + text
+	assert.Equal(t, 30, strings.Count(all, "Matches in File: 20"))
+	for i := range 30 {
+		assert.Contains(t, all, filepath.Join(dir, fmt.Sprintf("f%02d.txt", i)))
+	}
+}
+
+func TestSearchWorkspaceOmitsMinifiedFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.js"), []byte("const needle = 1\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.min.js"), []byte(strings.Repeat("var needle=1;", 1000)), 0o644))
+
+	text, err := SearchWorkspace(context.Background(), dir, "needle", false, nil)
+	require.NoError(t, err)
+	assert.Contains(t, text, "const needle = 1")
+	assert.Contains(t, text, filepath.Join(dir, "app.min.js")+"\nFirst match at: L1:C5\n[minified file, content not shown]")
+	assert.NotContains(t, text, "var needle=1;")
+}
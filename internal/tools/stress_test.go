@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/workspacegen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFallbackStress runs the fallback tools on a workspace of 5000 files and
+// checks that their results stay bounded
+func TestFallbackStress(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generates a large workspace")
+	}
+	w, err := workspacegen.Generate(t.TempDir(), workspacegen.Spec{
+		Packages:         50,
+		FilesPerPackage:  100,
+		FunctionsPerFile: 10,
+		BodyLines:        5,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var before runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	t.Run("search truncates", func(t *testing.T) {
+		text, err := SearchWorkspace(ctx, w.Dir, "Shared(n)", false, nil)
+		require.NoError(t, err)
+		assert.Contains(t, text, fmt.Sprintf("Results truncated to %d matches.", maxFallbackSearchResults))
+		assert.LessOrEqual(t, strings.Count(text, "Shared(n)"), maxFallbackSearchResults)
+	})
+
+	t.Run("references truncate", func(t *testing.T) {
+		text, err := FallbackFindReferences(ctx, w.Dir, w.Shared)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, strings.Count(text, "shared.Shared("), maxFallbackSearchResults)
+	})
+
+	t.Run("cross-package references", func(t *testing.T) {
+		name := workspacegen.FunctionName(10, 20, 3)
+		text, err := FallbackFindReferences(ctx, w.Dir, name)
+		require.NoError(t, err)
+		// The declaration and the calls from the next two packages
+		assert.Equal(t, 1+2, strings.Count(text, name+"("), text)
+	})
+
+	t.Run("search streams", func(t *testing.T) {
+		var parts int
+		stream := ResultStream(func(part string) error {
+			parts++
+			return nil
+		})
+		text, err := SearchWorkspace(ctx, w.Dir, "Shared(n)", false, stream)
+		require.NoError(t, err)
+		assert.Contains(t, text, fmt.Sprintf("Results truncated to %d matches.", maxStreamedSearchResults))
+		assert.Greater(t, parts, 0)
+	})
+
+	// Results are bounded, so the heap shouldn't grow with the workspace
+	var after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	assert.Less(t, int64(after.HeapAlloc)-int64(before.HeapAlloc), int64(64<<20))
+}
@@ -0,0 +1,289 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// maxPackageSymbols limits the symbols summarized for one package, since
+// each costs a hover request
+const maxPackageSymbols = 200
+
+// maxSummaryLength is the longest signature or doc summary shown
+const maxSummaryLength = 160
+
+// packageAPIKinds are the declarations that make up a package's API
+var packageAPIKinds = map[protocol.SymbolKind]bool{
+	protocol.Function:    true,
+	protocol.Method:      true,
+	protocol.Constructor: true,
+	protocol.Class:       true,
+	protocol.Struct:      true,
+	protocol.Interface:   true,
+	protocol.Enum:        true,
+	protocol.Constant:    true,
+	protocol.Variable:    true,
+}
+
+// packageContainerKinds hold members that are part of the API, unlike
+// functions whose children are locals
+var packageContainerKinds = map[protocol.SymbolKind]bool{
+	protocol.Class:     true,
+	protocol.Struct:    true,
+	protocol.Interface: true,
+	protocol.Enum:      true,
+	protocol.Object:    true,
+	protocol.Module:    true,
+	protocol.Namespace: true,
+}
+
+// apiSymbol is a declaration in a package's API
+type apiSymbol struct {
+	name      string
+	kind      protocol.SymbolKind
+	container string
+	selection protocol.Position
+	// depth is 0 for top-level declarations
+	depth int
+}
+
+// SummarizePackage lists the exported declarations of a directory, or of a
+// single file, with one-line signatures and the first sentence of their
+// documentation, from document symbols and hover. What counts as exported
+// follows the language: capitalized names in Go, "export" in TypeScript and
+// JavaScript, "pub" in Rust, "public" in Java and C#, and names without a
+// leading underscore elsewhere. Test files are left out.
+func SummarizePackage(ctx context.Context, client *lsp.Client, workspaceDir, path string) (string, error) {
+	if path == "" {
+		path = workspaceDir
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workspaceDir, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", codedErrorf(InvalidArgument, "invalid path: %v", err)
+	}
+
+	var files []string
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		for _, entry := range entries {
+			file := filepath.Join(path, entry.Name())
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || isTestFile(file) || heuristics.IsStructured(file) ||
+				lsp.DetectLanguageID("file://"+file) == "" {
+				continue
+			}
+			files = append(files, file)
+		}
+	} else {
+		files = []string{path}
+	}
+	if len(files) == 0 {
+		return fmt.Sprintf("No source files in %s", path), nil
+	}
+
+	var out strings.Builder
+	total, shown := 0, 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		symbols, lines, err := exportedSymbols(ctx, client, file)
+		if err != nil {
+			toolsLogger.Warn("Skipping %s: %v", file, err)
+			continue
+		}
+		total += len(symbols)
+		if len(symbols) == 0 || shown >= maxPackageSymbols {
+			continue
+		}
+
+		rel, err := filepath.Rel(workspaceDir, file)
+		if err != nil {
+			rel = file
+		}
+		fmt.Fprintf(&out, "\n%s\n", rel)
+		uri := protocol.URIFromPath(file)
+		for _, sym := range symbols {
+			if shown == maxPackageSymbols {
+				break
+			}
+			shown++
+			var doc symbolDocumentation
+			doc.addHover(hoverText(ctx, client, protocol.Location{URI: uri, Range: protocol.Range{Start: sym.selection, End: sym.selection}}))
+			signature := oneLineSignature(doc.signature)
+			if signature == "" && int(sym.selection.Line) < len(lines) {
+				signature = oneLineSignature(lines[sym.selection.Line])
+			}
+			if signature == "" {
+				signature = fmt.Sprintf("%s %s", strings.ToLower(protocol.TableKindMap[sym.kind]), sym.name)
+			}
+			fmt.Fprintf(&out, "%s- %s", strings.Repeat("  ", sym.depth), signature)
+			if summary := firstSentence(doc.documentation); summary != "" {
+				fmt.Fprintf(&out, " // %s", summary)
+			}
+			out.WriteString("\n")
+		}
+	}
+
+	rel, err := filepath.Rel(workspaceDir, path)
+	if err != nil {
+		rel = path
+	}
+	if total == 0 {
+		return fmt.Sprintf("No exported declarations found in %s (%d files)", rel, len(files)), nil
+	}
+	header := fmt.Sprintf("%s: %d exported declarations in %d files", rel, total, len(files))
+	if shown < total {
+		header += fmt.Sprintf(", the first %d shown", shown)
+	}
+	return header + "\n" + out.String(), nil
+}
+
+// exportedSymbols returns the exported declarations of a file in order,
+// members after their type, along with the file's lines
+func exportedSymbols(ctx context.Context, client *lsp.Client, file string) ([]apiSymbol, []string, error) {
+	if err := client.OpenFile(ctx, file); err != nil {
+		return nil, nil, fmt.Errorf("could not open file: %w", err)
+	}
+	content, err := lsp.ReadSourceFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines := strings.Split(string(content), "\n")
+	uri := protocol.URIFromPath(file)
+	symResult, err := client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get document symbols: %w", err)
+	}
+	results, err := symResult.Results()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to process document symbols: %w", err)
+	}
+
+	language := lsp.DetectLanguageID(string(uri))
+	// The line with the name holds the modifiers; ranges may start at doc
+	// comments or attributes above it
+	declaration := func(selection protocol.Position) string {
+		if int(selection.Line) < len(lines) {
+			return lines[selection.Line]
+		}
+		return ""
+	}
+
+	var symbols []apiSymbol
+	var walk func(results []protocol.DocumentSymbolResult, container string, depth int)
+	walk = func(results []protocol.DocumentSymbolResult, container string, depth int) {
+		for _, result := range results {
+			switch v := result.(type) {
+			case *protocol.DocumentSymbol:
+				exported := isExported(language, v.Name, declaration(v.SelectionRange.Start), container != "")
+				if exported && packageAPIKinds[v.Kind] {
+					symbols = append(symbols, apiSymbol{v.Name, v.Kind, container, v.SelectionRange.Start, depth})
+				}
+				if exported && packageContainerKinds[v.Kind] {
+					children := make([]protocol.DocumentSymbolResult, len(v.Children))
+					for i := range v.Children {
+						children[i] = &v.Children[i]
+					}
+					walk(children, v.Name, depth+1)
+				}
+			case *protocol.SymbolInformation:
+				// Flat symbols don't say whether their container is a type,
+				// so only methods are taken from inside one
+				if v.ContainerName != "" && v.Kind != protocol.Method && v.Kind != protocol.Constructor {
+					continue
+				}
+				selection := v.Location.Range.Start
+				if loc, err := GetExactSymbolLocation(v); err == nil {
+					selection = loc.Range.Start
+				}
+				if packageAPIKinds[v.Kind] && isExported(language, v.Name, declaration(selection), v.ContainerName != "") {
+					depth := 0
+					if v.ContainerName != "" {
+						depth = 1
+					}
+					symbols = append(symbols, apiSymbol{v.Name, v.Kind, v.ContainerName, selection, depth})
+				}
+			}
+		}
+	}
+	walk(results, "", 0)
+
+	return symbols, lines, nil
+}
+
+// isExported reports whether a declaration is visible outside its package
+// according to the conventions of its language. member is set for
+// declarations inside a type.
+func isExported(language protocol.LanguageKind, name, declaration string, member bool) bool {
+	declaration = strings.TrimSpace(declaration)
+	switch language {
+	case protocol.LangGo:
+		// Methods are named like "(*Store).Add" by gopls
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			receiver := strings.Trim(name[:i], "(*)")
+			if i := strings.Index(receiver, "["); i >= 0 {
+				receiver = receiver[:i]
+			}
+			return isCapitalized(receiver) && isCapitalized(name[i+1:])
+		}
+		return isCapitalized(name)
+	case protocol.LangTypeScript, protocol.LangTypeScriptReact, protocol.LangJavaScript, protocol.LangJavaScriptReact:
+		if member {
+			return !strings.HasPrefix(name, "#") && !strings.HasPrefix(declaration, "private ") && !strings.HasPrefix(declaration, "protected ")
+		}
+		return strings.HasPrefix(declaration, "export ")
+	case protocol.LangRust:
+		// The methods of impl blocks are checked on their own
+		return strings.HasPrefix(declaration, "pub ") || strings.HasPrefix(declaration, "pub(") || strings.HasPrefix(declaration, "impl")
+	case protocol.LangJava, protocol.LangCSharp:
+		return strings.Contains(" "+declaration, " public ")
+	}
+	return !strings.HasPrefix(name, "_")
+}
+
+func isCapitalized(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+// oneLineSignature collapses a signature to its first line, dropping the
+// body of types
+func oneLineSignature(signature string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(signature), "\n")
+	line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "{"))
+	line = strings.Join(strings.Fields(line), " ")
+	if len(line) > maxSummaryLength {
+		line = line[:maxSummaryLength] + "..."
+	}
+	return line
+}
+
+// firstSentence returns the first sentence of a documentation text
+func firstSentence(documentation string) string {
+	paragraph, _, _ := strings.Cut(strings.TrimSpace(documentation), "\n\n")
+	paragraph = strings.Join(strings.Fields(paragraph), " ")
+	if i := strings.Index(paragraph, ". "); i >= 0 {
+		paragraph = paragraph[:i+1]
+	}
+	if len(paragraph) > maxSummaryLength {
+		paragraph = paragraph[:maxSummaryLength] + "..."
+	}
+	return paragraph
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsExported(t *testing.T) {
+	tests := []struct {
+		language    protocol.LanguageKind
+		name        string
+		declaration string
+		member      bool
+		want        bool
+	}{
+		{protocol.LangGo, "Store", "type Store struct {", false, true},
+		{protocol.LangGo, "store", "type store struct {", false, false},
+		{protocol.LangGo, "(*Store).Add", "func (s *Store) Add(item int) {", false, true},
+		{protocol.LangGo, "(*Store).add", "func (s *Store) add(item int) {", false, false},
+		{protocol.LangGo, "(*store).Add", "func (s *store) Add(item int) {", false, false},
+		{protocol.LangGo, "(List[T]).Len", "func (l List[T]) Len() int {", false, true},
+		{protocol.LangTypeScript, "Cart", "export class Cart {", false, true},
+		{protocol.LangTypeScript, "helper", "function helper() {", false, false},
+		{protocol.LangTypeScript, "total", "  total(): number {", true, true},
+		{protocol.LangTypeScript, "secret", "  private secret: string", true, false},
+		{protocol.LangRust, "parse", "pub fn parse(input: &str) -> Ast {", false, true},
+		{protocol.LangRust, "parse_inner", "fn parse_inner(input: &str) {", false, false},
+		{protocol.LangRust, "impl Parser", "impl Parser {", false, true},
+		{protocol.LangJava, "run", "    public void run() {", true, true},
+		{protocol.LangJava, "check", "    private void check() {", true, false},
+		{protocol.LangPython, "load", "def load(path):", false, true},
+		{protocol.LangPython, "_cache", "_cache = {}", false, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isExported(tt.language, tt.name, tt.declaration, tt.member), "%s %s", tt.language, tt.name)
+	}
+}
+
+func TestOneLineSignature(t *testing.T) {
+	assert.Equal(t, "type Store struct", oneLineSignature("type Store struct {\n\titems []int\n}"))
+	assert.Equal(t, "func (s *Store) Add(item int)", oneLineSignature("func (s *Store) Add(item int)"))
+	assert.Equal(t, "def load(path):", oneLineSignature("  def   load(path):  "))
+}
+
+func TestFirstSentence(t *testing.T) {
+	assert.Equal(t, "Add appends an item.", firstSentence("Add appends an item. It is safe\nfor concurrent use.\n\nExample: ..."))
+	assert.Equal(t, "Loads the configuration from disk", firstSentence("Loads the configuration\nfrom disk"))
+	assert.Equal(t, "", firstSentence(""))
+}
@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// headerExtensions and sourceExtensions tell C, C++ and Objective-C headers
+// from the files that implement them, in the order counterparts are looked
+// for
+var (
+	headerExtensions = []string{".h", ".hpp", ".hh", ".hxx", ".h++", ".inl"}
+	sourceExtensions = []string{".cpp", ".cc", ".c", ".cxx", ".c++", ".m", ".mm"}
+)
+
+// SwitchSourceHeader finds the header of a C or C++ source file, or the
+// source file of a header, with clangd's textDocument/switchSourceHeader
+// extension. When the server doesn't support it or doesn't know the
+// counterpart, a file with the same name and the other kind of extension is
+// looked for in the workspace, nearest to the file first.
+func SwitchSourceHeader(ctx context.Context, client *lsp.Client, workspaceDir, filePath string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	isHeader := slices.Contains(headerExtensions, ext)
+	if !isHeader && !slices.Contains(sourceExtensions, ext) {
+		return "", codedErrorf(InvalidArgument, "%s is not a C or C++ source file or header", filePath)
+	}
+	kind, counterpartKind := "Source file", "Header"
+	if isHeader {
+		kind, counterpartKind = "Header", "Source file"
+	}
+
+	if err := client.OpenFile(ctx, filePath); err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+	var uri protocol.DocumentUri
+	params := protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filePath)}
+	err := client.Call(ctx, "textDocument/switchSourceHeader", params, &uri)
+	if err != nil && ErrorCodeOf(err) != UnsupportedCapability {
+		return "", fmt.Errorf("failed to switch between source and header: %w", err)
+	}
+	if uri != "" {
+		return fmt.Sprintf("%s %s\n%s: %s\n", kind, filePath, counterpartKind, uri.Path()), nil
+	}
+
+	candidates, err := counterpartsByName(ctx, workspaceDir, filePath, isHeader)
+	if err != nil {
+		return "", err
+	}
+	if len(candidates) == 0 {
+		return fmt.Sprintf("No %s found for %s", strings.ToLower(counterpartKind), filePath), nil
+	}
+	var result strings.Builder
+	fmt.Fprintf(&result, "%s %s\n", kind, filePath)
+	result.WriteString("NOTE: The language server did not find it, so these files were matched by name.\n")
+	for _, candidate := range candidates {
+		fmt.Fprintf(&result, "%s: %s\n", counterpartKind, candidate)
+	}
+	return result.String(), nil
+}
+
+// counterpartsByName returns the files named like filePath with the
+// extension of the other kind. Those in the nearest directories come first:
+// the file's own, then a sibling such as include/ for src/, then further.
+// Files as near as each other are all returned.
+func counterpartsByName(ctx context.Context, workspaceDir, filePath string, isHeader bool) ([]string, error) {
+	extensions := headerExtensions
+	if isHeader {
+		extensions = sourceExtensions
+	}
+	stem := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+
+	var candidates []string
+	best := -1
+	err := heuristics.WalkSourceFiles(ctx, workspaceDir, func(path string) error {
+		base := filepath.Base(path)
+		ext := filepath.Ext(base)
+		if strings.TrimSuffix(base, ext) != stem || !slices.Contains(extensions, strings.ToLower(ext)) {
+			return nil
+		}
+		distance := directoryDistance(filepath.Dir(filePath), filepath.Dir(path))
+		switch {
+		case best == -1 || distance < best:
+			best = distance
+			candidates = []string{path}
+		case distance == best:
+			candidates = append(candidates, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk workspace: %w", err)
+	}
+	slices.SortStableFunc(candidates, func(a, b string) int {
+		return slices.Index(extensions, strings.ToLower(filepath.Ext(a))) - slices.Index(extensions, strings.ToLower(filepath.Ext(b)))
+	})
+	return candidates, nil
+}
+
+// directoryDistance counts the directories between two directories, going
+// up from a to the nearest common parent and down to b
+func directoryDistance(a, b string) int {
+	aParts := strings.Split(filepath.ToSlash(a), "/")
+	bParts := strings.Split(filepath.ToSlash(b), "/")
+	common := 0
+	for common < len(aParts) && common < len(bParts) && aParts[common] == bParts[common] {
+		common++
+	}
+	return len(aParts) - common + len(bParts) - common
+}
@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwitchSourceHeader(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"include/helper.hpp": "void helper();\n",
+		"src/helper.cpp":     "#include \"helper.hpp\"\nvoid helper() {}\n",
+		"src/main.cpp":       "int main() {}\n",
+		"lib/util.h":         "",
+		"lib/util.c":         "",
+		"lib/util.cc":        "",
+		"README.md":          "",
+	})
+	ctx := context.Background()
+
+	t.Run("server", func(t *testing.T) {
+		server := lsptest.NewServer(protocol.ServerCapabilities{})
+		server.Handle("textDocument/switchSourceHeader", func(params json.RawMessage) (any, error) {
+			return protocol.URIFromPath(filepath.Join(dir, "include/helper.hpp")), nil
+		})
+		client := lsptest.Start(t, server, dir)
+
+		result, err := SwitchSourceHeader(ctx, client, dir, filepath.Join(dir, "src/helper.cpp"))
+		require.NoError(t, err)
+		assert.Equal(t, "Source file "+filepath.Join(dir, "src/helper.cpp")+"\nHeader: "+filepath.Join(dir, "include/helper.hpp")+"\n", result)
+		assert.Len(t, server.Received("textDocument/switchSourceHeader"), 1)
+	})
+
+	// Without the extension, or when the server doesn't know, files are
+	// matched by name
+	client := lsptest.Start(t, lsptest.NewServer(protocol.ServerCapabilities{}), dir)
+
+	t.Run("header in a sibling directory", func(t *testing.T) {
+		result, err := SwitchSourceHeader(ctx, client, dir, filepath.Join(dir, "src/helper.cpp"))
+		require.NoError(t, err)
+		assert.Contains(t, result, "matched by name")
+		assert.Contains(t, result, "Header: "+filepath.Join(dir, "include/helper.hpp"))
+	})
+
+	t.Run("sources next to the header", func(t *testing.T) {
+		result, err := SwitchSourceHeader(ctx, client, dir, filepath.Join(dir, "lib/util.h"))
+		require.NoError(t, err)
+		assert.Contains(t, result, "Source file: "+filepath.Join(dir, "lib/util.cc")+"\nSource file: "+filepath.Join(dir, "lib/util.c")+"\n")
+	})
+
+	t.Run("no counterpart", func(t *testing.T) {
+		result, err := SwitchSourceHeader(ctx, client, dir, filepath.Join(dir, "src/main.cpp"))
+		require.NoError(t, err)
+		assert.Equal(t, "No header found for "+filepath.Join(dir, "src/main.cpp"), result)
+	})
+
+	t.Run("not C or C++", func(t *testing.T) {
+		_, err := SwitchSourceHeader(ctx, client, dir, filepath.Join(dir, "README.md"))
+		assert.Equal(t, InvalidArgument, ErrorCodeOf(err))
+	})
+}
@@ -0,0 +1,325 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// relatedTest is a test function and why it was picked
+type relatedTest struct {
+	name string
+	kind protocol.SymbolKind
+	path string
+	rng  protocol.Range
+	// line is 1-indexed
+	line    int
+	reasons []string
+	lenses  []string
+}
+
+// FindTests locates test functions related to a symbol, a file or both. Tests
+// are found through references from test files, test names that mention the
+// symbol, and the naming conventions that pair a file with its tests. Code
+// lenses offered on each test, such as "run test", are listed alongside.
+func FindTests(ctx context.Context, client *lsp.Client, workspaceDir, symbolName, filePath string) (string, error) {
+	if symbolName == "" && filePath == "" {
+		return "", codedErrorf(InvalidArgument, "either symbolName or filePath is required")
+	}
+
+	symbolCache := make(map[protocol.DocumentUri][]flatSymbol)
+	tests := make(map[string]*relatedTest)
+	add := func(path string, sym flatSymbol, reason string) {
+		key := fmt.Sprintf("%s:%d:%d", path, sym.selection.Line, sym.selection.Character)
+		test, ok := tests[key]
+		if !ok {
+			name := sym.name
+			if sym.container != "" {
+				name = sym.container + "." + name
+			}
+			test = &relatedTest{name: name, kind: sym.kind, path: path, rng: sym.rng, line: int(sym.selection.Line) + 1}
+			tests[key] = test
+		}
+		for _, existing := range test.reasons {
+			if existing == reason {
+				return
+			}
+		}
+		test.reasons = append(test.reasons, reason)
+	}
+
+	// testsIn adds the tests in the test files paired with a source file,
+	// keeping only those accepted by include
+	testsIn := func(sourcePath string, include func(sym flatSymbol) bool, reason string) error {
+		testFiles, err := testFilesFor(ctx, workspaceDir, sourcePath)
+		if err != nil {
+			return err
+		}
+		for _, testFile := range testFiles {
+			symbols, err := documentSymbolsFlat(ctx, client, protocol.URIFromPath(testFile), symbolCache)
+			if err != nil {
+				toolsLogger.Debug("Skipping %s: %v", testFile, err)
+				continue
+			}
+			for _, sym := range symbols {
+				if isTestSymbol(testFile, sym) && include(sym) {
+					add(testFile, sym, reason)
+				}
+			}
+		}
+		return nil
+	}
+
+	if filePath != "" {
+		err := testsIn(filePath, func(flatSymbol) bool { return true }, "in test file for "+filepath.Base(filePath))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if symbolName != "" {
+		resolvedName, results, err := QuerySymbol(ctx, client, symbolName)
+		if err != nil {
+			return "", err
+		}
+		parts := strings.FieldsFunc(resolvedName, func(r rune) bool { return r == '.' || r == ':' })
+		if len(parts) == 0 {
+			return "", codedErrorf(InvalidArgument, "empty symbol name")
+		}
+		shortName := strings.ToLower(parts[len(parts)-1])
+		searched := make(map[string]bool)
+
+		for _, symbol := range results {
+			if !callHierarchySymbolMatches(resolvedName, symbol) {
+				continue
+			}
+			loc, err := GetExactSymbolLocation(symbol)
+			if err != nil {
+				toolsLogger.Warn("Error getting exact location of %s: %v", symbol.GetName(), err)
+				continue
+			}
+
+			refs, err := client.References(ctx, protocol.ReferenceParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: loc.URI},
+					Position:     loc.Range.Start,
+				},
+				Context: protocol.ReferenceContext{IncludeDeclaration: false},
+			})
+			if err != nil {
+				toolsLogger.Warn("Error getting references to %s: %v", symbol.GetName(), err)
+			}
+			for _, ref := range refs {
+				refPath := ref.URI.Path()
+				if !isTestFile(refPath) {
+					continue
+				}
+				symbols, err := documentSymbolsFlat(ctx, client, ref.URI, symbolCache)
+				if err != nil {
+					toolsLogger.Debug("Skipping %s: %v", refPath, err)
+					continue
+				}
+				// Symbols are ordered parents first, so the last match is innermost
+				var enclosing *flatSymbol
+				for i, sym := range symbols {
+					if containsPosition(sym.rng, ref.Range.Start) && isTestSymbol(refPath, sym) {
+						enclosing = &symbols[i]
+					}
+				}
+				if enclosing != nil {
+					add(refPath, *enclosing, "references "+symbol.GetName())
+				}
+			}
+
+			// Tests named after the symbol in the files paired with its declaration
+			declPath := loc.URI.Path()
+			if searched[declPath] {
+				continue
+			}
+			searched[declPath] = true
+			err = testsIn(declPath, func(sym flatSymbol) bool {
+				return strings.Contains(strings.ToLower(sym.name), shortName)
+			}, "named after "+symbol.GetName())
+			if err != nil {
+				toolsLogger.Warn("Error searching test files for %s: %v", declPath, err)
+			}
+		}
+	}
+
+	target := symbolName
+	if target == "" {
+		target = filePath
+	} else if filePath != "" {
+		target = symbolName + " in " + filePath
+	}
+	if len(tests) == 0 {
+		return fmt.Sprintf("No tests found for %s", target), nil
+	}
+
+	byFile := make(map[string][]*relatedTest)
+	for _, test := range tests {
+		byFile[test.path] = append(byFile[test.path], test)
+	}
+	paths := make([]string, 0, len(byFile))
+	for path := range byFile {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Tests related to %s: %d\n\n", target, len(tests))
+	for _, path := range paths {
+		fileTests := byFile[path]
+		sort.Slice(fileTests, func(i, j int) bool { return fileTests[i].line < fileTests[j].line })
+		attachTestLenses(ctx, client, path, fileTests)
+
+		fmt.Fprintf(&result, "---\n\n%s\nTests: %d\n", path, len(fileTests))
+		for _, test := range fileTests {
+			fmt.Fprintf(&result, "  %s %s (L%d) - %s\n", protocol.TableKindMap[test.kind], test.name, test.line, strings.Join(test.reasons, ", "))
+			if len(test.lenses) > 0 {
+				fmt.Fprintf(&result, "    Code lens: %s\n", strings.Join(test.lenses, ", "))
+			}
+		}
+		result.WriteString("\n")
+	}
+	return result.String(), nil
+}
+
+// attachTestLenses records the titles of code lenses that sit on each test.
+// Servers without code lens support are ignored.
+func attachTestLenses(ctx context.Context, client *lsp.Client, path string, tests []*relatedTest) {
+	lenses, err := client.CodeLens(ctx, protocol.CodeLensParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(path)},
+	})
+	if err != nil {
+		toolsLogger.Debug("Code lens unavailable for %s: %v", path, err)
+		return
+	}
+	for _, lens := range lenses {
+		if lens.Command == nil || lens.Command.Title == "" {
+			continue
+		}
+		for _, test := range tests {
+			if containsPosition(test.rng, lens.Range.Start) {
+				test.lenses = append(test.lenses, lens.Command.Title)
+				break
+			}
+		}
+	}
+}
+
+// testFilesFor lists the test files in the workspace that belong to a source
+// file by naming convention, e.g. config_test.go, test_config.py,
+// config.spec.ts or ConfigTest.java for config. A test file is paired with
+// itself.
+func testFilesFor(ctx context.Context, workspaceDir, sourcePath string) ([]string, error) {
+	if isTestFile(sourcePath) {
+		return []string{sourcePath}, nil
+	}
+	stem := testFileStem(sourcePath)
+	ext := filepath.Ext(sourcePath)
+
+	var files []string
+	err := heuristics.WalkSourceFiles(ctx, workspaceDir, func(path string) error {
+		if isTestFile(path) && filepath.Ext(path) == ext && testFileStem(path) == stem {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk workspace: %w", err)
+	}
+
+	// Rust unit tests live next to the code they test
+	if ext == ".rs" {
+		if _, err := os.Stat(sourcePath); err == nil {
+			files = append(files, sourcePath)
+		}
+	}
+	return files, nil
+}
+
+// testFileStem strips the extension and any test affixes from a file name
+func testFileStem(path string) string {
+	base := filepath.Base(path)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	for _, suffix := range []string{"_test", ".test", ".spec", "Tests", "Test"} {
+		if trimmed, ok := strings.CutSuffix(base, suffix); ok && trimmed != "" {
+			return trimmed
+		}
+	}
+	if trimmed, ok := strings.CutPrefix(base, "test_"); ok && trimmed != "" {
+		return trimmed
+	}
+	return base
+}
+
+// isTestFile reports whether a file holds tests according to the common
+// conventions of its language
+func isTestFile(path string) bool {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	slashed := filepath.ToSlash(path)
+
+	switch ext {
+	case ".go":
+		return strings.HasSuffix(stem, "_test")
+	case ".py":
+		return strings.HasPrefix(stem, "test_") || strings.HasSuffix(stem, "_test")
+	case ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs":
+		return strings.HasSuffix(stem, ".test") || strings.HasSuffix(stem, ".spec") ||
+			strings.Contains(slashed, "/__tests__/")
+	case ".rs":
+		return strings.Contains(slashed, "/tests/")
+	case ".java", ".kt", ".cs":
+		return strings.HasSuffix(stem, "Test") || strings.HasSuffix(stem, "Tests")
+	}
+	return strings.Contains(slashed, "/test/") || strings.Contains(slashed, "/tests/")
+}
+
+// isTestSymbol reports whether a symbol in a test file is a test rather than
+// a helper. Rust unit tests are recognized by their tests module.
+func isTestSymbol(path string, sym flatSymbol) bool {
+	if sym.kind != protocol.Function && sym.kind != protocol.Method {
+		return false
+	}
+	if filepath.Ext(path) == ".rs" {
+		return sym.container == "tests" || isTestFile(path)
+	}
+	return isTestFile(path) && isTestFunction(path, sym.name)
+}
+
+// isTestFunction reports whether a function name follows the test naming
+// convention of its file's language
+func isTestFunction(path, name string) bool {
+	switch filepath.Ext(path) {
+	case ".go":
+		for _, prefix := range []string{"Test", "Benchmark", "Example", "Fuzz"} {
+			if rest, ok := strings.CutPrefix(name, prefix); ok && (rest == "" || !unicode.IsLower(rune(rest[0]))) {
+				return true
+			}
+		}
+		return false
+	case ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs":
+		// Test callbacks are reported as e.g. "it('works') callback"
+		for _, prefix := range []string{"describe(", "it(", "test("} {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		}
+		return false
+	case ".java", ".kt", ".cs":
+		// Test methods are marked with annotations rather than names
+		return true
+	}
+	return strings.HasPrefix(name, "test") || strings.HasPrefix(name, "Test")
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTestFile(t *testing.T) {
+	assert.True(t, isTestFile("/src/config_test.go"))
+	assert.False(t, isTestFile("/src/config.go"))
+	assert.True(t, isTestFile("/src/test_config.py"))
+	assert.True(t, isTestFile("/web/src/config.spec.ts"))
+	assert.True(t, isTestFile("/web/src/__tests__/config.ts"))
+	assert.True(t, isTestFile("/crate/tests/integration.rs"))
+	assert.True(t, isTestFile("/java/ConfigTest.java"))
+	assert.False(t, isTestFile("/java/Config.java"))
+}
+
+func TestTestFileStem(t *testing.T) {
+	assert.Equal(t, "config", testFileStem("/src/config_test.go"))
+	assert.Equal(t, "config", testFileStem("/src/test_config.py"))
+	assert.Equal(t, "config", testFileStem("/web/config.test.ts"))
+	assert.Equal(t, "Config", testFileStem("/java/ConfigTest.java"))
+	assert.Equal(t, "config", testFileStem("/src/config.go"))
+}
+
+func TestTestFilesFor(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"config.go":            "package app\n",
+		"config_test.go":       "package app\n",
+		"server_test.go":       "package app\n",
+		"py/config.py":         "",
+		"tests/test_config.py": "",
+	})
+
+	files, err := testFilesFor(context.Background(), dir, dir+"/config.go")
+	require.NoError(t, err)
+	assert.Equal(t, []string{dir + "/config_test.go"}, files)
+
+	files, err = testFilesFor(context.Background(), dir, dir+"/py/config.py")
+	require.NoError(t, err)
+	assert.Equal(t, []string{dir + "/tests/test_config.py"}, files)
+}
@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/git"
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// DefaultTodoMarkers are the markers FindTodos looks for unless others are
+// configured
+var DefaultTodoMarkers = []string{"TODO", "FIXME", "HACK", "XXX"}
+
+// todoMarker is a marker found in a comment
+type todoMarker struct {
+	path   string
+	line   int // 0-indexed
+	marker string
+	text   string
+	symbol string
+	commit *git.Commit
+}
+
+// commentOpeners start comments in the languages the server is used with
+var commentOpeners = []string{"//", "/*", "#", "--", "<!--"}
+
+// todoPattern matches any of the markers as a whole, case-sensitive word
+func todoPattern(markers []string) (*regexp.Regexp, error) {
+	if len(markers) == 0 {
+		markers = DefaultTodoMarkers
+	}
+	quoted := make([]string, len(markers))
+	for i, marker := range markers {
+		marker = strings.TrimSpace(marker)
+		if marker == "" {
+			return nil, fmt.Errorf("empty marker")
+		}
+		quoted[i] = regexp.QuoteMeta(marker)
+		// Markers ending in punctuation, like NOTE(perf), can't end a word
+		if isWordByte(marker[0]) {
+			quoted[i] = `\b` + quoted[i]
+		}
+		if isWordByte(marker[len(marker)-1]) {
+			quoted[i] += `\b`
+		}
+	}
+	return regexp.Compile(`(` + strings.Join(quoted, "|") + `)`)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// commentMarker returns the first marker in a comment on a line and the
+// comment's text from the marker on
+func commentMarker(re *regexp.Regexp, line string) (string, string, bool) {
+	for _, loc := range re.FindAllStringSubmatchIndex(line, -1) {
+		before := line[:loc[0]]
+		inComment := strings.HasPrefix(strings.TrimSpace(before), "*")
+		for _, opener := range commentOpeners {
+			if strings.Contains(before, opener) {
+				inComment = true
+			}
+		}
+		if !inComment {
+			continue
+		}
+		text := strings.TrimSpace(line[loc[0]:])
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(text, "*/"), "-->"))
+		if len(text) > maxSummaryLength {
+			text = text[:maxSummaryLength] + "..."
+		}
+		return line[loc[2]:loc[3]], text, true
+	}
+	return "", "", false
+}
+
+// FindTodos lists the TODO, FIXME and similar markers in the comments of a
+// file or of the files under a directory, grouped by file, with the symbol
+// around each and, from git blame, when and by whom it was written. Only the
+// first maxResults markers, by path and line, are described.
+func FindTodos(ctx context.Context, client *lsp.Client, workspaceDir, path string, markers []string, maxResults int) (string, error) {
+	if maxResults < 1 {
+		return "", codedErrorf(InvalidArgument, "maxResults must be at least 1, got %d", maxResults)
+	}
+	re, err := todoPattern(markers)
+	if err != nil {
+		return "", codedErrorf(InvalidArgument, "invalid markers: %v", err)
+	}
+	if path == "" {
+		path = workspaceDir
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workspaceDir, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", codedErrorf(InvalidArgument, "invalid path: %v", err)
+	}
+
+	var matches []heuristics.Match
+	if info.IsDir() {
+		matches, err = heuristics.Search(ctx, path, re, 0)
+	} else {
+		matches, err = heuristics.SearchFile(path, re)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to search %s: %w", path, err)
+	}
+
+	var found []todoMarker
+	counts := make(map[string]int)
+	for _, match := range matches {
+		if match.Omitted != "" || (excludeGenerated() && isGeneratedFile(match.Path)) {
+			continue
+		}
+		marker, text, ok := commentMarker(re, match.Text)
+		if !ok {
+			continue
+		}
+		counts[marker]++
+		found = append(found, todoMarker{path: match.Path, line: match.Line, marker: marker, text: text})
+	}
+	rel := func(file string) string {
+		if r, err := filepath.Rel(workspaceDir, file); err == nil {
+			return r
+		}
+		return file
+	}
+	if len(found) == 0 {
+		if len(markers) == 0 {
+			markers = DefaultTodoMarkers
+		}
+		return fmt.Sprintf("No markers found in %s (looked for %s)", rel(path), strings.Join(markers, ", ")), nil
+	}
+	shown := found[:min(len(found), maxResults)]
+	describeTodos(ctx, client, shown)
+
+	files := 0
+	for i, item := range found {
+		if i == 0 || item.path != found[i-1].path {
+			files++
+		}
+	}
+	names := make([]string, 0, len(counts))
+	for marker := range counts {
+		names = append(names, marker)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	tally := make([]string, len(names))
+	for i, marker := range names {
+		tally[i] = fmt.Sprintf("%s %d", marker, counts[marker])
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Found %d markers in %d files under %s (%s)", len(found), files, rel(path), strings.Join(tally, ", "))
+	if len(shown) < len(found) {
+		fmt.Fprintf(&out, ", the first %d shown", len(shown))
+	}
+	out.WriteString("\n")
+	now := time.Now()
+	for i, item := range shown {
+		if i == 0 || item.path != shown[i-1].path {
+			fmt.Fprintf(&out, "\n%s\n", rel(item.path))
+		}
+		fmt.Fprintf(&out, "  L%d: %s", item.line+1, item.text)
+		var notes []string
+		if item.symbol != "" {
+			notes = append(notes, "in "+item.symbol)
+		}
+		if item.commit != nil {
+			notes = append(notes, describeTodoCommit(*item.commit, now))
+		}
+		if len(notes) > 0 {
+			fmt.Fprintf(&out, " (%s)", strings.Join(notes, ", "))
+		}
+		out.WriteString("\n")
+	}
+	return out.String(), nil
+}
+
+// describeTodos adds the enclosing symbol and the commit that wrote each
+// marker. Files are blamed once, over the lines between their first and
+// last marker.
+func describeTodos(ctx context.Context, client *lsp.Client, items []todoMarker) {
+	cache := make(map[protocol.DocumentUri][]flatSymbol)
+	for start := 0; start < len(items); {
+		end := start
+		for end < len(items) && items[end].path == items[start].path {
+			end++
+		}
+		path := items[start].path
+		commits := blameLines(ctx, path, items[start].line+1, items[end-1].line+1)
+		for i := start; i < end; i++ {
+			if commit, ok := commits[items[i].line+1]; ok {
+				items[i].commit = &commit
+			}
+			if client == nil || lsp.DetectLanguageID("file://"+path) == "" {
+				continue
+			}
+			loc := protocol.Location{URI: protocol.URIFromPath(path), Range: protocol.Range{Start: protocol.Position{Line: uint32(items[i].line)}}}
+			if symbol, ok := enclosingFunction(ctx, client, loc, cache); ok {
+				items[i].symbol = symbol.name
+			}
+		}
+		start = end
+	}
+}
+
+// describeTodoCommit gives the age and author of a marker
+func describeTodoCommit(commit git.Commit, now time.Time) string {
+	if commit.Uncommitted() {
+		return "uncommitted"
+	}
+	return fmt.Sprintf("%s by %s", git.FormatAge(commit.Time, now), commit.Author)
+}
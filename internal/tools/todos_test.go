@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentMarker(t *testing.T) {
+	re, err := todoPattern(nil)
+	require.NoError(t, err)
+	tests := []struct {
+		line, marker, text string
+		ok                 bool
+	}{
+		{"\t// TODO(alice): handle errors", "TODO", "TODO(alice): handle errors", true},
+		{"x := 1 // FIXME off by one", "FIXME", "FIXME off by one", true},
+		{"# HACK: works around a bug", "HACK", "HACK: works around a bug", true},
+		{"/* XXX remove */", "XXX", "XXX remove", true},
+		{" * TODO document the options", "TODO", "TODO document the options", true},
+		{"<!-- TODO: add a footer -->", "TODO", "TODO: add a footer", true},
+		{"ctx := context.TODO()", "", "", false},
+		{"// TODOS are tracked elsewhere", "", "", false},
+		{"// todo lowercase", "", "", false},
+	}
+	for _, tt := range tests {
+		marker, text, ok := commentMarker(re, tt.line)
+		assert.Equal(t, tt.ok, ok, tt.line)
+		assert.Equal(t, tt.marker, marker, tt.line)
+		assert.Equal(t, tt.text, text, tt.line)
+	}
+
+	_, err = todoPattern([]string{"TODO", " "})
+	assert.Error(t, err)
+}
+
+func TestFindTodos(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"a.go":    "package a\n\n// TODO: split this up\nfunc A() {\n\t// FIXME: leaks\n}\n",
+		"b/b.py":  "# NOTE(perf): cache this\n# TODO later\n",
+		"c/c.txt": "no markers here\n",
+	})
+	ctx := context.Background()
+
+	result, err := FindTodos(ctx, nil, dir, "", nil, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "Found 3 markers in 2 files under . (TODO 2, FIXME 1)\n"+
+		"\na.go\n  L3: TODO: split this up\n  L5: FIXME: leaks\n"+
+		"\nb/b.py\n  L2: TODO later\n", result)
+
+	result, err = FindTodos(ctx, nil, dir, "b", []string{"NOTE(perf)"}, 1)
+	require.NoError(t, err)
+	assert.Contains(t, result, "Found 1 markers in 1 files under b (NOTE(perf) 1)\n\nb/b.py\n  L1: NOTE(perf): cache this\n")
+
+	result, err = FindTodos(ctx, nil, dir, "a.go", nil, 1)
+	require.NoError(t, err)
+	assert.Contains(t, result, "Found 2 markers in 1 files under a.go (FIXME 1, TODO 1), the first 1 shown\n")
+
+	result, err = FindTodos(ctx, nil, dir, "c", nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "No markers found in c (looked for TODO, FIXME, HACK, XXX)", result)
+
+	_, err = FindTodos(ctx, nil, dir, "", nil, 0)
+	assert.Equal(t, InvalidArgument, ErrorCodeOf(err))
+}
@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// RenameTypeScriptFile moves a TypeScript or JavaScript file and updates the
+// import specifiers that refer to it, and its own relative imports, with
+// typescript-language-server's _typescript.applyRenameFile command. The
+// server sends the edits back before the file is moved.
+func RenameTypeScriptFile(ctx context.Context, client *lsp.Client, workspaceDir, oldPath, newPath string) (string, error) {
+	if !filepath.IsAbs(oldPath) {
+		oldPath = filepath.Join(workspaceDir, oldPath)
+	}
+	if !filepath.IsAbs(newPath) {
+		newPath = filepath.Join(workspaceDir, newPath)
+	}
+	oldPath, newPath = filepath.Clean(oldPath), filepath.Clean(newPath)
+
+	if info, err := os.Stat(oldPath); err != nil || info.IsDir() {
+		return "", codedErrorf(InvalidArgument, "%s is not a file", oldPath)
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return "", codedErrorf(EditConflict, "%s already exists", newPath)
+	}
+	for _, path := range []string{oldPath, newPath} {
+		if rel, err := filepath.Rel(workspaceDir, path); err != nil || strings.HasPrefix(rel, "..") {
+			return "", codedErrorf(InvalidArgument, "%s is not inside the workspace", path)
+		}
+	}
+
+	// The server needs the file in its project to find what imports it
+	if err := client.OpenFile(ctx, oldPath); err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+	command, err := serverCommand("_typescript.applyRenameFile", map[string]string{
+		"sourceUri": string(protocol.URIFromPath(oldPath)),
+		"targetUri": string(protocol.URIFromPath(newPath)),
+	})
+	if err != nil {
+		return "", err
+	}
+	changes, err := applyCodeAction(ctx, client, protocol.CodeAction{Title: command.Title, Command: &command})
+	if err != nil {
+		return "", err
+	}
+
+	if err := client.CloseFile(ctx, oldPath); err != nil {
+		toolsLogger.Debug("Error closing %s: %v", oldPath, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(newPath), err)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return "", fmt.Errorf("failed to move file: %w", err)
+	}
+	params := protocol.RenameFilesParams{
+		Files: []protocol.FileRename{{OldURI: string(protocol.URIFromPath(oldPath)), NewURI: string(protocol.URIFromPath(newPath))}},
+	}
+	if err := client.DidRenameFiles(ctx, params); err != nil {
+		toolsLogger.Debug("Error sending didRenameFiles: %v", err)
+	}
+	changes.renamed(oldPath, newPath)
+
+	diff := changes.diff()
+	if diff == "" {
+		return fmt.Sprintf("Moved %s to %s\nNo imports needed updating\n", oldPath, newPath), nil
+	}
+	return fmt.Sprintf("Moved %s to %s\n\n%s", oldPath, newPath, diff), nil
+}
+
+// OrganizeTypeScriptImports sorts the imports of a TypeScript or JavaScript
+// file and removes the unused ones, with typescript-language-server's
+// _typescript.organizeImports command, and returns the diff. keepUnused
+// only sorts them.
+func OrganizeTypeScriptImports(ctx context.Context, client *lsp.Client, filePath string, keepUnused bool) (string, error) {
+	if err := client.OpenFile(ctx, filePath); err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+	// The command takes the file path rather than its URI
+	command, err := serverCommand("_typescript.organizeImports", filePath, map[string]bool{"skipDestructiveCodeActions": keepUnused})
+	if err != nil {
+		return "", err
+	}
+	changes, err := applyCodeAction(ctx, client, protocol.CodeAction{Title: command.Title, Command: &command})
+	if err != nil {
+		return "", err
+	}
+	diff := changes.diff()
+	if diff == "" {
+		return fmt.Sprintf("Imports of %s are already organized\n", filePath), nil
+	}
+	return fmt.Sprintf("Organized imports of %s\n\n%s", filePath, diff), nil
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeScriptCommands(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"src/util.ts":  "import { b, a } from './lib';\nexport const util = a;\n",
+		"src/main.ts":  "import { util } from './util';\nconsole.log(util);\n",
+		"src/lib.ts":   "export const a = 1, b = 2;\n",
+		"src/taken.ts": "",
+	})
+	ctx := context.Background()
+	edit := func(path string, line uint32, start, end uint32, text string) protocol.ApplyWorkspaceEditParams {
+		return protocol.ApplyWorkspaceEditParams{Edit: protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			protocol.URIFromPath(filepath.Join(dir, path)): {{
+				Range:   protocol.Range{Start: protocol.Position{Line: line, Character: start}, End: protocol.Position{Line: line, Character: end}},
+				NewText: text,
+			}},
+		}}}
+	}
+
+	var server *lsptest.Server
+	var organizeArguments []json.RawMessage
+	server = lsptest.NewServer(protocol.ServerCapabilities{})
+	server.Handle("workspace/executeCommand", func(raw json.RawMessage) (any, error) {
+		var params protocol.ExecuteCommandParams
+		require.NoError(t, json.Unmarshal(raw, &params))
+		var applied protocol.ApplyWorkspaceEditResult
+		switch params.Command {
+		case "_typescript.applyRenameFile":
+			var args struct{ SourceURI, TargetURI protocol.DocumentUri }
+			require.NoError(t, json.Unmarshal(params.Arguments[0], &args))
+			assert.Equal(t, protocol.URIFromPath(filepath.Join(dir, "src/util.ts")), args.SourceURI)
+			assert.Equal(t, protocol.URIFromPath(filepath.Join(dir, "src/shared/util.ts")), args.TargetURI)
+			if err := server.Request(context.Background(), "workspace/applyEdit", edit("src/main.ts", 0, 22, 28, "./shared/util"), &applied); err != nil {
+				return nil, err
+			}
+			return nil, server.Request(context.Background(), "workspace/applyEdit", edit("src/util.ts", 0, 22, 27, "../lib"), &applied)
+		case "_typescript.organizeImports":
+			organizeArguments = params.Arguments
+			return nil, server.Request(context.Background(), "workspace/applyEdit", edit("src/lib.ts", 0, 0, 0, "// organized\n"), &applied)
+		}
+		return nil, nil
+	})
+	client := lsptest.Start(t, server, dir)
+
+	t.Run("rename file", func(t *testing.T) {
+		result, err := RenameTypeScriptFile(ctx, client, dir, "src/util.ts", "src/shared/util.ts")
+		require.NoError(t, err)
+		assert.Contains(t, result, "Moved "+filepath.Join(dir, "src/util.ts")+" to "+filepath.Join(dir, "src/shared/util.ts"))
+		assert.Contains(t, result, "+import { util } from './shared/util';")
+		assert.Contains(t, result, "+import { b, a } from '../lib';")
+
+		assert.NoFileExists(t, filepath.Join(dir, "src/util.ts"))
+		content, err := os.ReadFile(filepath.Join(dir, "src/shared/util.ts"))
+		require.NoError(t, err)
+		assert.Equal(t, "import { b, a } from '../lib';\nexport const util = a;\n", string(content))
+		assert.Len(t, server.Received("workspace/didRenameFiles"), 1)
+	})
+
+	t.Run("destination exists", func(t *testing.T) {
+		_, err := RenameTypeScriptFile(ctx, client, dir, "src/main.ts", "src/taken.ts")
+		assert.Equal(t, EditConflict, ErrorCodeOf(err))
+	})
+
+	t.Run("outside the workspace", func(t *testing.T) {
+		_, err := RenameTypeScriptFile(ctx, client, dir, "src/main.ts", "../main.ts")
+		assert.Equal(t, InvalidArgument, ErrorCodeOf(err))
+	})
+
+	t.Run("organize imports", func(t *testing.T) {
+		path := filepath.Join(dir, "src/lib.ts")
+		result, err := OrganizeTypeScriptImports(ctx, client, path, true)
+		require.NoError(t, err)
+		assert.Contains(t, result, "Organized imports of "+path)
+		assert.Contains(t, result, "+// organized")
+		require.Len(t, organizeArguments, 2)
+		assert.JSONEq(t, `"`+path+`"`, string(organizeArguments[0]))
+		assert.JSONEq(t, `{"skipDestructiveCodeActions":true}`, string(organizeArguments[1]))
+	})
+}
@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/journal"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// UndoEdits reverts the last editing tool call recorded in the journal, or
+// every call since a checkpoint, and shows what it changed
+func UndoEdits(ctx context.Context, client *lsp.Client, edits *journal.Journal, checkpoint string, force bool) (string, error) {
+	undone, restored, err := edits.Undo(checkpoint, force)
+	var changed *journal.ChangedError
+	if errors.As(err, &changed) {
+		return "", codedErrorf(EditConflict, "%v. Call undo_edits again with force to undo them anyway.", err)
+	}
+	if err != nil && len(restored) == 0 {
+		return "", err
+	}
+	files := make([]string, len(restored))
+	for i, file := range restored {
+		files[i] = file.Path
+	}
+	syncChangedFiles(ctx, client, files)
+	if err != nil {
+		return "", fmt.Errorf("%w; %d files were already restored", err, len(restored))
+	}
+
+	var out strings.Builder
+	calls := make([]string, len(undone))
+	for i, entry := range undone {
+		calls[i] = fmt.Sprintf("%s (%s)", entry.Tool, entry.Time.Local().Format("15:04:05"))
+	}
+	fmt.Fprintf(&out, "Undid %d tool calls: %s\n\n", len(undone), strings.Join(calls, ", "))
+	for _, file := range restored {
+		switch {
+		case file.Content == nil:
+			fmt.Fprintf(&out, "Removed %s\n", file.Path)
+		case file.Current == nil:
+			fmt.Fprintf(&out, "Recreated %s\n", file.Path)
+		default:
+			out.WriteString(utilities.UnifiedDiff(file.Path, string(file.Current), string(file.Content)))
+		}
+	}
+	return out.String(), nil
+}
+
+// TakeCheckpoint names the current point in the journal, so that the edits
+// made after it can be undone together, and records the errors of the open
+// files as a baseline for DiagnosticsSinceCheckpoint
+func TakeCheckpoint(client *lsp.Client, edits *journal.Journal, name string) (string, error) {
+	if name == "" {
+		return "", codedErrorf(InvalidArgument, "the checkpoint needs a name")
+	}
+	baseline := make(map[string]map[string]int)
+	count := 0
+	for _, path := range client.OpenFilePaths() {
+		uri := protocol.URIFromPath(path)
+		if client.DiagnosticsVersion(uri) == 0 {
+			continue
+		}
+		baseline[path] = errorCounts(client.GetFileDiagnostics(uri))
+		for _, n := range baseline[path] {
+			count += n
+		}
+	}
+	edits.Checkpoint(name, baseline)
+	return fmt.Sprintf("Took checkpoint %q. The %d open files with diagnostics have %d errors.", name, len(baseline), count), nil
+}
+
+// DiagnosticsSinceCheckpoint lists the errors of open files that they
+// didn't have when a checkpoint was taken. All the errors of files opened
+// since are listed, as their errors from then aren't known.
+func DiagnosticsSinceCheckpoint(client *lsp.Client, edits *journal.Journal, name string) (string, error) {
+	checkpoint, err := edits.FindCheckpoint(name)
+	if err != nil {
+		return "", WithCode(InvalidArgument, err)
+	}
+	// Only the diagnostics of open files are kept up to date
+	introduced := make(map[protocol.DocumentUri][]protocol.Diagnostic)
+	var unknown []string
+	count := 0
+	for _, path := range client.OpenFilePaths() {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		before, known := checkpoint.Errors[path]
+		errors := newErrors(client.GetFileDiagnostics(protocol.URIFromPath(path)), before)
+		if len(errors) == 0 {
+			continue
+		}
+		if !known {
+			unknown = append(unknown, path)
+		}
+		introduced[protocol.URIFromPath(path)] = errors
+		count += len(errors)
+	}
+	if count == 0 {
+		return fmt.Sprintf("No errors were introduced since checkpoint %q.", name), nil
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "%d errors were introduced since checkpoint %q.\n", count, name)
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		fmt.Fprintf(&out, "%s had no diagnostics at the checkpoint, so all of their errors are listed.\n", strings.Join(unknown, ", "))
+	}
+	out.WriteString("\n" + formatDiagnosticsByFile(introduced))
+	return out.String(), nil
+}
@@ -0,0 +1,305 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// unusedSymbolWorkers is the number of files checked concurrently
+const unusedSymbolWorkers = 8
+
+// unusedSymbolKinds are the declarations worth reporting. Fields, enum members
+// and locals are left out because they are rarely dead on their own.
+var unusedSymbolKinds = map[protocol.SymbolKind]bool{
+	protocol.Function:  true,
+	protocol.Method:    true,
+	protocol.Class:     true,
+	protocol.Struct:    true,
+	protocol.Interface: true,
+	protocol.Enum:      true,
+	protocol.Constant:  true,
+	protocol.Variable:  true,
+}
+
+// unusedSymbol is a declaration with no references outside its own body
+type unusedSymbol struct {
+	name      string
+	kind      protocol.SymbolKind
+	container string
+	// line is 1-indexed
+	line int
+}
+
+// unusedSymbolResult is the outcome of checking one file
+type unusedSymbolResult struct {
+	path    string
+	checked int
+	unused  []unusedSymbol
+	err     error
+}
+
+type unusedSymbolCacheEntry struct {
+	contentVersion int64
+	modTime        time.Time
+	result         unusedSymbolResult
+}
+
+// unusedSymbolCache keeps per-file results until the workspace changes, so
+// repeated scans of a large repository only query files that were not seen
+var unusedSymbolCache = struct {
+	sync.Mutex
+	files map[string]unusedSymbolCacheEntry
+}{files: make(map[string]unusedSymbolCacheEntry)}
+
+// FindUnusedSymbols reports functions, types, constants and variables declared
+// under scope that have no references outside their own definition. scope is
+// a file or directory; the whole workspace is checked if it is empty.
+func FindUnusedSymbols(ctx context.Context, client *lsp.Client, workspaceDir, scope string) (string, error) {
+	if scope == "" {
+		scope = workspaceDir
+	}
+	scope, err := filepath.Abs(scope)
+	if err != nil {
+		return "", fmt.Errorf("invalid scope: %w", err)
+	}
+	info, err := os.Stat(scope)
+	if err != nil {
+		return "", fmt.Errorf("invalid scope: %w", err)
+	}
+
+	var files []string
+	if info.IsDir() {
+		err = heuristics.WalkSourceFiles(ctx, scope, func(path string) error {
+			if lsp.DetectLanguageID("file://"+path) != "" && !heuristics.IsStructured(path) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to walk %s: %w", scope, err)
+		}
+	} else {
+		files = []string{scope}
+	}
+	if len(files) == 0 {
+		return fmt.Sprintf("No source files found in %s", scope), nil
+	}
+
+	results := make([]unusedSymbolResult, len(files))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(unusedSymbolWorkers, len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = cachedUnusedSymbols(ctx, client, files[i])
+			}
+		}()
+	}
+	for i := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	var body strings.Builder
+	checked, unused, failed := 0, 0, 0
+	for _, result := range results {
+		if result.err != nil {
+			toolsLogger.Warn("Skipping %s: %v", result.path, result.err)
+			failed++
+			continue
+		}
+		checked += result.checked
+		if len(result.unused) == 0 {
+			continue
+		}
+		unused += len(result.unused)
+		fmt.Fprintf(&body, "---\n\n%s\nUnused: %d\n", result.path, len(result.unused))
+		for _, sym := range result.unused {
+			name := sym.name
+			if sym.container != "" {
+				name = sym.container + "." + name
+			}
+			fmt.Fprintf(&body, "  %s %s (L%d)\n", protocol.TableKindMap[sym.kind], name, sym.line)
+		}
+		body.WriteString("\n")
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Checked %d symbols in %d files under %s\n", checked, len(files)-failed, scope)
+	if failed > 0 {
+		fmt.Fprintf(&result, "Skipped %d files the language server could not analyze\n", failed)
+	}
+	if unused == 0 {
+		result.WriteString("No unused symbols found\n")
+		return result.String(), nil
+	}
+	fmt.Fprintf(&result, "Unused symbol candidates: %d\n", unused)
+	result.WriteString("These have no references outside their own definition. Symbols used through reflection, " +
+		"generated code, build tags or from outside the workspace may still be needed.\n\n")
+	result.WriteString(body.String())
+	return result.String(), nil
+}
+
+// cachedUnusedSymbols checks a file, reusing the previous result if neither
+// the file nor the rest of the workspace changed since
+func cachedUnusedSymbols(ctx context.Context, client *lsp.Client, path string) unusedSymbolResult {
+	version := client.ContentVersion()
+	info, err := os.Stat(path)
+	if err != nil {
+		return unusedSymbolResult{path: path, err: err}
+	}
+
+	unusedSymbolCache.Lock()
+	entry, ok := unusedSymbolCache.files[path]
+	unusedSymbolCache.Unlock()
+	if ok && entry.contentVersion == version && entry.modTime.Equal(info.ModTime()) {
+		return entry.result
+	}
+
+	result := findUnusedSymbolsInFile(ctx, client, path)
+	if result.err == nil {
+		unusedSymbolCache.Lock()
+		unusedSymbolCache.files[path] = unusedSymbolCacheEntry{version, info.ModTime(), result}
+		unusedSymbolCache.Unlock()
+	}
+	return result
+}
+
+// findUnusedSymbolsInFile asks for the references of every reportable symbol
+// declared in a file
+func findUnusedSymbolsInFile(ctx context.Context, client *lsp.Client, path string) unusedSymbolResult {
+	result := unusedSymbolResult{path: path}
+
+	// Files opened only for this scan are closed again so that large
+	// workspaces don't keep every document open in the server
+	wasOpen := client.IsFileOpen(path)
+	if err := client.OpenFile(ctx, path); err != nil {
+		result.err = fmt.Errorf("could not open file: %w", err)
+		return result
+	}
+	if !wasOpen {
+		defer func() {
+			if err := client.CloseFile(ctx, path); err != nil {
+				toolsLogger.Debug("Error closing %s: %v", path, err)
+			}
+		}()
+	}
+
+	uri := protocol.URIFromPath(path)
+	symResult, err := client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	})
+	if err != nil {
+		result.err = fmt.Errorf("failed to get document symbols: %w", err)
+		return result
+	}
+	symbols, err := symResult.Results()
+	if err != nil {
+		result.err = fmt.Errorf("failed to process document symbols: %w", err)
+		return result
+	}
+
+	type candidate struct {
+		unusedSymbol
+		position protocol.Position
+		body     protocol.Range
+	}
+	var candidates []candidate
+	for _, sym := range symbols {
+		switch v := sym.(type) {
+		case *protocol.DocumentSymbol:
+			if unusedSymbolKinds[v.Kind] {
+				candidates = append(candidates, candidate{
+					unusedSymbol{v.Name, v.Kind, "", int(v.SelectionRange.Start.Line) + 1},
+					v.SelectionRange.Start, v.Range,
+				})
+			}
+			// Methods of top level types are reported too
+			for _, child := range v.Children {
+				if child.Kind == protocol.Method || child.Kind == protocol.Function {
+					candidates = append(candidates, candidate{
+						unusedSymbol{child.Name, child.Kind, v.Name, int(child.SelectionRange.Start.Line) + 1},
+						child.SelectionRange.Start, child.Range,
+					})
+				}
+			}
+		case *protocol.SymbolInformation:
+			if !unusedSymbolKinds[v.Kind] {
+				continue
+			}
+			loc, err := GetExactSymbolLocation(v)
+			if err != nil {
+				continue
+			}
+			candidates = append(candidates, candidate{
+				unusedSymbol{v.Name, v.Kind, v.ContainerName, int(loc.Range.Start.Line) + 1},
+				loc.Range.Start, v.Location.Range,
+			})
+		}
+	}
+
+	for _, c := range candidates {
+		if ctx.Err() != nil {
+			result.err = ctx.Err()
+			return result
+		}
+		if isEntryPoint(path, c.name) {
+			continue
+		}
+		result.checked++
+
+		refs, err := client.References(ctx, protocol.ReferenceParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+				Position:     c.position,
+			},
+			Context: protocol.ReferenceContext{IncludeDeclaration: false},
+		})
+		if err != nil {
+			toolsLogger.Debug("References failed for %s in %s: %v", c.name, path, err)
+			continue
+		}
+
+		used := false
+		for _, ref := range refs {
+			// References from inside the definition, such as recursion, don't count
+			if ref.URI != uri || !containsPosition(c.body, ref.Range.Start) {
+				used = true
+				break
+			}
+		}
+		if !used {
+			result.unused = append(result.unused, c.unusedSymbol)
+		}
+	}
+
+	return result
+}
+
+// isEntryPoint reports symbols that are called by a runtime or test runner
+// rather than by other code
+func isEntryPoint(path, name string) bool {
+	switch name {
+	case "main", "init", "__init__", "__main__":
+		return true
+	}
+	return isTestFile(path) && isTestFunction(path, name)
+}
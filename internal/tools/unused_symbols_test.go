@@ -0,0 +1,17 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsEntryPoint(t *testing.T) {
+	assert.True(t, isEntryPoint("/src/main.go", "main"))
+	assert.True(t, isEntryPoint("/src/foo_test.go", "TestFoo"))
+	assert.True(t, isEntryPoint("/src/foo_test.go", "Benchmark_Parse"))
+	assert.False(t, isEntryPoint("/src/foo_test.go", "Testify"))
+	assert.False(t, isEntryPoint("/src/foo.go", "TestFoo"))
+	assert.True(t, isEntryPoint("/src/test_models.py", "test_save"))
+	assert.False(t, isEntryPoint("/src/models.py", "helper"))
+}
@@ -0,0 +1,172 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// maxExampleLines bounds a usage example. Calls whose arguments run on
+// longer are cut off.
+const maxExampleLines = 8
+
+// usageExample is a use of a symbol with the statement around it
+type usageExample struct {
+	path  string
+	line  int // 0-indexed line of the reference
+	lines []string
+	test  bool
+}
+
+// key identifies the code of an example regardless of its layout, so that
+// repeated calls are shown once
+func (e usageExample) key() string {
+	return strings.Join(strings.Fields(strings.Join(e.lines, " ")), " ")
+}
+
+// UsageExamples shows up to count short uses of a symbol, chosen to differ
+// from each other: tests, which show a symbol used on purpose, come first,
+// then uses in distinct packages and files, shortest first. Imports and
+// repeated snippets are left out.
+func UsageExamples(ctx context.Context, client *lsp.Client, symbolName string, count int) (string, error) {
+	if count < 1 {
+		return "", codedErrorf(InvalidArgument, "count must be at least 1, got %d", count)
+	}
+	resolvedName, results, refs, found, err := symbolReferences(ctx, client, symbolName)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", codedErrorf(SymbolNotFound, "%s not found%s", symbolName, emptyResultHints(client, similarSymbolsHint(symbolName, results)))
+	}
+
+	var candidates []usageExample
+	seen := make(map[string]bool)
+	files := make(map[string][]string)
+	for _, ref := range refs {
+		path := ref.URI.Path()
+		key := fmt.Sprintf("%s:%d", path, ref.Range.Start.Line)
+		if seen[key] || (excludeGenerated() && isGeneratedFile(path)) {
+			continue
+		}
+		seen[key] = true
+		lines, ok := files[path]
+		if !ok {
+			if content, err := lsp.ReadSourceFile(path); err == nil && heuristics.OpaqueKind(content) == "" {
+				lines = strings.Split(string(content), "\n")
+			}
+			files[path] = lines
+		}
+		line := int(ref.Range.Start.Line)
+		if line >= len(lines) || isImportLine(lines[line]) {
+			continue
+		}
+		candidates = append(candidates, usageExample{
+			path:  path,
+			line:  line,
+			lines: statementAt(lines, line),
+			test:  isTestFile(path),
+		})
+	}
+	if len(candidates) == 0 {
+		return fmt.Sprintf("No uses of %s were found", resolvedName) + emptyResultHints(client, referencesHint(client, resolvedName, results)), nil
+	}
+
+	examples := pickUsageExamples(candidates, count)
+	cache := make(map[protocol.DocumentUri][]flatSymbol)
+	var out strings.Builder
+	fmt.Fprintf(&out, "Usage examples of %s (%d of %d uses):\n", resolvedName, len(examples), len(candidates))
+	for _, example := range examples {
+		fmt.Fprintf(&out, "\n%s:%d", example.path, example.line+1)
+		loc := protocol.Location{URI: protocol.URIFromPath(example.path), Range: protocol.Range{Start: protocol.Position{Line: uint32(example.line)}}}
+		if function, ok := enclosingFunction(ctx, client, loc, cache); ok {
+			fmt.Fprintf(&out, " in %s", function.name)
+		}
+		if example.test {
+			out.WriteString(" (test)")
+		}
+		out.WriteString("\n" + addLineNumbers(strings.Join(example.lines, "\n"), example.line+1))
+	}
+	return out.String(), nil
+}
+
+// statementAt returns the lines of the statement that starts on a line,
+// following it onto the next lines while parentheses or brackets opened on it
+// are unclosed. Braces aren't counted, since they open the blocks of
+// statements like if and for.
+func statementAt(lines []string, line int) []string {
+	depth := 0
+	end := line
+	for ; end < len(lines) && end < line+maxExampleLines; end++ {
+		for _, r := range lines[end] {
+			switch r {
+			case '(', '[':
+				depth++
+			case ')', ']':
+				depth--
+			}
+		}
+		if depth <= 0 {
+			break
+		}
+	}
+	end = min(end, len(lines)-1, line+maxExampleLines-1)
+	statement := make([]string, 0, end-line+1)
+	for _, text := range lines[line : end+1] {
+		statement = append(statement, strings.TrimRight(text, "\r"))
+	}
+	return statement
+}
+
+// pickUsageExamples chooses up to count examples that differ from each other.
+// Tests come before other uses and shorter examples before longer ones; the
+// first pass takes one example per directory, the second one per file and
+// the last any that are left. Examples with the same code are taken once.
+func pickUsageExamples(candidates []usageExample, count int) []usageExample {
+	sorted := append([]usageExample(nil), candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		switch {
+		case a.test != b.test:
+			return a.test
+		case len(a.lines) != len(b.lines):
+			return len(a.lines) < len(b.lines)
+		case a.path != b.path:
+			return a.path < b.path
+		}
+		return a.line < b.line
+	})
+
+	var picked []usageExample
+	taken := make([]bool, len(sorted))
+	codes := make(map[string]bool)
+	dirs := make(map[string]bool)
+	files := make(map[string]bool)
+	passes := []func(usageExample) bool{
+		func(e usageExample) bool { return !dirs[filepath.Dir(e.path)] },
+		func(e usageExample) bool { return !files[e.path] },
+		func(usageExample) bool { return true },
+	}
+	for _, allowed := range passes {
+		for i, example := range sorted {
+			if len(picked) == count {
+				return picked
+			}
+			if taken[i] || codes[example.key()] || !allowed(example) {
+				continue
+			}
+			taken[i] = true
+			codes[example.key()] = true
+			dirs[filepath.Dir(example.path)] = true
+			files[example.path] = true
+			picked = append(picked, example)
+		}
+	}
+	return picked
+}
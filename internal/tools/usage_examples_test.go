@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatementAt(t *testing.T) {
+	lines := strings.Split(`	if err := Parse(input); err != nil {
+		return err
+	}
+	cfg, err := Load(Options{
+		Path: "a.toml",
+		Strict: true,
+	})
+	call(`+"\r", "\n")
+
+	assert.Equal(t, []string{"	if err := Parse(input); err != nil {"}, statementAt(lines, 0))
+	assert.Equal(t, lines[3:7], statementAt(lines, 3))
+	// Unclosed calls are cut off at the end of the file
+	assert.Equal(t, []string{"	call("}, statementAt(lines, 7))
+
+	long := append([]string{"Run("}, strings.Split(strings.Repeat("arg,\n", 20), "\n")...)
+	assert.Len(t, statementAt(long, 0), maxExampleLines)
+}
+
+func TestPickUsageExamples(t *testing.T) {
+	example := func(path string, line int, code ...string) usageExample {
+		return usageExample{path: path, line: line, lines: code, test: isTestFile(path)}
+	}
+	candidates := []usageExample{
+		example("/ws/api/server.go", 10, "Parse(a)"),
+		example("/ws/api/server.go", 20, "Parse(b)"),
+		example("/ws/api/handler.go", 5, "Parse(", "	c,", ")"),
+		example("/ws/cli/main.go", 7, "Parse(d)"),
+		example("/ws/cli/main.go", 9, "\t\tParse(d)"),
+		example("/ws/parse/parse_test.go", 30, "Parse(e)"),
+	}
+
+	paths := func(examples []usageExample) []string {
+		var result []string
+		for _, e := range examples {
+			result = append(result, e.path+":"+e.lines[0])
+		}
+		return result
+	}
+	assert.Equal(t, []string{
+		"/ws/parse/parse_test.go:Parse(e)",
+		"/ws/api/server.go:Parse(a)",
+		"/ws/cli/main.go:Parse(d)",
+	}, paths(pickUsageExamples(candidates, 3)))
+
+	// Other files and then the rest fill up larger counts, and the repeated
+	// call in main.go is left out
+	assert.Equal(t, []string{
+		"/ws/parse/parse_test.go:Parse(e)",
+		"/ws/api/server.go:Parse(a)",
+		"/ws/cli/main.go:Parse(d)",
+		"/ws/api/handler.go:Parse(",
+		"/ws/api/server.go:Parse(b)",
+	}, paths(pickUsageExamples(candidates, 10)))
+}
@@ -3,7 +3,6 @@ package tools
 import (
 	"context"
 	"fmt"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -13,9 +12,9 @@ import (
 )
 
 func ExtractTextFromLocation(loc protocol.Location) (string, error) {
-	path := strings.TrimPrefix(string(loc.URI), "file://")
+	path := loc.URI.Path()
 
-	content, err := os.ReadFile(path)
+	content, err := lsp.ReadSourceFile(path)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
@@ -25,7 +24,7 @@ func ExtractTextFromLocation(loc protocol.Location) (string, error) {
 	startLine := int(loc.Range.Start.Line)
 	endLine := int(loc.Range.End.Line)
 	if startLine < 0 || startLine >= len(lines) || endLine < 0 || endLine >= len(lines) {
-		return "", fmt.Errorf("invalid Location range: %v", loc.Range)
+		return "", codedErrorf(PositionInvalid, "invalid Location range: %v", loc.Range)
 	}
 
 	// Handle single-line case
@@ -35,7 +34,7 @@ func ExtractTextFromLocation(loc protocol.Location) (string, error) {
 		endChar := int(loc.Range.End.Character)
 
 		if startChar < 0 || startChar > len(line) || endChar < 0 || endChar > len(line) {
-			return "", fmt.Errorf("invalid character range: %v", loc.Range)
+			return "", codedErrorf(PositionInvalid, "invalid character range: %v", loc.Range)
 		}
 
 		return line[startChar:endChar], nil
@@ -48,7 +47,7 @@ func ExtractTextFromLocation(loc protocol.Location) (string, error) {
 	firstLine := lines[startLine]
 	startChar := int(loc.Range.Start.Character)
 	if startChar < 0 || startChar > len(firstLine) {
-		return "", fmt.Errorf("invalid start character: %v", loc.Range.Start)
+		return "", codedErrorf(PositionInvalid, "invalid start character: %v", loc.Range.Start)
 	}
 	result.WriteString(firstLine[startChar:])
 
@@ -62,7 +61,7 @@ func ExtractTextFromLocation(loc protocol.Location) (string, error) {
 	lastLine := lines[endLine]
 	endChar := int(loc.Range.End.Character)
 	if endChar < 0 || endChar > len(lastLine) {
-		return "", fmt.Errorf("invalid end character: %v", loc.Range.End)
+		return "", codedErrorf(PositionInvalid, "invalid end character: %v", loc.Range.End)
 	}
 	result.WriteString("\n")
 	result.WriteString(lastLine[:endChar])
@@ -176,12 +175,12 @@ func doQuerySymbol(ctx context.Context, client *lsp.Client, symbolName string) (
 		Query: symbolName,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch symbol: %v", err)
+		return nil, fmt.Errorf("failed to fetch symbol: %w", err)
 	}
 
 	results, err := symbolResult.Results()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse results: %v", err)
+		return nil, fmt.Errorf("failed to parse results: %w", err)
 	}
 	return results, nil
 }
@@ -225,12 +224,12 @@ func GetExactSymbolLocation(symbol protocol.WorkspaceSymbolResult) (protocol.Loc
 	symbolName := symbol.GetName()
 
 	if symbolName == "" {
-		return loc, fmt.Errorf("symbol has empty name")
+		return loc, codedErrorf(SymbolNotFound, "symbol has empty name")
 	}
 
 	// Read the file content
-	path := strings.TrimPrefix(string(loc.URI), "file://")
-	content, err := os.ReadFile(path)
+	path := loc.URI.Path()
+	content, err := lsp.ReadSourceFile(path)
 	if err != nil {
 		return loc, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -239,7 +238,7 @@ func GetExactSymbolLocation(symbol protocol.WorkspaceSymbolResult) (protocol.Loc
 	startLine := int(loc.Range.Start.Line)
 
 	if startLine < 0 || startLine >= len(lines) {
-		return loc, fmt.Errorf("invalid line number: %d", startLine)
+		return loc, codedErrorf(PositionInvalid, "invalid line number: %d", startLine)
 	}
 
 	line := lines[startLine]
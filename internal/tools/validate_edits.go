@@ -0,0 +1,178 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// editValidationTimeout bounds the wait for the language server to publish
+// diagnostics for the edited files
+const editValidationTimeout = 5 * time.Second
+
+// maxValidatedFiles bounds the number of edited files checked after a call
+const maxValidatedFiles = 20
+
+// EditValidation finds the errors a tool's edits introduce into the files
+// they change, so that an edit that breaks a file, such as one that replaces
+// it with a fragment, is reported with the tool's result rather than by a
+// build later
+type EditValidation struct {
+	client *lsp.Client
+
+	mu    sync.Mutex
+	files []string
+	// before holds the errors of each edited file from before the edits, by
+	// errorKey. It is nil for files the server hadn't published diagnostics
+	// for, whose earlier errors aren't known.
+	before map[string]map[string]int
+}
+
+// NewEditValidation starts recording the files edited through client
+func NewEditValidation(client *lsp.Client) *EditValidation {
+	return &EditValidation{client: client, before: make(map[string]map[string]int)}
+}
+
+// Before records the errors of a file before it is edited. It is an
+// utilities.EditWatcher, and only the first call for a file counts.
+func (v *EditValidation) Before(path string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, seen := v.before[path]; seen {
+		return
+	}
+	v.files = append(v.files, path)
+	uri := protocol.URIFromPath(path)
+	if !v.client.IsFileOpen(path) || v.client.DiagnosticsVersion(uri) == 0 {
+		v.before[path] = nil
+		return
+	}
+	v.before[path] = errorCounts(v.client.GetFileDiagnostics(uri))
+}
+
+// Report waits for diagnostics of the edited files and describes the errors
+// they have that they didn't have before the edits. It returns "" when
+// there are none.
+func (v *EditValidation) Report(ctx context.Context) string {
+	v.mu.Lock()
+	files := append([]string(nil), v.files...)
+	before := v.before
+	v.mu.Unlock()
+
+	// Files that no longer exist, such as renamed ones, and files the server
+	// doesn't read aren't checked
+	var checked []string
+	skipped := 0
+	pending := make(map[protocol.DocumentUri]uint64)
+	for _, path := range files {
+		if _, err := os.Stat(path); err != nil || lsp.IsNotebook(path) || lsp.DetectLanguageID("file://"+path) == "" {
+			continue
+		}
+		if len(checked) == maxValidatedFiles {
+			skipped++
+			continue
+		}
+		uri := protocol.URIFromPath(path)
+		version := v.client.DiagnosticsVersion(uri)
+		var err error
+		if v.client.IsFileOpen(path) {
+			err = v.client.NotifyChange(ctx, path)
+		} else {
+			err = v.client.OpenFile(ctx, path)
+		}
+		if err != nil {
+			toolsLogger.Debug("Not validating %s: %v", path, err)
+			continue
+		}
+		pending[uri] = version
+		checked = append(checked, path)
+	}
+	if len(checked) == 0 {
+		return ""
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, editValidationTimeout)
+	complete := v.client.WaitForDiagnostics(waitCtx, pending)
+	cancel()
+
+	introduced := make(map[protocol.DocumentUri][]protocol.Diagnostic)
+	var unknown []string
+	count := 0
+	for _, path := range checked {
+		uri := protocol.URIFromPath(path)
+		errors := newErrors(v.client.GetFileDiagnostics(uri), before[path])
+		if len(errors) == 0 {
+			continue
+		}
+		if before[path] == nil {
+			unknown = append(unknown, path)
+		}
+		introduced[uri] = errors
+		count += len(errors)
+	}
+	if count == 0 && complete {
+		return ""
+	}
+
+	var out strings.Builder
+	out.WriteString("\n\nValidation: ")
+	if count == 0 {
+		out.WriteString("no new errors were found, but the language server didn't publish diagnostics for every edited file in time.\n")
+	} else {
+		fmt.Fprintf(&out, "the edited files have %d errors they didn't have before. Check that the edits left them complete.\n", count)
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			fmt.Fprintf(&out, "The errors of %s from before the edits weren't known, so all of its errors are listed.\n", strings.Join(unknown, ", "))
+		}
+		out.WriteString("\n" + formatDiagnosticsByFile(introduced))
+	}
+	if skipped > 0 {
+		fmt.Fprintf(&out, "%d more edited files weren't checked.\n", skipped)
+	}
+	return out.String()
+}
+
+// errorKey identifies an error independently of where it is, since edits
+// move the lines around it
+func errorKey(diag protocol.Diagnostic) string {
+	return diag.Source + "\x00" + diag.Message
+}
+
+// errorCounts counts the errors among diagnostics by errorKey
+func errorCounts(diagnostics []protocol.Diagnostic) map[string]int {
+	counts := make(map[string]int)
+	for _, diag := range diagnostics {
+		if diag.Severity == protocol.SeverityError {
+			counts[errorKey(diag)]++
+		}
+	}
+	return counts
+}
+
+// newErrors returns the errors among diagnostics beyond those counted
+// before
+func newErrors(diagnostics []protocol.Diagnostic, before map[string]int) []protocol.Diagnostic {
+	remaining := make(map[string]int, len(before))
+	for key, n := range before {
+		remaining[key] = n
+	}
+	var errors []protocol.Diagnostic
+	for _, diag := range diagnostics {
+		if diag.Severity != protocol.SeverityError {
+			continue
+		}
+		if key := errorKey(diag); remaining[key] > 0 {
+			remaining[key]--
+			continue
+		}
+		errors = append(errors, diag)
+	}
+	return errors
+}
@@ -0,0 +1,33 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewErrors(t *testing.T) {
+	diag := func(severity protocol.DiagnosticSeverity, message string) protocol.Diagnostic {
+		return protocol.Diagnostic{Severity: severity, Source: "compiler", Message: message}
+	}
+	before := errorCounts([]protocol.Diagnostic{
+		diag(protocol.SeverityError, "undefined: x"),
+		diag(protocol.SeverityWarning, "unused variable"),
+	})
+	assert.Equal(t, map[string]int{"compiler\x00undefined: x": 1}, before)
+
+	after := []protocol.Diagnostic{
+		diag(protocol.SeverityError, "undefined: x"),
+		diag(protocol.SeverityError, "undefined: x"),
+		diag(protocol.SeverityError, "expected '}', found 'EOF'"),
+		diag(protocol.SeverityWarning, "unused import"),
+	}
+	introduced := newErrors(after, before)
+	assert.Len(t, introduced, 2)
+	assert.Equal(t, "undefined: x", introduced[0].Message)
+	assert.Equal(t, "expected '}', found 'EOF'", introduced[1].Message)
+
+	// Without earlier diagnostics every error is new
+	assert.Len(t, newErrors(after, nil), 3)
+}
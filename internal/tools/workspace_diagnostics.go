@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+)
+
+// GetWorkspaceDiagnostics lists the diagnostics the language server has
+// published for every file, merged with those of the most recent build.
+// Build diagnostics the language server also reports are not repeated, but
+// labelled with both sources.
+// With a stream, large results are sent on in parts.
+func GetWorkspaceDiagnostics(client *lsp.Client, stream ResultStream) (string, error) {
+	files := client.GetWorkspaceDiagnostics()
+	// Build errors in generated files are kept below, since they still fail
+	// the build
+	generatedFiles := 0
+	if excludeGenerated() {
+		for uri, diagnostics := range files {
+			if len(diagnostics) > 0 && isGeneratedFile(uri.Path()) {
+				delete(files, uri)
+				generatedFiles++
+			}
+		}
+	}
+
+	lspCount := 0
+	for _, diagnostics := range files {
+		lspCount += len(diagnostics)
+	}
+
+	build, finished := LastBuildDiagnostics()
+	buildCount := 0
+	var unlocated []string
+	if build != nil {
+		unlocated = build.Unlocated
+		for uri, diagnostics := range build.Files {
+			for _, diag := range diagnostics {
+				var added bool
+				if files[uri], added = lsp.MergeDiagnostic(files[uri], diag); added {
+					buildCount++
+				}
+			}
+		}
+	}
+
+	if len(files) == 0 && len(unlocated) == 0 {
+		return "No diagnostics found in workspace" + generatedNote(generatedFiles), nil
+	}
+
+	out := newResultWriter(stream)
+	summary := fmt.Sprintf("Workspace diagnostics: %d from the language server", lspCount)
+	if build != nil {
+		summary += fmt.Sprintf(", %d more from the build finished at %s", buildCount+len(unlocated), finished.Format(time.TimeOnly))
+	}
+	out.write(summary + "\n\n")
+	writeDiagnosticsByFile(out, files)
+	out.write(formatUnlocatedErrors(unlocated))
+	out.write(generatedNote(generatedFiles))
+	return out.String(), nil
+}
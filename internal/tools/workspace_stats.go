@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/heuristics"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// maxStatsRows limits each ranking in the workspace statistics
+const maxStatsRows = 10
+
+// languageStats counts the files of a language
+type languageStats struct {
+	language string
+	files    int
+	lines    int
+}
+
+// packageStats counts the top-level declarations of a directory
+type packageStats struct {
+	dir       string
+	functions int
+	types     int
+	other     int
+}
+
+func (p *packageStats) total() int { return p.functions + p.types + p.other }
+
+// sizedItem is a file or function with its length in lines
+type sizedItem struct {
+	label string
+	lines int
+}
+
+// GetWorkspaceStats summarizes the workspace: files and lines per language,
+// top-level declarations per directory, the largest files and functions, and
+// the diagnostics published so far. Declarations are found by pattern
+// matching rather than by asking the language server about every file, so
+// that the summary stays fast on large workspaces. client may be nil when no
+// language server is running.
+func GetWorkspaceStats(ctx context.Context, client *lsp.Client, workspaceDir string) (string, error) {
+	languages := make(map[string]*languageStats)
+	packages := make(map[string]*packageStats)
+	var files, functions []sizedItem
+	totalLines := 0
+
+	err := heuristics.WalkSourceFiles(ctx, workspaceDir, func(path string) error {
+		content, err := os.ReadFile(path)
+		if err != nil || bytes.IndexByte(content, 0) >= 0 {
+			// Unreadable and binary files aren't code to summarize
+			return nil
+		}
+		rel, err := filepath.Rel(workspaceDir, path)
+		if err != nil {
+			rel = path
+		}
+		lines := bytes.Count(content, []byte("\n"))
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			lines++
+		}
+		totalLines += lines
+		files = append(files, sizedItem{label: rel, lines: lines})
+
+		language := string(lsp.DetectLanguageID("file://" + path))
+		if language == "" {
+			language = "other"
+		}
+		stats, ok := languages[language]
+		if !ok {
+			stats = &languageStats{language: language}
+			languages[language] = stats
+		}
+		stats.files++
+		stats.lines += lines
+
+		if heuristics.IsStructured(path) {
+			return nil
+		}
+		dir := filepath.Dir(rel)
+		for _, sym := range heuristics.ExtractSymbols(path, string(content)) {
+			switch sym.Kind {
+			case protocol.Function, protocol.Method, protocol.Constructor:
+				functions = append(functions, sizedItem{
+					label: fmt.Sprintf("%s:%d %s", rel, sym.Line+1, symbolLabel(sym.Container, sym.Name)),
+					lines: sym.EndLine - sym.Line + 1,
+				})
+			}
+			if sym.Container != "" {
+				continue
+			}
+			pkg, ok := packages[dir]
+			if !ok {
+				pkg = &packageStats{dir: dir}
+				packages[dir] = pkg
+			}
+			switch sym.Kind {
+			case protocol.Function, protocol.Method, protocol.Constructor:
+				pkg.functions++
+			case protocol.Class, protocol.Struct, protocol.Interface, protocol.Enum, protocol.TypeParameter:
+				pkg.types++
+			default:
+				pkg.other++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to walk workspace: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Sprintf("No source files found in %s", workspaceDir), nil
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Workspace %s: %d files, %d lines\n", workspaceDir, len(files), totalLines)
+
+	languageList := make([]*languageStats, 0, len(languages))
+	for _, stats := range languages {
+		languageList = append(languageList, stats)
+	}
+	sort.Slice(languageList, func(i, j int) bool {
+		if languageList[i].lines != languageList[j].lines {
+			return languageList[i].lines > languageList[j].lines
+		}
+		return languageList[i].language < languageList[j].language
+	})
+	out.WriteString("\nFiles by language:\n")
+	for _, stats := range languageList {
+		fmt.Fprintf(&out, "- %s: %d files, %d lines\n", stats.language, stats.files, stats.lines)
+	}
+
+	if len(packages) > 0 {
+		packageList := make([]*packageStats, 0, len(packages))
+		for _, pkg := range packages {
+			packageList = append(packageList, pkg)
+		}
+		sort.Slice(packageList, func(i, j int) bool {
+			if packageList[i].total() != packageList[j].total() {
+				return packageList[i].total() > packageList[j].total()
+			}
+			return packageList[i].dir < packageList[j].dir
+		})
+		fmt.Fprintf(&out, "\nTop-level declarations by directory (%d directories):\n", len(packageList))
+		for _, pkg := range packageList[:min(maxStatsRows, len(packageList))] {
+			fmt.Fprintf(&out, "- %s: %d (%d functions, %d types, %d other)\n", pkg.dir, pkg.total(), pkg.functions, pkg.types, pkg.other)
+		}
+	}
+
+	writeLargest(&out, "Largest files", files)
+	writeLargest(&out, "Largest functions", functions)
+
+	out.WriteString("\n" + diagnosticStats(client))
+	return out.String(), nil
+}
+
+// symbolLabel qualifies a name with its container
+func symbolLabel(container, name string) string {
+	if container == "" {
+		return name
+	}
+	return container + "." + name
+}
+
+// writeLargest lists the longest items, longest first
+func writeLargest(out *strings.Builder, title string, items []sizedItem) {
+	if len(items) == 0 {
+		return
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].lines > items[j].lines })
+	fmt.Fprintf(out, "\n%s:\n", title)
+	for _, item := range items[:min(maxStatsRows, len(items))] {
+		fmt.Fprintf(out, "- %s: %d lines\n", item.label, item.lines)
+	}
+}
+
+// diagnosticStats counts the diagnostics the language server has published
+// by severity
+func diagnosticStats(client *lsp.Client) string {
+	if client == nil {
+		return "Diagnostics: unavailable without a language server\n"
+	}
+	files := client.GetWorkspaceDiagnostics()
+	counts := make(map[protocol.DiagnosticSeverity]int)
+	for _, diagnostics := range files {
+		for _, diag := range diagnostics {
+			counts[diag.Severity]++
+		}
+	}
+	result := fmt.Sprintf("Diagnostics: %d errors, %d warnings, %d information, %d hints in %d files",
+		counts[protocol.SeverityError], counts[protocol.SeverityWarning], counts[protocol.SeverityInformation], counts[protocol.SeverityHint], len(files))
+	if build, _ := LastBuildDiagnostics(); build != nil {
+		buildCount := len(build.Unlocated)
+		for _, diagnostics := range build.Files {
+			buildCount += len(diagnostics)
+		}
+		result += fmt.Sprintf(", and %d from the last build", buildCount)
+	}
+	return result + ". Only files the language server has checked are counted; see workspace_diagnostics for details.\n"
+}
@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetWorkspaceStats(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("main.go", "package main\n\nfunc main() {\n\trun()\n}\n")
+	write("store/store.go", "package store\n\ntype Store struct {\n\titems []int\n}\n\nfunc (s *Store) Add(item int) {\n\ts.items = append(s.items,\n\t\titem)\n}\n\nfunc New() *Store {\n\treturn &Store{}\n}\n")
+	write("scripts/build.py", "def build():\n    pass\n")
+	write("notes.txt", "one\ntwo")
+	write("logo.dat", "\x00\x01\x02")
+
+	text, err := GetWorkspaceStats(context.Background(), nil, dir)
+	require.NoError(t, err)
+
+	assert.Contains(t, text, "4 files, 23 lines")
+	assert.Contains(t, text, "- go: 2 files, 19 lines\n")
+	assert.Contains(t, text, "- python: 1 files, 2 lines\n")
+	assert.Contains(t, text, "- other: 1 files, 2 lines\n")
+	assert.Contains(t, text, "- store: 3 (2 functions, 1 types, 0 other)\n")
+	assert.Contains(t, text, "- .: 1 (1 functions, 0 types, 0 other)\n")
+	assert.Contains(t, text, "Diagnostics: unavailable without a language server")
+
+	largestFiles := text[strings.Index(text, "Largest files:"):]
+	assert.True(t, strings.HasPrefix(largestFiles, "Largest files:\n- store/store.go: 14 lines\n"), largestFiles)
+	largestFunctions := text[strings.Index(text, "Largest functions:"):]
+	assert.True(t, strings.HasPrefix(largestFunctions, "Largest functions:\n- store/store.go:7 Add: 4 lines\n"), largestFunctions)
+}
+
+func TestGetWorkspaceStatsEmpty(t *testing.T) {
+	text, err := GetWorkspaceStats(context.Background(), nil, t.TempDir())
+	require.NoError(t, err)
+	assert.Contains(t, text, "No source files found")
+}
@@ -0,0 +1,137 @@
+// Package trust records which workspaces the user has allowed tools that
+// change files or run commands in.
+package trust
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Decision is what was decided for a workspace
+type Decision int
+
+const (
+	// Undecided workspaces haven't been approved or denied yet
+	Undecided Decision = iota
+	// Trusted workspaces allow every tool
+	Trusted
+	// Denied workspaces keep mutating and command running tools disabled
+	Denied
+)
+
+// String returns the name of a decision
+func (d Decision) String() string {
+	switch d {
+	case Trusted:
+		return "trusted"
+	case Denied:
+		return "denied"
+	default:
+		return "undecided"
+	}
+}
+
+// entry is a decision as stored on disk
+type entry struct {
+	Trusted bool      `json:"trusted"`
+	Time    time.Time `json:"time"`
+}
+
+// Store keeps decisions in a JSON file keyed by absolute workspace path
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore returns a store backed by the file at path, which is created when
+// the first decision is recorded
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultPath is the store shared by every server run by the current user
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "mcp-language-server", "trusted-workspaces.json"), nil
+}
+
+// Decision returns the recorded decision for a workspace
+func (s *Store) Decision(workspace string) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.load()
+	if err != nil {
+		return Undecided, err
+	}
+	e, ok := entries[filepath.Clean(workspace)]
+	switch {
+	case !ok:
+		return Undecided, nil
+	case e.Trusted:
+		return Trusted, nil
+	default:
+		return Denied, nil
+	}
+}
+
+// Record persists a decision for a workspace
+func (s *Store) Record(workspace string, trusted bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	entries[filepath.Clean(workspace)] = entry{Trusted: trusted, Time: time.Now().UTC()}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create trust store directory: %v", err)
+	}
+	// Replace the file in one step so a concurrent reader never sees it
+	// half written
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write trust store: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write trust store: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) load() (map[string]entry, error) {
+	entries := map[string]entry{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust store: %v", err)
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid trust store %s: %v", s.path, err)
+	}
+	return entries, nil
+}
+
+// IsApproval reports whether an answer to the trust question approves it.
+// Only an exact "yes" counts; anything else, including sentences that start
+// with yes, is a refusal.
+func IsApproval(answer string) bool {
+	return strings.EqualFold(strings.TrimSpace(answer), "yes")
+}
@@ -0,0 +1,55 @@
+package trust
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "trusted-workspaces.json")
+	store := NewStore(path)
+
+	decision, err := store.Decision("/work/project")
+	require.NoError(t, err)
+	assert.Equal(t, Undecided, decision)
+
+	require.NoError(t, store.Record("/work/project/", true))
+	require.NoError(t, store.Record("/work/other", false))
+
+	// Decisions are read back from the file
+	store = NewStore(path)
+	decision, err = store.Decision("/work/project")
+	require.NoError(t, err)
+	assert.Equal(t, Trusted, decision)
+	decision, err = store.Decision("/work/other")
+	require.NoError(t, err)
+	assert.Equal(t, Denied, decision)
+	decision, err = store.Decision("/work")
+	require.NoError(t, err)
+	assert.Equal(t, Undecided, decision)
+}
+
+func TestIsApproval(t *testing.T) {
+	for answer, want := range map[string]bool{
+		"yes":                 true,
+		" Yes ":               true,
+		"no":                  false,
+		"I don't know":        false,
+		"":                    false,
+		"not yes":             false,
+		"Deny this workspace": false,
+		"Yes, trust it.":      false,
+		"  **Trust**":         false,
+		"trust":               false,
+		"allow":               false,
+		"yesterday I said no": false,
+		"yes? no":             false,
+		"Trusting this workspace is not safe, no": false,
+		"Allowing that would be risky; no":        false,
+	} {
+		assert.Equal(t, want, IsApproval(answer), answer)
+	}
+}
@@ -0,0 +1,149 @@
+// Package usage keeps statistics of the tool calls of a session: how often
+// each tool is called, how often it fails and with which error codes, how
+// long it takes and how much text it returns.
+package usage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/benchmarks"
+)
+
+// Call is one finished tool call
+type Call struct {
+	Tool    string
+	Latency time.Duration
+	// Bytes is the size of the result's content
+	Bytes int
+	// Failed is set for calls that returned an error result, and ErrorCode
+	// is the result's code
+	Failed    bool
+	ErrorCode string
+}
+
+// Stats collects the calls of a session. It is safe for concurrent use.
+type Stats struct {
+	mu      sync.Mutex
+	started time.Time
+	tools   map[string]*toolStats
+}
+
+type toolStats struct {
+	latencies  benchmarks.Latencies
+	failures   int
+	errorCodes map[string]int
+	bytes      int64
+}
+
+// NewStats returns the statistics of a session starting now
+func NewStats() *Stats {
+	return &Stats{started: time.Now(), tools: make(map[string]*toolStats)}
+}
+
+// Record adds a call
+func (s *Stats) Record(call Call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tool := s.tools[call.Tool]
+	if tool == nil {
+		tool = &toolStats{errorCodes: make(map[string]int)}
+		s.tools[call.Tool] = tool
+	}
+	tool.latencies = append(tool.latencies, call.Latency)
+	tool.bytes += int64(call.Bytes)
+	if call.Failed {
+		tool.failures++
+		if call.ErrorCode != "" {
+			tool.errorCodes[call.ErrorCode]++
+		}
+	}
+}
+
+// Reset starts a new session, returning the report of the one that ended
+func (s *Stats) Reset() Report {
+	report := s.Report()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = time.Now()
+	s.tools = make(map[string]*toolStats)
+	return report
+}
+
+// Report is the machine-readable summary of a session's tool calls
+type Report struct {
+	// SessionID and Workspace are filled in by the server
+	SessionID string    `json:"sessionId,omitempty"`
+	Workspace string    `json:"workspace,omitempty"`
+	Started   time.Time `json:"started"`
+	Ended     time.Time `json:"ended"`
+	Calls     int       `json:"calls"`
+	Failures  int       `json:"failures"`
+	// Tools are ordered by how often they were called, most first
+	Tools []ToolReport `json:"tools"`
+}
+
+// ToolReport summarizes the calls to one tool. Latencies are in
+// milliseconds.
+type ToolReport struct {
+	Name        string         `json:"name"`
+	Calls       int            `json:"calls"`
+	Failures    int            `json:"failures"`
+	FailureRate float64        `json:"failureRate"`
+	ErrorCodes  map[string]int `json:"errorCodes,omitempty"`
+	P50Ms       float64        `json:"p50Ms"`
+	P95Ms       float64        `json:"p95Ms"`
+	MaxMs       float64        `json:"maxMs"`
+	MeanMs      float64        `json:"meanMs"`
+	Bytes       int64          `json:"bytes"`
+	MeanBytes   int64          `json:"meanBytes"`
+}
+
+// Report summarizes the calls so far
+func (s *Stats) Report() Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	report := Report{Started: s.started, Ended: time.Now(), Tools: []ToolReport{}}
+	for name, tool := range s.tools {
+		calls := len(tool.latencies)
+		var total time.Duration
+		for _, latency := range tool.latencies {
+			total += latency
+		}
+		t := ToolReport{
+			Name:        name,
+			Calls:       calls,
+			Failures:    tool.failures,
+			FailureRate: float64(tool.failures) / float64(calls),
+			P50Ms:       milliseconds(tool.latencies.Percentile(50)),
+			P95Ms:       milliseconds(tool.latencies.Percentile(95)),
+			MaxMs:       milliseconds(tool.latencies.Percentile(100)),
+			MeanMs:      milliseconds(total / time.Duration(calls)),
+			Bytes:       tool.bytes,
+			MeanBytes:   tool.bytes / int64(calls),
+		}
+		if len(tool.errorCodes) > 0 {
+			t.ErrorCodes = make(map[string]int, len(tool.errorCodes))
+			for code, count := range tool.errorCodes {
+				t.ErrorCodes[code] = count
+			}
+		}
+		report.Tools = append(report.Tools, t)
+		report.Calls += calls
+		report.Failures += tool.failures
+	}
+	sort.Slice(report.Tools, func(i, j int) bool {
+		a, b := report.Tools[i], report.Tools[j]
+		if a.Calls != b.Calls {
+			return a.Calls > b.Calls
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
+
+// milliseconds converts a duration, to the microsecond
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Round(time.Microsecond)) / float64(time.Millisecond)
+}
@@ -0,0 +1,54 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	stats := NewStats()
+	stats.Record(Call{Tool: "hover", Latency: 10 * time.Millisecond, Bytes: 100})
+	stats.Record(Call{Tool: "references", Latency: 20 * time.Millisecond, Bytes: 300})
+	stats.Record(Call{Tool: "references", Latency: 40 * time.Millisecond, Bytes: 100, Failed: true, ErrorCode: "SYMBOL_NOT_FOUND"})
+	stats.Record(Call{Tool: "references", Latency: 30 * time.Millisecond, Failed: true})
+
+	report := stats.Report()
+	assert.Equal(t, 4, report.Calls)
+	assert.Equal(t, 2, report.Failures)
+	require.Len(t, report.Tools, 2)
+
+	// The most called tool comes first
+	references := report.Tools[0]
+	assert.Equal(t, "references", references.Name)
+	assert.Equal(t, 3, references.Calls)
+	assert.Equal(t, 2, references.Failures)
+	assert.InDelta(t, 2.0/3, references.FailureRate, 1e-9)
+	assert.Equal(t, map[string]int{"SYMBOL_NOT_FOUND": 1}, references.ErrorCodes)
+	assert.Equal(t, 30.0, references.P50Ms)
+	assert.Equal(t, 40.0, references.P95Ms)
+	assert.Equal(t, 40.0, references.MaxMs)
+	assert.Equal(t, 30.0, references.MeanMs)
+	assert.Equal(t, int64(400), references.Bytes)
+	assert.Equal(t, int64(133), references.MeanBytes)
+
+	hover := report.Tools[1]
+	assert.Equal(t, "hover", hover.Name)
+	assert.Zero(t, hover.FailureRate)
+	assert.Nil(t, hover.ErrorCodes)
+}
+
+func TestReset(t *testing.T) {
+	stats := NewStats()
+	stats.Record(Call{Tool: "hover", Latency: time.Millisecond})
+
+	ended := stats.Reset()
+	assert.Equal(t, 1, ended.Calls)
+
+	report := stats.Report()
+	assert.Zero(t, report.Calls)
+	assert.Empty(t, report.Tools)
+	assert.False(t, report.Started.Before(ended.Started))
+}
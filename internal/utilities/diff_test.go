@@ -0,0 +1,151 @@
+package utilities
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   string
+		after    string
+		expected string
+	}{
+		{
+			name:     "Equal",
+			before:   "a\nb\n",
+			after:    "a\nb\n",
+			expected: "",
+		},
+		{
+			name:   "Changed line with context",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n",
+			after:  "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			expected: "--- f.go\n+++ f.go\n" +
+				"@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name:   "Separate hunks",
+			before: "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			after:  "A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			expected: "--- f.go\n+++ f.go\n" +
+				"@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n" +
+				"@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B\n",
+		},
+		{
+			name:     "Insertion into empty file",
+			before:   "",
+			after:    "x\n",
+			expected: "--- f.go\n+++ f.go\n@@ -0,0 +1,1 @@\n+x\n",
+		},
+		{
+			name:   "Insertion between lines",
+			before: "a\nc\n",
+			after:  "a\nb\nc\n",
+			expected: "--- f.go\n+++ f.go\n" +
+				"@@ -1,2 +1,3 @@\n a\n+b\n c\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnifiedDiff("f.go", tt.before, tt.after); got != tt.expected {
+				t.Errorf("UnifiedDiff() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// FuzzUnifiedDiff checks that a diff's hunks are numbered and counted
+// consistently, and that applying them gives the new text
+func FuzzUnifiedDiff(f *testing.F) {
+	f.Add("a\nb\n", "a\nb\n")
+	f.Add("1\n2\n3\n4\n5\n6\n7\n8\n", "1\n2\n3\n4\nfive\n6\n7\n8\n")
+	f.Add("a\n1\n2\n3\n4\n5\n6\n7\nb\n", "A\n1\n2\n3\n4\n5\n6\n7\nB\n")
+	f.Add("", "x\n")
+	f.Add("x", "")
+	f.Add("a\r\n--- b\n", "+++ a\n@@ -1 @@\n")
+	f.Fuzz(func(t *testing.T, before, after string) {
+		diff := UnifiedDiff("f.go", before, after)
+		if before == after {
+			if diff != "" {
+				t.Fatalf("diff of equal texts: %q", diff)
+			}
+			return
+		}
+		got, err := patch(splitLines(before), diff)
+		if err != nil {
+			t.Fatalf("%v in diff %q", err, diff)
+		}
+		if want := splitLines(after); !slices.Equal(got, want) {
+			t.Fatalf("patched lines = %q, want %q", got, want)
+		}
+	})
+}
+
+// patch applies a unified diff of f.go to lines
+func patch(lines []string, diff string) ([]string, error) {
+	rows := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	if len(rows) < 2 || rows[0] != "--- f.go" || rows[1] != "+++ f.go" {
+		return nil, fmt.Errorf("missing file header")
+	}
+	rows = rows[2:]
+	var out []string
+	next := 0 // the index in lines of the next line to copy
+	for len(rows) > 0 {
+		var oldStart, oldCount, newStart, newCount int
+		if _, err := fmt.Sscanf(rows[0], "@@ -%d,%d +%d,%d @@", &oldStart, &oldCount, &newStart, &newCount); err != nil {
+			return nil, fmt.Errorf("bad hunk header %q: %w", rows[0], err)
+		}
+		rows = rows[1:]
+		// An empty side is numbered by the line before it
+		oldIndex, newIndex := oldStart-1, newStart-1
+		if oldCount == 0 {
+			oldIndex++
+		}
+		if newCount == 0 {
+			newIndex++
+		}
+		if oldIndex < next || oldIndex > len(lines) {
+			return nil, fmt.Errorf("hunk at old line %d out of order", oldStart)
+		}
+		out = append(out, lines[next:oldIndex]...)
+		next = oldIndex
+		if len(out) != newIndex {
+			return nil, fmt.Errorf("hunk at new line %d should be at %d", newStart, len(out)+1)
+		}
+
+		oldLines, newLines := 0, 0
+		for len(rows) > 0 && !strings.HasPrefix(rows[0], "@@") {
+			row := rows[0]
+			rows = rows[1:]
+			if row == "" {
+				return nil, fmt.Errorf("empty line in hunk")
+			}
+			switch kind, text := row[0], row[1:]; kind {
+			case ' ', '-':
+				if next >= len(lines) || lines[next] != text {
+					return nil, fmt.Errorf("hunk line %q doesn't match the text", row)
+				}
+				next++
+				oldLines++
+				if kind == ' ' {
+					out = append(out, text)
+					newLines++
+				}
+			case '+':
+				out = append(out, text)
+				newLines++
+			default:
+				return nil, fmt.Errorf("bad hunk line %q", row)
+			}
+		}
+		if oldLines != oldCount || newLines != newCount {
+			return nil, fmt.Errorf("hunk counts -%d +%d, has -%d +%d", oldCount, newCount, oldLines, newLines)
+		}
+	}
+	return append(out, lines[next:]...), nil
+}
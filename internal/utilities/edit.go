@@ -1,7 +1,8 @@
 package utilities
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"sort"
@@ -20,37 +21,99 @@ var (
 	osRename    = os.Rename
 )
 
+// ErrEditConflict matches errors from edits that conflict with each other or
+// with the files on disk
+var ErrEditConflict = errors.New("edit conflict")
+
+// conflictError is an error that matches ErrEditConflict
+type conflictError struct {
+	message string
+}
+
+func (e *conflictError) Error() string { return e.message }
+
+func (e *conflictError) Is(target error) bool { return target == ErrEditConflict }
+
+// EditNotebook applies edits to a Jupyter notebook, addressed either by the
+// notebook's path with positions in its "# %%" view or by one of its cell
+// documents. It reports false for other documents. The lsp package, which
+// knows the notebook format, replaces it; until then notebook edits are
+// refused rather than applied to the raw JSON.
+var EditNotebook = func(uri protocol.DocumentUri, edits []protocol.TextEdit) (bool, error) {
+	path := uri.Path()
+	if strings.HasSuffix(strings.ToLower(path), ".ipynb") || strings.Contains(path, ".ipynb#cell") || strings.Contains(path, ".ipynb%23cell") {
+		return true, fmt.Errorf("editing notebooks is not supported: %s", path)
+	}
+	return false, nil
+}
+
 // ApplyTextEdits applies a sequence of text edits to a file specified by URI
 func ApplyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
-	path := strings.TrimPrefix(string(uri), "file://")
+	if handled, err := EditNotebook(uri, edits); handled {
+		return err
+	}
 
-	// Read the file content
+	path := uri.Path()
+
+	// Large files are streamed rather than read whole
+	if info, err := osStat(path); err == nil && info.Size() > spliceSize {
+		return spliceTextEdits(path, edits)
+	}
+
+	// Read the file content, edited as UTF-8 and written back in its own
+	// encoding
 	content, err := osReadFile(path)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
+	text, enc, err := DecodeText(content)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
 
-	// Detect line ending style
-	var lineEnding string
-	if bytes.Contains(content, []byte("\r\n")) {
-		lineEnding = "\r\n"
-	} else {
-		lineEnding = "\n"
+	newContent, err := applyEdits(text, edits, LineEnding)
+	if err != nil {
+		return err
 	}
+	encoded, err := enc.Encode(newContent)
+	if err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if err := osWriteFile(path, encoded, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// LineEnding, when set, is the line ending files are written with after
+// they are edited. Otherwise each file keeps its own.
+var LineEnding string
+
+// ApplyEditsToText applies a sequence of text edits to the content of a
+// document and returns the new content
+func ApplyEditsToText(content string, edits []protocol.TextEdit) (string, error) {
+	return applyEdits(content, edits, "")
+}
+
+// applyEdits applies edits to content. Each line keeps its line ending, and
+// lines added by the edits end like most of the content's lines do, unless
+// lineEnding is set, in which case every line ends with it.
+func applyEdits(content string, edits []protocol.TextEdit, lineEnding string) (string, error) {
+	if lineEnding != "" {
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+	crlf := lineEnding == "" && dominantLineEnding(strings.Count(content, "\r\n"), strings.Count(content, "\n")) == "\r\n"
 
 	// Track if file ends with a newline
-	endsWithNewline := len(content) > 0 && bytes.HasSuffix(content, []byte(lineEnding))
+	endsWithNewline := strings.HasSuffix(content, "\n")
 
-	// Split into lines without the endings
-	lines := strings.Split(string(content), lineEnding)
+	// Split into lines on LF, so lines that end with CRLF keep their CR
+	lines := strings.Split(content, "\n")
 
-	// Check for overlapping edits
-	for i, edit1 := range edits {
-		for j := i + 1; j < len(edits); j++ {
-			if RangesOverlap(edit1.Range, edits[j].Range) {
-				return fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
-			}
-		}
+	if err := checkOverlaps(edits); err != nil {
+		return "", err
 	}
 
 	// Sort edits in reverse order
@@ -65,45 +128,99 @@ func ApplyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
 
 	// Apply each edit
 	for _, edit := range sortedEdits {
-		newLines, err := ApplyTextEdit(lines, edit, lineEnding)
+		newLines, err := applyTextEdit(lines, edit, crlf)
 		if err != nil {
-			return fmt.Errorf("failed to apply edit: %w", err)
+			return "", fmt.Errorf("failed to apply edit: %w", err)
 		}
 		lines = newLines
 	}
 
 	// Join lines with proper line endings
-	var newContent strings.Builder
-	for i, line := range lines {
-		if i > 0 {
-			newContent.WriteString(lineEnding)
+	newContent := strings.Join(lines, "\n")
+
+	// Only add a newline if the original file had one and we haven't already added it
+	if endsWithNewline && !strings.HasSuffix(newContent, "\n") {
+		if crlf {
+			newContent += "\r"
 		}
-		newContent.WriteString(line)
+		newContent += "\n"
 	}
 
-	// Only add a newline if the original file had one and we haven't already added it
-	if endsWithNewline && !strings.HasSuffix(newContent.String(), lineEnding) {
-		newContent.WriteString(lineEnding)
+	if lineEnding == "\r\n" {
+		newContent = strings.ReplaceAll(newContent, "\n", "\r\n")
 	}
+	return newContent, nil
+}
 
-	if err := osWriteFile(path, []byte(newContent.String()), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+// checkOverlaps returns a conflict error if any two edits overlap
+func checkOverlaps(edits []protocol.TextEdit) error {
+	for i, edit1 := range edits {
+		for j := i + 1; j < len(edits); j++ {
+			if RangesOverlap(edit1.Range, edits[j].Range) {
+				return &conflictError{fmt.Sprintf("overlapping edits detected between edit %d and %d", i, j)}
+			}
+		}
 	}
+	return nil
+}
 
+// checkRange returns an error if a range ends before it starts
+func checkRange(r protocol.Range) error {
+	if r.End.Line < r.Start.Line || r.End.Line == r.Start.Line && r.End.Character < r.Start.Character {
+		return fmt.Errorf("invalid range: end %d:%d is before start %d:%d",
+			r.End.Line, r.End.Character, r.Start.Line, r.Start.Character)
+	}
 	return nil
 }
 
-// ApplyTextEdit applies a single text edit to a set of lines
-func ApplyTextEdit(lines []string, edit protocol.TextEdit, lineEnding string) ([]string, error) {
+// dominantLineEnding is the line ending most lines of a text end with,
+// given how many CRLFs and LFs, including those of the CRLFs, it has
+func dominantLineEnding(crlf, lf int) string {
+	if crlf > 0 && crlf >= lf-crlf {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// FileLineEnding returns the line ending a file with content is written
+// with: LineEnding if it is set, or else the one most of its lines end with
+func FileLineEnding(content string) string {
+	if LineEnding != "" {
+		return LineEnding
+	}
+	return dominantLineEnding(strings.Count(content, "\r\n"), strings.Count(content, "\n"))
+}
+
+// SetLineEndings returns text with every line ending in newline
+func SetLineEndings(text, newline string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if newline != "\n" {
+		text = strings.ReplaceAll(text, "\n", newline)
+	}
+	return text
+}
+
+// ApplyTextEdit applies a single text edit to a set of lines split on LF. A
+// CR ending a line is its line ending, which is kept. Characters are
+// counted in UTF-16 code units, as in LSP positions.
+func ApplyTextEdit(lines []string, edit protocol.TextEdit) ([]string, error) {
+	return applyTextEdit(lines, edit, false)
+}
+
+// applyTextEdit applies an edit to lines split on LF. A CR at the end of a
+// line belongs to its line ending, and with crlf the lines the edit adds end
+// with one too.
+func applyTextEdit(lines []string, edit protocol.TextEdit, crlf bool) ([]string, error) {
 	startLine := int(edit.Range.Start.Line)
 	endLine := int(edit.Range.End.Line)
-	startChar := int(edit.Range.Start.Character)
-	endChar := int(edit.Range.End.Character)
 
 	// Validate positions
 	if startLine < 0 || startLine >= len(lines) {
 		return nil, fmt.Errorf("invalid start line: %d", startLine)
 	}
+	if err := checkRange(edit.Range); err != nil {
+		return nil, err
+	}
 	if endLine < 0 || endLine >= len(lines) {
 		endLine = len(lines) - 1
 	}
@@ -116,26 +233,33 @@ func ApplyTextEdit(lines []string, edit protocol.TextEdit, lineEnding string) ([
 
 	// Get the prefix of the start line
 	startLineContent := lines[startLine]
-	if startChar < 0 || startChar > len(startLineContent) {
-		startChar = len(startLineContent)
-	}
+	startChar := ByteOffset(strings.TrimSuffix(startLineContent, "\r"), int(edit.Range.Start.Character))
 	prefix := startLineContent[:startChar]
 
 	// Get the suffix of the end line
 	endLineContent := lines[endLine]
-	if endChar < 0 || endChar > len(endLineContent) {
-		endChar = len(endLineContent)
+	endChar := ByteOffset(strings.TrimSuffix(endLineContent, "\r"), int(edit.Range.End.Character))
+	if endLine == startLine && endChar < startChar {
+		// The end was past the last line, which the start is on
+		endChar = startChar
 	}
 	suffix := endLineContent[endChar:]
 
 	// Handle the edit
 	if edit.NewText == "" {
-		if prefix+suffix != "" {
+		// Removing the content of whole lines removes the lines, but an
+		// empty edit leaves them as they are
+		if prefix+strings.TrimSuffix(suffix, "\r") != "" || endLine == startLine && endChar == startChar {
 			result = append(result, prefix+suffix)
 		}
 	} else {
-		// Split new text into lines
-		newLines := strings.Split(edit.NewText, "\n")
+		// Split new text into lines, whatever their endings
+		newLines := strings.Split(strings.ReplaceAll(edit.NewText, "\r\n", "\n"), "\n")
+		if crlf {
+			for i := range newLines[:len(newLines)-1] {
+				newLines[i] += "\r"
+			}
+		}
 
 		if len(newLines) == 1 {
 			// Single line change
@@ -173,7 +297,7 @@ func ApplyTextEdit(lines []string, edit protocol.TextEdit, lineEnding string) ([
 // ApplyDocumentChange applies a DocumentChange (create/rename/delete operations)
 func ApplyDocumentChange(change protocol.DocumentChange) error {
 	if change.CreateFile != nil {
-		path := strings.TrimPrefix(string(change.CreateFile.URI), "file://")
+		path := change.CreateFile.URI.Path()
 		if change.CreateFile.Options != nil {
 			if change.CreateFile.Options.Overwrite {
 				// Proceed with overwrite
@@ -189,7 +313,7 @@ func ApplyDocumentChange(change protocol.DocumentChange) error {
 	}
 
 	if change.DeleteFile != nil {
-		path := strings.TrimPrefix(string(change.DeleteFile.URI), "file://")
+		path := change.DeleteFile.URI.Path()
 		if change.DeleteFile.Options != nil && change.DeleteFile.Options.Recursive {
 			if err := osRemoveAll(path); err != nil {
 				return fmt.Errorf("failed to delete directory recursively: %w", err)
@@ -202,12 +326,12 @@ func ApplyDocumentChange(change protocol.DocumentChange) error {
 	}
 
 	if change.RenameFile != nil {
-		oldPath := strings.TrimPrefix(string(change.RenameFile.OldURI), "file://")
-		newPath := strings.TrimPrefix(string(change.RenameFile.NewURI), "file://")
+		oldPath := change.RenameFile.OldURI.Path()
+		newPath := change.RenameFile.NewURI.Path()
 		if change.RenameFile.Options != nil {
 			if !change.RenameFile.Options.Overwrite {
 				if _, err := osStat(newPath); err == nil {
-					return fmt.Errorf("target file already exists and overwrite is not allowed: %s", newPath)
+					return &conflictError{fmt.Sprintf("target file already exists and overwrite is not allowed: %s", newPath)}
 				}
 			}
 		}
@@ -219,6 +343,12 @@ func ApplyDocumentChange(change protocol.DocumentChange) error {
 	if change.TextDocumentEdit != nil {
 		textEdits := make([]protocol.TextEdit, len(change.TextDocumentEdit.Edits))
 		for i, edit := range change.TextDocumentEdit.Edits {
+			// Snippets are expanded rather than written out with their
+			// tabstops and placeholders
+			if snippet, ok := edit.Value.(protocol.SnippetTextEdit); ok {
+				textEdits[i] = protocol.TextEdit{Range: snippet.Range, NewText: ExpandSnippet(snippet.Snippet.Value)}
+				continue
+			}
 			var err error
 			textEdits[i], err = edit.AsTextEdit()
 			if err != nil {
@@ -231,8 +361,16 @@ func ApplyDocumentChange(change protocol.DocumentChange) error {
 	return nil
 }
 
-// ApplyWorkspaceEdit applies the given WorkspaceEdit to the filesystem
-func ApplyWorkspaceEdit(edit protocol.WorkspaceEdit) error {
+// ApplyWorkspaceEdit applies the given WorkspaceEdit to the filesystem. An
+// edit that would delete most of a file is refused before anything is
+// written, unless ctx forces edits, and the files it changes are reported
+// to the watcher of ctx.
+func ApplyWorkspaceEdit(ctx context.Context, edit protocol.WorkspaceEdit) error {
+	if err := checkDeletions(ctx, edit); err != nil {
+		return err
+	}
+	notifyEditWatchers(ctx, edit)
+
 	// Handle Changes field
 	for uri, textEdits := range edit.Changes {
 		if err := ApplyTextEdits(uri, textEdits); err != nil {
@@ -1,12 +1,14 @@
 package utilities
 
 import (
+	"context"
 	"errors"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 )
@@ -266,12 +268,11 @@ func TestRangesOverlap(t *testing.T) {
 
 func TestApplyTextEdit(t *testing.T) {
 	tests := []struct {
-		name       string
-		lines      []string
-		edit       protocol.TextEdit
-		lineEnding string
-		expected   []string
-		expectErr  bool
+		name      string
+		lines     []string
+		edit      protocol.TextEdit
+		expected  []string
+		expectErr bool
 	}{
 		{
 			name:  "Delete text - single line",
@@ -283,9 +284,8 @@ func TestApplyTextEdit(t *testing.T) {
 				},
 				NewText: "",
 			},
-			lineEnding: "\n",
-			expected:   []string{"This  test line"},
-			expectErr:  false,
+			expected:  []string{"This  test line"},
+			expectErr: false,
 		},
 		{
 			name:  "Replace text - single line",
@@ -297,9 +297,8 @@ func TestApplyTextEdit(t *testing.T) {
 				},
 				NewText: "was",
 			},
-			lineEnding: "\n",
-			expected:   []string{"This was test line"},
-			expectErr:  false,
+			expected:  []string{"This was test line"},
+			expectErr: false,
 		},
 		{
 			name:  "Insert text - single line",
@@ -311,9 +310,8 @@ func TestApplyTextEdit(t *testing.T) {
 				},
 				NewText: "really ",
 			},
-			lineEnding: "\n",
-			expected:   []string{"This really is a test line"},
-			expectErr:  false,
+			expected:  []string{"This really is a test line"},
+			expectErr: false,
 		},
 		{
 			name:  "Delete text - multi-line",
@@ -325,9 +323,8 @@ func TestApplyTextEdit(t *testing.T) {
 				},
 				NewText: "",
 			},
-			lineEnding: "\n",
-			expected:   []string{"Line 3"},
-			expectErr:  false,
+			expected:  []string{"Line 3"},
+			expectErr: false,
 		},
 		{
 			name:  "Replace text - multi-line",
@@ -339,9 +336,8 @@ func TestApplyTextEdit(t *testing.T) {
 				},
 				NewText: "updated content",
 			},
-			lineEnding: "\n",
-			expected:   []string{"Liupdated contentne 3"},
-			expectErr:  false,
+			expected:  []string{"Liupdated contentne 3"},
+			expectErr: false,
 		},
 		{
 			name:  "Replace text with multi-line content",
@@ -353,9 +349,8 @@ func TestApplyTextEdit(t *testing.T) {
 				},
 				NewText: "new\ntext\ncontent",
 			},
-			lineEnding: "\n",
-			expected:   []string{"Linew", "text", "contentne 3"},
-			expectErr:  false,
+			expected:  []string{"Linew", "text", "contentne 3"},
+			expectErr: false,
 		},
 		{
 			name:  "Invalid start line",
@@ -367,9 +362,8 @@ func TestApplyTextEdit(t *testing.T) {
 				},
 				NewText: "newtext",
 			},
-			lineEnding: "\n",
-			expected:   nil,
-			expectErr:  true,
+			expected:  nil,
+			expectErr: true,
 		},
 		{
 			name:  "End line beyond file - should default to last line",
@@ -381,9 +375,8 @@ func TestApplyTextEdit(t *testing.T) {
 				},
 				NewText: "newtext",
 			},
-			lineEnding: "\n",
-			expected:   []string{"Line 1", "LinewtextLine 3"},
-			expectErr:  false,
+			expected:  []string{"Line 1", "LinewtextLine 3"},
+			expectErr: false,
 		},
 		{
 			name:  "Start character beyond line length",
@@ -395,9 +388,8 @@ func TestApplyTextEdit(t *testing.T) {
 				},
 				NewText: "newtext",
 			},
-			lineEnding: "\n",
-			expected:   []string{"Line 1newtextne 2", "Line 3"},
-			expectErr:  false,
+			expected:  []string{"Line 1newtextne 2", "Line 3"},
+			expectErr: false,
 		},
 		{
 			name:  "End character beyond line length",
@@ -409,9 +401,8 @@ func TestApplyTextEdit(t *testing.T) {
 				},
 				NewText: "newtext",
 			},
-			lineEnding: "\n",
-			expected:   []string{"Linewtext", "Line 3"},
-			expectErr:  false,
+			expected:  []string{"Linewtext", "Line 3"},
+			expectErr: false,
 		},
 		{
 			name:  "Empty file - first insertion",
@@ -423,9 +414,8 @@ func TestApplyTextEdit(t *testing.T) {
 				},
 				NewText: "New content",
 			},
-			lineEnding: "\n",
-			expected:   []string{"New content"},
-			expectErr:  false,
+			expected:  []string{"New content"},
+			expectErr: false,
 		},
 		{
 			name:  "Replace entire file with empty content",
@@ -437,15 +427,66 @@ func TestApplyTextEdit(t *testing.T) {
 				},
 				NewText: "",
 			},
-			lineEnding: "\n",
-			expected:   []string{},
-			expectErr:  false,
+			expected:  []string{},
+			expectErr: false,
+		},
+		{
+			name:  "Characters counted in UTF-16 code units",
+			lines: []string{"a😀b é"},
+			edit: protocol.TextEdit{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 3},
+					End:   protocol.Position{Line: 0, Character: 6},
+				},
+				NewText: "c",
+			},
+			expected:  []string{"a😀c"},
+			expectErr: false,
+		},
+		{
+			name:  "Empty edit keeps an empty line",
+			lines: []string{"Line 1", "", "Line 3"},
+			edit: protocol.TextEdit{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 1, Character: 0},
+					End:   protocol.Position{Line: 1, Character: 0},
+				},
+				NewText: "",
+			},
+			expected:  []string{"Line 1", "", "Line 3"},
+			expectErr: false,
+		},
+		{
+			name:  "CRLF in new text",
+			lines: []string{"Line 1", "Line 2"},
+			edit: protocol.TextEdit{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 4},
+					End:   protocol.Position{Line: 0, Character: 4},
+				},
+				NewText: "\r\n",
+			},
+			expected:  []string{"Line", " 1", "Line 2"},
+			expectErr: false,
+		},
+		{
+			name:  "End before start",
+			lines: []string{"Line 1", "Line 2"},
+			edit: protocol.TextEdit{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 4},
+					End:   protocol.Position{Line: 0, Character: 2},
+				},
+				NewText: "",
+			},
+			expected:  nil,
+			expectErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := ApplyTextEdit(tt.lines, tt.edit, tt.lineEnding)
+			result, err := ApplyTextEdit(tt.lines, tt.edit)
 			if tt.expectErr {
 				if err == nil {
 					t.Errorf("Expected error but got none")
@@ -461,6 +502,72 @@ func TestApplyTextEdit(t *testing.T) {
 	}
 }
 
+// FuzzApplyEditsToText checks that an edit changes only the range it covers
+func FuzzApplyEditsToText(f *testing.F) {
+	f.Add("This is a test line", uint32(0), uint32(5), uint32(0), uint32(9), "was")
+	f.Add("Line 1\r\nLine 2\r\nLine 3", uint32(0), uint32(2), uint32(2), uint32(2), "new\r\ntext")
+	f.Add("Line 1\nLine 2\n", uint32(1), uint32(0), uint32(5), uint32(0), "")
+	f.Add("a😀b\n", uint32(0), uint32(2), uint32(0), uint32(3), "")
+	f.Add("a\n\nb", uint32(1), uint32(0), uint32(1), uint32(0), "")
+	f.Add("", uint32(0), uint32(0), uint32(0), uint32(0), "x\n")
+	f.Fuzz(func(t *testing.T, content string, startLine, startChar, endLine, endChar uint32, newText string) {
+		if crlf := strings.Count(content, "\r\n"); crlf > 0 && crlf != strings.Count(content, "\n") {
+			t.Skip("line breaks in the new text end like most of the file's lines")
+		}
+		lines := strings.Split(content, "\n")
+		rng := protocol.Range{
+			Start: protocol.Position{Line: startLine, Character: startChar},
+			End:   protocol.Position{Line: endLine, Character: endChar},
+		}
+		apply := func(rng protocol.Range, text string) (string, error) {
+			return ApplyEditsToText(content, []protocol.TextEdit{{Range: rng, NewText: text}})
+		}
+
+		result, err := apply(rng, newText)
+		reversed := endLine < startLine || endLine == startLine && endChar < startChar
+		if int(startLine) >= len(lines) || reversed {
+			if err == nil {
+				t.Fatalf("expected an error for %v in %d lines", rng, len(lines))
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error for %v: %v", rng, err)
+		}
+		if utf8.ValidString(content) && utf8.ValidString(newText) && !utf8.ValidString(result) {
+			t.Fatalf("edit %v produced invalid UTF-8: %q", rng, result)
+		}
+
+		// Replacing a range with its own text changes nothing
+		if text := textInRange(lines, rng); text != "" {
+			if result, err := apply(rng, text); err != nil || result != content {
+				t.Fatalf("replacing %v with %q gave %q, %v", rng, text, result, err)
+			}
+		}
+		// Nor does an empty edit
+		empty := protocol.Range{Start: rng.Start, End: rng.Start}
+		if result, err := apply(empty, ""); err != nil || result != content {
+			t.Fatalf("empty edit at %v gave %q, %v", rng.Start, result, err)
+		}
+	})
+}
+
+// textInRange returns the text a range covers in lines split on LF, clamped
+// to the lines as ApplyTextEdit clamps it, before the CR that ends a line
+func textInRange(lines []string, rng protocol.Range) string {
+	startLine, endLine := int(rng.Start.Line), min(int(rng.End.Line), len(lines)-1)
+	start := ByteOffset(strings.TrimSuffix(lines[startLine], "\r"), int(rng.Start.Character))
+	end := ByteOffset(strings.TrimSuffix(lines[endLine], "\r"), int(rng.End.Character))
+	if startLine == endLine {
+		return lines[startLine][start:max(start, end)]
+	}
+	text := lines[startLine][start:]
+	for _, line := range lines[startLine+1 : endLine] {
+		text += "\n" + line
+	}
+	return text + "\n" + lines[endLine][:end]
+}
+
 func TestApplyTextEdits(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -887,6 +994,42 @@ func TestApplyDocumentChange(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "Snippet text edit",
+			change: protocol.DocumentChange{
+				TextDocumentEdit: &protocol.TextDocumentEdit{
+					TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+						TextDocumentIdentifier: protocol.TextDocumentIdentifier{
+							URI: "file:///test/document.txt",
+						},
+					},
+					Edits: []protocol.Or_TextDocumentEdit_edits_Elem{
+						{
+							Value: protocol.SnippetTextEdit{
+								Range: protocol.Range{
+									Start: protocol.Position{Line: 0, Character: 0},
+									End:   protocol.Position{Line: 0, Character: 0},
+								},
+								Snippet: protocol.StringValue{Kind: "snippet", Value: "let ${1:value} = 1;$0\n"},
+							},
+						},
+					},
+				},
+			},
+			expectErr: false,
+			setupMocks: func(mfs *mockFileSystem) {
+				mfs.files = map[string][]byte{
+					"/test/document.txt": []byte("print(value);"),
+				}
+			},
+			checkState: func(t *testing.T, mfs *mockFileSystem) {
+				if content, ok := mfs.files["/test/document.txt"]; !ok {
+					t.Errorf("File not found")
+				} else if string(content) != "let value = 1;\nprint(value);" {
+					t.Errorf("Snippet not expanded correctly, content: %q", string(content))
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1100,7 +1243,7 @@ func TestApplyWorkspaceEdit(t *testing.T) {
 			cleanup := setupMockFileSystem(t, mfs)
 			defer cleanup()
 
-			err := ApplyWorkspaceEdit(tt.edit)
+			err := ApplyWorkspaceEdit(context.Background(), tt.edit)
 			if tt.expectErr {
 				if err == nil {
 					t.Errorf("Expected error but got none")
@@ -1114,3 +1257,37 @@ func TestApplyWorkspaceEdit(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyTextEditsRefusesNotebooks(t *testing.T) {
+	// Without the lsp package notebooks can't be edited through their view
+	for _, uri := range []protocol.DocumentUri{"file:///work/nb.ipynb", "file:///work/nb.ipynb%23cell1"} {
+		err := ApplyTextEdits(uri, []protocol.TextEdit{{NewText: "x"}})
+		if err == nil || !strings.Contains(err.Error(), "not supported") {
+			t.Errorf("ApplyTextEdits(%s) = %v, want an error", uri, err)
+		}
+	}
+}
+
+func TestApplyEditsLineEndings(t *testing.T) {
+	insert := []protocol.TextEdit{textEdit(1, 0, 1, 0, "new1\nnew2\r\n")}
+
+	// Added lines end like most of the file's lines
+	got, err := ApplyEditsToText("a\r\nb\r\nc\n", insert)
+	if err != nil || got != "a\r\nnew1\r\nnew2\r\nb\r\nc\n" {
+		t.Errorf("mostly CRLF: got %q, %v", got, err)
+	}
+	got, err = ApplyEditsToText("a\nb\r\nc\nd\n", insert)
+	if err != nil || got != "a\nnew1\nnew2\nb\r\nc\nd\n" {
+		t.Errorf("mostly LF: got %q, %v", got, err)
+	}
+
+	// An override ends every line with it
+	got, err = applyEdits("a\nb\r\nc\n", insert, "\r\n")
+	if err != nil || got != "a\r\nnew1\r\nnew2\r\nb\r\nc\r\n" {
+		t.Errorf("CRLF override: got %q, %v", got, err)
+	}
+	got, err = applyEdits("a\r\nb\r\nc", insert, "\n")
+	if err != nil || got != "a\nnew1\nnew2\nb\nc" {
+		t.Errorf("LF override: got %q, %v", got, err)
+	}
+}
@@ -0,0 +1,124 @@
+package utilities
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Encoding is the character encoding of a file on disk. Files are given to
+// the language server and to tools as UTF-8, and written back in the
+// encoding they were read in.
+type Encoding string
+
+const (
+	UTF8    Encoding = "UTF-8"
+	UTF8BOM Encoding = "UTF-8 with BOM"
+	UTF16LE Encoding = "UTF-16LE"
+	UTF16BE Encoding = "UTF-16BE"
+	// Latin1 is assumed for files that aren't valid UTF-8. Every byte is a
+	// character in it, so such files are written back unchanged outside
+	// of the edits.
+	Latin1 Encoding = "ISO-8859-1"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// DetectEncoding works out the encoding of a file's content from its byte
+// order mark, or else from whether it is valid UTF-8
+func DetectEncoding(content []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		return UTF8BOM
+	case bytes.HasPrefix(content, bomUTF16LE):
+		return UTF16LE
+	case bytes.HasPrefix(content, bomUTF16BE):
+		return UTF16BE
+	case utf8.Valid(content):
+		return UTF8
+	default:
+		return Latin1
+	}
+}
+
+// transcoder converts the encoding to and from UTF-8, or is nil for UTF-8
+func (e Encoding) transcoder() encoding.Encoding {
+	switch e {
+	case UTF8BOM:
+		return unicode.UTF8BOM
+	case UTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+	case UTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+	case Latin1:
+		return charmap.ISO8859_1
+	default:
+		return nil
+	}
+}
+
+// Decode converts content in the encoding to UTF-8, without a byte order mark
+func (e Encoding) Decode(content []byte) (string, error) {
+	transcoder := e.transcoder()
+	if transcoder == nil {
+		return string(content), nil
+	}
+	decoded, err := transcoder.NewDecoder().Bytes(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", e, err)
+	}
+	return string(decoded), nil
+}
+
+// Encode converts UTF-8 text to the encoding, with its byte order mark if
+// it has one. Characters the encoding can't represent are an error rather
+// than being replaced.
+func (e Encoding) Encode(text string) ([]byte, error) {
+	transcoder := e.transcoder()
+	if transcoder == nil {
+		return []byte(text), nil
+	}
+	encoded, err := transcoder.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode as %s: %w", e, err)
+	}
+	return encoded, nil
+}
+
+// decodingReader returns r read as UTF-8
+func (e Encoding) decodingReader(r io.Reader) io.Reader {
+	if transcoder := e.transcoder(); transcoder != nil {
+		return transform.NewReader(r, transcoder.NewDecoder())
+	}
+	return r
+}
+
+// encodingWriter returns a writer of UTF-8 that writes to w in the
+// encoding. It has to be closed to write out the end.
+func (e Encoding) encodingWriter(w io.Writer) io.WriteCloser {
+	if transcoder := e.transcoder(); transcoder != nil {
+		return transform.NewWriter(w, transcoder.NewEncoder())
+	}
+	return nopWriteCloser{w}
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// DecodeText detects the encoding of content and converts it to UTF-8
+func DecodeText(content []byte) (string, Encoding, error) {
+	enc := DetectEncoding(content)
+	text, err := enc.Decode(content)
+	return text, enc, err
+}
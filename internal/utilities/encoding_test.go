@@ -0,0 +1,115 @@
+package utilities
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		content []byte
+		want    Encoding
+	}{
+		{[]byte("plain ascii\n"), UTF8},
+		{[]byte("caf\xc3\xa9\n"), UTF8},
+		{[]byte(""), UTF8},
+		{[]byte("\xef\xbb\xbfwith bom\n"), UTF8BOM},
+		{[]byte("\xff\xfeh\x00i\x00"), UTF16LE},
+		{[]byte("\xfe\xff\x00h\x00i"), UTF16BE},
+		{[]byte("caf\xe9\n"), Latin1},
+	}
+	for _, tt := range tests {
+		if got := DetectEncoding(tt.content); got != tt.want {
+			t.Errorf("DetectEncoding(%q) = %s, want %s", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestEncodingRoundTrip(t *testing.T) {
+	for _, enc := range []Encoding{UTF8, UTF8BOM, UTF16LE, UTF16BE, Latin1} {
+		encoded, err := enc.Encode("café\r\nline2\n")
+		if err != nil {
+			t.Fatalf("%s: Encode: %v", enc, err)
+		}
+		if got := DetectEncoding(encoded); got != enc {
+			t.Errorf("%s: detected as %s", enc, got)
+		}
+		text, err := enc.Decode(encoded)
+		if err != nil || text != "café\r\nline2\n" {
+			t.Errorf("%s: Decode = %q, %v", enc, text, err)
+		}
+	}
+
+	if _, err := Latin1.Encode("€"); err == nil {
+		t.Error("encoding € as Latin-1 succeeded, want an error")
+	}
+}
+
+// TestApplyTextEditsKeepsEncoding edits files in each encoding, both read
+// whole and spliced, and checks they are written back in it
+func TestApplyTextEditsKeepsEncoding(t *testing.T) {
+	edits := []protocol.TextEdit{
+		textEdit(0, 3, 0, 4, "é!"),
+		textEdit(2, 0, 2, 0, "ünïcode\n"),
+	}
+	original := "cafe\r\nline2\r\nline3\r\n"
+	want := "café!\r\nline2\r\nünïcode\r\nline3\r\n"
+
+	for _, splice := range []bool{false, true} {
+		for _, enc := range []Encoding{UTF8, UTF8BOM, UTF16LE, UTF16BE, Latin1} {
+			content, err := enc.Encode(original)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if enc == Latin1 {
+				// Only invalid UTF-8 is taken for Latin-1
+				content = append(content, "\xff\r\n"...)
+			}
+			path := filepath.Join(t.TempDir(), "file.txt")
+			if err := os.WriteFile(path, content, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			saved := spliceSize
+			if splice {
+				spliceSize = 0
+			}
+			err = ApplyTextEdits(protocol.URIFromPath(path), edits)
+			spliceSize = saved
+			if err != nil {
+				t.Fatalf("%s (spliced %v): %v", enc, splice, err)
+			}
+
+			written, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := DetectEncoding(written); got != enc {
+				t.Errorf("%s (spliced %v): written as %s", enc, splice, got)
+			}
+			text, _ := enc.Decode(written)
+			expected := want
+			if enc == Latin1 {
+				expected += "ÿ\r\n"
+			}
+			if text != expected {
+				t.Errorf("%s (spliced %v): file = %q, want %q", enc, splice, text, expected)
+			}
+		}
+	}
+}
+
+func TestReadFileDecodes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	content, _ := UTF16LE.Encode("héllo\n")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadFile(path)
+	if err != nil || string(got) != "héllo\n" {
+		t.Errorf("ReadFile = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,184 @@
+package utilities
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// MaxDeletedPercent is the largest share of a file's lines, in percent, that
+// a workspace edit may delete unless edits are forced. 0 turns the check off.
+var MaxDeletedPercent = 50
+
+// minGuardedLines is the fewest lines a file has for the deletion check to
+// apply. Rewriting most of a short file is common.
+const minGuardedLines = 20
+
+// ErrLargeDeletion matches errors from edits that would delete more of a
+// file than MaxDeletedPercent allows
+var ErrLargeDeletion = errors.New("large deletion")
+
+// deletionError is an error that matches ErrLargeDeletion
+type deletionError struct {
+	path           string
+	deleted, lines int
+}
+
+func (e *deletionError) Error() string {
+	return fmt.Sprintf("the edits would delete %d of the %d lines of %s, more than the %d%% allowed without forcing them",
+		e.deleted, e.lines, e.path, MaxDeletedPercent)
+}
+
+func (e *deletionError) Is(target error) bool { return target == ErrLargeDeletion }
+
+// forceKey marks a context whose edits skip the deletion check
+type forceKey struct{}
+
+// ForceEdits returns a context under which workspace edits skip the
+// deletion check, for a tool call that asked to force its edits. Edits of
+// other calls running at the same time are still checked.
+func ForceEdits(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceKey{}, true)
+}
+
+// forced reports whether a context forces its edits
+func forced(ctx context.Context) bool {
+	force, _ := ctx.Value(forceKey{}).(bool)
+	return force
+}
+
+// checkDeletions refuses a workspace edit that would delete more than
+// MaxDeletedPercent of the lines of a file, before any file is written
+func checkDeletions(ctx context.Context, edit protocol.WorkspaceEdit) error {
+	if MaxDeletedPercent <= 0 || forced(ctx) {
+		return nil
+	}
+	for uri, edits := range edit.Changes {
+		if err := checkDeletion(uri.Path(), edits); err != nil {
+			return err
+		}
+	}
+	for _, change := range edit.DocumentChanges {
+		if change.TextDocumentEdit == nil {
+			continue
+		}
+		var edits []protocol.TextEdit
+		for _, edit := range change.TextDocumentEdit.Edits {
+			if textEdit, err := edit.AsTextEdit(); err == nil {
+				edits = append(edits, textEdit)
+			}
+		}
+		if err := checkDeletion(change.TextDocumentEdit.TextDocument.URI.Path(), edits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDeletion counts the lines edits remove from a file, less those they
+// add. Files that don't exist yet, as when the edit creates them, pass, and
+// so do notebooks, whose edits count the lines of their view.
+func checkDeletion(path string, edits []protocol.TextEdit) error {
+	if strings.HasSuffix(strings.ToLower(path), ".ipynb") {
+		return nil
+	}
+	lines, err := countLines(path)
+	if err != nil || lines < minGuardedLines {
+		return nil
+	}
+	deleted := 0
+	for _, edit := range edits {
+		start := min(int(edit.Range.Start.Line), lines)
+		end := min(int(edit.Range.End.Line), lines)
+		deleted += max(end-start, 0) - strings.Count(edit.NewText, "\n")
+	}
+	if deleted*100 > MaxDeletedPercent*lines {
+		return &deletionError{path: path, deleted: deleted, lines: lines}
+	}
+	return nil
+}
+
+// countLines counts the lines of a file, reading it in blocks. A last line
+// without a line ending counts too.
+func countLines(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 64<<10)
+	lines := 0
+	var last byte = '\n'
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			lines += bytes.Count(buf[:n], []byte("\n"))
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			if last != '\n' {
+				lines++
+			}
+			return lines, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// EditWatcher is called with the path of each file a workspace edit changes,
+// creates or removes, before any of it is written
+type EditWatcher func(path string)
+
+// watcherKey holds the EditWatcher of a context
+type watcherKey struct{}
+
+// WatchEdits returns a context under which workspace edits are reported to
+// watcher, along with any watcher of ctx. Only edits applied with the
+// returned context or one derived from it are seen.
+func WatchEdits(ctx context.Context, watcher EditWatcher) context.Context {
+	if parent, ok := ctx.Value(watcherKey{}).(EditWatcher); ok {
+		inner := watcher
+		watcher = func(path string) {
+			parent(path)
+			inner(path)
+		}
+	}
+	return context.WithValue(ctx, watcherKey{}, watcher)
+}
+
+// notifyEditWatchers tells the watcher of ctx about the files an edit
+// changes
+func notifyEditWatchers(ctx context.Context, edit protocol.WorkspaceEdit) {
+	watcher, ok := ctx.Value(watcherKey{}).(EditWatcher)
+	if !ok {
+		return
+	}
+	var paths []string
+	for uri := range edit.Changes {
+		paths = append(paths, uri.Path())
+	}
+	for _, change := range edit.DocumentChanges {
+		switch {
+		case change.TextDocumentEdit != nil:
+			paths = append(paths, change.TextDocumentEdit.TextDocument.URI.Path())
+		case change.CreateFile != nil:
+			paths = append(paths, change.CreateFile.URI.Path())
+		case change.RenameFile != nil:
+			paths = append(paths, change.RenameFile.OldURI.Path(), change.RenameFile.NewURI.Path())
+		case change.DeleteFile != nil:
+			paths = append(paths, change.DeleteFile.URI.Path())
+		}
+	}
+	for _, path := range paths {
+		watcher(path)
+	}
+}
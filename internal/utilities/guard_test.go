@@ -0,0 +1,139 @@
+package utilities
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// guardedFile writes a file of n numbered lines
+func guardedFile(t *testing.T, dir, name string, n int) string {
+	t.Helper()
+	var content strings.Builder
+	for i := range n {
+		content.WriteString("line " + string(rune('a'+i%26)) + "\n")
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// deleteLines is an edit deleting lines [start, end) of path
+func deleteLines(path string, start, end uint32) []protocol.TextEdit {
+	return []protocol.TextEdit{{Range: protocol.Range{
+		Start: protocol.Position{Line: start},
+		End:   protocol.Position{Line: end},
+	}}}
+}
+
+func TestCheckDeletions(t *testing.T) {
+	dir := t.TempDir()
+	long := guardedFile(t, dir, "long.go", 40)
+	short := guardedFile(t, dir, "short.go", 10)
+
+	tests := []struct {
+		name    string
+		changes map[protocol.DocumentUri][]protocol.TextEdit
+		refused bool
+	}{
+		{"half", map[protocol.DocumentUri][]protocol.TextEdit{protocol.URIFromPath(long): deleteLines(long, 0, 20)}, false},
+		{"most", map[protocol.DocumentUri][]protocol.TextEdit{protocol.URIFromPath(long): deleteLines(long, 0, 30)}, true},
+		// Lines put back by the new text don't count as deleted
+		{"replaced", map[protocol.DocumentUri][]protocol.TextEdit{protocol.URIFromPath(long): {{
+			Range:   protocol.Range{End: protocol.Position{Line: 30}},
+			NewText: strings.Repeat("new\n", 25),
+		}}}, false},
+		{"short file", map[protocol.DocumentUri][]protocol.TextEdit{protocol.URIFromPath(short): deleteLines(short, 0, 10)}, false},
+		{"new file", map[protocol.DocumentUri][]protocol.TextEdit{protocol.URIFromPath(filepath.Join(dir, "new.go")): deleteLines("", 0, 30)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDeletions(context.Background(), protocol.WorkspaceEdit{Changes: tt.changes})
+			if got := errors.Is(err, ErrLargeDeletion); got != tt.refused {
+				t.Errorf("refused = %v (%v), want %v", got, err, tt.refused)
+			}
+		})
+	}
+}
+
+func TestApplyWorkspaceEditLargeDeletion(t *testing.T) {
+	dir := t.TempDir()
+	small := guardedFile(t, dir, "small.go", 10)
+	long := guardedFile(t, dir, "long.go", 40)
+	before, _ := os.ReadFile(small)
+
+	// The edit to small.go is allowed, but isn't written since the edit to
+	// long.go is refused
+	edit := protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+		protocol.URIFromPath(small): deleteLines(small, 0, 5),
+		protocol.URIFromPath(long):  deleteLines(long, 0, 40),
+	}}
+	if err := ApplyWorkspaceEdit(context.Background(), edit); !errors.Is(err, ErrLargeDeletion) {
+		t.Fatalf("expected a large deletion error, got %v", err)
+	}
+	if after, _ := os.ReadFile(small); string(after) != string(before) {
+		t.Errorf("small.go was edited: %q", after)
+	}
+
+	// Forcing the edits of one call leaves those of others checked
+	forcedCtx := ForceEdits(context.Background())
+	if err := ApplyWorkspaceEdit(context.Background(), edit); !errors.Is(err, ErrLargeDeletion) {
+		t.Fatalf("expected an unforced edit to still be refused, got %v", err)
+	}
+	if err := ApplyWorkspaceEdit(forcedCtx, edit); err != nil {
+		t.Fatalf("forced edit failed: %v", err)
+	}
+	if after, _ := os.ReadFile(long); strings.Contains(string(after), "line") {
+		t.Errorf("long.go = %q, want its lines deleted", after)
+	}
+}
+
+func TestWatchEdits(t *testing.T) {
+	dir := t.TempDir()
+	path := guardedFile(t, dir, "a.go", 3)
+	renamed := filepath.Join(dir, "b.go")
+
+	var seen []string
+	ctx := WatchEdits(context.Background(), func(path string) { seen = append(seen, path) })
+	err := ApplyWorkspaceEdit(ctx, protocol.WorkspaceEdit{DocumentChanges: []protocol.DocumentChange{{
+		RenameFile: &protocol.RenameFile{Kind: "rename", OldURI: protocol.URIFromPath(path), NewURI: protocol.URIFromPath(renamed)},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 2 || seen[0] != path || seen[1] != renamed {
+		t.Errorf("watcher saw %v, want [%s %s]", seen, path, renamed)
+	}
+
+	// Edits applied with another context, as by a concurrent call, aren't
+	// seen
+	seen = nil
+	if err := ApplyWorkspaceEdit(context.Background(), protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+		protocol.URIFromPath(renamed): {{NewText: "// b\n"}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 0 {
+		t.Errorf("watcher saw %v from another context", seen)
+	}
+
+	// Nested watchers both see an edit
+	var outer []string
+	ctx = WatchEdits(WatchEdits(context.Background(), func(path string) { outer = append(outer, path) }),
+		func(path string) { seen = append(seen, path) })
+	if err := ApplyWorkspaceEdit(ctx, protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+		protocol.URIFromPath(renamed): {{NewText: "// c\n"}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 1 || len(outer) != 1 {
+		t.Errorf("watchers saw %v and %v, want the file once each", seen, outer)
+	}
+}
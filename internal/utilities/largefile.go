@@ -0,0 +1,388 @@
+package utilities
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// MaxReadSize is the largest file, in bytes, that is read whole, e.g. to
+// open it in the language server. 0 means no limit.
+var MaxReadSize int64 = 64 << 20
+
+// spliceSize is the size above which files are edited by copying them with
+// the edited lines spliced in, rather than by reading them whole
+var spliceSize int64 = 4 << 20
+
+// ErrFileTooLarge matches errors from reading files larger than MaxReadSize
+var ErrFileTooLarge = errors.New("file too large")
+
+// tooLargeError is an error that matches ErrFileTooLarge
+type tooLargeError struct {
+	path string
+	size int64
+}
+
+func (e *tooLargeError) Error() string {
+	return fmt.Sprintf("%s is %d bytes, more than the %d bytes that are read whole", e.path, e.size, MaxReadSize)
+}
+
+func (e *tooLargeError) Is(target error) bool { return target == ErrFileTooLarge }
+
+// CheckFileSize returns an error matching ErrFileTooLarge if the file is
+// larger than MaxReadSize. Files that can't be examined are left for the
+// read to report.
+func CheckFileSize(path string) error {
+	if MaxReadSize <= 0 {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() <= MaxReadSize {
+		return nil
+	}
+	return &tooLargeError{path: path, size: info.Size()}
+}
+
+// ReadFile reads a file whole, unless it is larger than MaxReadSize, and
+// returns its content as UTF-8 whatever its encoding
+func ReadFile(path string) ([]byte, error) {
+	if err := CheckFileSize(path); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text, _, err := DecodeText(content)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(text), nil
+}
+
+// spliceTextEdits applies edits to a file the way ApplyTextEdits does, but
+// streams it into a copy that replaces it. Only the lines the edits touch
+// are held in memory.
+func spliceTextEdits(path string, edits []protocol.TextEdit) error {
+	if err := checkOverlaps(edits); err != nil {
+		return err
+	}
+	// Replace the file a symlink points to rather than the link
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	newline, enc, err := detectFileText(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	// Edits whose lines overlap are applied together to those lines
+	sorted := make([]protocol.TextEdit, len(edits))
+	copy(sorted, edits)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Range.Start.Line < sorted[j].Range.Start.Line })
+	type window struct {
+		start, end int
+		edits      []protocol.TextEdit
+	}
+	var windows []*window
+	for _, edit := range sorted {
+		if err := checkRange(edit.Range); err != nil {
+			return err
+		}
+		start, end := int(edit.Range.Start.Line), int(edit.Range.End.Line)
+		if n := len(windows); n > 0 && start <= windows[n-1].end {
+			windows[n-1].end = max(windows[n-1].end, end)
+			windows[n-1].edits = append(windows[n-1].edits, edit)
+			continue
+		}
+		windows = append(windows, &window{start: start, end: end, edits: []protocol.TextEdit{edit}})
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	// Lines are read and written as UTF-8, in the file's own encoding
+	// Lines keep their CRs, unless an override ends them all with it
+	crlf := newline == "\r\n"
+	lineEnding := "\n"
+	lines := &lineReader{r: bufio.NewReaderSize(enc.decodingReader(in), 64<<10)}
+	if LineEnding != "" {
+		crlf, lineEnding = false, LineEnding
+		lines.stripCR = true
+	}
+	buffered := bufio.NewWriterSize(tmp, 64<<10)
+	encoded := enc.encodingWriter(buffered)
+	out := &joinWriter{w: encoded, sep: lineEnding}
+	line := 0
+	for _, win := range windows {
+		for ; line < win.start; line++ {
+			if lines.done {
+				return fmt.Errorf("failed to apply edit: invalid start line: %d", win.start)
+			}
+			if err := out.nextLine(); err != nil {
+				return fmt.Errorf("failed to write file: %w", err)
+			}
+			if err := lines.copyLine(out); err != nil {
+				return fmt.Errorf("failed to copy file: %w", err)
+			}
+		}
+
+		var edited []string
+		for ; line <= win.end && !lines.done; line++ {
+			var buf bytes.Buffer
+			if err := lines.copyLine(&buf); err != nil {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+			edited = append(edited, buf.String())
+		}
+		// Apply the window's edits from the bottom up, with lines counted
+		// from its start
+		sort.Slice(win.edits, func(i, j int) bool {
+			if win.edits[i].Range.Start.Line != win.edits[j].Range.Start.Line {
+				return win.edits[i].Range.Start.Line > win.edits[j].Range.Start.Line
+			}
+			return win.edits[i].Range.Start.Character > win.edits[j].Range.Start.Character
+		})
+		for _, edit := range win.edits {
+			if int(edit.Range.Start.Line) >= win.start+len(edited) {
+				return fmt.Errorf("failed to apply edit: invalid start line: %d", edit.Range.Start.Line)
+			}
+			edit.Range.Start.Line -= uint32(win.start)
+			edit.Range.End.Line -= uint32(win.start)
+			edited, err = applyTextEdit(edited, edit, crlf)
+			if err != nil {
+				return fmt.Errorf("failed to apply edit: %w", err)
+			}
+		}
+		for _, text := range edited {
+			if err := out.nextLine(); err != nil {
+				return fmt.Errorf("failed to write file: %w", err)
+			}
+			if _, err := io.WriteString(out, text); err != nil {
+				return fmt.Errorf("failed to write file: %w", err)
+			}
+		}
+	}
+	for !lines.done {
+		if err := out.nextLine(); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		if err := lines.copyLine(out); err != nil {
+			return fmt.Errorf("failed to copy file: %w", err)
+		}
+	}
+
+	// Keep the final newline, as ApplyEditsToText does
+	if lines.read > 0 && lines.lastEmpty && !bytes.HasSuffix(out.tail, []byte("\n")) {
+		if crlf {
+			lineEnding = "\r\n"
+		}
+		if _, err := io.WriteString(out, lineEnding); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+	}
+
+	if err := encoded.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := osRename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// detectFileText works out a file's encoding and the line ending most of
+// its lines end with, reading it in blocks
+func detectFileText(path string) (newline string, enc Encoding, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 3)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", "", err
+	}
+	enc = DetectEncoding(head[:n])
+	if enc != UTF16LE && enc != UTF16BE && enc != UTF8BOM {
+		// The rest of the file decides between UTF-8 and Latin-1
+		enc = UTF8
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+
+	// UTF-16 line endings are found in the decoded text
+	r := io.Reader(f)
+	if enc == UTF16LE || enc == UTF16BE {
+		r = enc.decodingReader(f)
+	}
+	buf := make([]byte, 64<<10)
+	var last byte
+	// partial is the start of a UTF-8 sequence cut off at the end of a block
+	var partial []byte
+	var crlf, lf int
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			block := buf[:n]
+			if last == '\r' && block[0] == '\n' {
+				crlf++
+			}
+			crlf += bytes.Count(block, []byte("\r\n"))
+			lf += bytes.Count(block, []byte("\n"))
+			last = block[n-1]
+			if enc == UTF8 {
+				block = append(partial, block...)
+				cut := len(block)
+				for i := 1; i < utf8.UTFMax && i <= len(block); i++ {
+					if utf8.RuneStart(block[len(block)-i]) {
+						if !utf8.FullRune(block[len(block)-i:]) {
+							cut = len(block) - i
+						}
+						break
+					}
+				}
+				if !utf8.Valid(block[:cut]) {
+					enc = Latin1
+				}
+				partial = append([]byte{}, block[cut:]...)
+			}
+		}
+		if err == io.EOF {
+			if enc == UTF8 && len(partial) > 0 {
+				enc = Latin1
+			}
+			return dominantLineEnding(crlf, lf), enc, nil
+		}
+		if err != nil {
+			return "", "", err
+		}
+	}
+}
+
+// lineReader reads a file line by line, splitting it on LF as strings.Split
+// does, without holding more than a block of it
+type lineReader struct {
+	r *bufio.Reader
+	// stripCR drops the CR of lines that end with CRLF
+	stripCR bool
+	// done is set once the last line, which follows the last line ending,
+	// has been read, and lastEmpty tells if that line was empty
+	done      bool
+	lastEmpty bool
+	// read counts the bytes read, line endings included
+	read int64
+}
+
+// copyLine copies the next line, without its line ending, to w
+func (l *lineReader) copyLine(w io.Writer) error {
+	// A trailing '\r' is held back until it is known not to be part of a
+	// line ending
+	var pendingCR bool
+	var length int
+	for {
+		chunk, err := l.r.ReadSlice('\n')
+		if err != nil && err != bufio.ErrBufferFull && err != io.EOF {
+			return err
+		}
+		l.read += int64(len(chunk))
+		ended := err == nil
+		if ended {
+			chunk = chunk[:len(chunk)-1]
+			if l.stripCR {
+				switch {
+				case len(chunk) > 0 && chunk[len(chunk)-1] == '\r':
+					chunk = chunk[:len(chunk)-1]
+				case len(chunk) == 0 && pendingCR:
+					pendingCR = false
+				}
+			}
+		}
+		if pendingCR {
+			if _, err := w.Write([]byte{'\r'}); err != nil {
+				return err
+			}
+			length++
+			pendingCR = false
+		}
+		if l.stripCR && !ended && err != io.EOF && len(chunk) > 0 && chunk[len(chunk)-1] == '\r' {
+			chunk = chunk[:len(chunk)-1]
+			pendingCR = true
+		}
+		if _, werr := w.Write(chunk); werr != nil {
+			return werr
+		}
+		length += len(chunk)
+		if ended {
+			return nil
+		}
+		if err == io.EOF {
+			l.done = true
+			l.lastEmpty = length == 0
+			return nil
+		}
+	}
+}
+
+// joinWriter writes lines joined by sep, remembering the last bytes written
+type joinWriter struct {
+	w     io.Writer
+	sep   string
+	lines int
+	tail  []byte
+}
+
+// nextLine starts a line, after the separator if it isn't the first
+func (j *joinWriter) nextLine() error {
+	j.lines++
+	if j.lines == 1 {
+		return nil
+	}
+	_, err := io.WriteString(j, j.sep)
+	return err
+}
+
+func (j *joinWriter) Write(p []byte) (int, error) {
+	n, err := j.w.Write(p)
+	j.tail = append(j.tail, p[:n]...)
+	if len(j.tail) > 2 {
+		j.tail = j.tail[len(j.tail)-2:]
+	}
+	return n, err
+}
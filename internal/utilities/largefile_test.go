@@ -0,0 +1,176 @@
+package utilities
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+func textEdit(startLine, startChar, endLine, endChar uint32, text string) protocol.TextEdit {
+	return protocol.TextEdit{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: startLine, Character: startChar},
+			End:   protocol.Position{Line: endLine, Character: endChar},
+		},
+		NewText: text,
+	}
+}
+
+func TestSpliceTextEditsMatchesApplyEditsToText(t *testing.T) {
+	contents := map[string]string{
+		"lf":             "line1\nline2\nline3\nline4\nline5\n",
+		"no final":       "line1\nline2\nline3\nline4\nline5",
+		"crlf":           "line1\r\nline2\r\nline3\r\nline4\r\nline5\r\n",
+		"crlf lone lf":   "line1\r\nli\nne2\r\nline3\r\nline4\r\nline5\r\n",
+		"cr at boundary": "line1\r\r\nline2\r\nline3\r\nline4\r\nline5",
+		"unicode":        "αβγ\n😀x😀\nline3\nline4\nline5\n",
+		"empty lines":    "\n\n\nline4\n\n",
+		"mostly lf":      "line1\nline2\r\nline3\nline4\nline5\n",
+		"trailing cr":    "line1\r\nline2\r\nline3\r\nline4\r\nline5\r",
+	}
+	edits := map[string][]protocol.TextEdit{
+		"replace in line":      {textEdit(1, 1, 1, 3, "XY")},
+		"insert lines":         {textEdit(2, 0, 2, 0, "new1\nnew2\n")},
+		"delete whole lines":   {textEdit(1, 0, 2, 5, "")},
+		"delete last lines":    {textEdit(3, 0, 5, 0, "")},
+		"delete to past end":   {textEdit(3, 0, 40, 0, "")},
+		"replace everything":   {textEdit(0, 0, 40, 0, "all\r\nnew")},
+		"several":              {textEdit(0, 0, 0, 2, "A"), textEdit(0, 3, 0, 4, "B"), textEdit(3, 1, 4, 2, "C\nD")},
+		"adjacent deletions":   {textEdit(2, 0, 2, 5, ""), textEdit(3, 0, 5, 0, "")},
+		"multi-line same line": {textEdit(4, 2, 4, 2, "1\n2\n3")},
+		"surrogate pair":       {textEdit(1, 2, 1, 3, "-")},
+	}
+
+	overrides := map[string]string{"keep": "", "lf": "\n", "crlf": "\r\n"}
+	for contentName, content := range contents {
+		for editName, edit := range edits {
+			for overrideName, override := range overrides {
+				t.Run(contentName+"/"+editName+"/"+overrideName, func(t *testing.T) {
+					testSpliceMatches(t, content, edit, override)
+				})
+			}
+		}
+	}
+}
+
+// testSpliceMatches checks that splicing edits into a file gives the
+// content applying them in memory does
+func testSpliceMatches(t *testing.T, content string, edit []protocol.TextEdit, lineEnding string) {
+	saved := LineEnding
+	LineEnding = lineEnding
+	defer func() { LineEnding = saved }()
+
+	want, wantErr := applyEdits(content, edit, lineEnding)
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	err := spliceTextEdits(path, edit)
+	if (err != nil) != (wantErr != nil) {
+		t.Fatalf("spliceTextEdits error = %v, applyEdits error = %v", err, wantErr)
+	}
+	if err != nil {
+		return
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("spliceTextEdits wrote %q, want %q", got, want)
+	}
+}
+
+func TestSpliceTextEditsLongLines(t *testing.T) {
+	// Lines longer than the reader's buffer are copied in parts
+	long := strings.Repeat("x", 3*bufio.MaxScanTokenSize) + "\r"
+	content := "a\r\n" + long + "\r\n" + long + "\n" + long + "\r\nlast"
+	edit := []protocol.TextEdit{textEdit(3, 0, 3, 0, "new\n")}
+	want, err := ApplyEditsToText(content, edit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte(content), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := spliceTextEdits(path, edit); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("spliceTextEdits wrote %d bytes, want %d", len(got), len(want))
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("file mode = %v, %v; want 0640", info.Mode().Perm(), err)
+	}
+
+	// CRs held back at the end of a block are dropped before an LF too
+	for _, lineEnding := range []string{"\n", "\r\n"} {
+		testSpliceMatches(t, content, edit, lineEnding)
+	}
+}
+
+func TestApplyTextEditsSplicesLargeFiles(t *testing.T) {
+	saved := spliceSize
+	spliceSize = 10
+	defer func() { spliceSize = saved }()
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("line1\nline2\nline3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyTextEdits(protocol.URIFromPath(path), []protocol.TextEdit{textEdit(1, 0, 1, 5, "LINE2")}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != "line1\nLINE2\nline3\n" {
+		t.Errorf("file = %q", got)
+	}
+
+	err := ApplyTextEdits(protocol.URIFromPath(path), []protocol.TextEdit{textEdit(0, 0, 1, 2, "a"), textEdit(1, 1, 1, 3, "b")})
+	if !errors.Is(err, ErrEditConflict) {
+		t.Errorf("overlapping edits: error = %v, want an edit conflict", err)
+	}
+}
+
+func TestReadFileRefusesLargeFiles(t *testing.T) {
+	saved := MaxReadSize
+	MaxReadSize = 10
+	defer func() { MaxReadSize = saved }()
+
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.txt")
+	large := filepath.Join(dir, "large.txt")
+	if err := os.WriteFile(small, []byte("small"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(large, []byte("larger than ten bytes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if content, err := ReadFile(small); err != nil || string(content) != "small" {
+		t.Errorf("ReadFile(small) = %q, %v", content, err)
+	}
+	if _, err := ReadFile(large); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("ReadFile(large) error = %v, want ErrFileTooLarge", err)
+	}
+	if _, err := ReadFile(filepath.Join(dir, "missing.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadFile(missing) error = %v, want os.ErrNotExist", err)
+	}
+
+	MaxReadSize = 0
+	if _, err := ReadFile(large); err != nil {
+		t.Errorf("ReadFile(large) without a limit: %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package utilities
+
+import "unicode/utf16"
+
+// ByteOffset converts an LSP column, counted in UTF-16 code units, to a byte
+// offset in line. A column past the end of the line is its end, and one
+// inside a surrogate pair is the end of that character.
+func ByteOffset(line string, column int) int {
+	units := 0
+	for offset, r := range line {
+		if units >= column {
+			return offset
+		}
+		units += utf16.RuneLen(r)
+	}
+	return len(line)
+}
+
+// UTF16Column converts a byte offset in line to an LSP column
+func UTF16Column(line string, offset int) int {
+	if offset > len(line) {
+		offset = len(line)
+	}
+	units := 0
+	for _, r := range line[:offset] {
+		units += utf16.RuneLen(r)
+	}
+	return units
+}
@@ -0,0 +1,39 @@
+package utilities
+
+import (
+	"testing"
+	"unicode/utf16"
+)
+
+// FuzzUTF16Column checks that LSP columns and byte offsets convert back and
+// forth without landing inside a character
+func FuzzUTF16Column(f *testing.F) {
+	for _, line := range []string{"", "func main() {", "a😀b", "日本語", "é́", "\xff\xfe", "😀\xf0\x9f"} {
+		f.Add(line)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		units := UTF16Column(line, len(line))
+		if want := len(utf16.Encode([]rune(line))); units != want {
+			t.Fatalf("UTF16Column(%q, %d) = %d, want %d", line, len(line), units, want)
+		}
+
+		boundaries := make(map[int]bool)
+		for offset := range line {
+			boundaries[offset] = true
+			column := UTF16Column(line, offset)
+			if got := ByteOffset(line, column); got != offset {
+				t.Fatalf("ByteOffset(%q, %d) = %d, want %d", line, column, got, offset)
+			}
+		}
+		for column := 0; column <= units+1; column++ {
+			offset := ByteOffset(line, column)
+			if offset < len(line) && !boundaries[offset] {
+				t.Fatalf("ByteOffset(%q, %d) = %d, inside a character", line, column, offset)
+			}
+			// A column inside a surrogate pair rounds up to the end of it
+			if got := UTF16Column(line, offset); got < min(column, units) {
+				t.Fatalf("UTF16Column(%q, ByteOffset(%d)) = %d", line, column, got)
+			}
+		}
+	})
+}
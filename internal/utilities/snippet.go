@@ -0,0 +1,159 @@
+package utilities
+
+import "strings"
+
+// ExpandSnippet turns LSP snippet syntax into plain text. Tabstops are
+// removed, placeholders are replaced by their default text, choices by their
+// first option and variables by their default, or nothing. Malformed syntax
+// is kept as written.
+//
+// See https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification#snippet_syntax
+func ExpandSnippet(snippet string) string {
+	p := snippetParser{s: snippet}
+	return p.parse(false)
+}
+
+type snippetParser struct {
+	s   string
+	pos int
+}
+
+// parse expands text up to the end of the snippet or, inside a placeholder,
+// up to its closing brace
+func (p *snippetParser) parse(inPlaceholder bool) string {
+	var out strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.s) && strings.IndexByte(`$}\`, p.s[p.pos+1]) >= 0:
+			out.WriteByte(p.s[p.pos+1])
+			p.pos += 2
+		case c == '}' && inPlaceholder:
+			return out.String()
+		case c == '$':
+			if expanded, ok := p.parseDollar(); ok {
+				out.WriteString(expanded)
+			} else {
+				out.WriteByte('$')
+				p.pos++
+			}
+		default:
+			out.WriteByte(c)
+			p.pos++
+		}
+	}
+	return out.String()
+}
+
+// parseDollar expands a tabstop, placeholder, choice or variable starting at
+// the current '$'. It leaves the position alone if the syntax is invalid.
+func (p *snippetParser) parseDollar() (string, bool) {
+	start := p.pos
+	p.pos++
+
+	// $1 or $name
+	if name := p.scanName(); name != "" {
+		return "", true
+	}
+	if p.pos >= len(p.s) || p.s[p.pos] != '{' {
+		p.pos = start
+		return "", false
+	}
+	p.pos++
+	if p.scanName() == "" {
+		p.pos = start
+		return "", false
+	}
+
+	if p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '}':
+			// ${1} or ${name}
+			p.pos++
+			return "", true
+		case ':':
+			// ${1:default} or ${name:default}, where default may nest
+			p.pos++
+			text := p.parse(true)
+			if p.pos < len(p.s) {
+				p.pos++
+				return text, true
+			}
+		case '|':
+			// ${1|first,second|}
+			p.pos++
+			if choice, ok := p.parseChoice(); ok {
+				return choice, true
+			}
+		case '/':
+			// ${name/regex/format/options} transforms are dropped
+			if end := p.closingBrace(); end >= 0 {
+				p.pos = end + 1
+				return "", true
+			}
+		}
+	}
+	p.pos = start
+	return "", false
+}
+
+// scanName consumes a tabstop number or variable name
+func (p *snippetParser) scanName() string {
+	start := p.pos
+	if p.pos < len(p.s) && isDigit(p.s[p.pos]) {
+		for p.pos < len(p.s) && isDigit(p.s[p.pos]) {
+			p.pos++
+		}
+		return p.s[start:p.pos]
+	}
+	for p.pos < len(p.s) && (isDigit(p.s[p.pos]) && p.pos > start || p.s[p.pos] == '_' ||
+		p.s[p.pos] >= 'a' && p.s[p.pos] <= 'z' || p.s[p.pos] >= 'A' && p.s[p.pos] <= 'Z') {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// parseChoice returns the first option of a choice and moves past its "|}"
+func (p *snippetParser) parseChoice() (string, bool) {
+	var first strings.Builder
+	inFirst := true
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.s) && strings.IndexByte(`$}\,|`, p.s[p.pos+1]) >= 0:
+			if inFirst {
+				first.WriteByte(p.s[p.pos+1])
+			}
+			p.pos += 2
+		case c == '|' && p.pos+1 < len(p.s) && p.s[p.pos+1] == '}':
+			p.pos += 2
+			return first.String(), true
+		case c == ',':
+			inFirst = false
+			p.pos++
+		default:
+			if inFirst {
+				first.WriteByte(c)
+			}
+			p.pos++
+		}
+	}
+	return "", false
+}
+
+// closingBrace finds the unescaped '}' ending the current element
+func (p *snippetParser) closingBrace() int {
+	for i := p.pos; i < len(p.s); i++ {
+		switch p.s[i] {
+		case '\\':
+			i++
+		case '}':
+			return i
+		}
+	}
+	return -1
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,35 @@
+package utilities
+
+import "testing"
+
+func TestExpandSnippet(t *testing.T) {
+	tests := []struct {
+		name     string
+		snippet  string
+		expected string
+	}{
+		{"Plain text", "fmt.Println()", "fmt.Println()"},
+		{"Tabstops", "foo($1, $2)$0", "foo(, )"},
+		{"Braced tabstop", "x${1}y", "xy"},
+		{"Placeholder", "func ${1:name}() {}", "func name() {}"},
+		{"Nested placeholder", "${1:new ${2:Foo}()}", "new Foo()"},
+		{"Choice", "${1|public,private|} int x;", "public int x;"},
+		{"Escaped choice", `${1|a\,b,c|}`, "a,b"},
+		{"Variable with default", "${TM_SELECTED_TEXT:default}", "default"},
+		{"Variable without default", "// $TM_FILENAME", "// "},
+		{"Transform", "${TM_FILENAME/(.*)\\..+$/$1/}", ""},
+		{"Escapes", `cost: \$5 \} \\`, `cost: $5 } \`},
+		{"Escaped brace in placeholder", `${1:a\}b}`, "a}b"},
+		{"Lone dollar", "price $ 5", "price $ 5"},
+		{"Unclosed placeholder", "${1:abc", "${1:abc"},
+		{"Shell-style variable kept as text", "echo ${", "echo ${"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandSnippet(tt.snippet); got != tt.expected {
+				t.Errorf("ExpandSnippet(%q) = %q, want %q", tt.snippet, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Package walk scans directory trees on a bounded pool of goroutines, so
+// that large workspaces are read with more than one core and scans stop
+// promptly when they are cancelled.
+package walk
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// DefaultWorkers is the number of goroutines used when Options.Workers is 0.
+// Reading directories mostly waits on the file system, so there are more
+// than there are cores.
+var DefaultWorkers = min(32, max(4, 2*runtime.GOMAXPROCS(0)))
+
+// Options configure a walk
+type Options struct {
+	// Workers is the number of directories read, and files handled, at once
+	Workers int
+	// SkipDir reports whether a directory below the root is left out. It may
+	// be called concurrently.
+	SkipDir func(path string, d fs.DirEntry) bool
+}
+
+// Walk calls fn for every entry under root, except root itself and the
+// directories SkipDir leaves out. Unlike filepath.WalkDir, directories are
+// read and fn is called on several goroutines at once, in no particular
+// order, though a directory's entries always come after it. The walk stops
+// at the first error returned by fn or when ctx is done, and returns that
+// error;
+// returning fs.SkipAll from fn stops it without one. Directories that can't
+// be read are skipped, except for root.
+func Walk(ctx context.Context, root string, opts Options, fn func(path string, d fs.DirEntry) error) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fn(root, fs.FileInfoToDirEntry(info))
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := &walker{ctx: ctx, root: root, opts: opts, fn: fn, queue: []string{root}, pending: 1}
+	w.cond = sync.NewCond(&w.mu)
+	// Wake idle workers when the walk is cancelled
+	stopWaking := context.AfterFunc(ctx, func() {
+		w.mu.Lock()
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	})
+	defer stopWaking()
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work()
+		}()
+	}
+	wg.Wait()
+
+	if w.err != nil && !errors.Is(w.err, fs.SkipAll) {
+		return w.err
+	}
+	if w.err == nil {
+		// Set if the caller's context is done, as ours is only canceled on
+		// return
+		return ctx.Err()
+	}
+	return nil
+}
+
+// walker is the state shared by the goroutines of a walk
+type walker struct {
+	ctx  context.Context
+	root string
+	opts Options
+	fn   func(path string, d fs.DirEntry) error
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// queue holds directories waiting to be read
+	queue []string
+	// pending counts directories queued or being read; the walk is done
+	// when it drops to 0
+	pending int
+	// err is the first error, which stops the walk
+	err error
+}
+
+// work reads directories from the queue until the walk is done or stopped
+func (w *walker) work() {
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && w.pending > 0 && w.err == nil && w.ctx.Err() == nil {
+			w.cond.Wait()
+		}
+		if w.pending == 0 || w.err != nil || w.ctx.Err() != nil {
+			w.mu.Unlock()
+			return
+		}
+		dir := w.queue[len(w.queue)-1]
+		w.queue = w.queue[:len(w.queue)-1]
+		w.mu.Unlock()
+
+		w.readDir(dir)
+
+		w.mu.Lock()
+		w.pending--
+		if w.pending == 0 {
+			w.cond.Broadcast()
+		}
+		w.mu.Unlock()
+	}
+}
+
+// readDir queues the subdirectories of dir and calls fn for its other
+// entries
+func (w *walker) readDir(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if dir == w.root {
+			w.stop(err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		if w.ctx.Err() != nil {
+			return
+		}
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			if w.opts.SkipDir != nil && w.opts.SkipDir(path, entry) {
+				continue
+			}
+			if err := w.fn(path, entry); err != nil {
+				w.stop(err)
+				return
+			}
+			w.mu.Lock()
+			w.queue = append(w.queue, path)
+			w.pending++
+			w.cond.Signal()
+			w.mu.Unlock()
+			continue
+		}
+		if err := w.fn(path, entry); err != nil {
+			w.stop(err)
+			return
+		}
+	}
+}
+
+// stop ends the walk with its first error
+func (w *walker) stop(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+	w.cond.Broadcast()
+}
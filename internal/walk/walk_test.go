@@ -0,0 +1,105 @@
+package walk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTree(t *testing.T, dirs, filesPerDir int) string {
+	t.Helper()
+	root := t.TempDir()
+	for i := range dirs {
+		dir := filepath.Join(root, fmt.Sprintf("d%02d", i), "sub")
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		for j := range filesPerDir {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%02d.txt", j)), nil, 0o644))
+		}
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "skip", "deep"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "skip", "deep", "hidden.txt"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "top.txt"), nil, 0o644))
+	return root
+}
+
+func TestWalk(t *testing.T) {
+	root := writeTree(t, 10, 5)
+	var mu sync.Mutex
+	var paths []string
+	err := Walk(context.Background(), root, Options{
+		Workers: 4,
+		SkipDir: func(path string, d fs.DirEntry) bool { return d.Name() == "skip" },
+	}, func(path string, d fs.DirEntry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		rel, _ := filepath.Rel(root, path)
+		if d.IsDir() {
+			rel += "/"
+		}
+		paths = append(paths, rel)
+		return nil
+	})
+	require.NoError(t, err)
+	sort.Strings(paths)
+	assert.Len(t, paths, 71)
+	assert.Equal(t, "d00/", paths[0])
+	assert.Equal(t, filepath.Join("d00", "sub")+"/", paths[1])
+	assert.Equal(t, filepath.Join("d00", "sub", "f00.txt"), paths[2])
+	assert.Equal(t, "top.txt", paths[70])
+	assert.NotContains(t, paths, "skip/")
+}
+
+func TestWalkFile(t *testing.T) {
+	root := writeTree(t, 1, 1)
+	var got []string
+	err := Walk(context.Background(), filepath.Join(root, "top.txt"), Options{}, func(path string, d fs.DirEntry) error {
+		got = append(got, d.Name())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"top.txt"}, got)
+
+	err = Walk(context.Background(), filepath.Join(root, "missing"), Options{}, func(string, fs.DirEntry) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestWalkStops(t *testing.T) {
+	root := writeTree(t, 20, 20)
+
+	var calls atomic.Int32
+	err := Walk(context.Background(), root, Options{Workers: 4}, func(path string, d fs.DirEntry) error {
+		if calls.Add(1) == 10 {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Less(t, int(calls.Load()), 401)
+
+	failure := errors.New("failed")
+	err = Walk(context.Background(), root, Options{Workers: 4}, func(path string, d fs.DirEntry) error {
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls.Store(0)
+	err = Walk(ctx, root, Options{Workers: 4}, func(path string, d fs.DirEntry) error {
+		if calls.Add(1) == 5 {
+			cancel()
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, int(calls.Load()), 401)
+}
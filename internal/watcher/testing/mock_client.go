@@ -44,6 +44,13 @@ func (m *MockLSPClient) IsFileOpen(path string) bool {
 	return m.openedFiles[path]
 }
 
+// CountOpenedFiles returns the number of files opened in the editor
+func (m *MockLSPClient) CountOpenedFiles() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.openedFiles)
+}
+
 // OpenFile mocks opening a file in the editor
 func (m *MockLSPClient) OpenFile(ctx context.Context, path string) error {
 	m.mu.Lock()
@@ -68,7 +75,7 @@ func (m *MockLSPClient) NotifyChange(ctx context.Context, path string) error {
 
 	// Record this as a change event
 	m.events = append(m.events, FileEvent{
-		URI:  "file://" + path,
+		URI:  string(protocol.URIFromPath(path)),
 		Type: protocol.FileChangeType(protocol.Changed),
 	})
 
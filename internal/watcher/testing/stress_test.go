@@ -0,0 +1,63 @@
+package testing
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/logging"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/watcher"
+	"github.com/isaacphi/mcp-language-server/internal/workspacegen"
+)
+
+// TestWatcherLargeWorkspace checks that registering a pattern on a workspace
+// of thousands of files opens every matching file
+func TestWatcherLargeWorkspace(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generates a large workspace")
+	}
+	// Debug logging of every opened file would dominate the test
+	logging.SetLevel(logging.Watcher, logging.LevelInfo)
+	defer logging.SetLevel(logging.Watcher, logging.LevelDebug)
+
+	w, err := workspacegen.Generate(t.TempDir(), workspacegen.Spec{
+		Packages:         40,
+		FilesPerPackage:  50,
+		FunctionsPerFile: 2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate workspace: %v", err)
+	}
+	goFiles := 0
+	for _, file := range w.Files {
+		if strings.HasSuffix(file, ".go") {
+			goFiles++
+		}
+	}
+
+	mockClient := NewMockLSPClient()
+	testWatcher := watcher.NewWorkspaceWatcher(mockClient)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	go testWatcher.WatchWorkspace(ctx, w.Dir)
+	time.Sleep(500 * time.Millisecond)
+
+	start := time.Now()
+	testWatcher.AddRegistrations(ctx, "stress", []protocol.FileSystemWatcher{
+		{GlobPattern: protocol.GlobPattern{Value: "**/*.go"}},
+	})
+	for mockClient.CountOpenedFiles() < goFiles {
+		if ctx.Err() != nil {
+			t.Fatalf("Opened %d of %d files before timing out", mockClient.CountOpenedFiles(), goFiles)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Logf("Opened %d files in %v", goFiles, time.Since(start))
+
+	// go.mod doesn't match the pattern
+	if opened := mockClient.CountOpenedFiles(); opened != goFiles {
+		t.Errorf("Expected %d opened files, got %d", goFiles, opened)
+	}
+}
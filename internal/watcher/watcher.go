@@ -7,12 +7,14 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/isaacphi/mcp-language-server/internal/logging"
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/walk"
 )
 
 // Create a logger for the watcher component
@@ -115,37 +117,25 @@ func (w *WorkspaceWatcher) AddRegistrations(ctx context.Context, id string, watc
 	// TODO: not all language servers require this, but typescript does. Make this configurable
 	go func() {
 		startTime := time.Now()
-		filesOpened := 0
+		var filesOpened atomic.Int64
 
-		err := filepath.WalkDir(w.workspacePath, func(path string, d os.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-
-			// Skip directories that should be excluded
+		err := walk.Walk(ctx, w.workspacePath, walk.Options{SkipDir: w.skipDirEntry}, func(path string, d os.DirEntry) error {
 			if d.IsDir() {
 				watcherLogger.Debug("Processing directory: %s", path)
-				if path != w.workspacePath && w.shouldExcludeDir(path) {
-					watcherLogger.Debug("Skipping excluded directory: %s", path)
-					return filepath.SkipDir
-				}
-			} else {
-				// Process files
-				w.openMatchingFile(ctx, path)
-				filesOpened++
-
-				// Add a small delay after every 100 files to prevent overwhelming the server
-				if filesOpened%100 == 0 {
-					time.Sleep(10 * time.Millisecond)
-				}
+				return nil
 			}
+			w.openMatchingFile(ctx, path)
 
+			// Add a small delay after every 100 files to prevent overwhelming the server
+			if filesOpened.Add(1)%100 == 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
 			return nil
 		})
 
 		elapsedTime := time.Since(startTime)
 		watcherLogger.Info("Workspace scan complete: processed %d files in %.2f seconds",
-			filesOpened, elapsedTime.Seconds())
+			filesOpened.Load(), elapsedTime.Seconds())
 
 		if err != nil {
 			watcherLogger.Error("Error scanning workspace for files to open: %v", err)
@@ -182,30 +172,21 @@ func (w *WorkspaceWatcher) WatchWorkspace(ctx context.Context, workspacePath str
 	}()
 
 	// Watch the workspace recursively
-	err = filepath.WalkDir(workspacePath, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip excluded directories (except workspace root)
-		if d.IsDir() && path != workspacePath {
-			if w.shouldExcludeDir(path) {
-				watcherLogger.Debug("Skipping watching excluded directory: %s", path)
-				return filepath.SkipDir
-			}
-		}
-
+	if err := watcher.Add(workspacePath); err != nil {
+		watcherLogger.Error("Error watching path %s: %v", workspacePath, err)
+	}
+	err = walk.Walk(ctx, workspacePath, walk.Options{SkipDir: w.skipDirEntry}, func(path string, d os.DirEntry) error {
 		// Add directories to watcher
 		if d.IsDir() {
-			err = watcher.Add(path)
-			if err != nil {
+			if err := watcher.Add(path); err != nil {
 				watcherLogger.Error("Error watching path %s: %v", path, err)
 			}
 		}
-
 		return nil
 	})
-
+	if ctx.Err() != nil {
+		return
+	}
 	if err != nil {
 		watcherLogger.Fatal("Error walking workspace: %v", err)
 	}
@@ -220,7 +201,7 @@ func (w *WorkspaceWatcher) WatchWorkspace(ctx context.Context, workspacePath str
 				return
 			}
 
-			uri := fmt.Sprintf("file://%s", event.Name)
+			uri := string(protocol.URIFromPath(event.Name))
 
 			// Check if this is a file (not a directory) and should be excluded
 			isFile := false
@@ -467,7 +448,6 @@ func (w *WorkspaceWatcher) matchesPattern(path string, pattern protocol.GlobPatt
 	}
 
 	// For relative patterns
-	basePath = strings.TrimPrefix(basePath, "file://")
 	basePath = filepath.ToSlash(basePath)
 
 	// Make path relative to basePath for matching
@@ -511,7 +491,7 @@ func (w *WorkspaceWatcher) debounceHandleFileEvent(ctx context.Context, uri stri
 // handleFileEvent sends file change notifications
 func (w *WorkspaceWatcher) handleFileEvent(ctx context.Context, uri string, changeType protocol.FileChangeType) {
 	// If the file is open and it's a change event, use didChange notification
-	filePath := uri[7:] // Remove "file://" prefix
+	filePath := protocol.DocumentUri(uri).Path()
 	if changeType == protocol.FileChangeType(protocol.Changed) && w.client.IsFileOpen(filePath) {
 		err := w.client.NotifyChange(ctx, filePath)
 		if err != nil {
@@ -565,6 +545,15 @@ func (w *WorkspaceWatcher) shouldExcludeDir(dirPath string) bool {
 	return false
 }
 
+// skipDirEntry leaves excluded directories out of workspace walks
+func (w *WorkspaceWatcher) skipDirEntry(path string, d os.DirEntry) bool {
+	if w.shouldExcludeDir(path) {
+		watcherLogger.Debug("Skipping excluded directory: %s", path)
+		return true
+	}
+	return false
+}
+
 // shouldExcludeFile returns true if the file should be excluded from opening
 func (w *WorkspaceWatcher) shouldExcludeFile(filePath string) bool {
 	fileName := filepath.Base(filePath)
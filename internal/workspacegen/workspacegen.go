@@ -0,0 +1,301 @@
+// Package workspacegen writes synthetic workspaces of any size, for stress
+// tests and benchmarks that need more files than the fixture workspaces
+// have. A workspace has packages of files of functions; every function calls
+// one shared function, so that it has as many references as there are
+// functions, and functions in earlier packages, so that references cross
+// packages. The same Spec always gives the same files.
+package workspacegen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Spec sizes a generated workspace. Zero fields take the defaults below.
+type Spec struct {
+	// Language is "go", "typescript" or "python". The default is "go".
+	Language string
+	// Packages is the number of packages, 10 by default
+	Packages int
+	// FilesPerPackage is the number of files in each package, 10 by default
+	FilesPerPackage int
+	// FunctionsPerFile is the number of functions in each file, 10 by
+	// default
+	FunctionsPerFile int
+	// CrossReferences is the number of earlier packages each function calls
+	// into, 2 by default. Calls only go to earlier packages, as Go forbids
+	// import cycles. Set it to -1 for none.
+	CrossReferences int
+	// BodyLines is the number of extra statements in each function, to make
+	// files larger
+	BodyLines int
+}
+
+// Workspace describes a generated workspace
+type Workspace struct {
+	Dir  string
+	Spec Spec
+	// Files are the generated source files, relative to Dir and sorted
+	Files []string
+	// Functions is the number of generated functions, besides Shared
+	Functions int
+	// Shared is the name of the function every generated function calls,
+	// declared in SharedFile
+	Shared     string
+	SharedFile string
+}
+
+// FunctionName returns the name of a generated function, by package, file
+// and function index
+func FunctionName(pkg, file, function int) string {
+	return fmt.Sprintf("Func%d_%d_%d", pkg, file, function)
+}
+
+// FilePath returns the path of a generated file relative to the workspace,
+// by package and file index
+func (w *Workspace) FilePath(pkg, file int) string {
+	return filepath.Join(packageName(pkg), fmt.Sprintf("file%d%s", file, languages[w.Spec.Language].ext))
+}
+
+// call is a call from a generated function to another one
+type call struct {
+	pkg, file, function int
+}
+
+// language writes the files of a workspace in one language
+type language struct {
+	ext string
+	// static are files written once, by path
+	static map[string]string
+	// sharedFile declares Shared
+	sharedFile, shared string
+	// packageFiles are written once per package, by name
+	packageFiles map[string]string
+	// source renders a file of functions
+	source func(w *Workspace, pkg, file int, calls [][]call) string
+}
+
+var languages = map[string]language{
+	"go": {
+		ext:          ".go",
+		static:       map[string]string{"go.mod": "module example.com/generated\n\ngo 1.21\n"},
+		sharedFile:   filepath.Join("shared", "shared.go"),
+		shared:       "package shared\n\n// Shared is called by every generated function\nfunc Shared(n int) int {\n\treturn n + 1\n}\n",
+		packageFiles: map[string]string{},
+		source:       goSource,
+	},
+	"typescript": {
+		ext: ".ts",
+		static: map[string]string{
+			"package.json":  "{\n  \"name\": \"generated\",\n  \"private\": true\n}\n",
+			"tsconfig.json": "{\n  \"compilerOptions\": {\n    \"strict\": true,\n    \"target\": \"es2020\",\n    \"module\": \"commonjs\"\n  }\n}\n",
+		},
+		sharedFile:   "shared.ts",
+		shared:       "// Shared is called by every generated function\nexport function Shared(n: number): number {\n  return n + 1;\n}\n",
+		packageFiles: map[string]string{},
+		source:       typeScriptSource,
+	},
+	"python": {
+		ext:          ".py",
+		static:       map[string]string{"pyproject.toml": "[project]\nname = \"generated\"\nversion = \"0.1.0\"\n"},
+		sharedFile:   "shared.py",
+		shared:       "def Shared(n: int) -> int:\n    \"\"\"Shared is called by every generated function.\"\"\"\n    return n + 1\n",
+		packageFiles: map[string]string{"__init__.py": ""},
+		source:       pythonSource,
+	},
+}
+
+// Generate writes a workspace sized by spec into dir, which is created if
+// needed
+func Generate(dir string, spec Spec) (*Workspace, error) {
+	if spec.Language == "" {
+		spec.Language = "go"
+	}
+	lang, ok := languages[spec.Language]
+	if !ok {
+		return nil, fmt.Errorf("unsupported language %q, expected go, typescript or python", spec.Language)
+	}
+	spec.Packages = orDefault(spec.Packages, 10)
+	spec.FilesPerPackage = orDefault(spec.FilesPerPackage, 10)
+	spec.FunctionsPerFile = orDefault(spec.FunctionsPerFile, 10)
+	spec.CrossReferences = max(orDefault(spec.CrossReferences, 2), 0)
+
+	w := &Workspace{Dir: dir, Spec: spec, Shared: "Shared", SharedFile: lang.sharedFile}
+	write := func(path, content string) error {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(full, []byte(content), 0o644)
+	}
+
+	for path, content := range lang.static {
+		if err := write(path, content); err != nil {
+			return nil, err
+		}
+	}
+	if err := write(lang.sharedFile, lang.shared); err != nil {
+		return nil, err
+	}
+	w.Files = append(w.Files, lang.sharedFile)
+
+	for pkg := range spec.Packages {
+		for name, content := range lang.packageFiles {
+			if err := write(filepath.Join(packageName(pkg), name), content); err != nil {
+				return nil, err
+			}
+		}
+		for file := range spec.FilesPerPackage {
+			calls := make([][]call, spec.FunctionsPerFile)
+			for function := range spec.FunctionsPerFile {
+				for k := 1; k <= spec.CrossReferences && pkg-k >= 0; k++ {
+					calls[function] = append(calls[function], call{
+						pkg:      pkg - k,
+						file:     (file + k) % spec.FilesPerPackage,
+						function: (function + k) % spec.FunctionsPerFile,
+					})
+				}
+			}
+			path := w.FilePath(pkg, file)
+			if err := write(path, lang.source(w, pkg, file, calls)); err != nil {
+				return nil, err
+			}
+			w.Files = append(w.Files, path)
+			w.Functions += spec.FunctionsPerFile
+		}
+	}
+	sort.Strings(w.Files)
+	return w, nil
+}
+
+func orDefault(n, fallback int) int {
+	if n == 0 {
+		return fallback
+	}
+	return n
+}
+
+func packageName(pkg int) string {
+	return fmt.Sprintf("pkg%d", pkg)
+}
+
+// imports returns the files a file's calls import, sorted
+func imports(calls [][]call) []call {
+	seen := make(map[call]bool)
+	var files []call
+	for _, fnCalls := range calls {
+		for _, c := range fnCalls {
+			file := call{pkg: c.pkg, file: c.file}
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].pkg != files[j].pkg {
+			return files[i].pkg < files[j].pkg
+		}
+		return files[i].file < files[j].file
+	})
+	return files
+}
+
+func goSource(w *Workspace, pkg, file int, calls [][]call) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "package %s\n\nimport (\n\t\"example.com/generated/shared\"\n", packageName(pkg))
+	seen := make(map[int]bool)
+	for _, imported := range imports(calls) {
+		if !seen[imported.pkg] {
+			seen[imported.pkg] = true
+			fmt.Fprintf(&b, "\t\"example.com/generated/%s\"\n", packageName(imported.pkg))
+		}
+	}
+	b.WriteString(")\n")
+	for function, fnCalls := range calls {
+		name := FunctionName(pkg, file, function)
+		fmt.Fprintf(&b, "\n// %s is generated\nfunc %s(n int) int {\n\tn = shared.Shared(n)\n", name, name)
+		for _, c := range fnCalls {
+			fmt.Fprintf(&b, "\tn = %s.%s(n)\n", packageName(c.pkg), FunctionName(c.pkg, c.file, c.function))
+		}
+		for i := range w.Spec.BodyLines {
+			fmt.Fprintf(&b, "\tn += %d\n", i)
+		}
+		b.WriteString("\treturn n\n}\n")
+	}
+	return b.String()
+}
+
+func typeScriptSource(w *Workspace, pkg, file int, calls [][]call) string {
+	var b strings.Builder
+	b.WriteString("import { Shared } from \"../shared\";\n")
+	for _, imported := range imports(calls) {
+		var names []string
+		for _, fnCalls := range calls {
+			for _, c := range fnCalls {
+				if c.pkg == imported.pkg && c.file == imported.file {
+					names = append(names, FunctionName(c.pkg, c.file, c.function))
+				}
+			}
+		}
+		sort.Strings(names)
+		names = compactStrings(names)
+		fmt.Fprintf(&b, "import { %s } from \"../%s/file%d\";\n", strings.Join(names, ", "), packageName(imported.pkg), imported.file)
+	}
+	for function, fnCalls := range calls {
+		name := FunctionName(pkg, file, function)
+		fmt.Fprintf(&b, "\n// %s is generated\nexport function %s(n: number): number {\n  n = Shared(n);\n", name, name)
+		for _, c := range fnCalls {
+			fmt.Fprintf(&b, "  n = %s(n);\n", FunctionName(c.pkg, c.file, c.function))
+		}
+		for i := range w.Spec.BodyLines {
+			fmt.Fprintf(&b, "  n += %d;\n", i)
+		}
+		b.WriteString("  return n;\n}\n")
+	}
+	return b.String()
+}
+
+func pythonSource(w *Workspace, pkg, file int, calls [][]call) string {
+	var b strings.Builder
+	b.WriteString("from shared import Shared\n")
+	for _, imported := range imports(calls) {
+		var names []string
+		for _, fnCalls := range calls {
+			for _, c := range fnCalls {
+				if c.pkg == imported.pkg && c.file == imported.file {
+					names = append(names, FunctionName(c.pkg, c.file, c.function))
+				}
+			}
+		}
+		sort.Strings(names)
+		names = compactStrings(names)
+		fmt.Fprintf(&b, "from %s.file%d import %s\n", packageName(imported.pkg), imported.file, strings.Join(names, ", "))
+	}
+	for function, fnCalls := range calls {
+		name := FunctionName(pkg, file, function)
+		fmt.Fprintf(&b, "\n\ndef %s(n: int) -> int:\n    \"\"\"%s is generated.\"\"\"\n    n = Shared(n)\n", name, name)
+		for _, c := range fnCalls {
+			fmt.Fprintf(&b, "    n = %s(n)\n", FunctionName(c.pkg, c.file, c.function))
+		}
+		for i := range w.Spec.BodyLines {
+			fmt.Fprintf(&b, "    n += %d\n", i)
+		}
+		b.WriteString("    return n\n")
+	}
+	return b.String()
+}
+
+// compactStrings removes adjacent duplicates from a sorted slice
+func compactStrings(names []string) []string {
+	out := names[:0]
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			out = append(out, name)
+		}
+	}
+	return out
+}
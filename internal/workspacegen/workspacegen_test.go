@@ -0,0 +1,93 @@
+package workspacegen
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countCalls counts the calls to name in the files of a workspace
+func countCalls(t *testing.T, w *Workspace, name string) int {
+	t.Helper()
+	call := regexp.MustCompile(`\b` + name + `\(`)
+	count := 0
+	for _, file := range w.Files {
+		content, err := os.ReadFile(filepath.Join(w.Dir, file))
+		require.NoError(t, err)
+		count += len(call.FindAll(content, -1))
+	}
+	return count
+}
+
+func TestGenerate(t *testing.T) {
+	w, err := Generate(t.TempDir(), Spec{Packages: 3, FilesPerPackage: 4, FunctionsPerFile: 5})
+	require.NoError(t, err)
+
+	assert.Equal(t, "go", w.Spec.Language)
+	assert.Equal(t, 2, w.Spec.CrossReferences)
+	assert.Len(t, w.Files, 3*4+1)
+	assert.Equal(t, 3*4*5, w.Functions)
+	assert.Contains(t, w.Files, w.SharedFile)
+	assert.Contains(t, w.Files, w.FilePath(2, 3))
+	assert.FileExists(t, filepath.Join(w.Dir, "go.mod"))
+
+	// Shared is declared once and called from every function
+	assert.Equal(t, w.Functions+1, countCalls(t, w, w.Shared))
+	// Func0_1_2 is called from the next two packages
+	assert.Equal(t, 1+2, countCalls(t, w, FunctionName(0, 1, 2)))
+	// Nothing calls into the last package
+	assert.Equal(t, 1, countCalls(t, w, FunctionName(2, 0, 0)))
+
+	// The same spec gives the same files
+	again, err := Generate(t.TempDir(), w.Spec)
+	require.NoError(t, err)
+	for _, file := range w.Files {
+		want, err := os.ReadFile(filepath.Join(w.Dir, file))
+		require.NoError(t, err)
+		got, err := os.ReadFile(filepath.Join(again.Dir, file))
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got), file)
+	}
+}
+
+func TestGenerateBuilds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go build")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	w, err := Generate(t.TempDir(), Spec{Packages: 4, FilesPerPackage: 3, FunctionsPerFile: 3, BodyLines: 2})
+	require.NoError(t, err)
+
+	cmd := exec.Command(goBin, "vet", "./...")
+	cmd.Dir = w.Dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(out))
+}
+
+func TestGenerateLanguages(t *testing.T) {
+	ts, err := Generate(t.TempDir(), Spec{Language: "typescript", Packages: 2, FilesPerPackage: 2, FunctionsPerFile: 2})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(ts.Dir, "tsconfig.json"))
+	content, err := os.ReadFile(filepath.Join(ts.Dir, ts.FilePath(1, 0)))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "import { Shared } from \"../shared\";\nimport { Func0_1_0, Func0_1_1 } from \"../pkg0/file1\";\n"), string(content))
+
+	py, err := Generate(t.TempDir(), Spec{Language: "python", Packages: 2, FilesPerPackage: 2, FunctionsPerFile: 2, CrossReferences: -1})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(py.Dir, "pkg1", "__init__.py"))
+	assert.Equal(t, 0, py.Spec.CrossReferences)
+	assert.Equal(t, 1, countCalls(t, py, FunctionName(0, 0, 0)))
+
+	_, err = Generate(t.TempDir(), Spec{Language: "cobol"})
+	assert.ErrorContains(t, err, "unsupported language")
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"maps"
+
+	"github.com/isaacphi/mcp-language-server/internal/i18n"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// localizeTools translates the descriptions of the listed tools and their
+// arguments into the locale of the config file. The registered tools are
+// left in English, so a new locale applies from the next tools/list.
+func localizeTools(ctx context.Context, listed []mcp.Tool) []mcp.Tool {
+	if i18n.Locale() == i18n.English {
+		return listed
+	}
+	result := make([]mcp.Tool, len(listed))
+	for i, tool := range listed {
+		tool.Description = i18n.Text(tool.Description)
+		if tool.RawInputSchema == nil {
+			tool.InputSchema.Properties = localizeProperties(tool.InputSchema.Properties)
+		}
+		result[i] = tool
+	}
+	return result
+}
+
+// localizeProperties returns a copy of the properties of a schema with
+// their descriptions translated
+func localizeProperties(properties map[string]any) map[string]any {
+	if properties == nil {
+		return nil
+	}
+	localized := make(map[string]any, len(properties))
+	for name, property := range properties {
+		if schema, ok := property.(map[string]any); ok {
+			property = localizeSchema(schema)
+		}
+		localized[name] = property
+	}
+	return localized
+}
+
+func localizeSchema(schema map[string]any) map[string]any {
+	schema = maps.Clone(schema)
+	if description, ok := schema["description"].(string); ok {
+		schema["description"] = i18n.Text(description)
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		schema["items"] = localizeSchema(items)
+	}
+	if properties, ok := schema["properties"].(map[string]any); ok {
+		schema["properties"] = localizeProperties(properties)
+	}
+	return schema
+}
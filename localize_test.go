@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/i18n"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalizeTools(t *testing.T) {
+	s := &mcpServer{}
+	s.mcpServer = server.NewMCPServer("test", "1", server.WithToolCapabilities(true), server.WithToolFilter(localizeTools))
+	s.mcpServer.AddTool(mcp.NewTool("callers",
+		mcp.WithDescription("Find the functions that call a method"),
+		mcp.WithNumber("column", mcp.Description("A column on that line (1-indexed)")),
+		mcp.WithArray("lines", mcp.Description("Not in the catalog"), mcp.Items(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"line": map[string]any{"type": "number", "description": "A line in the function (1-indexed)"},
+			},
+		})),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(""), nil
+	})
+
+	require.NoError(t, i18n.SetLocale("ja"))
+	t.Cleanup(func() { _ = i18n.SetLocale("") })
+
+	tool := listTest(t, s)["callers"]
+	assert.Equal(t, "メソッドを呼び出している関数を探す", tool.Description)
+	properties := tool.InputSchema.Properties
+	assert.Equal(t, "その行の列（1 始まり）", properties["column"].(map[string]any)["description"])
+	lines := properties["lines"].(map[string]any)
+	// Text without a translation stays in English
+	assert.Equal(t, "Not in the catalog", lines["description"])
+	line := lines["items"].(map[string]any)["properties"].(map[string]any)["line"].(map[string]any)
+	assert.Equal(t, "関数内の行（1 始まり）", line["description"])
+
+	// The registered tool is left in English for the next locale
+	require.NoError(t, i18n.SetLocale(""))
+	tool = listTest(t, s)["callers"]
+	assert.Equal(t, "Find the functions that call a method", tool.Description)
+	line = tool.InputSchema.Properties["lines"].(map[string]any)["items"].(map[string]any)["properties"].(map[string]any)["line"].(map[string]any)
+	assert.Equal(t, "A line in the function (1-indexed)", line["description"])
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// delegateServer is a language server given with --lsp-for, which works
+// alongside the --lsp server on the documents of some languages
+type delegateServer struct {
+	// languages are LSP language IDs, or nil for every language
+	languages []protocol.LanguageKind
+	command   string
+	args      []string
+}
+
+// parseDelegateServer parses an --lsp-for LANGUAGES=COMMAND entry. LANGUAGES
+// are comma-separated language IDs, or * for every language.
+func parseDelegateServer(entry string) (delegateServer, error) {
+	languages, commandLine, ok := strings.Cut(entry, "=")
+	if !ok || strings.TrimSpace(languages) == "" {
+		return delegateServer{}, fmt.Errorf("invalid --lsp-for %q, expected LANGUAGES=COMMAND", entry)
+	}
+	fields, err := parseCommandLine(commandLine)
+	if err != nil {
+		return delegateServer{}, fmt.Errorf("invalid --lsp-for %q: %v", entry, err)
+	}
+	server := delegateServer{command: fields[0], args: fields[1:]}
+	if strings.TrimSpace(languages) != "*" {
+		for _, language := range strings.Split(languages, ",") {
+			if language = strings.TrimSpace(language); language != "" {
+				server.languages = append(server.languages, protocol.LanguageKind(language))
+			}
+		}
+	}
+	return server, nil
+}
+
+// parseCommandLine splits a language server command line given in a flag
+// into the command and its arguments. It is either a JSON array, as
+// profiles give lspArgs, for arguments with spaces, or words separated by
+// spaces.
+func parseCommandLine(commandLine string) ([]string, error) {
+	commandLine = strings.TrimSpace(commandLine)
+	var fields []string
+	if strings.HasPrefix(commandLine, "[") {
+		if err := json.Unmarshal([]byte(commandLine), &fields); err != nil {
+			return nil, fmt.Errorf("the command is not a JSON array of strings: %v", err)
+		}
+	} else {
+		fields = strings.Fields(commandLine)
+	}
+	if len(fields) == 0 || fields[0] == "" {
+		return nil, fmt.Errorf("empty command")
+	}
+	return fields, nil
+}
+
+// delegateOptions returns the settings for starting an --lsp-for server.
+// It runs like the --lsp server, through the same runner and in a sandbox
+// of its own, but without what was added for the --lsp server, such as
+// bundle exec.
+func (cfg *config) delegateOptions(command string) lsp.ProcessOptions {
+	opts := cfg.processOptions()
+	opts.Env = append(cfg.egressPolicy().Env(), cfg.lspEnv...)
+	opts.Connect = ""
+	opts.Runner = nil
+	expand := cfg.variables().expand
+	for _, arg := range strings.Fields(cfg.lspRunner) {
+		opts.Runner = append(opts.Runner, expand(arg))
+	}
+	if cfg.sandbox {
+		opts.Sandbox = cfg.serverSandbox(command, opts)
+	}
+	return opts
+}
+
+// startDelegates starts the --lsp-for servers and adds them to the language
+// server as its delegates, in the order given. A server that can't be
+// started or initialized is left out with a warning.
+func (s *mcpServer) startDelegates(client *lsp.Client) {
+	serverWorkspace := lsp.ServerPath(s.config.pathMappings, s.config.workspaceDir)
+	for _, entry := range s.config.lspDelegates {
+		server, err := parseDelegateServer(entry)
+		if err != nil {
+			coreLogger.Warn("%v", err)
+			continue
+		}
+		for i, arg := range server.args {
+			server.args[i] = strings.ReplaceAll(arg, workspaceFolderVar, serverWorkspace)
+		}
+		if err := s.startDelegate(client, server); err != nil {
+			coreLogger.Warn("Not using %s alongside the language server: %v", server.command, err)
+		}
+	}
+}
+
+// startDelegate starts and initializes an --lsp-for server with the
+// settings of the --lsp server, and adds it to client
+func (s *mcpServer) startDelegate(client *lsp.Client, server delegateServer) error {
+	delegate, err := lsp.NewClientWithOptions(s.config.delegateOptions(server.command), server.command, server.args...)
+	if err != nil {
+		return err
+	}
+	delegate.SetEgressPolicy(s.config.egressPolicy())
+	delegate.SetRequestTimeout(s.config.requestTimeout)
+	delegate.SetSettings(client.Settings())
+
+	initResult, err := delegate.InitializeLSPClient(s.ctx, s.config.workspaceDir)
+	if err == nil {
+		err = delegate.WaitForServerReady(s.ctx)
+	}
+	if err != nil {
+		if closeErr := delegate.Close(); closeErr != nil {
+			coreLogger.Debug("Failed to close %s: %v", server.command, closeErr)
+		}
+		return fmt.Errorf("initialize failed: %v", err)
+	}
+	delegate.SetName(serverLabel(server.command, initResult))
+	if err := client.AddDelegate(s.ctx, delegate, server.languages); err != nil {
+		return err
+	}
+	coreLogger.Info("Using %s alongside the language server", delegate.Name())
+	go s.superviseLSP(delegate)
+	return nil
+}
+
+// serverLabel names a language server in merged results: by the name it
+// reports, or else its command
+func serverLabel(command string, initResult *protocol.InitializeResult) string {
+	if initResult != nil && initResult.ServerInfo != nil && initResult.ServerInfo.Name != "" {
+		return initResult.ServerInfo.Name
+	}
+	if command == "" {
+		return ""
+	}
+	return lsp.ServerName(command)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+)
+
+// checkProxyAddress checks that an --lsp-proxy address is a unix socket.
+// Editors connecting to it are not asked to trust the workspace, and any
+// local user could connect to a TCP port, so only a socket the user alone
+// can reach is accepted.
+func checkProxyAddress(address string) error {
+	network, _, err := lsp.ParseAddress(address)
+	if err != nil {
+		return err
+	}
+	if network != "unix" {
+		return fmt.Errorf("%s is not a unix socket, use unix:///path, as any local user could connect to a %s address", address, network)
+	}
+	return nil
+}
+
+// serveLSPProxy serves the language server to editors at --lsp-proxy, each
+// connection being an editor's LSP session. Editors can run the server's
+// commands, so the workspace must be trusted already.
+func (s *mcpServer) serveLSPProxy() error {
+	if s.fallback {
+		coreLogger.Warn("No language server to share at %s, --lsp-proxy is ignored in fallback mode", s.config.lspProxy)
+		return nil
+	}
+	if !s.trust.trusted() {
+		return fmt.Errorf("--lsp-proxy requires the workspace %s to be trusted, start the server with --trust-workspace", s.config.workspaceDir)
+	}
+	network, address, err := lsp.ParseAddress(s.config.lspProxy)
+	if err != nil {
+		return err
+	}
+	if network != "unix" {
+		return checkProxyAddress(s.config.lspProxy)
+	}
+	// The socket is created for the user alone, in a directory no one else
+	// can reach
+	if err := makeSocketDir(filepath.Dir(address)); err != nil {
+		return err
+	}
+	listener, err := listenSocket(address)
+	if err != nil {
+		return err
+	}
+	s.proxyListener = listener
+	coreLogger.Info("Serving the language server to editors at %s", s.config.lspProxy)
+
+	proxy := lsp.NewProxy(s.lspClient)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				coreLogger.Info("Editor connected to the language server")
+				if err := proxy.Serve(conn); err != nil {
+					coreLogger.Error("Editor session error: %v", err)
+				}
+				coreLogger.Info("Editor disconnected from the language server")
+			}()
+		}
+	}()
+	return nil
+}
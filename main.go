@@ -4,29 +4,160 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
-	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
+	"github.com/isaacphi/mcp-language-server/internal/journal"
 	"github.com/isaacphi/mcp-language-server/internal/logging"
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/sandbox"
+	"github.com/isaacphi/mcp-language-server/internal/session"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/internal/usage"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+	"github.com/isaacphi/mcp-language-server/internal/walk"
 	"github.com/isaacphi/mcp-language-server/internal/watcher"
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 // Create a logger for the core component
 var coreLogger = logging.NewLogger(logging.Core)
 
+// version is reported to MCP clients and recorded in index dumps
+const version = "v0.0.2"
+
 type config struct {
 	workspaceDir string
 	lspCommand   string
 	openGlobs    StringArrayFlag
 	lspArgs      []string
+	// testCommand enables the run_tests tool; "auto" detects the command
+	testCommand string
+	// buildCommand enables the run_build tool; "auto" detects the command
+	buildCommand string
+	// lspRequests enables the lsp_request tool
+	lspRequests bool
+	// lspSettings enables the update_lsp_settings tool
+	lspSettings bool
+	// getConfig enables the get_config tool
+	getConfig bool
+	// toolAliases is "hidden" to accept the former names of renamed tools
+	// without listing them, "listed" to also list them, or "off"
+	toolAliases string
+	// noToolExamples leaves the example calls out of tools/list
+	noToolExamples bool
+	// semanticSearchURL enables the semantic_search tool
+	semanticSearchURL string
+	// generatedGlobs name generated files beyond those recognized by name
+	// or header, and includeGenerated keeps them in results
+	generatedGlobs   StringArrayFlag
+	includeGenerated bool
+	// noInstallSuggestions leaves install commands out of diagnostics
+	// about Python packages that aren't installed
+	noInstallSuggestions bool
+	// streamResults sends large results as progress notifications
+	streamResults bool
+	// trustWorkspace records the workspace as trusted, enabling the tools
+	// that change files or run commands
+	trustWorkspace bool
+	// bundleExec starts Ruby servers with bundle exec even in a workspace
+	// that isn't trusted
+	bundleExec bool
+	// configFile holds settings that are reloaded when it changes
+	configFile string
+	// record is a file that tool calls are appended to for replay
+	record string
+	// usageStats is a file that the statistics of each session's tool calls
+	// are appended to, and enables the usage_stats tool
+	usageStats string
+	// requestTimeout bounds each request to the language server
+	requestTimeout time.Duration
+	// lspFallbacks are language server command lines tried in order when
+	// the --lsp server can't be started or initialized
+	lspFallbacks StringArrayFlag
+	// lspDelegates are LANGUAGES=COMMAND entries of language servers that
+	// work alongside the --lsp server on the documents of those languages
+	lspDelegates StringArrayFlag
+	// daemon passes the session to the daemon, over daemonSocket, instead
+	// of serving it
+	daemon       bool
+	daemonSocket string
+	// listen is a unix socket to serve sessions on instead of stdio, one
+	// after another, as the daemon's workers do
+	listen string
+	// sessionID names the session so that a client that reconnects with
+	// the same ID resumes it
+	sessionID string
+	// lspRestarts is how many times a language server that exits on its own
+	// is started again
+	lspRestarts int
+	// maxFileSize is the largest file, in bytes, that is read whole
+	maxFileSize int64
+	// lineEndings is "auto" to keep each file's line endings when editing
+	// it, or "lf" or "crlf" to write every file with those
+	lineEndings string
+	// maxDeletePercent is the largest share of a file that edits delete
+	// without being forced, and validateEdits reports the errors they add
+	maxDeletePercent int
+	validateEdits    bool
+	// showVersion prints the build information instead of starting
+	showVersion bool
+	// lspEnv, lspDir and lspPath configure the language server process
+	lspEnv  StringArrayFlag
+	lspDir  string
+	lspPath StringArrayFlag
+	// lspRunner wraps the LSP command, e.g. to run it in a container
+	lspRunner string
+	// runnerWorkspace is the workspace directory as the runner sees it
+	runnerWorkspace string
+	// lspConnect is the address of a language server listening on a socket
+	lspConnect string
+	// lspProxy is the address editors connect at to share the language
+	// server
+	lspProxy string
+	// pathMaps are LOCAL=REMOTE pairs for a server that sees files elsewhere
+	pathMaps StringArrayFlag
+	// lspRunnerArgs, serverEnv and pathMappings are derived by
+	// expandLSPOptions. serverEnv is what the server needs beyond lspEnv.
+	lspRunnerArgs []string
+	serverEnv     []string
+	pathMappings  []lsp.PathMapping
+	// sandbox runs the language server in a sandbox, which can also read
+	// sandboxRead and write sandboxWrite. sandboxPolicy is derived by
+	// expandLSPOptions.
+	sandbox       bool
+	sandboxRead   StringArrayFlag
+	sandboxWrite  StringArrayFlag
+	sandboxPolicy *sandbox.Policy
+	// profile names the profile of the global config to use instead of the
+	// one matching the workspace, and is then the one in use. noProfile
+	// uses none, and globalConfig replaces the default global config file.
+	profile      string
+	noProfile    bool
+	globalConfig string
+	// noTelemetry turns off the language server's telemetry, and offline
+	// also keeps it and the tools it runs off the network
+	noTelemetry bool
+	offline     bool
+	// pinnedExecutables are the lspExecutables of the config file when the
+	// server started. Reloading the file doesn't change them.
+	pinnedExecutables []lsp.PinnedExecutable
+	// sources records where the flags that aren't defaults came from, by
+	// name, for config show and get_config. addedLSPArgs are the arguments
+	// expandLSPOptions adds to the language server's for the workspace.
+	sources      map[string]string
+	addedLSPArgs []string
 }
 
 type mcpServer struct {
@@ -36,6 +167,38 @@ type mcpServer struct {
 	ctx              context.Context
 	cancelFunc       context.CancelFunc
 	workspaceWatcher *watcher.WorkspaceWatcher
+	// fallback is set when no language server could be started and tools
+	// are backed by text heuristics instead
+	fallback bool
+	// lspInfo is how the language server identified itself, if it did
+	lspInfo *protocol.ServerInfo
+	// liveConfig is the config file currently in effect
+	liveConfig *liveConfig
+	// trust gates the tools that change files or run commands
+	trust *workspaceTrust
+	// semanticSearcher backs the semantic_search tool, which is only
+	// registered when there is one
+	semanticSearcher tools.SemanticSearcher
+	// workspaceWarnings explain why the language server may not load the
+	// workspace, and are sent to the client once it is initialized
+	workspaceWarnings []string
+	// sessions stores the state of the session named with --session-id,
+	// and resumed is the state it was started with
+	sessions *session.Store
+	resumed  *session.State
+	// edits records the edits of the editing tools, so that they can be
+	// undone
+	edits *journal.Journal
+	// notify writes a notification to the client in order with responses
+	notify func(method string, params map[string]any) error
+	// calls are the tool calls the client can cancel
+	calls *toolCalls
+	// usage collects the statistics of the session's tool calls when
+	// --usage-stats is given
+	usage *usage.Stats
+	// proxyListener accepts the editors that share the language server
+	// when --lsp-proxy is given
+	proxyListener net.Listener
 }
 
 // StringArrayFlag is a custom flag type to handle an array of strings
@@ -52,41 +215,383 @@ func (s *StringArrayFlag) String() string {
 	return strings.Join(*s, ",")
 }
 
-func parseConfig() (*config, error) {
-	cfg := &config{}
-	flag.StringVar(&cfg.workspaceDir, "workspace", "", "Path to workspace directory")
-	flag.StringVar(&cfg.lspCommand, "lsp", "", "LSP command to run (args should be passed after --)")
-	flag.Var(&cfg.openGlobs, "open", "Glob of files to open by default (can specify more than once)")
-	flag.Parse()
+// addLSPFlags defines the flags that select the workspace and start the
+// language server, shared by every subcommand that talks to one
+func addLSPFlags(flags *flag.FlagSet, cfg *config) {
+	flags.StringVar(&cfg.workspaceDir, "workspace", "", "Path to workspace directory")
+	flags.StringVar(&cfg.profile, "profile", "", "Profile of the global config to take flags from, instead of the first whose workspaces match")
+	flags.BoolVar(&cfg.noProfile, "no-profile", false, "Don't take flags from a profile of the global config")
+	flags.StringVar(&cfg.globalConfig, "global-config", "", "JSON file with profiles of flags for the workspaces matching their globs, defaults to mcp-language-server/config.json in the user configuration directory")
+	flags.StringVar(&cfg.lspCommand, "lsp", "", "LSP command to run (args should be passed after --)")
+	flags.Var(&cfg.lspEnv, "lsp-env", "Environment variable KEY=VALUE for the LSP server (can specify more than once)")
+	flags.StringVar(&cfg.lspDir, "lsp-dir", "", "Working directory of the LSP server, defaults to the workspace")
+	flags.Var(&cfg.lspPath, "lsp-path", "Directory to put in front of PATH for the LSP server (can specify more than once)")
+	flags.StringVar(&cfg.lspRunner, "lsp-runner", "", "Command that the LSP command is appended to, e.g. \"docker run -i --rm image\" or \"ssh host --\"")
+	flags.StringVar(&cfg.runnerWorkspace, "lsp-runner-workspace", "", "Path of the workspace as seen by the LSP server when it runs through --lsp-runner")
+	flags.StringVar(&cfg.lspConnect, "lsp-connect", "", "Address of a running LSP server to connect to, tcp://host:port or unix:///path. With --lsp, the started server is expected to listen there")
+	flags.StringVar(&cfg.lspProxy, "lsp-proxy", "", "Unix socket to serve the LSP server to editors on, unix:///path, so that an editor shares it, and the documents it opens, with the tools. The socket's directory must be the user's alone, with mode 0700, and is created so if missing. Requires a trusted workspace")
+	flags.Var(&cfg.pathMaps, "path-map", "LOCAL=REMOTE directory mapping for an LSP server that sees files at other paths (can specify more than once)")
+	flags.BoolVar(&cfg.sandbox, "sandbox", false, "Run the LSP server without network access and with only the workspace, toolchain and cache directories on the filesystem (Linux)")
+	flags.Var(&cfg.sandboxRead, "sandbox-read", "Directory or file the sandboxed LSP server may also read (can specify more than once)")
+	flags.Var(&cfg.sandboxWrite, "sandbox-write", "Directory or file the sandboxed LSP server may also write (can specify more than once)")
+	flags.BoolVar(&cfg.noTelemetry, "no-telemetry", false, "Turn off telemetry in the LSP server's settings and opt the tools it runs out of telemetry")
+	flags.BoolVar(&cfg.offline, "offline", false, "Implies --no-telemetry, and points the LSP server's proxies at an address that refuses connections and tells its toolchain and package managers not to download anything")
+}
+
+// lineEndings maps the values of --line-endings to the line ending edited
+// files are written with, "" keeping each file's own
+var lineEndings = map[string]string{"auto": "", "lf": "\n", "crlf": "\r\n"}
+
+// addServeFlags defines the flags that configure the MCP server and its tools
+func addServeFlags(flags *flag.FlagSet, cfg *config) {
+	flags.Var(&cfg.openGlobs, "open", "Glob of files to open by default (can specify more than once)")
+	flags.StringVar(&cfg.testCommand, "test-command", "", "Test command for the run_tests tool, e.g. \"go test {target}\", or \"auto\" to detect it. run_tests is disabled if empty")
+	flags.StringVar(&cfg.buildCommand, "build-command", "", "Build command for the run_build tool, e.g. \"make all\", or \"auto\" to detect it. run_build is disabled if empty")
+	flags.BoolVar(&cfg.lspRequests, "allow-lsp-requests", false, "Enable the lsp_request tool, which sends arbitrary requests to the LSP server")
+	flags.BoolVar(&cfg.lspSettings, "allow-lsp-settings", false, "Enable the update_lsp_settings tool, which changes the LSP server's settings")
+	flags.BoolVar(&cfg.getConfig, "allow-get-config", false, "Enable the get_config tool, which shows the server's configuration, including the LSP server's environment, and where each setting came from")
+	flags.StringVar(&cfg.semanticSearchURL, "semantic-search-url", "", "HTTP endpoint of an embeddings index for the semantic_search tool. semantic_search is disabled if empty")
+	flags.Var(&cfg.generatedGlobs, "generated", "Glob of generated files to leave out of references and diagnostics, in addition to those recognized by name or header (can specify more than once)")
+	flags.BoolVar(&cfg.includeGenerated, "include-generated", false, "Include generated files in references and diagnostics, and don't list their definitions last")
+	flags.BoolVar(&cfg.noInstallSuggestions, "no-install-suggestions", false, "Don't suggest the PyPI package to install for imports pyright can't resolve, e.g. in air-gapped environments")
+	flags.BoolVar(&cfg.streamResults, "stream-results", false, "Send large references, workspace_diagnostics and search results in parts as progress notifications when the client asks for progress")
+	flags.BoolVar(&cfg.trustWorkspace, "trust-workspace", false, "Trust the workspace, remembering it, so tools that change files or run commands are enabled without asking")
+	flags.BoolVar(&cfg.bundleExec, "bundle-exec", false, "Start Ruby language servers in the workspace's bundle with bundle exec even if the workspace isn't trusted. Bundler runs the workspace's Gemfile, which is Ruby code")
+	flags.StringVar(&cfg.configFile, "config", "", "JSON file with disabledTools, logLevel, lspSettings and other settings, checked against the schema and reloaded when it changes")
+	flags.StringVar(&cfg.record, "record", "", "File to append tool calls to, for the replay command")
+	flags.StringVar(&cfg.usageStats, "usage-stats", "", "File to append each session's tool usage statistics to as a JSON line when it ends: calls, failures, latency and result size per tool. Also enables the usage_stats tool")
+	flags.DurationVar(&cfg.requestTimeout, "request-timeout", time.Minute, "How long to wait for the LSP server to answer a request, or 0 to wait as long as it takes")
+	flags.Var(&cfg.lspDelegates, "lsp-for", "Another LSP server to run alongside --lsp for some languages, as LANGUAGES=COMMAND with comma-separated language IDs or * for all, e.g. 'typescript,javascript=vscode-eslint-language-server --stdio'. The command may be a JSON array for arguments with spaces. Diagnostics and code actions of the servers are merged, and other requests go to the first server for the language that has the capability, --lsp last (can specify more than once, in order of priority)")
+	flags.Var(&cfg.lspFallbacks, "lsp-fallback", "LSP command line, with its args, to start when the --lsp server can't be started or initialized. It may be a JSON array for arguments with spaces (can specify more than once, tried in order)")
+	flags.BoolVar(&cfg.daemon, "daemon", false, "Pass the session to the daemon, starting it if it isn't running, so the language server is kept for the next session")
+	flags.StringVar(&cfg.daemonSocket, "daemon-socket", "", "Unix socket of the daemon for --daemon, defaults to the daemon command's. Its directory must be yours with mode 0700, and is created so if missing")
+	flags.StringVar(&cfg.listen, "listen", "", "Unix socket to serve sessions on, one after another, instead of stdio, as the daemon's workers do")
+	flags.StringVar(&cfg.sessionID, "session-id", "", "Save the session's open files, changed LSP settings and last build under this ID, and resume them when a server is started with the same ID and workspace")
+	flags.IntVar(&cfg.lspRestarts, "lsp-restarts", 3, "How many times to restart the LSP server when it exits unexpectedly")
+	flags.Int64Var(&cfg.maxFileSize, "max-file-size", utilities.MaxReadSize, "Largest file in bytes that is opened or read whole, or 0 for no limit. Edits to large files are streamed instead")
+	flags.IntVar(&cfg.maxDeletePercent, "max-delete-percent", utilities.MaxDeletedPercent, "Refuse edits that delete more than this percent of a file's lines, unless the tool is called with force. 0 turns the check off")
+	flags.BoolVar(&cfg.validateEdits, "validate-edits", false, "After a tool edits files, wait for their diagnostics and report the errors the edits introduced")
+	flags.StringVar(&cfg.toolAliases, "tool-aliases", "hidden", "Former names of renamed tools: hidden accepts calls to them with a deprecation notice, listed also lists them, off refuses them")
+	flags.BoolVar(&cfg.noToolExamples, "no-tool-examples", false, "Don't list example calls and their results in the _meta of tools/list")
+	flags.StringVar(&cfg.lineEndings, "line-endings", "auto", "Line endings of edited files: auto keeps each file's own, lf or crlf converts every edited file to them")
+}
+
+func newServeFlags(cfg *config) *flag.FlagSet {
+	flags := newFlagSet("serve")
+	addLSPFlags(flags, cfg)
+	addServeFlags(flags, cfg)
+	flags.BoolVar(&cfg.showVersion, "version", false, "Print version and build information and exit")
+	return flags
+}
+
+func parseConfig(args []string) (*config, error) {
+	cfg, err := parseFlags(args, newServeFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.showVersion {
+		return cfg, nil
+	}
+	if cfg.profile != "" {
+		coreLogger.Info("Using profile %s", cfg.profile)
+	}
+
+	if err := cfg.resolveWorkspace(); err != nil {
+		return nil, err
+	}
 
-	// Get remaining args after -- as LSP arguments
-	cfg.lspArgs = flag.Args()
+	if err := cfg.expandLSPOptions(); err != nil {
+		return nil, err
+	}
+
+	// The server changes to the workspace directory before writing it
+	if cfg.usageStats != "" {
+		usageStats, err := filepath.Abs(cfg.usageStats)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path for usage statistics: %v", err)
+		}
+		cfg.usageStats = usageStats
+	}
+
+	// The server changes to the workspace directory before reading it
+	if cfg.configFile != "" {
+		configFile, err := filepath.Abs(cfg.configFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path for config file: %v", err)
+		}
+		cfg.configFile = configFile
+		fileCfg, err := loadFileConfig(configFile, cfg.variables())
+		if err != nil {
+			return nil, err
+		}
+		cfg.pinnedExecutables = fileCfg.LSPExecutables
+	}
+
+	for _, glob := range cfg.generatedGlobs {
+		if !doublestar.ValidatePattern(glob) {
+			return nil, fmt.Errorf("invalid --generated glob %q", glob)
+		}
+	}
 
-	// Validate workspace directory
+	if cfg.semanticSearchURL != "" {
+		endpoint, err := url.Parse(cfg.semanticSearchURL)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid --semantic-search-url %q: expected an http or https URL", cfg.semanticSearchURL)
+		}
+	}
+
+	if cfg.requestTimeout < 0 {
+		return nil, fmt.Errorf("invalid --request-timeout %v: must not be negative", cfg.requestTimeout)
+	}
+	if cfg.lspRestarts < 0 {
+		return nil, fmt.Errorf("invalid --lsp-restarts %d: must not be negative", cfg.lspRestarts)
+	}
+	if cfg.maxFileSize < 0 {
+		return nil, fmt.Errorf("invalid --max-file-size %d: must not be negative", cfg.maxFileSize)
+	}
+	if cfg.maxDeletePercent < 0 || cfg.maxDeletePercent > 100 {
+		return nil, fmt.Errorf("invalid --max-delete-percent %d: must be between 0 and 100", cfg.maxDeletePercent)
+	}
+	if !slices.Contains(toolAliasModes, cfg.toolAliases) {
+		return nil, fmt.Errorf("invalid --tool-aliases %q: expected hidden, listed or off", cfg.toolAliases)
+	}
+	if _, ok := lineEndings[cfg.lineEndings]; !ok {
+		return nil, fmt.Errorf("invalid --line-endings %q: expected auto, lf or crlf", cfg.lineEndings)
+	}
+	if cfg.daemon && cfg.listen != "" {
+		return nil, fmt.Errorf("--daemon and --listen can't be used together")
+	}
+	for _, command := range cfg.lspFallbacks {
+		if cfg.lspCommand == "" {
+			return nil, fmt.Errorf("--lsp-fallback requires --lsp")
+		}
+		if _, err := parseCommandLine(command); err != nil {
+			return nil, fmt.Errorf("invalid --lsp-fallback %q: %v", command, err)
+		}
+	}
+	for _, entry := range cfg.lspDelegates {
+		if cfg.lspCommand == "" {
+			return nil, fmt.Errorf("--lsp-for requires --lsp")
+		}
+		if _, err := parseDelegateServer(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate LSP command. Without one the server still starts in fallback mode.
+	// With a runner the command only has to exist wherever the runner runs it.
+	executable := cfg.lspCommand
+	if len(cfg.lspRunnerArgs) > 0 {
+		executable = cfg.lspRunnerArgs[0]
+	}
+	if cfg.lspCommand == "" && cfg.lspConnect == "" {
+		coreLogger.Warn("No LSP command given, only heuristic tools will be available")
+	} else if cfg.lspCommand != "" {
+		if _, err := cfg.processOptions().LookPath(executable); err != nil {
+			coreLogger.Warn("LSP command not found: %s, only heuristic tools will be available", executable)
+		}
+	}
+
+	return cfg, nil
+}
+
+// resolveWorkspace makes the workspace directory absolute and checks that it
+// exists. Once it is known, the variables in the flags are substituted.
+func (cfg *config) resolveWorkspace() error {
 	if cfg.workspaceDir == "" {
-		return nil, fmt.Errorf("workspace directory is required")
+		return fmt.Errorf("workspace directory is required")
 	}
 
-	workspaceDir, err := filepath.Abs(cfg.workspaceDir)
+	workspaceDir, err := filepath.Abs(variables{}.expand(cfg.workspaceDir))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get absolute path for workspace: %v", err)
+		return fmt.Errorf("failed to get absolute path for workspace: %v", err)
 	}
 	cfg.workspaceDir = workspaceDir
 
 	if _, err := os.Stat(cfg.workspaceDir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("workspace directory does not exist: %s", cfg.workspaceDir)
+		return fmt.Errorf("workspace directory does not exist: %s", cfg.workspaceDir)
 	}
+	cfg.expandVariables()
+	return nil
+}
 
-	// Validate LSP command
-	if cfg.lspCommand == "" {
-		return nil, fmt.Errorf("LSP command is required")
+// workspaceFolderVar is replaced with the workspace directory as the
+// language server sees it in its arguments
+const workspaceFolderVar = "${workspaceFolder}"
+
+// expandLSPOptions substitutes the workspace folder into the LSP process
+// settings, validates them and makes paths absolute. Arguments after -- are
+// given the workspace as the language server sees it, which differs from
+// the local one when paths are mapped.
+func (cfg *config) expandLSPOptions() error {
+	expand := cfg.variables().expand
+	cfg.pathMappings = nil
+	if cfg.runnerWorkspace != "" {
+		if cfg.lspRunner == "" {
+			return fmt.Errorf("--lsp-runner-workspace requires --lsp-runner")
+		}
+		cfg.pathMappings = append(cfg.pathMappings, lsp.PathMapping{Local: cfg.workspaceDir, Remote: filepath.Clean(cfg.runnerWorkspace)})
+	}
+	for _, entry := range cfg.pathMaps {
+		local, remote, ok := strings.Cut(entry, "=")
+		if !ok || local == "" || remote == "" {
+			return fmt.Errorf("invalid --path-map %q, expected LOCAL=REMOTE", entry)
+		}
+		local, err := filepath.Abs(expand(local))
+		if err != nil {
+			return fmt.Errorf("invalid --path-map %q: %v", entry, err)
+		}
+		// The remote side may be another OS, so it is only checked loosely
+		if !strings.HasPrefix(remote, "/") && !filepath.IsAbs(remote) {
+			return fmt.Errorf("invalid --path-map %q, the remote path must be absolute", entry)
+		}
+		cfg.pathMappings = append(cfg.pathMappings, lsp.PathMapping{Local: local, Remote: remote})
+	}
+	serverWorkspace := lsp.ServerPath(cfg.pathMappings, cfg.workspaceDir)
+	if cfg.lspConnect != "" {
+		if _, _, err := lsp.ParseAddress(cfg.lspConnect); err != nil {
+			return fmt.Errorf("invalid --lsp-connect: %v", err)
+		}
+	}
+	if cfg.lspProxy != "" {
+		if err := checkProxyAddress(cfg.lspProxy); err != nil {
+			return fmt.Errorf("invalid --lsp-proxy: %v", err)
+		}
+	}
+	cfg.lspRunnerArgs = nil
+	for _, arg := range strings.Fields(cfg.lspRunner) {
+		cfg.lspRunnerArgs = append(cfg.lspRunnerArgs, expand(arg))
+	}
+	// Relative directories are taken relative to the workspace
+	resolve := func(dir string) string {
+		dir = expand(dir)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(cfg.workspaceDir, dir)
+		}
+		return filepath.Clean(dir)
+	}
+
+	for i, arg := range cfg.lspArgs {
+		cfg.lspArgs[i] = strings.ReplaceAll(arg, workspaceFolderVar, serverWorkspace)
+	}
+	cfg.addedLSPArgs = lsp.ServerArgs(cfg.workspaceDir, cfg.lspCommand, cfg.lspArgs)
+	cfg.lspArgs = append(cfg.lspArgs, cfg.addedLSPArgs...)
+	for i, entry := range cfg.lspEnv {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid --lsp-env %q, expected KEY=VALUE", entry)
+		}
+		cfg.lspEnv[i] = key + "=" + expand(value)
+	}
+	// Ruby servers in the workspace's bundle run with its gems, and Kotlin
+	// and Swift servers are told where their toolchain is. A runner has its
+	// own environment. Bundler runs the workspace's Gemfile, so the bundle
+	// is only used in a trusted workspace or with --bundle-exec.
+	cfg.serverEnv = nil
+	if len(cfg.lspRunnerArgs) == 0 && cfg.lspCommand != "" {
+		runner, env := lsp.BundleExec(cfg.workspaceDir, cfg.lspCommand)
+		if runner != nil && !cfg.bundleExec && !workspaceTrusted(cfg.workspaceDir, cfg.trustWorkspace) {
+			coreLogger.Warn("Not starting %s with bundle exec, which runs the workspace's Gemfile, as the workspace isn't trusted. Pass --trust-workspace or --bundle-exec to use the bundle", cfg.lspCommand)
+			runner, env = nil, nil
+		}
+		cfg.lspRunnerArgs = runner
+		cfg.serverEnv = append(env, lsp.ToolchainEnv(cfg.workspaceDir, cfg.lspCommand, cfg.processOptions())...)
+	}
+	cfg.serverEnv = append(cfg.serverEnv, cfg.egressPolicy().Env()...)
+	for i, dir := range cfg.lspPath {
+		cfg.lspPath[i] = resolve(dir)
 	}
 
-	if _, err := exec.LookPath(cfg.lspCommand); err != nil {
-		return nil, fmt.Errorf("LSP command not found: %s", cfg.lspCommand)
+	if cfg.lspDir != "" {
+		cfg.lspDir = resolve(cfg.lspDir)
+		if info, err := os.Stat(cfg.lspDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("LSP working directory does not exist: %s", cfg.lspDir)
+		}
 	}
 
-	return cfg, nil
+	cfg.sandboxPolicy = nil
+	if cfg.sandbox {
+		if cfg.lspRunner != "" || cfg.lspConnect != "" {
+			return fmt.Errorf("--sandbox can't be used with --lsp-runner or --lsp-connect")
+		}
+		if err := sandbox.Check(); err != nil {
+			return fmt.Errorf("--sandbox: %v", err)
+		}
+		for i, path := range cfg.sandboxRead {
+			cfg.sandboxRead[i] = resolve(path)
+		}
+		for i, path := range cfg.sandboxWrite {
+			cfg.sandboxWrite[i] = resolve(path)
+		}
+		opts := cfg.processOptions()
+		command, _ := opts.Command(cfg.lspCommand)
+		cfg.sandboxPolicy = cfg.serverSandbox(command, opts)
+	} else if len(cfg.sandboxRead) > 0 || len(cfg.sandboxWrite) > 0 {
+		return fmt.Errorf("--sandbox-read and --sandbox-write require --sandbox")
+	}
+	return nil
+}
+
+// serverSandbox returns the sandbox of a language server started as
+// command with opts
+func (cfg *config) serverSandbox(command string, opts lsp.ProcessOptions) *sandbox.Policy {
+	executable, _ := opts.LookPath(command)
+	policy := sandbox.ForWorkspace(cfg.workspaceDir, executable, opts.Environ())
+	if cfg.lspDir != "" {
+		policy.Read = append(policy.Read, cfg.lspDir)
+	}
+	policy.Read = append(policy.Read, cfg.sandboxRead...)
+	policy.Write = append(policy.Write, cfg.sandboxWrite...)
+	return &policy
+}
+
+// processOptions returns the settings for starting the language server
+func (cfg *config) processOptions() lsp.ProcessOptions {
+	return lsp.ProcessOptions{
+		// Variables given with --lsp-env are added after, so they win
+		Env:          append(slices.Clone(cfg.serverEnv), cfg.lspEnv...),
+		Dir:          cfg.lspDir,
+		Path:         cfg.lspPath,
+		Runner:       cfg.lspRunnerArgs,
+		PathMappings: cfg.pathMappings,
+		Connect:      cfg.lspConnect,
+		Pinned:       cfg.pinnedExecutables,
+		Sandbox:      cfg.sandboxPolicy,
+	}
+}
+
+// useFallbackServer makes a --lsp-fallback command line the language
+// server to start
+func (cfg *config) useFallbackServer(command string) error {
+	fields, err := parseCommandLine(command)
+	if err != nil {
+		return err
+	}
+	cfg.lspCommand, cfg.lspArgs = fields[0], fields[1:]
+	return cfg.expandLSPOptions()
+}
+
+// startLSPClient starts the configured language server, or connects to one
+// that is already running
+func (cfg *config) startLSPClient(ctx context.Context) (*lsp.Client, error) {
+	var client *lsp.Client
+	var err error
+	if cfg.lspCommand == "" {
+		client, err = lsp.Connect(ctx, cfg.lspConnect, cfg.pathMappings)
+	} else {
+		client, err = lsp.NewClientWithOptions(cfg.processOptions(), cfg.lspCommand, cfg.lspArgs...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	client.SetEgressPolicy(cfg.egressPolicy())
+	return client, nil
+}
+
+// egressPolicy returns what the language server may send out
+func (cfg *config) egressPolicy() lsp.EgressPolicy {
+	return lsp.EgressPolicy{NoTelemetry: cfg.noTelemetry, Offline: cfg.offline}
 }
 
 func newServer(config *config) (*mcpServer, error) {
@@ -95,19 +600,26 @@ func newServer(config *config) (*mcpServer, error) {
 		config:     *config,
 		ctx:        ctx,
 		cancelFunc: cancel,
+		edits:      journal.New(),
 	}, nil
 }
 
 func (s *mcpServer) initializeLSP() error {
-	if err := os.Chdir(s.config.workspaceDir); err != nil {
-		return fmt.Errorf("failed to change to workspace directory: %v", err)
+	if s.config.lspCommand == "" && s.config.lspConnect == "" {
+		return fmt.Errorf("no LSP command configured")
 	}
 
-	client, err := lsp.NewClient(s.config.lspCommand, s.config.lspArgs...)
+	client, err := s.config.startLSPClient(s.ctx)
 	if err != nil {
 		return fmt.Errorf("failed to create LSP client: %v", err)
 	}
 	s.lspClient = client
+	client.SetRequestTimeout(s.config.requestTimeout)
+	if s.resumed != nil && s.resumed.Settings != nil {
+		client.SetSettings(s.resumed.Settings)
+	} else {
+		client.SetSettings(s.liveConfig.lspSettings())
+	}
 	s.workspaceWatcher = watcher.NewWorkspaceWatcher(client)
 
 	initResult, err := client.InitializeLSPClient(s.ctx, s.config.workspaceDir)
@@ -116,22 +628,126 @@ func (s *mcpServer) initializeLSP() error {
 	}
 
 	coreLogger.Debug("Server capabilities: %+v", initResult.Capabilities)
+	if initResult.ServerInfo != nil {
+		coreLogger.Info("Language server: %s %s", initResult.ServerInfo.Name, initResult.ServerInfo.Version)
+		s.lspInfo = initResult.ServerInfo
+	}
+	client.SetName(serverLabel(s.config.lspCommand, initResult))
+	s.checkWorkspace()
 
 	if len(s.config.openGlobs) > 0 {
 		s.openInitialFiles()
 	}
+	s.resumeSession()
 
+	if err := client.WaitForServerReady(s.ctx); err != nil {
+		return err
+	}
+	s.startDelegates(client)
+	// Only watch once the server is usable, so a failed start leaves nothing
+	// sending notifications to a closed client
 	go s.workspaceWatcher.WatchWorkspace(s.ctx, s.config.workspaceDir)
-	return client.WaitForServerReady(s.ctx)
+	go s.superviseLSP(client)
+	return nil
 }
 
-func (s *mcpServer) openInitialFiles() {
+// initializeFirstLSP initializes the --lsp server or, when it can't be, the
+// first of the --lsp-fallback servers that can
+func (s *mcpServer) initializeFirstLSP() error {
+	err := s.initializeLSP()
+	for _, command := range s.config.lspFallbacks {
+		if err == nil {
+			break
+		}
+		coreLogger.Warn("Language server %s unavailable, trying %s: %v", s.config.lspCommand, command, err)
+		s.stopLSP()
+		s.lspInfo = nil
+		if err = s.config.useFallbackServer(command); err == nil {
+			err = s.initializeLSP()
+		}
+	}
+	return err
+}
+
+// superviseLSP restarts the language server when it exits without being
+// asked to, up to --lsp-restarts times, waiting longer before each attempt.
+// Tool calls fail with SERVER_NOT_READY in the meantime.
+func (s *mcpServer) superviseLSP(client *lsp.Client) {
+	restarts := 0
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-client.Done():
+		}
+		if !client.Crashed() {
+			return
+		}
+		for {
+			if restarts >= s.config.lspRestarts {
+				coreLogger.Error("Language server exited, not restarting it after %d restarts", restarts)
+				return
+			}
+			restarts++
+			coreLogger.Warn("Language server exited, restarting it (attempt %d of %d)", restarts, s.config.lspRestarts)
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(time.Duration(restarts) * time.Second):
+			}
+			if _, err := client.Restart(s.ctx); err != nil {
+				coreLogger.Error("Failed to restart language server: %v", err)
+				continue
+			}
+			coreLogger.Info("Language server restarted")
+			break
+		}
+	}
+}
+
+// checkWorkspace looks for the project files the language server needs,
+// warning about them rather than letting every tool call fail
+func (s *mcpServer) checkWorkspace() {
+	servers := []string{s.config.lspCommand}
+	if s.lspInfo != nil {
+		servers = append(servers, s.lspInfo.Name)
+	}
+	s.workspaceWarnings = lsp.CheckWorkspace(s.config.workspaceDir, servers...)
+	for _, warning := range s.workspaceWarnings {
+		coreLogger.Warn("%s", warning)
+	}
+}
 
-	err := filepath.WalkDir(s.config.workspaceDir, func(path string, d os.DirEntry, err error) error {
+// sendWorkspaceWarnings sends the workspace warnings to a client as log
+// messages once it has initialized
+func (s *mcpServer) sendWorkspaceWarnings(ctx context.Context, notification mcp.JSONRPCNotification) {
+	for _, warning := range s.workspaceWarnings {
+		err := s.mcpServer.SendNotificationToClient(ctx, "notifications/message", map[string]any{
+			"level":  mcp.LoggingLevelWarning,
+			"logger": "workspace",
+			"data":   warning,
+		})
 		if err != nil {
-			return err
+			coreLogger.Error("Failed to send workspace warning: %v", err)
 		}
+	}
+}
+
+// stopLSP closes a language server that failed to initialize
+func (s *mcpServer) stopLSP() {
+	if s.lspClient == nil {
+		return
+	}
+	if err := s.lspClient.Close(); err != nil {
+		coreLogger.Error("Failed to close LSP client: %v", err)
+	}
+	s.lspClient = nil
+	s.workspaceWatcher = nil
+}
 
+func (s *mcpServer) openInitialFiles() {
+
+	err := walk.Walk(s.ctx, s.config.workspaceDir, walk.Options{}, func(path string, d os.DirEntry) error {
 		if !d.IsDir() {
 			for _, pattern := range s.config.openGlobs {
 				match, err := doublestar.PathMatch(pattern, path)
@@ -156,40 +772,220 @@ func (s *mcpServer) openInitialFiles() {
 }
 
 func (s *mcpServer) start() error {
-	if err := s.initializeLSP(); err != nil {
+	// A worker listens before starting the language server, so that the
+	// daemon can connect while it starts
+	var listener *net.UnixListener
+	if s.config.listen != "" {
+		var err error
+		if listener, err = listenUnix(s.config.listen); err != nil {
+			return err
+		}
+		defer listener.Close()
+	}
+	if err := s.setup(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if listener == nil {
+		return s.serveSession(ctx, os.Stdin, os.Stdout)
+	}
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		sessionCtx, cancel := context.WithCancel(ctx)
+		err = s.serveSession(sessionCtx, conn, conn)
+		cancel()
+		_ = conn.Close()
+		if err != nil {
+			coreLogger.Error("Session error: %v", err)
+		}
+	}
+}
+
+// serveSession serves an MCP session over a stream. The stdio server is set
+// up as ServeStdio does, with the elicitor between it and the client.
+func (s *mcpServer) serveSession(ctx context.Context, in io.Reader, out io.Writer) error {
+	elicitor := newStdioElicitor(in, out)
+	// The session's calls run under ctx, so they ask this session's client
+	ctx = tools.WithChooser(ctx, elicitor.choose)
+	s.notify = elicitor.notify
+	s.trust.setElicitor(elicitor)
+	if s.usage != nil {
+		// A worker's statistics start with each session
+		s.usage.Reset()
+		defer s.dumpUsageStats()
+	}
+	return server.NewStdioServer(s.mcpServer).Listen(ctx, elicitor.in, elicitor.out)
+}
+
+// setup starts the language server, falling back to heuristic tools if it
+// can't, and creates the MCP server with its tools
+func (s *mcpServer) setup() error {
+	if err := os.Chdir(s.config.workspaceDir); err != nil {
+		return fmt.Errorf("failed to change to workspace directory: %v", err)
+	}
+
+	if err := s.loadConfigFile(); err != nil {
+		return err
+	}
+
+	utilities.MaxReadSize = s.config.maxFileSize
+	utilities.LineEnding = lineEndings[s.config.lineEndings]
+	utilities.MaxDeletedPercent = s.config.maxDeletePercent
+
+	if err := s.loadSession(); err != nil {
+		return err
+	}
+
+	trust, err := newWorkspaceTrust(s.config.workspaceDir, s.config.trustWorkspace)
+	if err != nil {
 		return err
 	}
+	s.trust = trust
 
+	if err := s.initializeFirstLSP(); err != nil {
+		coreLogger.Error("Language server unavailable, falling back to heuristic tools: %v", err)
+		s.stopLSP()
+		s.fallback = true
+	}
+
+	options, err := s.serverOptions()
+	if err != nil {
+		return err
+	}
 	s.mcpServer = server.NewMCPServer(
 		"MCP Language Server",
-		"v0.0.2",
-		server.WithLogging(),
-		server.WithRecovery(),
+		currentBuildInfo().serverVersion(s.lspInfo),
+		options...,
 	)
+	s.trust.mcpServer = s.mcpServer
+	s.mcpServer.AddNotificationHandler("notifications/initialized", s.sendWorkspaceWarnings)
+	s.mcpServer.AddNotificationHandler("notifications/cancelled", s.calls.cancelled)
 
-	err := s.registerTools()
+	if s.config.semanticSearchURL != "" {
+		s.semanticSearcher = tools.NewHTTPSemanticSearcher(s.config.semanticSearchURL)
+	}
+	if err := tools.SetGeneratedCode(s.config.workspaceDir, s.config.generatedGlobs, s.config.includeGenerated); err != nil {
+		return err
+	}
+	// Offline, the suggested packages couldn't be installed anyway
+	tools.SetInstallSuggestions(s.config.workspaceDir, !s.config.noInstallSuggestions && !s.config.offline)
+
+	if s.fallback {
+		err = s.registerFallbackTools()
+	} else {
+		err = s.registerTools()
+	}
+	if err == nil {
+		err = s.registerRunTools()
+	}
+	if err == nil && s.config.getConfig {
+		s.registerConfigTool()
+	}
+	if err == nil && s.usage != nil {
+		s.registerUsageStatsTool()
+	}
+	if err == nil {
+		s.registerToolAliases()
+	}
 	if err != nil {
 		return fmt.Errorf("tool registration failed: %v", err)
 	}
 
-	return server.ServeStdio(s.mcpServer)
+	if s.config.configFile != "" {
+		if err := s.watchConfigFile(); err != nil {
+			return err
+		}
+	}
+	if s.config.lspProxy != "" {
+		if err := s.serveLSPProxy(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serverOptions returns the options of the MCP server: the middleware tool
+// calls go through, the tool list filters and the hooks
+func (s *mcpServer) serverOptions() ([]server.ServerOption, error) {
+	var options []server.ServerOption
+	if s.config.usageStats != "" {
+		// Outermost, to see the error codes the other middleware sets
+		s.usage = usage.NewStats()
+		options = append(options, server.WithToolHandlerMiddleware(s.usageMiddleware))
+	}
+	if s.sessions != nil {
+		// Outside the edit guard, so that the saved journal has the call's
+		// edits
+		options = append(options, server.WithToolHandlerMiddleware(s.sessionMiddleware))
+	}
+	s.calls = newToolCalls()
+	options = append(options,
+		server.WithToolHandlerMiddleware(s.calls.middleware),
+		server.WithLogging(),
+		server.WithRecovery(),
+		server.WithToolHandlerMiddleware(errorCodeMiddleware),
+		server.WithToolFilter(s.liveConfig.filterTools),
+		server.WithToolHandlerMiddleware(s.liveConfig.middleware),
+		server.WithToolFilter(s.trust.filterTools),
+		server.WithToolHandlerMiddleware(s.trust.middleware),
+		server.WithToolFilter(addForceArgument),
+		server.WithToolHandlerMiddleware(s.editGuardMiddleware),
+		server.WithToolFilter(localizeTools),
+		server.WithToolFilter(s.listToolAliases),
+	)
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(s.calls.register)
+	hooks.AddOnError(s.calls.failed)
+	if !s.config.noToolExamples {
+		hooks.AddAfterListTools(addToolExamples)
+	}
+	options = append(options, server.WithHooks(hooks))
+	if s.config.record != "" {
+		recorder, err := newSessionRecorder(s.config.record)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, server.WithToolHandlerMiddleware(recorder.middleware))
+	}
+	return options, nil
 }
 
-func main() {
-	coreLogger.Info("MCP Language Server starting")
+// runServe implements the serve subcommand, which runs the MCP server over
+// stdio. It is also what runs when no subcommand is given.
+func runServe(args []string) error {
+	config, err := parseConfig(args)
+	if err != nil {
+		return err
+	}
+	if config.showVersion {
+		fmt.Print(currentBuildInfo())
+		return nil
+	}
+	if config.daemon {
+		return runDaemonSession(config, args)
+	}
+
+	coreLogger.Info("MCP Language Server %s starting", currentBuildInfo().serverVersion(nil))
 
 	done := make(chan struct{})
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	config, err := parseConfig()
-	if err != nil {
-		coreLogger.Fatal("%v", err)
-	}
-
 	server, err := newServer(config)
 	if err != nil {
-		coreLogger.Fatal("%v", err)
+		return err
 	}
 
 	// Parent process monitoring channel
@@ -234,12 +1030,12 @@ func main() {
 	if err := server.start(); err != nil {
 		coreLogger.Error("Server error: %v", err)
 		cleanup(server, done)
-		os.Exit(1)
+		return err
 	}
 
 	<-done
 	coreLogger.Info("Server shutdown complete for PID: %d", os.Getpid())
-	os.Exit(0)
+	return nil
 }
 
 func cleanup(s *mcpServer, done chan struct{}) {
@@ -249,6 +1045,11 @@ func cleanup(s *mcpServer, done chan struct{}) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	s.dumpUsageStats()
+
+	if s.proxyListener != nil {
+		_ = s.proxyListener.Close()
+	}
 	if s.lspClient != nil {
 		coreLogger.Info("Closing open files")
 		s.lspClient.CloseAllFiles(ctx)
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/trust"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The test binary stands in for the processes the server starts, depending
+// on these variables
+const (
+	testWorkerEnv = "MCP_LANGUAGE_SERVER_TEST_WORKER"
+	testServerEnv = "MCP_LANGUAGE_SERVER_TEST_LSP"
+)
+
+func TestMain(m *testing.M) {
+	switch {
+	case os.Getenv(testWorkerEnv) != "":
+		runTestWorker(os.Args[1:])
+	case os.Getenv(testServerEnv) != "":
+		runTestServer(os.Args[1:])
+	default:
+		os.Exit(m.Run())
+	}
+}
+
+// runTestServer is a language server on stdio, or one that fails to start
+// when given --fail
+func runTestServer(args []string) {
+	if slices.Contains(args, "--fail") {
+		os.Exit(1)
+	}
+	server := lsptest.NewServer(protocol.ServerCapabilities{})
+	_ = server.Serve(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout})
+	os.Exit(0)
+}
+
+func TestLSPFallbackOrder(t *testing.T) {
+	t.Setenv(testServerEnv, "1")
+	server := os.Args[0]
+	cfg, err := parseConfig([]string{
+		"--workspace", t.TempDir(),
+		"--lsp", "mcp-language-server-missing",
+		"--lsp-fallback", server + " --fail",
+		"--lsp-fallback", server + " --first",
+		"--lsp-fallback", server + " --second",
+	})
+	require.NoError(t, err)
+	s, err := newServer(cfg)
+	require.NoError(t, err)
+	s.liveConfig = newLiveConfig()
+	t.Cleanup(func() {
+		s.stopLSP()
+		s.cancelFunc()
+	})
+
+	// The missing server and the one that fails are passed over for the
+	// first that initializes, and the rest aren't started
+	require.NoError(t, s.initializeFirstLSP())
+	assert.Equal(t, server, s.config.lspCommand)
+	assert.Equal(t, []string{"--first"}, s.config.lspArgs)
+	require.NotNil(t, s.lspInfo)
+	assert.Equal(t, "lsptest", s.lspInfo.Name)
+}
+
+func TestLSPFallbackNone(t *testing.T) {
+	t.Setenv(testServerEnv, "1")
+	cfg, err := parseConfig([]string{
+		"--workspace", t.TempDir(),
+		"--lsp", os.Args[0], "--lsp-fallback", os.Args[0] + " --fail", "--", "--fail",
+	})
+	require.NoError(t, err)
+	s, err := newServer(cfg)
+	require.NoError(t, err)
+	s.liveConfig = newLiveConfig()
+	t.Cleanup(s.cancelFunc)
+
+	assert.Error(t, s.initializeFirstLSP())
+	s.stopLSP()
+}
+
+func TestLSPDelegates(t *testing.T) {
+	t.Setenv(testServerEnv, "1")
+	server := os.Args[0]
+	delegate, err := json.Marshal([]string{server, "--delegate"})
+	require.NoError(t, err)
+	cfg, err := parseConfig([]string{
+		"--workspace", t.TempDir(),
+		"--lsp", server,
+		"--lsp-for", "typescript=" + server + " --fail",
+		"--lsp-for", "*=" + string(delegate),
+	})
+	require.NoError(t, err)
+	s, err := newServer(cfg)
+	require.NoError(t, err)
+	s.liveConfig = newLiveConfig()
+	t.Cleanup(func() {
+		s.stopLSP()
+		s.cancelFunc()
+	})
+
+	// The server that fails is left out
+	require.NoError(t, s.initializeFirstLSP())
+	delegates := s.lspClient.Delegates()
+	require.Len(t, delegates, 1)
+	assert.Equal(t, "lsptest", delegates[0].Name())
+	assert.Equal(t, "lsptest", s.lspClient.Name())
+}
+
+func TestParseDelegateServer(t *testing.T) {
+	server, err := parseDelegateServer("typescript, javascript=vscode-eslint-language-server --stdio")
+	require.NoError(t, err)
+	assert.Equal(t, delegateServer{
+		languages: []protocol.LanguageKind{"typescript", "javascript"},
+		command:   "vscode-eslint-language-server",
+		args:      []string{"--stdio"},
+	}, server)
+
+	// A JSON array keeps arguments with spaces whole
+	server, err = parseDelegateServer(`*=["/opt/my servers/biome", "lsp-proxy", "--config-path", "a b"]`)
+	require.NoError(t, err)
+	assert.Equal(t, delegateServer{command: "/opt/my servers/biome", args: []string{"lsp-proxy", "--config-path", "a b"}}, server)
+
+	for _, entry := range []string{"biome lsp-proxy", "=biome", "typescript=", "typescript= ", `typescript=["biome"`, "typescript=[]", `typescript=[""]`} {
+		_, err := parseDelegateServer(entry)
+		assert.Error(t, err, entry)
+	}
+}
+
+func TestUseFallbackServer(t *testing.T) {
+	// An OCaml workspace without dune gets an extra argument for ocamllsp
+	workspace := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, ".merlin"), nil, 0644))
+	cfg, err := parseConfig([]string{
+		"--workspace", workspace,
+		"--lsp", "ocamllsp",
+		"--lsp-env", "CACHE=${workspaceFolder}/.cache",
+		"--lsp-fallback", "other-server --root ${workspaceFolder}",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"--fallback-read-dot-merlin"}, cfg.lspArgs)
+
+	// The fallback's options are expanded for it, without what was added
+	// for the first server
+	require.NoError(t, cfg.useFallbackServer(cfg.lspFallbacks[0]))
+	assert.Equal(t, "other-server", cfg.lspCommand)
+	assert.Equal(t, []string{"--root", cfg.workspaceDir}, cfg.lspArgs)
+	assert.Empty(t, cfg.addedLSPArgs)
+
+	var cache []string
+	for _, entry := range cfg.processOptions().Env {
+		if strings.HasPrefix(entry, "CACHE=") {
+			cache = append(cache, entry)
+		}
+	}
+	assert.Equal(t, []string{"CACHE=" + filepath.Join(cfg.workspaceDir, ".cache")}, cache)
+
+	// Arguments with spaces are given as a JSON array
+	require.NoError(t, cfg.useFallbackServer(`["/opt/my servers/other-server", "--log-file", "a b.log"]`))
+	assert.Equal(t, "/opt/my servers/other-server", cfg.lspCommand)
+	assert.Equal(t, []string{"--log-file", "a b.log"}, cfg.lspArgs)
+
+	_, err = parseConfig([]string{"--workspace", workspace, "--lsp", "ocamllsp", "--lsp-fallback", `["other-server"`})
+	assert.ErrorContains(t, err, "invalid --lsp-fallback")
+}
+
+// TestBundleExecNeedsTrust checks that bundler, which runs the workspace's
+// Gemfile, is only used for a trusted workspace or with --bundle-exec
+func TestBundleExecNeedsTrust(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	workspace := t.TempDir()
+	lock := "GEM\n  specs:\n    solargraph (0.50.0)\n"
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, "Gemfile.lock"), []byte(lock), 0644))
+
+	for _, test := range []struct {
+		flags   []string
+		bundled bool
+	}{
+		{nil, false},
+		{[]string{"--bundle-exec"}, true},
+		{[]string{"--trust-workspace"}, true},
+	} {
+		cfg, err := parseConfig(append([]string{"--workspace", workspace, "--lsp", "solargraph"}, test.flags...))
+		require.NoError(t, err)
+		if test.bundled {
+			assert.Equal(t, []string{"bundle", "exec"}, cfg.lspRunnerArgs, test.flags)
+		} else {
+			assert.Empty(t, cfg.lspRunnerArgs, test.flags)
+		}
+	}
+
+	// A workspace trusted earlier uses its bundle
+	path, err := trust.DefaultPath()
+	require.NoError(t, err)
+	require.NoError(t, trust.NewStore(path).Record(workspace, true))
+	cfg, err := parseConfig([]string{"--workspace", workspace, "--lsp", "solargraph"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bundle", "exec"}, cfg.lspRunnerArgs)
+}
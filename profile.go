@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// globalConfig is the configuration shared by every workspace, read from
+// config.json in the user configuration directory or the --global-config
+// file
+type globalConfig struct {
+	// Profiles are tried in order, and the first one whose workspaces
+	// match applies
+	Profiles []profile `json:"profiles"`
+}
+
+// profile gives the workspaces matching its globs serve flags, so that one
+// MCP client entry can start the right language server in every project
+type profile struct {
+	Name string `json:"name"`
+	// Workspaces are globs matched against the absolute workspace path. A
+	// leading ~ is the home directory.
+	Workspaces []string `json:"workspaces"`
+	// Args are serve flags, which the command line overrides
+	Args []string `json:"args"`
+	// LSPArgs are the arguments of the language server, used unless the
+	// command line gives --lsp or arguments after --
+	LSPArgs []string `json:"lspArgs"`
+}
+
+// profileFlags can't be set by a profile, since they select it
+var profileFlags = []string{"workspace", "profile", "no-profile", "global-config"}
+
+// defaultGlobalConfigPath returns where the global config is read from
+// without --global-config
+func defaultGlobalConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "mcp-language-server", "config.json"), nil
+}
+
+// loadGlobalConfig reads and validates the global config. A missing file
+// is an empty config.
+func loadGlobalConfig(path string) (*globalConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &globalConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read global config: %v", err)
+	}
+	var cfg globalConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid global config %s: %v", path, err)
+	}
+	names := make(map[string]bool)
+	for i, p := range cfg.Profiles {
+		if p.Name == "" {
+			return nil, fmt.Errorf("invalid global config %s: profiles[%d] has no name", path, i)
+		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("invalid global config %s: more than one profile is named %q", path, p.Name)
+		}
+		names[p.Name] = true
+		for _, glob := range p.Workspaces {
+			if !doublestar.ValidatePattern(variables{}.expand(expandHome(glob))) {
+				return nil, fmt.Errorf("invalid global config %s: invalid glob %q in profile %s", path, glob, p.Name)
+			}
+		}
+		for _, arg := range p.Args {
+			name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if strings.HasPrefix(arg, "-") && slices.Contains(profileFlags, name) {
+				return nil, fmt.Errorf("invalid global config %s: profile %s can't set --%s", path, p.Name, name)
+			}
+			if arg == "--" {
+				return nil, fmt.Errorf("invalid global config %s: profile %s gives language server arguments in args rather than lspArgs", path, p.Name)
+			}
+		}
+		if _, err := parseProfileArgs(&config{}, p); err != nil {
+			return nil, fmt.Errorf("invalid global config %s: %v", path, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// parseProfileArgs parses a profile's serve flags into cfg and returns the
+// names of those it sets
+func parseProfileArgs(cfg *config, p profile) ([]string, error) {
+	flags := newServeFlags(cfg)
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(p.Args); err != nil {
+		return nil, fmt.Errorf("invalid args in profile %s: %v", p.Name, err)
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("invalid args in profile %s: %q is not a flag", p.Name, flags.Arg(0))
+	}
+	var names []string
+	flags.Visit(func(f *flag.Flag) { names = append(names, f.Name) })
+	return names, nil
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// matches reports whether the profile applies to a workspace
+func (p profile) matches(workspaceDir string) bool {
+	for _, glob := range p.Workspaces {
+		if ok, _ := doublestar.Match(filepath.ToSlash(variables{}.expand(expandHome(glob))), filepath.ToSlash(workspaceDir)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// find returns the profile with a name or, without one, the first that
+// matches the workspace, or nil if none does
+func (g *globalConfig) find(name, workspaceDir string) (*profile, error) {
+	for i, p := range g.Profiles {
+		if name != "" && p.Name == name || name == "" && p.matches(workspaceDir) {
+			return &g.Profiles[i], nil
+		}
+	}
+	if name != "" {
+		return nil, fmt.Errorf("no profile named %q in the global config", name)
+	}
+	return nil, nil
+}
+
+// parseFlags parses the flags of a command that starts a language server
+// over those of the profile that applies to the workspace, so that flags on
+// the command line win and repeatable flags add to the profile's. The
+// arguments after -- become the language server's. Where each flag came
+// from is recorded in the config's sources.
+func parseFlags(args []string, newFlags func(*config) *flag.FlagSet) (*config, error) {
+	// The command line is parsed once to find the workspace and profile
+	given := &config{}
+	if err := newFlags(given).Parse(args); err != nil {
+		return nil, err
+	}
+	cfg := &config{sources: make(map[string]string)}
+	flags := newFlags(cfg)
+	var selected *profile
+	if given.workspaceDir != "" && !given.noProfile {
+		path := variables{}.expand(given.globalConfig)
+		if path == "" {
+			var err error
+			if path, err = defaultGlobalConfigPath(); err != nil {
+				return nil, fmt.Errorf("failed to find the global config: %v", err)
+			}
+		}
+		global, err := loadGlobalConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		workspaceDir, err := filepath.Abs(variables{}.expand(given.workspaceDir))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path for workspace: %v", err)
+		}
+		if selected, err = global.find(given.profile, workspaceDir); err != nil {
+			return nil, err
+		}
+		if selected != nil {
+			names, err := parseProfileArgs(cfg, *selected)
+			if err != nil {
+				return nil, err
+			}
+			for _, name := range names {
+				cfg.sources[name] = "profile " + selected.Name
+			}
+		}
+	} else if given.profile != "" {
+		return nil, fmt.Errorf("--profile requires --workspace")
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	flags.Visit(func(f *flag.Flag) {
+		// Repeatable flags add to the profile's values
+		if _, repeatable := f.Value.(*StringArrayFlag); repeatable && cfg.sources[f.Name] != "" {
+			cfg.sources[f.Name] += " and command line"
+		} else {
+			cfg.sources[f.Name] = "command line"
+		}
+	})
+	cfg.lspArgs = flags.Args()
+	if len(cfg.lspArgs) > 0 {
+		cfg.sources[lspArgsSource] = "command line"
+	}
+	if selected != nil {
+		cfg.profile = selected.Name
+		if given.lspCommand == "" && len(cfg.lspArgs) == 0 && len(selected.LSPArgs) > 0 {
+			cfg.lspArgs = slices.Clone(selected.LSPArgs)
+			cfg.sources[lspArgsSource] = "profile " + selected.Name
+		}
+	}
+	return cfg, nil
+}
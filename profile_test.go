@@ -0,0 +1,164 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileMatches(t *testing.T) {
+	t.Setenv("HOME", "/home/dev")
+	t.Setenv("PROJECTS", "/src")
+	tests := []struct {
+		name      string
+		glob      string
+		workspace string
+		want      bool
+	}{
+		{"exact", "/src/app", "/src/app", true},
+		{"star", "/src/*", "/src/app", true},
+		{"star doesn't cross directories", "/src/*", "/src/go/app", false},
+		{"doublestar", "/src/**", "/src/go/app", true},
+		{"doublestar in the middle", "/src/**/web", "/src/a/b/web", true},
+		{"home", "~/work/**", "/home/dev/work/app", true},
+		{"home alone", "~", "/home/dev", true},
+		{"tilde elsewhere", "/src/~/app", "/src/app", false},
+		{"environment variable", "${env:PROJECTS}/*", "/src/app", true},
+		{"unset environment variable", "${env:MCP_LANGUAGE_SERVER_UNSET}/src/*", "/src/app", true},
+		{"other directory", "/src/*", "/tmp/app", false},
+		{"prefix isn't enough", "/src/app", "/src/app2", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := profile{Name: "p", Workspaces: []string{"/nowhere", tt.glob}}
+			assert.Equal(t, tt.want, p.matches(tt.workspace))
+		})
+	}
+	assert.False(t, profile{Name: "none"}.matches("/src/app"))
+}
+
+func TestGlobalConfigFind(t *testing.T) {
+	global := &globalConfig{Profiles: []profile{
+		{Name: "web", Workspaces: []string{"/src/web/**"}},
+		{Name: "go", Workspaces: []string{"/src/**"}},
+		{Name: "rust", Workspaces: []string{"/rust/**"}},
+	}}
+	tests := []struct {
+		name      string
+		profile   string
+		workspace string
+		want      string
+		wantErr   string
+	}{
+		{"first match wins", "", "/src/web/app", "web", ""},
+		{"later match", "", "/src/go/app", "go", ""},
+		{"no match", "", "/tmp/app", "", ""},
+		{"by name over the workspace", "rust", "/src/web/app", "rust", ""},
+		{"by name without a match", "go", "/tmp/app", "go", ""},
+		{"unknown name", "java", "/src/web/app", "", `no profile named "java"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := global.find(tt.profile, tt.workspace)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.want == "" {
+				assert.Nil(t, p)
+			} else if assert.NotNil(t, p) {
+				assert.Equal(t, tt.want, p.Name)
+			}
+		})
+	}
+}
+
+func TestLoadGlobalConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"valid", `{"profiles": [{"name": "go", "workspaces": ["~/go/**"], "args": ["--lsp", "gopls", "--open=**/*.go"], "lspArgs": ["serve"]}]}`, ""},
+		{"empty", `{}`, ""},
+		{"not JSON", `{"profiles": [`, "invalid global config"},
+		{"no name", `{"profiles": [{"workspaces": ["/src/**"]}]}`, "profiles[0] has no name"},
+		{"duplicate name", `{"profiles": [{"name": "go"}, {"name": "go"}]}`, `more than one profile is named "go"`},
+		{"invalid glob", `{"profiles": [{"name": "go", "workspaces": ["/src/[a"]}]}`, `invalid glob "/src/[a" in profile go`},
+		{"unknown flag", `{"profiles": [{"name": "go", "args": ["--no-such-flag"]}]}`, "invalid args in profile go"},
+		{"not a flag", `{"profiles": [{"name": "go", "args": ["gopls"]}]}`, `"gopls" is not a flag`},
+		{"workspace", `{"profiles": [{"name": "go", "args": ["--workspace", "/src"]}]}`, "profile go can't set --workspace"},
+		{"profile", `{"profiles": [{"name": "go", "args": ["-profile=rust"]}]}`, "profile go can't set --profile"},
+		{"no-profile", `{"profiles": [{"name": "go", "args": ["--no-profile"]}]}`, "profile go can't set --no-profile"},
+		{"global-config", `{"profiles": [{"name": "go", "args": ["--global-config", "/c.json"]}]}`, "profile go can't set --global-config"},
+		{"server arguments in args", `{"profiles": [{"name": "go", "args": ["--lsp", "gopls", "--", "serve"]}]}`, "gives language server arguments in args rather than lspArgs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+			cfg, err := loadGlobalConfig(path)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, cfg)
+		})
+	}
+
+	// A missing file is an empty config
+	cfg, err := loadGlobalConfig(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Profiles)
+}
+
+func TestParseFlagsProfile(t *testing.T) {
+	workspace := t.TempDir()
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"profiles": [
+		{"name": "go", "workspaces": ["`+filepath.ToSlash(workspace)+`"], "args": ["--lsp", "gopls", "--open", "**/*.go"], "lspArgs": ["serve"]},
+		{"name": "rust", "workspaces": ["/nowhere"], "args": ["--lsp", "rust-analyzer"]}
+	]}`), 0644))
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantProfile string
+		wantLSP     string
+		wantArgs    []string
+	}{
+		{"matching profile", nil, "go", "gopls", []string{"serve"}},
+		{"command line wins", []string{"--lsp", "pyright"}, "go", "pyright", nil},
+		{"server arguments on the command line", []string{"--", "-rpc.trace"}, "go", "gopls", []string{"-rpc.trace"}},
+		{"named profile", []string{"--profile", "rust"}, "rust", "rust-analyzer", nil},
+		{"no profile", []string{"--no-profile", "--lsp", "clangd"}, "", "clangd", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"--workspace", workspace, "--global-config", path}, tt.args...)
+			cfg, err := parseFlags(args, newServeFlags)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantProfile, cfg.profile)
+			assert.Equal(t, tt.wantLSP, cfg.lspCommand)
+			if len(tt.wantArgs) == 0 {
+				assert.Empty(t, cfg.lspArgs)
+			} else {
+				assert.Equal(t, tt.wantArgs, cfg.lspArgs)
+			}
+		})
+	}
+
+	// Repeatable flags add to the profile's
+	cfg, err := parseFlags([]string{"--workspace", workspace, "--global-config", path, "--open", "**/*.mod"}, newServeFlags)
+	require.NoError(t, err)
+	assert.Equal(t, StringArrayFlag{"**/*.go", "**/*.mod"}, cfg.openGlobs)
+	assert.Equal(t, "profile go and command line", cfg.sources["open"])
+
+	_, err = parseFlags([]string{"--profile", "go"}, newServeFlags)
+	assert.ErrorContains(t, err, "--profile requires --workspace")
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/benchmarks"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// sessionRecord is one tool call in a file written by serve --record
+type sessionRecord struct {
+	Time      time.Time `json:"time"`
+	Tool      string    `json:"tool"`
+	Arguments any       `json:"arguments,omitempty"`
+}
+
+// sessionRecorder appends every tool call to a session file, one JSON
+// object per line, so a session can be replayed against another build or
+// language server
+type sessionRecorder struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newSessionRecorder(path string) (*sessionRecorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session file: %v", err)
+	}
+	return &sessionRecorder{file: file}, nil
+}
+
+// middleware records a tool call before handling it
+func (r *sessionRecorder) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// The alias's call is recorded instead, so it isn't replayed twice
+		if isAliasedCall(ctx) {
+			return next(ctx, request)
+		}
+		r.record(sessionRecord{
+			Time:      time.Now().UTC(),
+			Tool:      request.Params.Name,
+			Arguments: request.Params.Arguments,
+		})
+		return next(ctx, request)
+	}
+}
+
+func (r *sessionRecorder) record(entry sessionRecord) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		coreLogger.Error("Failed to encode session record: %v", err)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.file.Write(append(data, '\n')); err != nil {
+		coreLogger.Error("Failed to write session record: %v", err)
+	}
+}
+
+// replayOptions are the flags of the replay subcommand besides the server's
+type replayOptions struct {
+	session string
+	// benchmark prints latencies instead of results
+	benchmark bool
+	// repeat is how many times the session is sent
+	repeat int
+	// maxP95 fails the replay when a tool's p95 latency is higher
+	maxP95 time.Duration
+}
+
+func newReplayFlags(cfg *config, opts *replayOptions) *flag.FlagSet {
+	flags := newFlagSet("replay")
+	addLSPFlags(flags, cfg)
+	addServeFlags(flags, cfg)
+	flags.StringVar(&opts.session, "session", "", "Session file written by serve --record")
+	flags.BoolVar(&opts.benchmark, "benchmark", false, "Print the p50 and p95 latency of each tool instead of the results")
+	flags.IntVar(&opts.repeat, "repeat", 1, "Number of times to send the session, for steadier latencies")
+	flags.DurationVar(&opts.maxP95, "max-p95", 0, "Fail when a tool's p95 latency is higher than this, e.g. 500ms (implies --benchmark)")
+	return flags
+}
+
+// runReplay implements the replay subcommand. It sets up the server the same
+// way serve does and sends it the recorded tool calls in order. With
+// --benchmark, the calls are timed and a latency table replaces the results.
+func runReplay(args []string) error {
+	opts := &replayOptions{}
+	cfg, err := parseFlags(args, func(cfg *config) *flag.FlagSet { return newReplayFlags(cfg, opts) })
+	if err != nil {
+		return err
+	}
+	if opts.session == "" {
+		return fmt.Errorf("--session is required")
+	}
+	if opts.repeat < 1 {
+		return fmt.Errorf("--repeat must be at least 1")
+	}
+	if opts.maxP95 < 0 {
+		return fmt.Errorf("--max-p95 can't be negative")
+	}
+	if opts.maxP95 > 0 {
+		opts.benchmark = true
+	}
+	// Recording a replay would append to the file being read
+	if cfg.record != "" {
+		return fmt.Errorf("--record can't be used with replay")
+	}
+	if err := cfg.resolveWorkspace(); err != nil {
+		return err
+	}
+	if err := cfg.expandLSPOptions(); err != nil {
+		return err
+	}
+
+	records, err := readSession(opts.session)
+	if err != nil {
+		return err
+	}
+
+	s, err := newServer(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup(s, make(chan struct{}))
+	if err := s.setup(); err != nil {
+		return err
+	}
+
+	failed := 0
+	report := benchmarks.Report{}
+	for round := range opts.repeat {
+		for i, record := range records {
+			id := round*len(records) + i + 1
+			message, err := json.Marshal(mcp.JSONRPCRequest{
+				JSONRPC: mcp.JSONRPC_VERSION,
+				ID:      mcp.NewRequestId(int64(id)),
+				Request: mcp.Request{Method: string(mcp.MethodToolsCall)},
+				Params:  mcp.CallToolParams{Name: record.Tool, Arguments: record.Arguments},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to encode call %d: %v", i+1, err)
+			}
+
+			if !opts.benchmark {
+				fmt.Printf("=== %d: %s\n", id, record.Tool)
+			}
+			started := time.Now()
+			response := s.mcpServer.HandleMessage(s.ctx, message)
+			elapsed := time.Since(started)
+			report.Add(record.Tool, elapsed)
+
+			text, ok := responseText(response)
+			if !ok {
+				failed++
+			}
+			if !opts.benchmark {
+				fmt.Print(text)
+				fmt.Printf("--- %s\n\n", elapsed.Round(time.Millisecond))
+			}
+		}
+	}
+
+	if opts.benchmark {
+		if err := report.Write(os.Stdout); err != nil {
+			return err
+		}
+	}
+	total := len(records) * opts.repeat
+	if failed > 0 {
+		return fmt.Errorf("%d of %d calls failed", failed, total)
+	}
+	if tool, p95 := report.Slowest(); opts.maxP95 > 0 && p95 > opts.maxP95 {
+		return fmt.Errorf("%s p95 latency %s is over --max-p95 %s", tool, p95.Round(time.Microsecond), opts.maxP95)
+	}
+	return nil
+}
+
+// responseText returns the text of a tool call's response, and whether the
+// call succeeded
+func responseText(response mcp.JSONRPCMessage) (string, bool) {
+	switch response := response.(type) {
+	case mcp.JSONRPCResponse:
+		result, _ := response.Result.(mcp.CallToolResult)
+		var text strings.Builder
+		for _, content := range result.Content {
+			if content, ok := content.(mcp.TextContent); ok {
+				text.WriteString(content.Text + "\n")
+			}
+		}
+		return text.String(), !result.IsError
+	case mcp.JSONRPCError:
+		return fmt.Sprintf("Error: %s\n", response.Error.Message), false
+	}
+	return "", true
+}
+
+func readSession(path string) ([]sessionRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session file: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var records []sessionRecord
+	scanner := bufio.NewScanner(file)
+	// Arguments can carry whole documents
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record sessionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read session file: %v", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// resultStream returns a stream that sends the parts of a large tool result
+// to the client as progress notifications, with each part as the message.
+// It is nil unless --stream-results is set and the client gave a progress
+// token with the call, since clients that don't ask for progress would
+// never see the parts.
+func (s *mcpServer) resultStream(ctx context.Context, request mcp.CallToolRequest) tools.ResultStream {
+	if !s.config.streamResults || request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return nil
+	}
+	token := request.Params.Meta.ProgressToken
+	notify := s.notify
+	if notify == nil {
+		notify = func(method string, params map[string]any) error {
+			return s.mcpServer.SendNotificationToClient(ctx, method, params)
+		}
+	}
+	progress := 0
+	return func(part string) error {
+		progress++
+		return notify("notifications/progress", map[string]any{
+			"progressToken": token,
+			"progress":      progress,
+			"message":       part,
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// registerRunTools registers the tools that run project commands. They don't
+// need a language server and are only enabled when configured.
+func (s *mcpServer) registerRunTools() error {
+	if s.config.testCommand != "" {
+		s.registerRunTestsTool()
+	}
+	if s.config.buildCommand != "" {
+		s.registerRunBuildTool()
+	}
+	return nil
+}
+
+func (s *mcpServer) registerRunTestsTool() {
+	coreLogger.Debug("Registering run_tests with command: %s", s.config.testCommand)
+
+	runTestsTool := mcp.NewTool("run_tests",
+		mcp.WithDescription("Run the project's tests in the workspace and report the exit status and output. Long output is truncated, keeping the beginning and the end."),
+		mcp.WithString("target",
+			mcp.Description("What to test, passed to the test command (e.g. './internal/lsp', 'tests/test_api.py', a file or directory). Defaults to the whole project."),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description("Timeout in seconds"),
+			mcp.DefaultNumber(600),
+			mcp.Min(1),
+			mcp.Max(3600),
+		),
+	)
+	s.mcpServer.AddTool(runTestsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		target := request.GetString("target", "")
+		timeout := time.Duration(request.GetInt("timeout", 600)) * time.Second
+
+		coreLogger.Debug("Executing run_tests for target: %s", target)
+		text, err := tools.RunTests(ctx, s.config.workspaceDir, s.config.testCommand, target, timeout)
+		if err != nil {
+			coreLogger.Error("Failed to run tests: %v", err)
+			return toolError("failed to run tests", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+}
+
+func (s *mcpServer) registerRunBuildTool() {
+	coreLogger.Debug("Registering run_build with command: %s", s.config.buildCommand)
+
+	description := "Build the project and report compiler errors and warnings as diagnostics, including errors the language server can't see such as link errors."
+	// workspace_diagnostics needs a language server
+	if !s.fallback {
+		description += " The results are also included in workspace_diagnostics."
+	}
+	runBuildTool := mcp.NewTool("run_build",
+		mcp.WithDescription(description),
+		mcp.WithNumber("timeout",
+			mcp.Description("Timeout in seconds"),
+			mcp.DefaultNumber(600),
+			mcp.Min(1),
+			mcp.Max(3600),
+		),
+	)
+	s.mcpServer.AddTool(runBuildTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		timeout := time.Duration(request.GetInt("timeout", 600)) * time.Second
+
+		coreLogger.Debug("Executing run_build")
+		text, err := tools.RunBuild(ctx, s.config.workspaceDir, s.config.buildCommand, timeout)
+		if err != nil {
+			coreLogger.Error("Failed to run build: %v", err)
+			return toolError("failed to run build", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/isaacphi/mcp-language-server/internal/sandbox"
+)
+
+func newSandboxFlags(read, write *StringArrayFlag) *flag.FlagSet {
+	flags := newFlagSet(sandbox.Subcommand)
+	flags.Var(read, "read", "Directory or file the command may read and execute (can specify more than once)")
+	flags.Var(write, "write", "Directory or file the command may also write (can specify more than once)")
+	return flags
+}
+
+// runSandbox implements the sandbox subcommand, which --sandbox starts
+// language servers through. It restricts its own access to the filesystem
+// and then runs the command in its place. The network is cut off by the
+// namespaces the server starts it in, so run directly it only restricts
+// the filesystem.
+func runSandbox(args []string) error {
+	var read, write StringArrayFlag
+	flags := newSandboxFlags(&read, &write)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("usage: mcp-language-server sandbox [--read PATH] [--write PATH] -- COMMAND [args]")
+	}
+	policy := sandbox.Policy{Read: read, Write: write}
+	return sandbox.Exec(policy, flags.Arg(0), flags.Args()[1:])
+}
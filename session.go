@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"os"
+	"reflect"
+
+	"github.com/isaacphi/mcp-language-server/internal/session"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// loadSession reads the saved state of the session named with --session-id,
+// which the server resumes once the language server is up
+func (s *mcpServer) loadSession() error {
+	if s.config.sessionID == "" {
+		return nil
+	}
+	dir, err := session.DefaultDir()
+	if err != nil {
+		return err
+	}
+	s.sessions = session.NewStore(dir)
+	s.resumed, err = s.sessions.Load(s.config.workspaceDir, s.config.sessionID)
+	if err != nil {
+		// A session that can't be resumed starts afresh
+		coreLogger.Warn("Not resuming session %s: %v", s.config.sessionID, err)
+		return nil
+	}
+	if s.resumed != nil {
+		coreLogger.Info("Resuming session %s saved at %s", s.config.sessionID, s.resumed.Saved.Local().Format("2006-01-02 15:04:05"))
+		if s.resumed.Build != nil {
+			tools.SetLastBuildDiagnostics(s.resumed.Build, s.resumed.BuildFinished)
+		}
+		if s.resumed.Journal != nil {
+			s.edits.Restore(*s.resumed.Journal)
+		}
+	}
+	return nil
+}
+
+// resumeSession opens the files the resumed session had open
+func (s *mcpServer) resumeSession() {
+	if s.resumed == nil {
+		return
+	}
+	opened := 0
+	for _, path := range s.resumed.OpenFiles {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := s.lspClient.OpenFile(s.ctx, path); err != nil {
+			coreLogger.Error("Failed to reopen %s: %v", path, err)
+			continue
+		}
+		opened++
+	}
+	coreLogger.Info("Reopened %d files of session %s", opened, s.config.sessionID)
+}
+
+// saveSession records the session's state for a client that reconnects
+func (s *mcpServer) saveSession() {
+	state := session.State{Workspace: s.config.workspaceDir}
+	state.Build, state.BuildFinished = tools.LastBuildDiagnostics()
+	if edits := s.edits.State(); len(edits.Entries) > 0 || len(edits.Checkpoints) > 0 {
+		state.Journal = &edits
+	}
+	if s.lspClient != nil {
+		state.OpenFiles = s.lspClient.OpenFilePaths()
+		// Settings are only kept when the session changed them, so that
+		// later changes to the config file aren't hidden
+		if settings := s.lspClient.Settings(); !reflect.DeepEqual(settings, s.liveConfig.lspSettings()) {
+			state.Settings = settings
+		}
+	}
+	if err := s.sessions.Save(s.config.sessionID, state); err != nil {
+		coreLogger.Error("Failed to save session %s: %v", s.config.sessionID, err)
+	}
+}
+
+// sessionMiddleware saves the session after each tool call
+func (s *mcpServer) sessionMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		s.saveSession()
+		return result, err
+	}
+}
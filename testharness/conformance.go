@@ -0,0 +1,275 @@
+package testharness
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Position is a place in a workspace file. Lines and columns start at 1.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// ConformanceCase says where the conformance scenarios look in a workspace.
+// The same scenarios run against every server; a case only names the
+// symbols and positions of its workspace. Scenarios whose fields are empty
+// are skipped.
+type ConformanceCase struct {
+	// Files are written to the workspace before the scenarios run, by
+	// path relative to the workspace
+	Files map[string]string
+	// OpenFiles are opened before the scenarios run, for servers that only
+	// know about open files
+	OpenFiles []string
+	// Settle is how long to wait after opening files, for servers that
+	// index them in the background
+	Settle time.Duration
+
+	// Definition is a symbol read_definition finds
+	Definition string
+	// References is a symbol find_references finds references to
+	References string
+	// Diagnostics is a file with at least one diagnostic
+	Diagnostics string
+	// CodeActions is a position with a diagnostic the server offers code
+	// actions for, usually in the Diagnostics file
+	CodeActions Position
+	// Rename is a symbol to rename to RenameTo. It runs last, as it changes
+	// the workspace.
+	Rename   Position
+	RenameTo string
+}
+
+// ConformanceStatus is the outcome of a scenario against a server
+type ConformanceStatus string
+
+const (
+	// ConformancePass means the server gave the expected answer
+	ConformancePass ConformanceStatus = "pass"
+	// ConformanceFail means the server failed or gave no answer
+	ConformanceFail ConformanceStatus = "FAIL"
+	// ConformanceUnsupported means the server doesn't advertise the
+	// capability the scenario needs
+	ConformanceUnsupported ConformanceStatus = "unsupported"
+	// ConformanceSkipped means the case doesn't set up the scenario, or the
+	// server isn't installed
+	ConformanceSkipped ConformanceStatus = "skipped"
+)
+
+// ConformanceResult is the outcome of one scenario against one server
+type ConformanceResult struct {
+	Server   string
+	Scenario string
+	Status   ConformanceStatus
+	// Detail is the error or unexpected answer of a failure
+	Detail string
+}
+
+// conformanceScenario is a canonical task every server is asked to do
+type conformanceScenario struct {
+	name string
+	// supported reports whether the server advertises what the scenario
+	// needs
+	supported func(protocol.ServerCapabilities) bool
+	// configured reports whether the case sets the scenario up
+	configured func(ConformanceCase) bool
+	// run returns an error when the server doesn't do the task
+	run func(ctx context.Context, ts *TestSuite, c ConformanceCase) error
+}
+
+// conformanceScenarios run in this order, against every server
+var conformanceScenarios = []conformanceScenario{
+	{
+		name:       "definition",
+		supported:  func(caps protocol.ServerCapabilities) bool { return caps.DefinitionProvider != nil },
+		configured: func(c ConformanceCase) bool { return c.Definition != "" },
+		run: func(ctx context.Context, ts *TestSuite, c ConformanceCase) error {
+			result, err := ts.Definition(ctx, c.Definition)
+			return expectAnswer(result, err, "Symbol: "+c.Definition, "")
+		},
+	},
+	{
+		name:       "references",
+		supported:  func(caps protocol.ServerCapabilities) bool { return caps.ReferencesProvider != nil },
+		configured: func(c ConformanceCase) bool { return c.References != "" },
+		run: func(ctx context.Context, ts *TestSuite, c ConformanceCase) error {
+			result, err := ts.References(ctx, c.References)
+			return expectAnswer(result, err, "", "No references found")
+		},
+	},
+	{
+		// Every server can publish diagnostics, there is no capability
+		name:       "diagnostics",
+		supported:  func(protocol.ServerCapabilities) bool { return true },
+		configured: func(c ConformanceCase) bool { return c.Diagnostics != "" },
+		run: func(ctx context.Context, ts *TestSuite, c ConformanceCase) error {
+			result, err := ts.Diagnostics(ctx, c.Diagnostics)
+			return expectAnswer(result, err, "", "No diagnostics found")
+		},
+	},
+	{
+		name:       "code actions",
+		supported:  func(caps protocol.ServerCapabilities) bool { return caps.CodeActionProvider != nil },
+		configured: func(c ConformanceCase) bool { return c.CodeActions.File != "" },
+		run: func(ctx context.Context, ts *TestSuite, c ConformanceCase) error {
+			actions, err := ts.CodeActions(ctx, c.CodeActions)
+			if err != nil {
+				return err
+			}
+			if len(actions) == 0 {
+				return fmt.Errorf("no code actions at %s:%d:%d", c.CodeActions.File, c.CodeActions.Line, c.CodeActions.Column)
+			}
+			return nil
+		},
+	},
+	{
+		name:       "rename",
+		supported:  func(caps protocol.ServerCapabilities) bool { return caps.RenameProvider != nil },
+		configured: func(c ConformanceCase) bool { return c.Rename.File != "" && c.RenameTo != "" },
+		run: func(ctx context.Context, ts *TestSuite, c ConformanceCase) error {
+			result, err := ts.Rename(ctx, c.Rename.File, c.Rename.Line, c.Rename.Column, c.RenameTo)
+			return expectAnswer(result, err, "Successfully renamed", "")
+		},
+	},
+}
+
+// expectAnswer checks that a tool result has want in it, if set, and not
+// unwanted, if set
+func expectAnswer(result string, err error, want, unwanted string) error {
+	if err != nil {
+		return err
+	}
+	if want != "" && !strings.Contains(result, want) {
+		return fmt.Errorf("expected %q in:\n%s", want, result)
+	}
+	if unwanted != "" && strings.Contains(result, unwanted) {
+		return fmt.Errorf("unexpected answer:\n%s", result)
+	}
+	return nil
+}
+
+// RunConformance starts the server of config on its workspace and runs
+// every conformance scenario against it, as subtests of t. Scenarios the
+// server doesn't advertise are skipped rather than failed; the results say
+// which.
+func RunConformance(t *testing.T, config LSPTestConfig, c ConformanceCase) []ConformanceResult {
+	t.Helper()
+	suite := NewTestSuite(t, config)
+	if err := suite.Setup(); err != nil {
+		t.Fatalf("Failed to set up test suite: %v", err)
+	}
+	t.Cleanup(suite.Cleanup)
+
+	for path, content := range c.Files {
+		if err := suite.WriteFile(path, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, path := range c.OpenFiles {
+		if err := suite.Client.OpenFile(suite.Context, suite.path(path)); err != nil {
+			t.Logf("Failed to open %s: %v", path, err)
+		}
+	}
+	time.Sleep(c.Settle)
+
+	caps := suite.Client.ServerCapabilities()
+	var results []ConformanceResult
+	for _, scenario := range conformanceScenarios {
+		result := ConformanceResult{Server: config.Name, Scenario: scenario.name}
+		t.Run(scenario.name, func(t *testing.T) {
+			switch {
+			case !scenario.configured(c):
+				result.Status = ConformanceSkipped
+				t.Skip("not set up by the conformance case")
+			case !scenario.supported(caps):
+				result.Status = ConformanceUnsupported
+				t.Skip("the server doesn't advertise the capability")
+			}
+			ctx, cancel := context.WithTimeout(suite.Context, 30*time.Second)
+			defer cancel()
+			if err := scenario.run(ctx, suite, c); err != nil {
+				result.Status, result.Detail = ConformanceFail, err.Error()
+				t.Error(err)
+				return
+			}
+			result.Status = ConformancePass
+		})
+		results = append(results, result)
+	}
+	return results
+}
+
+// WriteConformanceReport writes results as a table with a row per server
+// and a column per scenario, followed by the details of the failures
+func WriteConformanceReport(w io.Writer, results []ConformanceResult) error {
+	var servers []string
+	status := make(map[string]map[string]ConformanceStatus)
+	for _, result := range results {
+		if status[result.Server] == nil {
+			servers = append(servers, result.Server)
+			status[result.Server] = make(map[string]ConformanceStatus)
+		}
+		status[result.Server][result.Scenario] = result.Status
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprint(tw, "server")
+	for _, scenario := range conformanceScenarios {
+		fmt.Fprintf(tw, "\t%s", scenario.name)
+	}
+	fmt.Fprintln(tw)
+	for _, server := range servers {
+		fmt.Fprint(tw, server)
+		for _, scenario := range conformanceScenarios {
+			cell := status[server][scenario.name]
+			if cell == "" {
+				cell = ConformanceSkipped
+			}
+			fmt.Fprintf(tw, "\t%s", cell)
+		}
+		fmt.Fprintln(tw)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if result.Status == ConformanceFail {
+			if _, err := fmt.Fprintf(w, "\n%s %s: %s\n", result.Server, result.Scenario, result.Detail); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CodeActions returns the code actions the server offers at a position,
+// with the diagnostics there as context
+func (ts *TestSuite) CodeActions(ctx context.Context, pos Position) ([]protocol.Or_Result_textDocument_codeAction_Item0_Elem, error) {
+	path := ts.path(pos.File)
+	if err := ts.Client.OpenFile(ctx, path); err != nil {
+		return nil, err
+	}
+	uri := protocol.URIFromPath(path)
+	at := protocol.Position{Line: uint32(pos.Line - 1), Character: uint32(pos.Column - 1)}
+	diagnostics := []protocol.Diagnostic{}
+	for _, diag := range ts.Client.GetFileDiagnostics(uri) {
+		if diag.Range.Start.Line <= at.Line && diag.Range.End.Line >= at.Line {
+			diagnostics = append(diagnostics, diag)
+		}
+	}
+	return ts.Client.CodeAction(ctx, protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        protocol.Range{Start: at, End: at},
+		Context:      protocol.CodeActionContext{Diagnostics: diagnostics},
+	})
+}
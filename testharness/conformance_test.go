@@ -0,0 +1,37 @@
+package testharness
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunConformance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts language servers")
+	}
+	// The fake server advertises no capabilities
+	results := RunConformance(t, fakeServerConfig(t, 0), ConformanceCase{Definition: "main"})
+	require.Len(t, results, 5)
+	assert.Equal(t, ConformanceResult{Server: "fake-TestRunConformance", Scenario: "definition", Status: ConformanceUnsupported}, results[0])
+	for _, result := range results[1:] {
+		assert.Equal(t, ConformanceSkipped, result.Status, result.Scenario)
+	}
+}
+
+func TestWriteConformanceReport(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteConformanceReport(&out, []ConformanceResult{
+		{Server: "gopls", Scenario: "definition", Status: ConformancePass},
+		{Server: "gopls", Scenario: "rename", Status: ConformanceFail, Detail: "no edits"},
+		{Server: "clangd", Scenario: "code actions", Status: ConformanceUnsupported},
+	}))
+	assert.Equal(t, `server  definition  references  diagnostics  code actions  rename
+gopls   pass        skipped     skipped      skipped       FAIL
+clangd  skipped     skipped     skipped      unsupported   skipped
+
+gopls rename: no edits
+`, out.String())
+}
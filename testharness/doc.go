@@ -0,0 +1,55 @@
+// Package testharness runs language servers against sample workspaces and
+// checks what mcp-language-server's tools make of their answers. It is the
+// harness of this repository's integration tests, exported so that forks
+// and language server authors can write the same tests for their servers:
+//
+//	func TestDefinition(t *testing.T) {
+//		config, err := testharness.Fixture("typescript")
+//		if err != nil {
+//			t.Skip(err)
+//		}
+//		config.Command = "my-typescript-server"
+//		suite := testharness.NewTestSuite(t, config)
+//		if err := suite.Setup(); err != nil {
+//			t.Fatal(err)
+//		}
+//		t.Cleanup(suite.Cleanup)
+//
+//		result, err := suite.Definition(suite.Context, "TestFunction")
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		testharness.SnapshotTestIn(t, "testdata/snapshots", "typescript", "definition", "function", result)
+//	}
+//
+// Each test gets a fresh copy of the workspace in LSPTestConfig.OutputDir,
+// together with the server's log, so that a failure can be inspected after
+// the run. Workspaces can be the fixtures this repository uses, see
+// Fixture, or any directory.
+//
+// As each suite has its own workspace and server, tests can call
+// t.Parallel. LSPTestConfig.MaxParallel caps how many servers of a kind run
+// at once, and the MAX_PARALLEL_SERVERS environment variable caps all of
+// them.
+//
+// Snapshots are files holding the expected output of a tool, with paths
+// made independent of the machine. SnapshotTestIn compares a result with
+// its snapshot, and writes the snapshot when it is missing or when the
+// UPDATE_SNAPSHOTS environment variable is "true". A mismatch fails the
+// test with a unified diff. Normalizers such as NormalizeVersions and
+// SortLines take out what changes from run to run. SnapshotJSONIn does the
+// same for structured results, with options that normalize, ignore or sort
+// single fields. Snapshots written on Windows match those written elsewhere.
+//
+// RunConformance runs canonical scenarios, such as finding a definition or
+// renaming a symbol, against a server, given a ConformanceCase that places
+// them in its workspace. WriteConformanceReport turns the results of several
+// servers into a compatibility matrix.
+//
+// GenerateWorkspace writes a workspace of any size, with functions that
+// reference each other across packages, for stress tests of pagination,
+// truncation, file watching and memory use on large repositories.
+//
+// Tests of servers that aren't installed fail, unless SKIP_MISSING_SERVERS
+// is "true", when they are skipped.
+package testharness
@@ -0,0 +1,180 @@
+package testharness
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// fixtures configure the language servers this repository's integration
+// tests run against, by language. WorkspaceDir is relative to
+// integrationtests/workspaces. rust-analyzer, the Roslyn language server,
+// kotlin-language-server and sourcekit-lsp load a lot on startup, so fewer
+// of them run at once.
+var fixtures = map[string]LSPTestConfig{
+	"go": {
+		Name:             "go",
+		Command:          "gopls",
+		WorkspaceDir:     "go",
+		InitializeTimeMs: 2000,
+		MaxParallel:      4,
+	},
+	"python": {
+		Name:             "python",
+		Command:          "pyright-langserver",
+		Args:             []string{"--stdio"},
+		WorkspaceDir:     "python",
+		InitializeTimeMs: 2000,
+		MaxParallel:      4,
+	},
+	"rust": {
+		Name:             "rust",
+		Command:          "rust-analyzer",
+		WorkspaceDir:     "rust",
+		InitializeTimeMs: 5000,
+		MaxParallel:      2,
+	},
+	"typescript": {
+		Name:             "typescript",
+		Command:          "typescript-language-server",
+		Args:             []string{"--stdio"},
+		WorkspaceDir:     "typescript",
+		InitializeTimeMs: 2000,
+		MaxParallel:      4,
+	},
+	"clangd": {
+		Name:             "clangd",
+		Command:          "clangd",
+		WorkspaceDir:     "clangd",
+		InitializeTimeMs: 2000,
+		MaxParallel:      4,
+	},
+	"terraform": {
+		Name:             "terraform",
+		Command:          "terraform-ls",
+		Args:             []string{"serve"},
+		WorkspaceDir:     "terraform",
+		InitializeTimeMs: 2000,
+		MaxParallel:      4,
+	},
+	"yaml": {
+		Name:             "yaml",
+		Command:          "yaml-language-server",
+		Args:             []string{"--stdio"},
+		WorkspaceDir:     "yaml",
+		InitializeTimeMs: 2000,
+		MaxParallel:      4,
+	},
+	"bash": {
+		Name:             "bash",
+		Command:          "bash-language-server",
+		Args:             []string{"start"},
+		WorkspaceDir:     "bash",
+		InitializeTimeMs: 2000,
+		MaxParallel:      4,
+	},
+	"docker": {
+		Name:             "docker",
+		Command:          "docker-langserver",
+		Args:             []string{"--stdio"},
+		WorkspaceDir:     "docker",
+		InitializeTimeMs: 2000,
+		MaxParallel:      4,
+	},
+	"php": {
+		Name:             "php",
+		Command:          "intelephense",
+		Args:             []string{"--stdio"},
+		WorkspaceDir:     "php",
+		InitializeTimeMs: 2000,
+		MaxParallel:      4,
+	},
+	"ruby": {
+		Name:             "ruby",
+		Command:          "solargraph",
+		Args:             []string{"stdio"},
+		WorkspaceDir:     "ruby",
+		InitializeTimeMs: 3000,
+		MaxParallel:      4,
+	},
+	"kotlin": {
+		Name:             "kotlin",
+		Command:          "kotlin-language-server",
+		WorkspaceDir:     "kotlin",
+		InitializeTimeMs: 10000,
+		MaxParallel:      1,
+	},
+	"swift": {
+		Name:             "swift",
+		Command:          "sourcekit-lsp",
+		WorkspaceDir:     "swift",
+		InitializeTimeMs: 5000,
+		MaxParallel:      2,
+	},
+	"zig": {
+		Name:             "zig",
+		Command:          "zls",
+		WorkspaceDir:     "zig",
+		InitializeTimeMs: 2000,
+		MaxParallel:      4,
+	},
+	"elixir": {
+		Name:             "elixir",
+		Command:          "language_server.sh",
+		WorkspaceDir:     "elixir",
+		InitializeTimeMs: 2000,
+		MaxParallel:      2,
+	},
+	"ocaml": {
+		Name:             "ocaml",
+		Command:          "ocamllsp",
+		WorkspaceDir:     "ocaml",
+		InitializeTimeMs: 2000,
+		MaxParallel:      4,
+	},
+	"csharp": {
+		Name:             "csharp",
+		Command:          "roslyn-language-server",
+		Args:             []string{"--stdio"},
+		WorkspaceDir:     "csharp",
+		InitializeTimeMs: 2000,
+		MaxParallel:      2,
+	},
+}
+
+// Fixture returns the configuration of one of the workspaces this
+// repository tests against, with the language server it is tested with:
+// "go", "python", "rust", "typescript", "clangd", "terraform", "yaml", "bash",
+// "docker", "php", "ruby", "kotlin", "swift", "zig", "elixir", "ocaml" or
+// "csharp".
+// Change Command and Args to run the same workspace against another server.
+//
+// The workspace is read from wherever this package is, a checkout or the
+// module cache. Workspaces that are modules of their own, like the Go one,
+// aren't part of the downloaded module, and return an error there.
+func Fixture(language string) (LSPTestConfig, error) {
+	config, ok := fixtures[language]
+	if !ok {
+		languages := make([]string, 0, len(fixtures))
+		for name := range fixtures {
+			languages = append(languages, name)
+		}
+		sort.Strings(languages)
+		return LSPTestConfig{}, fmt.Errorf("no fixture for %q, expected one of %v", language, languages)
+	}
+	_, thisFile, _, ok := runtime.Caller(0)
+	if !ok {
+		return LSPTestConfig{}, fmt.Errorf("failed to find the fixture workspaces")
+	}
+	config.WorkspaceDir = filepath.Join(filepath.Dir(thisFile), "..", "integrationtests", "workspaces", config.WorkspaceDir)
+	if _, err := os.Stat(config.WorkspaceDir); err != nil {
+		return LSPTestConfig{}, fmt.Errorf("fixture workspace for %s is not available: %w", language, err)
+	}
+	config.Args = append([]string(nil), config.Args...)
+	if language == "clangd" {
+		config.Args = append(config.Args, "--compile-commands-dir="+config.WorkspaceDir)
+	}
+	return config, nil
+}
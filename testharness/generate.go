@@ -0,0 +1,30 @@
+package testharness
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/workspacegen"
+)
+
+// WorkspaceSpec sizes a generated workspace: how many packages, files per
+// package and functions per file, in which language, and how many earlier
+// packages each function calls into. Zero fields take defaults.
+type WorkspaceSpec = workspacegen.Spec
+
+// GeneratedWorkspace describes a generated workspace: its files, the number
+// of functions, and the shared function every one of them calls
+type GeneratedWorkspace = workspacegen.Workspace
+
+// GenerateWorkspace writes a workspace sized by spec into a temporary
+// directory, for stress tests of workspaces larger than the fixtures. Set
+// WorkspaceDir of an LSPTestConfig to its Dir to run a server on it.
+// Function names come from workspacegen.FunctionName's scheme,
+// Func<package>_<file>_<function>.
+func GenerateWorkspace(t testing.TB, spec WorkspaceSpec) *GeneratedWorkspace {
+	t.Helper()
+	workspace, err := workspacegen.Generate(t.TempDir(), spec)
+	if err != nil {
+		t.Fatalf("Failed to generate workspace: %v", err)
+	}
+	return workspace
+}
@@ -0,0 +1,193 @@
+package testharness
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixture(t *testing.T) {
+	config, err := Fixture("typescript")
+	require.NoError(t, err)
+	assert.Equal(t, "typescript-language-server", config.Command)
+	assert.Equal(t, []string{"--stdio"}, config.Args)
+	assert.FileExists(t, filepath.Join(config.WorkspaceDir, "tsconfig.json"))
+
+	// Callers may change the arguments without changing the fixture
+	config.Args[0] = "--changed"
+	config, err = Fixture("typescript")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--stdio"}, config.Args)
+
+	config, err = Fixture("clangd")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--compile-commands-dir=" + config.WorkspaceDir}, config.Args)
+
+	_, err = Fixture("cobol")
+	assert.ErrorContains(t, err, `no fixture for "cobol", expected one of [bash clangd csharp docker elixir go kotlin ocaml php python ruby rust swift terraform typescript yaml zig]`)
+}
+
+func TestSnapshotTestIn(t *testing.T) {
+	dir := t.TempDir()
+	result := "/home/me/test-output/go/TestX/workspace/main.go:3\nfunc main()"
+
+	// A missing snapshot is written, with paths normalized
+	SnapshotTestIn(t, dir, "go", "definition", "main", result)
+	snapshot, err := os.ReadFile(filepath.Join(dir, "go", "definition", "main.snap"))
+	require.NoError(t, err)
+	assert.Equal(t, "/TEST_OUTPUT/workspace/main.go:3\nfunc main()", string(snapshot))
+
+	// The same result from another machine matches it
+	SnapshotTestIn(t, dir, "go", "definition", "main", "/tmp/other/workspace/main.go:3\nfunc main()")
+}
+
+// recordingTB records failures instead of failing the test
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTB) Logf(string, ...any) {}
+
+func TestNormalizePathsWindows(t *testing.T) {
+	for input, expected := range map[string]string{
+		`C:\Users\ci\AppData\Local\Temp\mcp-language-server-tests\go\TestX\workspace\pkg\main.go:3`: "/TEST_OUTPUT/workspace/pkg/main.go:3",
+		"file:///C:/Users/ci/test-output/go/TestX/workspace/pkg/main.go":                            "/TEST_OUTPUT/workspace/pkg/main.go",
+		"file:///c%3A/Users/ci/test-output/go/TestX/workspace/pkg/main.go":                          "/TEST_OUTPUT/workspace/pkg/main.go",
+		`D:\a\repo\integrationtests\workspaces\clangd\main.cpp`:                                     "/TEST_OUTPUT/workspace/clangd/main.cpp",
+		// Backslashes in results on other platforms are left alone
+		`/tmp/workspace/main.go: fmt.Print("\n")`: `/TEST_OUTPUT/workspace/main.go: fmt.Print("\n")`,
+	} {
+		assert.Equal(t, expected, NormalizePaths(input), input)
+	}
+}
+
+func TestSnapshotLineEndings(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "go", "hover", "func.snap")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	// As checked out on Windows
+	require.NoError(t, os.WriteFile(path, []byte("func main()\r\ndoc\r\n"), 0644))
+
+	failing := &recordingTB{TB: t}
+	SnapshotTestIn(failing, dir, "go", "hover", "func", "func main()\ndoc\n")
+	SnapshotTestIn(failing, dir, "go", "hover", "func", "func main()\r\ndoc\r\n")
+	assert.Empty(t, failing.errors)
+}
+
+func TestCopyDir(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "pkg"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "pkg", "main.go"), []byte("package main\n"), 0444))
+
+	dst := filepath.Join(t.TempDir(), "workspace")
+	require.NoError(t, CopyDir(src, dst))
+	content, err := os.ReadFile(filepath.Join(dst, "pkg", "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(content))
+
+	// Read-only sources give copies the tests can edit
+	require.NoError(t, os.WriteFile(filepath.Join(dst, "pkg", "main.go"), []byte("package changed\n"), 0644))
+}
+
+func TestGenerateWorkspace(t *testing.T) {
+	workspace := GenerateWorkspace(t, WorkspaceSpec{Language: "python", Packages: 2, FilesPerPackage: 3, FunctionsPerFile: 4})
+	assert.Len(t, workspace.Files, 2*3+1)
+	assert.Equal(t, 2*3*4, workspace.Functions)
+	assert.FileExists(t, filepath.Join(workspace.Dir, workspace.FilePath(1, 2)))
+}
+
+func TestSnapshotDiff(t *testing.T) {
+	dir := t.TempDir()
+	SnapshotTestIn(t, dir, "go", "references", "refs", "a\nb\nc\nd\n")
+
+	failing := &recordingTB{TB: t}
+	SnapshotTestIn(failing, dir, "go", "references", "refs", "a\nb\nC\nd\n")
+	require.Len(t, failing.errors, 1)
+	diff := "--- refs.snap\n+++ refs.snap\n@@ -1,4 +1,4 @@\n a\n b\n-c\n+C\n d\n"
+	assert.Contains(t, failing.errors[0], diff)
+	written, err := os.ReadFile(filepath.Join(dir, "go", "references", "refs.snap.diff"))
+	require.NoError(t, err)
+	assert.Equal(t, diff, string(written))
+}
+
+func TestNormalizers(t *testing.T) {
+	assert.Equal(t, "gopls VERSION (go VERSION)", NormalizeVersions("gopls v0.18.1 (go 1.24.0-rc.2)"))
+	assert.Equal(t, "took DURATION, then DURATION; 10 files", NormalizeDurations("took 1m2.5s, then 250µs; 10 files"))
+	assert.Equal(t, "a\nb\nc\n", SortLines("c\na\nb\n"))
+
+	dir := t.TempDir()
+	SnapshotTestIn(t, dir, "go", "version", "server", "gopls v0.18.1 in 2s", NormalizeVersions, NormalizeDurations)
+	snapshot, err := os.ReadFile(filepath.Join(dir, "go", "version", "server.snap"))
+	require.NoError(t, err)
+	assert.Equal(t, "gopls VERSION in DURATION", string(snapshot))
+}
+
+func TestSnapshotJSONIn(t *testing.T) {
+	dir := t.TempDir()
+	opts := []JSONOption{
+		IgnoreField("started"),
+		NormalizeField("server", NormalizeVersions),
+		NormalizeStrings(NormalizeDurations),
+		SortArray("files"),
+		SortArray("files.*.lines"),
+	}
+	SnapshotJSONIn(t, dir, "go", "stats", "workspace", `{
+		"started": "2026-01-02T03:04:05Z",
+		"server": "gopls v0.18.1",
+		"elapsed": "1.5s",
+		"files": [
+			{"path": "/tmp/a/workspace/b.go", "lines": [3, 1]},
+			{"path": "/tmp/a/workspace/a.go", "lines": [2]}
+		]
+	}`, opts...)
+	snapshot, err := os.ReadFile(filepath.Join(dir, "go", "stats", "workspace.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "elapsed": "DURATION",
+  "files": [
+    {
+      "lines": [
+        1,
+        3
+      ],
+      "path": "/TEST_OUTPUT/workspace/b.go"
+    },
+    {
+      "lines": [
+        2
+      ],
+      "path": "/TEST_OUTPUT/workspace/a.go"
+    }
+  ],
+  "server": "gopls VERSION",
+  "started": "IGNORED"
+}
+`, string(snapshot))
+
+	// Another run, in another order, on another machine, matches
+	failing := &recordingTB{TB: t}
+	SnapshotJSONIn(failing, dir, "go", "stats", "workspace", map[string]any{
+		"started": "2026-05-06T07:08:09Z",
+		"server":  "gopls v0.19.0",
+		"elapsed": "20ms",
+		"files": []map[string]any{
+			{"path": "/home/ci/workspace/a.go", "lines": []int{2}},
+			{"path": "/home/ci/workspace/b.go", "lines": []int{1, 3}},
+		},
+	}, opts...)
+	assert.Empty(t, failing.errors)
+
+	// A changed field fails
+	SnapshotJSONIn(failing, dir, "go", "stats", "workspace", `{"files": []}`, opts...)
+	require.Len(t, failing.errors, 1)
+	assert.Contains(t, failing.errors[0], `-  "elapsed": "DURATION",`)
+}
@@ -0,0 +1,136 @@
+package testharness
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Logger is an interface for logging in tests
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// Helper to copy directories recursively
+func CopyDir(src, dst string) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(dst, srcInfo.Mode()); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		srcPath := filepath.Join(src, entry.Name())
+		dstPath := filepath.Join(dst, entry.Name())
+
+		if entry.IsDir() {
+			if err = CopyDir(srcPath, dstPath); err != nil {
+				return err
+			}
+		} else {
+			if err = CopyFile(srcPath, dstPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Helper to copy a single file
+func CopyFile(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := srcFile.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close source file: %v\n", err)
+		}
+	}()
+
+	srcInfo, err := srcFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	dstFile, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := dstFile.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close destination file: %v\n", err)
+		}
+	}()
+
+	if _, err = io.Copy(dstFile, srcFile); err != nil {
+		return err
+	}
+
+	// Keep the copy writable, as tests edit it and remove it afterwards.
+	// Checkouts on Windows may have read-only files.
+	return os.Chmod(dst, srcInfo.Mode()|0200)
+}
+
+// CleanupTestSuites is a helper to clean up all test suites in a test
+func CleanupTestSuites(suites ...*TestSuite) {
+	for _, suite := range suites {
+		if suite != nil {
+			suite.Cleanup()
+		}
+	}
+}
+
+// use instead of runtime.GOROOT which is deprecated. Looked up once, as
+// every string of a JSON snapshot is normalized.
+var getGoRoot = sync.OnceValue(func() string {
+	cmd := exec.Command("go", "env", "GOROOT")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := cmd.Run()
+	if err != nil {
+		panic(err)
+	}
+	return strings.TrimSpace(out.String())
+})
+
+// FindRepoRoot locates the repository root by looking for specific indicators
+// Exported so it can be used by other packages
+func FindRepoRoot() (string, error) {
+	// Start from the current directory and walk up until we find the main.go file
+	// which is at the repository root
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		// Check if this is the repo root (has a go.mod file)
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			// Found the repo root
+			return dir, nil
+		}
+
+		// Move up one directory
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			// We've reached the filesystem root without finding repo root
+			return "", fmt.Errorf("repository root not found")
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,46 @@
+package testharness
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// serverSlots limits how many servers of each name run at once, across the
+// suites of a test binary
+var serverSlots = struct {
+	sync.Mutex
+	byName map[string]chan struct{}
+}{byName: make(map[string]chan struct{})}
+
+// maxParallel returns how many servers of a config may run at once
+func maxParallel(config LSPTestConfig) int {
+	if n, err := strconv.Atoi(os.Getenv("MAX_PARALLEL_SERVERS")); err == nil && n > 0 {
+		return n
+	}
+	if config.MaxParallel > 0 {
+		return config.MaxParallel
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// acquireServerSlot blocks until fewer than maxParallel servers named like
+// config run, and returns the function that frees the slot. The cap is the
+// one of the first suite of a name; a test that runs more suites of a name
+// at once than the cap blocks forever.
+func acquireServerSlot(config LSPTestConfig) (release func()) {
+	serverSlots.Lock()
+	slots, ok := serverSlots.byName[config.Name]
+	if !ok {
+		slots = make(chan struct{}, maxParallel(config))
+		serverSlots.byName[config.Name] = slots
+	}
+	serverSlots.Unlock()
+
+	slots <- struct{}{}
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-slots })
+	}
+}
@@ -0,0 +1,93 @@
+package testharness
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain runs the test binary as a language server when a suite starts it
+func TestMain(m *testing.M) {
+	if os.Getenv("TESTHARNESS_FAKE_SERVER") == "true" {
+		server := lsptest.NewServer(protocol.ServerCapabilities{})
+		server.OnNotification("exit", func(json.RawMessage) { os.Exit(0) })
+		if err := server.Serve(struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, os.Stdout}); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func fakeServerConfig(t *testing.T, maxParallel int) LSPTestConfig {
+	t.Setenv("TESTHARNESS_FAKE_SERVER", "true")
+	executable, err := os.Executable()
+	require.NoError(t, err)
+	workspace := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, "main.go"), []byte("package main\n"), 0644))
+	return LSPTestConfig{
+		Name:             "fake-" + t.Name(),
+		Command:          executable,
+		WorkspaceDir:     workspace,
+		InitializeTimeMs: 1,
+		OutputDir:        t.TempDir(),
+		MaxParallel:      maxParallel,
+	}
+}
+
+func TestParallelSuites(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts language servers")
+	}
+	t.Setenv("MAX_PARALLEL_SERVERS", "")
+	config := fakeServerConfig(t, 1)
+
+	var running atomic.Int32
+	workspaces := make(chan string, 2)
+	t.Run("group", func(t *testing.T) {
+		for _, name := range []string{"first", "second"} {
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				suite := NewTestSuite(t, config)
+				require.NoError(t, suite.Setup())
+				defer suite.Cleanup()
+
+				// The cap of one keeps the servers from overlapping
+				assert.Equal(t, int32(1), running.Add(1))
+				defer running.Add(-1)
+				workspaces <- suite.WorkspaceDir
+				assert.FileExists(t, filepath.Join(suite.WorkspaceDir, "main.go"))
+				assert.FileExists(t, suite.logFile)
+				time.Sleep(100 * time.Millisecond)
+			})
+		}
+	})
+	close(workspaces)
+
+	// Each test had its own workspace
+	var dirs []string
+	for dir := range workspaces {
+		dirs = append(dirs, dir)
+	}
+	require.Len(t, dirs, 2)
+	assert.NotEqual(t, dirs[0], dirs[1])
+}
+
+func TestMaxParallel(t *testing.T) {
+	t.Setenv("MAX_PARALLEL_SERVERS", "")
+	assert.Equal(t, 3, maxParallel(LSPTestConfig{MaxParallel: 3}))
+	t.Setenv("MAX_PARALLEL_SERVERS", "1")
+	assert.Equal(t, 1, maxParallel(LSPTestConfig{MaxParallel: 3}))
+}
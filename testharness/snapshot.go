@@ -0,0 +1,380 @@
+package testharness
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// Normalizer rewrites the parts of a result that differ between machines or
+// runs, so that snapshots only change when behavior does
+type Normalizer func(text string) string
+
+var (
+	// versionPattern matches semantic versions, with an optional v, pre-release
+	// and build
+	versionPattern = regexp.MustCompile(`\bv?\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?\b`)
+	// durationPattern matches durations as Go prints them, such as 1m2.5s
+	durationPattern = regexp.MustCompile(`\b(?:\d+(?:\.\d+)?(?:ns|us|µs|ms|s|m|h))+\b`)
+)
+
+// NormalizePaths replaces paths to workspace copies, fixture workspaces and
+// GOROOT with placeholders. Snapshots always have it applied. Windows paths,
+// with drive letters and backslashes, become the same placeholders with
+// forward slashes, so that snapshots are shared between platforms.
+func NormalizePaths(input string) string {
+	// No need to get the repo root - we're just looking for patterns
+
+	// But this is useful
+	goroot := getGoRoot()
+	if goroot == "" {
+		// Every line would match
+		goroot = "/GOROOT"
+	}
+
+	// Simple approach: just replace any path segments that contain workspace/.
+	// Some tests, e.g. clangd, may include fully qualified paths to the base
+	// workspaces/ directory.
+	lines := strings.Split(input, "\n")
+	for i, line := range lines {
+		for _, marker := range []string{"/workspace/", "/workspaces/", `\workspace\`, `\workspaces\`} {
+			// Any line containing a path to a workspace file needs normalization
+			parts := strings.Split(line, marker)
+			if len(parts) > 1 {
+				// Replace with a simple placeholder path
+				lines[i] = "/TEST_OUTPUT/workspace/" + toSlash(parts[1], marker)
+			}
+		}
+		for _, root := range []string{goroot, filepath.ToSlash(goroot)} {
+			parts := strings.Split(line, root)
+			if len(parts) > 1 {
+				lines[i] = "/GOROOT" + toSlash(parts[1], root)
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// toSlash turns the backslashes of the rest of a path into slashes when its
+// matched prefix was a Windows path
+func toSlash(rest, prefix string) string {
+	if !strings.Contains(prefix, `\`) {
+		return rest
+	}
+	return strings.ReplaceAll(rest, `\`, "/")
+}
+
+// NormalizeLineEndings replaces Windows line endings with newlines.
+// Snapshots always have it applied, and are compared with it applied, so
+// that a checkout with CRLF line endings matches them.
+func NormalizeLineEndings(text string) string {
+	return strings.ReplaceAll(text, "\r\n", "\n")
+}
+
+// NormalizeVersions replaces versions such as v1.2.3 or 0.4.0-rc.1 with
+// VERSION, for output that names a language server or toolchain version
+func NormalizeVersions(text string) string {
+	return versionPattern.ReplaceAllString(text, "VERSION")
+}
+
+// NormalizeDurations replaces durations such as 250ms or 1m2.5s with
+// DURATION
+func NormalizeDurations(text string) string {
+	return durationPattern.ReplaceAllString(text, "DURATION")
+}
+
+// SortLines sorts the lines of a result, for output whose order depends on
+// the language server or on timing
+func SortLines(text string) string {
+	trailingNewline := strings.HasSuffix(text, "\n")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	sort.Strings(lines)
+	text = strings.Join(lines, "\n")
+	if trailingNewline {
+		text += "\n"
+	}
+	return text
+}
+
+// SnapshotTest compares the actual result against an expected result file
+// in this repository's integrationtests/snapshots
+// If the file doesn't exist or UPDATE_SNAPSHOTS=true env var is set, it will update the snapshot
+func SnapshotTest(t testing.TB, languageName, toolName, testName, actualResult string, normalizers ...Normalizer) {
+	t.Helper()
+	// Get the absolute path to the snapshots directory
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		t.Fatalf("Failed to find repo root: %v", err)
+	}
+	SnapshotTestIn(t, filepath.Join(repoRoot, "integrationtests", "snapshots"), languageName, toolName, testName, actualResult, normalizers...)
+}
+
+// SnapshotTestIn works like SnapshotTest with the snapshots kept in dir,
+// as dir/LANGUAGE/TOOL/TEST.snap. The normalizers are applied in order after
+// NormalizePaths.
+func SnapshotTestIn(t testing.TB, dir, languageName, toolName, testName, actualResult string, normalizers ...Normalizer) {
+	t.Helper()
+	// Normalize paths in the result to avoid system-specific paths in snapshots
+	actualResult = NormalizePaths(NormalizeLineEndings(actualResult))
+	for _, normalize := range normalizers {
+		actualResult = normalize(actualResult)
+	}
+	compareSnapshot(t, filepath.Join(dir, languageName, toolName, testName+".snap"), actualResult)
+}
+
+// JSONOption changes how SnapshotJSON normalizes a value
+type JSONOption func(*jsonNormalization)
+
+// jsonNormalization holds what SnapshotJSON does to a value, in order:
+// fields first, then strings, then arrays
+type jsonNormalization struct {
+	fields  []fieldNormalizer
+	strings []Normalizer
+	sorted  []string
+}
+
+type fieldNormalizer struct {
+	path      string
+	normalize func(value any) any
+}
+
+// NormalizeStrings applies normalizers to every string in the value, after
+// NormalizePaths
+func NormalizeStrings(normalizers ...Normalizer) JSONOption {
+	return func(n *jsonNormalization) {
+		n.strings = append(n.strings, normalizers...)
+	}
+}
+
+// NormalizeField applies a normalizer to the values at a path. Values that
+// aren't strings are normalized as JSON and replaced by the result if it
+// changed.
+//
+// Paths are field names separated by dots, where * matches every field of
+// an object or element of an array, as in "diagnostics.*.message".
+func NormalizeField(path string, normalize Normalizer) JSONOption {
+	return func(n *jsonNormalization) {
+		n.fields = append(n.fields, fieldNormalizer{path: path, normalize: func(value any) any {
+			if text, ok := value.(string); ok {
+				return normalize(text)
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return value
+			}
+			if normalized := normalize(string(encoded)); normalized != string(encoded) {
+				return normalized
+			}
+			return value
+		}})
+	}
+}
+
+// IgnoreField replaces the values at a path with IGNORED, for fields such
+// as timestamps that are never the same. See NormalizeField for paths.
+func IgnoreField(path string) JSONOption {
+	return func(n *jsonNormalization) {
+		n.fields = append(n.fields, fieldNormalizer{path: path, normalize: func(any) any { return "IGNORED" }})
+	}
+}
+
+// SortArray sorts the arrays at a path by the JSON of their elements, once
+// everything else is normalized and inner arrays are sorted. See
+// NormalizeField for paths; "" is the value itself.
+func SortArray(path string) JSONOption {
+	return func(n *jsonNormalization) {
+		n.sorted = append(n.sorted, path)
+	}
+}
+
+// SnapshotJSON compares a structured result with a snapshot in this
+// repository's integrationtests/snapshots, as LANGUAGE/TOOL/TEST.json.
+// value is a JSON document as a string, []byte or json.RawMessage, or
+// anything else that encodes to JSON. The snapshot is indented with sorted
+// keys, so that its diffs point at the fields that changed.
+func SnapshotJSON(t testing.TB, languageName, toolName, testName string, value any, opts ...JSONOption) {
+	t.Helper()
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		t.Fatalf("Failed to find repo root: %v", err)
+	}
+	SnapshotJSONIn(t, filepath.Join(repoRoot, "integrationtests", "snapshots"), languageName, toolName, testName, value, opts...)
+}
+
+// SnapshotJSONIn works like SnapshotJSON with the snapshots kept in dir
+func SnapshotJSONIn(t testing.TB, dir, languageName, toolName, testName string, value any, opts ...JSONOption) {
+	t.Helper()
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	case json.RawMessage:
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			t.Fatalf("Failed to encode result: %v", err)
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		t.Fatalf("Result is not JSON: %v", err)
+	}
+
+	var n jsonNormalization
+	for _, opt := range opts {
+		opt(&n)
+	}
+	for _, field := range n.fields {
+		doc = applyAtPath(doc, splitPath(field.path), field.normalize)
+	}
+	doc = normalizeStrings(doc, append([]Normalizer{NormalizePaths}, n.strings...))
+	// Inner arrays are sorted first, as outer ones are ordered by their content
+	sort.SliceStable(n.sorted, func(i, j int) bool {
+		return len(splitPath(n.sorted[i])) > len(splitPath(n.sorted[j]))
+	})
+	for _, path := range n.sorted {
+		doc = applyAtPath(doc, splitPath(path), sortArray)
+	}
+
+	normalized, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to encode snapshot: %v", err)
+	}
+	compareSnapshot(t, filepath.Join(dir, languageName, toolName, testName+".json"), string(normalized)+"\n")
+}
+
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+// applyAtPath replaces the values at path in doc with what apply returns
+func applyAtPath(doc any, path []string, apply func(any) any) any {
+	if len(path) == 0 {
+		return apply(doc)
+	}
+	key, rest := path[0], path[1:]
+	switch v := doc.(type) {
+	case map[string]any:
+		for field, value := range v {
+			if key == "*" || key == field {
+				v[field] = applyAtPath(value, rest, apply)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			if key == "*" || key == strconv.Itoa(i) {
+				v[i] = applyAtPath(value, rest, apply)
+			}
+		}
+	}
+	return doc
+}
+
+// normalizeStrings applies normalizers to every string in doc
+func normalizeStrings(doc any, normalizers []Normalizer) any {
+	switch v := doc.(type) {
+	case string:
+		for _, normalize := range normalizers {
+			v = normalize(v)
+		}
+		return v
+	case map[string]any:
+		for field, value := range v {
+			v[field] = normalizeStrings(value, normalizers)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = normalizeStrings(value, normalizers)
+		}
+	}
+	return doc
+}
+
+// sortArray orders the elements of an array by their JSON encoding
+func sortArray(doc any) any {
+	array, ok := doc.([]any)
+	if !ok {
+		return doc
+	}
+	keys := make(map[int]string, len(array))
+	for i, value := range array {
+		encoded, _ := json.Marshal(value)
+		keys[i] = string(encoded)
+	}
+	indexes := make([]int, len(array))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool { return keys[indexes[i]] < keys[indexes[j]] })
+	sorted := make([]any, len(array))
+	for i, index := range indexes {
+		sorted[i] = array[index]
+	}
+	return sorted
+}
+
+// compareSnapshot checks a normalized result against a snapshot file,
+// writing the file when it is missing or UPDATE_SNAPSHOTS=true. A mismatch
+// fails the test with a unified diff, also written next to the snapshot.
+func compareSnapshot(t testing.TB, snapshotFile, actualResult string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(snapshotFile), 0755); err != nil {
+		t.Fatalf("Failed to create snapshots directory: %v", err)
+	}
+
+	// Use a package-level flag to control snapshot updates
+	updateFlag := os.Getenv("UPDATE_SNAPSHOTS") == "true"
+
+	// If snapshot doesn't exist or update flag is set, write the snapshot
+	_, err := os.Stat(snapshotFile)
+	if os.IsNotExist(err) || updateFlag {
+		if err := os.WriteFile(snapshotFile, []byte(actualResult), 0644); err != nil {
+			t.Fatalf("Failed to write snapshot: %v", err)
+		}
+		if os.IsNotExist(err) {
+			t.Logf("Created new snapshot: %s", snapshotFile)
+		} else {
+			t.Logf("Updated snapshot: %s", snapshotFile)
+		}
+		return
+	}
+
+	// Read the expected result
+	expectedBytes, err := os.ReadFile(snapshotFile)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	expected := NormalizeLineEndings(string(expectedBytes))
+
+	// Compare the results
+	diff := utilities.UnifiedDiff(filepath.Base(snapshotFile), expected, actualResult)
+	if diff == "" {
+		return
+	}
+	t.Errorf("Result doesn't match snapshot %s (- expected, + actual):\n%s\nRun with UPDATE_SNAPSHOTS=true to accept the change.", snapshotFile, diff)
+
+	// Create a diff file for debugging
+	diffFile := snapshotFile + ".diff"
+	if err := os.WriteFile(diffFile, []byte(diff), 0644); err != nil {
+		t.Logf("Failed to write diff file: %v", err)
+	} else {
+		t.Logf("Wrote diff to: %s", diffFile)
+	}
+}
@@ -1,10 +1,11 @@
-package common
+package testharness
 
 import (
 	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -23,9 +24,21 @@ type LSPTestConfig struct {
 	Args             []string // Arguments
 	WorkspaceDir     string   // Template workspace directory
 	InitializeTimeMs int      // Time to wait after initialization in ms
+	// OutputDir holds a copy of the workspace and the logs of each test,
+	// kept after the test for inspection. It defaults to
+	// mcp-language-server-tests in the system temporary directory.
+	OutputDir string
+	// MaxParallel caps how many suites with this Name run their server at
+	// once in a test binary, when tests call t.Parallel. It defaults to
+	// GOMAXPROCS. The
+	// MAX_PARALLEL_SERVERS environment variable overrides it for every
+	// server, e.g. 1 to run the suites one at a time.
+	MaxParallel int
 }
 
-// TestSuite contains everything needed for running integration tests
+// TestSuite runs a language server on a fresh copy of a workspace for a
+// test. Client talks to the server, and methods such as Definition and
+// Hover call the tools the way an MCP client would.
 type TestSuite struct {
 	Config       LSPTestConfig
 	Client       *lsp.Client
@@ -37,12 +50,15 @@ type TestSuite struct {
 	initialized  bool
 	cleanupOnce  sync.Once
 	logFile      string
-	t            *testing.T
+	removeLog    func() error
+	release      func()
+	t            testing.TB
 	LanguageName string
 }
 
-// NewTestSuite creates a new test suite for the given language server
-func NewTestSuite(t *testing.T, config LSPTestConfig) *TestSuite {
+// NewTestSuite creates a new test suite for the given language server. t
+// may be a benchmark, so that benchmarks run on the same workspaces.
+func NewTestSuite(t testing.TB, config LSPTestConfig) *TestSuite {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &TestSuite{
 		Config:       config,
@@ -54,27 +70,39 @@ func NewTestSuite(t *testing.T, config LSPTestConfig) *TestSuite {
 	}
 }
 
+// testNameReplacer replaces the characters of subtest names that can't be
+// part of a file name
+var testNameReplacer = strings.NewReplacer("/", "_", " ", "_", `\`, "_", ":", "_", "*", "_", "?", "_", `"`, "_", "<", "_", ">", "_", "|", "_")
+
 // Setup initializes the test suite, copies the workspace, and starts the LSP
 func (ts *TestSuite) Setup() error {
 	if ts.initialized {
 		return fmt.Errorf("test suite already initialized")
 	}
 
-	// Create test output directory in the repo
+	// Without the server there is nothing to test. CI runs on platforms
+	// with only some servers installed set SKIP_MISSING_SERVERS=true.
+	if os.Getenv("SKIP_MISSING_SERVERS") == "true" {
+		if _, err := exec.LookPath(ts.Config.Command); err != nil {
+			ts.t.Skipf("Skipping, %s is not installed: %v", ts.Config.Command, err)
+		}
+	}
+
+	// Wait for a free slot, so that parallel tests don't start more servers
+	// than the machine can run
+	ts.release = acquireServerSlot(ts.Config)
+	// Release it even when Setup fails and the caller never calls Cleanup
+	ts.t.Cleanup(ts.Cleanup)
+
 	// Create a log file named after the test
 	testName := ts.t.Name()
-	// Clean the test name for use in a filename
-	testName = strings.ReplaceAll(testName, "/", "_")
-	testName = strings.ReplaceAll(testName, " ", "_")
+	// Clean the test name for use in a filename, on Windows too
+	testName = testNameReplacer.Replace(testName)
 
-	// Navigate to the repo root (assuming tests run from within the repo)
-	// The executable is in a temporary directory, so find the repo root based on the package path
-	pkgDir, err := filepath.Abs("../../../")
-	if err != nil {
-		return fmt.Errorf("failed to get absolute path to repo root: %w", err)
+	testOutputDir := ts.Config.OutputDir
+	if testOutputDir == "" {
+		testOutputDir = filepath.Join(os.TempDir(), "mcp-language-server-tests")
 	}
-
-	testOutputDir := filepath.Join(pkgDir, "test-output")
 	if err := os.MkdirAll(testOutputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create test-output directory: %w", err)
 	}
@@ -120,9 +148,11 @@ func (ts *TestSuite) Setup() error {
 	}
 
 	// Configure logging to write to the file
-	if err := logging.SetupFileLogging(ts.logFile); err != nil {
+	removeLog, err := logging.AddFileLogging(ts.logFile)
+	if err != nil {
 		return fmt.Errorf("failed to set up logging: %w", err)
 	}
+	ts.removeLog = removeLog
 
 	// Set log level based on environment variable or default to Info
 	logLevel := logging.LevelInfo
@@ -219,7 +249,14 @@ func (ts *TestSuite) Cleanup() {
 			}
 		}
 
-		// No need to close log files explicitly, logging package handles that
+		if ts.removeLog != nil {
+			if err := ts.removeLog(); err != nil {
+				ts.t.Logf("Failed to close log file: %v", err)
+			}
+		}
+		if ts.release != nil {
+			ts.release()
+		}
 
 		ts.t.Logf("Test artifacts are in: %s", ts.TempDir)
 		ts.t.Logf("Log file: %s", ts.logFile)
@@ -0,0 +1,44 @@
+package testharness
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// The methods below call the tools the MCP server exposes against the
+// suite's language server, with the options the server uses by default.
+// Paths are relative to the workspace, and lines and columns start at 1.
+
+// Definition returns the read_definition result for a symbol
+func (ts *TestSuite) Definition(ctx context.Context, symbolName string) (string, error) {
+	return tools.ReadDefinition(ctx, ts.Client, symbolName)
+}
+
+// References returns the find_references result for a symbol, ordered by
+// path so that it is stable across runs
+func (ts *TestSuite) References(ctx context.Context, symbolName string) (string, error) {
+	return tools.FindReferences(ctx, ts.Client, symbolName, false, tools.OrderPath, nil)
+}
+
+// Hover returns the hover result at a position
+func (ts *TestSuite) Hover(ctx context.Context, relPath string, line, column int) (string, error) {
+	return tools.GetHoverInfo(ctx, ts.Client, ts.path(relPath), line, column)
+}
+
+// Diagnostics returns the diagnostics result for a file, with two lines of
+// context and line numbers
+func (ts *TestSuite) Diagnostics(ctx context.Context, relPath string) (string, error) {
+	return tools.GetDiagnosticsForFile(ctx, ts.Client, ts.path(relPath), 2, true)
+}
+
+// Rename renames the symbol at a position and returns the rename_symbol
+// result. The workspace files are changed; read them with ReadFile.
+func (ts *TestSuite) Rename(ctx context.Context, relPath string, line, column int, newName string) (string, error) {
+	return tools.RenameSymbol(ctx, ts.Client, ts.path(relPath), line, column, newName)
+}
+
+func (ts *TestSuite) path(relPath string) string {
+	return filepath.Join(ts.WorkspaceDir, relPath)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/i18n"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// toolAlias is a former name of a tool. Prompts written for older versions
+// name tools this way, so calls to it are passed on to the tool it became,
+// with a deprecation notice, rather than failing.
+type toolAlias struct {
+	name string
+	tool string
+}
+
+// toolAliases lists the names tools had before they were renamed or merged
+// into others. An alias is kept for a few releases after the rename.
+var toolAliases = []toolAlias{
+	{name: "read_definition", tool: "definition"},
+	{name: "find_references", tool: "references"},
+	{name: "get_diagnostics", tool: "diagnostics"},
+}
+
+// deprecatedKey is the _meta field of an aliased call's result naming the
+// tool to call instead
+const deprecatedKey = "deprecated"
+
+// toolAliasModes are the values of --tool-aliases: whether aliases can be
+// called and whether they are listed
+var toolAliasModes = []string{"hidden", "listed", "off"}
+
+// aliasedCallKey marks the context of the call an alias passes on, so that
+// middleware that already saw the alias's call can skip it
+type aliasedCallKey struct{}
+
+// isAliasedCall reports whether a call was passed on by an alias
+func isAliasedCall(ctx context.Context) bool {
+	return ctx.Value(aliasedCallKey{}) != nil
+}
+
+// aliasWarnings records the aliases already logged, so that each is logged
+// once
+var aliasWarnings sync.Map
+
+func findToolAlias(name string) (toolAlias, bool) {
+	for _, alias := range toolAliases {
+		if alias.name == name {
+			return alias, true
+		}
+	}
+	return toolAlias{}, false
+}
+
+// registerToolAliases registers a handler for each alias that passes the
+// call on to the tool through the server, so that the tool's own gating,
+// middleware and argument handling apply as if it had been called by name
+func (s *mcpServer) registerToolAliases() {
+	if s.config.toolAliases == "off" {
+		return
+	}
+	for _, alias := range toolAliases {
+		aliasTool := mcp.NewTool(alias.name,
+			mcp.WithDescription(fmt.Sprintf("Deprecated: renamed to %s, which this calls.", alias.tool)),
+		)
+		s.mcpServer.AddTool(aliasTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return s.callAlias(ctx, alias, request), nil
+		})
+	}
+}
+
+// callAlias calls the tool an alias stands for and adds the deprecation
+// notice to its result
+func (s *mcpServer) callAlias(ctx context.Context, alias toolAlias, request mcp.CallToolRequest) *mcp.CallToolResult {
+	if _, logged := aliasWarnings.LoadOrStore(alias.name, true); !logged {
+		coreLogger.Warn("Tool %s is deprecated and was called, call %s instead", alias.name, alias.tool)
+	}
+	message, err := json.Marshal(mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId("alias:" + alias.name),
+		Request: mcp.Request{Method: string(mcp.MethodToolsCall)},
+		Params:  mcp.CallToolParams{Name: alias.tool, Arguments: request.Params.Arguments, Meta: request.Params.Meta},
+	})
+	if err != nil {
+		return codedResult(tools.InternalError, i18n.Sprintf("failed to call %s: %v", alias.tool, err))
+	}
+
+	var result mcp.CallToolResult
+	ctx = context.WithValue(ctx, aliasedCallKey{}, alias.name)
+	switch response := s.mcpServer.HandleMessage(ctx, message).(type) {
+	case mcp.JSONRPCResponse:
+		switch r := response.Result.(type) {
+		case mcp.CallToolResult:
+			result = r
+		case *mcp.CallToolResult:
+			result = *r
+		}
+	case mcp.JSONRPCError:
+		// The tool isn't registered, e.g. without a language server
+		if response.Error.Code == mcp.INVALID_PARAMS {
+			return codedResult(tools.UnsupportedCapability, i18n.Sprintf("%s was renamed to %s, which is unavailable: %s", alias.name, alias.tool, response.Error.Message))
+		}
+		return codedResult(tools.InternalError, i18n.Sprintf("%s failed: %s", alias.tool, response.Error.Message))
+	}
+
+	notice := i18n.Sprintf("Note: the %s tool is deprecated and will be removed. Call %s instead, with the same arguments.", alias.name, alias.tool)
+	result.Content = append(result.Content, mcp.NewTextContent(notice))
+	if result.Meta == nil {
+		result.Meta = map[string]any{}
+	}
+	result.Meta[deprecatedKey] = map[string]any{"tool": alias.name, "replacement": alias.tool}
+	return &result
+}
+
+// listToolAliases removes the aliases from tools/list or, with
+// --tool-aliases listed, lists each alias of a listed tool as a copy of it
+// marked deprecated. It runs after the other filters, so aliases of tools
+// that are hidden stay hidden.
+func (s *mcpServer) listToolAliases(ctx context.Context, listed []mcp.Tool) []mcp.Tool {
+	result := make([]mcp.Tool, 0, len(listed))
+	current := make(map[string]mcp.Tool, len(listed))
+	for _, tool := range listed {
+		if _, ok := findToolAlias(tool.Name); ok {
+			continue
+		}
+		result = append(result, tool)
+		current[tool.Name] = tool
+	}
+	if s.config.toolAliases != "listed" {
+		return result
+	}
+	for _, alias := range toolAliases {
+		tool, ok := current[alias.tool]
+		if !ok {
+			continue
+		}
+		tool.Name = alias.name
+		tool.Description = i18n.Sprintf("Deprecated: use %s, which this is an old name of. %s", alias.tool, tool.Description)
+		result = append(result, tool)
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAliasServer is a server with the aliases of --tool-aliases mode and a
+// definition tool that echoes its arguments
+func newAliasServer(t *testing.T, mode string) *mcpServer {
+	t.Helper()
+	s := &mcpServer{config: config{toolAliases: mode}}
+	s.mcpServer = server.NewMCPServer("test", "1", server.WithToolCapabilities(true), server.WithToolFilter(s.listToolAliases))
+	s.mcpServer.AddTool(mcp.NewTool("definition", mcp.WithDescription("Find a definition.")),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result := mcp.NewToolResultText(request.GetString("symbolName", ""))
+			result.Meta = map[string]any{"aliased": isAliasedCall(ctx)}
+			return result, nil
+		})
+	s.registerToolAliases()
+	return s
+}
+
+func handleTest(t *testing.T, s *mcpServer, method string, params any) mcp.JSONRPCMessage {
+	t.Helper()
+	message, err := json.Marshal(mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(1),
+		Request: mcp.Request{Method: method},
+		Params:  params,
+	})
+	require.NoError(t, err)
+	return s.mcpServer.HandleMessage(context.Background(), message)
+}
+
+func callTest(t *testing.T, s *mcpServer, name string, arguments map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	response, ok := handleTest(t, s, string(mcp.MethodToolsCall), mcp.CallToolParams{Name: name, Arguments: arguments}).(mcp.JSONRPCResponse)
+	require.True(t, ok, "%s wasn't called", name)
+	switch result := response.Result.(type) {
+	case mcp.CallToolResult:
+		return &result
+	case *mcp.CallToolResult:
+		return result
+	}
+	t.Fatalf("Unexpected result %T", response.Result)
+	return nil
+}
+
+func listTest(t *testing.T, s *mcpServer) map[string]mcp.Tool {
+	t.Helper()
+	response, ok := handleTest(t, s, string(mcp.MethodToolsList), nil).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	var list mcp.ListToolsResult
+	switch result := response.Result.(type) {
+	case mcp.ListToolsResult:
+		list = result
+	case *mcp.ListToolsResult:
+		list = *result
+	default:
+		t.Fatalf("Unexpected result %T", response.Result)
+	}
+	listed := make(map[string]mcp.Tool)
+	for _, tool := range list.Tools {
+		listed[tool.Name] = tool
+	}
+	return listed
+}
+
+func resultText(result *mcp.CallToolResult) []string {
+	var texts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return texts
+}
+
+func TestCallAlias(t *testing.T) {
+	s := newAliasServer(t, "hidden")
+
+	// The tool's result comes back with the notice and the name to use
+	result := callTest(t, s, "read_definition", map[string]any{"symbolName": "main"})
+	require.False(t, result.IsError)
+	texts := resultText(result)
+	require.Len(t, texts, 2)
+	assert.Equal(t, "main", texts[0])
+	assert.Contains(t, texts[1], "read_definition tool is deprecated")
+	assert.Contains(t, texts[1], "Call definition instead")
+	assert.Equal(t, map[string]any{"tool": "read_definition", "replacement": "definition"}, result.Meta[deprecatedKey])
+	assert.Equal(t, true, result.Meta["aliased"])
+
+	// The tool called by name has neither
+	result = callTest(t, s, "definition", map[string]any{"symbolName": "main"})
+	assert.Equal(t, []string{"main"}, resultText(result))
+	assert.NotContains(t, result.Meta, deprecatedKey)
+}
+
+func TestCallAliasUnregistered(t *testing.T) {
+	s := newAliasServer(t, "hidden")
+
+	// Without a references tool the server answers INVALID_PARAMS, which
+	// the alias reports as a tool error
+	result := callTest(t, s, "find_references", map[string]any{"symbolName": "main"})
+	assert.True(t, result.IsError)
+	assert.Equal(t, string(tools.UnsupportedCapability), result.Meta[errorCodeKey])
+	require.NotEmpty(t, resultText(result))
+	assert.Contains(t, resultText(result)[0], "find_references was renamed to references, which is unavailable")
+}
+
+func TestListToolAliases(t *testing.T) {
+	t.Run("hidden", func(t *testing.T) {
+		listed := listTest(t, newAliasServer(t, "hidden"))
+		assert.Contains(t, listed, "definition")
+		assert.NotContains(t, listed, "read_definition")
+		assert.NotContains(t, listed, "find_references")
+	})
+
+	t.Run("listed", func(t *testing.T) {
+		listed := listTest(t, newAliasServer(t, "listed"))
+		require.Contains(t, listed, "read_definition")
+		assert.Equal(t, "Deprecated: use definition, which this is an old name of. Find a definition.", listed["read_definition"].Description)
+		// Aliases of tools that aren't listed aren't either
+		assert.NotContains(t, listed, "find_references")
+	})
+
+	t.Run("off", func(t *testing.T) {
+		s := newAliasServer(t, "off")
+		assert.NotContains(t, listTest(t, s), "read_definition")
+		response, ok := handleTest(t, s, string(mcp.MethodToolsCall), mcp.CallToolParams{Name: "read_definition"}).(mcp.JSONRPCError)
+		require.True(t, ok, "alias was called")
+		assert.Equal(t, mcp.INVALID_PARAMS, response.Error.Code)
+	})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/isaacphi/mcp-language-server/internal/i18n"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// errorCodeKey is the _meta field of a failed tool result holding its code
+const errorCodeKey = "errorCode"
+
+// toolError reports a failed tool call. The error's code starts the text, so
+// models see it, and is set in _meta for clients that branch on it. The
+// message is translated into the configured locale.
+func toolError(message string, err error) *mcp.CallToolResult {
+	return codedResult(tools.ErrorCodeOf(err), fmt.Sprintf("%s: %v", i18n.Text(message), err))
+}
+
+// argumentError reports an argument that is missing or has the wrong type
+func argumentError(err error) *mcp.CallToolResult {
+	return codedResult(tools.InvalidArgument, err.Error())
+}
+
+func codedResult(code tools.ErrorCode, text string) *mcp.CallToolResult {
+	result := mcp.NewToolResultError(fmt.Sprintf("[%s] %s", code, text))
+	result.Meta = map[string]any{errorCodeKey: string(code)}
+	return result
+}
+
+// errorCodeMiddleware makes sure every failed tool result has a code, so
+// that clients can rely on one being there
+func errorCodeMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		if result != nil && result.IsError {
+			if _, ok := result.Meta[errorCodeKey]; !ok {
+				if result.Meta == nil {
+					result.Meta = map[string]any{}
+				}
+				result.Meta[errorCodeKey] = string(tools.InternalError)
+			}
+		}
+		return result, err
+	}
+}
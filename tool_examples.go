@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+
+	"github.com/isaacphi/mcp-language-server/internal/i18n"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// toolExamplesKey is the _meta field of the tools/list result that holds the
+// examples of the listed tools, keyed by tool name
+const toolExamplesKey = "examples"
+
+// toolExample is a call of a tool and what it returned, so that models can
+// see how to call a tool and what they get back before choosing it
+type toolExample struct {
+	// Title says what the call is for
+	Title     string         `json:"title"`
+	Arguments map[string]any `json:"arguments"`
+	Output    string         `json:"output"`
+}
+
+// toolExamples are calls made in a small Go module at /home/me/shop, with a
+// store package that keeps orders:
+//
+//	store/store.go  ErrNotFound, Store, Order and (*Store).Get
+//	main.go         main, which gets an order from a Store
+var toolExamples = map[string][]toolExample{
+	"definition": {{
+		Title:     "Read a method by its type and name",
+		Arguments: map[string]any{"symbolName": "Store.Get"},
+		Output: `---
+
+Symbol: Store.Get
+File: /home/me/shop/store/store.go
+Kind: Method
+Container Name: example.com/shop/store
+Range: L20:C1 - L26:C2
+
+20|func (s *Store) Get(id string) (Order, error) {
+21|	order, ok := s.orders[id]
+22|	if !ok {
+23|		return Order{}, ErrNotFound
+24|	}
+25|	return order, nil
+26|}
+`,
+	}},
+	"references": {{
+		Title:     "Find the code that calls a method before changing it",
+		Arguments: map[string]any{"symbolName": "Store.Get"},
+		Output: `---
+
+/home/me/shop/main.go
+References in File: 1
+At: L11:C18
+
+ 9|func main() {
+10|	var s store.Store
+11|	order, err := s.Get("42")
+12|	if err != nil {
+13|		fmt.Println("error:", err)
+14|		return
+15|	}
+16|	fmt.Println(order.Total)
+`,
+	}},
+	"hover": {{
+		Title:     "Get the signature and documentation of a call",
+		Arguments: map[string]any{"filePath": "/home/me/shop/main.go", "line": 11, "column": 18},
+		Output:    "```go\nfunc (s *store.Store) Get(id string) (store.Order, error)\n```\n\n---\n\nGet returns the order with an ID\n",
+	}},
+	"diagnostics": {{
+		Title:     "Check a file for errors after editing it",
+		Arguments: map[string]any{"filePath": "/home/me/shop/main.go"},
+		Output: `/home/me/shop/main.go
+Diagnostics in File: 1
+ERROR at L16:C20: order.Totl undefined (type store.Order has no field or method Totl, but does have field Total) (Source: compiler, Code: MissingFieldOrMethod)
+
+11|	order, err := s.Get("42")
+12|	if err != nil {
+13|		fmt.Println("error:", err)
+14|		return
+15|	}
+16|	fmt.Println(order.Totl)
+17|}
+`,
+	}},
+	"rename_symbol": {{
+		Title:     "Rename a type everywhere it is used",
+		Arguments: map[string]any{"filePath": "/home/me/shop/store/store.go", "line": 14, "column": 6, "newName": "Purchase"},
+		Output: `Successfully renamed symbol to 'Purchase'.
+Updated 4 occurrences across 1 files:
+/home/me/shop/store/store.go: L10:C20, L14:C6, L20:C33, L23:C10
+`,
+	}},
+	"callers": {{
+		Title:     "Find the functions that call a method",
+		Arguments: map[string]any{"symbolName": "Store.Get"},
+		Output: `---
+Name: Get
+Detail: example.com/shop/store • store.go
+File: /home/me/shop/store/store.go
+Range: L20:C17 - L20:C20
+- Called By: main
+  Detail: example.com/shop • main.go
+  File: /home/me/shop/main.go
+  Range: L9:C6 - L9:C10
+`,
+	}},
+	"outline": {{
+		Title:     "List the declarations of a file without reading it",
+		Arguments: map[string]any{"filePath": "/home/me/shop/store/store.go"},
+		Output: `/home/me/shop/store/store.go
+Symbols in File: 7
+
+Variable ErrNotFound (L6-L6)
+Struct Store (L9-L11)
+  Field orders (L10-L10)
+Struct Order (L14-L17)
+  Field ID (L15-L15)
+  Field Total (L16-L16)
+Method (*Store).Get (L20-L26)
+`,
+	}},
+	"search": {{
+		Title:     "Find the lines that mention a name",
+		Arguments: map[string]any{"pattern": "ErrNotFound"},
+		Output: `---
+
+/home/me/shop/store/store.go
+Matches in File: 3
+At: L5:C4, L6:C5, L23:C19
+
+5|// ErrNotFound is returned for unknown IDs
+6|var ErrNotFound = errors.New("not found")
+23|		return Order{}, ErrNotFound
+`,
+	}},
+}
+
+// addToolExamples adds the examples of the listed tools to the _meta of
+// tools/list. Tools that are hidden have none listed.
+func addToolExamples(ctx context.Context, id any, request *mcp.ListToolsRequest, result *mcp.ListToolsResult) {
+	examples := make(map[string][]toolExample)
+	for _, tool := range result.Tools {
+		for _, example := range toolExamples[tool.Name] {
+			example.Title = i18n.Text(example.Title)
+			examples[tool.Name] = append(examples[tool.Name], example)
+		}
+	}
+	if len(examples) == 0 {
+		return
+	}
+	if result.Meta == nil {
+		result.Meta = map[string]any{}
+	}
+	result.Meta[toolExamplesKey] = examples
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/isaacphi/mcp-language-server/internal/tools"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -17,20 +18,26 @@ func (s *mcpServer) registerTools() error {
 			mcp.Required(),
 			mcp.Description("The name of the symbol whose definition you want to find (e.g. 'mypackage.MyFunction', 'MyType.MyMethod')"),
 		),
+		mcp.WithBoolean("blame",
+			mcp.Description("If true, annotates each definition with who last changed it and when, using git blame"),
+			mcp.DefaultBool(false),
+		),
 	)
 
 	s.mcpServer.AddTool(readDefinitionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		symbolName, err := request.RequireString("symbolName")
 		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+			return argumentError(err), nil
 		}
 
+		blame := request.GetBool("blame", false)
+
 		coreLogger.Debug("Executing definition for symbol: %s", symbolName)
-		text, err := tools.ReadDefinition(s.ctx, s.lspClient, symbolName)
+		text, err := tools.ReadDefinitionWithFallback(ctx, s.lspClient, s.config.workspaceDir, symbolName, blame)
 		if err != nil {
 			coreLogger.Error("Failed to get definition: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to get definition: %v", err)), nil
+			return toolError("failed to get definition", err), nil
 		}
 		return mcp.NewToolResultText(text), nil
 	})
@@ -41,30 +48,101 @@ func (s *mcpServer) registerTools() error {
 			mcp.Required(),
 			mcp.Description("The name of the symbol to search for (e.g. 'mypackage.MyFunction', 'MyType')"),
 		),
+		mcp.WithBoolean("blame",
+			mcp.Description("If true, annotates each referencing line with who last changed it and when, using git blame"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithString("order",
+			mcp.Description("'relevance' lists non-test files, files in the definition's package and call sites before the rest; 'path' sorts files by path. Defaults to 'relevance'."),
+			mcp.Enum(tools.OrderRelevance, tools.OrderPath),
+		),
 	)
 
 	s.mcpServer.AddTool(findReferencesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		symbolName, err := request.RequireString("symbolName")
 		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+			return argumentError(err), nil
 		}
 
-		coreLogger.Debug("Executing references for symbol: %s", symbolName)
-		text, err := tools.FindReferences(s.ctx, s.lspClient, symbolName)
+		blame := request.GetBool("blame", false)
+		order := request.GetString("order", tools.OrderRelevance)
+
+		coreLogger.Debug("Executing references for symbol: %s order: %s", symbolName, order)
+		text, err := tools.FindReferences(ctx, s.lspClient, symbolName, blame, order, s.resultStream(ctx, request))
 		if err != nil {
 			coreLogger.Error("Failed to find references: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to find references: %v", err)), nil
+			return toolError("failed to find references", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	usageExamplesTool := mcp.NewTool("usage_examples",
+		mcp.WithDescription("Show short, varied examples of how a symbol is used, taken from its references: uses in tests first, then uses in different packages and files. Use this to learn how to call an unfamiliar function or type."),
+		mcp.WithString("symbolName",
+			mcp.Required(),
+			mcp.Description("The name of the symbol to find examples of (e.g. 'mypackage.MyFunction', 'MyType')"),
+		),
+		mcp.WithNumber("count",
+			mcp.Description("How many examples to show"),
+			mcp.DefaultNumber(5),
+			mcp.Min(1),
+			mcp.Max(50),
+		),
+	)
+
+	s.mcpServer.AddTool(usageExamplesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		symbolName, err := request.RequireString("symbolName")
+		if err != nil {
+			return argumentError(err), nil
+		}
+		count := request.GetInt("count", 5)
+
+		coreLogger.Debug("Executing usage_examples for symbol: %s count: %d", symbolName, count)
+		text, err := tools.UsageExamples(ctx, s.lspClient, symbolName, count)
+		if err != nil {
+			coreLogger.Error("Failed to find usage examples: %v", err)
+			return toolError("failed to find usage examples", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	callSitesTool := mcp.NewTool("call_sites",
+		mcp.WithDescription("List every call of a function with its argument expressions, each labeled with the parameter it is passed for, and the references that aren't calls. Use this before changing a function's signature to update its callers."),
+		mcp.WithString("symbolName",
+			mcp.Required(),
+			mcp.Description("The name of the function or method (e.g. 'mypackage.MyFunction', 'MyType.MyMethod')"),
+		),
+	)
+
+	s.mcpServer.AddTool(callSitesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		symbolName, err := request.RequireString("symbolName")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing call_sites for symbol: %s", symbolName)
+		text, err := tools.FindCallSites(ctx, s.lspClient, symbolName)
+		if err != nil {
+			coreLogger.Error("Failed to find call sites: %v", err)
+			return toolError("failed to find call sites", err), nil
 		}
 		return mcp.NewToolResultText(text), nil
 	})
 
 	getDiagnosticsTool := mcp.NewTool("diagnostics",
-		mcp.WithDescription("Get diagnostic information for a specific file from the language server."),
+		mcp.WithDescription("Get diagnostic information for a specific file, or for unsaved content, from the language server."),
 		mcp.WithString("filePath",
-			mcp.Required(),
 			mcp.Description("The path to the file to get diagnostics for"),
 		),
+		mcp.WithString("content",
+			mcp.Description("Analyze this text instead of the file on disk, e.g. code that hasn't been saved yet. Without filePath it is opened as an untitled document in the workspace root"),
+		),
+		mcp.WithString("languageId",
+			mcp.Description("LSP language ID of the document (e.g. 'go', 'python', 'typescriptreact'). Required for content without filePath, otherwise overrides the language detected from the extension"),
+		),
 		mcp.WithBoolean("contextLines",
 			mcp.Description("Lines to include around each diagnostic."),
 			mcp.DefaultBool(false),
@@ -77,19 +155,55 @@ func (s *mcpServer) registerTools() error {
 
 	s.mcpServer.AddTool(getDiagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
-		filePath, err := request.RequireString("filePath")
-		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
-		}
+		filePath := request.GetString("filePath", "")
+		content := request.GetString("content", "")
+		languageID := request.GetString("languageId", "")
 
 		contextLines := request.GetInt("contextLines", 5)
 		showLineNumbers := request.GetBool("showLineNumbers", true)
 
 		coreLogger.Debug("Executing diagnostics for file: %s", filePath)
-		text, err := tools.GetDiagnosticsForFile(s.ctx, s.lspClient, filePath, contextLines, showLineNumbers)
+		text, err := tools.WithDocument(ctx, s.lspClient, s.config.workspaceDir, filePath, content, languageID, func(filePath string) (string, error) {
+			return tools.GetDiagnosticsForFile(ctx, s.lspClient, filePath, contextLines, showLineNumbers)
+		})
 		if err != nil {
 			coreLogger.Error("Failed to get diagnostics: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to get diagnostics: %v", err)), nil
+			return toolError("failed to get diagnostics", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	workspaceDiagnosticsTool := mcp.NewTool("workspace_diagnostics",
+		mcp.WithDescription("List the diagnostics the language server has reported for all files in the workspace, merged with errors from the last run_build, such as link errors that never appear through the language server."),
+	)
+
+	s.mcpServer.AddTool(workspaceDiagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		coreLogger.Debug("Executing workspace_diagnostics")
+		text, err := tools.GetWorkspaceDiagnostics(s.lspClient, s.resultStream(ctx, request))
+		if err != nil {
+			coreLogger.Error("Failed to get workspace diagnostics: %v", err)
+			return toolError("failed to get workspace diagnostics", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	changedFilesDiagnosticsTool := mcp.NewTool("changed_files_diagnostics",
+		mcp.WithDescription("Get diagnostics only for the files that differ from a git ref in the working tree, including new files. Diagnostics on the changed lines are listed first, so problems introduced by the current work stand out."),
+		mcp.WithString("baseRef",
+			mcp.Description("The git ref to compare against, such as a branch, tag or commit"),
+			mcp.DefaultString("HEAD"),
+		),
+	)
+
+	s.mcpServer.AddTool(changedFilesDiagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		baseRef := request.GetString("baseRef", "HEAD")
+
+		coreLogger.Debug("Executing changed_files_diagnostics against: %s", baseRef)
+		text, err := tools.GetChangedFilesDiagnostics(ctx, s.lspClient, s.config.workspaceDir, baseRef)
+		if err != nil {
+			coreLogger.Error("Failed to get diagnostics for changed files: %v", err)
+			return toolError("failed to get diagnostics for changed files", err), nil
 		}
 		return mcp.NewToolResultText(text), nil
 	})
@@ -112,10 +226,10 @@ func (s *mcpServer) registerTools() error {
 	// 	}
 	//
 	// 	coreLogger.Debug("Executing get_codelens for file: %s", filePath)
-	// 	text, err := tools.GetCodeLens(s.ctx, s.lspClient, filePath)
+	// 	text, err := tools.GetCodeLens(ctx, s.lspClient, filePath)
 	// 	if err != nil {
 	// 		coreLogger.Error("Failed to get code lens: %v", err)
-	// 		return mcp.NewToolResultError(fmt.Sprintf("failed to get code lens: %v", err)), nil
+	// 		return toolError("failed to get code lens", err), nil
 	// 	}
 	// 	return mcp.NewToolResultText(text), nil
 	// })
@@ -151,10 +265,10 @@ func (s *mcpServer) registerTools() error {
 	// 	}
 	//
 	// 	coreLogger.Debug("Executing execute_codelens for file: %s index: %d", filePath, index)
-	// 	text, err := tools.ExecuteCodeLens(s.ctx, s.lspClient, filePath, index)
+	// 	text, err := tools.ExecuteCodeLens(ctx, s.lspClient, filePath, index)
 	// 	if err != nil {
 	// 		coreLogger.Error("Failed to execute code lens: %v", err)
-	// 		return mcp.NewToolResultError(fmt.Sprintf("failed to execute code lens: %v", err)), nil
+	// 		return toolError("failed to execute code lens", err), nil
 	// 	}
 	// 	return mcp.NewToolResultText(text), nil
 	// })
@@ -162,7 +276,6 @@ func (s *mcpServer) registerTools() error {
 	hoverTool := mcp.NewTool("hover",
 		mcp.WithDescription("Get hover information (type, documentation) for a symbol at the specified position."),
 		mcp.WithString("filePath",
-			mcp.Required(),
 			mcp.Description("The path to the file to get hover information for"),
 		),
 		mcp.WithNumber("line",
@@ -173,30 +286,126 @@ func (s *mcpServer) registerTools() error {
 			mcp.Required(),
 			mcp.Description("The column number where the hover is requested (1-indexed)"),
 		),
+		mcp.WithString("content",
+			mcp.Description("Analyze this text instead of the file on disk, e.g. code that hasn't been saved yet. Without filePath it is opened as an untitled document in the workspace root"),
+		),
+		mcp.WithString("languageId",
+			mcp.Description("LSP language ID of the document (e.g. 'go', 'python', 'typescriptreact'). Required for content without filePath, otherwise overrides the language detected from the extension"),
+		),
 	)
 
 	s.mcpServer.AddTool(hoverTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
-		filePath, err := request.RequireString("filePath")
-		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
-		}
+		filePath := request.GetString("filePath", "")
+		content := request.GetString("content", "")
+		languageID := request.GetString("languageId", "")
 
 		line, err := request.RequireInt("line")
 		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+			return argumentError(err), nil
 		}
 
 		column, err := request.RequireInt("column")
 		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+			return argumentError(err), nil
 		}
 
 		coreLogger.Debug("Executing hover for file: %s line: %d column: %d", filePath, line, column)
-		text, err := tools.GetHoverInfo(s.ctx, s.lspClient, filePath, line, column)
+		text, err := tools.WithDocument(ctx, s.lspClient, s.config.workspaceDir, filePath, content, languageID, func(filePath string) (string, error) {
+			return tools.GetHoverInfo(ctx, s.lspClient, filePath, line, column)
+		})
 		if err != nil {
 			coreLogger.Error("Failed to get hover information: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to get hover information: %v", err)), nil
+			return toolError("failed to get hover information", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	hoverRangeTool := mcp.NewTool("hover_range",
+		mcp.WithDescription("Get the type of an expression spanning a range, such as a call chain or an operation, rather than of a single identifier. Servers that support hovering over ranges, like rust-analyzer, answer for the range; otherwise the names in the expression are hovered over, the outermost first, and the result says which one answered."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the expression"),
+		),
+		mcp.WithNumber("startLine",
+			mcp.Required(),
+			mcp.Description("The line where the expression starts (1-indexed)"),
+		),
+		mcp.WithNumber("startColumn",
+			mcp.Required(),
+			mcp.Description("The column where the expression starts (1-indexed)"),
+		),
+		mcp.WithNumber("endLine",
+			mcp.Required(),
+			mcp.Description("The line where the expression ends (1-indexed, inclusive)"),
+		),
+		mcp.WithNumber("endColumn",
+			mcp.Required(),
+			mcp.Description("The column of the expression's last character (1-indexed, inclusive)"),
+		),
+	)
+
+	s.mcpServer.AddTool(hoverRangeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		startLine, err := request.RequireInt("startLine")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		startColumn, err := request.RequireInt("startColumn")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		endLine, err := request.RequireInt("endLine")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		endColumn, err := request.RequireInt("endColumn")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing hover_range for file: %s L%d:C%d - L%d:C%d", filePath, startLine, startColumn, endLine, endColumn)
+		text, err := tools.GetRangeHover(ctx, s.lspClient, filePath, startLine, startColumn, endLine, endColumn)
+		if err != nil {
+			coreLogger.Error("Failed to get hover information for range: %v", err)
+			return toolError("failed to get hover information for range", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	annotateFileTool := mcp.NewTool("annotate_file",
+		mcp.WithDescription("Return a file with the type and a one-line documentation summary of its identifiers shown below the lines they are on, gathered with batched hover requests. Use it to understand unfamiliar code in one call instead of hovering name by name."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to annotate"),
+		),
+		mcp.WithString("mode",
+			mcp.Description("'symbols' annotates the file's declarations; 'all_identifiers' annotates every distinct name where it first appears. Defaults to 'symbols'."),
+			mcp.Enum(tools.AnnotateSymbols, tools.AnnotateAllIdentifiers),
+		),
+	)
+
+	s.mcpServer.AddTool(annotateFileTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+		mode := request.GetString("mode", tools.AnnotateSymbols)
+
+		coreLogger.Debug("Executing annotate_file for file: %s mode: %s", filePath, mode)
+		text, err := tools.AnnotateFile(ctx, s.lspClient, filePath, mode)
+		if err != nil {
+			coreLogger.Error("Failed to annotate file: %v", err)
+			return toolError("failed to annotate file", err), nil
 		}
 		return mcp.NewToolResultText(text), nil
 	})
@@ -225,29 +434,29 @@ func (s *mcpServer) registerTools() error {
 		// Extract arguments
 		filePath, err := request.RequireString("filePath")
 		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+			return argumentError(err), nil
 		}
 
 		newName, err := request.RequireString("newName")
 		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+			return argumentError(err), nil
 		}
 
 		line, err := request.RequireInt("line")
 		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+			return argumentError(err), nil
 		}
 
 		column, err := request.RequireInt("column")
 		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+			return argumentError(err), nil
 		}
 
 		coreLogger.Debug("Executing rename_symbol for file: %s line: %d column: %d newName: %s", filePath, line, column, newName)
-		text, err := tools.RenameSymbol(s.ctx, s.lspClient, filePath, line, column, newName)
+		text, err := tools.RenameSymbol(ctx, s.lspClient, filePath, line, column, newName)
 		if err != nil {
 			coreLogger.Error("Failed to rename symbol: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to rename symbol: %v", err)), nil
+			return toolError("failed to rename symbol", err), nil
 		}
 		return mcp.NewToolResultText(text), nil
 	})
@@ -263,14 +472,14 @@ func (s *mcpServer) registerTools() error {
 		// Extract arguments
 		symbolName, err := request.RequireString("symbolName")
 		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+			return argumentError(err), nil
 		}
 
 		coreLogger.Debug("Executing callers for symbol: %s", symbolName)
-		text, err := tools.GetCallers(s.ctx, s.lspClient, symbolName, 1)
+		text, err := tools.GetCallers(ctx, s.lspClient, symbolName, 1)
 		if err != nil {
 			coreLogger.Error("Failed to find callers: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to find callers: %v", err)), nil
+			return toolError("failed to find callers", err), nil
 		}
 		return mcp.NewToolResultText(text), nil
 	})
@@ -286,60 +495,941 @@ func (s *mcpServer) registerTools() error {
 		// Extract arguments
 		symbolName, err := request.RequireString("symbolName")
 		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+			return argumentError(err), nil
 		}
 
 		coreLogger.Debug("Executing callees for symbol: %s", symbolName)
-		text, err := tools.GetCallees(s.ctx, s.lspClient, symbolName, 1)
+		text, err := tools.GetCallees(ctx, s.lspClient, symbolName, 1)
 		if err != nil {
 			coreLogger.Error("Failed to find callees: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to find callees: %v", err)), nil
+			return toolError("failed to find callees", err), nil
 		}
 		return mcp.NewToolResultText(text), nil
 	})
 
-	contentTool := mcp.NewTool("content",
-		mcp.WithDescription("Read the source code definition of a symbol (function, type, constant, etc.) at the specified location."),
+	exportCallGraphTool := mcp.NewTool("export_call_graph",
+		mcp.WithDescription("Export the graph of calls reachable from a root function as Graphviz DOT or JSON. Useful for seeing the blast radius of a change before refactoring."),
+		mcp.WithString("rootSymbol",
+			mcp.Required(),
+			mcp.Description("The name of the function or method to start from (e.g. 'mypackage.MyFunction', 'MyType.MyMethod')"),
+		),
+		mcp.WithNumber("depth",
+			mcp.Description("How many levels of calls to follow"),
+			mcp.DefaultNumber(3),
+			mcp.Min(1),
+			mcp.Max(10),
+		),
+		mcp.WithString("format",
+			mcp.Description("Output format"),
+			mcp.Enum("json", "dot"),
+			mcp.DefaultString("json"),
+		),
+	)
+	s.mcpServer.AddTool(exportCallGraphTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		rootSymbol, err := request.RequireString("rootSymbol")
+		if err != nil {
+			return argumentError(err), nil
+		}
+		depth := request.GetInt("depth", 3)
+		format := request.GetString("format", "json")
+
+		coreLogger.Debug("Executing export_call_graph for symbol: %s depth: %d format: %s", rootSymbol, depth, format)
+		text, err := tools.ExportCallGraph(ctx, s.lspClient, rootSymbol, depth, format)
+		if err != nil {
+			coreLogger.Error("Failed to export call graph: %v", err)
+			return toolError("failed to export call graph", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	importGraphTool := mcp.NewTool("import_graph",
+		mcp.WithDescription("Compute the package import graph of the workspace by parsing Go, Python, JavaScript/TypeScript, C/C++ and Rust imports. Returns a summary and the graph as JSON. Set package to see what a single package imports and what imports it."),
+		mcp.WithString("package",
+			mcp.Description("Optional package to report on (e.g. 'internal/lsp', 'myapp.models', 'react'). External dependencies are accepted too."),
+		),
+	)
+	s.mcpServer.AddTool(importGraphTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		packageName := request.GetString("package", "")
+
+		coreLogger.Debug("Executing import_graph for package: %s", packageName)
+		text, err := tools.GetImportGraph(ctx, s.config.workspaceDir, packageName)
+		if err != nil {
+			coreLogger.Error("Failed to get import graph: %v", err)
+			return toolError("failed to get import graph", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	workspaceStatsTool := mcp.NewTool("workspace_stats",
+		mcp.WithDescription("Summarize the workspace cheaply: files and lines per language, top-level declarations per directory, the largest files and functions, and diagnostic counts. Declarations are found by pattern matching, so this is fast even on large workspaces. Call it at the start of a task to get oriented."),
+	)
+	s.mcpServer.AddTool(workspaceStatsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		coreLogger.Debug("Executing workspace_stats")
+		text, err := tools.GetWorkspaceStats(ctx, s.lspClient, s.config.workspaceDir)
+		if err != nil {
+			coreLogger.Error("Failed to get workspace statistics: %v", err)
+			return toolError("failed to get workspace statistics", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	summarizePackageTool := mcp.NewTool("summarize_package",
+		mcp.WithDescription("List the exported declarations of a package (a directory) or a single file with one-line signatures and the first sentence of their documentation. Test files are left out. Use it to learn a package's API without reading its source."),
+		mcp.WithString("path",
+			mcp.Description("Directory or file to summarize, absolute or relative to the workspace. Defaults to the workspace root."),
+		),
+	)
+	s.mcpServer.AddTool(summarizePackageTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		path := request.GetString("path", "")
+
+		coreLogger.Debug("Executing summarize_package for path: %s", path)
+		text, err := tools.SummarizePackage(ctx, s.lspClient, s.config.workspaceDir, path)
+		if err != nil {
+			coreLogger.Error("Failed to summarize package: %v", err)
+			return toolError("failed to summarize package", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	docCoverageTool := mcp.NewTool("doc_coverage",
+		mcp.WithDescription("Report which exported declarations lack doc comments in a directory, searched recursively, or a file, with their lines and the share that is documented. Test files are left out. Use it to find what to document."),
+		mcp.WithString("path",
+			mcp.Description("Directory or file to check, absolute or relative to the workspace. Defaults to the workspace root."),
+		),
+	)
+	s.mcpServer.AddTool(docCoverageTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		path := request.GetString("path", "")
+
+		coreLogger.Debug("Executing doc_coverage for path: %s", path)
+		text, err := tools.DocCoverage(ctx, s.lspClient, s.config.workspaceDir, path)
+		if err != nil {
+			coreLogger.Error("Failed to report documentation coverage: %v", err)
+			return toolError("failed to report documentation coverage", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	findTodosTool := mcp.NewTool("find_todos",
+		mcp.WithDescription("List the TODO, FIXME, HACK and XXX comments in a directory, searched recursively, or a file, grouped by file, with the symbol each is in and how old it is and who wrote it from git blame. Use it to find outstanding maintenance work."),
+		mcp.WithString("path",
+			mcp.Description("Directory or file to search, absolute or relative to the workspace. Defaults to the workspace root."),
+		),
+		mcp.WithString("markers",
+			mcp.Description("Comma-separated markers to look for instead of the configured ones, e.g. 'TODO,FIXME,NOTE(perf)'. Markers are matched case-sensitively as whole words"),
+		),
+		mcp.WithNumber("maxResults",
+			mcp.Description("The most markers to describe"),
+			mcp.DefaultNumber(200),
+			mcp.Min(1),
+		),
+	)
+	s.mcpServer.AddTool(findTodosTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		path := request.GetString("path", "")
+		markers := s.liveConfig.todoMarkers()
+		if list := request.GetString("markers", ""); list != "" {
+			markers = strings.Split(list, ",")
+		}
+		maxResults := request.GetInt("maxResults", 200)
+
+		coreLogger.Debug("Executing find_todos for path: %s markers: %v", path, markers)
+		text, err := tools.FindTodos(ctx, s.lspClient, s.config.workspaceDir, path, markers, maxResults)
+		if err != nil {
+			coreLogger.Error("Failed to find todos: %v", err)
+			return toolError("failed to find todos", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	codeMetricsTool := mcp.NewTool("code_metrics",
+		mcp.WithDescription("Measure functions and methods: lines of code, deepest block nesting and an estimate of cyclomatic complexity, from their source. Give a path to rank the functions of a file or directory, most complex first, or a symbolName to measure one function. Use it to pick what to refactor."),
+		mcp.WithString("path",
+			mcp.Description("Directory or file to measure, absolute or relative to the workspace"),
+		),
+		mcp.WithString("symbolName",
+			mcp.Description("The name of a function or method to measure instead (e.g. 'mypackage.MyFunction', 'MyType.MyMethod')"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("How many functions to list"),
+			mcp.DefaultNumber(20),
+			mcp.Min(1),
+		),
+	)
+	s.mcpServer.AddTool(codeMetricsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		path := request.GetString("path", "")
+		symbolName := request.GetString("symbolName", "")
+		limit := request.GetInt("limit", 20)
+
+		coreLogger.Debug("Executing code_metrics for path: %s symbol: %s", path, symbolName)
+		text, err := tools.CodeMetrics(ctx, s.lspClient, s.config.workspaceDir, path, symbolName, limit)
+		if err != nil {
+			coreLogger.Error("Failed to compute code metrics: %v", err)
+			return toolError("failed to compute code metrics", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	findDuplicatesTool := mcp.NewTool("find_duplicates",
+		mcp.WithDescription("Find duplicated code: blocks of at least minTokens tokens that occur more than once, even with renamed names or different formatting, with the locations of each copy, largest first. Use it to find code to consolidate when refactoring."),
+		mcp.WithNumber("minTokens",
+			mcp.Description("The fewest tokens a duplicated block has"),
+			mcp.DefaultNumber(50),
+			mcp.Min(10),
+		),
+		mcp.WithString("path",
+			mcp.Description("Directory or file to search, absolute or relative to the workspace. Defaults to the workspace root."),
+		),
+		mcp.WithNumber("maxResults",
+			mcp.Description("The most duplicated blocks to list"),
+			mcp.DefaultNumber(20),
+			mcp.Min(1),
+		),
+	)
+	s.mcpServer.AddTool(findDuplicatesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		minTokens := request.GetInt("minTokens", 50)
+		path := request.GetString("path", "")
+		maxResults := request.GetInt("maxResults", 20)
+
+		coreLogger.Debug("Executing find_duplicates for path: %s minTokens: %d", path, minTokens)
+		text, err := tools.FindDuplicates(ctx, s.config.workspaceDir, path, minTokens, maxResults)
+		if err != nil {
+			coreLogger.Error("Failed to find duplicates: %v", err)
+			return toolError("failed to find duplicates", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	findUnusedSymbolsTool := mcp.NewTool("find_unused_symbols",
+		mcp.WithDescription("Find functions, methods, types, constants and variables that have no references outside their own definition. Useful for dead code cleanup. Results are cached until files in the workspace change, so repeated scans are fast."),
+		mcp.WithString("scope",
+			mcp.Description("File or directory to check. Defaults to the whole workspace."),
+		),
+	)
+	s.mcpServer.AddTool(findUnusedSymbolsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		scope := request.GetString("scope", "")
+
+		coreLogger.Debug("Executing find_unused_symbols for scope: %s", scope)
+		text, err := tools.FindUnusedSymbols(ctx, s.lspClient, s.config.workspaceDir, scope)
+		if err != nil {
+			coreLogger.Error("Failed to find unused symbols: %v", err)
+			return toolError("failed to find unused symbols", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	impactTool := mcp.NewTool("impact_of",
+		mcp.WithDescription("Estimate the impact of changing a range of lines. Finds the symbols declared in the range, then ranks the functions and files that reference or directly call them. Use this before a risky edit."),
 		mcp.WithString("filePath",
 			mcp.Required(),
 			mcp.Description("The path to the file"),
 		),
-		mcp.WithNumber("line",
+		mcp.WithNumber("startLine",
 			mcp.Required(),
-			mcp.Description("The line number where the content is requested (1-indexed)"),
+			mcp.Description("First line of the range (1-indexed)"),
 		),
-		mcp.WithNumber("column",
+		mcp.WithNumber("endLine",
 			mcp.Required(),
-			mcp.Description("The column number where the content is requested (1-indexed)"),
+			mcp.Description("Last line of the range, inclusive (1-indexed)"),
 		),
 	)
-
-	s.mcpServer.AddTool(contentTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.mcpServer.AddTool(impactTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		filePath, err := request.RequireString("filePath")
 		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+			return argumentError(err), nil
 		}
 
-		line, err := request.RequireInt("line")
+		startLine, err := request.RequireInt("startLine")
 		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+			return argumentError(err), nil
 		}
 
-		column, err := request.RequireInt("column")
+		endLine, err := request.RequireInt("endLine")
 		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+			return argumentError(err), nil
 		}
 
-		coreLogger.Debug("Executing content for file: %s line: %d column: %d", filePath, line, column)
-		text, err := tools.GetContentInfo(s.ctx, s.lspClient, filePath, line, column)
+		coreLogger.Debug("Executing impact_of for file: %s lines: %d-%d", filePath, startLine, endLine)
+		text, err := tools.GetImpact(ctx, s.lspClient, filePath, startLine, endLine)
 		if err != nil {
-			coreLogger.Error("Failed to get content information: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to get content: %v", err)), nil
+			coreLogger.Error("Failed to analyze impact: %v", err)
+			return toolError("failed to analyze impact", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	checkInterfaceTool := mcp.NewTool("check_interface",
+		mcp.WithDescription("Check whether a type satisfies an interface and list the methods it is missing, before a compile cycle finds them. For Go, methods that only the pointer type has are listed too."),
+		mcp.WithString("typeName",
+			mcp.Required(),
+			mcp.Description("The type that should implement the interface (e.g. 'MemoryStore', 'store.MemoryStore')"),
+		),
+		mcp.WithString("interfaceName",
+			mcp.Required(),
+			mcp.Description("The interface (e.g. 'Store', 'io.Reader')"),
+		),
+	)
+	s.mcpServer.AddTool(checkInterfaceTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		typeName, err := request.RequireString("typeName")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		interfaceName, err := request.RequireString("interfaceName")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing check_interface for type: %s interface: %s", typeName, interfaceName)
+		text, err := tools.CheckInterface(ctx, s.lspClient, typeName, interfaceName)
+		if err != nil {
+			coreLogger.Error("Failed to check interface: %v", err)
+			return toolError("failed to check interface", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	findTestsTool := mcp.NewTool("find_tests",
+		mcp.WithDescription("Find the tests related to a symbol or file, so you know which tests to run or update after changing it. Tests are found through references from test files, test names that mention the symbol, and test file naming conventions. Code lenses such as 'run test' are listed with each test."),
+		mcp.WithString("symbolName",
+			mcp.Description("The symbol to find tests for (e.g. 'ParseConfig', 'Server.Start')"),
+		),
+		mcp.WithString("filePath",
+			mcp.Description("The source file to find tests for"),
+		),
+	)
+	s.mcpServer.AddTool(findTestsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		symbolName := request.GetString("symbolName", "")
+		filePath := request.GetString("filePath", "")
+		if symbolName == "" && filePath == "" {
+			return argumentError(fmt.Errorf("either symbolName or filePath is required")), nil
+		}
+
+		coreLogger.Debug("Executing find_tests for symbol: %s file: %s", symbolName, filePath)
+		text, err := tools.FindTests(ctx, s.lspClient, s.config.workspaceDir, symbolName, filePath)
+		if err != nil {
+			coreLogger.Error("Failed to find tests: %v", err)
+			return toolError("failed to find tests", err), nil
 		}
 		return mcp.NewToolResultText(text), nil
 	})
 
+	recentChangesTool := mcp.NewTool("recent_changes",
+		mcp.WithDescription("List the symbols that changed recently according to git, including uncommitted edits, along with the commits that touched each file. Useful to see what is being worked on before making changes."),
+		mcp.WithString("path",
+			mcp.Description("File or directory to look at. Defaults to the whole workspace."),
+		),
+		mcp.WithString("since",
+			mcp.Description("How far back to look, in any form git accepts (e.g. '3 days ago', '2024-01-31')"),
+			mcp.DefaultString("1 week ago"),
+		),
+	)
+
+	s.mcpServer.AddTool(recentChangesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		path := request.GetString("path", "")
+		since := request.GetString("since", "1 week ago")
+
+		coreLogger.Debug("Executing recent_changes for path: %s since %s", path, since)
+		text, err := tools.GetRecentChanges(ctx, s.lspClient, s.config.workspaceDir, path, since)
+		if err != nil {
+			coreLogger.Error("Failed to get recent changes: %v", err)
+			return toolError("failed to get recent changes", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	documentationTool := mcp.NewTool("documentation",
+		mcp.WithDescription("Get the documentation of a symbol, given either its name or a position in a file. Combines hover, completion and signature help information into its signature, doc comment and parameter descriptions, with markdown links and other noise removed."),
+		mcp.WithString("symbolName",
+			mcp.Description("The name of the symbol to document (e.g. 'mypackage.MyFunction', 'MyType.MyMethod'). Use this or filePath, line and column."),
+		),
+		mcp.WithString("filePath",
+			mcp.Description("The path to a file containing the symbol"),
+		),
+		mcp.WithNumber("line",
+			mcp.Description("The line number of the symbol in filePath (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Description("The column number of the symbol in filePath (1-indexed)"),
+		),
+	)
+
+	s.mcpServer.AddTool(documentationTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		symbolName := request.GetString("symbolName", "")
+		filePath := request.GetString("filePath", "")
+		line := request.GetInt("line", 0)
+		column := request.GetInt("column", 0)
+
+		coreLogger.Debug("Executing documentation for symbol: %s file: %s line: %d column: %d", symbolName, filePath, line, column)
+		text, err := tools.GetDocumentation(ctx, s.lspClient, symbolName, filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to get documentation: %v", err)
+			return toolError("failed to get documentation", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	contextForTool := mcp.NewTool("context_for",
+		mcp.WithDescription("Assemble the code most relevant to understanding a symbol within a token budget: its definition and documentation, then the types it uses, the code that calls or uses it and the functions it calls, ranked by how close they are and how often they are used. Parts that don't fit are listed by location. Use this to load context before working on a symbol instead of reading whole files."),
+		mcp.WithString("symbolName",
+			mcp.Description("The name of the symbol (e.g. 'mypackage.MyFunction', 'MyType.MyMethod'). Use this or filePath and line."),
+		),
+		mcp.WithString("filePath",
+			mcp.Description("The path to a file; context is gathered for the declaration enclosing line"),
+		),
+		mcp.WithNumber("line",
+			mcp.Description("The line number in filePath (1-indexed)"),
+		),
+		mcp.WithNumber("budgetTokens",
+			mcp.Description("Approximate number of tokens the result may use"),
+			mcp.DefaultNumber(tools.DefaultContextBudget),
+		),
+	)
+
+	s.mcpServer.AddTool(contextForTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		symbolName := request.GetString("symbolName", "")
+		filePath := request.GetString("filePath", "")
+		line := request.GetInt("line", 0)
+		budgetTokens := request.GetInt("budgetTokens", tools.DefaultContextBudget)
+
+		coreLogger.Debug("Executing context_for for symbol: %s file: %s line: %d budget: %d", symbolName, filePath, line, budgetTokens)
+		text, err := tools.GetContextFor(ctx, s.lspClient, symbolName, filePath, line, budgetTokens)
+		if err != nil {
+			coreLogger.Error("Failed to gather context: %v", err)
+			return toolError("failed to gather context", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	renamePackageTool := mcp.NewTool("rename_package",
+		mcp.WithDescription("Rename or move a package or module directory, such as a Go package or a TypeScript module folder. Moves the files, updates imports and the package name through the language server, and reports any errors in the affected files afterwards."),
+		mcp.WithString("oldPath",
+			mcp.Required(),
+			mcp.Description("The directory to move, absolute or relative to the workspace"),
+		),
+		mcp.WithString("newPath",
+			mcp.Required(),
+			mcp.Description("The new directory, absolute or relative to the workspace. It must not exist yet."),
+		),
+	)
+
+	s.mcpServer.AddTool(renamePackageTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		oldPath, err := request.RequireString("oldPath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		newPath, err := request.RequireString("newPath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing rename_package from: %s to: %s", oldPath, newPath)
+		text, err := tools.RenamePackage(ctx, s.lspClient, s.config.workspaceDir, oldPath, newPath)
+		if err != nil {
+			coreLogger.Error("Failed to rename package: %v", err)
+			return toolError("failed to rename package", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	extractFunctionTool := mcp.NewTool("extract_function",
+		mcp.WithDescription("Move the selected statements into a new function using the language server's extract refactoring, name it, and return the diff."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the code to extract"),
+		),
+		mcp.WithNumber("startLine",
+			mcp.Required(),
+			mcp.Description("The line where the selection starts (1-indexed)"),
+		),
+		mcp.WithNumber("startColumn",
+			mcp.Required(),
+			mcp.Description("The column where the selection starts (1-indexed)"),
+		),
+		mcp.WithNumber("endLine",
+			mcp.Required(),
+			mcp.Description("The line where the selection ends (1-indexed, inclusive)"),
+		),
+		mcp.WithNumber("endColumn",
+			mcp.Required(),
+			mcp.Description("The column of the last selected character (1-indexed, inclusive)"),
+		),
+		mcp.WithString("newName",
+			mcp.Required(),
+			mcp.Description("The name of the new function"),
+		),
+	)
+
+	s.mcpServer.AddTool(extractFunctionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		startLine, err := request.RequireInt("startLine")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		startColumn, err := request.RequireInt("startColumn")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		endLine, err := request.RequireInt("endLine")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		endColumn, err := request.RequireInt("endColumn")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		newName, err := request.RequireString("newName")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing extract_function for file: %s L%d:C%d - L%d:C%d", filePath, startLine, startColumn, endLine, endColumn)
+		text, err := tools.ExtractFunction(ctx, s.lspClient, filePath, startLine, startColumn, endLine, endColumn, newName)
+		if err != nil {
+			coreLogger.Error("Failed to extract function: %v", err)
+			return toolError("failed to extract function", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	extractVariableTool := mcp.NewTool("extract_variable",
+		mcp.WithDescription("Move the selected expression into a new variable using the language server's extract refactoring, name it, and return the diff."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the code to extract"),
+		),
+		mcp.WithNumber("startLine",
+			mcp.Required(),
+			mcp.Description("The line where the selection starts (1-indexed)"),
+		),
+		mcp.WithNumber("startColumn",
+			mcp.Required(),
+			mcp.Description("The column where the selection starts (1-indexed)"),
+		),
+		mcp.WithNumber("endLine",
+			mcp.Required(),
+			mcp.Description("The line where the selection ends (1-indexed, inclusive)"),
+		),
+		mcp.WithNumber("endColumn",
+			mcp.Required(),
+			mcp.Description("The column of the last selected character (1-indexed, inclusive)"),
+		),
+		mcp.WithString("newName",
+			mcp.Required(),
+			mcp.Description("The name of the new variable"),
+		),
+	)
+
+	s.mcpServer.AddTool(extractVariableTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		startLine, err := request.RequireInt("startLine")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		startColumn, err := request.RequireInt("startColumn")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		endLine, err := request.RequireInt("endLine")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		endColumn, err := request.RequireInt("endColumn")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		newName, err := request.RequireString("newName")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing extract_variable for file: %s L%d:C%d - L%d:C%d", filePath, startLine, startColumn, endLine, endColumn)
+		text, err := tools.ExtractVariable(ctx, s.lspClient, filePath, startLine, startColumn, endLine, endColumn, newName)
+		if err != nil {
+			coreLogger.Error("Failed to extract variable: %v", err)
+			return toolError("failed to extract variable", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	generateCodeTool := mcp.NewTool("generate_code",
+		mcp.WithDescription("List the code the language server can generate at a position, such as interface implementations, missing struct fields, constructors, getters and setters. Pass kind to apply one and get the diff."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to generate code in"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number of the type, field or identifier to generate code for (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number of the type, field or identifier to generate code for (1-indexed)"),
+		),
+		mcp.WithString("kind",
+			mcp.Description("The code action kind (e.g. 'source.generate.constructor') or words from the title of a listed action to apply. Leave empty to list what can be generated."),
+		),
+	)
+
+	s.mcpServer.AddTool(generateCodeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		column, err := request.RequireInt("column")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		kind := request.GetString("kind", "")
+
+		coreLogger.Debug("Executing generate_code for file: %s line: %d column: %d kind: %s", filePath, line, column, kind)
+		text, err := tools.GenerateCode(ctx, s.lspClient, filePath, line, column, kind)
+		if err != nil {
+			coreLogger.Error("Failed to generate code: %v", err)
+			return toolError("failed to generate code", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	inlineSymbolTool := mcp.NewTool("inline_symbol",
+		mcp.WithDescription("Inline the symbol at a position using the language server's inline refactoring, e.g. replace a call to a trivial wrapper with its body or a variable with its value, and return the diff."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the symbol"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number of the call or symbol to inline (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number of the call or symbol to inline (1-indexed)"),
+		),
+	)
+
+	s.mcpServer.AddTool(inlineSymbolTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		column, err := request.RequireInt("column")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing inline_symbol for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.InlineSymbol(ctx, s.lspClient, filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to inline symbol: %v", err)
+			return toolError("failed to inline symbol", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	moveSymbolTool := mcp.NewTool("move_symbol",
+		mcp.WithDescription("Move the declaration at a position to a new file using the language server's move refactoring, fixing imports in the files that use it. Returns the diff and any errors in the affected files."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the declaration"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number of the declaration (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number of the declaration (1-indexed)"),
+		),
+		mcp.WithString("destination",
+			mcp.Required(),
+			mcp.Description("The path of the new file, absolute or relative to the file's directory. It must not exist yet."),
+		),
+	)
+
+	s.mcpServer.AddTool(moveSymbolTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		column, err := request.RequireInt("column")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		destination, err := request.RequireString("destination")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing move_symbol for file: %s line: %d column: %d to: %s", filePath, line, column, destination)
+		text, err := tools.MoveSymbol(ctx, s.lspClient, filePath, line, column, destination)
+		if err != nil {
+			coreLogger.Error("Failed to move symbol: %v", err)
+			return toolError("failed to move symbol", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	addCallArgumentTool := mcp.NewTool("add_call_argument",
+		mcp.WithDescription("Insert an argument into every call of a function, e.g. after adding a parameter to it, and return the diff. The function's declaration isn't changed, and references that aren't calls are listed for updating by hand."),
+		mcp.WithString("symbolName",
+			mcp.Required(),
+			mcp.Description("The name of the function or method (e.g. 'mypackage.MyFunction', 'MyType.MyMethod')"),
+		),
+		mcp.WithString("argument",
+			mcp.Required(),
+			mcp.Description("The argument expression to insert, e.g. 'ctx' or 'nil'"),
+		),
+		mcp.WithNumber("position",
+			mcp.Description("The position of the new argument (1-indexed). 0 or omitted adds it after the last argument, as do positions past the end of a call's arguments"),
+			mcp.DefaultNumber(0),
+			mcp.Min(0),
+		),
+	)
+
+	s.mcpServer.AddTool(addCallArgumentTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		symbolName, err := request.RequireString("symbolName")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		argument, err := request.RequireString("argument")
+		if err != nil {
+			return argumentError(err), nil
+		}
+		position := request.GetInt("position", 0)
+
+		coreLogger.Debug("Executing add_call_argument for symbol: %s argument: %s position: %d", symbolName, argument, position)
+		text, err := tools.AddCallArgument(ctx, s.lspClient, symbolName, argument, position)
+		if err != nil {
+			coreLogger.Error("Failed to add call argument: %v", err)
+			return toolError("failed to add call argument", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	contentTool := mcp.NewTool("content",
+		mcp.WithDescription("Read the source code definition of a symbol (function, type, constant, etc.) at the specified location."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number where the content is requested (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number where the content is requested (1-indexed)"),
+		),
+	)
+
+	s.mcpServer.AddTool(contentTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		column, err := request.RequireInt("column")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing content for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.GetContentInfo(ctx, s.lspClient, filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to get content information: %v", err)
+			return toolError("failed to get content", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	outlineTool := mcp.NewTool("outline",
+		mcp.WithDescription("List the symbols (functions, types, classes, sections, keys, etc.) in a file. Uses the language server where possible and text heuristics for files it doesn't handle, such as YAML, Dockerfiles, protobuf, Makefiles, shell scripts, Markdown and TOML."),
+		mcp.WithString("filePath",
+			mcp.Description("The path to the file"),
+		),
+		mcp.WithString("content",
+			mcp.Description("Analyze this text instead of the file on disk, e.g. code that hasn't been saved yet. Without filePath it is opened as an untitled document in the workspace root"),
+		),
+		mcp.WithString("languageId",
+			mcp.Description("LSP language ID of the document (e.g. 'go', 'python', 'typescriptreact'). Required for content without filePath, otherwise overrides the language detected from the extension"),
+		),
+	)
+
+	s.mcpServer.AddTool(outlineTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath := request.GetString("filePath", "")
+		content := request.GetString("content", "")
+		languageID := request.GetString("languageId", "")
+
+		coreLogger.Debug("Executing outline for file: %s", filePath)
+		text, err := tools.WithDocument(ctx, s.lspClient, s.config.workspaceDir, filePath, content, languageID, func(filePath string) (string, error) {
+			return tools.GetDocumentOutline(ctx, s.lspClient, filePath)
+		})
+		if err != nil {
+			coreLogger.Error("Failed to get outline: %v", err)
+			return toolError("failed to get outline", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	if s.semanticSearcher != nil {
+		semanticSearchTool := mcp.NewTool("semantic_search",
+			mcp.WithDescription("Find code by what it does rather than by name, e.g. 'where are failed HTTP requests retried', using the embeddings index the server is configured with. Results are ranked by similarity and merged with the workspace symbols named like words of the query. Use this for conceptual questions that a symbol or text search misses."),
+			mcp.WithString("query",
+				mcp.Required(),
+				mcp.Description("A description of the code to find"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of results from the index"),
+				mcp.DefaultNumber(tools.DefaultSemanticResults),
+			),
+		)
+
+		s.mcpServer.AddTool(semanticSearchTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Extract arguments
+			query, err := request.RequireString("query")
+			if err != nil {
+				return argumentError(err), nil
+			}
+			limit := request.GetInt("limit", tools.DefaultSemanticResults)
+
+			coreLogger.Debug("Executing semantic_search for query: %s", query)
+			text, err := tools.SemanticSearch(ctx, s.lspClient, s.semanticSearcher, query, limit)
+			if err != nil {
+				coreLogger.Error("Failed to search semantically: %v", err)
+				return toolError("failed to search semantically", err), nil
+			}
+			return mcp.NewToolResultText(text), nil
+		})
+	}
+
+	if s.config.lspRequests {
+		lspRequestTool := mcp.NewTool("lsp_request",
+			mcp.WithDescription("Send a raw request to the language server and return its JSON result. For server specific extensions that have no dedicated tool, such as rust-analyzer/expandMacro or gopls commands. Positions are 0-indexed and documents are identified by file:// URIs, as in the LSP specification."),
+			mcp.WithString("method",
+				mcp.Required(),
+				mcp.Description("The LSP method, e.g. 'rust-analyzer/expandMacro' or 'workspace/executeCommand'"),
+			),
+			mcp.WithString("params",
+				mcp.Description("The request parameters as a JSON object or array"),
+			),
+			mcp.WithBoolean("notification",
+				mcp.Description("If true, sends a notification and doesn't wait for a result"),
+				mcp.DefaultBool(false),
+			),
+		)
+
+		s.mcpServer.AddTool(lspRequestTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Extract arguments
+			method, err := request.RequireString("method")
+			if err != nil {
+				return argumentError(err), nil
+			}
+			params := request.GetString("params", "")
+			notification := request.GetBool("notification", false)
+
+			coreLogger.Debug("Executing lsp_request for method: %s", method)
+			text, err := tools.SendLSPRequest(ctx, s.lspClient, method, params, notification)
+			if err != nil {
+				coreLogger.Error("Failed to send LSP request: %v", err)
+				return toolError("failed to send LSP request", err), nil
+			}
+			return mcp.NewToolResultText(text), nil
+		})
+	}
+
+	if s.config.lspSettings {
+		updateSettingsTool := mcp.NewTool("update_lsp_settings",
+			mcp.WithDescription("Change the language server's settings for the rest of the session, e.g. {\"gopls\": {\"staticcheck\": true}}. The settings are merged into the current ones and sent with workspace/didChangeConfiguration. Returns the settings now in effect."),
+			mcp.WithString("settings",
+				mcp.Required(),
+				mcp.Description("JSON object of settings keyed by section, as the language server expects them in workspace/configuration"),
+			),
+		)
+
+		s.mcpServer.AddTool(updateSettingsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Extract arguments
+			settings, err := request.RequireString("settings")
+			if err != nil {
+				return argumentError(err), nil
+			}
+
+			coreLogger.Debug("Executing update_lsp_settings")
+			text, err := tools.UpdateLSPSettings(ctx, s.lspClient, settings)
+			if err != nil {
+				coreLogger.Error("Failed to update LSP settings: %v", err)
+				return toolError("failed to update LSP settings", err), nil
+			}
+			return mcp.NewToolResultText(text), nil
+		})
+	}
+
+	s.registerUndoTools()
+	s.registerExtensionTools()
+
 	coreLogger.Info("Successfully registered all MCP tools")
 	return nil
 }
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/i18n"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/internal/trust"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// gatedTools change files or run commands, so they are only available in
+// trusted workspaces
+var gatedTools = map[string]bool{
+	"rename_symbol":       true,
+	"rename_package":      true,
+	"extract_function":    true,
+	"extract_variable":    true,
+	"generate_code":       true,
+	"inline_symbol":       true,
+	"move_symbol":         true,
+	"run_tests":           true,
+	"run_build":           true,
+	"lsp_request":         true,
+	"update_lsp_settings": true,
+	"add_import":          true,
+	"rename_file":         true,
+	"organize_imports":    true,
+	"add_call_argument":   true,
+	"undo_edits":          true,
+}
+
+// workspaceTrust gates tools on the user's decision to trust the workspace.
+// An undecided workspace is asked about, with an elicitation form the client
+// shows to the user, the first time a gated tool is called. The question
+// isn't sent as a sampling request, which a model would answer. Clients that
+// can't be asked don't see the gated tools until the workspace is trusted
+// with --trust-workspace.
+type workspaceTrust struct {
+	store     *trust.Store
+	workspace string
+	// mcpServer is notified when the gated tools are hidden
+	mcpServer *server.MCPServer
+
+	// askMu makes concurrent calls wait for a single question
+	askMu sync.Mutex
+	// mu guards the fields below
+	mu       sync.RWMutex
+	decision trust.Decision
+	// elicitor asks the user of the current session
+	elicitor *stdioElicitor
+}
+
+// newWorkspaceTrust loads the decision for a workspace, recording it as
+// trusted first if trustNow is set
+func newWorkspaceTrust(workspace string, trustNow bool) (*workspaceTrust, error) {
+	path, err := trust.DefaultPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the trust store: %v", err)
+	}
+	t := &workspaceTrust{store: trust.NewStore(path), workspace: workspace}
+	if trustNow {
+		if err := t.store.Record(workspace, true); err != nil {
+			return nil, err
+		}
+	}
+	if t.decision, err = t.store.Decision(workspace); err != nil {
+		return nil, err
+	}
+	coreLogger.Info("Workspace %s is %s", workspace, t.decision)
+	return t, nil
+}
+
+// workspaceTrusted reports whether a workspace is trusted before the server
+// starts, by trustNow or a decision recorded earlier, without asking
+func workspaceTrusted(workspace string, trustNow bool) bool {
+	if trustNow {
+		return true
+	}
+	path, err := trust.DefaultPath()
+	if err != nil {
+		return false
+	}
+	decision, err := trust.NewStore(path).Decision(workspace)
+	return err == nil && decision == trust.Trusted
+}
+
+// setElicitor sets the elicitor of a new session
+func (t *workspaceTrust) setElicitor(elicitor *stdioElicitor) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.elicitor = elicitor
+}
+
+// canAsk reports whether the client supports elicitation. Must be called
+// with mu held.
+func (t *workspaceTrust) canAsk() bool {
+	return t.elicitor != nil && t.elicitor.supported.Load()
+}
+
+// gatedVisible reports whether gated tools are listed. They are while the
+// client can still be asked to trust the workspace.
+func (t *workspaceTrust) gatedVisible() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.decision == trust.Trusted || (t.decision == trust.Undecided && t.canAsk())
+}
+
+// trusted reports whether the workspace is trusted without asking
+func (t *workspaceTrust) trusted() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.decision == trust.Trusted
+}
+
+// filterTools hides gated tools that can't be used
+func (t *workspaceTrust) filterTools(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	if t.gatedVisible() {
+		return tools
+	}
+	filtered := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if !gatedTools[tool.Name] {
+			filtered = append(filtered, tool)
+		}
+	}
+	return filtered
+}
+
+// middleware refuses gated tools until the workspace is trusted, asking the
+// user if it hasn't been decided yet
+func (t *workspaceTrust) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !gatedTools[request.Params.Name] {
+			return next(ctx, request)
+		}
+		if err := t.ensureTrusted(ctx); err != nil {
+			return codedResult(tools.ToolDisabled, i18n.Sprintf("%s is disabled: %v", request.Params.Name, err)), nil
+		}
+		return next(ctx, request)
+	}
+}
+
+// ensureTrusted returns an error explaining why gated tools can't run
+func (t *workspaceTrust) ensureTrusted(ctx context.Context) error {
+	t.askMu.Lock()
+	defer t.askMu.Unlock()
+
+	t.mu.RLock()
+	decision, elicitor, canAsk := t.decision, t.elicitor, t.canAsk()
+	t.mu.RUnlock()
+
+	switch {
+	case decision == trust.Trusted:
+		return nil
+	case decision == trust.Denied:
+		return errors.New(i18n.Sprintf("the workspace %s is not trusted. Restart the server with --trust-workspace to allow tools that change files or run commands", t.workspace))
+	case !canAsk:
+		return errors.New(i18n.Sprintf("the workspace %s has not been trusted yet and the client can't be asked. Restart the server with --trust-workspace to allow tools that change files or run commands", t.workspace))
+	}
+
+	trusted, err := t.ask(ctx, elicitor)
+	if err != nil {
+		// Not asked again this session, but not recorded either
+		coreLogger.Error("Failed to ask whether to trust the workspace: %v", err)
+		t.setDecision(trust.Denied)
+		return errors.New(i18n.Sprintf("trusting the workspace %s was not confirmed: %v", t.workspace, err))
+	}
+	if err := t.store.Record(t.workspace, trusted); err != nil {
+		coreLogger.Error("Failed to record the trust decision: %v", err)
+	}
+	if !trusted {
+		t.setDecision(trust.Denied)
+		return errors.New(i18n.Sprintf("the user did not trust the workspace %s", t.workspace))
+	}
+	t.setDecision(trust.Trusted)
+	return nil
+}
+
+// setDecision updates the decision, telling clients if gated tools are
+// hidden as a result
+func (t *workspaceTrust) setDecision(decision trust.Decision) {
+	visible := t.gatedVisible()
+	t.mu.Lock()
+	t.decision = decision
+	t.mu.Unlock()
+	coreLogger.Info("Workspace %s is %s", t.workspace, decision)
+	if visible != t.gatedVisible() && t.mcpServer != nil {
+		t.mcpServer.SendNotificationToAllClients(mcp.MethodNotificationToolsListChanged, nil)
+	}
+}
+
+// ask shows the trust question to the user in an elicitation form with a
+// trust or deny choice
+func (t *workspaceTrust) ask(ctx context.Context, elicitor *stdioElicitor) (bool, error) {
+	var names []string
+	for name := range gatedTools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	question := fmt.Sprintf("Trust the workspace %s? Trusting it allows tools that change files or run commands in it: %s. "+
+		"The decision is remembered for this workspace.",
+		t.workspace, strings.Join(names, ", "))
+	answer, err := elicitor.confirm(ctx, question, "Trust the workspace", "Don't trust it")
+	if err != nil {
+		return false, err
+	}
+	coreLogger.Info("Trust question answered: %q", answer)
+	return trust.IsApproval(answer), nil
+}
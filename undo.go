@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// registerUndoTools registers the tools that undo the edits of the editing
+// tools, set checkpoints and compare diagnostics with a checkpoint's
+func (s *mcpServer) registerUndoTools() {
+	undoTool := mcp.NewTool("undo_edits",
+		mcp.WithDescription("Undo the edits of the last editing tool call, such as rename_symbol or move_symbol, or of every call since a checkpoint, including the changes post-edit hooks made to the files. Call it again to undo earlier calls. Files changed since the edits by anything else are refused unless force is set."),
+		mcp.WithString("checkpoint",
+			mcp.Description("Name of a checkpoint taken with checkpoint. Every edit since it is undone."),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Undo even the files changed since the edits, losing those changes"),
+			mcp.DefaultBool(false),
+		),
+	)
+
+	s.mcpServer.AddTool(undoTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		checkpoint := request.GetString("checkpoint", "")
+		force := request.GetBool("force", false)
+
+		coreLogger.Debug("Executing undo_edits for checkpoint: %q", checkpoint)
+		text, err := tools.UndoEdits(ctx, s.lspClient, s.edits, checkpoint, force)
+		if err != nil {
+			coreLogger.Error("Failed to undo edits: %v", err)
+			return toolError("failed to undo edits", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	checkpointTool := mcp.NewTool("checkpoint",
+		mcp.WithDescription("Name the current state of the workspace's edits before a series of changes, so that undo_edits can return to it and diagnostics_since can list the errors introduced after it. Taking a checkpoint with a name that is already used moves it."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the checkpoint"),
+		),
+	)
+
+	s.mcpServer.AddTool(checkpointTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("name")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing checkpoint: %s", name)
+		text, err := tools.TakeCheckpoint(s.lspClient, s.edits, name)
+		if err != nil {
+			coreLogger.Error("Failed to take checkpoint: %v", err)
+			return toolError("failed to take checkpoint", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	sinceTool := mcp.NewTool("diagnostics_since",
+		mcp.WithDescription("List the errors in open files that weren't there when a checkpoint was taken, ignoring errors that only moved. Use it after a series of edits to see what they broke."),
+		mcp.WithString("checkpoint",
+			mcp.Required(),
+			mcp.Description("Name of a checkpoint taken with checkpoint"),
+		),
+	)
+
+	s.mcpServer.AddTool(sinceTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		checkpoint, err := request.RequireString("checkpoint")
+		if err != nil {
+			return argumentError(err), nil
+		}
+
+		coreLogger.Debug("Executing diagnostics_since for checkpoint: %s", checkpoint)
+		text, err := tools.DiagnosticsSinceCheckpoint(s.lspClient, s.edits, checkpoint)
+		if err != nil {
+			coreLogger.Error("Failed to compare diagnostics: %v", err)
+			return toolError("failed to compare diagnostics", err), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsptest"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUndoEdits(t *testing.T) {
+	s := newToolServer(t, renameServer())
+	format := filepath.Join(t.TempDir(), "format.sh")
+	require.NoError(t, os.WriteFile(format, []byte("#!/bin/sh\necho // formatted >> \"$1\"\n"), 0755))
+	s.liveConfig.current.PostEditHooks = []tools.PostEditHook{{Glob: "*.go", Command: format + " {files}"}}
+	file := filepath.Join(s.config.workspaceDir, "main.go")
+	require.NoError(t, os.WriteFile(file, []byte("package main\n"), 0644))
+	rename := map[string]any{"filePath": file, "line": 1, "column": 1, "newName": "renamed"}
+
+	result := callTest(t, s, "rename_symbol", rename)
+	require.False(t, result.IsError, resultText(result))
+	result = callTest(t, s, "checkpoint", map[string]any{"name": "renamed"})
+	require.False(t, result.IsError, resultText(result))
+	rename["newName"] = "again"
+	result = callTest(t, s, "rename_symbol", rename)
+	require.False(t, result.IsError, resultText(result))
+	written, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "again\n// formatted\n", string(written))
+
+	// The hook's change is undone along with the edit
+	result = callTest(t, s, "undo_edits", map[string]any{"checkpoint": "renamed"})
+	require.False(t, result.IsError, resultText(result))
+	assert.Contains(t, resultText(result)[0], "Undid 1 tool calls: rename_symbol")
+	assert.Contains(t, resultText(result)[0], "+++ "+file)
+	written, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed\n// formatted\n", string(written))
+
+	// Files changed since are only undone with force
+	require.NoError(t, os.WriteFile(file, []byte("by hand\n"), 0644))
+	result = callTest(t, s, "undo_edits", nil)
+	assert.True(t, result.IsError)
+	assert.Equal(t, "EDIT_CONFLICT", result.Meta[errorCodeKey])
+	result = callTest(t, s, "undo_edits", map[string]any{"force": true})
+	require.False(t, result.IsError, resultText(result))
+	written, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(written))
+
+	result = callTest(t, s, "undo_edits", nil)
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(result)[0], "there are no edits to undo")
+}
+
+func TestDiagnosticsSince(t *testing.T) {
+	lspServer := lsptest.NewServer(protocol.ServerCapabilities{})
+	s := newToolServer(t, lspServer)
+	file := filepath.Join(s.config.workspaceDir, "main.go")
+	require.NoError(t, os.WriteFile(file, []byte("package main\n"), 0644))
+	require.NoError(t, s.lspClient.OpenFile(context.Background(), file))
+	uri := protocol.URIFromPath(file)
+	publish := func(messages ...string) {
+		var diagnostics []protocol.Diagnostic
+		for i, message := range messages {
+			diagnostics = append(diagnostics, protocol.Diagnostic{
+				Range:    protocol.Range{Start: protocol.Position{Line: uint32(i)}},
+				Severity: protocol.SeverityError,
+				Message:  message,
+			})
+		}
+		version := s.lspClient.DiagnosticsVersion(uri)
+		require.NoError(t, lspServer.PublishDiagnostics(uri, diagnostics))
+		require.Eventually(t, func() bool { return s.lspClient.DiagnosticsVersion(uri) > version }, 5*time.Second, 10*time.Millisecond)
+	}
+
+	publish("undefined: x")
+	result := callTest(t, s, "checkpoint", map[string]any{"name": "before"})
+	require.False(t, result.IsError, resultText(result))
+	assert.Contains(t, resultText(result)[0], "have 1 errors")
+
+	// The old error only moved
+	publish("missing return", "undefined: x")
+	result = callTest(t, s, "diagnostics_since", map[string]any{"checkpoint": "before"})
+	require.False(t, result.IsError, resultText(result))
+	text := resultText(result)[0]
+	assert.Contains(t, text, `1 errors were introduced since checkpoint "before"`)
+	assert.Contains(t, text, "missing return")
+	assert.NotContains(t, text, "undefined: x")
+
+	result = callTest(t, s, "diagnostics_since", map[string]any{"checkpoint": "after"})
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(result)[0], `no checkpoint named "after"; the checkpoints are before`)
+}
+
+func TestUndoResumedSession(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	s := newToolServer(t, renameServer(), "--session-id", "undo")
+	require.NoError(t, s.loadSession())
+	file := filepath.Join(s.config.workspaceDir, "main.go")
+	require.NoError(t, os.WriteFile(file, []byte("package main\n"), 0644))
+	result := callTest(t, s, "rename_symbol", map[string]any{"filePath": file, "line": 1, "column": 1, "newName": "renamed"})
+	require.False(t, result.IsError, resultText(result))
+	s.saveSession()
+
+	resumed, err := newServer(&s.config)
+	require.NoError(t, err)
+	require.NoError(t, resumed.loadSession())
+	require.NotNil(t, resumed.resumed)
+	_, err = tools.UndoEdits(context.Background(), s.lspClient, resumed.edits, "", false)
+	require.NoError(t, err)
+	written, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(written))
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/usage"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// usageStatsTool isn't counted in the statistics it reports
+const usageStatsTool = "usage_stats"
+
+// usageMiddleware records every tool call in the session's statistics. It
+// wraps the other middleware, so calls they refuse are counted as failures
+// with their codes.
+func (s *mcpServer) usageMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Calls passed on by aliases are counted under the alias
+		if request.Params.Name == usageStatsTool || isAliasedCall(ctx) {
+			return next(ctx, request)
+		}
+		start := time.Now()
+		result, err := next(ctx, request)
+		call := usage.Call{Tool: request.Params.Name, Latency: time.Since(start), Failed: err != nil}
+		if result != nil {
+			call.Bytes = resultSize(result)
+			if result.IsError {
+				call.Failed = true
+				call.ErrorCode, _ = result.Meta[errorCodeKey].(string)
+			}
+		}
+		s.usage.Record(call)
+		return result, err
+	}
+}
+
+// resultSize returns the size of a result's content: the length of its
+// text, and of the JSON of content that isn't text
+func resultSize(result *mcp.CallToolResult) int {
+	size := 0
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			size += len(text.Text)
+			continue
+		}
+		data, _ := json.Marshal(content)
+		size += len(data)
+	}
+	return size
+}
+
+// usageReport returns the statistics of the session so far, or of the
+// session that ended when reset is set
+func (s *mcpServer) usageReport(reset bool) usage.Report {
+	var report usage.Report
+	if reset {
+		report = s.usage.Reset()
+	} else {
+		report = s.usage.Report()
+	}
+	report.SessionID = s.config.sessionID
+	report.Workspace = s.config.workspaceDir
+	return report
+}
+
+// dumpUsageStats appends the statistics of the session that ended to the
+// --usage-stats file, one JSON object per line. Sessions without calls
+// aren't written.
+func (s *mcpServer) dumpUsageStats() {
+	if s.usage == nil {
+		return
+	}
+	report := s.usageReport(true)
+	if report.Calls == 0 {
+		return
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		coreLogger.Error("Failed to encode usage statistics: %v", err)
+		return
+	}
+	file, err := os.OpenFile(s.config.usageStats, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		coreLogger.Error("Failed to write usage statistics: %v", err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		coreLogger.Error("Failed to write usage statistics: %v", err)
+		return
+	}
+	coreLogger.Info("Wrote usage statistics of %d tool calls, %d failed, to %s", report.Calls, report.Failures, s.config.usageStats)
+}
+
+func (s *mcpServer) registerUsageStatsTool() {
+	usageStatsTool := mcp.NewTool(usageStatsTool,
+		mcp.WithDescription("Report the tool calls of this session as JSON: for each tool, how often it was called and failed, with which error codes, its p50, p95, maximum and mean latency in milliseconds, and the bytes its results returned. Tools are ordered by how often they were called."),
+	)
+
+	s.mcpServer.AddTool(usageStatsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		coreLogger.Debug("Executing usage_stats")
+		data, err := json.MarshalIndent(s.usageReport(false), "", "  ")
+		if err != nil {
+			return toolError("failed to encode usage statistics", err), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+)
+
+// variablePattern matches the variables substituted in flags, profiles and
+// the config file, named as in VS Code. Other ${...} text is left alone,
+// since shell commands such as --test-command may use it.
+var variablePattern = regexp.MustCompile(`\$\{(workspaceFolder|userHome|serverCache|env:[A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// variables holds what the variables stand for
+type variables struct {
+	workspaceDir string
+	// server is the language server command, whose name ${serverCache} is
+	// named after
+	server string
+}
+
+// variables returns the values of the variables for this configuration
+func (cfg *config) variables() variables {
+	return variables{workspaceDir: cfg.workspaceDir, server: cfg.lspCommand}
+}
+
+// expand substitutes the variables in s. ${env:NAME} is empty for unset
+// variables, as in VS Code, and ${workspaceFolder} is left alone while the
+// workspace isn't known.
+func (v variables) expand(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return variablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := match[2 : len(match)-1]
+		switch {
+		case name == "workspaceFolder":
+			if v.workspaceDir == "" {
+				return match
+			}
+			return v.workspaceDir
+		case name == "userHome":
+			if home, err := os.UserHomeDir(); err == nil {
+				return home
+			}
+			return match
+		case name == "serverCache":
+			if dir, err := v.serverCache(); err == nil {
+				return dir
+			}
+			return match
+		default:
+			return os.Getenv(strings.TrimPrefix(name, "env:"))
+		}
+	})
+}
+
+// expandAll substitutes the variables in each string
+func (v variables) expandAll(values []string) {
+	for i, value := range values {
+		values[i] = v.expand(value)
+	}
+}
+
+// expandJSON substitutes the variables in the strings of a JSON document,
+// escaping what they stand for
+func (v variables) expandJSON(data []byte) []byte {
+	return variablePattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		value, _ := json.Marshal(v.expand(string(match)))
+		return value[1 : len(value)-1]
+	})
+}
+
+// serverCache returns, creating it, a cache directory for the language
+// server, in the user cache directory and named after the server
+func (v variables) serverCache() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	name := "default"
+	if v.server != "" {
+		name = lsp.ServerName(v.server)
+	}
+	dir = filepath.Join(dir, "mcp-language-server", "servers", name)
+	return dir, os.MkdirAll(dir, 0o755)
+}
+
+// expandVariables substitutes the variables in the flags. The arguments of
+// the language server, including those of --lsp-fallback, are given
+// ${workspaceFolder} by expandLSPOptions, as the server sees it.
+func (cfg *config) expandVariables() {
+	// ${serverCache} is named after the command
+	cfg.lspCommand = cfg.variables().expand(cfg.lspCommand)
+	vars := cfg.variables()
+	for _, field := range []*string{
+		&cfg.lspDir, &cfg.lspRunner, &cfg.runnerWorkspace, &cfg.lspConnect,
+		&cfg.configFile, &cfg.record, &cfg.usageStats, &cfg.testCommand, &cfg.buildCommand, &cfg.semanticSearchURL,
+		&cfg.daemonSocket, &cfg.listen, &cfg.sessionID,
+	} {
+		*field = vars.expand(*field)
+	}
+	for _, values := range []StringArrayFlag{
+		cfg.openGlobs, cfg.generatedGlobs, cfg.lspEnv, cfg.lspPath, cfg.pathMaps, cfg.sandboxRead, cfg.sandboxWrite,
+	} {
+		vars.expandAll(values)
+	}
+
+	// Everything but the workspace, which the server may see elsewhere
+	vars.workspaceDir = ""
+	vars.expandAll(cfg.lspArgs)
+	vars.expandAll(cfg.lspFallbacks)
+	vars.expandAll(cfg.lspDelegates)
+}
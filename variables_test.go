@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariablesExpand(t *testing.T) {
+	t.Setenv("HOME", "/home/dev")
+	t.Setenv("MCP_TEST_TOKEN", "secret")
+	vars := variables{workspaceDir: "/src/app"}
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"workspace", "${workspaceFolder}/build", "/src/app/build"},
+		{"home", "${userHome}/.cache", "/home/dev/.cache"},
+		{"environment variable", "token=${env:MCP_TEST_TOKEN}", "token=secret"},
+		{"unset environment variable", "[${env:MCP_TEST_UNSET}]", "[]"},
+		{"several", "${workspaceFolder}:${env:MCP_TEST_TOKEN}", "/src/app:secret"},
+		{"shell variable", "go test ${PKG:-./...} $HOME", "go test ${PKG:-./...} $HOME"},
+		{"unknown variable", "${workspaceRoot}", "${workspaceRoot}"},
+		{"invalid environment name", "${env:1X}", "${env:1X}"},
+		{"none", "plain", "plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, vars.expand(tt.in))
+		})
+	}
+
+	// The workspace is left alone while it isn't known
+	assert.Equal(t, "${workspaceFolder}/build:/home/dev", variables{}.expand("${workspaceFolder}/build:${userHome}"))
+}
+
+func TestVariablesServerCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	cache, err := os.UserCacheDir()
+	require.NoError(t, err)
+	dir := variables{server: "/usr/bin/gopls"}.expand("${serverCache}")
+	assert.Equal(t, filepath.Join(cache, "mcp-language-server", "servers", "gopls"), dir)
+	assert.DirExists(t, dir)
+}
+
+func TestVariablesExpandJSON(t *testing.T) {
+	t.Setenv("MCP_TEST_QUOTED", `say "hi" \ bye`)
+	vars := variables{workspaceDir: `C:\src\app`}
+	data := vars.expandJSON([]byte(`{"root": "${workspaceFolder}/x", "greeting": "${env:MCP_TEST_QUOTED}", "test": "echo ${PKG}"}`))
+
+	var decoded map[string]string
+	require.NoError(t, json.Unmarshal(data, &decoded), string(data))
+	assert.Equal(t, map[string]string{
+		"root":     `C:\src\app/x`,
+		"greeting": `say "hi" \ bye`,
+		"test":     "echo ${PKG}",
+	}, decoded)
+}
+
+func TestExpandVariables(t *testing.T) {
+	t.Setenv("MCP_TEST_BIN", "/opt/bin")
+	cfg := &config{
+		workspaceDir: "/src/app",
+		lspCommand:   "${env:MCP_TEST_BIN}/gopls",
+		testCommand:  "go test ${PKG} -C ${workspaceFolder}",
+		openGlobs:    StringArrayFlag{"${workspaceFolder}/**/*.go"},
+		lspArgs:      []string{"--root", "${workspaceFolder}", "--bin=${env:MCP_TEST_BIN}"},
+		lspFallbacks: StringArrayFlag{"pyright --root ${workspaceFolder}"},
+	}
+	cfg.expandVariables()
+	assert.Equal(t, "/opt/bin/gopls", cfg.lspCommand)
+	assert.Equal(t, "go test ${PKG} -C /src/app", cfg.testCommand)
+	assert.Equal(t, StringArrayFlag{"/src/app/**/*.go"}, cfg.openGlobs)
+	// The language server's arguments get the workspace as it sees it later
+	assert.Equal(t, []string{"--root", "${workspaceFolder}", "--bin=/opt/bin"}, cfg.lspArgs)
+	assert.Equal(t, StringArrayFlag{"pyright --root ${workspaceFolder}"}, cfg.lspFallbacks)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// commit and buildDate are set at build time with
+// -ldflags "-X main.commit=... -X main.buildDate=...". Builds from a git
+// checkout fill them in from the VCS information Go records instead.
+var (
+	commit    string
+	buildDate string
+)
+
+// buildInfo identifies this binary in bug reports
+type buildInfo struct {
+	version    string
+	commit     string
+	commitDate string
+	buildDate  string
+	goVersion  string
+	// modified is set when the binary was built from a dirty working tree
+	modified bool
+}
+
+func currentBuildInfo() buildInfo {
+	info := buildInfo{
+		version:   version,
+		commit:    commit,
+		buildDate: buildDate,
+		goVersion: runtime.Version(),
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.commit == "" {
+					info.commit = setting.Value
+				}
+			case "vcs.time":
+				info.commitDate = setting.Value
+			case "vcs.modified":
+				info.modified = setting.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+// shortCommit abbreviates the commit hash and marks dirty builds
+func (b buildInfo) shortCommit() string {
+	c := b.commit
+	if len(c) > 12 {
+		c = c[:12]
+	}
+	if c != "" && b.modified {
+		c += "-dirty"
+	}
+	return c
+}
+
+// String formats the build information for --version
+func (b buildInfo) String() string {
+	var result strings.Builder
+	fmt.Fprintf(&result, "mcp-language-server %s\n", b.version)
+	fmt.Fprintf(&result, "Commit: %s\n", orUnknown(b.shortCommit()))
+	if b.commitDate != "" {
+		fmt.Fprintf(&result, "Commit date: %s\n", b.commitDate)
+	}
+	fmt.Fprintf(&result, "Built: %s\n", orUnknown(b.buildDate))
+	fmt.Fprintf(&result, "Go: %s %s/%s\n", b.goVersion, runtime.GOOS, runtime.GOARCH)
+	return result.String()
+}
+
+// serverVersion is the version reported in the MCP serverInfo. It names the
+// commit and the language server behind the tools, if it identified itself.
+func (b buildInfo) serverVersion(lspInfo *protocol.ServerInfo) string {
+	var details []string
+	if c := b.shortCommit(); c != "" {
+		details = append(details, "commit "+c)
+	}
+	if lspInfo != nil && lspInfo.Name != "" {
+		details = append(details, strings.TrimSpace(lspInfo.Name+" "+lspInfo.Version))
+	}
+	if len(details) == 0 {
+		return b.version
+	}
+	return fmt.Sprintf("%s (%s)", b.version, strings.Join(details, ", "))
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}